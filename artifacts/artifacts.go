@@ -0,0 +1,210 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package artifacts stores the output files of testnets, such as genesis files, keys and benchmark
+// results, so that they can be served through the api instead of being fetched out of the nodes by hand.
+package artifacts
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/secrets"
+	"github.com/whiteblock/genesis/util"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9._\-/]+$`)
+
+// Artifact describes a stored output file of a testnet
+type Artifact struct {
+	// Name is the path of the artifact relative to the artifacts of its testnet
+	Name string `json:"name"`
+	// Size is the size of the artifact in bytes
+	Size int64 `json:"size"`
+	// Modified is when the artifact was last written
+	Modified time.Time `json:"modified"`
+}
+
+// ValidateName ensures that the given artifact name is a relative path which stays within the
+// artifacts of its testnet
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid artifact name \"%s\"", name)
+	}
+	if path.IsAbs(name) || path.Clean(name) != name || name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("artifact name \"%s\" must be a clean relative path", name)
+	}
+	return nil
+}
+
+func baseDir() string {
+	if len(conf.ArtifactsDir) > 0 {
+		return conf.ArtifactsDir
+	}
+	return filepath.Join(conf.DataDirectory, "artifacts")
+}
+
+// Dir gets the directory the artifacts of the given testnet are stored in
+func Dir(testnetID string) string {
+	return filepath.Join(baseDir(), testnetID)
+}
+
+// fileMode gets the mode artifacts are written with, which keeps them private in secrets mode
+func fileMode() os.FileMode {
+	if secrets.Enabled() {
+		return 0600
+	}
+	return 0664
+}
+
+// Create creates or truncates the artifact with the given name, returning the file to write it to
+func Create(testnetID string, name string) (*os.File, error) {
+	err := ValidateName(name)
+	if err != nil {
+		return nil, err
+	}
+	file := filepath.Join(Dir(testnetID), filepath.FromSlash(name))
+	err = os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode())
+}
+
+// Store stores data as the artifact with the given name, replacing it if it already exists
+func Store(testnetID string, name string, data []byte) error {
+	file, err := Create(testnetID, name)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return util.LogError(err)
+	}
+	return util.LogError(file.Close())
+}
+
+// Append appends data to the artifact with the given name, creating it if it does not exist
+func Append(testnetID string, name string, data []byte) error {
+	err := ValidateName(name)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(Dir(testnetID), filepath.FromSlash(name))
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return util.LogError(err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, fileMode())
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return util.LogError(err)
+	}
+	return util.LogError(file.Close())
+}
+
+// Rename moves the artifact, or directory of artifacts, with the given name to the new name
+func Rename(testnetID string, name string, newName string) error {
+	for _, n := range []string{name, newName} {
+		err := ValidateName(n)
+		if err != nil {
+			return err
+		}
+	}
+	dest := filepath.Join(Dir(testnetID), filepath.FromSlash(newName))
+	err := os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return os.Rename(filepath.Join(Dir(testnetID), filepath.FromSlash(name)), dest)
+}
+
+// Delete removes the artifact, or directory of artifacts, with the given name. It is not an error for it
+// to not exist.
+func Delete(testnetID string, name string) error {
+	err := ValidateName(name)
+	if err != nil {
+		return err
+	}
+	return util.LogError(os.RemoveAll(filepath.Join(Dir(testnetID), filepath.FromSlash(name))))
+}
+
+// Open opens the artifact with the given name for reading
+func Open(testnetID string, name string) (*os.File, error) {
+	err := ValidateName(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(Dir(testnetID), filepath.FromSlash(name)))
+}
+
+// Read reads the contents of the artifact with the given name
+func Read(testnetID string, name string) ([]byte, error) {
+	file, err := Open(testnetID, name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(file)
+}
+
+// List gets all of the artifacts of the given testnet, sorted by name
+func List(testnetID string) ([]Artifact, error) {
+	out := []Artifact{}
+	dir := Dir(testnetID)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return out, nil
+	}
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		out = append(out, Artifact{Name: filepath.ToSlash(name), Size: info.Size(), Modified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// Remove removes all of the artifacts of the given testnet
+func Remove(testnetID string) error {
+	return util.LogError(os.RemoveAll(Dir(testnetID)))
+}
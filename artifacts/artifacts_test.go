@@ -0,0 +1,214 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package artifacts
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	var test = []struct {
+		name  string
+		valid bool
+	}{
+		{name: "genesis.json", valid: true},
+		{name: "node1/keys/validator.json", valid: true},
+		{name: "capture-2.pcap", valid: true},
+		{name: "", valid: false},
+		{name: "/etc/passwd", valid: false},
+		{name: "../genesis.json", valid: false},
+		{name: "node1/../../genesis.json", valid: false},
+		{name: "node1//genesis.json", valid: false},
+		{name: "node1/", valid: false},
+		{name: "..", valid: false},
+		{name: "bench mark.csv", valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := ValidateName(tt.name)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateName(%q) returned %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	conf.ArtifactsDir = dir
+	defer func() { conf.ArtifactsDir = "" }()
+
+	err = Store("tn1", "node0/bench.csv", []byte("tps\n100\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Store("tn1", "genesis.json", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Store("tn1", "../tn2/genesis.json", []byte("{}"))
+	if err == nil {
+		t.Error("expected an error when storing outside of the testnet")
+	}
+
+	list, err := List("tn1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, artifact := range list {
+		names = append(names, artifact.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"genesis.json", "node0/bench.csv"}) {
+		t.Errorf("listed %v", names)
+	}
+	if list[1].Size != 8 {
+		t.Errorf("expected a size of 8, got %d", list[1].Size)
+	}
+
+	data, err := Read("tn1", "node0/bench.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "tps\n100\n" {
+		t.Errorf("read %q", data)
+	}
+
+	err = Remove("tn1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err = List("tn1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Errorf("expected no artifacts after removal, got %v", list)
+	}
+}
+
+func TestAppendRenameDelete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	conf.ArtifactsDir = dir
+	defer func() { conf.ArtifactsDir = "" }()
+
+	for _, line := range []string{"a\n", "b\n"} {
+		err = Append("tn1", "soak/samples.jsonl", []byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := Read("tn1", "soak/samples.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a\nb\n" {
+		t.Errorf("read %q", data)
+	}
+
+	err = Store("tn1", "captures/node0.pcap", []byte("pcap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Rename("tn1", "captures/node0.pcap", "soak/captures/1/node0.pcap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Rename("tn1", "soak/captures/1", "../tn2/captures")
+	if err == nil {
+		t.Error("expected an error when renaming outside of the testnet")
+	}
+	err = Delete("tn1", "soak/captures/2")
+	if err != nil {
+		t.Error(err)
+	}
+
+	list, err := List("tn1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, artifact := range list {
+		names = append(names, artifact.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"soak/captures/1/node0.pcap", "soak/samples.jsonl"}) {
+		t.Errorf("listed %v", names)
+	}
+
+	err = Delete("tn1", "soak/captures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err = List("tn1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Errorf("expected only the samples after the deletion, got %v", list)
+	}
+}
+
+func TestSource_GetName(t *testing.T) {
+	var test = []struct {
+		source   Source
+		expected string
+	}{
+		{source: Source{Node: 2, Path: "/geth/bench.csv"}, expected: "node2/bench.csv"},
+		{source: Source{Node: 2, Path: "/geth/bench.csv", Name: "results.csv"}, expected: "results.csv"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if tt.source.GetName() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, tt.source.GetName())
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	err := Register("tn1", Source{Node: 0, Path: "/geth/bench.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Register("tn1", Source{Node: 1, Path: "bench.csv"})
+	if err == nil {
+		t.Error("expected an error when registering a relative path")
+	}
+	pending := TakePending("tn1")
+	if !reflect.DeepEqual(pending, []Source{{Node: 0, Path: "/geth/bench.csv"}}) {
+		t.Errorf("unexpected pending sources %v", pending)
+	}
+	if len(TakePending("tn1")) != 0 {
+		t.Error("the pending sources were not removed")
+	}
+}
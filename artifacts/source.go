@@ -0,0 +1,86 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package artifacts
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"path"
+	"sync"
+)
+
+// Source is a file in a node which is collected as an artifact
+type Source struct {
+	// Node is the absolute number of the node the file is in
+	Node int `json:"node"`
+	// Path is the absolute path of the file in the node
+	Path string `json:"path"`
+	// Name is the name the file is stored as, defaults to node<node>/<base name of path>
+	Name string `json:"name,omitempty"`
+}
+
+// GetName gets the name the file is stored as
+func (source Source) GetName() string {
+	if len(source.Name) > 0 {
+		return source.Name
+	}
+	return fmt.Sprintf("node%d/%s", source.Node, path.Base(source.Path))
+}
+
+// Validate ensures that the source is an absolute path in a node, with a valid name
+func (source Source) Validate() error {
+	if source.Node < 0 {
+		return fmt.Errorf("invalid node %d", source.Node)
+	}
+	err := util.ValidateFilePath(source.Path)
+	if err != nil {
+		return fmt.Errorf("invalid artifact path: %s", err.Error())
+	}
+	if !path.IsAbs(source.Path) {
+		return fmt.Errorf("the artifact path \"%s\" must be an absolute path", source.Path)
+	}
+	return ValidateName(source.GetName())
+}
+
+var (
+	pending    = map[string][]Source{}
+	pendingMux = sync.Mutex{}
+)
+
+// Register registers a file in a node to be collected as an artifact of the given testnet once its
+// build completes. Builders use this for files which only exist once the blockchain has been started.
+func Register(testnetID string, source Source) error {
+	err := source.Validate()
+	if err != nil {
+		return err
+	}
+	pendingMux.Lock()
+	defer pendingMux.Unlock()
+	pending[testnetID] = append(pending[testnetID], source)
+	return nil
+}
+
+// TakePending removes and returns the sources registered for the given testnet
+func TakePending(testnetID string) []Source {
+	pendingMux.Lock()
+	defer pendingMux.Unlock()
+	out := pending[testnetID]
+	delete(pending, testnetID)
+	return out
+}
@@ -0,0 +1,277 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package capture orchestrates packet captures with tcpdump on the nodes of a testnet, or on the bridges
+// of their networks, collecting the resulting pcap files as artifacts of the testnet.
+package capture
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/artifacts"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+const (
+	// StartAction starts the captures
+	StartAction = "start"
+	// StopAction stops the captures early, and collects them
+	StopAction = "stop"
+
+	defaultDuration = 60
+	defaultMaxSize  = 100
+
+	// collectGrace is how long after the duration of a capture it is collected, giving tcpdump time to exit
+	collectGrace = 5 * time.Second
+)
+
+// Request is a request to start or stop packet captures
+type Request struct {
+	// Action is either start or stop
+	Action string `json:"action"`
+	// Nodes are the absolute numbers of the nodes to capture the traffic of from within their network
+	Nodes []int `json:"nodes"`
+	// Bridges are the absolute numbers of the nodes to capture the traffic of on the bridge of their network,
+	// which is after the network conditions have been applied
+	Bridges []int `json:"bridges"`
+	// Duration is the maximum number of seconds to capture for, defaults to 60
+	Duration int `json:"duration"`
+	// MaxSize is the maximum size of each capture in megabytes, after which the oldest packets are
+	// overwritten. Defaults to 100
+	MaxSize int `json:"maxSize"`
+	// Filter is a pcap filter expression, such as "tcp port 30303"
+	Filter string `json:"filter"`
+}
+
+// ValidateAndSetDefaults ensures that the request is within the limits of the configuration, filling
+// in the defaults of the missing fields
+func (req *Request) ValidateAndSetDefaults() error {
+	if req.Action != StartAction && req.Action != StopAction {
+		return fmt.Errorf("invalid action \"%s\", expected \"%s\" or \"%s\"", req.Action, StartAction, StopAction)
+	}
+	if req.Duration == 0 {
+		req.Duration = defaultDuration
+	}
+	if req.MaxSize == 0 {
+		req.MaxSize = defaultMaxSize
+	}
+	if req.Duration < 0 || req.Duration > conf.MaxCaptureDuration {
+		return fmt.Errorf("the duration must be between 1 and %d seconds", conf.MaxCaptureDuration)
+	}
+	if req.MaxSize < 0 || req.MaxSize > conf.MaxCaptureSize {
+		return fmt.Errorf("the max size must be between 1 and %d megabytes", conf.MaxCaptureSize)
+	}
+	if strings.ContainsAny(req.Filter, "\n\r") {
+		return fmt.Errorf("invalid filter \"%s\"", req.Filter)
+	}
+	if req.Action == StartAction && len(req.Nodes) == 0 && len(req.Bridges) == 0 {
+		return fmt.Errorf("no nodes or bridges were given to capture on")
+	}
+	return nil
+}
+
+// target is a single capture
+type target struct {
+	node   db.Node
+	bridge bool
+}
+
+// name gets the name of the capture, which is also the name of its container and pcap file
+func (t target) name() string {
+	if t.bridge {
+		return t.node.GetNodeName() + "-capture-bridge"
+	}
+	return t.node.GetNodeName() + "-capture"
+}
+
+// artifact gets the name of the artifact the capture is stored as
+func (t target) artifact() string {
+	if t.bridge {
+		return fmt.Sprintf("captures/bridge%d.pcap", t.node.AbsoluteNum)
+	}
+	return fmt.Sprintf("captures/node%d.pcap", t.node.AbsoluteNum)
+}
+
+// captureDir gets the directory on a server which the captures of the testnet are written to
+func captureDir(testnetID string) string {
+	return fmt.Sprintf("/tmp/%s/captures", testnetID)
+}
+
+// getTargets gets the captures for the given nodes and bridges
+func getTargets(tn *testnet.TestNet, nodes []int, bridges []int) ([]target, error) {
+	byNumber := map[int]db.Node{}
+	for _, node := range tn.Nodes {
+		byNumber[node.AbsoluteNum] = node
+	}
+	out := []target{}
+	for i, numbers := range [][]int{nodes, bridges} {
+		for _, number := range numbers {
+			node, ok := byNumber[number]
+			if !ok {
+				return nil, fmt.Errorf("node %d does not exist", number)
+			}
+			out = append(out, target{node: node, bridge: i == 1})
+		}
+	}
+	return out, nil
+}
+
+// allTargets gets every capture which could exist in the testnet
+func allTargets(tn *testnet.TestNet) []target {
+	out := []target{}
+	for _, node := range tn.Nodes {
+		out = append(out, target{node: node}, target{node: node, bridge: true})
+	}
+	return out
+}
+
+// startCommand gets the command which runs tcpdump for the given capture in its own container
+func startCommand(client ssh.Client, testnetID string, t target, req Request) string {
+	cli := client.Runtime().CLI
+	network := "container:" + t.node.GetNodeName()
+	iface := "any"
+	if t.bridge {
+		network = "host"
+		iface = fmt.Sprintf("%s%d", conf.BridgePrefix, t.node.LocalID)
+	}
+	cmd := fmt.Sprintf("%s run -d --name %s --network %s --cap-add NET_RAW --cap-add NET_ADMIN -v %s:/captures %s "+
+		"timeout -s INT %d tcpdump -i %s -Z root -U -w /captures/%s.pcap -C %d -W 1",
+		cli, t.name(), network, captureDir(testnetID), conf.CaptureImage, req.Duration, iface, t.name(), req.MaxSize)
+	if len(req.Filter) > 0 {
+		cmd += " " + util.ShellQuote(req.Filter)
+	}
+	return cmd
+}
+
+var mux = sync.Mutex{}
+
+// Start starts the captures of the request, which are collected as artifacts of the testnet once
+// their duration has elapsed, unless they are stopped earlier. Gives the names of the artifacts
+// the captures will be stored as.
+func Start(tn *testnet.TestNet, req Request) ([]string, error) {
+	cfg, err := tn.GetKubernetesConfig()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	if cfg.Enabled {
+		return nil, fmt.Errorf("packet captures are not supported on kubernetes")
+	}
+	targets, err := getTargets(tn, req.Nodes, req.Bridges)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range targets {
+		if t.bridge && tn.Clients[t.node.Server].Runtime().Rootless {
+			return nil, fmt.Errorf("the bridge of node %d cannot be captured on with a rootless runtime",
+				t.node.AbsoluteNum)
+		}
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	out := []string{}
+	for _, t := range targets {
+		client := tn.Clients[t.node.Server]
+		_, err = client.Run(fmt.Sprintf("mkdir -p %s && %s rm -f %s 2>/dev/null; %s",
+			captureDir(tn.TestNetID), client.Runtime().CLI, t.name(), startCommand(client, tn.TestNetID, t, req)))
+		if err != nil {
+			return out, util.LogError(err)
+		}
+		out = append(out, t.artifact())
+	}
+	log.WithFields(log.Fields{"testnet": tn.TestNetID, "captures": out, "duration": req.Duration}).Info(
+		"started the packet captures")
+
+	time.AfterFunc(time.Duration(req.Duration)*time.Second+collectGrace, func() {
+		_, err := collect(tn, targets)
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": tn.TestNetID, "error": err}).Error(
+				"failed to collect the packet captures")
+		}
+	})
+	return out, nil
+}
+
+// Stop stops the captures on the given nodes and bridges, or all of the captures of the testnet if
+// none are given, and collects them. Gives the names of the artifacts the captures were stored as.
+func Stop(tn *testnet.TestNet, nodes []int, bridges []int) ([]string, error) {
+	targets := allTargets(tn)
+	if len(nodes) > 0 || len(bridges) > 0 {
+		var err error
+		targets, err = getTargets(tn, nodes, bridges)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return collect(tn, targets)
+}
+
+// collect stops the given captures if they are still running, and stores their pcap files as artifacts,
+// removing them from the servers. Captures which do not exist, such as those already collected, are skipped.
+func collect(tn *testnet.TestNet, targets []target) ([]string, error) {
+	mux.Lock()
+	defer mux.Unlock()
+	out := []string{}
+	var collectErr error
+	for _, t := range targets {
+		client := tn.Clients[t.node.Server]
+		cli := client.Runtime().CLI
+		res, err := client.Run(fmt.Sprintf("%s ps -aq -f name=^%s$", cli, t.name()))
+		if err != nil || len(strings.TrimSpace(res)) == 0 {
+			continue
+		}
+		client.Run(fmt.Sprintf("%s stop -t 10 %s", cli, t.name()))
+
+		file := fmt.Sprintf("%s/%s.pcap", captureDir(tn.TestNetID), t.name())
+		err = fetch(client, tn.TestNetID, file, t.artifact())
+		if err != nil {
+			collectErr = fmt.Errorf("failed to collect the capture %s: %s", t.name(), err.Error())
+		} else {
+			out = append(out, t.artifact())
+		}
+		client.Run(fmt.Sprintf("%s rm -f %s; rm -f %s", cli, t.name(), file))
+	}
+	if len(out) > 0 {
+		log.WithFields(log.Fields{"testnet": tn.TestNetID, "captures": out}).Info("collected the packet captures")
+	}
+	return out, collectErr
+}
+
+// fetch stores the file on the server as the given artifact
+func fetch(client ssh.Client, testnetID string, file string, name string) error {
+	dest, err := artifacts.Create(testnetID, name)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = client.Fetch(file, dest)
+	dest.Close()
+	if err != nil {
+		os.Remove(dest.Name())
+		return util.LogError(err)
+	}
+	return nil
+}
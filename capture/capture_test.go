@@ -0,0 +1,134 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package capture
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh/mocks"
+	"github.com/whiteblock/genesis/util"
+)
+
+func TestRequest_ValidateAndSetDefaults(t *testing.T) {
+	conf.MaxCaptureDuration = 3600
+	conf.MaxCaptureSize = 1000
+	var test = []struct {
+		req      Request
+		expected Request
+		err      error
+	}{
+		{
+			req:      Request{Action: "start", Nodes: []int{0}},
+			expected: Request{Action: "start", Nodes: []int{0}, Duration: 60, MaxSize: 100},
+			err:      nil,
+		},
+		{
+			req:      Request{Action: "stop"},
+			expected: Request{Action: "stop", Duration: 60, MaxSize: 100},
+			err:      nil,
+		},
+		{
+			req:      Request{Action: "start", Bridges: []int{1}, Duration: 10, MaxSize: 5, Filter: "tcp port 30303"},
+			expected: Request{Action: "start", Bridges: []int{1}, Duration: 10, MaxSize: 5, Filter: "tcp port 30303"},
+			err:      nil,
+		},
+		{
+			req:      Request{Action: "pause", Nodes: []int{0}},
+			expected: Request{Action: "pause", Nodes: []int{0}},
+			err:      errors.New("invalid action \"pause\", expected \"start\" or \"stop\""),
+		},
+		{
+			req:      Request{Action: "start"},
+			expected: Request{Action: "start", Duration: 60, MaxSize: 100},
+			err:      errors.New("no nodes or bridges were given to capture on"),
+		},
+		{
+			req:      Request{Action: "start", Nodes: []int{0}, Duration: 7200},
+			expected: Request{Action: "start", Nodes: []int{0}, Duration: 7200, MaxSize: 100},
+			err:      errors.New("the duration must be between 1 and 3600 seconds"),
+		},
+		{
+			req:      Request{Action: "start", Nodes: []int{0}, MaxSize: -1},
+			expected: Request{Action: "start", Nodes: []int{0}, Duration: 60, MaxSize: -1},
+			err:      errors.New("the max size must be between 1 and 1000 megabytes"),
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.req.ValidateAndSetDefaults()
+			if !reflect.DeepEqual(err, tt.err) {
+				t.Errorf("expected error %v, got %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(tt.req, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, tt.req)
+			}
+		})
+	}
+}
+
+func TestStartCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conf.NodePrefix = "whiteblock-node"
+	conf.BridgePrefix = "wb_bridge"
+	conf.CaptureImage = "nicolaka/netshoot"
+	testnetID := "4ac9d3b2-1a2b-4c5d-9e8f-0123456789ab"
+	node := db.Node{TestNetID: testnetID, LocalID: 2, AbsoluteNum: 5}
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Runtime().Return(util.Runtime{Name: util.DockerRuntime, CLI: "docker"}).AnyTimes()
+
+	var test = []struct {
+		target   target
+		req      Request
+		expected string
+	}{
+		{
+			target: target{node: node},
+			req:    Request{Duration: 60, MaxSize: 100},
+			expected: "docker run -d --name whiteblock-node4ac9d3b2-2-capture --network container:whiteblock-node4ac9d3b2-2 " +
+				"--cap-add NET_RAW --cap-add NET_ADMIN -v /tmp/" + testnetID + "/captures:/captures nicolaka/netshoot " +
+				"timeout -s INT 60 tcpdump -i any -Z root -U -w /captures/whiteblock-node4ac9d3b2-2-capture.pcap -C 100 -W 1",
+		},
+		{
+			target: target{node: node, bridge: true},
+			req:    Request{Duration: 10, MaxSize: 5, Filter: "tcp port 30303"},
+			expected: "docker run -d --name whiteblock-node4ac9d3b2-2-capture-bridge --network host " +
+				"--cap-add NET_RAW --cap-add NET_ADMIN -v /tmp/" + testnetID + "/captures:/captures nicolaka/netshoot " +
+				"timeout -s INT 10 tcpdump -i wb_bridge2 -Z root -U -w /captures/whiteblock-node4ac9d3b2-2-capture-bridge.pcap " +
+				"-C 5 -W 1 'tcp port 30303'",
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := startCommand(client, testnetID, tt.target, tt.req)
+			if out != tt.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tt.expected, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ContainerUsage is the resource usage of a container, as measured by cAdvisor
+type ContainerUsage struct {
+	// CPU is the cpu usage of the container over the last interval, as a percentage of a single core
+	CPU float64
+	// Memory is the total memory usage of the container, in bytes
+	Memory uint64
+	// WorkingSet is the memory of the container which can't be reclaimed, in bytes
+	WorkingSet uint64
+	// Time is when the usage was measured
+	Time time.Time
+}
+
+// CAdvisor is a client for the api of a cAdvisor instance, giving the resource usage of the containers
+// on its server
+type CAdvisor struct {
+	// Timeout is how long a call may take
+	Timeout time.Duration
+
+	url    string
+	dialer Dialer
+}
+
+// NewCAdvisor creates a client for the cAdvisor api served on the given port of host, reached through
+// the given dialer
+func NewCAdvisor(dialer Dialer, host string, port int) *CAdvisor {
+	return &CAdvisor{Timeout: DefaultTimeout, dialer: dialer,
+		url: "http://" + net.JoinHostPort(host, strconv.Itoa(port))}
+}
+
+// Usage gets the latest resource usage of the docker container with the given name
+func (ca *CAdvisor) Usage(container string) (ContainerUsage, error) {
+	call := "stats/" + container
+	target := ca.url + "/api/v2.0/stats/" + url.PathEscape(container) + "?type=docker&count=2"
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return ContainerUsage{}, err
+	}
+	body, err := do(httpClient(ca.dialer, ca.Timeout), req)
+	if err != nil {
+		return ContainerUsage{}, fmt.Errorf("%s failed: %s", call, err.Error())
+	}
+	var res map[string][]struct {
+		Timestamp time.Time `json:"timestamp"`
+		HasCPU    bool      `json:"has_cpu"`
+		CPU       struct {
+			Usage struct {
+				Total uint64 `json:"total"`
+			} `json:"usage"`
+		} `json:"cpu"`
+		HasMemory bool `json:"has_memory"`
+		Memory    struct {
+			Usage      uint64 `json:"usage"`
+			WorkingSet uint64 `json:"working_set"`
+		} `json:"memory"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return ContainerUsage{}, invalidResponse(call, body)
+	}
+	if len(res) != 1 {
+		return ContainerUsage{}, fmt.Errorf("%s matched %d containers", call, len(res))
+	}
+	for _, stats := range res {
+		if len(stats) < 2 {
+			return ContainerUsage{}, fmt.Errorf("%s gave %d samples, at least 2 are needed", call, len(stats))
+		}
+		prev, last := stats[len(stats)-2], stats[len(stats)-1]
+		if !last.HasCPU || !last.HasMemory {
+			return ContainerUsage{}, fmt.Errorf("%s is missing the cpu or memory usage", call)
+		}
+		out := ContainerUsage{Memory: last.Memory.Usage, WorkingSet: last.Memory.WorkingSet, Time: last.Timestamp}
+		interval := last.Timestamp.Sub(prev.Timestamp)
+		if interval > 0 && last.CPU.Usage.Total >= prev.CPU.Usage.Total {
+			out.CPU = float64(last.CPU.Usage.Total-prev.CPU.Usage.Total) / float64(interval.Nanoseconds()) * 100
+		}
+		return out, nil
+	}
+	return ContainerUsage{}, nil
+}
@@ -0,0 +1,60 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package clients
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCAdvisorUsage(t *testing.T) {
+	responses := map[string]string{
+		"/api/v2.0/stats/whiteblock-node0": `{"/docker/abc":[
+			{"timestamp":"2020-01-01T00:00:00Z","has_cpu":true,"cpu":{"usage":{"total":1000000000}},
+				"has_memory":true,"memory":{"usage":4096,"working_set":2048}},
+			{"timestamp":"2020-01-01T00:00:02Z","has_cpu":true,"cpu":{"usage":{"total":2000000000}},
+				"has_memory":true,"memory":{"usage":8192,"working_set":4096}}]}`,
+		"/api/v2.0/stats/whiteblock-node1": `{"/docker/def":[
+			{"timestamp":"2020-01-01T00:00:00Z","has_cpu":true,"cpu":{"usage":{"total":1}},
+				"has_memory":true,"memory":{"usage":1,"working_set":1}}]}`,
+		"/api/v2.0/stats/whiteblock-node2": `{}`,
+		"/api/v2.0/stats/whiteblock-node3": `unknown container`,
+	}
+	dialer, host, port := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("type") != "docker" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, responses[r.URL.Path])
+	})
+	ca := NewCAdvisor(dialer, host, port)
+
+	usage, err := ca.Usage("whiteblock-node0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.CPU != 50 || usage.Memory != 8192 || usage.WorkingSet != 4096 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+	for _, name := range []string{"whiteblock-node1", "whiteblock-node2", "whiteblock-node3"} {
+		if _, err := ca.Usage(name); err == nil {
+			t.Errorf("expected an error for %s", name)
+		}
+	}
+}
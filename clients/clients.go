@@ -0,0 +1,86 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package clients contains thin clients for the rpc apis of the blockchains, which reach the nodes from
+// genesis through the connections to their servers, tunneled over ssh when the servers are remote.
+package clients
+
+import (
+	"context"
+	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+// DefaultTimeout is how long a call to a node may take by default
+var DefaultTimeout = 5 * time.Second
+
+// retryDelay is how long to wait between the attempts at a call
+var retryDelay = time.Second
+
+// maxResponseSize is the largest response which is read from a node
+const maxResponseSize = 32 << 20
+
+// Dialer opens connections to the nodes. ssh.Client is a Dialer which opens them from the server.
+type Dialer interface {
+	// Dial opens a connection to the given address
+	Dial(network string, address string) (net.Conn, error)
+}
+
+// httpClient creates an http client whose connections are opened by the given dialer. Connections are not
+// kept alive, as those tunneled over ssh take up one of the sessions to the server.
+func httpClient(dialer Dialer, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			DialContext: func(_ context.Context, network string, address string) (net.Conn, error) {
+				return dialer.Dial(network, address)
+			},
+		},
+	}
+}
+
+// do sends the request, giving the body of the response
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 != 2 && len(body) == 0 {
+		return nil, fmt.Errorf("%s %s: %s", req.Method, req.URL.String(), res.Status)
+	}
+	return body, nil
+}
+
+// invalidResponse gives the error for a response which could not be decoded
+func invalidResponse(call string, body []byte) error {
+	return fmt.Errorf("invalid response to %s: %s", call, strings.TrimSpace(string(body)))
+}
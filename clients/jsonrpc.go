@@ -0,0 +1,159 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// RPCError is an error returned by a node for a call
+type RPCError struct {
+	// Call is the method or path which was called
+	Call    string      `json:"-"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error gives the message of the error, along with its data if it has any
+func (re RPCError) Error() string {
+	if re.Data == nil || re.Data == "" {
+		return fmt.Sprintf("%s failed: %s", re.Call, re.Message)
+	}
+	return fmt.Sprintf("%s failed: %s: %v", re.Call, re.Message, re.Data)
+}
+
+// response is the envelope of a JSON-RPC response
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// decode decodes the result of the response to the given call into out, unless out is nil
+func (res response) decode(call string, body []byte, out interface{}) error {
+	if res.Error != nil {
+		res.Error.Call = call
+		return *res.Error
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(res.Result, out); err != nil {
+		return invalidResponse(call, body)
+	}
+	return nil
+}
+
+// JSONRPC is a client for the JSON-RPC api of a node, such as that of the ethereum clients
+type JSONRPC struct {
+	// Timeout is how long a call may take
+	Timeout time.Duration
+
+	url    string
+	dialer Dialer
+	id     int64
+}
+
+// NewJSONRPC creates a client for the JSON-RPC api served on the given port of the node at host, reached
+// through the given dialer
+func NewJSONRPC(dialer Dialer, host string, port int) *JSONRPC {
+	return &JSONRPC{Timeout: DefaultTimeout, dialer: dialer,
+		url: "http://" + net.JoinHostPort(host, strconv.Itoa(port))}
+}
+
+// Call calls the given method with the given params, decoding its result into out, unless out is nil
+func (rpc *JSONRPC) Call(method string, params []interface{}, out interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	data, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": atomic.AddInt64(&rpc.id, 1),
+		"method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, rpc.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	body, err := do(httpClient(rpc.dialer, rpc.Timeout), req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %s", method, err.Error())
+	}
+	var res response
+	if err := json.Unmarshal(body, &res); err != nil {
+		return invalidResponse(method, body)
+	}
+	return res.decode(method, body, out)
+}
+
+// KeepTryCall is Call, attempting the call up to maxRunAttempts times while the node cannot be reached,
+// such as when it is still starting. Errors returned by the node are not retried.
+func (rpc *JSONRPC) KeepTryCall(method string, params []interface{}, out interface{}) error {
+	var err error
+	for i := 0; i < conf.MaxRunAttempts; i++ {
+		if i > 0 {
+			time.Sleep(retryDelay)
+		}
+		err = rpc.Call(method, params, out)
+		if _, answered := err.(RPCError); err == nil || answered {
+			break
+		}
+	}
+	return err
+}
+
+// callUint64 calls a method whose result is a hex encoded quantity
+func (rpc *JSONRPC) callUint64(method string, params ...interface{}) (uint64, error) {
+	var raw string
+	err := rpc.Call(method, params, &raw)
+	if err != nil {
+		return 0, err
+	}
+	out, err := hexutil.DecodeUint64(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid result of %s \"%s\"", method, raw)
+	}
+	return out, nil
+}
+
+// BlockNumber gets the height of the chain of an ethereum node
+func (rpc *JSONRPC) BlockNumber() (uint64, error) {
+	return rpc.callUint64("eth_blockNumber")
+}
+
+// PeerCount gets the number of peers of an ethereum node
+func (rpc *JSONRPC) PeerCount() (uint64, error) {
+	return rpc.callUint64("net_peerCount")
+}
+
+// SendRawTransaction broadcasts the given signed transaction from an ethereum node, giving its hash
+func (rpc *JSONRPC) SendRawTransaction(tx []byte) (string, error) {
+	var hash string
+	err := rpc.Call("eth_sendRawTransaction", []interface{}{hexutil.Encode(tx)}, &hash)
+	return hash, err
+}
@@ -0,0 +1,88 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// serve starts a server with the given handler, giving a dialer and the address to reach it with
+func serve(t *testing.T, handler http.HandlerFunc) (Dialer, string, int) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := strconv.Atoi(port)
+	return &net.Dialer{}, host, p
+}
+
+func TestJSONRPC(t *testing.T) {
+	responses := map[string]string{
+		"eth_blockNumber":        `{"jsonrpc":"2.0","id":1,"result":"0x1b"}`,
+		"net_peerCount":          `{"jsonrpc":"2.0","id":1,"result":"0x3"}`,
+		"eth_sendRawTransaction": `{"jsonrpc":"2.0","id":1,"result":"0xabc"}`,
+		"eth_syncing":            `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`,
+		"broken":                 `not json`,
+	}
+	var lastParams []interface{}
+	dialer, host, port := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		lastParams = req.Params
+		fmt.Fprint(w, responses[req.Method])
+	})
+	rpc := NewJSONRPC(dialer, host, port)
+
+	height, err := rpc.BlockNumber()
+	if err != nil || height != 27 {
+		t.Errorf("unexpected height %d: %v", height, err)
+	}
+	peers, err := rpc.PeerCount()
+	if err != nil || peers != 3 {
+		t.Errorf("unexpected peers %d: %v", peers, err)
+	}
+	hash, err := rpc.SendRawTransaction([]byte{0x01, 0xff})
+	if err != nil || hash != "0xabc" {
+		t.Errorf("unexpected hash %s: %v", hash, err)
+	}
+	if len(lastParams) != 1 || lastParams[0] != "0x01ff" {
+		t.Errorf("unexpected params %v", lastParams)
+	}
+
+	err = rpc.Call("eth_syncing", nil, nil)
+	if re, ok := err.(RPCError); !ok || re.Code != -32601 || re.Error() != "eth_syncing failed: method not found" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err = rpc.Call("broken", nil, nil); err == nil || err.Error() != "invalid response to broken: not json" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package clients
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TendermintStatus is the status of a tendermint node
+type TendermintStatus struct {
+	// Height is the height of the latest block of the node
+	Height int64
+	// Hash is the hash of the latest block of the node
+	Hash string
+	// CatchingUp is whether the node is still syncing with its peers
+	CatchingUp bool
+}
+
+// Tendermint is a client for the rpc api of a tendermint node, which is also that of the blockchains
+// built on tendermint
+type Tendermint struct {
+	// Timeout is how long a call may take
+	Timeout time.Duration
+
+	url    string
+	dialer Dialer
+}
+
+// NewTendermint creates a client for the tendermint rpc api served on the given port of the node at host,
+// reached through the given dialer
+func NewTendermint(dialer Dialer, host string, port int) *Tendermint {
+	return &Tendermint{Timeout: DefaultTimeout, dialer: dialer,
+		url: "http://" + net.JoinHostPort(host, strconv.Itoa(port))}
+}
+
+// Get calls the given endpoint, such as status, with the given query, decoding its result into out
+func (tm *Tendermint) Get(path string, query url.Values, out interface{}) error {
+	target := tm.url + "/" + strings.TrimPrefix(path, "/")
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	body, err := do(httpClient(tm.dialer, tm.Timeout), req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %s", path, err.Error())
+	}
+	var res response
+	if err := json.Unmarshal(body, &res); err != nil {
+		return invalidResponse(path, body)
+	}
+	return res.decode(path, body, out)
+}
+
+// Status gets the status of the node
+func (tm *Tendermint) Status() (TendermintStatus, error) {
+	var status struct {
+		SyncInfo struct {
+			Height     string `json:"latest_block_height"`
+			Hash       string `json:"latest_block_hash"`
+			CatchingUp bool   `json:"catching_up"`
+		} `json:"sync_info"`
+	}
+	err := tm.Get("status", nil, &status)
+	if err != nil {
+		return TendermintStatus{}, err
+	}
+	height, err := strconv.ParseInt(status.SyncInfo.Height, 10, 64)
+	if err != nil {
+		return TendermintStatus{}, fmt.Errorf("invalid height \"%s\"", status.SyncInfo.Height)
+	}
+	return TendermintStatus{Height: height, Hash: status.SyncInfo.Hash, CatchingUp: status.SyncInfo.CatchingUp}, nil
+}
+
+// BlockHash gets the hash of the block at the given height
+func (tm *Tendermint) BlockHash(height int64) (string, error) {
+	type blockID struct {
+		Hash string `json:"hash"`
+	}
+	var block struct {
+		BlockID   blockID `json:"block_id"`
+		BlockMeta struct {
+			BlockID blockID `json:"block_id"`
+		} `json:"block_meta"` //before tendermint 0.33
+	}
+	err := tm.Get("block", url.Values{"height": []string{strconv.FormatInt(height, 10)}}, &block)
+	if err != nil {
+		return "", err
+	}
+	if len(block.BlockID.Hash) > 0 {
+		return block.BlockID.Hash, nil
+	}
+	return block.BlockMeta.BlockID.Hash, nil
+}
+
+// PeerCount gets the number of peers of the node
+func (tm *Tendermint) PeerCount() (int, error) {
+	var info struct {
+		Peers string `json:"n_peers"`
+	}
+	err := tm.Get("net_info", nil, &info)
+	if err != nil {
+		return 0, err
+	}
+	out, err := strconv.Atoi(info.Peers)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of peers \"%s\"", info.Peers)
+	}
+	return out, nil
+}
+
+// BroadcastTx broadcasts the given transaction, waiting for it to pass CheckTx, and gives its hash
+func (tm *Tendermint) BroadcastTx(tx []byte) (string, error) {
+	var res struct {
+		Code int    `json:"code"`
+		Log  string `json:"log"`
+		Hash string `json:"hash"`
+	}
+	err := tm.Get("broadcast_tx_sync", url.Values{"tx": []string{"0x" + hex.EncodeToString(tx)}}, &res)
+	if err != nil {
+		return "", err
+	}
+	if res.Code != 0 {
+		return res.Hash, fmt.Errorf("the transaction was rejected with code %d: %s", res.Code, res.Log)
+	}
+	return res.Hash, nil
+}
@@ -0,0 +1,68 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package clients
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestTendermint(t *testing.T) {
+	var query string
+	dialer, host, port := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		switch r.URL.Path {
+		case "/status":
+			fmt.Fprint(w, `{"result":{"sync_info":{"latest_block_height":"12","latest_block_hash":"AB",`+
+				`"catching_up":true}}}`)
+		case "/block":
+			fmt.Fprint(w, `{"result":{"block_meta":{"block_id":{"hash":"CD"}}}}`)
+		case "/net_info":
+			fmt.Fprint(w, `{"result":{"n_peers":"4"}}`)
+		case "/broadcast_tx_sync":
+			fmt.Fprint(w, `{"result":{"code":2,"log":"bad nonce","hash":"EF"}}`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error":{"code":-32603,"message":"Internal error","data":"not ready"}}`)
+		}
+	})
+	tm := NewTendermint(dialer, host, port)
+
+	status, err := tm.Status()
+	if err != nil || status != (TendermintStatus{Height: 12, Hash: "AB", CatchingUp: true}) {
+		t.Errorf("unexpected status %+v: %v", status, err)
+	}
+	hash, err := tm.BlockHash(7)
+	if err != nil || hash != "CD" || query != "height=7" {
+		t.Errorf("unexpected hash %s for %s: %v", hash, query, err)
+	}
+	peers, err := tm.PeerCount()
+	if err != nil || peers != 4 {
+		t.Errorf("unexpected peers %d: %v", peers, err)
+	}
+	hash, err = tm.BroadcastTx([]byte("k=v"))
+	if err == nil || hash != "EF" || query != "tx=0x6b3d76" {
+		t.Errorf("expected the transaction to be rejected, got %s for %s: %v", hash, query, err)
+	}
+	err = tm.Get("validators", nil, nil)
+	if err == nil || err.Error() != "validators failed: Internal error: not ready" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+var (
+	specFile  string
+	buildWait bool
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build -f spec.yaml",
+	Short: "Build a new testnet from a spec file",
+	Long: "Build a new testnet from a spec file, which contains the deployment details of the testnet in " +
+		"yaml or json, as given to POST /testnets. The id of the new testnet is printed.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		spec, err := readSpec(specFile)
+		if err != nil {
+			return err
+		}
+		res, err := request("POST", "/testnets", spec)
+		if err != nil {
+			return err
+		}
+		testnetID := string(res)
+		fmt.Println(testnetID)
+		if !buildWait {
+			return nil
+		}
+		return waitForBuild(testnetID)
+	},
+}
+
+func init() {
+	buildCmd.Flags().StringVarP(&specFile, "file", "f", "", "spec file of the testnet, - for stdin")
+	buildCmd.Flags().BoolVarP(&buildWait, "wait", "w", false, "wait for the build to finish")
+	buildCmd.MarkFlagRequired("file")
+}
+
+// readSpec reads a yaml or json spec file, and converts it into a json compatible value
+func readSpec(file string) (interface{}, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var spec interface{}
+	err = yaml.Unmarshal(data, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec file: %s", err.Error())
+	}
+	return toJSONValue(spec)
+}
+
+// toJSONValue converts the maps decoded from yaml, which have interface keys, into maps
+// with string keys
+func toJSONValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		out := map[string]interface{}{}
+		for key, value := range val {
+			converted, err := toJSONValue(value)
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(key)] = converted
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i := range val {
+			converted, err := toJSONValue(val[i])
+			if err != nil {
+				return nil, err
+			}
+			out[i] = converted
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+type buildStatus struct {
+	Progress float64     `json:"progress"`
+	Error    interface{} `json:"error"`
+	Stage    string      `json:"stage"`
+}
+
+// waitForBuild polls the status of the build until it finishes, printing each stage it goes through
+func waitForBuild(testnetID string) error {
+	stage := ""
+	for {
+		res, err := request("GET", "/status/build/"+testnetID, nil)
+		if err != nil {
+			return err
+		}
+		var status buildStatus
+		err = json.Unmarshal(res, &status)
+		if err != nil {
+			return err
+		}
+		if status.Error != nil {
+			data, _ := json.Marshal(status.Error)
+			return fmt.Errorf("the build failed: %s", string(data))
+		}
+		if status.Stage != stage {
+			stage = status.Stage
+			fmt.Fprintf(os.Stderr, "%s (%.0f%%)\n", stage, status.Progress)
+		}
+		if status.Progress >= 100 {
+			return nil
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <testnet> <node> -- <command>...",
+	Short: "Execute a command in a node",
+	Args:  cobra.MinimumNArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res, err := request("POST", fmt.Sprintf("/nodes/exec/%s/%s", args[0], args[1]), strings.Join(args[2:], " "))
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(res))
+		return nil
+	},
+}
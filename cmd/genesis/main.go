@@ -0,0 +1,63 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Command genesis is the command line interface of genesis. It runs the genesis server, and drives
+// a running genesis server through its REST API, so that testnets can be managed from scripts
+// and terminals.
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/whiteblock/genesis/util"
+	"os"
+)
+
+var (
+	host  string
+	token string
+)
+
+var rootCmd = &cobra.Command{
+	Use:           "genesis",
+	Short:         "Deploy and manage blockchain testnets",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func defaultHost() string {
+	if env := os.Getenv("GENESIS_HOST"); len(env) > 0 {
+		return env
+	}
+	return "http://" + util.GetConfig().Listen
+}
+
+func main() {
+	rootCmd.PersistentFlags().StringVar(&host, "host", defaultHost(),
+		"url of the genesis server, or set GENESIS_HOST")
+	rootCmd.PersistentFlags().StringVar(&token, "token", os.Getenv("GENESIS_TOKEN"),
+		"jwt to authenticate with, or set GENESIS_TOKEN")
+	rootCmd.PersistentFlags().AddFlagSet(util.ConfigFlags())
+	rootCmd.AddCommand(serveCmd, buildCmd, statusCmd, teardownCmd, netemCmd, execCmd, workerCmd)
+
+	err := rootCmd.Execute()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	netem "github.com/whiteblock/genesis/net"
+	"io/ioutil"
+)
+
+var (
+	netemFile string
+	netemNode int
+	netemFrom int
+	netemConf netem.Netconf
+)
+
+var netemCmd = &cobra.Command{
+	Use:   "netem",
+	Short: "Emulate network conditions between the nodes of a testnet",
+}
+
+var netemApplyCmd = &cobra.Command{
+	Use:   "apply <testnet>",
+	Short: "Apply network conditions to a node, or to every node of the testnet",
+	Long: "Apply network conditions to the node given by --node, or to every node of the testnet if it is not " +
+		"given. With -f, the conditions of each node are instead read from a json file, as given to POST /emulate.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var res []byte
+		var err error
+		if netemFrom >= 0 {
+			netemConf.From = &netemFrom
+		}
+		switch {
+		case len(netemFile) > 0:
+			var data []byte
+			data, err = ioutil.ReadFile(netemFile)
+			if err != nil {
+				return err
+			}
+			var confs []netem.Netconf
+			err = json.Unmarshal(data, &confs)
+			if err != nil {
+				return fmt.Errorf("invalid netem file: %s", err.Error())
+			}
+			res, err = request("POST", "/emulate/"+args[0], confs)
+		case netemNode >= 0:
+			netemConf.Node = netemNode
+			res, err = request("POST", "/emulate/"+args[0], []netem.Netconf{netemConf})
+		default:
+			res, err = request("POST", "/emulate/all/"+args[0], netemConf)
+		}
+		if err != nil {
+			return err
+		}
+		printResponse(res)
+		return nil
+	},
+}
+
+var netemClearCmd = &cobra.Command{
+	Use:   "clear <testnet>",
+	Short: "Remove the network conditions from every node of the testnet",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res, err := request("DELETE", "/emulate/"+args[0], nil)
+		if err != nil {
+			return err
+		}
+		printResponse(res)
+		return nil
+	},
+}
+
+func init() {
+	flags := netemApplyCmd.Flags()
+	flags.StringVarP(&netemFile, "file", "f", "", "json file of the conditions of each node")
+	flags.IntVar(&netemNode, "node", -1, "absolute number of the node, defaults to every node")
+	flags.IntVar(&netemConf.Delay, "delay", 0, "delay in microseconds")
+	flags.Float64Var(&netemConf.Loss, "loss", 0, "packet loss in percent")
+	flags.StringVar(&netemConf.Rate, "rate", "", "bandwidth limit, such as 10mbit")
+	flags.IntVar(&netemConf.Limit, "limit", 1000, "maximum number of packets queued")
+	flags.Float64Var(&netemConf.Duplication, "duplicate", 0, "packet duplication in percent")
+	flags.Float64Var(&netemConf.Corrupt, "corrupt", 0, "packet corruption in percent")
+	flags.Float64Var(&netemConf.Reorder, "reorder", 0, "packet reordering in percent")
+	flags.IntVar(&netemConf.Port, "port", 0, "only apply the conditions to the traffic to this port")
+	flags.StringVar(&netemConf.Protocol, "protocol", "", "only apply the conditions to the traffic of this protocol, tcp, udp or icmp")
+	flags.IntVar(&netemFrom, "from", -1, "only apply the conditions to the traffic from this node")
+	netemCmd.AddCommand(netemApplyCmd, netemClearCmd)
+}
@@ -0,0 +1,51 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/whiteblock/genesis/preflight"
+	"github.com/whiteblock/genesis/queue"
+	"github.com/whiteblock/genesis/util"
+	"os"
+)
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Run the builds dispatched by the genesis servers through the queue",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf := util.GetConfig()
+		if conf.QueueBackend != "redis" {
+			return fmt.Errorf("workers need the redis queue backend")
+		}
+		broker, err := queue.Get()
+		if err != nil {
+			return err
+		}
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		preflight.CheckAll()
+		queue.Work(broker, fmt.Sprintf("%s-%d", hostname, os.Getpid()), conf.QueueWorkers, nil)
+		return nil
+	},
+}
@@ -0,0 +1,331 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package composite builds deployments made up of several testnets which depend on each other, such as an L1
+// network, an L2 rollup pointed at it and a bridge between them. Each stage of a composite deployment is the
+// build of a testnet, which starts once the stages it depends on are done, and whose deployment details may
+// refer to their outputs, such as the ips and rpc urls of their nodes and the addresses of their contracts.
+package composite
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/ethereum"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PendingState is the state of a stage which is waiting on the stages it depends on
+	PendingState = "pending"
+	// BuildingState is the state of a stage which is being built
+	BuildingState = "building"
+	// DoneState is the state of a stage which was built, along with its contracts
+	DoneState = "done"
+	// FailedState is the state of a stage whose build failed
+	FailedState = "failed"
+	// SkippedState is the state of a stage which was not built, as a stage it depends on failed
+	SkippedState = "skipped"
+)
+
+var (
+	nameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+	// refRegex matches a reference to an output of a stage, such as ${l1.rpc.0}
+	refRegex = regexp.MustCompile(`\$\{([a-zA-Z0-9][a-zA-Z0-9_-]*)\.([a-zA-Z0-9_.-]+)\}`)
+)
+
+// Stage is one of the testnets of a composite deployment
+type Stage struct {
+	// Name identifies the stage within the deployment, and is how the other stages refer to it
+	Name string `json:"name"`
+	// DependsOn are the names of the stages which must be done before this one is built
+	DependsOn []string `json:"dependsOn"`
+	// Details are the deployment details of the testnet of the stage. Their strings may refer to the outputs
+	// of the stages it depends on, directly or not, as ${stage.output}.
+	Details json.RawMessage `json:"details"`
+	// Contracts are deployed to the testnet once it is built, by name, each giving the output contracts.<name>
+	Contracts map[string]ethereum.Contract `json:"contracts,omitempty"`
+	// RPCPort is the port of the rpc interface of the nodes, which gives the rpc.<node> outputs. It defaults to
+	// the port the build reports, if any.
+	RPCPort int `json:"rpcPort,omitempty"`
+}
+
+// Request is a request for a composite deployment
+type Request struct {
+	Stages []Stage `json:"stages"`
+}
+
+// GetDetails gets the deployment details of the stage
+func (stage Stage) GetDetails() (db.DeploymentDetails, error) {
+	var out db.DeploymentDetails
+	decoder := json.NewDecoder(bytes.NewReader(stage.Details))
+	decoder.UseNumber()
+	err := decoder.Decode(&out)
+	if err != nil {
+		return out, fmt.Errorf("invalid details for stage \"%s\": %s", stage.Name, err.Error())
+	}
+	return out, nil
+}
+
+// references gets the names of the stages which the stage refers to the outputs of
+func (stage Stage) references() ([]string, error) {
+	raw, err := json.Marshal(stage)
+	if err != nil {
+		return nil, err
+	}
+	out := []string{}
+	for _, match := range refRegex.FindAllStringSubmatch(string(raw), -1) {
+		out = append(out, match[1])
+	}
+	return out, nil
+}
+
+// order gets the stages in an order in which each stage comes after the stages it depends on, failing
+// if a stage depends on a stage which does not exist, or if the dependencies form a cycle
+func (req Request) order() ([]Stage, error) {
+	stages := map[string]Stage{}
+	for _, stage := range req.Stages {
+		stages[stage.Name] = stage
+	}
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := map[string]int{}
+	out := []Stage{}
+	var visit func(stage Stage, path []string) error
+	visit = func(stage Stage, path []string) error {
+		switch marks[stage.Name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("the stages depend on each other: %s", strings.Join(append(path, stage.Name), " -> "))
+		}
+		marks[stage.Name] = visiting
+		for _, name := range stage.DependsOn {
+			dep, ok := stages[name]
+			if !ok {
+				return fmt.Errorf("stage \"%s\" depends on \"%s\", which does not exist", stage.Name, name)
+			}
+			err := visit(dep, append(path, stage.Name))
+			if err != nil {
+				return err
+			}
+		}
+		marks[stage.Name] = visited
+		out = append(out, stage)
+		return nil
+	}
+	for _, stage := range req.Stages {
+		err := visit(stage, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// ancestors gets the names of all of the stages each stage depends on, directly or not. The stages must
+// be in order.
+func ancestors(stages []Stage) map[string]map[string]bool {
+	out := map[string]map[string]bool{}
+	for _, stage := range stages {
+		out[stage.Name] = map[string]bool{}
+		for _, dep := range stage.DependsOn {
+			out[stage.Name][dep] = true
+			for name := range out[dep] {
+				out[stage.Name][name] = true
+			}
+		}
+	}
+	return out
+}
+
+// Validate ensures that the stages of the request can be built in order. As each build tears down the
+// nodes already on its servers, no two stages may share a server.
+func (req Request) Validate() error {
+	if len(req.Stages) == 0 {
+		return fmt.Errorf("a composite deployment needs at least one stage")
+	}
+	names := map[string]bool{}
+	servers := map[int]string{}
+	for _, stage := range req.Stages {
+		if !nameRegex.MatchString(stage.Name) {
+			return fmt.Errorf("invalid stage name \"%s\"", stage.Name)
+		}
+		if names[stage.Name] {
+			return fmt.Errorf("the stage \"%s\" is given more than once", stage.Name)
+		}
+		names[stage.Name] = true
+		details, err := stage.GetDetails()
+		if err != nil {
+			return err
+		}
+		if len(details.Servers) == 0 {
+			return fmt.Errorf("stage \"%s\" needs at least one server", stage.Name)
+		}
+		for _, server := range details.Servers {
+			if other, ok := servers[server]; ok {
+				return fmt.Errorf("stages \"%s\" and \"%s\" both use server %d", other, stage.Name, server)
+			}
+			servers[server] = stage.Name
+		}
+		for name := range stage.Contracts {
+			if !nameRegex.MatchString(name) {
+				return fmt.Errorf("invalid contract name \"%s\" in stage \"%s\"", name, stage.Name)
+			}
+		}
+	}
+	stages, err := req.order()
+	if err != nil {
+		return err
+	}
+	deps := ancestors(stages)
+	for _, stage := range stages {
+		refs, err := stage.references()
+		if err != nil {
+			return err
+		}
+		for _, name := range refs {
+			if names[name] && !deps[stage.Name][name] {
+				return fmt.Errorf("stage \"%s\" refers to the outputs of \"%s\", which it does not depend on",
+					stage.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// substitute replaces the references to the outputs of the stages in the given string. References to
+// anything other than a stage with outputs, such as ${HOME}, are left as they are.
+func substitute(str string, outputs map[string]map[string]string) (string, error) {
+	var err error
+	out := refRegex.ReplaceAllStringFunc(str, func(ref string) string {
+		match := refRegex.FindStringSubmatch(ref)
+		stageOutputs, ok := outputs[match[1]]
+		if !ok {
+			return ref
+		}
+		value, ok := stageOutputs[match[2]]
+		if !ok && err == nil {
+			err = fmt.Errorf("stage \"%s\" has no output \"%s\"", match[1], match[2])
+		}
+		return value
+	})
+	return out, err
+}
+
+// substituteAll replaces the references in each of the strings within the given value, as decoded from json
+func substituteAll(value interface{}, outputs map[string]map[string]string) (interface{}, error) {
+	switch val := value.(type) {
+	case string:
+		return substitute(val, outputs)
+	case []interface{}:
+		for i := range val {
+			res, err := substituteAll(val[i], outputs)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = res
+		}
+	case map[string]interface{}:
+		for key := range val {
+			res, err := substituteAll(val[key], outputs)
+			if err != nil {
+				return nil, err
+			}
+			val[key] = res
+		}
+	}
+	return value, nil
+}
+
+// Resolve gives the stage with the references to the outputs of the stages it depends on replaced by their values
+func (stage Stage) Resolve(outputs map[string]map[string]string) (Stage, error) {
+	raw, err := json.Marshal(stage)
+	if err != nil {
+		return stage, err
+	}
+	var tree interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	err = decoder.Decode(&tree)
+	if err != nil {
+		return stage, err
+	}
+	tree, err = substituteAll(tree, outputs)
+	if err != nil {
+		return stage, err
+	}
+	raw, err = json.Marshal(tree)
+	if err != nil {
+		return stage, err
+	}
+	var out Stage
+	return out, json.Unmarshal(raw, &out)
+}
+
+// Outputs gets the outputs of a stage, from the id of its testnet, its nodes, the values its build
+// reported and the addresses of its contracts by name:
+//   - testnetId: the id of the testnet
+//   - ips: the ips of the nodes, comma separated
+//   - ip.<node>: the ip of the node with the given absolute number
+//   - rpc and rpc.<node>: the rpc url of the first node and of the given node, when the rpc port is known
+//   - contracts.<name>: the address of the contract with the given name
+//   - any other string or number reported by the build, such as explorer, under its own name
+func Outputs(testnetID string, nodes []db.Node, ext map[string]interface{}, rpcPort int,
+	contracts map[string]string) map[string]string {
+
+	out := map[string]string{}
+	for key, value := range ext {
+		switch val := value.(type) {
+		case string:
+			out[key] = val
+		case json.Number, float64, int:
+			out[key] = fmt.Sprint(val)
+		}
+	}
+	if rpcPort == 0 {
+		rpcPort, _ = strconv.Atoi(out["port"])
+	}
+	sorted := append([]db.Node{}, nodes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].AbsoluteNum < sorted[j].AbsoluteNum })
+	ips := []string{}
+	for _, node := range sorted {
+		num := strconv.Itoa(node.AbsoluteNum)
+		ips = append(ips, node.IP)
+		out["ip."+num] = node.IP
+		if rpcPort > 0 {
+			out["rpc."+num] = fmt.Sprintf("http://%s:%d", node.IP, rpcPort)
+		}
+	}
+	out["ips"] = strings.Join(ips, ",")
+	if len(sorted) > 0 && rpcPort > 0 {
+		out["rpc"] = out["rpc."+strconv.Itoa(sorted[0].AbsoluteNum)]
+	}
+	for name, address := range contracts {
+		out["contracts."+name] = address
+	}
+	out["testnetId"] = testnetID
+	return out
+}
@@ -0,0 +1,177 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package composite
+
+import (
+	"encoding/json"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/ethereum"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func stage(name string, servers string, deps ...string) Stage {
+	return Stage{Name: name, DependsOn: deps, Details: json.RawMessage(`{"blockchain":"geth","servers":` + servers + `}`)}
+}
+
+func TestRequest_Validate(t *testing.T) {
+	withRef := stage("l2", "[2]", "l1")
+	withRef.Details = json.RawMessage(`{"servers":[2],"environments":[{"L1":"${l1.rpc}","HOME":"${HOME}"}]}`)
+	indirect := stage("bridge", "[3]", "l2")
+	indirect.Details = json.RawMessage(`{"servers":[3],"params":{"l1":"${l1.ip.0}"}}`)
+	unrelated := stage("other", "[3]")
+	unrelated.Details = json.RawMessage(`{"servers":[3],"params":{"l1":"${l1.ip.0}"}}`)
+	badContract := stage("l1", "[1]")
+	badContract.Contracts = map[string]ethereum.Contract{"bad name": {}}
+
+	var test = []struct {
+		stages []Stage
+		valid  bool
+	}{
+		{stages: []Stage{stage("l1", "[1]"), withRef, indirect}, valid: true},
+		{stages: []Stage{stage("l1", "[1]"), stage("l2", "[2]", "l1"), stage("l3", "[3]", "l1")}, valid: true},
+		{stages: []Stage{}, valid: false},
+		{stages: []Stage{stage("l 1", "[1]")}, valid: false},
+		{stages: []Stage{stage("l1", "[1]"), stage("l1", "[2]")}, valid: false},
+		{stages: []Stage{stage("l1", "[]")}, valid: false},
+		{stages: []Stage{{Name: "l1", Details: json.RawMessage(`{"servers":"1"}`)}}, valid: false},
+		{stages: []Stage{stage("l1", "[1]"), stage("l2", "[1, 2]", "l1")}, valid: false},
+		{stages: []Stage{stage("l1", "[1]", "l3")}, valid: false},
+		{stages: []Stage{stage("l1", "[1]", "l2"), stage("l2", "[2]", "l1")}, valid: false},
+		{stages: []Stage{stage("l1", "[1]", "l1")}, valid: false},
+		{stages: []Stage{stage("l1", "[1]"), unrelated}, valid: false},
+		{stages: []Stage{badContract}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := Request{Stages: tt.stages}.Validate()
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate returned %v, expected valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestRequest_order(t *testing.T) {
+	req := Request{Stages: []Stage{
+		stage("bridge", "[3]", "l2", "l1"),
+		stage("l2", "[2]", "l1"),
+		stage("l1", "[1]"),
+	}}
+	stages, err := req.order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, stage := range stages {
+		names = append(names, stage.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"l1", "l2", "bridge"}) {
+		t.Errorf("unexpected order %v", names)
+	}
+}
+
+func TestStage_Resolve(t *testing.T) {
+	outputs := map[string]map[string]string{
+		"l1": {"rpc": "http://10.1.0.2:8545", "contracts.bridge": "0xabc"},
+	}
+	in := Stage{Name: "l2", DependsOn: []string{"l1"}, Details: json.RawMessage(
+		`{"nodes":2,"environments":[{"L1":"${l1.rpc}","BRIDGE":"bridge=${l1.contracts.bridge}","HOME":"${HOME}"}]}`)}
+	in.Contracts = map[string]ethereum.Contract{"token": {Args: "${l1.contracts.bridge}", Gas: 100}}
+
+	out, err := in.Resolve(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	details, err := out.GetDetails()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"L1": "http://10.1.0.2:8545", "BRIDGE": "bridge=0xabc", "HOME": "${HOME}"}
+	if details.Nodes != 2 || !reflect.DeepEqual(details.Environments, []map[string]string{expected}) {
+		t.Errorf("unexpected details %+v", details)
+	}
+	if out.Contracts["token"].Args != "0xabc" || out.Contracts["token"].Gas != 100 {
+		t.Errorf("unexpected contracts %+v", out.Contracts)
+	}
+
+	in.Details = json.RawMessage(`{"params":{"x":"${l1.missing}"}}`)
+	_, err = in.Resolve(outputs)
+	if err == nil {
+		t.Error("expected an error for a missing output")
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	nodes := []db.Node{{AbsoluteNum: 1, IP: "10.1.0.6"}, {AbsoluteNum: 0, IP: "10.1.0.2"}}
+	ext := map[string]interface{}{"port": json.Number("8545"), "explorer": "http://1.2.3.4:8090",
+		"accounts": []interface{}{"0x1"}, "testnetId": "override"}
+	expected := map[string]string{
+		"testnetId":        "abc",
+		"ips":              "10.1.0.2,10.1.0.6",
+		"ip.0":             "10.1.0.2",
+		"ip.1":             "10.1.0.6",
+		"rpc":              "http://10.1.0.2:8545",
+		"rpc.0":            "http://10.1.0.2:8545",
+		"rpc.1":            "http://10.1.0.6:8545",
+		"contracts.bridge": "0xabc",
+		"port":             "8545",
+		"explorer":         "http://1.2.3.4:8090",
+	}
+	out := Outputs("abc", nodes, ext, 0, map[string]string{"bridge": "0xabc"})
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("unexpected outputs %v", out)
+	}
+
+	out = Outputs("abc", nodes, nil, 26657, nil)
+	if out["rpc.1"] != "http://10.1.0.6:26657" {
+		t.Errorf("unexpected outputs %v", out)
+	}
+	out = Outputs("abc", nodes, nil, 0, nil)
+	if _, ok := out["rpc"]; ok {
+		t.Errorf("unexpected rpc output without a port %v", out)
+	}
+}
+
+func TestDeployment_State(t *testing.T) {
+	var test = []struct {
+		states   []string
+		expected string
+	}{
+		{states: []string{DoneState, DoneState}, expected: DoneState},
+		{states: []string{DoneState, BuildingState}, expected: BuildingState},
+		{states: []string{FailedState, PendingState}, expected: BuildingState},
+		{states: []string{FailedState, SkippedState}, expected: FailedState},
+		{states: []string{DoneState, SkippedState}, expected: FailedState},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dep := Deployment{}
+			for _, state := range tt.states {
+				dep.Stages = append(dep.Stages, StageState{State: state})
+			}
+			if dep.State() != tt.expected {
+				t.Errorf("State returned %s, expected %s", dep.State(), tt.expected)
+			}
+		})
+	}
+}
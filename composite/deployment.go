@@ -0,0 +1,323 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package composite
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/manager"
+	"github.com/whiteblock/genesis/protocols/ethereum"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const deploymentsKey = "composite_deployments"
+
+var deploymentsMux = sync.Mutex{}
+
+// StageState is the state of a stage of a composite deployment
+type StageState struct {
+	Name      string   `json:"name"`
+	DependsOn []string `json:"dependsOn"`
+	State     string   `json:"state"`
+	// TestNetID is the id of the testnet of the stage, once its build has started
+	TestNetID string `json:"testnetId,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Outputs are the outputs of the stage once it is done, which the stages depending on it may refer to
+	Outputs  map[string]string `json:"outputs,omitempty"`
+	Started  int64             `json:"started,omitempty"`
+	Finished int64             `json:"finished,omitempty"`
+}
+
+// Deployment is a composite deployment
+type Deployment struct {
+	ID      string       `json:"id"`
+	Created int64        `json:"created"`
+	Stages  []StageState `json:"stages"`
+}
+
+// State gets the state of the deployment as a whole: building until each stage is done or could not be
+// built, then failed if any stage failed
+func (dep Deployment) State() string {
+	out := DoneState
+	for _, stage := range dep.Stages {
+		switch stage.State {
+		case PendingState, BuildingState:
+			return BuildingState
+		case FailedState, SkippedState:
+			out = FailedState
+		}
+	}
+	return out
+}
+
+// MarshalJSON gives the deployment along with its state
+func (dep Deployment) MarshalJSON() ([]byte, error) {
+	type deployment Deployment
+	return json.Marshal(struct {
+		deployment
+		State string `json:"state"`
+	}{deployment: deployment(dep), State: dep.State()})
+}
+
+func getDeployments() []Deployment {
+	out := []Deployment{}
+	db.GetMetaP(deploymentsKey, &out) //An error here just means that there are no composite deployments
+	return out
+}
+
+// List gets all of the composite deployments
+func List() []Deployment {
+	deploymentsMux.Lock()
+	defer deploymentsMux.Unlock()
+	return getDeployments()
+}
+
+// Get gets the composite deployment with the given id
+func Get(id string) (Deployment, error) {
+	for _, dep := range List() {
+		if dep.ID == id {
+			return dep, nil
+		}
+	}
+	return Deployment{}, fmt.Errorf("composite deployment \"%s\" not found", id)
+}
+
+// updateStage applies fn to the state of the given stage of the deployment, and stores it
+func updateStage(id string, name string, fn func(*StageState)) {
+	deploymentsMux.Lock()
+	defer deploymentsMux.Unlock()
+	deps := getDeployments()
+	for i := range deps {
+		if deps[i].ID != id {
+			continue
+		}
+		for j := range deps[i].Stages {
+			if deps[i].Stages[j].Name == name {
+				fn(&deps[i].Stages[j])
+			}
+		}
+	}
+	util.LogError(db.SetMeta(deploymentsKey, deps))
+}
+
+// Build validates the request and starts building its stages, each once the stages it depends on are done,
+// giving the id of the composite deployment. jwt is that of the caller, which each build is made with.
+func Build(req Request, jwt string) (string, error) {
+	err := req.Validate()
+	if err != nil {
+		return "", err
+	}
+	stages, err := req.order()
+	if err != nil {
+		return "", err
+	}
+	id, err := util.GetUUIDString()
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	dep := Deployment{ID: id, Created: time.Now().Unix()}
+	for _, stage := range stages {
+		dep.Stages = append(dep.Stages, StageState{Name: stage.Name, DependsOn: stage.DependsOn, State: PendingState})
+	}
+	deploymentsMux.Lock()
+	err = db.SetMeta(deploymentsKey, append(getDeployments(), dep))
+	deploymentsMux.Unlock()
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	log.WithFields(log.Fields{"deployment": id, "stages": len(stages)}).Info("started a composite deployment")
+	go run(id, stages, jwt)
+	return id, nil
+}
+
+// run builds each of the stages once the stages it depends on are done. The stages depending on a stage
+// which failed are skipped, while the others carry on.
+func run(id string, stages []Stage, jwt string) {
+	done := map[string]chan struct{}{}
+	for _, stage := range stages {
+		done[stage.Name] = make(chan struct{})
+	}
+	outputsMux := sync.Mutex{}
+	outputs := map[string]map[string]string{}
+	wg := sync.WaitGroup{}
+	for _, stage := range stages {
+		wg.Add(1)
+		go func(stage Stage) {
+			defer wg.Done()
+			defer close(done[stage.Name])
+			for _, name := range stage.DependsOn {
+				<-done[name]
+			}
+			outputsMux.Lock()
+			failed := []string{}
+			for _, name := range stage.DependsOn {
+				if _, ok := outputs[name]; !ok {
+					failed = append(failed, name)
+				}
+			}
+			resolved, err := stage.Resolve(outputs)
+			outputsMux.Unlock()
+			if len(failed) > 0 {
+				updateStage(id, stage.Name, func(ss *StageState) {
+					ss.State = SkippedState
+					ss.Error = fmt.Sprintf("depends on %s, which could not be built", strings.Join(failed, ", "))
+				})
+				return
+			}
+			var res map[string]string
+			if err == nil {
+				res, err = buildStage(id, resolved, jwt)
+				if err == nil {
+					outputsMux.Lock()
+					outputs[stage.Name] = res
+					outputsMux.Unlock()
+				}
+			}
+			updateStage(id, stage.Name, func(ss *StageState) {
+				ss.Finished = time.Now().Unix()
+				if err != nil {
+					ss.State = FailedState
+					ss.Error = err.Error()
+					return
+				}
+				ss.State = DoneState
+				ss.Outputs = res
+			})
+		}(stage)
+	}
+	wg.Wait()
+	log.WithFields(log.Fields{"deployment": id}).Info("finished the composite deployment")
+}
+
+// buildStage builds the testnet of the stage and deploys its contracts, giving the outputs of the stage
+func buildStage(id string, stage Stage, jwt string) (map[string]string, error) {
+	details, err := stage.GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	details.SetJwt(jwt)
+	testnetID, err := util.GetUUIDString()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	err = state.AcquireBuilding(details.Servers, testnetID)
+	if err != nil {
+		return nil, err
+	}
+	updateStage(id, stage.Name, func(ss *StageState) {
+		ss.State = BuildingState
+		ss.TestNetID = testnetID
+		ss.Started = time.Now().Unix()
+	})
+	log.WithFields(log.Fields{"deployment": id, "stage": stage.Name, "build": testnetID}).Info("building a stage")
+	err = manager.AddTestNet(&details, testnetID)
+	if err != nil {
+		return nil, err
+	}
+	ext := map[string]interface{}{}
+	bs, err := state.GetBuildStateByID(testnetID)
+	if err == nil {
+		raw, err := bs.GetExtExtras()
+		if err == nil {
+			decoder := json.NewDecoder(bytes.NewReader(raw))
+			decoder.UseNumber()
+			util.LogError(decoder.Decode(&ext))
+		}
+	}
+	contracts, err := deployContracts(testnetID, stage.Contracts)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := db.GetAllNodesByTestNet(testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return Outputs(testnetID, nodes, ext, stage.RPCPort, contracts), nil
+}
+
+// deployContracts deploys the given contracts to the testnet in the order of their names, giving their
+// addresses by name
+func deployContracts(testnetID string, contracts map[string]ethereum.Contract) (map[string]string, error) {
+	out := map[string]string{}
+	if len(contracts) == 0 {
+		return out, nil
+	}
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	names := []string{}
+	for name := range contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		deployment, err := ethereum.DeployContract(tn, contracts[name])
+		if err != nil {
+			return nil, fmt.Errorf("could not deploy contract \"%s\": %s", name, err.Error())
+		}
+		out[name] = deployment.Address
+	}
+	return out, nil
+}
+
+// Destroy tears down the testnet of each stage of the composite deployment, the stages which depend on
+// others first, then forgets it. It is kept if any of them could not be torn down, so that it can be tried
+// again. A deployment which is still being built cannot be destroyed.
+func Destroy(id string) error {
+	dep, err := Get(id)
+	if err != nil {
+		return err
+	}
+	if dep.State() == BuildingState {
+		return fmt.Errorf("composite deployment \"%s\" is still being built", id)
+	}
+	errs := []string{}
+	for i := len(dep.Stages) - 1; i >= 0; i-- {
+		stage := dep.Stages[i]
+		if len(stage.TestNetID) == 0 {
+			continue
+		}
+		err := manager.DeleteTestNet(stage.TestNetID)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", stage.Name, err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("could not tear down all of the stages: %s", strings.Join(errs, ", "))
+	}
+	deploymentsMux.Lock()
+	defer deploymentsMux.Unlock()
+	out := []Deployment{}
+	for _, other := range getDeployments() {
+		if other.ID != id {
+			out = append(out, other)
+		}
+	}
+	return db.SetMeta(deploymentsKey, out)
+}
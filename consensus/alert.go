@@ -0,0 +1,185 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package consensus
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/notify"
+	"github.com/whiteblock/genesis/webhook"
+	"time"
+)
+
+const (
+	// ForkRule fires when the chains of the nodes have diverged for at least Depth blocks
+	ForkRule = "fork"
+	// StallRule fires when the height of a node, or of the whole testnet, has not increased for Seconds
+	StallRule = "stall"
+	// ReorgRule fires when a node replaces its head by a block which does not build on it
+	ReorgRule = "reorg"
+)
+
+// Rule is a condition on the consensus of a testnet, which fires an alert when it is met
+type Rule struct {
+	// Type is one of fork, stall or reorg
+	Type string `json:"type"`
+	// Depth is the number of blocks a fork needs to reach for a fork rule to fire, defaults to 1
+	Depth int64 `json:"depth,omitempty"`
+	// Seconds is how long the height needs to stay the same for a stall rule to fire, defaults
+	// to consensusStallTimeout
+	Seconds int `json:"seconds,omitempty"`
+}
+
+// Alert is the firing of a rule
+type Alert struct {
+	Rule    Rule   `json:"rule"`
+	Message string `json:"message"`
+	// Node is the absolute number of the node the alert is about, left out for the whole testnet
+	Node   *int      `json:"node,omitempty"`
+	Height int64     `json:"height"`
+	Fired  time.Time `json:"fired"`
+}
+
+// Validate ensures that the rule is valid
+func (r Rule) Validate() error {
+	switch r.Type {
+	case ForkRule, StallRule, ReorgRule:
+	default:
+		return fmt.Errorf("unknown rule type \"%s\"", r.Type)
+	}
+	if r.Depth < 0 {
+		return fmt.Errorf("the depth cannot be negative")
+	}
+	if r.Seconds < 0 {
+		return fmt.Errorf("the seconds cannot be negative")
+	}
+	return nil
+}
+
+func (r Rule) getDepth() int64 {
+	if r.Depth == 0 {
+		return 1
+	}
+	return r.Depth
+}
+
+func (r Rule) getTimeout() time.Duration {
+	if r.Seconds == 0 {
+		return time.Duration(conf.ConsensusStallTimeout) * time.Second
+	}
+	return time.Duration(r.Seconds) * time.Second
+}
+
+func rulesKey(testnetID string) string {
+	return "consensus_rules_" + testnetID
+}
+
+// GetRules gets the alert rules of the testnet
+func GetRules(testnetID string) []Rule {
+	out := []Rule{}
+	db.GetMetaP(rulesKey(testnetID), &out) //An error means that the testnet has no rules
+	return out
+}
+
+// SetRules validates and replaces the alert rules of the testnet, which are evaluated on every probe
+// of its consensus. The alerts which already fired may fire again under the new rules.
+func SetRules(testnetID string, rules []Rule) error {
+	for i, rule := range rules {
+		err := rule.Validate()
+		if err != nil {
+			return fmt.Errorf("rule %d: %s", i, err.Error())
+		}
+	}
+	err := db.SetMeta(rulesKey(testnetID), rules)
+	if err != nil {
+		return err
+	}
+	monitorMux.Lock()
+	m, ok := monitors[testnetID]
+	monitorMux.Unlock()
+	if ok {
+		m.mux.Lock()
+		m.rules = rules
+		m.fired = map[string]bool{}
+		m.mux.Unlock()
+	}
+	return nil
+}
+
+// ClearRules removes the alert rules of the testnet
+func ClearRules(testnetID string) error {
+	return db.DeleteMeta(rulesKey(testnetID))
+}
+
+// fire sends the alert to the webhooks and the chat services
+var fire = func(testnetID string, alert Alert) {
+	log.WithFields(log.Fields{"testnet": testnetID, "rule": alert.Rule.Type}).Warn(alert.Message)
+	webhook.Emit(webhook.ConsensusAlert, testnetID, alert)
+	notify.Alert(testnetID, alert.Message)
+}
+
+// evaluate checks the rules against the report, returning the alerts which have not fired yet.
+// The caller must hold the lock of the monitor.
+func (m *monitor) evaluate(now time.Time) []Alert {
+	out := []Alert{}
+	add := func(key string, alert Alert) {
+		if m.fired[key] {
+			return
+		}
+		m.fired[key] = true
+		alert.Fired = now
+		out = append(out, alert)
+	}
+	for i, rule := range m.rules {
+		switch rule.Type {
+		case ForkRule:
+			for _, fork := range m.report.Forks {
+				if fork.Depth < rule.getDepth() {
+					continue
+				}
+				add(fmt.Sprintf("%d/%d", i, fork.Base), Alert{Rule: rule, Height: fork.Height,
+					Message: fmt.Sprintf("the nodes have forked for %d blocks since block %d", fork.Depth, fork.Base)})
+			}
+		case StallRule:
+			timeout := rule.getTimeout()
+			if now.Sub(m.progressed) >= timeout {
+				add(fmt.Sprintf("%d/%d", i, m.progressed.UnixNano()), Alert{Rule: rule, Height: m.report.Height,
+					Message: fmt.Sprintf("no new blocks for %s, at height %d", timeout, m.report.Height)})
+			}
+			for j := range m.report.Nodes {
+				node := m.report.Nodes[j]
+				if now.Sub(node.Progressed) < timeout {
+					continue
+				}
+				add(fmt.Sprintf("%d/%d/%d", i, node.Node, node.Progressed.UnixNano()), Alert{Rule: rule,
+					Node: &node.Node, Height: node.Height,
+					Message: fmt.Sprintf("node %d has been stuck at height %d for %s", node.Node, node.Height, timeout)})
+			}
+		case ReorgRule:
+			for j := range m.report.Reorgs {
+				reorg := m.report.Reorgs[j]
+				add(fmt.Sprintf("%d/%d/%d/%s", i, reorg.Node, reorg.Height, reorg.Replaced), Alert{Rule: rule,
+					Node: &reorg.Node, Height: reorg.Height,
+					Message: fmt.Sprintf("node %d replaced its block %s at height %d", reorg.Node, reorg.Replaced, reorg.Height)})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package consensus
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRule_Validate(t *testing.T) {
+	var test = []struct {
+		rule  Rule
+		valid bool
+	}{
+		{rule: Rule{Type: ForkRule, Depth: 3}, valid: true},
+		{rule: Rule{Type: StallRule}, valid: true},
+		{rule: Rule{Type: ReorgRule}, valid: true},
+		{rule: Rule{Type: "explode"}, valid: false},
+		{rule: Rule{Type: ForkRule, Depth: -1}, valid: false},
+		{rule: Rule{Type: StallRule, Seconds: -5}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.rule.Validate()
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate returned %v, expected valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestMonitor_evaluate(t *testing.T) {
+	m := newTestMonitor(newFakeChain(nil), false, 2)
+	now := m.report.Started.Add(time.Minute)
+	m.progressed = now.Add(-10 * time.Second)
+	m.report.Nodes[0].Progressed = now.Add(-40 * time.Second)
+	m.report.Nodes[1].Progressed = now.Add(-10 * time.Second)
+	m.report.Forks = []Fork{{Base: 10, Height: 12, Depth: 2}, {Base: 20, Height: 25, Depth: 5}}
+	m.report.Reorgs = []Reorg{{Node: 1, Height: 7, Replaced: "a", By: "b"}}
+
+	var test = []struct {
+		rule     Rule
+		expected []int
+	}{
+		{rule: Rule{Type: ForkRule}, expected: []int{-1, -1}},
+		{rule: Rule{Type: ForkRule, Depth: 3}, expected: []int{-1}},
+		{rule: Rule{Type: ForkRule, Depth: 6}, expected: []int{}},
+		{rule: Rule{Type: StallRule, Seconds: 30}, expected: []int{0}},
+		{rule: Rule{Type: StallRule, Seconds: 5}, expected: []int{-1, 0, 1}},
+		{rule: Rule{Type: StallRule, Seconds: 50}, expected: []int{}},
+		{rule: Rule{Type: ReorgRule}, expected: []int{1}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			m.rules = []Rule{tt.rule}
+			m.fired = map[string]bool{}
+			alerts := m.evaluate(now)
+			if len(alerts) != len(tt.expected) {
+				t.Fatalf("expected %d alerts, got %+v", len(tt.expected), alerts)
+			}
+			for j, alert := range alerts {
+				node := -1
+				if alert.Node != nil {
+					node = *alert.Node
+				}
+				if node != tt.expected[j] || alert.Rule != tt.rule || !alert.Fired.Equal(now) {
+					t.Errorf("unexpected alert %+v", alert)
+				}
+			}
+			if len(m.evaluate(now)) != 0 {
+				t.Error("expected the alerts to only fire once")
+			}
+		})
+	}
+}
@@ -0,0 +1,532 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package consensus periodically probes the chains of the nodes of testnets, to detect the loss of
+// liveness, when no new blocks are produced, and of safety, when the nodes disagree on the blocks.
+package consensus
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"sort"
+	"sync"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+// maxEvents limits the number of forks, reorgs and alerts kept in the report of a testnet
+const maxEvents = 100
+
+// NodeStatus is the latest probe of the chain of a node
+type NodeStatus struct {
+	// Node is the absolute number of the node
+	Node int `json:"node"`
+	// Height is the height of the latest block of the node
+	Height int64  `json:"height"`
+	Hash   string `json:"hash"`
+	// Finalized is the height of the latest finalized block, left out for blockchains without finality
+	Finalized *int64 `json:"finalized,omitempty"`
+	// FinalityLag is the number of blocks which are not yet finalized
+	FinalityLag *int64 `json:"finalityLag,omitempty"`
+	// Progressed is when the height of the node last increased
+	Progressed time.Time `json:"progressed"`
+	// Stalled is whether the node has not had a new block for consensusStallTimeout seconds
+	Stalled bool `json:"stalled"`
+	// Error is why the node could not be probed
+	Error string `json:"error,omitempty"`
+}
+
+// Fork is a divergence of the chains of the nodes from a common block
+type Fork struct {
+	// Base is the height of the last block which all of the nodes agree on
+	Base int64 `json:"base"`
+	// Height is the height at which the blocks of the nodes were last compared
+	Height int64 `json:"height"`
+	// Depth is the number of blocks since the base, on the shortest of the conflicting chains
+	Depth int64 `json:"depth"`
+	// Hashes are the nodes which have each of the conflicting blocks at the height
+	Hashes map[string][]int `json:"hashes"`
+	// Finalized is whether all of the conflicting blocks were finalized, which breaks the safety of the consensus
+	Finalized bool      `json:"finalized"`
+	Detected  time.Time `json:"detected"`
+}
+
+// Reorg is the replacement of the head of a node by a block which does not build on it
+type Reorg struct {
+	// Node is the absolute number of the node
+	Node int `json:"node"`
+	// Height is the height of the replaced block
+	Height int64 `json:"height"`
+	// Replaced is the hash of the replaced block
+	Replaced string `json:"replaced"`
+	// By is the hash of the block now at the height, left out if the chain of the node is now shorter
+	By       string    `json:"by,omitempty"`
+	Detected time.Time `json:"detected"`
+}
+
+// Stall is a period during which none of the nodes had a new block
+type Stall struct {
+	Started time.Time `json:"started"`
+	// Ended is when a new block was produced, left out while the chain is still stalled
+	Ended *time.Time `json:"ended,omitempty"`
+}
+
+// Report is the state of the consensus of a testnet
+type Report struct {
+	Blockchain string    `json:"blockchain"`
+	Started    time.Time `json:"started"`
+	Sampled    time.Time `json:"sampled"`
+	// Height is the highest height of the nodes
+	Height int64 `json:"height"`
+	// Live is whether any of the nodes had a new block in the last consensusStallTimeout seconds
+	Live  bool         `json:"live"`
+	Nodes []NodeStatus `json:"nodes"`
+	// Forks are the forks detected since the monitoring started, one for each base
+	Forks []Fork `json:"forks"`
+	// Reorgs are the reorgs of the nodes detected since the monitoring started
+	Reorgs []Reorg `json:"reorgs"`
+	// Stalls are the periods since the monitoring started during which the chain was not live
+	Stalls []Stall `json:"stalls"`
+	// Alerts are the alerts fired by the rules of the testnet since the monitoring started
+	Alerts []Alert `json:"alerts"`
+}
+
+// monitor periodically probes the chains of the nodes of a testnet
+type monitor struct {
+	testnetID string
+	probe     helpers.ConsensusProbe
+	nodes     []ssh.Node
+	getClient func(serverID int) (ssh.Client, error)
+	stop      chan bool
+
+	mux        sync.RWMutex
+	report     Report
+	progressed time.Time
+	// agreed is the latest height at which all of the nodes were found to have the same block
+	agreed int64
+	rules  []Rule
+	// fired are the keys of the alerts which have already been fired, so that each only fires once
+	fired map[string]bool
+}
+
+var (
+	monitors   = map[string]*monitor{}
+	monitorMux = sync.Mutex{}
+)
+
+func newMonitor(testnetID string, blockchain string, probe helpers.ConsensusProbe, nodes []ssh.Node) *monitor {
+	now := time.Now()
+	out := &monitor{
+		testnetID:  testnetID,
+		probe:      probe,
+		nodes:      nodes,
+		getClient:  status.GetClient,
+		stop:       make(chan bool),
+		progressed: now,
+		rules:      []Rule{},
+		fired:      map[string]bool{},
+		report: Report{
+			Blockchain: blockchain,
+			Started:    now,
+			Live:       true,
+			Nodes:      []NodeStatus{},
+			Forks:      []Fork{},
+			Reorgs:     []Reorg{},
+			Stalls:     []Stall{},
+			Alerts:     []Alert{},
+		},
+	}
+	for _, node := range nodes {
+		out.report.Nodes = append(out.report.Nodes, NodeStatus{Node: node.GetAbsoluteNumber(), Progressed: now})
+	}
+	return out
+}
+
+// forEachNode runs fn on every node at once, along with the client of its server
+func (m *monitor) forEachNode(fn func(i int, client ssh.Client, node ssh.Node) error) []error {
+	out := make([]error, len(m.nodes))
+	wg := sync.WaitGroup{}
+	for i, node := range m.nodes {
+		wg.Add(1)
+		go func(i int, node ssh.Node) {
+			defer wg.Done()
+			client, err := m.getClient(node.GetServerID())
+			if err == nil {
+				err = fn(i, client, node)
+			}
+			out[i] = err
+		}(i, node)
+	}
+	wg.Wait()
+	return out
+}
+
+// blockAt gets the block of the node at the given height, from its head if it is at that height
+func (m *monitor) blockAt(client ssh.Client, node ssh.Node, head helpers.Block, height int64) (helpers.Block, error) {
+	if head.Height == height {
+		return head, nil
+	}
+	return m.probe.BlockAt(client, node, height)
+}
+
+// sample probes the heads of all of the nodes, checks whether they replaced their previous heads,
+// then compares their blocks at the highest height they all have
+func (m *monitor) sample() {
+	now := time.Now()
+	previous := m.get().Nodes
+	heads := make([]helpers.Block, len(m.nodes))
+	finalized := make([]*int64, len(m.nodes))
+	errs := m.forEachNode(func(i int, client ssh.Client, node ssh.Node) error {
+		var err error
+		heads[i], err = m.probe.Head(client, node)
+		if err != nil || m.probe.Finalized == nil {
+			return err
+		}
+		height, err := m.probe.Finalized(client, node)
+		if err != nil {
+			return err
+		}
+		finalized[i] = &height
+		return nil
+	})
+
+	reorgs := make([]*Reorg, len(m.nodes))
+	m.forEachNode(func(i int, client ssh.Client, node ssh.Node) error {
+		prev := previous[i]
+		if errs[i] != nil || len(prev.Hash) == 0 {
+			return nil
+		}
+		if heads[i].Height < prev.Height {
+			reorgs[i] = &Reorg{Node: prev.Node, Height: prev.Height, Replaced: prev.Hash, Detected: now}
+			return nil
+		}
+		block, err := m.blockAt(client, node, heads[i], prev.Height)
+		if err != nil {
+			return err
+		}
+		if block.Hash != prev.Hash {
+			reorgs[i] = &Reorg{Node: prev.Node, Height: prev.Height, Replaced: prev.Hash, By: block.Hash, Detected: now}
+		}
+		return nil
+	})
+
+	common := int64(-1)
+	for i := range m.nodes {
+		if errs[i] == nil && (common == -1 || heads[i].Height < common) {
+			common = heads[i].Height
+		}
+	}
+	hashes := map[int]string{}
+	final := map[int]int64{}
+	if common >= 0 {
+		blocks := make([]helpers.Block, len(m.nodes))
+		blockErrs := m.forEachNode(func(i int, client ssh.Client, node ssh.Node) error {
+			if errs[i] != nil {
+				return errs[i]
+			}
+			var err error
+			blocks[i], err = m.blockAt(client, node, heads[i], common)
+			return err
+		})
+		for i, node := range m.nodes {
+			if blockErrs[i] == nil {
+				hashes[node.GetAbsoluteNumber()] = blocks[i].Hash
+			}
+			if finalized[i] != nil {
+				final[node.GetAbsoluteNumber()] = *finalized[i]
+			}
+		}
+	}
+	fork := detectFork(common, hashes, final)
+	if fork != nil {
+		fork.Base = m.findBase(fork)
+		fork.Depth = fork.Height - fork.Base
+	}
+	m.update(now, heads, finalized, errs, common, fork, reorgs)
+}
+
+// findBase finds the height of the last block all of the nodes in the fork agree on, with a binary search
+// between the last height they were found to agree at and the height of the fork. The genesis block is
+// assumed to be shared.
+func (m *monitor) findBase(fork *Fork) int64 {
+	byNumber := map[int]ssh.Node{}
+	for _, node := range m.nodes {
+		byNumber[node.GetAbsoluteNumber()] = node
+	}
+	//The nodes agree at a height if one node from each of the conflicting blocks agrees
+	nodes := []ssh.Node{}
+	for _, group := range fork.Hashes {
+		nodes = append(nodes, byNumber[group[0]])
+	}
+	agree := func(height int64) bool {
+		hash := ""
+		for _, node := range nodes {
+			client, err := m.getClient(node.GetServerID())
+			if err != nil {
+				return false
+			}
+			block, err := m.probe.BlockAt(client, node, height)
+			if err != nil || (len(hash) > 0 && block.Hash != hash) {
+				return false
+			}
+			hash = block.Hash
+		}
+		return true
+	}
+
+	m.mux.RLock()
+	low := m.agreed
+	m.mux.RUnlock()
+	if low >= fork.Height || (low > 0 && !agree(low)) {
+		low = 0
+	}
+	high := fork.Height
+	for high-low > 1 {
+		mid := low + (high-low)/2
+		if agree(mid) {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return low
+}
+
+// update updates the report with the latest probe of the nodes, then evaluates the alert rules and fires the
+// alerts which are new. fork is the fork found at the common height, if any.
+func (m *monitor) update(now time.Time, heads []helpers.Block, finalized []*int64, errs []error,
+	common int64, fork *Fork, reorgs []*Reorg) {
+
+	m.mux.Lock()
+	timeout := time.Duration(conf.ConsensusStallTimeout) * time.Second
+	report := &m.report
+	report.Sampled = now
+	for i := range m.nodes {
+		node := &report.Nodes[i]
+		node.Error = ""
+		if errs[i] != nil {
+			node.Error = errs[i].Error()
+		} else {
+			if heads[i].Height > node.Height {
+				node.Progressed = now
+			}
+			node.Height = heads[i].Height
+			node.Hash = heads[i].Hash
+			node.Finalized = finalized[i]
+			node.FinalityLag = nil
+			if finalized[i] != nil {
+				lag := heads[i].Height - *finalized[i]
+				node.FinalityLag = &lag
+			}
+		}
+		node.Stalled = now.Sub(node.Progressed) >= timeout
+	}
+
+	height := report.Height
+	for _, node := range report.Nodes {
+		if node.Height > height {
+			height = node.Height
+		}
+	}
+	if height > report.Height {
+		m.progressed = now
+		report.Height = height
+	}
+	live := now.Sub(m.progressed) < timeout
+	if !live && report.Live {
+		report.Stalls = append(report.Stalls, Stall{Started: m.progressed})
+	} else if live && !report.Live && len(report.Stalls) > 0 {
+		report.Stalls[len(report.Stalls)-1].Ended = &now
+	}
+	report.Live = live
+
+	for _, reorg := range reorgs {
+		if reorg != nil {
+			report.Reorgs = appendReorg(report.Reorgs, *reorg)
+			log.WithFields(log.Fields{"testnet": m.testnetID, "node": reorg.Node, "height": reorg.Height}).Warn(
+				"detected a reorg")
+		}
+	}
+	if fork == nil && common > m.agreed {
+		m.agreed = common
+	}
+	if fork != nil {
+		m.addFork(now, *fork)
+	}
+	alerts := m.evaluate(now)
+	for _, alert := range alerts {
+		report.Alerts = appendAlert(report.Alerts, alert)
+	}
+	m.mux.Unlock()
+
+	for _, alert := range alerts {
+		fire(m.testnetID, alert)
+	}
+}
+
+// addFork adds the fork to the report, replacing the fork with the same base if there is one
+func (m *monitor) addFork(now time.Time, fork Fork) {
+	report := &m.report
+	fork.Detected = now
+	for i := range report.Forks {
+		if report.Forks[i].Base == fork.Base {
+			fork.Detected = report.Forks[i].Detected
+			report.Forks[i] = fork
+			return
+		}
+	}
+	report.Forks = append(report.Forks, fork)
+	if len(report.Forks) > maxEvents {
+		report.Forks = report.Forks[len(report.Forks)-maxEvents:]
+	}
+	log.WithFields(log.Fields{"testnet": m.testnetID, "base": fork.Base, "hashes": fork.Hashes}).Warn(
+		"detected a fork")
+}
+
+func appendReorg(reorgs []Reorg, reorg Reorg) []Reorg {
+	out := append(reorgs, reorg)
+	if len(out) > maxEvents {
+		out = out[len(out)-maxEvents:]
+	}
+	return out
+}
+
+func appendAlert(alerts []Alert, alert Alert) []Alert {
+	out := append(alerts, alert)
+	if len(out) > maxEvents {
+		out = out[len(out)-maxEvents:]
+	}
+	return out
+}
+
+// detectFork checks whether the nodes have different blocks at the given height, returning the fork
+// if they do or nil otherwise. finalized are the heights of the latest finalized blocks of the nodes
+// which have finality.
+func detectFork(height int64, hashes map[int]string, finalized map[int]int64) *Fork {
+	byHash := map[string][]int{}
+	for node, hash := range hashes {
+		byHash[hash] = append(byHash[hash], node)
+	}
+	if len(byHash) < 2 {
+		return nil
+	}
+	for hash := range byHash {
+		sort.Ints(byHash[hash])
+	}
+	final := true
+	for node := range hashes {
+		if at, ok := finalized[node]; !ok || at < height {
+			final = false
+		}
+	}
+	return &Fork{Height: height, Hashes: byHash, Finalized: final}
+}
+
+func (m *monitor) run() {
+	ticker := time.NewTicker(time.Duration(conf.ConsensusProbeInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.sample()
+		}
+	}
+}
+
+func (m *monitor) get() Report {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	out := m.report
+	out.Nodes = append([]NodeStatus{}, m.report.Nodes...)
+	out.Forks = append([]Fork{}, m.report.Forks...)
+	out.Reorgs = append([]Reorg{}, m.report.Reorgs...)
+	out.Stalls = append([]Stall{}, m.report.Stalls...)
+	out.Alerts = append([]Alert{}, m.report.Alerts...)
+	return out
+}
+
+// Watch starts probing the chains of the nodes of the testnet every consensusProbeInterval seconds.
+// If the testnet is already being watched, it is restarted with its current nodes.
+// Returns an error if its blockchain does not have a consensus probe.
+func Watch(tn *testnet.TestNet) error {
+	cfg, err := tn.GetKubernetesConfig()
+	if err != nil {
+		return util.LogError(err)
+	}
+	if cfg.Enabled {
+		return fmt.Errorf("probing the consensus is not supported on kubernetes")
+	}
+	probe, err := registrar.GetConsensusProbe(tn.LDD.Blockchain)
+	if err != nil {
+		return fmt.Errorf("%s does not have a consensus probe", tn.LDD.Blockchain)
+	}
+	nodes := []ssh.Node{}
+	for _, node := range tn.Nodes {
+		nodes = append(nodes, node)
+	}
+	Unwatch(tn.TestNetID)
+	m := newMonitor(tn.TestNetID, tn.LDD.Blockchain, probe, nodes)
+	m.rules = GetRules(tn.TestNetID)
+	m.sample()
+	monitorMux.Lock()
+	monitors[tn.TestNetID] = m
+	monitorMux.Unlock()
+	go m.run()
+	log.WithFields(log.Fields{"testnet": tn.TestNetID, "nodes": len(nodes)}).Info("started probing the consensus")
+	return nil
+}
+
+// Watching checks whether the consensus of the testnet is being probed
+func Watching(testnetID string) bool {
+	monitorMux.Lock()
+	defer monitorMux.Unlock()
+	_, ok := monitors[testnetID]
+	return ok
+}
+
+// Unwatch stops probing the consensus of the testnet
+func Unwatch(testnetID string) {
+	monitorMux.Lock()
+	defer monitorMux.Unlock()
+	m, ok := monitors[testnetID]
+	if !ok {
+		return
+	}
+	close(m.stop)
+	delete(monitors, testnetID)
+}
+
+// GetReport gets the state of the consensus of the testnet, as of its latest probe
+func GetReport(testnetID string) (Report, error) {
+	monitorMux.Lock()
+	m, ok := monitors[testnetID]
+	monitorMux.Unlock()
+	if !ok {
+		return Report{}, fmt.Errorf("the consensus of testnet \"%s\" is not being probed", testnetID)
+	}
+	return m.get(), nil
+}
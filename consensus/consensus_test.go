@@ -0,0 +1,267 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package consensus
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/ssh"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeChain has a block at every height, with the same hash on every node unless it is overridden
+type fakeChain struct {
+	mux       sync.Mutex
+	heads     map[int]int64
+	overrides map[int]map[int64]string
+	final     map[int]int64
+	down      map[int]bool
+}
+
+func newFakeChain(heads map[int]int64) *fakeChain {
+	return &fakeChain{heads: heads, overrides: map[int]map[int64]string{}, final: map[int]int64{}, down: map[int]bool{}}
+}
+
+func (fc *fakeChain) hash(node int, height int64) string {
+	if hash, ok := fc.overrides[node][height]; ok {
+		return hash
+	}
+	return strconv.FormatInt(height, 10)
+}
+
+func (fc *fakeChain) probe(finality bool) helpers.ConsensusProbe {
+	out := helpers.ConsensusProbe{
+		Head: func(_ ssh.Client, node ssh.Node) (helpers.Block, error) {
+			fc.mux.Lock()
+			defer fc.mux.Unlock()
+			if fc.down[node.GetAbsoluteNumber()] {
+				return helpers.Block{}, fmt.Errorf("connection refused")
+			}
+			height := fc.heads[node.GetAbsoluteNumber()]
+			return helpers.Block{Height: height, Hash: fc.hash(node.GetAbsoluteNumber(), height)}, nil
+		},
+		BlockAt: func(_ ssh.Client, node ssh.Node, height int64) (helpers.Block, error) {
+			fc.mux.Lock()
+			defer fc.mux.Unlock()
+			return helpers.Block{Height: height, Hash: fc.hash(node.GetAbsoluteNumber(), height)}, nil
+		},
+	}
+	if finality {
+		out.Finalized = func(_ ssh.Client, node ssh.Node) (int64, error) {
+			fc.mux.Lock()
+			defer fc.mux.Unlock()
+			return fc.final[node.GetAbsoluteNumber()], nil
+		}
+	}
+	return out
+}
+
+func newTestMonitor(chain *fakeChain, finality bool, n int) *monitor {
+	nodes := []ssh.Node{}
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, db.Node{AbsoluteNum: i, Server: 1})
+	}
+	m := newMonitor("test", "test", chain.probe(finality), nodes)
+	m.getClient = func(int) (ssh.Client, error) { return nil, nil }
+	return m
+}
+
+func mockFire(t *testing.T) *[]Alert {
+	out := &[]Alert{}
+	original := fire
+	fire = func(_ string, alert Alert) {
+		*out = append(*out, alert)
+	}
+	t.Cleanup(func() { fire = original })
+	return out
+}
+
+func TestMonitor_sample(t *testing.T) {
+	fired := mockFire(t)
+	chain := newFakeChain(map[int]int64{0: 10, 1: 12, 2: 12})
+	m := newTestMonitor(chain, false, 3)
+	m.rules = []Rule{{Type: ForkRule, Depth: 3}, {Type: ReorgRule}}
+	m.sample()
+	report := m.get()
+	if report.Height != 12 || !report.Live || len(report.Forks) != 0 || len(report.Reorgs) != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.Nodes[1].Hash != "12" || report.Nodes[0].Height != 10 || report.Nodes[0].FinalityLag != nil {
+		t.Errorf("unexpected nodes %+v", report.Nodes)
+	}
+
+	chain.heads[0] = 12
+	chain.overrides[2] = map[int64]string{11: "x11", 12: "x12", 13: "x13"}
+	m.sample()
+	report = m.get()
+	if len(report.Forks) != 1 {
+		t.Fatalf("expected a fork, got %+v", report.Forks)
+	}
+	expected := map[string][]int{"12": {0, 1}, "x12": {2}}
+	fork := report.Forks[0]
+	if fork.Base != 10 || fork.Height != 12 || fork.Depth != 2 || !reflect.DeepEqual(fork.Hashes, expected) || fork.Finalized {
+		t.Errorf("unexpected fork %+v", fork)
+	}
+	if len(report.Reorgs) != 1 || report.Reorgs[0] != (Reorg{Node: 2, Height: 12, Replaced: "12", By: "x12",
+		Detected: report.Reorgs[0].Detected}) {
+		t.Errorf("expected node 2 to have reorged, got %+v", report.Reorgs)
+	}
+	if len(*fired) != 1 || (*fired)[0].Rule.Type != ReorgRule || *(*fired)[0].Node != 2 {
+		t.Errorf("expected only the reorg rule to fire, got %+v", *fired)
+	}
+
+	for node := range chain.heads {
+		chain.heads[node] = 13
+	}
+	m.sample()
+	report = m.get()
+	if len(report.Forks) != 1 || report.Forks[0].Depth != 3 || report.Forks[0].Detected != fork.Detected {
+		t.Errorf("expected the same fork to get deeper, got %+v", report.Forks)
+	}
+	if len(report.Reorgs) != 1 {
+		t.Errorf("expected no new reorg, got %+v", report.Reorgs)
+	}
+	if len(*fired) != 2 || (*fired)[1].Rule.Type != ForkRule || report.Alerts[1].Message != (*fired)[1].Message {
+		t.Errorf("expected the fork rule to fire, got %+v", *fired)
+	}
+
+	chain.down[0] = true
+	m.sample()
+	report = m.get()
+	if report.Nodes[0].Error == "" || report.Nodes[0].Height != 13 {
+		t.Errorf("expected the node to keep its last height along with the error, got %+v", report.Nodes[0])
+	}
+	if len(*fired) != 2 {
+		t.Errorf("expected the alerts to only fire once, got %+v", *fired)
+	}
+}
+
+func TestMonitor_update(t *testing.T) {
+	mockFire(t)
+	m := newTestMonitor(newFakeChain(nil), true, 2)
+	start := m.report.Started
+	timeout := time.Duration(conf.ConsensusStallTimeout) * time.Second
+	final := int64(4)
+	heads := []helpers.Block{{Height: 5}, {Height: 5}}
+	finalized := []*int64{&final, &final}
+	errs := []error{nil, nil}
+
+	m.update(start.Add(time.Second), heads, finalized, errs, 5, nil, make([]*Reorg, 2))
+	report := m.get()
+	if !report.Live || *report.Nodes[0].FinalityLag != 1 || report.Nodes[0].Stalled || m.agreed != 5 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	m.update(start.Add(time.Second+timeout), heads, finalized, errs, 5, nil, make([]*Reorg, 2))
+	report = m.get()
+	if report.Live || !report.Nodes[0].Stalled || len(report.Stalls) != 1 || report.Stalls[0].Ended != nil {
+		t.Errorf("expected the chain to be stalled, got %+v", report)
+	}
+	if !report.Stalls[0].Started.Equal(start.Add(time.Second)) {
+		t.Errorf("expected the stall to start at the last new block, got %v", report.Stalls[0].Started)
+	}
+
+	end := start.Add(2 * timeout)
+	m.update(end, []helpers.Block{{Height: 6}, {Height: 5}}, finalized, errs, 5, nil, make([]*Reorg, 2))
+	report = m.get()
+	if !report.Live || report.Nodes[0].Stalled || !report.Nodes[1].Stalled {
+		t.Errorf("expected only the second node to be stalled, got %+v", report)
+	}
+	if len(report.Stalls) != 1 || report.Stalls[0].Ended == nil || !report.Stalls[0].Ended.Equal(end) {
+		t.Errorf("expected the stall to end, got %+v", report.Stalls)
+	}
+}
+
+func TestMonitor_findBase(t *testing.T) {
+	var test = []struct {
+		agreed   int64
+		diverged int64
+		expected int64
+	}{
+		{agreed: 0, diverged: 1, expected: 0},
+		{agreed: 0, diverged: 57, expected: 56},
+		{agreed: 40, diverged: 57, expected: 56},
+		{agreed: 99, diverged: 57, expected: 56},
+		{agreed: 70, diverged: 57, expected: 56},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			chain := newFakeChain(map[int]int64{0: 100, 1: 100})
+			chain.overrides[1] = map[int64]string{}
+			for height := tt.diverged; height <= 100; height++ {
+				chain.overrides[1][height] = "x"
+			}
+			m := newTestMonitor(chain, false, 2)
+			m.agreed = tt.agreed
+			base := m.findBase(&Fork{Height: 100, Hashes: map[string][]int{"100": {0}, "x": {1}}})
+			if base != tt.expected {
+				t.Errorf("expected a base of %d, got %d", tt.expected, base)
+			}
+		})
+	}
+}
+
+func TestDetectFork(t *testing.T) {
+	var test = []struct {
+		hashes    map[int]string
+		finalized map[int]int64
+		expected  *Fork
+	}{
+		{
+			hashes:    map[int]string{0: "a", 1: "a"},
+			finalized: map[int]int64{},
+			expected:  nil,
+		},
+		{
+			hashes:    map[int]string{},
+			finalized: map[int]int64{},
+			expected:  nil,
+		},
+		{
+			hashes:    map[int]string{0: "a", 1: "b"},
+			finalized: map[int]int64{0: 5, 1: 5},
+			expected:  &Fork{Height: 4, Hashes: map[string][]int{"a": {0}, "b": {1}}, Finalized: true},
+		},
+		{
+			hashes:    map[int]string{0: "a", 1: "b"},
+			finalized: map[int]int64{0: 5, 1: 3},
+			expected:  &Fork{Height: 4, Hashes: map[string][]int{"a": {0}, "b": {1}}, Finalized: false},
+		},
+		{
+			hashes:    map[int]string{0: "a", 2: "b", 1: "a"},
+			finalized: map[int]int64{},
+			expected:  &Fork{Height: 4, Hashes: map[string][]int{"a": {0, 1}, "b": {2}}, Finalized: false},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			fork := detectFork(4, tt.hashes, tt.finalized)
+			if !reflect.DeepEqual(fork, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, fork)
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	_ "github.com/mattn/go-sqlite3" //Bring db in
+	"github.com/whiteblock/genesis/secrets"
 	"github.com/whiteblock/genesis/util"
 )
 
@@ -57,8 +58,12 @@ type DeploymentDetails struct {
 	*/
 	Resources []util.Resources `json:"resources"`
 	/*
-		Environments is the environment variables to be passed to each node.
-		If it doesn't exist for a node, it defaults first to index 0.
+		Env is the environment variables passed to all of the nodes
+	*/
+	Env map[string]string `json:"env,omitempty"`
+	/*
+		Environments is the environment variables to be passed to each node, by absolute number.
+		They are added to those of Env, replacing any with the same name.
 	*/
 	Environments []map[string]string `json:"environments"`
 	/*
@@ -74,8 +79,39 @@ type DeploymentDetails struct {
 		Fairly Arbitrary extras for when additional customizations are added.
 	*/
 	Extras map[string]interface{} `json:"extras"`
-	jwt    string
-	kid    string
+
+	/*
+		TTL is how long the testnet should live for, such as "24h". Once it expires, the
+		testnet will be torn down. If empty, the testnet will live until it is deleted.
+	*/
+	TTL string `json:"ttl,omitempty"`
+
+	/*
+		Seed is the seed all of the randomness of the testnet is derived from: the keys of the nodes and
+		accounts, the peering graphs, the times in the genesis files and the seeds given to the load of
+		scenarios. Rebuilding with the same details and seed gives the same keys and genesis files. If empty,
+		all of these are random and the genesis files are timestamped with the time of the build.
+	*/
+	Seed string `json:"seed,omitempty"`
+
+	/*
+		Timeouts override how long each stage of the build and each command run for it may take.
+		Once a stage goes over its timeout, the build fails.
+	*/
+	Timeouts util.Timeouts `json:"timeouts"`
+
+	/*
+		Hooks are the scripts to run in the nodes at stages of the build. Their outputs are stored as
+		artifacts of the testnet.
+	*/
+	Hooks []Hook `json:"hooks,omitempty"`
+
+	/*
+		Startup overrides the entrypoint, command and working directory of the containers of groups of nodes
+	*/
+	Startup []Startup `json:"startup,omitempty"`
+	jwt     string
+	kid     string
 }
 
 //SetJwt stores the callers jwt
@@ -97,9 +133,53 @@ func (dd DeploymentDetails) GetKid() string {
 	return dd.kid
 }
 
-//QueryBuilds fetches DeploymentDetails based on the given SQL select query
-func QueryBuilds(query string) ([]DeploymentDetails, error) {
-	rows, err := db.Query(query)
+// Redacted gets a copy of the deployment details with the sensitive values replaced, which are the
+// contents of the files, the hook scripts, the key seed and the docker credentials. Nothing is replaced unless
+// secrets mode is enabled.
+func (dd DeploymentDetails) Redacted() DeploymentDetails {
+	if !secrets.Enabled() {
+		return dd
+	}
+	out := dd
+	if len(out.Seed) > 0 {
+		out.Seed = secrets.Redacted
+	}
+	if dd.Hooks != nil {
+		out.Hooks = make([]Hook, len(dd.Hooks))
+		for i, hook := range dd.Hooks {
+			out.Hooks[i] = hook
+			out.Hooks[i].Script = secrets.Redacted
+		}
+	}
+	if dd.Files != nil {
+		out.Files = make([]map[string]string, len(dd.Files))
+		for i, files := range dd.Files {
+			out.Files[i] = map[string]string{}
+			for name := range files {
+				out.Files[i][name] = secrets.Redacted
+			}
+		}
+	}
+	prebuild, ok := dd.Extras["prebuild"].(map[string]interface{})
+	if _, hasAuth := prebuild["auth"]; ok && hasAuth {
+		out.Extras = map[string]interface{}{}
+		for key, value := range dd.Extras {
+			out.Extras[key] = value
+		}
+		redactedPrebuild := map[string]interface{}{}
+		for key, value := range prebuild {
+			redactedPrebuild[key] = value
+		}
+		redactedPrebuild["auth"] = secrets.Redacted
+		out.Extras["prebuild"] = redactedPrebuild
+	}
+	return out
+}
+
+// QueryBuilds fetches DeploymentDetails based on the given SQL select query, with the given arguments
+// for its placeholders
+func QueryBuilds(query string, args ...interface{}) ([]DeploymentDetails, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, util.LogError(err)
 	}
@@ -122,6 +202,13 @@ func QueryBuilds(query string) ([]DeploymentDetails, error) {
 			return nil, util.LogError(err)
 		}
 
+		for _, data := range []*[]byte{&files, &environment, &extras} {
+			*data, err = secrets.Decrypt(*data)
+			if err != nil {
+				return nil, util.LogError(err)
+			}
+		}
+
 		err = json.Unmarshal(servers, &build.Servers)
 		if err != nil {
 			return nil, util.LogError(err)
@@ -178,7 +265,7 @@ GetBuildByTestnet gets the build parameters based off testnet id
 */
 func GetBuildByTestnet(id string) (DeploymentDetails, error) {
 
-	details, err := QueryBuilds(fmt.Sprintf("SELECT testnet,servers,blockchain,nodes,image,params,resources,files,environment,logs,extras,kid FROM %s WHERE testnet = \"%s\"", BuildsTable, id))
+	details, err := QueryBuilds(fmt.Sprintf("SELECT testnet,servers,blockchain,nodes,image,params,resources,files,environment,logs,extras,kid FROM %s WHERE testnet = ?", BuildsTable), id)
 	if err != nil {
 		return DeploymentDetails{}, util.LogError(err)
 	}
@@ -193,7 +280,7 @@ func GetLastBuildByKid(kid string) (DeploymentDetails, error) {
 
 	details, err := QueryBuilds(fmt.Sprintf(
 		"SELECT testnet,servers,blockchain,nodes,image,params,resources,files,environment,logs,extras,kid FROM %s"+
-			" WHERE kid = \"%s\" ORDER BY id DESC LIMIT 1", BuildsTable, kid))
+			" WHERE kid = ? ORDER BY id DESC LIMIT 1", BuildsTable), kid)
 	if err != nil {
 		return DeploymentDetails{}, util.LogError(err)
 	}
@@ -232,6 +319,13 @@ func InsertBuild(dd DeploymentDetails, testnetID string) error {
 	if err != nil {
 		return util.LogError(err)
 	}
+	// the files, environment variables and extras are where credentials and keys are given
+	for _, data := range []*[]byte{&files, &environment, &extras} {
+		*data, err = secrets.Encrypt(*data)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
 
 	_, err = stmt.Exec(testnetID, string(servers), dd.Blockchain, dd.Nodes, string(images),
 		string(params), string(resources), string(files), string(environment), string(logs), string(extras), dd.kid)
@@ -241,3 +335,9 @@ func InsertBuild(dd DeploymentDetails, testnetID string) error {
 	}
 	return util.LogError(tx.Commit())
 }
+
+// DeleteBuildsByTestnet deletes all of the builds of the given testnet
+func DeleteBuildsByTestnet(id string) error {
+	_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE testnet = ?", BuildsTable), id)
+	return err
+}
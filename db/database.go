@@ -50,6 +50,14 @@ func init() {
 	}
 	db.SetMaxOpenConns(50)
 	checkAndUpdate()
+	err = createDeploymentsTable()
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Panic("unable to create the deployments table")
+	}
+	err = createTestNetsTable()
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Panic("unable to create the testnets table")
+	}
 }
 func getDB() (*sql.DB, error) {
 	dataLoc := conf.DataDirectory + "/.gdata"
@@ -77,16 +85,24 @@ func dbInit(dataLoc string) error {
 		return util.LogError(err)
 	}
 	log.Debug("initializing tables")
-	serverSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s);",
+	serverSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s,%s,%s, %s,%s,%s, %s,%s);",
 		ServerTable,
 		"id INTEGER PRIMARY KEY AUTOINCREMENT",
 		"server_id INTEGER",
 		"addr TEXT NOT NULL",
 		"nodes INTEGER DEFAULT 0",
 		"max INTEGER",
-		"name TEXT")
+		"name TEXT",
+		"runtime TEXT DEFAULT ''",
+		"arch TEXT DEFAULT ''",
+		"ssh_user TEXT DEFAULT ''",
+		"ssh_key TEXT DEFAULT ''",
+		"ssh_private_key TEXT DEFAULT ''",
+		"ssh_port INTEGER DEFAULT 0",
+		"staging_dir TEXT DEFAULT ''",
+		"extra_subnet_ids TEXT DEFAULT '[]'")
 
-	nodesSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s,%s,%s);",
+	nodesSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s,%s,%s, %s,%s,%s, %s,%s);",
 		NodesTable,
 		"id TEXT",
 		"abs_num INTEGER",
@@ -96,7 +112,12 @@ func dbInit(dataLoc string) error {
 		"ip TEXT NOT NULL",
 		"label TEXT",
 		"image TEXT",
-		"protocol TEXT")
+		"protocol TEXT",
+		"ports TEXT DEFAULT '[]'",
+		"sidecars TEXT DEFAULT '[]'",
+		"entrypoint TEXT DEFAULT ''",
+		"command TEXT DEFAULT '[]'",
+		"workdir TEXT DEFAULT ''")
 
 	buildSchema := fmt.Sprintf("CREATE TABLE %s (%s,%s,%s, %s,%s,%s, %s,%s,%s, %s,%s,%s, %s);",
 		BuildsTable,
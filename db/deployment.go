@@ -0,0 +1,138 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/secrets"
+	"github.com/whiteblock/genesis/util"
+	"time"
+)
+
+// DeploymentsTable contains the name of the deployments table, which holds every revision of
+// the deployment details of each testnet
+const DeploymentsTable = "deployments"
+
+// Deployment represents a single revision of the deployment details of a testnet
+type Deployment struct {
+	// Revision is the number of the deployment within the testnet, starting at 1
+	Revision int `json:"revision"`
+	// TestNetID is the id of the testnet which was deployed
+	TestNetID string `json:"testnet"`
+	// Time is when the deployment happened, as a unix timestamp
+	Time int64 `json:"time"`
+	// Details are the deployment details which were given for the deployment
+	Details DeploymentDetails `json:"details"`
+}
+
+func createDeploymentsTable() error {
+	_, err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s,%s,%s,%s,%s);",
+		DeploymentsTable,
+		"id INTEGER PRIMARY KEY AUTOINCREMENT",
+		"testnet TEXT",
+		"revision INTEGER",
+		"created INTEGER",
+		"details TEXT"))
+	return err
+}
+
+// InsertDeployment stores the given deployment details as the next revision of the given testnet
+func InsertDeployment(testnetID string, dd DeploymentDetails) (Deployment, error) {
+	out := Deployment{TestNetID: testnetID, Time: time.Now().Unix(), Details: dd}
+	details, err := json.Marshal(dd)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	details, err = secrets.Encrypt(details)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	row := tx.QueryRow(fmt.Sprintf("SELECT COALESCE(MAX(revision),0) FROM %s WHERE testnet = ?", DeploymentsTable),
+		testnetID)
+	err = row.Scan(&out.Revision)
+	if err != nil {
+		tx.Rollback()
+		return out, util.LogError(err)
+	}
+	out.Revision++
+
+	_, err = tx.Exec(fmt.Sprintf("INSERT INTO %s (testnet,revision,created,details) VALUES (?,?,?,?)",
+		DeploymentsTable), testnetID, out.Revision, out.Time, string(details))
+	if err != nil {
+		tx.Rollback()
+		return out, util.LogError(err)
+	}
+	return out, util.LogError(tx.Commit())
+}
+
+// GetDeployments gets all of the deployments of the given testnet, ordered by revision
+func GetDeployments(testnetID string) ([]Deployment, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT testnet,revision,created,details FROM %s WHERE testnet = ? ORDER BY revision",
+		DeploymentsTable), testnetID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	defer rows.Close()
+
+	deployments := []Deployment{}
+	for rows.Next() {
+		var deployment Deployment
+		var details []byte
+		err = rows.Scan(&deployment.TestNetID, &deployment.Revision, &deployment.Time, &details)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		details, err = secrets.Decrypt(details)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		err = json.Unmarshal(details, &deployment.Details)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		deployments = append(deployments, deployment)
+	}
+	return deployments, nil
+}
+
+// GetDeployment gets the given revision of the deployments of the given testnet
+func GetDeployment(testnetID string, revision int) (Deployment, error) {
+	deployments, err := GetDeployments(testnetID)
+	if err != nil {
+		return Deployment{}, util.LogError(err)
+	}
+	for _, deployment := range deployments {
+		if deployment.Revision == revision {
+			return deployment, nil
+		}
+	}
+	return Deployment{}, fmt.Errorf("revision %d not found for testnet \"%s\"", revision, testnetID)
+}
+
+// DeleteDeploymentsByTestNet deletes all of the deployments of the given testnet
+func DeleteDeploymentsByTestNet(testnetID string) error {
+	_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE testnet = ?", DeploymentsTable), testnetID)
+	return err
+}
@@ -0,0 +1,79 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// BeforeInitHook is the stage of the hooks which are run once the nodes are created, before the
+	// blockchain is initialized on them
+	BeforeInitHook = "beforeInit"
+	// AfterGenesisHook is the stage of the hooks which are run once the genesis file has been distributed
+	// to the nodes, before the blockchain is started. Only the blockchains whose genesis file can be
+	// overridden have this stage.
+	AfterGenesisHook = "afterGenesis"
+	// AfterStartHook is the stage of the hooks which are run once the blockchain has been started and
+	// the nodes are healthy
+	AfterStartHook = "afterStart"
+)
+
+// Hook is a script given by the user which is run in the nodes at a stage of the build
+type Hook struct {
+	// Stage is the stage of the build the script is run at
+	Stage string `json:"stage"`
+	// Script is the shell script to run
+	Script string `json:"script"`
+	// Nodes are the absolute numbers of the nodes to run the script in, all of the nodes if empty
+	Nodes []int `json:"nodes,omitempty"`
+}
+
+// Validate ensures that the hook is run at a known stage, in nodes which exist among the given number of nodes
+func (hook Hook) Validate(nodes int) error {
+	switch hook.Stage {
+	case BeforeInitHook, AfterGenesisHook, AfterStartHook:
+	default:
+		return fmt.Errorf("unknown hook stage \"%s\", must be one of %s", hook.Stage,
+			strings.Join([]string{BeforeInitHook, AfterGenesisHook, AfterStartHook}, ", "))
+	}
+	if len(strings.TrimSpace(hook.Script)) == 0 {
+		return fmt.Errorf("the hook script cannot be empty")
+	}
+	for _, node := range hook.Nodes {
+		if node < 0 || node >= nodes {
+			return fmt.Errorf("the node %d does not exist with %d nodes", node, nodes)
+		}
+	}
+	return nil
+}
+
+// RunsOn checks if the hook is run in the node with the given absolute number
+func (hook Hook) RunsOn(node int) bool {
+	if len(hook.Nodes) == 0 {
+		return true
+	}
+	for _, num := range hook.Nodes {
+		if num == node {
+			return true
+		}
+	}
+	return false
+}
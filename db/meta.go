@@ -21,16 +21,24 @@ package db
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/whiteblock/genesis/secrets"
 	"github.com/whiteblock/genesis/util"
 )
 
-//SetMeta stores a key value pair in the sql-lite database as json
+// SetMeta stores a key value pair in the sql-lite database as json, replacing
+// any value previously stored at key. The value is encrypted if secrets mode is enabled.
 func SetMeta(key string, value interface{}) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return util.LogError(err)
 	}
 
+	_, err = tx.Exec("DELETE FROM meta WHERE key = ?", key)
+	if err != nil {
+		tx.Rollback()
+		return util.LogError(err)
+	}
+
 	stmt, err := tx.Prepare("INSERT INTO meta (key,value) VALUES (?,?)")
 
 	if err != nil {
@@ -43,6 +51,10 @@ func SetMeta(key string, value interface{}) error {
 	if err != nil {
 		return util.LogError(err)
 	}
+	v, err = secrets.Encrypt(v)
+	if err != nil {
+		return util.LogError(err)
+	}
 
 	_, err = stmt.Exec(key, string(v))
 	if err != nil {
@@ -59,6 +71,10 @@ func GetMeta(key string) (interface{}, error) {
 	if err != nil {
 		return nil, util.LogError(err)
 	}
+	data, err = secrets.Decrypt(data)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
 	var out interface{}
 	return out, util.LogError(json.Unmarshal(data, &out))
 }
@@ -71,6 +87,10 @@ func GetMetaP(key string, v interface{}) error {
 	if err != nil {
 		return util.LogError(err)
 	}
+	data, err = secrets.Decrypt(data)
+	if err != nil {
+		return util.LogError(err)
+	}
 	return util.LogError(json.Unmarshal(data, &v))
 }
 
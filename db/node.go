@@ -20,6 +20,7 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	_ "github.com/mattn/go-sqlite3" //Include sqlite as the db
 	"github.com/whiteblock/genesis/util"
@@ -53,8 +54,28 @@ type Node struct {
 
 	// Protocol is the protocol type of this node
 	Protocol string `json:"protocol"`
+
+	// Ports are the ports of the node which are mapped to ports on its server, in the form
+	// taken by docker run -p, only given when port forwarding is enabled
+	Ports []string `json:"ports"`
+
+	// SideCars are the types of the side cars which were built alongside the node
+	SideCars []string `json:"sidecars"`
+
+	// Entrypoint is the entrypoint the container of the node was started with, if it was overridden
+	Entrypoint string `json:"entrypoint,omitempty"`
+
+	// Command are the arguments given to the entrypoint of the container of the node
+	Command []string `json:"command,omitempty"`
+
+	// WorkDir is the working directory of the container of the node, if it was overridden
+	WorkDir string `json:"workdir,omitempty"`
 }
 
+// nodeColumns are the columns of the nodes table, in the order they are scanned in
+const nodeColumns = "id,test_net,server,local_id,ip,label,abs_num,image,protocol,ports,sidecars," +
+	"entrypoint,command,workdir"
+
 // GetID gets the id of this side car
 func (n Node) GetID() string {
 	return n.ID
@@ -87,7 +108,7 @@ func (n Node) GetTestNetID() string {
 
 // GetNodeName gets the whiteblock name of this node
 func (n Node) GetNodeName() string {
-	return fmt.Sprintf("%s%d", conf.NodePrefix, n.AbsoluteNum)
+	return util.GetNodeName(n.TestNetID, n.LocalID)
 }
 
 func getNodesByQuery(query string) ([]Node, error) {
@@ -100,8 +121,27 @@ func getNodesByQuery(query string) ([]Node, error) {
 	nodes := []Node{}
 	for rows.Next() {
 		var node Node
+		var ports []byte
+		var sidecars []byte
+		var command []byte
 		err := rows.Scan(&node.ID, &node.TestNetID, &node.Server, &node.LocalID, &node.IP,
-			&node.Label, &node.AbsoluteNum, &node.Image, &node.Protocol)
+			&node.Label, &node.AbsoluteNum, &node.Image, &node.Protocol, &ports, &sidecars,
+			&node.Entrypoint, &command, &node.WorkDir)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+
+		err = json.Unmarshal(command, &node.Command)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+
+		err = json.Unmarshal(ports, &node.Ports)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+
+		err = json.Unmarshal(sidecars, &node.SideCars)
 		if err != nil {
 			return nil, util.LogError(err)
 		}
@@ -112,26 +152,22 @@ func getNodesByQuery(query string) ([]Node, error) {
 
 // GetAllNodesByServer gets all nodes that have ever existed on a server
 func GetAllNodesByServer(serverID int) ([]Node, error) {
-	return getNodesByQuery(fmt.Sprintf("SELECT id,test_net,server,local_id,ip,label,abs_num,image,protocol"+
-		" FROM %s WHERE server = %d", NodesTable, serverID))
+	return getNodesByQuery(fmt.Sprintf("SELECT %s FROM %s WHERE server = %d", nodeColumns, NodesTable, serverID))
 }
 
 // GetAllNodesByTestNet gets all the nodes which are in the given testnet
 func GetAllNodesByTestNet(testID string) ([]Node, error) {
-	return getNodesByQuery(fmt.Sprintf("SELECT id,test_net,server,local_id,ip,label,abs_num,image,protocol"+
-		" FROM %s WHERE test_net = \"%s\"", NodesTable, testID))
+	return getNodesByQuery(fmt.Sprintf("SELECT %s FROM %s WHERE test_net = \"%s\"", nodeColumns, NodesTable, testID))
 }
 
 // GetAllNodes gets every node that has ever existed.
 func GetAllNodes() ([]Node, error) {
-	return getNodesByQuery(fmt.Sprintf("SELECT id,test_net,server,local_id,ip,label,abs_num,image,protocol"+
-		" FROM %s", NodesTable))
+	return getNodesByQuery(fmt.Sprintf("SELECT %s FROM %s", nodeColumns, NodesTable))
 }
 
 // GetNode fetches a node by id
 func GetNode(id string) (Node, error) {
-	nodes, err := getNodesByQuery(fmt.Sprintf("SELECT id,test_net,server,local_id,ip,label,abs_num,image,protocol"+
-		" FROM %s WHERE id = %s", NodesTable, id))
+	nodes, err := getNodesByQuery(fmt.Sprintf("SELECT %s FROM %s WHERE id = %s", nodeColumns, NodesTable, id))
 
 	if len(nodes) == 0 || err == sql.ErrNoRows {
 		return Node{}, fmt.Errorf("node %s not found", id)
@@ -147,8 +183,8 @@ func InsertNode(node Node) (int, error) {
 		return -1, util.LogError(err)
 	}
 
-	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (id,test_net,server,local_id,ip,label,abs_num,image,protocol) "+
-		" VALUES (?,?,?,?,?,?,?,?,?)", NodesTable))
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)", NodesTable,
+		nodeColumns))
 
 	if err != nil {
 		return -1, util.LogError(err)
@@ -156,8 +192,12 @@ func InsertNode(node Node) (int, error) {
 
 	defer stmt.Close()
 
+	ports, _ := json.Marshal(node.Ports)
+	sidecars, _ := json.Marshal(node.SideCars)
+	command, _ := json.Marshal(node.Command)
 	res, err := stmt.Exec(node.ID, node.TestNetID, node.Server, node.LocalID, node.IP, node.Label,
-		node.AbsoluteNum, node.Image, node.Protocol)
+		node.AbsoluteNum, node.Image, node.Protocol, string(ports), string(sidecars), node.Entrypoint,
+		string(command), node.WorkDir)
 	if err != nil {
 		return -1, nil
 	}
@@ -167,6 +207,18 @@ func InsertNode(node Node) (int, error) {
 	return int(id), util.LogError(err)
 }
 
+// UpdateNodeImage sets the image of the node with the given id
+func UpdateNodeImage(id string, image string) error {
+	_, err := db.Exec(fmt.Sprintf("UPDATE %s SET image = ? WHERE id = ?", NodesTable), image, id)
+	return err
+}
+
+// DeleteNodesByTestNet deletes all of the nodes of the given testnet
+func DeleteNodesByTestNet(testID string) error {
+	_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE test_net = \"%s\"", NodesTable, testID))
+	return err
+}
+
 /**Helper functions which do not query the database**/
 
 // GetNodeByLocalID looks up a node by its localID
@@ -19,10 +19,13 @@
 package db
 
 import (
+	"encoding/json"
 	"fmt"
 	_ "github.com/mattn/go-sqlite3" //sqlite
+	"github.com/whiteblock/genesis/secrets"
 	"github.com/whiteblock/genesis/util"
 	"regexp"
+	"strings"
 )
 
 // Server represents a server on which genesis can build
@@ -37,6 +40,41 @@ type Server struct {
 	ID int `json:"id"`
 	// SubnetID is the number used in the IP scheme for nodes on this server
 	SubnetID int `json:"subnetID"`
+	// ExtraSubnetIDs are further numbers of the IP scheme given to this server, whose ip blocks are
+	// used for its nodes once the block of SubnetID is full
+	ExtraSubnetIDs []int `json:"extraSubnetIDs,omitempty"`
+	// Runtime is the container runtime of the server, defaults to containerRuntime
+	Runtime string `json:"runtime"`
+	// Arch is the cpu architecture of the server, as docker names it. It is detected
+	// during the first build on the server if not given.
+	Arch string `json:"arch"`
+	// SSHUser is the user to ssh into the server as, defaults to sshUser
+	SSHUser string `json:"sshUser"`
+	// SSHKey is the location of the private key to ssh into the server with, defaults to sshKey
+	SSHKey string `json:"sshKey"`
+	// SSHPrivateKey is the contents of the private key to ssh into the server with, it
+	// takes the place of SSHKey and is encrypted at rest in secrets mode
+	SSHPrivateKey string `json:"sshPrivateKey,omitempty"`
+	// SSHPort is the port of the ssh server, defaults to 22
+	SSHPort int `json:"sshPort"`
+	// StagingDir is the directory under which files are staged on the server on their way into
+	// the nodes, defaults to stagingDir
+	StagingDir string `json:"stagingDir"`
+}
+
+// Redacted gets a copy of the server with the private key replaced, so that it can be
+// given out by the api
+func (s Server) Redacted() Server {
+	if len(s.SSHPrivateKey) > 0 {
+		s.SSHPrivateKey = secrets.Redacted
+	}
+	return s
+}
+
+// Subnets gets all of the numbers of the IP scheme given to the server, in the order their ip blocks
+// are filled with nodes
+func (s Server) Subnets() []int {
+	return append([]int{s.SubnetID}, s.ExtraSubnetIDs...)
 }
 
 // Validate ensures that the  server object contains valid data
@@ -54,13 +92,34 @@ func (s Server) Validate() error {
 	if s.SubnetID < 1 {
 		return fmt.Errorf("invalid SubnetID")
 	}
-	return nil
+	seen := map[int]bool{s.SubnetID: true}
+	for _, id := range s.ExtraSubnetIDs {
+		if id < 1 || seen[id] {
+			return fmt.Errorf("invalid extraSubnetIDs")
+		}
+		seen[id] = true
+	}
+	if s.Max > util.NetworksPerSubnet()*len(s.Subnets()) {
+		return fmt.Errorf("max is more than the %d nodes which fit in the ip blocks of the server, give it extraSubnetIDs",
+			util.NetworksPerSubnet()*len(s.Subnets()))
+	}
+	if s.SSHPort < 0 || s.SSHPort > 65535 {
+		return fmt.Errorf("invalid sshPort")
+	}
+	if len(s.SSHKey) > 0 && len(s.SSHPrivateKey) > 0 {
+		return fmt.Errorf("only one of sshKey and sshPrivateKey may be given")
+	}
+	if len(s.StagingDir) > 0 && !strings.HasPrefix(s.StagingDir, "/") {
+		return fmt.Errorf("stagingDir must be an absolute path")
+	}
+	_, err := util.GetRuntime(s.Runtime)
+	return err
 }
 
 // GetAllServers gets all of the servers, indexed by name
 func GetAllServers() (map[string]Server, error) {
 
-	rows, err := db.Query(fmt.Sprintf("SELECT id,server_id,addr,nodes,max,name FROM %s", ServerTable))
+	rows, err := db.Query(fmt.Sprintf("SELECT id,server_id,addr,nodes,max,name,runtime,arch,ssh_user,ssh_key,ssh_private_key,ssh_port,staging_dir,extra_subnet_ids FROM %s", ServerTable))
 	if err != nil {
 		return nil, err
 	}
@@ -69,8 +128,19 @@ func GetAllServers() (map[string]Server, error) {
 	for rows.Next() {
 		var name string
 		var server Server
+		var extraSubnetIDs []byte
 		err := rows.Scan(&server.ID, &server.SubnetID, &server.Addr,
-			&server.Nodes, &server.Max, &name)
+			&server.Nodes, &server.Max, &name, &server.Runtime, &server.Arch,
+			&server.SSHUser, &server.SSHKey, &server.SSHPrivateKey, &server.SSHPort, &server.StagingDir,
+			&extraSubnetIDs)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		err = json.Unmarshal(extraSubnetIDs, &server.ExtraSubnetIDs)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		server.SSHPrivateKey, err = decryptPrivateKey(server.SSHPrivateKey)
 		if err != nil {
 			return nil, util.LogError(err)
 		}
@@ -98,7 +168,7 @@ func GetServer(id int) (Server, string, error) {
 	var name string
 	var server Server
 
-	rows, err := db.Query(fmt.Sprintf("SELECT id,server_id,addr,nodes,max,name FROM %s WHERE id = %d",
+	rows, err := db.Query(fmt.Sprintf("SELECT id,server_id,addr,nodes,max,name,runtime,arch,ssh_user,ssh_key,ssh_private_key,ssh_port,staging_dir,extra_subnet_ids FROM %s WHERE id = %d",
 		ServerTable, id))
 	if err != nil {
 		return server, name, util.LogError(err)
@@ -108,8 +178,19 @@ func GetServer(id int) (Server, string, error) {
 		return server, name, fmt.Errorf("not found")
 	}
 	defer rows.Close()
+	var extraSubnetIDs []byte
 	err = rows.Scan(&server.ID, &server.SubnetID, &server.Addr,
-		&server.Nodes, &server.Max, &name)
+		&server.Nodes, &server.Max, &name, &server.Runtime, &server.Arch,
+		&server.SSHUser, &server.SSHKey, &server.SSHPrivateKey, &server.SSHPort, &server.StagingDir,
+		&extraSubnetIDs)
+	if err != nil {
+		return server, name, util.LogError(err)
+	}
+	err = json.Unmarshal(extraSubnetIDs, &server.ExtraSubnetIDs)
+	if err != nil {
+		return server, name, util.LogError(err)
+	}
+	server.SSHPrivateKey, err = decryptPrivateKey(server.SSHPrivateKey)
 	if err != nil {
 		return server, name, util.LogError(err)
 	}
@@ -125,15 +206,23 @@ func InsertServer(name string, server Server) (int, error) {
 		return -1, util.LogError(err)
 	}
 
-	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (addr,server_id,nodes,max,name) VALUES (?,?,?,?,?)", ServerTable))
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (addr,server_id,nodes,max,name,runtime,arch,"+
+		"ssh_user,ssh_key,ssh_private_key,ssh_port,staging_dir,extra_subnet_ids) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)", ServerTable))
 	if err != nil {
 		return -1, util.LogError(err)
 	}
 
 	defer stmt.Close()
 
+	privateKey, err := encryptPrivateKey(server.SSHPrivateKey)
+	if err != nil {
+		return -1, util.LogError(err)
+	}
+
+	extraSubnetIDs, _ := json.Marshal(server.ExtraSubnetIDs)
 	res, err := stmt.Exec(server.Addr, server.SubnetID,
-		server.Nodes, server.Max, name)
+		server.Nodes, server.Max, name, server.Runtime, server.Arch,
+		server.SSHUser, server.SSHKey, privateKey, server.SSHPort, server.StagingDir, string(extraSubnetIDs))
 	if err != nil {
 		return -1, util.LogError(err)
 	}
@@ -157,16 +246,32 @@ func UpdateServer(id int, server Server) error {
 		return util.LogError(err)
 	}
 
-	stmt, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET server_id = ?,addr = ?, nodes = ?, max = ? WHERE id = ? ", ServerTable))
+	stmt, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET server_id = ?,addr = ?, nodes = ?, max = ?, runtime = ?, arch = ?, "+
+		"ssh_user = ?, ssh_key = ?, ssh_private_key = ?, ssh_port = ?, staging_dir = ?, "+
+		"extra_subnet_ids = ? WHERE id = ? ", ServerTable))
 	if err != nil {
 		return util.LogError(err)
 	}
 	defer stmt.Close()
 
+	privateKey, err := encryptPrivateKey(server.SSHPrivateKey)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	extraSubnetIDs, _ := json.Marshal(server.ExtraSubnetIDs)
 	_, err = stmt.Exec(server.SubnetID,
 		server.Addr,
 		server.Nodes,
 		server.Max,
+		server.Runtime,
+		server.Arch,
+		server.SSHUser,
+		server.SSHKey,
+		privateKey,
+		server.SSHPort,
+		server.StagingDir,
+		string(extraSubnetIDs),
 		server.ID)
 	if err != nil {
 		return util.LogError(err)
@@ -197,6 +302,29 @@ func UpdateServerNodes(id int, nodes int) error {
 
 }
 
+// UpdateServerArch records the cpu architecture of a server
+func UpdateServerArch(id int, arch string) error {
+
+	tx, err := db.Begin()
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	stmt, err := tx.Prepare(fmt.Sprintf("UPDATE %s SET arch = ? WHERE id = ?", ServerTable))
+
+	if err != nil {
+		return util.LogError(err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(arch, id)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return util.LogError(tx.Commit())
+
+}
+
 //GetHostIPsByTestNet gets the ips of the hosts for a testnet
 func GetHostIPsByTestNet(id int) ([]string, error) {
 
@@ -228,3 +356,16 @@ func GetHostIPsByTestNet(id int) ([]string, error) {
 	}
 	return ips, nil
 }
+
+func encryptPrivateKey(key string) (string, error) {
+	if len(key) == 0 {
+		return key, nil
+	}
+	data, err := secrets.Encrypt([]byte(key))
+	return string(data), err
+}
+
+func decryptPrivateKey(key string) (string, error) {
+	data, err := secrets.Decrypt([]byte(key))
+	return string(data), err
+}
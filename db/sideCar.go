@@ -19,7 +19,7 @@
 package db
 
 import (
-	"fmt"
+	"github.com/whiteblock/genesis/util"
 )
 
 // SideCar represents a supporting node within the network
@@ -83,5 +83,5 @@ func (n SideCar) GetTestNetID() string {
 
 // GetNodeName gets the whiteblock name of this side car
 func (n SideCar) GetNodeName() string {
-	return fmt.Sprintf("%s%d-%d", conf.NodePrefix, n.AbsoluteNodeNum, n.NetworkIndex)
+	return util.GetSideCarName(n.TestnetID, n.LocalID, n.NetworkIndex)
 }
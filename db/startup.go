@@ -0,0 +1,114 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package db
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"path"
+)
+
+// Startup overrides how the containers of a group of nodes are started, for images which need a wrapper
+// around their startup
+type Startup struct {
+	// Nodes are the absolute numbers of the nodes in the group. If empty, the group holds all of the
+	// nodes which are not in another group.
+	Nodes []int `json:"nodes,omitempty"`
+	// Entrypoint is the entrypoint of the containers, defaults to /bin/sh
+	Entrypoint string `json:"entrypoint,omitempty"`
+	// Command are the arguments given to the entrypoint
+	Command []string `json:"command,omitempty"`
+	// WorkDir is the working directory of the containers, and of the commands run in them
+	WorkDir string `json:"workdir,omitempty"`
+}
+
+// Validate ensures that the startup overrides something, with values which can be given to docker, for
+// nodes which exist among the given number of nodes
+func (startup Startup) Validate(nodes int) error {
+	if len(startup.Entrypoint) == 0 && len(startup.Command) == 0 && len(startup.WorkDir) == 0 {
+		return fmt.Errorf("the startup must override the entrypoint, the command or the workdir")
+	}
+	for _, node := range startup.Nodes {
+		if node < 0 || node >= nodes {
+			return fmt.Errorf("the node %d does not exist with %d nodes", node, nodes)
+		}
+	}
+	err := util.ValidateNormalASCII(startup.Entrypoint)
+	if err != nil {
+		return fmt.Errorf("invalid entrypoint: %s", err.Error())
+	}
+	for _, arg := range startup.Command {
+		err = util.ValidateNormalASCII(arg)
+		if err != nil {
+			return fmt.Errorf("invalid command: %s", err.Error())
+		}
+	}
+	if len(startup.WorkDir) > 0 {
+		err = util.ValidateFilePath(startup.WorkDir)
+		if err != nil {
+			return fmt.Errorf("invalid workdir: %s", err.Error())
+		}
+		if !path.IsAbs(startup.WorkDir) {
+			return fmt.Errorf("the workdir \"%s\" must be an absolute path", startup.WorkDir)
+		}
+	}
+	return nil
+}
+
+// ValidateStartups ensures that each of the given startups is valid, and that no node is in more than one group
+func ValidateStartups(startups []Startup, nodes int) error {
+	groups := map[int]int{}
+	hasDefault := false
+	for i, startup := range startups {
+		err := startup.Validate(nodes)
+		if err != nil {
+			return fmt.Errorf("%s. For startup %d", err.Error(), i)
+		}
+		if len(startup.Nodes) == 0 {
+			if hasDefault {
+				return fmt.Errorf("only one startup can be without nodes. For startup %d", i)
+			}
+			hasDefault = true
+		}
+		for _, node := range startup.Nodes {
+			if group, ok := groups[node]; ok {
+				return fmt.Errorf("the node %d is already in startup %d. For startup %d", node, group, i)
+			}
+			groups[node] = i
+		}
+	}
+	return nil
+}
+
+// GetStartup gets the startup of the node with the given absolute number, out of the given startups. It is
+// the zero value if the node is not in any group.
+func GetStartup(startups []Startup, absNum int) Startup {
+	out := Startup{}
+	for _, startup := range startups {
+		if len(startup.Nodes) == 0 {
+			out = startup
+		}
+		for _, node := range startup.Nodes {
+			if node == absNum {
+				return startup
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package db
+
+// Store is the part of the database which is used while building a testnet, so that
+// it can be replaced by a mock in tests
+type Store interface {
+	// GetServers gets servers from their ids
+	GetServers(ids []int) ([]Server, error)
+	// UpdateServerArch sets the cpu architecture of a server
+	UpdateServerArch(id int, arch string) error
+	// InsertNode inserts a node into the database
+	InsertNode(node Node) (int, error)
+	// GetAllNodesByServer gets all nodes that have ever existed on a server
+	GetAllNodesByServer(serverID int) ([]Node, error)
+	// SetMeta stores a value at key
+	SetMeta(key string, value interface{}) error
+	// GetMetaP fetches the value of key and returns it to v, v should be a pointer
+	GetMetaP(key string, v interface{}) error
+	// DeleteMeta deletes the value stored at key
+	DeleteMeta(key string) error
+}
+
+type sqlStore struct{}
+
+// NewStore gets the Store backed by the genesis database
+func NewStore() Store {
+	return sqlStore{}
+}
+
+func (sqlStore) GetServers(ids []int) ([]Server, error) {
+	return GetServers(ids)
+}
+
+func (sqlStore) UpdateServerArch(id int, arch string) error {
+	return UpdateServerArch(id, arch)
+}
+
+func (sqlStore) InsertNode(node Node) (int, error) {
+	return InsertNode(node)
+}
+
+func (sqlStore) GetAllNodesByServer(serverID int) ([]Node, error) {
+	return GetAllNodesByServer(serverID)
+}
+
+func (sqlStore) SetMeta(key string, value interface{}) error {
+	return SetMeta(key, value)
+}
+
+func (sqlStore) GetMetaP(key string, v interface{}) error {
+	return GetMetaP(key, v)
+}
+
+func (sqlStore) DeleteMeta(key string) error {
+	return DeleteMeta(key)
+}
@@ -0,0 +1,199 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package db
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"strings"
+	"time"
+)
+
+// TestNetsTable contains the name of the testnets table, which holds a summary of each testnet
+// which has been built, so that they can be listed without restoring them
+const TestNetsTable = "testnets"
+
+const (
+	// TestNetBuilding is the status of a testnet which is still being built
+	TestNetBuilding = "building"
+	// TestNetRunning is the status of a testnet which was built successfully
+	TestNetRunning = "running"
+	// TestNetFailed is the status of a testnet whose build failed
+	TestNetFailed = "failed"
+	// TestNetDeleted is the status of a testnet which has been deleted
+	TestNetDeleted = "deleted"
+)
+
+// TestNetStatuses are all of the statuses a testnet may have
+var TestNetStatuses = []string{TestNetBuilding, TestNetRunning, TestNetFailed, TestNetDeleted}
+
+// TestNet is the summary of a testnet
+type TestNet struct {
+	// ID is the id of the testnet
+	ID string `json:"id"`
+	// Blockchain is the blockchain the testnet was built with
+	Blockchain string `json:"blockchain"`
+	// Nodes is the number of nodes in the testnet
+	Nodes int `json:"nodes"`
+	// Owner is the kid of the jwt of the creator of the testnet
+	Owner string `json:"owner"`
+	// Status is where the testnet is in its lifecycle, one of TestNetStatuses
+	Status string `json:"status"`
+	// Created is when the build of the testnet was started, as a unix timestamp
+	Created int64 `json:"created"`
+	// Updated is when the testnet was last changed, as a unix timestamp
+	Updated int64 `json:"updated"`
+}
+
+// TestNetFilter selects which testnets are listed by ListTestNets. Empty fields match every testnet.
+type TestNetFilter struct {
+	Blockchain string
+	Status     string
+	Owner      string
+	// CreatedAfter only matches the testnets created after the given unix timestamp
+	CreatedAfter int64
+	// Limit is the most testnets to give, 0 for no limit
+	Limit int
+	// Offset is the number of matching testnets to skip
+	Offset int
+}
+
+func createTestNetsTable() error {
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s,%s,%s,%s,%s,%s,%s);",
+			TestNetsTable,
+			"id TEXT PRIMARY KEY",
+			"blockchain TEXT",
+			"nodes INTEGER",
+			"owner TEXT DEFAULT ''",
+			"status TEXT",
+			"created INTEGER",
+			"updated INTEGER"),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS testnets_created ON %s (created)", TestNetsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS testnets_blockchain ON %s (blockchain, created)", TestNetsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS testnets_status ON %s (status, created)", TestNetsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS testnets_owner ON %s (owner, created)", TestNetsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS builds_testnet ON %s (testnet)", BuildsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS builds_kid ON %s (kid)", BuildsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS deployments_testnet ON %s (testnet, revision)", DeploymentsTable),
+		// testnets built before this table existed are listed from their builds
+		fmt.Sprintf("INSERT INTO %s (id,blockchain,nodes,owner,status,created,updated)"+
+			" SELECT b.testnet, b.blockchain, b.nodes, COALESCE(b.kid,''), '%s', COALESCE(MIN(d.created),0), COALESCE(MAX(d.created),0)"+
+			" FROM %s b LEFT JOIN %s d ON d.testnet = b.testnet"+
+			" WHERE b.testnet NOT IN (SELECT id FROM %s) GROUP BY b.testnet",
+			TestNetsTable, TestNetRunning, BuildsTable, DeploymentsTable, TestNetsTable),
+	}
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertTestNet records a new testnet, whose build has just been started
+func InsertTestNet(testnetID string, dd DeploymentDetails) error {
+	now := time.Now().Unix()
+	_, err := db.Exec(fmt.Sprintf("INSERT OR REPLACE INTO %s (id,blockchain,nodes,owner,status,created,updated)"+
+		" VALUES (?,?,?,?,?,?,?)", TestNetsTable), testnetID, dd.Blockchain, dd.Nodes, dd.GetKid(),
+		TestNetBuilding, now, now)
+	return util.LogError(err)
+}
+
+// SetTestNetStatus updates the status of the given testnet
+func SetTestNetStatus(testnetID string, status string) error {
+	_, err := db.Exec(fmt.Sprintf("UPDATE %s SET status = ?, updated = ? WHERE id = ?", TestNetsTable),
+		status, time.Now().Unix(), testnetID)
+	return util.LogError(err)
+}
+
+// SetTestNetNodes updates the number of nodes in the given testnet
+func SetTestNetNodes(testnetID string, nodes int) error {
+	_, err := db.Exec(fmt.Sprintf("UPDATE %s SET nodes = ?, updated = ? WHERE id = ?", TestNetsTable),
+		nodes, time.Now().Unix(), testnetID)
+	return util.LogError(err)
+}
+
+// GetTestNet gets the summary of the given testnet
+func GetTestNet(testnetID string) (TestNet, error) {
+	var out TestNet
+	row := db.QueryRow(fmt.Sprintf("SELECT id,blockchain,nodes,owner,status,created,updated FROM %s WHERE id = ?",
+		TestNetsTable), testnetID)
+	err := row.Scan(&out.ID, &out.Blockchain, &out.Nodes, &out.Owner, &out.Status, &out.Created, &out.Updated)
+	if err != nil {
+		return out, fmt.Errorf("testnet \"%s\" not found", testnetID)
+	}
+	return out, nil
+}
+
+// ListTestNets gets the testnets which match the given filter, newest first, along with the total number
+// of testnets which match it, regardless of the limit and offset
+func ListTestNets(filter TestNetFilter) ([]TestNet, int, error) {
+	conds := []string{}
+	args := []interface{}{}
+	for column, value := range map[string]string{
+		"blockchain": filter.Blockchain, "status": filter.Status, "owner": filter.Owner} {
+		if len(value) > 0 {
+			conds = append(conds, column+" = ?")
+			args = append(args, value)
+		}
+	}
+	if filter.CreatedAfter > 0 {
+		conds = append(conds, "created > ?")
+		args = append(args, filter.CreatedAfter)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s%s", TestNetsTable, where), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, util.LogError(err)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1 // no limit in sqlite
+	}
+	rows, err := db.Query(fmt.Sprintf("SELECT id,blockchain,nodes,owner,status,created,updated FROM %s%s"+
+		" ORDER BY created DESC, id LIMIT ? OFFSET ?", TestNetsTable, where), append(args, limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, util.LogError(err)
+	}
+	defer rows.Close()
+
+	out := []TestNet{}
+	for rows.Next() {
+		var tn TestNet
+		err = rows.Scan(&tn.ID, &tn.Blockchain, &tn.Nodes, &tn.Owner, &tn.Status, &tn.Created, &tn.Updated)
+		if err != nil {
+			return nil, 0, util.LogError(err)
+		}
+		out = append(out, tn)
+	}
+	return out, total, util.LogError(rows.Err())
+}
+
+// DeleteTestNet removes the record of the given testnet
+func DeleteTestNet(testnetID string) error {
+	_, err := db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ?", TestNetsTable), testnetID)
+	return err
+}
@@ -26,15 +26,45 @@ import (
 )
 
 // Version represents the database version, upon change of this constant, the database will
-// be purged
-const Version = "2.2.5"
+// be migrated if there is a migration from the previous version, otherwise it will be purged
+const Version = "2.3.2"
 
-func check() error {
+// migration upgrades the database from one version to the next
+type migration struct {
+	// to is the version the database is at after the migration
+	to string
+	// statements are run in order to upgrade the database
+	statements []string
+}
+
+// migrations are the migrations from each of the previous versions of the database,
+// by the version they upgrade from
+var migrations = map[string]migration{
+	"2.2.9": {to: "2.3.0", statements: []string{
+		"ALTER TABLE " + NodesTable + " ADD COLUMN ports TEXT DEFAULT '[]'",
+		"ALTER TABLE " + NodesTable + " ADD COLUMN sidecars TEXT DEFAULT '[]'",
+	}},
+	"2.3.0": {to: "2.3.1", statements: []string{
+		"ALTER TABLE " + NodesTable + " ADD COLUMN entrypoint TEXT DEFAULT ''",
+		"ALTER TABLE " + NodesTable + " ADD COLUMN command TEXT DEFAULT '[]'",
+		"ALTER TABLE " + NodesTable + " ADD COLUMN workdir TEXT DEFAULT ''",
+	}},
+	"2.3.1": {to: "2.3.2", statements: []string{
+		"ALTER TABLE " + ServerTable + " ADD COLUMN extra_subnet_ids TEXT DEFAULT '[]'",
+	}},
+}
+
+func getVersion() (string, error) {
 	row := db.QueryRow("SELECT value FROM meta WHERE key = \"version\"")
 	var version string
 	err := row.Scan(&version)
+	return version, util.LogError(err)
+}
+
+func check() error {
+	version, err := getVersion()
 	if err != nil {
-		return util.LogError(err)
+		return err
 	}
 	if version != Version {
 		//Old version, previous database is now invalid
@@ -43,11 +73,47 @@ func check() error {
 	return nil
 }
 
+// migrate runs the migrations from the current version of the database up to Version, in a
+// single transaction. Returns an error if there is no way to migrate from the current version.
+func migrate() error {
+	version, err := getVersion()
+	if err != nil {
+		return err
+	}
+	statements := []string{}
+	for version != Version {
+		m, ok := migrations[version]
+		if !ok {
+			return fmt.Errorf("no migration from version %s", version)
+		}
+		statements = append(statements, m.statements...)
+		version = m.to
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return util.LogError(err)
+	}
+	statements = append(statements, fmt.Sprintf("UPDATE meta SET value = \"%s\" WHERE key = \"version\"", Version))
+	for _, statement := range statements {
+		_, err = tx.Exec(statement)
+		if err != nil {
+			tx.Rollback()
+			return util.LogError(err)
+		}
+	}
+	return util.LogError(tx.Commit())
+}
+
 func checkAndUpdate() {
 	if check() != nil {
-		log.Info("updating the database")
+		err := migrate()
+		if err == nil {
+			log.WithFields(log.Fields{"version": Version}).Info("migrated the database")
+			return
+		}
+		log.WithFields(log.Fields{"error": err}).Info("updating the database")
 		util.Rm(conf.DataDirectory + "/.gdata")
-		_, err := getDB()
+		_, err = getDB()
 		if err != nil {
 			log.Fatal("database update failed")
 		}
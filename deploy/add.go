@@ -35,6 +35,36 @@ func AddNodes(tn *testnet.TestNet) error {
 	defer tn.BuildState.FinishDeploy()
 	wg := sync.WaitGroup{}
 
+	cfg, err := tn.GetKubernetesConfig()
+	if err != nil {
+		return util.LogError(err)
+	}
+	if cfg.Enabled {
+		tn.BuildState.SetBuildStage("Provisioning the nodes")
+		err = deployPods(tn, cfg)
+		if err != nil {
+			return util.LogError(err)
+		}
+		return finalizeNewNodes(tn)
+	}
+	pcfg, err := tn.GetProcessConfig()
+	if err != nil {
+		return util.LogError(err)
+	}
+	if pcfg.Enabled {
+		tn.BuildState.SetBuildStage("Provisioning the nodes")
+		err = provisionProcesses(tn, pcfg)
+		if err != nil {
+			return util.LogError(err)
+		}
+		return finalizeNewNodes(tn)
+	}
+
+	err = checkPlatforms(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+
 	tn.BuildState.SetBuildStage("Provisioning the nodes")
 
 	availableServers := make([]int, len(tn.Servers))
@@ -62,20 +92,30 @@ func AddNodes(tn *testnet.TestNet) error {
 			return util.LogError(err)
 		}
 
-		nodeIP, err := util.GetNodeIP(tn.Servers[serverIndex].SubnetID, len(tn.Nodes), 0)
+		subnet, network, err := util.GetNodeNetwork(tn.Servers[serverIndex].Subnets(), tn.Servers[serverIndex].Nodes)
+		if err != nil {
+			return util.LogError(err)
+		}
+		nodeIP, err := util.GetNodeIP(subnet, network, 0)
+		if err != nil {
+			return util.LogError(err)
+		}
+
+		ports, err := nodePorts(tn, serverID, len(tn.Nodes))
 		if err != nil {
 			return util.LogError(err)
 		}
 
 		node := tn.AddNode(db.Node{
 			ID: nodeID, TestNetID: tn.TestNetID, Server: serverID,
-			LocalID: tn.Servers[serverIndex].Nodes, IP: nodeIP, Protocol: tn.LDD.Blockchain})
+			LocalID: tn.Servers[serverIndex].Nodes, IP: nodeIP, Protocol: tn.LDD.Blockchain, Ports: ports})
 
 		tn.Servers[serverIndex].Nodes++
 
 		wg.Add(1)
 		go func(server *db.Server, node *db.Node) {
 			defer wg.Done()
+			defer util.Recover(tn.BuildState.ReportError)
 			BuildNode(tn, server, node)
 		}(&tn.Servers[serverIndex], node)
 
@@ -96,11 +136,19 @@ func AddNodes(tn *testnet.TestNet) error {
 	}()
 
 	for _, client := range tn.Clients {
+		if client.Runtime().Name != util.DockerRuntime {
+			continue //only the rootful docker daemon isolates its networks with iptables
+		}
 		//noinspection SpellCheckingInspection
 		client.Run("sudo -n iptables --flush DOCKER-ISOLATION-STAGE-1")
 	}
 	wg.Wait()
 
+	err = restrictPorts(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+
 	log.Info("finished adding nodes into the network")
 	return tn.BuildState.GetError()
 }
@@ -0,0 +1,134 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/artifacts"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"os"
+	"sync"
+)
+
+// GetArtifactPaths gets the paths of the files which are collected as artifacts from each new node once
+// the build completes, given in the extras of the deployment details under "artifacts"
+func GetArtifactPaths(details *db.DeploymentDetails) ([]string, error) {
+	out := []string{}
+	raw, ok := details.Extras["artifacts"]
+	if !ok {
+		return out, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	err = json.Unmarshal(data, &out)
+	if err != nil {
+		return out, fmt.Errorf("invalid artifacts: %s", err.Error())
+	}
+	for i, path := range out {
+		err = artifacts.Source{Path: path}.Validate()
+		if err != nil {
+			return out, fmt.Errorf("%s. For artifact %d", err.Error(), i)
+		}
+	}
+	return out, nil
+}
+
+// collectArtifact fetches the file of the given source out of its node, and stores it as an artifact
+func collectArtifact(client ssh.Client, node ssh.Node, testnetID string, source artifacts.Source) error {
+	file, err := artifacts.Create(testnetID, source.GetName())
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = client.DockerFetch(node, source.Path, file)
+	file.Close()
+	if err != nil {
+		os.Remove(file.Name())
+		return util.LogError(err)
+	}
+	return nil
+}
+
+// CollectArtifacts fetches the files of the given sources out of the nodes of the testnet, storing
+// them as artifacts. Every source is attempted, and the names of those which were collected are returned.
+func CollectArtifacts(tn *testnet.TestNet, sources []artifacts.Source) ([]string, error) {
+	nodes := map[int]db.Node{}
+	for _, node := range tn.Nodes {
+		nodes[node.AbsoluteNum] = node
+	}
+	for _, source := range sources {
+		err := source.Validate()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := nodes[source.Node]; !ok {
+			return nil, fmt.Errorf("node %d does not exist", source.Node)
+		}
+	}
+
+	collected := []string{}
+	var collectErr error
+	mux := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, source := range sources {
+		wg.Add(1)
+		go func(source artifacts.Source) {
+			defer wg.Done()
+			node := nodes[source.Node]
+			err := collectArtifact(tn.Clients[node.Server], node, tn.TestNetID, source)
+			mux.Lock()
+			defer mux.Unlock()
+			if err != nil {
+				collectErr = fmt.Errorf("failed to collect \"%s\" from node %d: %s", source.Path, source.Node, err.Error())
+				return
+			}
+			collected = append(collected, source.GetName())
+		}(source)
+	}
+	wg.Wait()
+	return collected, collectErr
+}
+
+// CollectBuildArtifacts collects the artifacts registered during the build, along with the files given
+// in the extras of the deployment from each new node
+func CollectBuildArtifacts(tn *testnet.TestNet) error {
+	sources := artifacts.TakePending(tn.TestNetID)
+	paths, err := GetArtifactPaths(tn.LDD)
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, node := range tn.NewlyBuiltNodes {
+		for _, path := range paths {
+			sources = append(sources, artifacts.Source{Node: node.AbsoluteNum, Path: path})
+		}
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	tn.BuildState.SetBuildStage("collecting the artifacts")
+	collected, err := CollectArtifacts(tn, sources)
+	log.WithFields(log.Fields{"build": tn.TestNetID, "artifacts": collected}).Info("collected the artifacts")
+	return err
+}
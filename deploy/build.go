@@ -49,11 +49,20 @@ func buildSideCars(tn *testnet.TestNet, server *db.Server, node *db.Node) {
 			return
 		}
 
-		sidecarIP, err := util.GetNodeIP(server.SubnetID, node.LocalID, i+1)
+		subnet, network, err := util.GetNodeNetwork(server.Subnets(), node.LocalID)
 		if err != nil {
 			tn.BuildState.ReportError(err)
 			return
 		}
+		sidecarIP, err := util.GetNodeIP(subnet, network, i+1)
+		if err != nil {
+			tn.BuildState.ReportError(err)
+			return
+		}
+		image := sideCarDetails.Image
+		if sideCarDetails.GetImage != nil {
+			image = sideCarDetails.GetImage(tn)
+		}
 		scNode := db.SideCar{
 			NodeID:          node.ID,
 			AbsoluteNodeNum: node.AbsoluteNum,
@@ -62,11 +71,11 @@ func buildSideCars(tn *testnet.TestNet, server *db.Server, node *db.Node) {
 			LocalID:         node.LocalID,
 			NetworkIndex:    i + 1,
 			IP:              sidecarIP,
-			Image:           sideCarDetails.Image,
+			Image:           image,
 			Type:            sidecar,
 		}
 		tn.AddSideCar(scNode, i)
-		err = docker.Run(tn, server.ID, docker.NewSideCarContainer(&scNode, nil, util.Resources{}, server.SubnetID))
+		err = docker.Run(tn, server.ID, docker.NewSideCarContainer(&scNode, nil, util.Resources{}, server.Subnets()))
 		if err != nil {
 			tn.BuildState.ReportError(err)
 			return
@@ -74,45 +83,95 @@ func buildSideCars(tn *testnet.TestNet, server *db.Server, node *db.Node) {
 	}
 }
 
+// nodeResources gets the resources given for the node with the given absolute number
+func nodeResources(tn *testnet.TestNet, absNum int) util.Resources {
+	var resource util.Resources
+	if len(tn.LDD.Resources) == 0 {
+		resource = util.Resources{Cpus: "", Memory: ""}
+		log.WithFields(log.Fields{"resource": resource, "node": absNum}).Trace("using default resources")
+	} else {
+		resource = tn.LDD.Resources[0]
+	}
+
+	if len(tn.LDD.Resources) > absNum {
+		resource = tn.LDD.Resources[absNum]
+		log.WithFields(log.Fields{"resource": resource, "node": absNum}).Trace("using given resources")
+	}
+	return resource
+}
+
+// nodeEnv gets the environment variables given for the node with the given absolute number, being those given
+// for all of the nodes along with those given for the node
+func nodeEnv(tn *testnet.TestNet, absNum int) map[string]string {
+	out := map[string]string{}
+	for key, value := range tn.LDD.Env {
+		out[key] = value
+	}
+	if tn.LDD.Environments != nil && len(tn.LDD.Environments) > absNum && tn.LDD.Environments[absNum] != nil {
+		log.WithFields(log.Fields{"env": tn.LDD.Environments[absNum], "node": absNum}).Trace("using custom env vars")
+		for key, value := range tn.LDD.Environments[absNum] {
+			out[key] = value
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// nodeGPUs gets the indexes of the gpus of the server to pass into the node, ensuring that the
+// server has the gpus requested by its resources
+func nodeGPUs(tn *testnet.TestNet, server *db.Server, node *db.Node, resources util.Resources) ([]int, error) {
+	if resources.NoGPUs() {
+		return nil, nil
+	}
+	available, err := docker.GetGPUCount(tn.Clients[server.ID])
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	gpus, err := resources.GetGPUDevices(available)
+	if err != nil {
+		return nil, fmt.Errorf("node %d cannot be given its gpus on server %d: %s", node.AbsoluteNum, server.ID, err.Error())
+	}
+	return gpus, nil
+}
+
 // BuildNode builds out a single node in a testnet
 func BuildNode(tn *testnet.TestNet, server *db.Server, node *db.Node) {
-	docker.NetworkDestroy(tn.Clients[server.ID], node.LocalID)
-	docker.Kill(tn.Clients[server.ID], node.LocalID)
+	docker.NetworkDestroy(tn, server.ID, node.LocalID)
+	docker.Kill(tn.Clients[server.ID], node)
 
 	if conf.RemoveNodesOnFailure {
 		tn.BuildState.OnError(func() {
-			docker.Kill(tn.Clients[server.ID], node.LocalID)
-			docker.NetworkDestroy(tn.Clients[server.ID], node.LocalID)
+			docker.Kill(tn.Clients[server.ID], node)
+			docker.NetworkDestroy(tn, server.ID, node.LocalID)
 		})
 	}
 	defer buildSideCars(tn, server, node) //Needs to be handled better
-	err := docker.NetworkCreate(tn, server.ID, server.SubnetID, node.LocalID)
+
+	resources := nodeResources(tn, node.AbsoluteNum)
+	resources.Ports = node.Ports
+	gpus, err := nodeGPUs(tn, server, node, resources)
 	if err != nil {
 		tn.BuildState.ReportError(err)
 		return
 	}
-	tn.BuildState.IncrementDeployProgress()
 
-	var resource util.Resources
-	if len(tn.LDD.Resources) == 0 {
-		resource = util.Resources{Cpus: "", Memory: ""}
-		log.WithFields(log.Fields{"resource": resource, "node": node.AbsoluteNum}).Trace("using default resources")
-	} else {
-		resource = tn.LDD.Resources[0]
+	err = docker.NetworkCreate(tn, server.ID, server.Subnets(), node.LocalID)
+	if err != nil {
+		tn.BuildState.ReportError(err)
+		return
 	}
+	tn.BuildState.IncrementDeployProgress()
 
-	var env map[string]string
-
-	if len(tn.LDD.Resources) > node.AbsoluteNum {
-		resource = tn.LDD.Resources[node.AbsoluteNum]
-		log.WithFields(log.Fields{"resource": resource, "node": node.AbsoluteNum}).Trace("using given resources")
+	err = docker.CreateVolumes(tn.Clients[server.ID], tn.TestNetID, node.GetNodeName(), resources.Mounts)
+	if err != nil {
+		tn.BuildState.ReportError(err)
+		return
 	}
 
-	if tn.LDD.Environments != nil && len(tn.LDD.Environments) > node.AbsoluteNum && tn.LDD.Environments[node.AbsoluteNum] != nil {
-		env = tn.LDD.Environments[node.AbsoluteNum]
-		log.WithFields(log.Fields{"env": env, "node": node.AbsoluteNum}).Trace("using custom env vars")
-	}
-	err = docker.Run(tn, server.ID, docker.NewNodeContainer(node, env, resource, server.SubnetID))
+	err = docker.Run(tn, server.ID, docker.NewNodeContainer(node, nodeEnv(tn, node.AbsoluteNum),
+		resources, server.Subnets(), gpus))
 	if err != nil {
 		tn.BuildState.ReportError(err)
 		return
@@ -130,10 +189,32 @@ func Build(tn *testnet.TestNet, services []services.Service) error {
 
 	tn.BuildState.SetBuildStage("Initializing build")
 
-	err := handlePreBuildExtras(tn)
+	cfg, err := tn.GetKubernetesConfig()
+	if err != nil {
+		return util.LogError(err)
+	}
+	if cfg.Enabled {
+		return buildOnKubernetes(tn, cfg, services)
+	}
+	pcfg, err := tn.GetProcessConfig()
+	if err != nil {
+		return util.LogError(err)
+	}
+	if pcfg.Enabled {
+		return buildAsProcesses(tn, pcfg, services)
+	}
+
+	err = handlePreBuildExtras(tn)
 	if err != nil {
 		return util.LogError(err)
 	}
+	err = checkPlatforms(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if conf.EnableCAdvisor {
+		startCAdvisors(tn)
+	}
 	PurgeTestNetwork(tn)
 
 	tn.BuildState.SetBuildStage("Provisioning the nodes")
@@ -163,20 +244,30 @@ func Build(tn *testnet.TestNet, services []services.Service) error {
 			return util.LogError(err)
 		}
 
-		nodeIP, err := util.GetNodeIP(tn.Servers[serverIndex].SubnetID, tn.Servers[serverIndex].Nodes, 0)
+		subnet, network, err := util.GetNodeNetwork(tn.Servers[serverIndex].Subnets(), tn.Servers[serverIndex].Nodes)
+		if err != nil {
+			return util.LogError(err)
+		}
+		nodeIP, err := util.GetNodeIP(subnet, network, 0)
+		if err != nil {
+			return util.LogError(err)
+		}
+
+		ports, err := nodePorts(tn, serverID, len(tn.Nodes))
 		if err != nil {
 			return util.LogError(err)
 		}
 
 		node := tn.AddNode(db.Node{
 			ID: nodeID, TestNetID: tn.TestNetID, Server: serverID,
-			LocalID: tn.Servers[serverIndex].Nodes, IP: nodeIP, Protocol: tn.LDD.Blockchain})
+			LocalID: tn.Servers[serverIndex].Nodes, IP: nodeIP, Protocol: tn.LDD.Blockchain, Ports: ports})
 
 		tn.Servers[serverIndex].Nodes++
 
 		wg.Add(1)
 		go func(server *db.Server, node *db.Node) {
 			defer wg.Done()
+			defer util.Recover(tn.BuildState.ReportError)
 			BuildNode(tn, server, node)
 		}(&tn.Servers[serverIndex], node)
 
@@ -215,6 +306,9 @@ func Build(tn *testnet.TestNet, services []services.Service) error {
 	}()
 
 	for _, client := range tn.Clients {
+		if client.Runtime().Name != util.DockerRuntime {
+			continue //only the rootful docker daemon isolates its networks with iptables
+		}
 		wg.Add(1)
 		go func(client ssh.Client) {
 			defer wg.Done()
@@ -229,6 +323,11 @@ func Build(tn *testnet.TestNet, services []services.Service) error {
 	//Acquire all of the resources here, then release and destroy
 	wg.Wait()
 
+	err = restrictPorts(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+
 	//Check if we should freeze
 	if tn.LDD.Extras != nil {
 		shouldFreezeI, ok := tn.LDD.Extras["freezeAfterInfrastructure"]
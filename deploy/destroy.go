@@ -19,12 +19,15 @@
 package deploy
 
 import (
+	"github.com/whiteblock/genesis/consensus"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/docker"
+	"github.com/whiteblock/genesis/kubernetes"
 	netem "github.com/whiteblock/genesis/net"
 	"github.com/whiteblock/genesis/protocols/helpers"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
 )
 
 // PurgeTestNetwork goes into each given ssh client and removes all the nodes and the networks.
@@ -45,6 +48,7 @@ func PurgeTestNetwork(tn *testnet.TestNet) error {
 			tn.BuildState.IncrementDeployProgress()
 		}
 		netem.RemoveAllOutages(client)
+		netem.RemoveAllExposedPortRules(client)
 		//Redundant because the network is already destroy, so the tc rules are implicitly destroyed.
 		//netem.RemoveAllOnServer(client, server.Nodes)
 
@@ -52,7 +56,43 @@ func PurgeTestNetwork(tn *testnet.TestNet) error {
 	})
 }
 
-// Destroy is an alias of PurgeTestNetwork
+// Destroy tears down the testnet. For a testnet on docker, the traffic accounting and the consensus probes
+// are stopped, the network is purged with PurgeTestNetwork, then the named volumes of the nodes are removed,
+// unless they are to be preserved. For a testnet whose nodes run as processes, the nodes are purged with
+// PurgeProcesses instead.
 func Destroy(tn *testnet.TestNet) error {
-	return PurgeTestNetwork(tn)
+	cfg, err := tn.GetKubernetesConfig()
+	if err != nil {
+		return util.LogError(err)
+	}
+	if cfg.Enabled {
+		return kubernetes.DeleteTestNet(cfg, tn.TestNetID)
+	}
+	pcfg, err := tn.GetProcessConfig()
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = netem.StopTrafficAccounting(tn.TestNetID, tn.Nodes)
+	if err != nil {
+		return util.LogError(err)
+	}
+	consensus.Unwatch(tn.TestNetID)
+	err = consensus.ClearRules(tn.TestNetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = netem.ClearOutages(tn.TestNetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if pcfg.Enabled {
+		return PurgeProcesses(tn, pcfg)
+	}
+	err = PurgeTestNetwork(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return helpers.AllServerExecCon(tn, func(client ssh.Client, _ *db.Server) error {
+		return docker.RemoveVolumes(client, tn.TestNetID)
+	})
 }
@@ -88,8 +88,9 @@ func dockerBuild(tn *testnet.TestNet, contextDir string) error {
 		go func(client ssh.Client) {
 			defer wg.Done()
 
-			_, err := client.Run(fmt.Sprintf("docker build %s -t %s", contextDir, imageName))
-			tn.BuildState.Defer(func() { client.Run(fmt.Sprintf("docker rmi %s", imageName)) })
+			cli := client.Runtime().CLI
+			_, err := client.Run(fmt.Sprintf("%s build %s -t %s", cli, contextDir, imageName))
+			tn.BuildState.Defer(func() { client.Run(fmt.Sprintf("%s rmi %s", cli, imageName)) })
 			if err != nil {
 				tn.BuildState.ReportError(err)
 				return
@@ -140,7 +141,7 @@ func handleDockerBuildRequest(tn *testnet.TestNet, prebuild map[string]interface
 	if err != nil {
 		return util.LogError(err)
 	}
-	return dockerBuild(tn, "/tmp"+dir)
+	return dockerBuild(tn, "/tmp/"+dir)
 }
 
 func handleRepoBuild(tn *testnet.TestNet, prebuild map[string]interface{}) error {
@@ -29,7 +29,6 @@ import (
 	"github.com/whiteblock/genesis/protocols/helpers"
 	"github.com/whiteblock/genesis/protocols/registrar"
 	"github.com/whiteblock/genesis/ssh"
-	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 )
@@ -78,7 +77,7 @@ func alwaysRunFinalize(tn *testnet.TestNet) {
 	copy(newNodes, tn.NewlyBuiltNodes)
 	tn.BuildState.Defer(func() {
 		for i, node := range newNodes {
-			err := finalizeNode(node, tn.LDD, i)
+			err := finalizeNode(tn.Clients[node.Server], node, tn.LDD, i)
 			if err != nil {
 				tn.BuildState.ReportError(err)
 			}
@@ -164,15 +163,11 @@ func declareNode(node *db.Node, tn *testnet.TestNet) error {
 	return err
 }
 
-func finalizeNode(node db.Node, details *db.DeploymentDetails, absNum int) error {
+func finalizeNode(client ssh.Client, node db.Node, details *db.DeploymentDetails, absNum int) error {
 	if conf.DisableNibbler {
 		log.Info("skipping nibbler setup as it is disabled")
 		return nil
 	}
-	client, err := status.GetClient(node.Server)
-	if err != nil {
-		return util.LogError(err)
-	}
 	files := details.Blockchain + " " + conf.DockerOutputFile
 	if details.Logs != nil && len(details.Logs) > 0 {
 		var logFiles map[string]string
@@ -190,7 +185,7 @@ func finalizeNode(node db.Node, details *db.DeploymentDetails, absNum int) error
 		files += " " + name + " " + logFile
 	}
 
-	_, err = client.DockerExecd(node,
+	_, err := client.DockerExecd(node,
 		fmt.Sprintf("bash -c 'nibbler --node-type %s --api %s --jwt %s --testnet %s --node %s %s 2>&1 >> /nibbler.log'",
 			details.Blockchain, conf.APIEndpoint, details.GetJwt(), node.TestNetID, node.ID, files))
 	return util.LogError(err)
@@ -0,0 +1,94 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"sync"
+	"time"
+)
+
+// NodeHealth is the result of the health check of a single node
+type NodeHealth struct {
+	// Node is the absolute number of the node
+	Node int `json:"node"`
+	// ID is the id of the node
+	ID      string `json:"id"`
+	Healthy bool   `json:"healthy"`
+	// Error is why the node is not healthy
+	Error string `json:"error,omitempty"`
+}
+
+func checkNode(tn *testnet.TestNet, check func(ssh.Client, ssh.Node) error, node ssh.Node) NodeHealth {
+	out := NodeHealth{Node: node.GetAbsoluteNumber(), ID: node.GetID(), Healthy: true}
+	err := check(tn.Clients[node.GetServerID()], node)
+	if err != nil {
+		out.Healthy = false
+		out.Error = err.Error()
+	}
+	return out
+}
+
+// CheckHealth runs the health check of the blockchain of the given testnet once on each of its nodes.
+// Returns an error if the blockchain does not have a health check.
+func CheckHealth(tn *testnet.TestNet) ([]NodeHealth, error) {
+	check, err := registrar.GetHealthCheckFunc(tn.LDD.Blockchain)
+	if err != nil {
+		return nil, fmt.Errorf("%s does not have a health check", tn.LDD.Blockchain)
+	}
+	nodes := tn.GetSSHNodes(false, false, -1)
+	out := make([]NodeHealth, len(nodes))
+	wg := sync.WaitGroup{}
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node ssh.Node) {
+			defer wg.Done()
+			out[i] = checkNode(tn, check, node)
+		}(i, node)
+	}
+	wg.Wait()
+	return out, nil
+}
+
+// WaitForHealthy polls the health check of the blockchain on each of the new nodes of the testnet,
+// until either all of them are healthy, or healthCheckTimeout has elapsed, in which case the error
+// will give the last health check failure of each of the nodes which never became healthy.
+// Does nothing if the blockchain does not have a health check.
+func WaitForHealthy(tn *testnet.TestNet) error {
+	check, err := registrar.GetHealthCheckFunc(tn.LDD.Blockchain)
+	if err != nil {
+		return nil
+	}
+	tn.BuildState.SetBuildStage("Waiting for the nodes to become healthy")
+	err = helpers.WaitForAllNew(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) (bool, error) {
+		err := check(client, node)
+		return err == nil, err
+	}, time.Duration(conf.HealthCheckTimeout)*time.Second)
+	if err != nil {
+		return fmt.Errorf("the nodes did not become healthy: %s", err.Error())
+	}
+	log.WithFields(log.Fields{"build": tn.TestNetID}).Info("all of the nodes are healthy")
+	return nil
+}
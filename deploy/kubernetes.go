@@ -0,0 +1,111 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/kubernetes"
+	"github.com/whiteblock/genesis/protocols/services"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+// buildOnKubernetes is the counterpart of Build for a testnet whose nodes run as pods
+func buildOnKubernetes(tn *testnet.TestNet, cfg kubernetes.Config, services []services.Service) error {
+	err := kubernetes.DeleteTestNet(cfg, tn.TestNetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if len(services) > 0 {
+		log.WithFields(log.Fields{"build": tn.TestNetID, "services": len(services)}).Warn(
+			"services are not supported on kubernetes, skipping them")
+	}
+
+	tn.BuildState.SetBuildStage("Provisioning the nodes")
+	err = deployPods(tn, cfg)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	tn.BuildState.SetBuildStage("Setting up services")
+	err = finalize(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return tn.BuildState.GetError()
+}
+
+// deployPods creates a pod for each of the nodes in the latest deployment, waits for them to be ready,
+// then adds the nodes to the testnet with the ip addresses given to their pods by the cluster.
+func deployPods(tn *testnet.TestNet, cfg kubernetes.Config) error {
+	if len(tn.Servers) == 0 {
+		return fmt.Errorf("a server is needed to stand in for the cluster")
+	}
+	server := &tn.Servers[0]
+
+	if len(tn.LDD.Startup) > 0 {
+		return fmt.Errorf("overriding the startup of the nodes is not supported on kubernetes")
+	}
+	start := len(tn.Nodes)
+	objects := []interface{}{kubernetes.NetworkPolicy(tn.TestNetID)}
+	for absNum := start; absNum < start+tn.LDD.Nodes; absNum++ {
+		image := tn.LDD.Images[0]
+		if len(tn.LDD.Images) > absNum {
+			image = tn.LDD.Images[absNum]
+		}
+		pod, err := kubernetes.NodePod(util.GetNodeName(tn.TestNetID, absNum), tn.TestNetID, image,
+			nodeResources(tn, absNum), nodeEnv(tn, absNum))
+		if err != nil {
+			return util.LogError(err)
+		}
+		objects = append(objects, pod)
+	}
+	err := kubernetes.Apply(cfg, objects...)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = kubernetes.WaitForPods(cfg, tn.TestNetID, conf.KubeReadyTimeout)
+	if err != nil {
+		return util.LogError(err)
+	}
+	ips, err := kubernetes.GetPodIPs(cfg, tn.TestNetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	for absNum := start; absNum < start+tn.LDD.Nodes; absNum++ {
+		name := util.GetNodeName(tn.TestNetID, absNum)
+		ip, ok := ips[name]
+		if !ok || len(ip) == 0 {
+			return fmt.Errorf("pod %s was not given an ip address", name)
+		}
+		nodeID, err := util.GetUUIDString()
+		if err != nil {
+			return util.LogError(err)
+		}
+		tn.AddNode(db.Node{
+			ID: nodeID, TestNetID: tn.TestNetID, Server: server.ID,
+			LocalID: absNum, IP: ip, Protocol: tn.LDD.Blockchain})
+		server.Nodes++
+		tn.BuildState.IncrementDeployProgress()
+	}
+	return nil
+}
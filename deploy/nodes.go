@@ -0,0 +1,178 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NodeState is the state of the container of a node, gathered from the server it is on
+type NodeState struct {
+	db.Node
+	// State is the state of the container, such as running or exited, or missing if it does not exist
+	State string `json:"state"`
+	// RestartCount is the number of times the container has been restarted
+	RestartCount int `json:"restartCount"`
+	// Uptime is how long the container has been running for, in seconds
+	Uptime float64 `json:"uptime"`
+	// PID is the pid of the blockchain process within the container, 0 if it is not running
+	PID int `json:"pid"`
+	// Health is the result of the health check of the node, nil if the blockchain does not have a health check
+	// or the container is not running
+	Health *NodeHealth `json:"health,omitempty"`
+	// Error is why the state of the node could not be fully gathered
+	Error string `json:"error,omitempty"`
+}
+
+// inspectNode fills in the state of the container of the node
+func inspectNode(client ssh.Client, out *NodeState) error {
+	res, err := client.Run(fmt.Sprintf("%s inspect --format '{{.State.Status}}|{{.RestartCount}}|{{.State.StartedAt}}' %s",
+		client.Runtime().CLI, out.GetNodeName()))
+	if err != nil {
+		if strings.Contains(err.Error(), "No such") {
+			out.State = "missing"
+			return nil
+		}
+		return err
+	}
+	fields := strings.Split(strings.TrimSpace(res), "|")
+	if len(fields) != 3 {
+		return fmt.Errorf("unexpected output from inspect: %s", res)
+	}
+	out.State = fields[0]
+	out.RestartCount, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return err
+	}
+	started, err := time.Parse(time.RFC3339Nano, fields[2])
+	if err == nil && out.State == "running" {
+		out.Uptime = time.Since(started).Seconds()
+	}
+	return nil
+}
+
+// findBlockchainPID gets the pid of the process started with the main command of the node, 0 if it
+// is not running
+func findBlockchainPID(tn *testnet.TestNet, client ssh.Client, node db.Node) (int, error) {
+	var cmd util.Command
+	if !tn.BuildState.GetP(strconv.Itoa(node.AbsoluteNum), &cmd) || len(cmd.Cmdline) == 0 {
+		return 0, nil
+	}
+	res, err := client.DockerExec(node, fmt.Sprintf("ps aux | grep '%s' | grep -v grep | grep -v nibbler | awk '{print $2}' | head -n 1",
+		strings.Split(cmd.Cmdline, " ")[0]))
+	if err != nil || len(strings.TrimSpace(res)) == 0 {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(res))
+}
+
+// GetNodeStates gathers the state of the container of each node of the testnet, along with the pid of its
+// blockchain process and the result of its health check, from all of the nodes at once
+func GetNodeStates(tn *testnet.TestNet) []NodeState {
+	check, err := registrar.GetHealthCheckFunc(tn.LDD.Blockchain)
+	if err != nil {
+		check = nil
+	}
+	out := make([]NodeState, len(tn.Nodes))
+	wg := sync.WaitGroup{}
+	for i, node := range tn.Nodes {
+		out[i].Node = node
+		client, ok := tn.Clients[node.Server]
+		if !ok {
+			out[i].Error = fmt.Sprintf("no client for server %d", node.Server)
+			continue
+		}
+		wg.Add(1)
+		go func(client ssh.Client, out *NodeState) {
+			defer wg.Done()
+			err := inspectNode(client, out)
+			if err == nil && out.State == "running" {
+				out.PID, err = findBlockchainPID(tn, client, out.Node)
+				if check != nil {
+					health := checkNode(tn, check, out.Node)
+					out.Health = &health
+				}
+			}
+			if err != nil {
+				out.Error = util.LogError(err).Error()
+			}
+		}(client, &out[i])
+	}
+	wg.Wait()
+	return out
+}
+
+// NotRunningError is given when the blockchain process of a node is not running
+type NotRunningError struct {
+	// Node is the absolute number of the node
+	Node int
+}
+
+func (err NotRunningError) Error() string {
+	return fmt.Sprintf("the blockchain process of node %d is not running", err.Node)
+}
+
+// Signaled is the result of sending a signal to the blockchain process of a node
+type Signaled struct {
+	// Node is the absolute number of the node
+	Node int `json:"node"`
+	// PID is the pid of the blockchain process within the container
+	PID int `json:"pid"`
+	// Signal is the signal which was sent
+	Signal string `json:"signal"`
+	// State is the state of the process after the signal was sent, as given by /proc/{pid}/status, such as
+	// "T (stopped)" after STOP. Empty if the process no longer exists.
+	State string `json:"state"`
+}
+
+// SignalNode sends the given signal, such as KILL or STOP, to the blockchain process of the given node.
+// Gives a NotRunningError if the process could not be found.
+func SignalNode(tn *testnet.TestNet, node db.Node, signal string) (Signaled, error) {
+	out := Signaled{Node: node.AbsoluteNum, Signal: signal}
+	client, ok := tn.Clients[node.Server]
+	if !ok {
+		return out, fmt.Errorf("no client for server %d", node.Server)
+	}
+	pid, err := findBlockchainPID(tn, client, node)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	if pid == 0 {
+		return out, NotRunningError{Node: node.AbsoluteNum}
+	}
+	out.PID = pid
+	_, err = client.DockerExec(node, fmt.Sprintf("kill -%s %d", signal, pid))
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	res, err := client.DockerExec(node, fmt.Sprintf("grep ^State: /proc/%d/status", pid))
+	if err == nil {
+		out.State = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(res), "State:"))
+	}
+	return out, nil
+}
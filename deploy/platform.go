@@ -0,0 +1,83 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/docker"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+)
+
+// checkPlatforms records the architecture of each server which does not have one yet, and ensures that
+// the images of the new nodes can run on each of the servers, so that a mismatch fails the build
+// with a clear error rather than with an exec format error when the nodes are started.
+func checkPlatforms(tn *testnet.TestNet) error {
+	tn.BuildState.SetBuildStage("Checking the images")
+	images := util.GetUniqueStrings(tn.LDD.Images)
+	wg := sync.WaitGroup{}
+	for i := range tn.Servers {
+		wg.Add(1)
+		go func(server *db.Server) {
+			defer wg.Done()
+			client := tn.Clients[server.ID]
+			if len(server.Arch) == 0 {
+				arch, err := docker.GetArch(client)
+				if err != nil {
+					tn.BuildState.ReportError(fmt.Errorf("server %d: %s", server.ID, err.Error()))
+					return
+				}
+				server.Arch = arch
+				err = tn.DB.UpdateServerArch(server.ID, arch)
+				if err != nil {
+					tn.BuildState.ReportError(err)
+					return
+				}
+			}
+			for _, image := range images {
+				err := docker.EnsurePlatform(client, image, server.Arch)
+				if err != nil {
+					tn.BuildState.ReportError(fmt.Errorf("server %d: %s", server.ID, err.Error()))
+				}
+			}
+		}(&tn.Servers[i])
+	}
+	wg.Wait()
+	return tn.BuildState.GetError()
+}
+
+// startCAdvisors starts cAdvisor on each of the servers which is not already running it, so that the
+// resource use of the nodes can be read from it. A failure only loses that, so it does not fail the build.
+func startCAdvisors(tn *testnet.TestNet) {
+	wg := sync.WaitGroup{}
+	for _, server := range tn.Servers {
+		wg.Add(1)
+		go func(serverID int) {
+			defer wg.Done()
+			err := docker.EnsureCAdvisor(tn.Clients[serverID])
+			if err != nil {
+				log.WithFields(log.Fields{"server": serverID, "error": err}).Warn("unable to start cadvisor")
+			}
+		}(server.ID)
+	}
+	wg.Wait()
+}
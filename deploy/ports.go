@@ -0,0 +1,100 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	netem "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+// serverPorts gets an allocator for the ports of the given server, with the ports which are mapped for the
+// nodes already on the server, including those of the testnet which are still being built, marked as used
+func serverPorts(tn *testnet.TestNet, serverID int) (*util.PortAllocator, error) {
+	nodes, err := tn.DB.GetAllNodesByServer(serverID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := util.NewPortAllocator(conf.HostPortMin, conf.HostPortMax)
+	for _, node := range append(nodes, tn.Nodes...) {
+		if node.Server != serverID {
+			continue
+		}
+		for _, port := range node.Ports {
+			mapping, err := util.ParsePortMapping(port)
+			if err == nil {
+				out.Use(mapping)
+			}
+		}
+	}
+	return out, nil
+}
+
+// nodePorts maps the ports given in the resources of the node with the given absolute number to ports on the
+// given server, allocating a port between hostPortMin and hostPortMax for each one which is not given a port
+// on the server. The mappings are stored with the node, so that the ports are not given out again while
+// the node exists.
+func nodePorts(tn *testnet.TestNet, serverID int, absNum int) ([]string, error) {
+	if !conf.EnablePortForwarding {
+		return nil, nil
+	}
+	ports := nodeResources(tn, absNum).Ports
+	if len(ports) == 0 {
+		return nil, nil
+	}
+	mappings := make([]util.PortMapping, len(ports))
+	for i, port := range ports {
+		var err error
+		mappings[i], err = util.ParsePortMapping(port)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+	}
+	allocator, err := serverPorts(tn, serverID)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	mappings, err = allocator.Allocate(mappings)
+	if err != nil {
+		return nil, fmt.Errorf("unable to map the ports of node %d on server %d: %s", absNum, serverID, err.Error())
+	}
+	out := make([]string, len(mappings))
+	for i, mapping := range mappings {
+		out[i] = mapping.String()
+	}
+	return out, nil
+}
+
+// restrictPorts restricts access to the mapped ports of the nodes of the testnet on each of its servers,
+// replacing the rules from any earlier build of the testnet
+func restrictPorts(tn *testnet.TestNet) error {
+	return helpers.AllServerExecCon(tn, func(client ssh.Client, server *db.Server) error {
+		nodes := []db.Node{}
+		for _, node := range tn.Nodes {
+			if node.Server == server.ID {
+				nodes = append(nodes, node)
+			}
+		}
+		return netem.RestrictExposedPorts(client, tn.TestNetID, nodes)
+	})
+}
@@ -0,0 +1,181 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	netem "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/process"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/protocols/services"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+)
+
+// buildAsProcesses is the counterpart of Build for a testnet whose nodes run as processes
+func buildAsProcesses(tn *testnet.TestNet, cfg process.Config, services []services.Service) error {
+	if len(services) > 0 {
+		log.WithFields(log.Fields{"build": tn.TestNetID, "services": len(services)}).Warn(
+			"services are not supported for nodes which run as processes, skipping them")
+	}
+	err := PurgeProcesses(tn, cfg)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	tn.BuildState.SetBuildStage("Provisioning the nodes")
+	err = provisionProcesses(tn, cfg)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	tn.BuildState.SetBuildStage("Setting up services")
+	err = finalize(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return tn.BuildState.GetError()
+}
+
+// PurgeProcesses removes all of the nodes which run as processes from the servers of the testnet,
+// along with the docker services and the outages
+func PurgeProcesses(tn *testnet.TestNet, cfg process.Config) error {
+	if tn.BuildState != nil {
+		tn.BuildState.SetBuildStage("Tearing down the previous testnet")
+	}
+	return helpers.AllServerExecCon(tn, func(client ssh.Client, server *db.Server) error {
+		_, err := client.Run(process.TeardownAll(cfg))
+		if err != nil {
+			return util.LogError(err)
+		}
+		if tn.BuildState != nil {
+			tn.BuildState.IncrementDeployProgress()
+		}
+		netem.RemoveAllOutages(client)
+		return nil
+	})
+}
+
+// provisionProcesses adds the nodes of the latest deployment to the servers of the testnet, filling each
+// server up to its maximum before moving on to the next one, then provisions them as processes
+func provisionProcesses(tn *testnet.TestNet, cfg process.Config) error {
+	if len(tn.LDD.Startup) > 0 {
+		return fmt.Errorf("overriding the startup of the nodes is not supported for nodes which run as processes")
+	}
+	sidecars, err := registrar.GetBlockchainSideCars(tn)
+	if err == nil && len(sidecars) > 0 {
+		return fmt.Errorf("%s needs sidecars, which are not supported for nodes which run as processes",
+			tn.LDD.Blockchain)
+	}
+
+	nodes := map[int][]*db.Node{}
+	serverIndex := 0
+	for i := 0; i < tn.LDD.Nodes; i++ {
+		for serverIndex < len(tn.Servers) && tn.Servers[serverIndex].Nodes >= tn.Servers[serverIndex].Max {
+			serverIndex++
+		}
+		if serverIndex == len(tn.Servers) {
+			return fmt.Errorf("cannot build that many nodes with the available resources")
+		}
+		server := &tn.Servers[serverIndex]
+		nodeID, err := util.GetUUIDString()
+		if err != nil {
+			return util.LogError(err)
+		}
+		subnet, network, err := util.GetNodeNetwork(server.Subnets(), server.Nodes)
+		if err != nil {
+			return util.LogError(err)
+		}
+		nodeIP, err := util.GetNodeIP(subnet, network, 0)
+		if err != nil {
+			return util.LogError(err)
+		}
+		node := tn.AddNode(db.Node{
+			ID: nodeID, TestNetID: tn.TestNetID, Server: server.ID,
+			LocalID: server.Nodes, IP: nodeIP, Protocol: tn.LDD.Blockchain})
+		nodes[server.ID] = append(nodes[server.ID], node)
+		server.Nodes++
+	}
+
+	return helpers.AllServerExecCon(tn, func(client ssh.Client, server *db.Server) error {
+		images := map[string]bool{}
+		for _, node := range nodes[server.ID] {
+			if images[node.Image] {
+				continue
+			}
+			images[node.Image] = true
+			_, err := client.Run(process.PrepareImage(cfg, client.Runtime().CLI, node.Image))
+			if err != nil {
+				return util.LogError(err)
+			}
+		}
+
+		wg := sync.WaitGroup{}
+		for _, node := range nodes[server.ID] {
+			wg.Add(1)
+			go func(node *db.Node) {
+				defer wg.Done()
+				err := provisionProcess(tn, cfg, client, server, node)
+				if err != nil {
+					tn.BuildState.ReportError(err)
+				}
+			}(node)
+		}
+		wg.Wait()
+		return tn.BuildState.GetError()
+	})
+}
+
+// provisionProcess provisions the given node as a process on its server
+func provisionProcess(tn *testnet.TestNet, cfg process.Config, client ssh.Client, server *db.Server,
+	node *db.Node) error {
+	subnet, network, err := util.GetNodeNetwork(server.Subnets(), node.LocalID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	command, err := process.Provision(cfg, process.Node{
+		Name:      node.GetNodeName(),
+		Image:     node.Image,
+		IP:        node.IP,
+		Gateway:   util.GetGateway(subnet, network),
+		Bridge:    fmt.Sprintf("%s%d", conf.BridgePrefix, node.LocalID),
+		Veth:      fmt.Sprintf("wb_veth%d", node.LocalID),
+		Resources: nodeResources(tn, node.AbsoluteNum),
+		Env:       nodeEnv(tn, node.AbsoluteNum),
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+	if conf.RemoveNodesOnFailure {
+		tn.BuildState.OnError(func() {
+			client.Run(process.Teardown(cfg, node.GetNodeName()))
+		})
+	}
+	_, err = client.Run(command)
+	if err != nil {
+		return util.LogError(err)
+	}
+	tn.BuildState.IncrementDeployProgress()
+	return nil
+}
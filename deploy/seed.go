@@ -0,0 +1,202 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Seed is a snapshot of chain data which is extracted into the data directory of a node before
+// the blockchain is started on it, given in the extras of the deployment details under "seeds"
+type Seed struct {
+	// URL is the http(s) url of a tar archive of the chain data, which may be compressed
+	URL string `json:"url"`
+	// Volume is the name of a volume on the server of the node which holds the chain data,
+	// such as a preserved volume of a previous testnet
+	Volume string `json:"volume"`
+	// Dir is the directory in the node to extract the chain data into, defaults to the
+	// data directory of the blockchain
+	Dir string `json:"dir"`
+}
+
+// Validate ensures that the seed has exactly one valid source, and a valid directory if one is given
+func (seed Seed) Validate() error {
+	if len(seed.URL) > 0 == (len(seed.Volume) > 0) {
+		return fmt.Errorf("a seed needs either a url or a volume")
+	}
+	if len(seed.URL) > 0 {
+		u, err := url.Parse(seed.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("invalid seed url \"%s\"", seed.URL)
+		}
+		if strings.ContainsAny(seed.URL, "'\n") {
+			return fmt.Errorf("invalid seed url \"%s\"", seed.URL)
+		}
+	}
+	if len(seed.Volume) > 0 {
+		err := util.ValidateVolumeName(seed.Volume)
+		if err != nil {
+			return err
+		}
+	}
+	if len(seed.Dir) > 0 {
+		err := util.ValidateFilePath(seed.Dir)
+		if err != nil {
+			return fmt.Errorf("invalid seed dir: %s", err.Error())
+		}
+		if !path.IsAbs(seed.Dir) {
+			return fmt.Errorf("the seed dir \"%s\" must be an absolute path", seed.Dir)
+		}
+	}
+	return nil
+}
+
+// GetSeeds gets the chain data seeds from the given deployment details. The first seed is the default,
+// and the others are for the node with the same absolute number, like resources.
+func GetSeeds(details *db.DeploymentDetails) ([]Seed, error) {
+	out := []Seed{}
+	raw, ok := details.Extras["seeds"]
+	if !ok {
+		return out, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	err = json.Unmarshal(data, &out)
+	if err != nil {
+		return out, fmt.Errorf("invalid seeds: %s", err.Error())
+	}
+	for i, seed := range out {
+		err = seed.Validate()
+		if err != nil {
+			return out, fmt.Errorf("%s. For seed %d", err.Error(), i)
+		}
+	}
+	return out, nil
+}
+
+// seedIndex gets the index of the seed of the node with the given absolute number
+func seedIndex(seeds []Seed, absNum int) int {
+	if absNum < len(seeds) {
+		return absNum
+	}
+	return 0
+}
+
+// seedArchiveDir gets the directory on a server into which the archive of the seed is extracted
+func seedArchiveDir(tn *testnet.TestNet, index int) string {
+	return fmt.Sprintf("/tmp/%s/seed%d", tn.TestNetID, index)
+}
+
+// fetchSeeds downloads and extracts the archives of the url seeds used by the new nodes, once on each
+// server which hosts one of those nodes
+func fetchSeeds(tn *testnet.TestNet, seeds []Seed) error {
+	needed := map[int]map[int]bool{}
+	for _, node := range tn.NewlyBuiltNodes {
+		index := seedIndex(seeds, node.AbsoluteNum)
+		if len(seeds[index].URL) == 0 {
+			continue
+		}
+		if _, ok := needed[node.Server]; !ok {
+			needed[node.Server] = map[int]bool{}
+		}
+		needed[node.Server][index] = true
+	}
+	wg := sync.WaitGroup{}
+	for serverID, indexes := range needed {
+		for index := range indexes {
+			wg.Add(1)
+			go func(client ssh.Client, index int) {
+				defer wg.Done()
+				dir := seedArchiveDir(tn, index)
+				tn.BuildState.Defer(func() { client.Run(fmt.Sprintf("rm -rf %s", dir)) })
+				_, err := client.Run(fmt.Sprintf(
+					"mkdir -p %s && curl -sSfL -o %s.archive '%s' && tar -xf %s.archive -C %s && rm -f %s.archive",
+					dir, dir, seeds[index].URL, dir, dir, dir))
+				if err != nil {
+					tn.BuildState.ReportError(fmt.Errorf("unable to fetch seed %d: %s", index, err.Error()))
+				}
+			}(tn.Clients[serverID], index)
+		}
+	}
+	wg.Wait()
+	return tn.BuildState.GetError()
+}
+
+// SeedChainData extracts the chain data seeds into the data directories of the new nodes, before the
+// blockchain is started on them. It does nothing if no seeds were given.
+func SeedChainData(tn *testnet.TestNet) error {
+	seeds, err := GetSeeds(tn.LDD)
+	if err != nil || len(seeds) == 0 {
+		return util.LogError(err)
+	}
+	tn.BuildState.SetBuildStage("Seeding the chain data")
+	defaultDir := registrar.GetDataDirectory(tn.LDD.Blockchain)
+	for i, seed := range seeds {
+		if len(seed.Dir) == 0 && len(defaultDir) == 0 {
+			return fmt.Errorf("no data directory is known for %s, a dir must be given for seed %d",
+				tn.LDD.Blockchain, i)
+		}
+	}
+
+	err = fetchSeeds(tn, seeds)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	images := map[int]string{}
+	for _, node := range tn.NewlyBuiltNodes {
+		images[node.AbsoluteNum] = node.Image
+	}
+	return helpers.AllNewNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		index := seedIndex(seeds, node.GetAbsoluteNumber())
+		seed := seeds[index]
+		dir := seed.Dir
+		if len(dir) == 0 {
+			dir = defaultDir
+		}
+		_, err := client.DockerExec(node, fmt.Sprintf("mkdir -p %s", dir))
+		if err != nil {
+			return util.LogError(err)
+		}
+		if len(seed.URL) > 0 {
+			return client.DockerCp(node, seedArchiveDir(tn, index)+"/.", dir)
+		}
+		cli := client.Runtime().CLI
+		if len(cli) == 0 {
+			return fmt.Errorf("volume seeds are not supported on %s", client.Runtime().Name)
+		}
+		_, err = client.Run(fmt.Sprintf(
+			"%s run --rm -v %s:/seed:ro --entrypoint tar %s -C /seed -cf - . | %s exec -i %s tar -C %s -xf -",
+			cli, seed.Volume, images[node.GetAbsoluteNumber()], cli, node.GetNodeName(), dir))
+		return util.LogError(err)
+	})
+}
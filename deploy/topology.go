@@ -0,0 +1,100 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package deploy
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	netem "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/topology"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+)
+
+// adjacent checks whether i and j are peers in the given graph
+func adjacent(graph [][]int, i int, j int) bool {
+	if i >= len(graph) {
+		return false
+	}
+	for _, peer := range graph[i] {
+		if peer == j {
+			return true
+		}
+	}
+	return false
+}
+
+// EnforceTopology blocks the traffic between the nodes which are not peers in the topology of the
+// testnet, as outages between them. When nodes are added, the graph is recomputed over all of the nodes,
+// so the outages between the existing nodes are updated to match it as well.
+func EnforceTopology(tn *testnet.TestNet) error {
+	top, err := tn.GetTopology()
+	if err != nil {
+		return util.LogError(err)
+	}
+	if top.Type == topology.Full {
+		return nil
+	}
+	graph, err := top.Graph(len(tn.Nodes))
+	if err != nil {
+		return util.LogError(err)
+	}
+	existing := len(tn.Nodes) - len(tn.NewlyBuiltNodes)
+	oldGraph := [][]int{}
+	if existing > 0 {
+		oldGraph, err = top.Graph(existing)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	tn.BuildState.SetBuildStage("enforcing the topology")
+	nodes := map[int]db.Node{}
+	for _, node := range tn.Nodes {
+		nodes[node.AbsoluteNum] = node
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < len(tn.Nodes); i++ {
+		for j := i + 1; j < len(tn.Nodes); j++ {
+			wasBlocked := j < existing && !adjacent(oldGraph, i, j)
+			block := !adjacent(graph, i, j)
+			if wasBlocked == block {
+				continue
+			}
+			wg.Add(1)
+			go func(node1 db.Node, node2 db.Node, block bool) {
+				defer wg.Done()
+				var err error
+				if block {
+					err = netem.MakeOutage(node1, node2)
+				} else {
+					err = netem.RemoveOutage(node1, node2)
+				}
+				if err != nil {
+					tn.BuildState.ReportError(err)
+				}
+			}(nodes[i], nodes[j], block)
+		}
+	}
+	wg.Wait()
+	if tn.BuildState.ErrorFree() {
+		log.WithFields(log.Fields{"build": tn.TestNetID, "topology": top.Type}).Debug("enforced the topology")
+	}
+	return tn.BuildState.GetError()
+}
@@ -0,0 +1,231 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/docker"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"path"
+	"strconv"
+)
+
+// Upgrade is a rolling upgrade of the image of the nodes of a testnet. The nodes are upgraded in batches,
+// each of which must become healthy before the next is started.
+type Upgrade struct {
+	// Image is the image the nodes are switched to
+	Image string `json:"image"`
+	// Nodes are the absolute numbers of the nodes to upgrade, in the order they are upgraded. Defaults
+	// to all of the nodes.
+	Nodes []int `json:"nodes"`
+	// BatchSize is how many nodes are upgraded at once, defaults to 1
+	BatchSize int `json:"batchSize"`
+	// Preserve are the absolute paths within the nodes which are carried over to their new containers.
+	// Defaults to the data directory of the blockchain.
+	Preserve []string `json:"preserve"`
+}
+
+// Validate ensures that the upgrade can be applied to a testnet of the given blockchain with the given
+// number of nodes
+func (up Upgrade) Validate(blockchain string, nodes int) error {
+	if len(up.Image) == 0 {
+		return fmt.Errorf("an image must be given")
+	}
+	err := util.ValidateCommandLine(up.Image)
+	if err != nil {
+		return err
+	}
+	if up.BatchSize < 0 {
+		return fmt.Errorf("the batch size cannot be negative")
+	}
+	seen := map[int]bool{}
+	for _, node := range up.Nodes {
+		if node < 0 || node >= nodes {
+			return fmt.Errorf("node %d does not exist", node)
+		}
+		if seen[node] {
+			return fmt.Errorf("node %d is given more than once", node)
+		}
+		seen[node] = true
+	}
+	for _, dir := range up.Preserve {
+		err := util.ValidateFilePath(dir)
+		if err != nil {
+			return fmt.Errorf("invalid path to preserve: %s", err.Error())
+		}
+		if !path.IsAbs(dir) || path.Clean(dir) == "/" {
+			return fmt.Errorf("the path to preserve \"%s\" must be an absolute path other than /", dir)
+		}
+	}
+	if len(up.Preserve) == 0 && len(registrar.GetDataDirectory(blockchain)) == 0 {
+		return fmt.Errorf("no data directory is known for %s, the paths to preserve must be given", blockchain)
+	}
+	return nil
+}
+
+// Batches gives the absolute numbers of the nodes to upgrade, out of the given number of nodes,
+// split into the batches they are upgraded in
+func (up Upgrade) Batches(nodes int) [][]int {
+	order := up.Nodes
+	if len(order) == 0 {
+		order = make([]int, nodes)
+		for i := range order {
+			order[i] = i
+		}
+	}
+	size := up.BatchSize
+	if size == 0 {
+		size = 1
+	}
+	out := [][]int{}
+	for i := 0; i < len(order); i += size {
+		end := i + size
+		if end > len(order) {
+			end = len(order)
+		}
+		out = append(out, order[i:end])
+	}
+	return out
+}
+
+// PreservedPaths gives the paths within the nodes which are carried over by the upgrade of a testnet of the
+// given blockchain
+func (up Upgrade) PreservedPaths(blockchain string) []string {
+	if len(up.Preserve) > 0 {
+		return up.Preserve
+	}
+	return []string{registrar.GetDataDirectory(blockchain)}
+}
+
+// upgradeDir gets the directory on a server into which the preserved paths of a node are copied while
+// its container is replaced
+func upgradeDir(tn *testnet.TestNet, node *db.Node) string {
+	return fmt.Sprintf("/tmp/%s/upgrade/node%d", tn.TestNetID, node.AbsoluteNum)
+}
+
+// PrepareUpgrade pulls the image on each of the servers of the testnet, ensuring that it can run on them,
+// before any node is taken down
+func PrepareUpgrade(tn *testnet.TestNet, image string) error {
+	tn.BuildState.SetBuildStage("Pulling " + image)
+	cfg, err := tn.GetKubernetesConfig()
+	if err != nil {
+		return util.LogError(err)
+	}
+	if cfg.Enabled {
+		return fmt.Errorf("rolling upgrades are not supported on kubernetes")
+	}
+	pcfg, err := tn.GetProcessConfig()
+	if err != nil {
+		return util.LogError(err)
+	}
+	if pcfg.Enabled {
+		return fmt.Errorf("rolling upgrades are not supported for nodes which run as processes")
+	}
+	for _, server := range tn.Servers {
+		client := tn.Clients[server.ID]
+		if len(client.Runtime().CLI) == 0 {
+			return fmt.Errorf("rolling upgrades are not supported on %s", client.Runtime().Name)
+		}
+		arch := server.Arch
+		if len(arch) == 0 {
+			arch, err = docker.GetArch(client)
+			if err != nil {
+				return fmt.Errorf("server %d: %s", server.ID, err.Error())
+			}
+		}
+		err = docker.EnsurePlatform(client, image, arch)
+		if err != nil {
+			return fmt.Errorf("server %d: %s", server.ID, err.Error())
+		}
+	}
+	return nil
+}
+
+// UpgradeNode replaces the container of the node with one running the given image, carrying over the given
+// paths and the named volumes of the node, then restarts the blockchain on it with the command it was
+// last started with. The sidecars and the network of the node are left as they are.
+func UpgradeNode(tn *testnet.TestNet, node *db.Node, image string, paths []string) error {
+	var cmd util.Command
+	if !tn.BuildState.GetP(strconv.Itoa(node.AbsoluteNum), &cmd) {
+		return fmt.Errorf("no start command was recorded for node %d", node.AbsoluteNum)
+	}
+	server := tn.GetServer(node.Server)
+	if server == nil {
+		return fmt.Errorf("server %d of node %d not found", node.Server, node.AbsoluteNum)
+	}
+	client := tn.Clients[server.ID]
+	cli := client.Runtime().CLI
+	dir := upgradeDir(tn, node)
+	defer client.Run("rm -rf " + dir)
+
+	for i, src := range paths {
+		_, err := client.Run(fmt.Sprintf("rm -rf %s/%d && mkdir -p %s/%d && %s cp %s:%s %s/%d/",
+			dir, i, dir, i, cli, node.GetNodeName(), path.Clean(src), dir, i))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+
+	err := docker.KillNode(client, node)
+	if err != nil {
+		return util.LogError(err)
+	}
+	resources := nodeResources(tn, node.AbsoluteNum)
+	resources.Ports = node.Ports
+	gpus, err := nodeGPUs(tn, server, node, resources)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = docker.CreateVolumes(client, tn.TestNetID, node.GetNodeName(), resources.Mounts)
+	if err != nil {
+		return util.LogError(err)
+	}
+	node.Image = image
+	err = docker.Run(tn, server.ID, docker.NewNodeContainer(node, nodeEnv(tn, node.AbsoluteNum),
+		resources, server.Subnets(), gpus))
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	for i, dest := range paths {
+		dest = path.Clean(dest)
+		_, err = client.DockerExec(node, "mkdir -p "+path.Dir(dest))
+		if err != nil {
+			return util.LogError(err)
+		}
+		_, err = client.Run(fmt.Sprintf("%s cp %s/%d/%s %s:%s",
+			cli, dir, i, path.Base(dest), node.GetNodeName(), path.Dir(dest)))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	return util.LogError(client.DockerExecdLogAppend(node, cmd.Cmdline))
+}
+
+// WaitForUpgraded waits for the given nodes, which were just upgraded, to pass the health check of the
+// blockchain, as WaitForHealthy does for new nodes
+func WaitForUpgraded(tn *testnet.TestNet, nodes []db.Node) error {
+	previous := tn.NewlyBuiltNodes
+	defer func() { tn.NewlyBuiltNodes = previous }()
+	tn.NewlyBuiltNodes = nodes
+	return WaitForHealthy(tn)
+}
@@ -0,0 +1,140 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package deploy
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"strings"
+	"sync"
+)
+
+const (
+	// FileMissing is the drift state of a file which is no longer in the node
+	FileMissing = "missing"
+	// FileModified is the drift state of a file whose content is not what was copied into the node
+	FileModified = "modified"
+)
+
+// FileDrift is a file of a node which differs from what the build put there
+type FileDrift struct {
+	// Path is where the file is in the node
+	Path string `json:"path"`
+	// State is either missing or modified
+	State string `json:"state"`
+	// Expected is the sha256 checksum of the file as it was copied into the node
+	Expected string `json:"expected"`
+	// Actual is the sha256 checksum of the file which is in the node now
+	Actual string `json:"actual,omitempty"`
+	// Stage is the build stage during which the file was copied into the node
+	Stage string `json:"stage"`
+	// Diff gives the removed lines of the file prefixed with "-" and the added lines prefixed with "+". It
+	// is only given when the content the file was copied with is known.
+	Diff []string `json:"diff,omitempty"`
+}
+
+// NodeDrift is the result of verifying the files of a single node
+type NodeDrift struct {
+	// Node is the absolute number of the node
+	Node int `json:"node"`
+	// ID is the id of the node
+	ID string `json:"id"`
+	// Checked is the number of files which were checked
+	Checked int `json:"checked"`
+	// Drifted is whether any of the files differ from what the build put there
+	Drifted bool        `json:"drifted"`
+	Files   []FileDrift `json:"files"`
+	// Error is why the files of the node couldn't be verified
+	Error string `json:"error,omitempty"`
+}
+
+// VerifyFiles compares the files which the builds of the given testnet copied into each of its nodes, such as
+// the configuration files of the blockchain, against the files which are in the nodes now, and reports the ones
+// which have since gone missing or been modified.
+func VerifyFiles(tn *testnet.TestNet) []NodeDrift {
+	nodes := tn.GetSSHNodes(false, false, -1)
+	out := make([]NodeDrift, len(nodes))
+	wg := sync.WaitGroup{}
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node ssh.Node) {
+			defer wg.Done()
+			out[i] = verifyNode(tn.Clients[node.GetServerID()], node, tn.BuildState.GetExpectedFiles(node.GetNodeName()))
+		}(i, node)
+	}
+	wg.Wait()
+	return out
+}
+
+func verifyNode(client ssh.Client, node ssh.Node, expected []state.ExpectedFile) NodeDrift {
+	out := NodeDrift{Node: node.GetAbsoluteNumber(), ID: node.GetID(), Checked: len(expected), Files: []FileDrift{}}
+	if len(expected) == 0 {
+		return out
+	}
+	actual, err := checksums(client, node, expected)
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	for _, file := range expected {
+		sum, exists := actual[file.Path]
+		if exists && sum == file.Sha256 {
+			continue
+		}
+		drift := FileDrift{Path: file.Path, State: FileMissing, Expected: file.Sha256, Stage: file.Stage}
+		if exists {
+			drift.State = FileModified
+			drift.Actual = sum
+			if len(file.Content) > 0 || file.Size == 0 {
+				content, err := client.DockerExec(node, "cat "+util.ShellQuote(file.Path))
+				if err == nil {
+					drift.Diff = util.DiffLines(file.Content, content)
+				}
+			}
+		}
+		out.Files = append(out.Files, drift)
+	}
+	out.Drifted = len(out.Files) > 0
+	return out
+}
+
+// checksums gets the sha256 checksum of each of the expected files which is in the node, by path
+func checksums(client ssh.Client, node ssh.Node, expected []state.ExpectedFile) (map[string]string, error) {
+	script := ""
+	for _, file := range expected {
+		path := util.ShellQuote(file.Path)
+		script += fmt.Sprintf("if [ -f %s ]; then sha256sum %s; fi;", path, path)
+	}
+	res, err := client.DockerExec(node, "sh -c "+util.ShellQuote(script))
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := map[string]string{}
+	for _, line := range strings.Split(res, "\n") {
+		parts := strings.SplitN(line, "  ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		out[parts[1]] = parts[0]
+	}
+	return out, nil
+}
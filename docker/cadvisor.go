@@ -0,0 +1,52 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docker
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/util"
+	"strings"
+)
+
+// CAdvisorName is the name of the cAdvisor container on each server. It does not start with the node
+// prefix, so that it outlives the testnets on the server.
+const CAdvisorName = "whiteblock-cadvisor"
+
+// EnsureCAdvisor starts cAdvisor on the server, serving its api on conf.CAdvisorPort, unless it is
+// already running there
+func EnsureCAdvisor(client ssh.Client) error {
+	cli := client.Runtime().CLI
+	res, err := client.Run(fmt.Sprintf("%s inspect --format '{{.State.Running}}' %s 2>/dev/null || echo false",
+		cli, CAdvisorName))
+	if err != nil {
+		return util.LogError(err)
+	}
+	if strings.TrimSpace(res) == "true" {
+		return nil
+	}
+	log.WithFields(log.Fields{"image": conf.CAdvisorImage, "port": conf.CAdvisorPort}).Info("starting cadvisor")
+	_, err = client.Run(fmt.Sprintf("%s rm -f %s > /dev/null 2>&1; %s run -d --name %s --restart always "+
+		"--network host --privileged --device /dev/kmsg "+
+		"-v /:/rootfs:ro -v /var/run:/var/run:ro -v /sys:/sys:ro -v /var/lib/docker/:/var/lib/docker:ro "+
+		"-v /dev/disk/:/dev/disk:ro %s --port=%d --docker_only",
+		cli, CAdvisorName, cli, CAdvisorName, conf.CAdvisorImage, conf.CAdvisorPort))
+	return util.LogError(err)
+}
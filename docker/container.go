@@ -19,7 +19,6 @@
 package docker
 
 import (
-	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/util"
@@ -61,40 +60,66 @@ type Container interface {
 
 	// GetResources gets the maximum resource allocation of the node
 	GetResources() util.Resources
+
+	// GetGPUs gets the indexes of the gpus of the server to pass into the container
+	GetGPUs() []int
+
+	// GetEntrypoint gets the entrypoint of the container, empty for the default
+	GetEntrypoint() string
+
+	// GetCommand gets the arguments given to the entrypoint of the container
+	GetCommand() []string
+
+	// GetWorkDir gets the working directory of the container, empty for the default of its image
+	GetWorkDir() string
 }
 
 // ContainerDetails represents a docker containers details
 type ContainerDetails struct {
 	Environment  map[string]string
 	Image        string
+	TestNetID    string
 	Node         int
 	Resources    util.Resources
-	SubnetID     int
+	Subnets      []int
 	NetworkIndex int
 	Type         ContainerType
+	GPUs         []int
+	Entrypoint   string
+	Command      []string
+	WorkDir      string
 }
 
-// NewNodeContainer creates a representation of a container for a regular node
-func NewNodeContainer(node *db.Node, env map[string]string, resources util.Resources, SubnetID int) Container {
+// NewNodeContainer creates a representation of a container for a regular node, on a server with the
+// given subnet ids, which is given the gpus of the server with the given indexes
+func NewNodeContainer(node *db.Node, env map[string]string, resources util.Resources, subnets []int,
+	gpus []int) Container {
 	return &ContainerDetails{
 		Environment:  env,
 		Image:        node.Image,
+		TestNetID:    node.TestNetID,
 		Node:         node.LocalID,
 		Resources:    resources,
-		SubnetID:     SubnetID,
+		Subnets:      subnets,
 		NetworkIndex: 0,
 		Type:         Node,
+		GPUs:         gpus,
+		Entrypoint:   node.Entrypoint,
+		Command:      node.Command,
+		WorkDir:      node.WorkDir,
 	}
 }
 
-// NewSideCarContainer creates a representation of a container for a side car node
-func NewSideCarContainer(sc *db.SideCar, env map[string]string, resources util.Resources, SubnetID int) Container {
+// NewSideCarContainer creates a representation of a container for a side car node, on a server with
+// the given subnet ids
+func NewSideCarContainer(sc *db.SideCar, env map[string]string, resources util.Resources, subnets []int) Container {
 	return &ContainerDetails{
 		Environment:  env,
 		Image:        sc.Image,
+		TestNetID:    sc.TestnetID,
 		Node:         sc.LocalID,
 		Resources:    resources,
-		SubnetID:     SubnetID,
+		Subnets:      subnets,
 		NetworkIndex: sc.NetworkIndex,
 		Type:         SideCar,
 	}
@@ -112,11 +137,15 @@ func (cd *ContainerDetails) GetImage() string {
 
 // GetIP gives the IP address for the container
 func (cd *ContainerDetails) GetIP() (string, error) {
+	subnet, network, err := util.GetNodeNetwork(cd.Subnets, cd.Node)
+	if err != nil {
+		return "", err
+	}
 	switch cd.Type {
 	case Node:
-		return util.GetNodeIP(cd.SubnetID, cd.Node, 0)
+		return util.GetNodeIP(subnet, network, 0)
 	case SideCar:
-		return util.GetNodeIP(cd.SubnetID, cd.Node, cd.NetworkIndex)
+		return util.GetNodeIP(subnet, network, cd.NetworkIndex)
 	}
 	log.Panic("Unsupported type")
 	return "", nil
@@ -126,9 +155,9 @@ func (cd *ContainerDetails) GetIP() (string, error) {
 func (cd *ContainerDetails) GetName() string {
 	switch cd.Type {
 	case Node:
-		return fmt.Sprintf("%s%d", conf.NodePrefix, cd.Node)
+		return util.GetNodeName(cd.TestNetID, cd.Node)
 	case SideCar:
-		return fmt.Sprintf("%s%d-%d", conf.NodePrefix, cd.Node, cd.NetworkIndex)
+		return util.GetSideCarName(cd.TestNetID, cd.Node, cd.NetworkIndex)
 	}
 	log.Panic("Unsupported type")
 	return ""
@@ -141,10 +170,30 @@ func (cd *ContainerDetails) GetPorts() []string {
 
 // GetNetworkName gets the name of the containers network
 func (cd *ContainerDetails) GetNetworkName() string {
-	return fmt.Sprintf("%s%d", conf.NodeNetworkPrefix, cd.Node)
+	return util.GetNetworkName(cd.TestNetID, cd.Node)
 }
 
 // GetResources gets the maximum resource allocation of the node
 func (cd *ContainerDetails) GetResources() util.Resources {
 	return cd.Resources
 }
+
+// GetGPUs gets the indexes of the gpus of the server to pass into the container
+func (cd *ContainerDetails) GetGPUs() []int {
+	return cd.GPUs
+}
+
+// GetEntrypoint gets the entrypoint of the container, empty for the default
+func (cd *ContainerDetails) GetEntrypoint() string {
+	return cd.Entrypoint
+}
+
+// GetCommand gets the arguments given to the entrypoint of the container
+func (cd *ContainerDetails) GetCommand() []string {
+	return cd.Command
+}
+
+// GetWorkDir gets the working directory of the container, empty for the default of its image
+func (cd *ContainerDetails) GetWorkDir() string {
+	return cd.WorkDir
+}
@@ -28,64 +28,83 @@ import (
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
+	"sort"
 	"strings"
 )
 
 var conf = util.GetConfig()
 
-// KillNode kills a single node by index on a server
-func KillNode(client ssh.Client, node int) error {
-	_, err := client.Run(fmt.Sprintf("docker rm -f %s%d", conf.NodePrefix, node))
+// KillNode kills a single node on a server
+func KillNode(client ssh.Client, node ssh.Node) error {
+	_, err := client.Run(fmt.Sprintf("%s rm -f %s", client.Runtime().CLI, node.GetNodeName()))
 	return err
 }
 
 //Kill kills a node and all of its sidecars
-func Kill(client ssh.Client, node int) error {
-	_, err := client.Run(fmt.Sprintf("docker rm -f $(docker ps -aq -f name=\"%s%d\")", conf.NodePrefix, node))
+func Kill(client ssh.Client, node ssh.Node) error {
+	cli := client.Runtime().CLI
+	_, err := client.Run(fmt.Sprintf("%s rm -f $(%s ps -aq -f name=\"%s$\" -f name=\"%s-\")",
+		cli, cli, node.GetNodeName(), node.GetNodeName()))
 	return err
 }
 
 // KillAll kills all nodes on a server
 func KillAll(client ssh.Client) error {
-	_, err := client.Run(fmt.Sprintf("docker rm -f $(docker ps -aq -f name=\"%s\")", conf.NodePrefix))
+	cli := client.Runtime().CLI
+	_, err := client.Run(fmt.Sprintf("%s rm -f $(%s ps -aq -f name=\"%s\")", cli, cli, conf.NodePrefix))
 	return err
 }
 
 /*
    Create the command to a docker network for a node
 */
-func dockerNetworkCreateCmd(subnet string, gateway string, network int, name string) string {
-	return fmt.Sprintf("docker network create --subnet %s --gateway %s -o \"com.docker.network.bridge.name=%s%d\" %s",
+func dockerNetworkCreateCmd(rt util.Runtime, subnet string, gateway string, network int, name string) string {
+	return fmt.Sprintf("%s network create --subnet %s --gateway %s %s %s",
+		rt.CLI,
 		subnet,
 		gateway,
-		conf.BridgePrefix,
-		network,
+		rt.BridgeNameOption(fmt.Sprintf("%s%d", conf.BridgePrefix, network)),
 		name)
 }
 
-// NetworkCreate creates a docker network for a node
-func NetworkCreate(tn *testnet.TestNet, serverID int, subnetID int, node int) error {
-	command := dockerNetworkCreateCmd(
-		util.GetNetworkAddress(subnetID, node),
-		util.GetGateway(subnetID, node),
+// NetworkCreate creates a docker network for a node, on a server with the given subnet ids. The
+// network is named after the testnet and the node, and its subnet is given by the ip block the node falls in.
+func NetworkCreate(tn *testnet.TestNet, serverID int, subnets []int, node int) error {
+	subnet, network, err := util.GetNodeNetwork(subnets, node)
+	if err != nil {
+		return err
+	}
+	command := dockerNetworkCreateCmd(tn.Clients[serverID].Runtime(),
+		util.GetNetworkAddress(subnet, network),
+		util.GetGateway(subnet, network),
 		node,
-		fmt.Sprintf("%s%d", conf.NodeNetworkPrefix, node))
+		util.GetNetworkName(tn.TestNetID, node))
 
-	_, err := tn.Clients[serverID].KeepTryRun(command)
+	_, err = tn.Clients[serverID].KeepTryRun(command)
 
 	return err
 }
 
-// NetworkDestroy tears down a single docker network
-func NetworkDestroy(client ssh.Client, node int) error {
-	_, err := client.Run(fmt.Sprintf("docker network rm %s%d", conf.NodeNetworkPrefix, node))
+// NetworkDestroy tears down the docker network of a node of the testnet, on the given server.
+// Succeeds if the network is removed under any of the names it can have.
+func NetworkDestroy(tn *testnet.TestNet, serverID int, node int) error {
+	client := tn.Clients[serverID]
+	var err error
+	for _, name := range tn.GetNetworkNames(node) {
+		_, err = client.Run(fmt.Sprintf("%s network rm %s", client.Runtime().CLI, name))
+		if err == nil {
+			return nil
+		}
+	}
 	return err
 }
 
 // NetworkDestroyAll removes all whiteblock networks on a node
 func NetworkDestroyAll(client ssh.Client) error {
+	cli := client.Runtime().CLI
 	_, err := client.Run(fmt.Sprintf(
-		"for net in $(docker network ls | grep %s | awk '{print $1}'); do docker network rm $net; done", conf.NodeNetworkPrefix))
+		"for net in $(%s network ls | grep %s | awk '{print $1}'); do %s network rm $net; done",
+		cli, conf.NodeNetworkPrefix, cli))
 	return err
 }
 
@@ -93,20 +112,20 @@ func NetworkDestroyAll(client ssh.Client) error {
 func Login(client ssh.Client, username string, password string) error {
 	user := strings.Replace(username, "\"", "\\\"", -1) //Escape the quotes
 	pass := strings.Replace(password, "\"", "\\\"", -1) //Escape the quotes
-	_, err := client.Run(fmt.Sprintf("docker login -u \"%s\" -p \"%s\"", user, pass))
+	_, err := client.Run(fmt.Sprintf("%s login -u \"%s\" -p \"%s\"", client.Runtime().CLI, user, pass))
 	return err
 }
 
 // Logout is an abstraction of docker logout
 func Logout(client ssh.Client) error {
-	_, err := client.Run("docker logout")
+	_, err := client.Run(client.Runtime().CLI + " logout")
 	return err
 }
 
 // Pull pulls an image on all the given servers
 func Pull(clients []ssh.Client, image string) error {
 	for _, client := range clients {
-		_, err := client.Run("docker pull " + image)
+		_, err := client.Run(client.Runtime().CLI + " pull " + image)
 		if err != nil {
 			return util.LogError(err)
 		}
@@ -115,9 +134,16 @@ func Pull(clients []ssh.Client, image string) error {
 }
 
 // dockerRunCmd makes a docker run command to start a node
-func dockerRunCmd(c Container) (string, error) {
-	command := "docker run -itd --entrypoint /bin/sh "
+func dockerRunCmd(rt util.Runtime, c Container) (string, error) {
+	entrypoint := "/bin/sh"
+	if len(c.GetEntrypoint()) > 0 {
+		entrypoint = util.ShellQuote(c.GetEntrypoint())
+	}
+	command := fmt.Sprintf("%s run -itd --entrypoint %s ", rt.CLI, entrypoint)
 	command += fmt.Sprintf("--network %s", c.GetNetworkName())
+	if len(c.GetWorkDir()) > 0 {
+		command += " -w " + util.ShellQuote(c.GetWorkDir())
+	}
 
 	if !c.GetResources().NoCPULimits() {
 		command += fmt.Sprintf(" --cpus %s", c.GetResources().Cpus)
@@ -136,6 +162,12 @@ func dockerRunCmd(c Container) (string, error) {
 		}
 	}
 
+	command += mountFlags(c.GetName(), c.GetResources().Mounts)
+
+	if len(c.GetGPUs()) > 0 {
+		command += " " + rt.GPUOptions(c.GetGPUs())
+	}
+
 	if !c.GetResources().NoMemoryLimits() {
 		mem, err := c.GetResources().GetMemory()
 		if err != nil {
@@ -143,8 +175,14 @@ func dockerRunCmd(c Container) (string, error) {
 		}
 		command += fmt.Sprintf(" --memory %d", mem)
 	}
-	for key, value := range c.GetEnvironment() {
-		command += fmt.Sprintf(" -e \"%s=%s\"", key, value)
+	env := c.GetEnvironment()
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		command += " -e " + util.ShellQuote(key+"="+env[key])
 	}
 	ip, err := c.GetIP()
 	if err != nil {
@@ -154,12 +192,15 @@ func dockerRunCmd(c Container) (string, error) {
 	command += fmt.Sprintf(" --hostname %s", c.GetName())
 	command += fmt.Sprintf(" --name %s", c.GetName())
 	command += " " + c.GetImage()
+	for _, arg := range c.GetCommand() {
+		command += " " + util.ShellQuote(arg)
+	}
 	return command, nil
 }
 
 // Run starts a node
 func Run(tn *testnet.TestNet, serverID int, container Container) error {
-	command, err := dockerRunCmd(container)
+	command, err := dockerRunCmd(tn.Clients[serverID].Runtime(), container)
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -170,7 +211,7 @@ func Run(tn *testnet.TestNet, serverID int, container Container) error {
 	return nil
 }
 
-func serviceDockerRunCmd(network string, ip string, name string, env map[string]string, volumes []string, ports []string, image string, cmd string) string {
+func serviceDockerRunCmd(rt util.Runtime, network string, ip string, name string, env map[string]string, volumes []string, ports []string, image string, cmd string) string {
 	envFlags := ""
 	for k, v := range env {
 		envFlags += fmt.Sprintf("-e \"%s=%s\" ", k, v)
@@ -194,7 +235,8 @@ func serviceDockerRunCmd(network string, ip string, name string, env map[string]
 		}
 	}
 
-	return fmt.Sprintf("docker run -itd --network %s %s --hostname %s --name %s %s %s %s %s %s",
+	return fmt.Sprintf("%s run -itd --network %s %s --hostname %s --name %s %s %s %s %s %s",
+		rt.CLI,
 		network,
 		ipFlag,
 		name,
@@ -208,13 +250,15 @@ func serviceDockerRunCmd(network string, ip string, name string, env map[string]
 
 // StopServices stops all services and remove the service network from a server
 func StopServices(tn *testnet.TestNet) error {
+	tn.ClearServices()
 	return helpers.AllServerExecCon(tn, func(client ssh.Client, _ *db.Server) error {
-		_, err := client.Run(fmt.Sprintf("docker rm -f $(docker ps -aq -f name=%s)", conf.ServicePrefix))
+		cli := client.Runtime().CLI
+		_, err := client.Run(fmt.Sprintf("%s rm -f $(%s ps -aq -f name=%s)", cli, cli, conf.ServicePrefix))
 		if err != nil {
 			log.WithFields(log.Fields{"error": err}).Info("no service containers to remove")
 		}
 
-		_, err = client.Run("docker network rm " + conf.ServiceNetworkName)
+		_, err = client.Run(cli + " network rm " + conf.ServiceNetworkName)
 		if err != nil {
 			log.WithFields(log.Fields{"error": err}).Info("no service network to remove")
 		}
@@ -223,42 +267,60 @@ func StopServices(tn *testnet.TestNet) error {
 	})
 }
 
-// StartServices creates the service network and starts all the services on a server
+// StartServices creates the service network and starts each of the services, either on the first server
+// of the testnet or, for server scoped services, on every server. The started containers are recorded in tn.Services.
 func StartServices(tn *testnet.TestNet, servs []services.Service) error {
 	gateway, subnet, err := util.GetServiceNetwork()
 	if err != nil {
 		return util.LogError(err)
 	}
-	client := tn.GetFlatClients()[0] //TODO make this nice
-	_, err = client.KeepTryRun(dockerNetworkCreateCmd(subnet, gateway, -1, conf.ServiceNetworkName))
-	if err != nil {
-		return util.LogError(err)
-	}
 	ips, err := services.GetServiceIps(servs)
 	if err != nil {
 		return util.LogError(err)
 	}
+	hasNetwork := map[int]bool{}
 
-	for i, service := range servs {
-		net := conf.ServiceNetworkName
-		ip := ips[service.GetName()]
-		if len(service.GetNetwork()) != 0 {
-			net = service.GetNetwork()
-			ip = ""
+	for _, service := range servs {
+		servers := tn.Servers[:1]
+		if service.GetScope() == services.ServerScope {
+			servers = tn.Servers
 		}
-		err = service.Prepare(client, tn)
-		if err != nil {
-			return util.LogError(err)
-		}
-		_, err = client.KeepTryRun(serviceDockerRunCmd(net, ip,
-			fmt.Sprintf("%s%d", conf.ServicePrefix, i),
-			service.GetEnv(),
-			service.GetVolumes(),
-			service.GetPorts(),
-			service.GetImage(),
-			service.GetCommand()))
-		if err != nil {
-			return util.LogError(err)
+		for _, server := range servers {
+			client := tn.Clients[server.ID]
+			if !hasNetwork[server.ID] {
+				_, err = client.KeepTryRun(dockerNetworkCreateCmd(client.Runtime(), subnet, gateway, -1, conf.ServiceNetworkName))
+				if err != nil {
+					return util.LogError(err)
+				}
+				hasNetwork[server.ID] = true
+			}
+			net := conf.ServiceNetworkName
+			ip := ips[service.GetName()]
+			if len(service.GetNetwork()) != 0 {
+				net = service.GetNetwork()
+				ip = ""
+			}
+			err = service.Prepare(client, tn)
+			if err != nil {
+				return util.LogError(err)
+			}
+			name := util.GetServiceName(tn.TestNetID, service.GetName())
+			_, err = client.KeepTryRun(serviceDockerRunCmd(client.Runtime(), net, ip,
+				name,
+				service.GetEnv(),
+				service.GetVolumes(),
+				service.GetPorts(),
+				service.GetImage(),
+				service.GetCommand()))
+			if err != nil {
+				return util.LogError(err)
+			}
+			details := testnet.ServiceDetails{Name: service.GetName(), Image: service.GetImage(),
+				Container: name, Server: server.ID, IP: ip}
+			if conf.EnablePortForwarding {
+				details.Ports = service.GetPorts()
+			}
+			tn.AddService(details)
 		}
 		tn.BuildState.IncrementDeployProgress()
 	}
@@ -0,0 +1,95 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docker
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+	"strings"
+)
+
+// GetArch gets the cpu architecture of the server, as docker names it
+func GetArch(client ssh.Client) (string, error) {
+	res, err := client.Run("uname -m")
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	arch := util.NormalizeArch(res)
+	if len(arch) == 0 {
+		return "", fmt.Errorf("unable to determine the architecture of the server")
+	}
+	return arch, nil
+}
+
+// getImagePlatform pulls the image if it is not already present on the server, then
+// gets the platform, os/arch, it was built for
+func getImagePlatform(client ssh.Client, image string) (string, error) {
+	cli := client.Runtime().CLI
+	_, err := client.Run(fmt.Sprintf("%s image inspect %s > /dev/null 2>&1 || %s pull %s", cli, image, cli, image))
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	res, err := client.Run(fmt.Sprintf("%s image inspect --format '{{.Os}}/{{.Architecture}}' %s", cli, image))
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	return strings.TrimSpace(res), nil
+}
+
+// EnsurePlatform ensures that the image on the server is built for linux on the given architecture.
+// If it is not, the variant of the image tagged for that architecture, as given by util.PlatformTag, is
+// pulled and tagged as the image on that server instead.
+func EnsurePlatform(client ssh.Client, image string, arch string) error {
+	expected := "linux/" + arch
+	platform, err := getImagePlatform(client, image)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if platform == expected {
+		return nil
+	}
+	alt := util.PlatformTag(image, arch)
+	if len(alt) > 0 {
+		altPlatform, err := getImagePlatform(client, alt)
+		if err == nil && altPlatform == expected {
+			log.WithFields(log.Fields{"image": image, "using": alt, "arch": arch}).Info(
+				"using the variant of the image for the architecture of the server")
+			_, err = client.Run(fmt.Sprintf("%s tag %s %s", client.Runtime().CLI, alt, image))
+			return util.LogError(err)
+		}
+	}
+	return fmt.Errorf("image %s is built for %s, but the server runs %s, and no %s variant of the image was found",
+		image, platform, expected, alt)
+}
+
+// GetGPUCount gets the number of nvidia gpus on the server, which is 0 if nvidia-smi is not installed
+func GetGPUCount(client ssh.Client) (int, error) {
+	res, err := client.Run("nvidia-smi -L 2> /dev/null | grep -c '^GPU' || true")
+	if err != nil {
+		return 0, util.LogError(err)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(res))
+	if err != nil {
+		return 0, util.LogError(err)
+	}
+	return count, nil
+}
@@ -0,0 +1,89 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package docker
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/util"
+)
+
+const (
+	// VolumeTestNetLabel is the label of a named volume which gives the testnet it was created for
+	VolumeTestNetLabel = "genesis.testnet"
+	// VolumePreserveLabel is the label of a named volume which gives whether it is kept after teardown
+	VolumePreserveLabel = "genesis.preserve"
+	// VolumeSizeLabel is the label of a named volume which gives the size hint it was created with
+	VolumeSizeLabel = "genesis.size"
+)
+
+// volumeName gets the name of the named volume with the given name, for the given container
+func volumeName(container string, name string) string {
+	return fmt.Sprintf("%s-%s", container, name)
+}
+
+// mountFlags gets the flags of docker run which mount the given mounts into the container
+func mountFlags(container string, mounts []util.Mount) string {
+	out := ""
+	for _, mount := range mounts {
+		source := volumeName(container, mount.Name)
+		if len(mount.Source) > 0 {
+			if !conf.EnableDockerVolumes {
+				continue
+			}
+			source = mount.Source
+		}
+		out += fmt.Sprintf(" -v %s:%s", source, mount.Target)
+		if mount.ReadOnly {
+			out += ":ro"
+		}
+	}
+	return out
+}
+
+// CreateVolumes creates the named volumes among the given mounts for the container, labelled with the
+// testnet. Volumes which already exist are reused.
+func CreateVolumes(client ssh.Client, testnetID string, container string, mounts []util.Mount) error {
+	cli := client.Runtime().CLI
+	for _, mount := range mounts {
+		if len(mount.Name) == 0 {
+			continue
+		}
+		name := volumeName(container, mount.Name)
+		labels := fmt.Sprintf("--label %s=%s --label %s=%t", VolumeTestNetLabel, testnetID, VolumePreserveLabel, mount.Preserve)
+		if len(mount.Size) > 0 {
+			labels += fmt.Sprintf(" --label %s=%s", VolumeSizeLabel, mount.Size)
+		}
+		_, err := client.Run(fmt.Sprintf("%s volume inspect %s > /dev/null 2>&1 || %s volume create %s %s",
+			cli, name, cli, labels, name))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	return nil
+}
+
+// RemoveVolumes removes the named volumes of the testnet, except for those which are to be preserved
+func RemoveVolumes(client ssh.Client, testnetID string) error {
+	cli := client.Runtime().CLI
+	_, err := client.Run(fmt.Sprintf(
+		"for vol in $(%s volume ls -q --filter label=%s=%s --filter label=%s=false); do %s volume rm $vol; done",
+		cli, VolumeTestNetLabel, testnetID, VolumePreserveLabel, cli))
+	return util.LogError(err)
+}
@@ -0,0 +1,108 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package federation lets a genesis coordinator delegate the builds of the nodes of a testnet to remote
+// genesis agents, one for each region or datacenter, and merges their parts into a single logical testnet.
+// The coordinator talks to the agents through their internal api, which is authenticated with the
+// federationToken they share.
+package federation
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+var conf = util.GetConfig()
+
+const agentsKey = "federation_agents"
+
+var (
+	agentsMux   = sync.Mutex{}
+	regionRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+)
+
+// Agent is a remote genesis which builds the nodes of a region
+type Agent struct {
+	// Region is the name of the region or datacenter of the agent, which identifies it
+	Region string `json:"region"`
+	// URL is the base url of the api of the agent, such as http://10.0.4.2:8000
+	URL string `json:"url"`
+}
+
+// Validate ensures that the agent is valid
+func (agent Agent) Validate() error {
+	if !regionRegex.MatchString(agent.Region) {
+		return fmt.Errorf("invalid region \"%s\"", agent.Region)
+	}
+	uri, err := url.Parse(agent.URL)
+	if err != nil {
+		return err
+	}
+	if uri.Scheme != "http" && uri.Scheme != "https" {
+		return fmt.Errorf("agent url must be http or https")
+	}
+	return nil
+}
+
+func getAgents() map[string]Agent {
+	out := map[string]Agent{}
+	db.GetMetaP(agentsKey, &out) //An error here just means that no agent has been registered yet
+	return out
+}
+
+// GetAgents gets all of the registered agents, sorted by region
+func GetAgents() []Agent {
+	agentsMux.Lock()
+	defer agentsMux.Unlock()
+	out := []Agent{}
+	for _, agent := range getAgents() {
+		out = append(out, agent)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Region < out[j].Region })
+	return out
+}
+
+// SetAgent validates and registers the agent, replacing the agent of the same region if there is one
+func SetAgent(agent Agent) error {
+	err := agent.Validate()
+	if err != nil {
+		return err
+	}
+	agentsMux.Lock()
+	defer agentsMux.Unlock()
+	agents := getAgents()
+	agents[agent.Region] = agent
+	return db.SetMeta(agentsKey, agents)
+}
+
+// RemoveAgent removes the agent of the given region
+func RemoveAgent(region string) error {
+	agentsMux.Lock()
+	defer agentsMux.Unlock()
+	agents := getAgents()
+	if _, ok := agents[region]; !ok {
+		return fmt.Errorf("there is no agent for region \"%s\"", region)
+	}
+	delete(agents, region)
+	return db.SetMeta(agentsKey, agents)
+}
@@ -0,0 +1,47 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package federation
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestAgent_Validate(t *testing.T) {
+	var test = []struct {
+		agent Agent
+		valid bool
+	}{
+		{agent: Agent{Region: "us-east-1", URL: "http://10.0.4.2:8000"}, valid: true},
+		{agent: Agent{Region: "eu_west.2", URL: "https://genesis.example.com"}, valid: true},
+		{agent: Agent{Region: "", URL: "http://10.0.4.2:8000"}, valid: false},
+		{agent: Agent{Region: "us east", URL: "http://10.0.4.2:8000"}, valid: false},
+		{agent: Agent{Region: "us-east-1", URL: "ftp://10.0.4.2"}, valid: false},
+		{agent: Agent{Region: "us-east-1", URL: ":nope"}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.agent.Validate()
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate returned %v, expected valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
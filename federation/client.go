@@ -0,0 +1,118 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package federation
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TokenHeader is the header in which the coordinator gives the federation token to the agents
+const TokenHeader = "X-Genesis-Federation-Token"
+
+// requestTimeout limits how long a request to an agent can take
+const requestTimeout = 30 * time.Second
+
+// PartStatus is the state of the part of a federated testnet built by an agent, as reported by the agent
+type PartStatus struct {
+	// Build is the status of the build, as given by GET /status/build/{id}
+	Build json.RawMessage `json:"build"`
+	// Nodes are the nodes of the part, numbered from 0
+	Nodes []db.Node `json:"nodes"`
+}
+
+// Authorize checks that the request to the internal api of an agent comes from its coordinator
+func Authorize(r *http.Request) error {
+	if len(conf.FederationToken) == 0 {
+		return fmt.Errorf("this genesis is not a federation agent, federationToken is not set")
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(TokenHeader)), []byte(conf.FederationToken)) != 1 {
+		return fmt.Errorf("invalid federation token")
+	}
+	return nil
+}
+
+// request makes a request to the internal api of the agent, decoding the response into out unless it is nil.
+// authorization is passed on to the agent, so that it builds on behalf of the same user.
+func (agent Agent) request(method string, path string, body interface{}, authorization string, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(agent.URL, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TokenHeader, conf.FederationToken)
+	if len(authorization) > 0 {
+		req.Header.Set("Authorization", authorization)
+	}
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("agent %s responded with %d: %s", agent.Region, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	if raw, ok := out.(*string); ok {
+		*raw = string(data)
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// build starts the build of a part on the agent, returning the id of the testnet on the agent
+func (agent Agent) build(details db.DeploymentDetails, authorization string) (string, error) {
+	var id string
+	err := agent.request("POST", "/federation/agent/testnets", details, authorization, &id)
+	return strings.TrimSpace(id), err
+}
+
+// status gets the state of a part from the agent
+func (agent Agent) status(testnetID string) (PartStatus, error) {
+	var out PartStatus
+	err := agent.request("GET", "/federation/agent/testnets/"+testnetID, nil, "", &out)
+	return out, err
+}
+
+// destroy tears down a part on the agent
+func (agent Agent) destroy(testnetID string) error {
+	return agent.request("DELETE", "/federation/agent/testnets/"+testnetID, nil, "", nil)
+}
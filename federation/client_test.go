@@ -0,0 +1,105 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package federation
+
+import (
+	"encoding/json"
+	"github.com/whiteblock/genesis/db"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func withToken(t *testing.T, token string) {
+	original := conf.FederationToken
+	conf.FederationToken = token
+	t.Cleanup(func() { conf.FederationToken = original })
+}
+
+func TestAuthorize(t *testing.T) {
+	req := httptest.NewRequest("GET", "/federation/agent/testnets/1", nil)
+	withToken(t, "")
+	if Authorize(req) == nil {
+		t.Error("expected an error when federationToken is not set")
+	}
+	withToken(t, "secret")
+	if Authorize(req) == nil {
+		t.Error("expected an error without the token")
+	}
+	req.Header.Set(TokenHeader, "wrong")
+	if Authorize(req) == nil {
+		t.Error("expected an error with the wrong token")
+	}
+	req.Header.Set(TokenHeader, "secret")
+	if err := Authorize(req); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAgent_requests(t *testing.T) {
+	withToken(t, "secret")
+	var received db.DeploymentDetails
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Authorize(r) != nil {
+			http.Error(w, "forbidden", 403)
+			return
+		}
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/federation/agent/testnets":
+			if r.Header.Get("Authorization") != "Bearer abc" {
+				http.Error(w, "missing the authorization", 403)
+				return
+			}
+			json.NewDecoder(r.Body).Decode(&received)
+			w.Write([]byte("remote-1"))
+		case r.Method == "GET" && r.URL.Path == "/federation/agent/testnets/remote-1":
+			json.NewEncoder(w).Encode(PartStatus{Build: json.RawMessage(`{"progress":100,"error":null}`),
+				Nodes: []db.Node{{AbsoluteNum: 0, IP: "10.1.0.2"}}})
+		case r.Method == "DELETE" && r.URL.Path == "/federation/agent/testnets/remote-1":
+			w.Write([]byte("Success"))
+		default:
+			http.Error(w, "not found", 404)
+		}
+	}))
+	defer server.Close()
+	agent := Agent{Region: "us-east-1", URL: server.URL + "/"}
+
+	id, err := agent.build(db.DeploymentDetails{Blockchain: "geth", Nodes: 3}, "Bearer abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "remote-1" || received.Blockchain != "geth" || received.Nodes != 3 {
+		t.Errorf("unexpected build %s of %+v", id, received)
+	}
+	res, err := agent.status(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Nodes) != 1 || res.Nodes[0].IP != "10.1.0.2" || parseBuildStatus(res.Build).State != DoneState {
+		t.Errorf("unexpected status %+v", res)
+	}
+	err = agent.destroy(id)
+	if err != nil {
+		t.Error(err)
+	}
+	err = agent.destroy("remote-2")
+	if err == nil {
+		t.Error("expected an error destroying an unknown testnet")
+	}
+}
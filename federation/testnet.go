@@ -0,0 +1,386 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package federation
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"strings"
+	"sync"
+	"time"
+)
+
+const testnetsKey = "federation_testnets"
+
+const (
+	// BuildingState is the state of a part which is still being built
+	BuildingState = "building"
+	// DoneState is the state of a part which was built
+	DoneState = "done"
+	// FailedState is the state of a part whose build failed
+	FailedState = "failed"
+	// UnknownState is the state of a part whose agent could not be reached
+	UnknownState = "unknown"
+)
+
+var testnetsMux = sync.Mutex{}
+
+// Region is the share of the nodes of a federated testnet which is built by the agent of a region
+type Region struct {
+	// Region is the region of the agent
+	Region string `json:"region"`
+	// Nodes is the number of nodes the agent builds
+	Nodes int `json:"nodes"`
+	// Servers are the ids of the servers of the agent to build on
+	Servers []int `json:"servers"`
+}
+
+// Request is a request for a federated testnet
+type Request struct {
+	// Details are the deployment details of the whole testnet. The nodes are split between the regions
+	// in order, along with their images, resources, environments, files and logs.
+	Details db.DeploymentDetails `json:"details"`
+	Regions []Region             `json:"regions"`
+}
+
+// Part is the part of a federated testnet which is built by the agent of a region
+type Part struct {
+	Region string `json:"region"`
+	// TestNetID is the id of the testnet of the part on the agent
+	TestNetID string `json:"testnetId"`
+	Nodes     int    `json:"nodes"`
+	// Offset is the absolute number of the first node of the part in the federated testnet
+	Offset int `json:"offset"`
+}
+
+// TestNet is a federated testnet
+type TestNet struct {
+	ID         string    `json:"id"`
+	Blockchain string    `json:"blockchain"`
+	Created    time.Time `json:"created"`
+	Parts      []Part    `json:"parts"`
+}
+
+// PartState is the state of a part of a federated testnet
+type PartState struct {
+	Part
+	State    string  `json:"state"`
+	Stage    string  `json:"stage,omitempty"`
+	Progress float64 `json:"progress"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Node is a node of a federated testnet
+type Node struct {
+	db.Node
+	// Region is the region of the agent which built the node. The absolute number of the node is its number
+	// in the federated testnet, while its testnet id is the id of the testnet of its part on the agent.
+	Region string `json:"region"`
+}
+
+// Status is the state of a federated testnet, with its nodes
+type Status struct {
+	TestNet
+	// State is the state of the testnet as a whole, the worst of the states of its parts
+	State string      `json:"state"`
+	Parts []PartState `json:"parts"`
+	Nodes []Node      `json:"nodes"`
+}
+
+// Validate ensures that the request can be built with the given agents
+func (req Request) Validate(agents []Agent) error {
+	if len(req.Regions) == 0 {
+		return fmt.Errorf("a federated testnet needs at least one region")
+	}
+	known := map[string]bool{}
+	for _, agent := range agents {
+		known[agent.Region] = true
+	}
+	seen := map[string]bool{}
+	for _, region := range req.Regions {
+		if !known[region.Region] {
+			return fmt.Errorf("there is no agent for region \"%s\"", region.Region)
+		}
+		if seen[region.Region] {
+			return fmt.Errorf("the region \"%s\" is given more than once", region.Region)
+		}
+		seen[region.Region] = true
+		if region.Nodes <= 0 {
+			return fmt.Errorf("the region \"%s\" needs at least one node", region.Region)
+		}
+		if len(region.Servers) == 0 {
+			return fmt.Errorf("the region \"%s\" needs at least one server", region.Region)
+		}
+	}
+	return nil
+}
+
+// perNode gets the index of the value of each of the n nodes starting at offset, out of count values
+// given for each node, a node without a value getting the first one. Returns nil if there are no values
+// or a single value for all of the nodes.
+func perNode(count int, offset int, n int) []int {
+	if count <= 1 {
+		return nil
+	}
+	out := make([]int, n)
+	for i := range out {
+		if offset+i < count {
+			out[i] = offset + i
+		}
+	}
+	return out
+}
+
+// partDetails gets the deployment details of the part of the region, whose first node is at offset
+func partDetails(details db.DeploymentDetails, region Region, offset int) db.DeploymentDetails {
+	out := details
+	out.ID = ""
+	out.Nodes = region.Nodes
+	out.Servers = region.Servers
+	if indices := perNode(len(details.Images), offset, region.Nodes); indices != nil {
+		out.Images = make([]string, len(indices))
+		for i, j := range indices {
+			out.Images[i] = details.Images[j]
+		}
+	}
+	if indices := perNode(len(details.Resources), offset, region.Nodes); indices != nil {
+		out.Resources = make([]util.Resources, len(indices))
+		for i, j := range indices {
+			out.Resources[i] = details.Resources[j]
+		}
+	}
+	split := func(values []map[string]string) []map[string]string {
+		indices := perNode(len(values), offset, region.Nodes)
+		if indices == nil {
+			return values
+		}
+		out := make([]map[string]string, len(indices))
+		for i, j := range indices {
+			out[i] = values[j]
+		}
+		return out
+	}
+	out.Environments = split(details.Environments)
+	out.Files = split(details.Files)
+	out.Logs = split(details.Logs)
+	return out
+}
+
+func getTestNets() []TestNet {
+	out := []TestNet{}
+	db.GetMetaP(testnetsKey, &out) //An error here just means that there are no federated testnets
+	return out
+}
+
+// List gets all of the federated testnets
+func List() []TestNet {
+	testnetsMux.Lock()
+	defer testnetsMux.Unlock()
+	return getTestNets()
+}
+
+// Get gets the federated testnet with the given id
+func Get(id string) (TestNet, error) {
+	for _, tn := range List() {
+		if tn.ID == id {
+			return tn, nil
+		}
+	}
+	return TestNet{}, fmt.Errorf("federated testnet \"%s\" not found", id)
+}
+
+func getAgent(region string) (Agent, error) {
+	for _, agent := range GetAgents() {
+		if agent.Region == region {
+			return agent, nil
+		}
+	}
+	return Agent{}, fmt.Errorf("there is no agent for region \"%s\"", region)
+}
+
+// Build starts building each part of the federated testnet on the agent of its region, returning the
+// id of the federated testnet once all of the builds have been started. If any of them cannot be started,
+// the parts which were started are torn down. authorization is the authorization header of the caller,
+// which is passed on to the agents.
+func Build(req Request, authorization string) (string, error) {
+	if len(conf.FederationToken) == 0 {
+		return "", fmt.Errorf("federationToken must be set to build on agents")
+	}
+	agents := GetAgents()
+	err := req.Validate(agents)
+	if err != nil {
+		return "", err
+	}
+	id, err := util.GetUUIDString()
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	tn := TestNet{ID: id, Blockchain: req.Details.Blockchain, Created: time.Now(), Parts: make([]Part, len(req.Regions))}
+	errs := make([]error, len(req.Regions))
+	offset := 0
+	wg := sync.WaitGroup{}
+	for i, region := range req.Regions {
+		tn.Parts[i] = Part{Region: region.Region, Nodes: region.Nodes, Offset: offset}
+		wg.Add(1)
+		go func(i int, region Region, offset int) {
+			defer wg.Done()
+			agent, err := getAgent(region.Region)
+			if err == nil {
+				tn.Parts[i].TestNetID, err = agent.build(partDetails(req.Details, region, offset), authorization)
+			}
+			errs[i] = err
+		}(i, region, offset)
+		offset += region.Nodes
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		for _, part := range tn.Parts {
+			if len(part.TestNetID) > 0 {
+				util.LogError(destroyPart(part))
+			}
+		}
+		return "", fmt.Errorf("could not start the build in region \"%s\": %s", req.Regions[i].Region, err.Error())
+	}
+
+	testnetsMux.Lock()
+	defer testnetsMux.Unlock()
+	log.WithFields(log.Fields{"testnet": id, "parts": len(tn.Parts), "nodes": offset}).Info(
+		"started a federated build")
+	return id, db.SetMeta(testnetsKey, append(getTestNets(), tn))
+}
+
+func destroyPart(part Part) error {
+	agent, err := getAgent(part.Region)
+	if err != nil {
+		return err
+	}
+	return agent.destroy(part.TestNetID)
+}
+
+// parseBuildStatus gets the state of a part from the status of its build
+func parseBuildStatus(raw json.RawMessage) PartState {
+	var build struct {
+		Progress float64                `json:"progress"`
+		Stage    string                 `json:"stage"`
+		Error    map[string]interface{} `json:"error"`
+	}
+	err := json.Unmarshal(raw, &build)
+	if err != nil {
+		return PartState{State: UnknownState, Error: fmt.Sprintf("invalid build status: %s", err.Error())}
+	}
+	out := PartState{State: BuildingState, Stage: build.Stage, Progress: build.Progress}
+	if build.Error != nil {
+		out.State = FailedState
+		out.Error = fmt.Sprint(build.Error["what"])
+	} else if build.Progress >= 100 {
+		out.State = DoneState
+	}
+	return out
+}
+
+// worstState gets the state of a testnet from the states of its parts
+func worstState(parts []PartState) string {
+	rank := map[string]int{DoneState: 0, BuildingState: 1, UnknownState: 2, FailedState: 3}
+	out := DoneState
+	for _, part := range parts {
+		if rank[part.State] > rank[out] {
+			out = part.State
+		}
+	}
+	return out
+}
+
+// mergeNodes numbers the nodes of the parts as a single testnet
+func mergeNodes(parts []Part, nodes [][]db.Node) []Node {
+	out := []Node{}
+	for i, part := range parts {
+		for _, node := range nodes[i] {
+			node.AbsoluteNum += part.Offset
+			out = append(out, Node{Node: node, Region: part.Region})
+		}
+	}
+	return out
+}
+
+// GetStatus gets the state of each of the parts of the federated testnet from their agents, along with
+// their nodes
+func GetStatus(id string) (Status, error) {
+	tn, err := Get(id)
+	if err != nil {
+		return Status{}, err
+	}
+	parts := make([]PartState, len(tn.Parts))
+	nodes := make([][]db.Node, len(tn.Parts))
+	wg := sync.WaitGroup{}
+	for i, part := range tn.Parts {
+		wg.Add(1)
+		go func(i int, part Part) {
+			defer wg.Done()
+			agent, err := getAgent(part.Region)
+			var res PartStatus
+			if err == nil {
+				res, err = agent.status(part.TestNetID)
+			}
+			if err != nil {
+				parts[i] = PartState{State: UnknownState, Error: err.Error()}
+			} else {
+				parts[i] = parseBuildStatus(res.Build)
+				nodes[i] = res.Nodes
+			}
+			parts[i].Part = part
+		}(i, part)
+	}
+	wg.Wait()
+	return Status{TestNet: tn, State: worstState(parts), Parts: parts, Nodes: mergeNodes(tn.Parts, nodes)}, nil
+}
+
+// Destroy tears down each of the parts of the federated testnet, then forgets it. It is kept if any of
+// the parts could not be torn down, so that it can be tried again.
+func Destroy(id string) error {
+	tn, err := Get(id)
+	if err != nil {
+		return err
+	}
+	errs := []string{}
+	for _, part := range tn.Parts {
+		err := destroyPart(part)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", part.Region, err.Error()))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("could not tear down all of the parts: %s", strings.Join(errs, ", "))
+	}
+	testnetsMux.Lock()
+	defer testnetsMux.Unlock()
+	out := []TestNet{}
+	for _, other := range getTestNets() {
+		if other.ID != id {
+			out = append(out, other)
+		}
+	}
+	return db.SetMeta(testnetsKey, out)
+}
@@ -0,0 +1,151 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package federation
+
+import (
+	"encoding/json"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestRequest_Validate(t *testing.T) {
+	agents := []Agent{{Region: "us"}, {Region: "eu"}}
+	var test = []struct {
+		regions []Region
+		valid   bool
+	}{
+		{regions: []Region{{Region: "us", Nodes: 2, Servers: []int{1}}, {Region: "eu", Nodes: 3, Servers: []int{1, 2}}}, valid: true},
+		{regions: []Region{}, valid: false},
+		{regions: []Region{{Region: "asia", Nodes: 2, Servers: []int{1}}}, valid: false},
+		{regions: []Region{{Region: "us", Nodes: 2, Servers: []int{1}}, {Region: "us", Nodes: 2, Servers: []int{1}}}, valid: false},
+		{regions: []Region{{Region: "us", Nodes: 0, Servers: []int{1}}}, valid: false},
+		{regions: []Region{{Region: "us", Nodes: 2}}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := Request{Regions: tt.regions}.Validate(agents)
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate returned %v, expected valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestPartDetails(t *testing.T) {
+	details := db.DeploymentDetails{
+		ID:           "original",
+		Servers:      []int{1, 2},
+		Blockchain:   "geth",
+		Nodes:        5,
+		Images:       []string{"a", "b", "c"},
+		Resources:    []util.Resources{{Cpus: "1"}},
+		Environments: []map[string]string{{"N": "0"}, {"N": "1"}, {"N": "2"}, {"N": "3"}, {"N": "4"}},
+	}
+	part := partDetails(details, Region{Region: "eu", Nodes: 3, Servers: []int{7}}, 2)
+	if part.ID != "" || part.Nodes != 3 || !reflect.DeepEqual(part.Servers, []int{7}) || part.Blockchain != "geth" {
+		t.Errorf("unexpected details %+v", part)
+	}
+	if !reflect.DeepEqual(part.Images, []string{"c", "a", "a"}) {
+		t.Errorf("unexpected images %v", part.Images)
+	}
+	if !reflect.DeepEqual(part.Resources, details.Resources) {
+		t.Errorf("expected the shared resources to be kept, got %v", part.Resources)
+	}
+	if !reflect.DeepEqual(part.Environments, []map[string]string{{"N": "2"}, {"N": "3"}, {"N": "4"}}) {
+		t.Errorf("unexpected environments %v", part.Environments)
+	}
+	if part.Files != nil || part.Logs != nil {
+		t.Errorf("expected no files or logs, got %v and %v", part.Files, part.Logs)
+	}
+}
+
+func TestParseBuildStatus(t *testing.T) {
+	var test = []struct {
+		raw      string
+		expected PartState
+	}{
+		{raw: `{"progress":42.5,"error":null,"stage":"Starting geth"}`,
+			expected: PartState{State: BuildingState, Stage: "Starting geth", Progress: 42.5}},
+		{raw: `{"progress":100,"error":null,"stage":"Finished"}`,
+			expected: PartState{State: DoneState, Stage: "Finished", Progress: 100}},
+		{raw: `{"progress":12,"error":{"what":"too many nodes"},"stage":"Provisioning"}`,
+			expected: PartState{State: FailedState, Stage: "Provisioning", Progress: 12, Error: "too many nodes"}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			state := parseBuildStatus(json.RawMessage(tt.raw))
+			if !reflect.DeepEqual(state, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, state)
+			}
+		})
+	}
+	if parseBuildStatus(json.RawMessage("<html>")).State != UnknownState {
+		t.Error("expected an invalid status to be unknown")
+	}
+}
+
+func TestWorstState(t *testing.T) {
+	var test = []struct {
+		states   []string
+		expected string
+	}{
+		{states: []string{DoneState, DoneState}, expected: DoneState},
+		{states: []string{DoneState, BuildingState}, expected: BuildingState},
+		{states: []string{UnknownState, BuildingState}, expected: UnknownState},
+		{states: []string{FailedState, UnknownState, DoneState}, expected: FailedState},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			parts := []PartState{}
+			for _, state := range tt.states {
+				parts = append(parts, PartState{State: state})
+			}
+			if state := worstState(parts); state != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, state)
+			}
+		})
+	}
+}
+
+func TestMergeNodes(t *testing.T) {
+	parts := []Part{{Region: "us", Nodes: 2, Offset: 0}, {Region: "eu", Nodes: 2, Offset: 2}}
+	nodes := [][]db.Node{
+		{{AbsoluteNum: 0, IP: "10.0.0.2"}, {AbsoluteNum: 1, IP: "10.0.0.6"}},
+		{{AbsoluteNum: 1, IP: "10.1.0.6"}, {AbsoluteNum: 0, IP: "10.1.0.2"}},
+	}
+	expected := []Node{
+		{Node: db.Node{AbsoluteNum: 0, IP: "10.0.0.2"}, Region: "us"},
+		{Node: db.Node{AbsoluteNum: 1, IP: "10.0.0.6"}, Region: "us"},
+		{Node: db.Node{AbsoluteNum: 3, IP: "10.1.0.6"}, Region: "eu"},
+		{Node: db.Node{AbsoluteNum: 2, IP: "10.1.0.2"}, Region: "eu"},
+	}
+	merged := mergeNodes(parts, nodes)
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %+v, got %+v", expected, merged)
+	}
+	if len(mergeNodes(parts, make([][]db.Node, 2))) != 0 {
+		t.Error("expected no nodes from unreachable agents")
+	}
+}
@@ -1,17 +1,21 @@
 module github.com/whiteblock/genesis
 
-go 1.12
+go 1.16
 
 require (
 	github.com/btcsuite/btcd v0.0.0-20190427004231-96897255fd17 // indirect
+	github.com/creack/pty v1.1.11
 	github.com/ethereum/go-ethereum v1.8.27
 	github.com/golang/mock v1.1.1
 	github.com/gorilla/mux v1.7.1
+	github.com/gorilla/websocket v1.4.1
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51 // indirect
 	github.com/libp2p/go-libp2p-crypto v0.1.0
 	github.com/libp2p/go-libp2p-peer v0.2.0
 	github.com/mattn/go-sqlite3 v1.10.0
 	github.com/sirupsen/logrus v1.4.1
+	github.com/spf13/cobra v0.0.6
+	github.com/spf13/pflag v1.0.3
 	github.com/spf13/viper v1.4.0
 	github.com/tmc/scp v0.0.0-20170824174625-f7b48647feef // indirect
 	github.com/whiteblock/go.uuid v1.2.1
@@ -19,4 +23,5 @@ require (
 	github.com/whiteblock/scp v0.0.0-20190401151346-3a0c9dc7020d
 	golang.org/x/crypto v0.0.0-20190530122614-20be4c3c3ed5
 	golang.org/x/sync v0.0.0-20190423024810-112230192c58
+	gopkg.in/yaml.v2 v2.2.2
 )
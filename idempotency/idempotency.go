@@ -0,0 +1,135 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package idempotency keeps the responses given to requests made with an idempotency key, so that a
+// client retrying a request, such as a build, is given the original response rather than having the
+// request carried out a second time.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+	"time"
+)
+
+const (
+	// metaKey is the meta key the records are kept under
+	metaKey = "idempotency"
+	// maxKeyLength is the longest an idempotency key can be
+	maxKeyLength = 255
+	// TTL is how long the response to a request is kept for its idempotency key
+	TTL = 24 * time.Hour
+)
+
+var (
+	mux        = sync.Mutex{}
+	inProgress = map[string]bool{}
+)
+
+// Record is the response given to the first request made with an idempotency key
+type Record struct {
+	// Hash is the hash of the request, which a retry must match
+	Hash string `json:"hash"`
+	// Status is the status code of the response
+	Status int `json:"status"`
+	// ContentType is the content type of the response
+	ContentType string `json:"contentType,omitempty"`
+	// Body is the body of the response
+	Body []byte `json:"body"`
+	// Created is when the response was given, as a unix timestamp
+	Created int64 `json:"created"`
+}
+
+// Expired checks whether the record is older than TTL, at which point its key can be reused
+func (rec Record) Expired(now time.Time) bool {
+	return now.Sub(time.Unix(rec.Created, 0)) > TTL
+}
+
+// ValidateKey ensures that the given idempotency key is not empty, not too long and only made of
+// printable ascii characters
+func ValidateKey(key string) error {
+	if len(key) == 0 {
+		return fmt.Errorf("the idempotency key is empty")
+	}
+	if len(key) > maxKeyLength {
+		return fmt.Errorf("the idempotency key is longer than %d characters", maxKeyLength)
+	}
+	for _, c := range key {
+		if c < ' ' || c > '~' {
+			return fmt.Errorf("the idempotency key contains a character which is not printable ascii")
+		}
+	}
+	return nil
+}
+
+// Hash gets the hash of a request, from its method, path and body. A retry of a request must have
+// the same hash.
+func Hash(method string, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func getRecords() map[string]Record {
+	out := map[string]Record{}
+	db.GetMetaP(metaKey, &out) //An error means no request has been made with an idempotency key
+	return out
+}
+
+// Begin gets the record kept for the given key. If there is none, the key is marked as in
+// progress until Finish is called with it, and an error is given to any request made with the
+// key in the meantime.
+func Begin(key string) (Record, bool, error) {
+	mux.Lock()
+	defer mux.Unlock()
+	if inProgress[key] {
+		return Record{}, false, fmt.Errorf("a request with the idempotency key \"%s\" is still in progress", key)
+	}
+	rec, ok := getRecords()[key]
+	if ok && !rec.Expired(time.Now()) {
+		return rec, true, nil
+	}
+	inProgress[key] = true
+	return Record{}, false, nil
+}
+
+// Finish stores the record for the given key, which was given by Begin, and releases the key.
+// If rec is nil, nothing is stored, so that the request can be retried.
+func Finish(key string, rec *Record) error {
+	mux.Lock()
+	defer mux.Unlock()
+	delete(inProgress, key)
+	if rec == nil {
+		return nil
+	}
+	now := time.Now()
+	rec.Created = now.Unix()
+	records := getRecords()
+	for k, r := range records {
+		if r.Expired(now) {
+			delete(records, k)
+		}
+	}
+	records[key] = *rec
+	return util.LogError(db.SetMeta(metaKey, records))
+}
@@ -0,0 +1,86 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package idempotency
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateKey(t *testing.T) {
+	var test = []struct {
+		key string
+		err bool
+	}{
+		{key: "a1b2c3", err: false},
+		{key: "8e03978e-40d5-43e8-bc93-6894a57f9324", err: false},
+		{key: strings.Repeat("a", maxKeyLength), err: false},
+		{key: "", err: true},
+		{key: strings.Repeat("a", maxKeyLength+1), err: true},
+		{key: "a\nb", err: true},
+		{key: "clé", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := ValidateKey(tt.key)
+			if (err != nil) != tt.err {
+				t.Errorf("unexpected result from ValidateKey: %v", err)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	hash := Hash("POST", "/testnets", []byte(`{"nodes":2}`))
+	if Hash("POST", "/testnets", []byte(`{"nodes":2}`)) != hash {
+		t.Error("the same request gave two different hashes")
+	}
+	if Hash("POST", "/testnets", []byte(`{"nodes":3}`)) == hash {
+		t.Error("requests with different bodies gave the same hash")
+	}
+	if Hash("DELETE", "/testnets", []byte(`{"nodes":2}`)) == hash {
+		t.Error("requests with different methods gave the same hash")
+	}
+	if Hash("POST", "/templates/geth/build", []byte(`{"nodes":2}`)) == hash {
+		t.Error("requests with different paths gave the same hash")
+	}
+}
+
+func TestRecord_Expired(t *testing.T) {
+	now := time.Now()
+	var test = []struct {
+		created  time.Time
+		expected bool
+	}{
+		{created: now, expected: false},
+		{created: now.Add(-TTL + time.Minute), expected: false},
+		{created: now.Add(-TTL - time.Minute), expected: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if (Record{Created: tt.created.Unix()}).Expired(now) != tt.expected {
+				t.Errorf("return value of Expired does not match expected value")
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+//go:build integration
+// +build integration
+
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package integration
+
+import (
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/manager"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/util"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	var test = []struct {
+		blockchain string
+		image      string
+		nodes      int
+	}{
+		{blockchain: "tendermint", image: "gcr.io/whiteblock/tendermint:dev", nodes: 2},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.blockchain, func(t *testing.T) {
+			id, err := util.GetUUIDString()
+			if err != nil {
+				t.Fatal(err)
+			}
+			details := &db.DeploymentDetails{
+				Servers:    []int{server.ID},
+				Blockchain: tt.blockchain,
+				Nodes:      tt.nodes,
+				Images:     []string{tt.image},
+			}
+			err = state.AcquireBuilding(details.Servers, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = manager.AddTestNet(details, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer manager.DeleteTestNet(id)
+
+			nodes, err := db.GetAllNodesByTestNet(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(nodes) != tt.nodes {
+				t.Errorf("built %d nodes, expected %d", len(nodes), tt.nodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+//go:build integration
+// +build integration
+
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package integration
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var conf = util.GetConfig()
+
+// newTestNet creates a testnet on the harness server with containers for the given number of
+// nodes, which do nothing but sleep
+func newTestNet(t *testing.T, nodes int) *testnet.TestNet {
+	id, err := util.GetUUIDString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers := []int{server.ID}
+	err = state.AcquireBuilding(servers, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tn, err := testnet.NewTestNet(db.DeploymentDetails{
+		Servers: servers,
+		Nodes:   nodes,
+		Images:  []string{"alpine:3.10"},
+	}, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < nodes; i++ {
+		node := tn.AddNode(db.Node{TestNetID: id, Server: server.ID, LocalID: i})
+		_, err = tn.Clients[server.ID].Run(fmt.Sprintf("docker run -d --name %s %s sleep 600",
+			node.GetNodeName(), node.Image))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tn
+}
+
+func removeTestNet(tn *testnet.TestNet) {
+	for _, node := range tn.Nodes {
+		tn.Clients[server.ID].Run("docker rm -f " + node.GetNodeName())
+	}
+	tn.FinishedBuilding()
+}
+
+func TestCopyBytesToAllNodes(t *testing.T) {
+	tn := newTestNet(t, 3)
+	defer removeTestNet(tn)
+
+	err := helpers.CopyBytesToAllNodes(tn, "copied bytes", "/copied.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, node := range tn.Nodes {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := tn.Clients[server.ID].DockerRead(node, "/copied.txt", -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(out) != "copied bytes" {
+				t.Errorf("node %d has \"%s\"", i, out)
+			}
+		})
+	}
+}
+
+func TestCopyBytesToAllNodesBatched(t *testing.T) {
+	tn := newTestNet(t, 3)
+	defer removeTestNet(tn)
+
+	large := strings.Repeat("0123456789abcdef", 8192)
+	files := map[string]string{"/genesis.json": large, "/first.txt": "first", "/second.txt": "second"}
+	err := helpers.CopyBytesToAllNodes(tn, large, "/genesis.json", "first", "/first.txt", "second", "/second.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, node := range tn.Nodes {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			for file, expected := range files {
+				out, err := tn.Clients[server.ID].DockerRead(node, file, -1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if out != expected {
+					t.Errorf("node %d has the wrong contents in %s", i, file)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateConfigs(t *testing.T) {
+	tn := newTestNet(t, 3)
+	defer removeTestNet(tn)
+
+	err := helpers.CreateConfigs(tn, "/node.conf", func(node ssh.Node) ([]byte, error) {
+		return []byte(fmt.Sprintf("node=%d", node.GetAbsoluteNumber())), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, node := range tn.Nodes {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := tn.Clients[server.ID].DockerRead(node, "/node.conf", -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(out) != fmt.Sprintf("node=%d", i) {
+				t.Errorf("node %d has \"%s\"", i, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+// Package integration contains the integration tests of genesis, which are run against local
+// servers started by the harness package. They need docker, and are only built with the
+// integration build tag:
+//
+//	DATADIR=$(mktemp -d) go test -tags integration -count=1 ./integration/...
+//
+// The DATADIR keeps the servers the tests register out of the usual database.
+package integration
@@ -0,0 +1,206 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+// Package harness runs local docker containers with sshd and dockerd (docker in docker), which
+// genesis can use as servers, so that the ssh layer, the helpers and small builds can be
+// exercised by the integration tests without any real servers.
+package harness
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	_ "embed" //for the Dockerfile
+	"encoding/pem"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/util"
+	cryptossh "golang.org/x/crypto/ssh"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Image is the image the servers are run from, which is built from the Dockerfile of this package
+const Image = "genesis-harness:latest"
+
+// ReadyTimeout is how long a server has to start sshd and dockerd
+var ReadyTimeout = 2 * time.Minute
+
+//go:embed Dockerfile
+var dockerfile []byte
+
+var (
+	buildOnce sync.Once
+	buildErr  error
+)
+
+// Server is a docker container running sshd and dockerd
+type Server struct {
+	// Name is the name the server is registered with
+	Name string
+	// Container is the name of the container
+	Container string
+	// Addr is the address of the container on the docker bridge
+	Addr string
+	// PrivateKey is the private key which can log in as root
+	PrivateKey string
+	// ID is the id of the server in the database, or -1 if it is not registered
+	ID int
+}
+
+// Available gets whether or not docker can be used to run the servers
+func Available() bool {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return false
+	}
+	return exec.Command("docker", "info").Run() == nil
+}
+
+func docker(args ...string) (string, error) {
+	out, err := exec.Command("docker", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// BuildImage builds the image of the servers, it is only built once
+func BuildImage() error {
+	buildOnce.Do(func() {
+		cmd := exec.Command("docker", "build", "-t", Image, "-")
+		cmd.Stdin = bytes.NewReader(dockerfile)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			buildErr = fmt.Errorf("failed to build %s: %s", Image, strings.TrimSpace(string(out)))
+		}
+	})
+	return buildErr
+}
+
+// newKey generates a key pair, giving the pem encoded private key and the authorized key
+func newKey() (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+	pub, err := cryptossh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	private := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return string(private), strings.TrimSpace(string(cryptossh.MarshalAuthorizedKey(pub))), nil
+}
+
+// Start starts a new server, and waits until it is ready to be built on
+func Start(name string) (*Server, error) {
+	err := BuildImage()
+	if err != nil {
+		return nil, err
+	}
+	private, public, err := newKey()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := &Server{Name: name, Container: "genesis-harness-" + name, PrivateKey: private, ID: -1}
+	docker("rm", "-f", out.Container) //left over from an interrupted run
+	_, err = docker("run", "-d", "--privileged", "--name", out.Container, "-e", "AUTHORIZED_KEY="+public, Image)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out.Addr, err = docker("inspect", "-f", "{{.NetworkSettings.IPAddress}}", out.Container)
+	if err != nil {
+		out.Stop()
+		return nil, util.LogError(err)
+	}
+	err = out.waitUntilReady()
+	if err != nil {
+		out.Stop()
+		return nil, util.LogError(err)
+	}
+	log.WithFields(log.Fields{"server": name, "addr": out.Addr}).Info("started a harness server")
+	return out, nil
+}
+
+func (s *Server) waitUntilReady() error {
+	var err error
+	for start := time.Now(); time.Since(start) < ReadyTimeout; time.Sleep(time.Second) {
+		var client ssh.Client
+		client, err = s.Client()
+		if err != nil {
+			continue
+		}
+		_, err = client.Run("docker info")
+		client.Close()
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("server %s was not ready in time: %v", s.Name, err)
+}
+
+// Credentials gets the ssh credentials of the server
+func (s *Server) Credentials() ssh.Credentials {
+	return ssh.Credentials{User: "root", PrivateKey: s.PrivateKey, Port: 22}
+}
+
+// Client opens a new client to the server. It should be closed by the caller.
+func (s *Server) Client() (ssh.Client, error) {
+	runtime, err := util.GetRuntime(util.DockerRuntime)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return ssh.NewClient(s.Addr, s.ID, runtime, s.Credentials())
+}
+
+// Register adds the server to the database with the given subnet id, so that it can be built on
+func (s *Server) Register(subnetID int) error {
+	creds := s.Credentials()
+	id, err := db.InsertServer(s.Name, db.Server{
+		Addr:          s.Addr,
+		Max:           30,
+		SubnetID:      subnetID,
+		ID:            -1,
+		SSHUser:       creds.User,
+		SSHPrivateKey: creds.PrivateKey,
+		SSHPort:       creds.Port,
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+	s.ID = id
+	return nil
+}
+
+// Exec runs a command in the container of the server, bypassing ssh
+func (s *Server) Exec(command string) (string, error) {
+	return docker("exec", s.Container, "sh", "-c", command)
+}
+
+// Stop removes the server from the database if it was registered, and removes its container
+func (s *Server) Stop() error {
+	if s.ID != -1 {
+		util.LogError(db.DeleteServer(s.ID))
+		s.ID = -1
+	}
+	_, err := docker("rm", "-f", "-v", s.Container)
+	return err
+}
@@ -0,0 +1,54 @@
+//go:build integration
+// +build integration
+
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package integration
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/integration/harness"
+	"os"
+	"testing"
+)
+
+// server is the harness server shared by the tests, registered with the subnet id 200
+var server *harness.Server
+
+func TestMain(m *testing.M) {
+	if !harness.Available() {
+		fmt.Println("skipping the integration tests, docker is not available")
+		os.Exit(0)
+	}
+	var err error
+	server, err = harness.Start("integration")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	err = server.Register(200)
+	if err != nil {
+		server.Stop()
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	server.Stop()
+	os.Exit(code)
+}
@@ -0,0 +1,241 @@
+//go:build integration
+// +build integration
+
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestClient_Run(t *testing.T) {
+	client, err := server.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var test = []struct {
+		command  string
+		expected string
+		valid    bool
+	}{
+		{command: "echo hello", expected: "hello\n", valid: true},
+		{command: "docker version -f '{{.Server.Os}}'", expected: "linux\n", valid: true},
+		{command: "exit 3", valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := client.Run(tt.command)
+			if (err == nil) != tt.valid {
+				t.Fatalf("unexpected result of Run: %v", err)
+			}
+			if tt.valid && out != tt.expected {
+				t.Errorf("return value of Run \"%s\" does not match expected value \"%s\"", out, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClient_Scp(t *testing.T) {
+	client, err := server.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	file, err := ioutil.TempFile("", "scp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("copied over scp")
+	file.Close()
+
+	err = client.Scp(file.Name(), "/tmp/scp.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := server.Exec("cat /tmp/scp.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "copied over scp" {
+		t.Errorf("copied file contains \"%s\"", out)
+	}
+}
+
+func TestClient_ScpCached(t *testing.T) {
+	client, err := server.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	conf.RemoteCacheDir = "/tmp/genesis-cache"
+	defer func() { conf.RemoteCacheDir = "" }()
+
+	file, err := ioutil.TempFile("", "scp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("cached")
+	file.Close()
+
+	for i := 0; i < 2; i++ {
+		dest := fmt.Sprintf("/tmp/cached%d.txt", i)
+		err = client.Scp(file.Name(), dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := server.Exec("cat " + dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "cached" {
+			t.Errorf("copied file contains \"%s\"", out)
+		}
+	}
+	// sha256 of "cached"
+	out, err := server.Exec("ls /tmp/genesis-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != "3673014e72b67383be302485694555a57ad393afdebaed6ded110a775bd0556d" {
+		t.Errorf("cache contains \"%s\"", out)
+	}
+}
+
+func TestClient_Sync(t *testing.T) {
+	client, err := server.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	dir, err := ioutil.TempDir("", "sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = os.MkdirAll(dir+"/chain/db", 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(dir+"/chain/db/000001.log", []byte("first"), 0644)
+
+	for i, rsync := range []bool{false, true} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			conf.EnableRsync = rsync
+			defer func() { conf.EnableRsync = false }()
+			ioutil.WriteFile(dir+"/chain/genesis.json", []byte(strconv.Itoa(i)), 0644)
+
+			err := client.Sync(dir+"/chain", "/data/sync")
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := server.Exec("cat /data/sync/genesis.json /data/sync/db/000001.log")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != strconv.Itoa(i)+"first" {
+				t.Errorf("synced files contain \"%s\"", out)
+			}
+		})
+	}
+}
+
+func TestClient_Docker(t *testing.T) {
+	client, err := server.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	node := db.Node{TestNetID: "integration", Server: server.ID, LocalID: 0}
+	_, err = client.Run(fmt.Sprintf("docker run -d --name %s alpine:3.10 sleep 600", node.GetNodeName()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Run("docker rm -f " + node.GetNodeName())
+
+	_, err = client.DockerExec(node, "sh -c 'echo from the node > /node.txt'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := client.DockerRead(node, "/node.txt", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != "from the node" {
+		t.Errorf("read \"%s\" from the node", out)
+	}
+	buf := &bytes.Buffer{}
+	err = client.DockerFetch(node, "/node.txt", buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(buf.String()) != "from the node" {
+		t.Errorf("fetched \"%s\" from the node", buf.String())
+	}
+}
+
+func TestClient_ConcurrentSessions(t *testing.T) {
+	client, err := server.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out, err := client.Run(fmt.Sprintf("sleep 0.5 && echo %d", i))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if out != fmt.Sprintf("%d\n", i) {
+				t.Errorf("session %d got the output \"%s\"", i, out)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := client.(ssh.PoolStater).PoolStats()
+	if stats.Sessions != 0 {
+		t.Errorf("%d sessions were not given back", stats.Sessions)
+	}
+	if stats.Peak > conf.SSHPoolMax {
+		t.Errorf("opened %d connections, more than the maximum of %d", stats.Peak, conf.SSHPoolMax)
+	}
+}
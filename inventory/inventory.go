@@ -0,0 +1,261 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package inventory checks a build request against the servers it is to be built on, so that a build
+// which cannot fit is rejected with every reason why before anything is provisioned.
+package inventory
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/kubernetes"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var conf = util.GetConfig()
+
+// probeCommand prints the number of cpus, the total memory in kB and the free disk in kB of a server,
+// one per line. The disk is that of /var/lib, where the container runtimes keep their data.
+const probeCommand = "nproc && awk '/^MemTotal:/ {print $2}' /proc/meminfo && df -Pk /var/lib | awk 'NR==2 {print $4}'"
+
+// The constraints of the servers which a build request can violate
+const (
+	// ExistsConstraint is violated by a server which is not registered
+	ExistsConstraint = "exists"
+	// HealthyConstraint is violated by a server which cannot be reached
+	HealthyConstraint = "healthy"
+	// SlotsConstraint is violated when the servers do not have enough free node slots for the nodes
+	SlotsConstraint = "slots"
+	// DiskConstraint is violated by a server which does not have enough free disk for its nodes
+	DiskConstraint = "disk"
+	// MemoryConstraint is violated by a server with less memory than the sum of that of its nodes
+	MemoryConstraint = "memory"
+	// CPUConstraint is violated by a server with fewer cpus than the sum of those of its nodes
+	CPUConstraint = "cpus"
+)
+
+// Violation is a constraint of the servers which a build request does not meet
+type Violation struct {
+	// Server is the id of the server which violates the constraint, left out for the constraints
+	// of all of the servers
+	Server int `json:"server,omitempty"`
+	// Constraint is the constraint which is violated
+	Constraint string `json:"constraint"`
+	// Message describes the violation
+	Message string `json:"message"`
+}
+
+// Error gives the message of the violation
+func (v Violation) Error() string {
+	if v.Server == 0 {
+		return v.Message
+	}
+	return fmt.Sprintf("server %d: %s", v.Server, v.Message)
+}
+
+// Capacity is what a server has to offer to its nodes
+type Capacity struct {
+	// Cpus is the number of cpus of the server
+	Cpus float64
+	// Memory is the total memory of the server, in bytes
+	Memory int64
+	// Disk is the free disk of the server, in bytes
+	Disk int64
+}
+
+// parseCapacity parses the output of probeCommand
+func parseCapacity(out string) (Capacity, error) {
+	fields := strings.Fields(out)
+	if len(fields) != 3 {
+		return Capacity{}, fmt.Errorf("unexpected output from the capacity probe: \"%s\"", out)
+	}
+	cpus, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Capacity{}, err
+	}
+	memory, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return Capacity{}, err
+	}
+	disk, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return Capacity{}, err
+	}
+	return Capacity{Cpus: cpus, Memory: memory * 1024, Disk: disk * 1024}, nil
+}
+
+// Plan gets how many nodes will be built on each of the servers, filling them in order the same way
+// the build does. Returns false if the servers do not have enough free node slots for all of the nodes.
+func Plan(servers []db.Server, nodes int) ([]int, bool) {
+	out := make([]int, len(servers))
+	for i, server := range servers {
+		free := server.Max - server.Nodes
+		if free <= 0 {
+			continue
+		}
+		if free > nodes {
+			free = nodes
+		}
+		out[i] = free
+		nodes -= free
+	}
+	return out, nodes == 0
+}
+
+// nodeResources gets the resources given for the node with the given absolute number
+func nodeResources(resources []util.Resources, absNum int) util.Resources {
+	if len(resources) > absNum {
+		return resources[absNum]
+	}
+	if len(resources) > 0 {
+		return resources[0]
+	}
+	return util.Resources{}
+}
+
+// checkCapacity checks that the given server can hold the nodes with the absolute numbers from first
+// up to first+nodes, with the given resources
+func checkCapacity(serverID int, capacity Capacity, resources []util.Resources, first int, nodes int) []Violation {
+	out := []Violation{}
+	if len(conf.NodeDiskSize) > 0 {
+		size, err := util.ParseSize(conf.NodeDiskSize)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "nodeDiskSize": conf.NodeDiskSize}).Error("invalid nodeDiskSize")
+		} else if size*int64(nodes) > capacity.Disk {
+			out = append(out, Violation{Server: serverID, Constraint: DiskConstraint,
+				Message: fmt.Sprintf("%d nodes need %d bytes of disk, but only %d are free", nodes,
+					size*int64(nodes), capacity.Disk)})
+		}
+	}
+
+	var memory int64
+	var cpus float64
+	for i := first; i < first+nodes; i++ {
+		res := nodeResources(resources, i)
+		if !res.NoMemoryLimits() {
+			m, err := res.GetMemory()
+			if err == nil {
+				memory += m
+			}
+		}
+		if !res.NoCPULimits() {
+			c, err := strconv.ParseFloat(res.Cpus, 64)
+			if err == nil {
+				cpus += c
+			}
+		}
+	}
+	if memory > capacity.Memory {
+		out = append(out, Violation{Server: serverID, Constraint: MemoryConstraint,
+			Message: fmt.Sprintf("%d nodes need %d bytes of memory, but the server has %d", nodes, memory,
+				capacity.Memory)})
+	}
+	if cpus > capacity.Cpus {
+		out = append(out, Violation{Server: serverID, Constraint: CPUConstraint,
+			Message: fmt.Sprintf("%d nodes need %g cpus, but the server has %g", nodes, cpus, capacity.Cpus)})
+	}
+	return out
+}
+
+// probe gets the capacity of the given server, returning a violation if it cannot be reached. The
+// capacity is nil if the output of the probe could not be understood, in which case it is not checked.
+func probe(serverID int) (*Capacity, *Violation) {
+	client, err := status.GetClient(serverID)
+	if err != nil {
+		return nil, &Violation{Server: serverID, Constraint: HealthyConstraint,
+			Message: fmt.Sprintf("cannot connect to the server: %s", err.Error())}
+	}
+	res, err := client.Run(probeCommand)
+	if err != nil {
+		return nil, &Violation{Server: serverID, Constraint: HealthyConstraint,
+			Message: fmt.Sprintf("cannot run commands on the server: %s", err.Error())}
+	}
+	capacity, err := parseCapacity(res)
+	if err != nil {
+		log.WithFields(log.Fields{"server": serverID, "error": err}).Warn("could not get the capacity of the server")
+		return nil, nil
+	}
+	return &capacity, nil
+}
+
+// Check checks the given build request against the servers it is to be built on: that they exist,
+// are healthy, have enough free node slots and disk, and have the memory and cpus the nodes are given.
+// Returns every violated constraint, which is none if the build fits. Builds on kubernetes are not checked.
+func Check(details db.DeploymentDetails) []Violation {
+	out := []Violation{}
+	cfg, err := kubernetes.GetConfig(&details)
+	if err != nil || cfg.Enabled || len(details.Servers) == 0 {
+		return out //left for the validation of the build
+	}
+
+	servers := []db.Server{}
+	for _, id := range details.Servers {
+		server, _, err := db.GetServer(id)
+		if err != nil {
+			out = append(out, Violation{Server: id, Constraint: ExistsConstraint,
+				Message: "the server does not exist"})
+			continue
+		}
+		servers = append(servers, server)
+	}
+	if len(out) > 0 {
+		return out
+	}
+
+	plan, ok := Plan(servers, details.Nodes)
+	if !ok {
+		free := 0
+		for _, server := range servers {
+			if server.Max > server.Nodes {
+				free += server.Max - server.Nodes
+			}
+		}
+		out = append(out, Violation{Constraint: SlotsConstraint,
+			Message: fmt.Sprintf("%d nodes were requested, but the servers only have %d free node slots",
+				details.Nodes, free)})
+	}
+
+	violations := make([][]Violation, len(servers))
+	wg := sync.WaitGroup{}
+	first := 0
+	for i := range servers {
+		wg.Add(1)
+		go func(i int, first int) {
+			defer wg.Done()
+			capacity, violation := probe(servers[i].ID)
+			if violation != nil {
+				violations[i] = []Violation{*violation}
+				return
+			}
+			if capacity != nil && plan[i] > 0 {
+				violations[i] = checkCapacity(servers[i].ID, *capacity, details.Resources, first, plan[i])
+			}
+		}(i, first)
+		first += plan[i]
+	}
+	wg.Wait()
+	for _, v := range violations {
+		out = append(out, v...)
+	}
+	return out
+}
@@ -0,0 +1,116 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package inventory
+
+import (
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseCapacity(t *testing.T) {
+	var test = []struct {
+		out      string
+		expected Capacity
+		err      bool
+	}{
+		{out: "8\n16384000\n2048\n", expected: Capacity{Cpus: 8, Memory: 16384000 * 1024, Disk: 2048 * 1024}},
+		{out: "8\n16384000\n", err: true},
+		{out: "", err: true},
+		{out: "eight\n16384000\n2048\n", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			capacity, err := parseCapacity(tt.out)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected result from parseCapacity: %v", err)
+			}
+			if !reflect.DeepEqual(capacity, tt.expected) {
+				t.Errorf("return value of parseCapacity does not match expected value")
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	var test = []struct {
+		servers  []db.Server
+		nodes    int
+		expected []int
+		ok       bool
+	}{
+		{servers: []db.Server{{Max: 10}}, nodes: 4, expected: []int{4}, ok: true},
+		{servers: []db.Server{{Max: 3}, {Max: 10}}, nodes: 5, expected: []int{3, 2}, ok: true},
+		{servers: []db.Server{{Max: 3, Nodes: 3}, {Max: 10, Nodes: 5}}, nodes: 5, expected: []int{0, 5}, ok: true},
+		{servers: []db.Server{{Max: 3}, {Max: 1}}, nodes: 5, expected: []int{3, 1}, ok: false},
+		{servers: []db.Server{{Max: 3, Nodes: 4}}, nodes: 1, expected: []int{0}, ok: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			plan, ok := Plan(tt.servers, tt.nodes)
+			if ok != tt.ok {
+				t.Errorf("unexpected result from Plan: %v", ok)
+			}
+			if !reflect.DeepEqual(plan, tt.expected) {
+				t.Errorf("return value of Plan does not match expected value")
+			}
+		})
+	}
+}
+
+func TestCheckCapacity(t *testing.T) {
+	oldSize := conf.NodeDiskSize
+	conf.NodeDiskSize = "1kb"
+	defer func() { conf.NodeDiskSize = oldSize }()
+
+	capacity := Capacity{Cpus: 4, Memory: 4096, Disk: 4096}
+	var test = []struct {
+		resources []util.Resources
+		first     int
+		nodes     int
+		expected  []string
+	}{
+		{resources: nil, nodes: 4, expected: []string{}},
+		{resources: nil, nodes: 5, expected: []string{DiskConstraint}},
+		{resources: []util.Resources{{Cpus: "1", Memory: "1kb"}}, nodes: 4, expected: []string{}},
+		{resources: []util.Resources{{Cpus: "2", Memory: "2kb"}}, nodes: 3,
+			expected: []string{MemoryConstraint, CPUConstraint}},
+		{resources: []util.Resources{{Cpus: "0.5"}, {Cpus: "4"}, {Cpus: "0.5"}}, first: 0, nodes: 2,
+			expected: []string{CPUConstraint}},
+		{resources: []util.Resources{{Cpus: "0.5"}, {Cpus: "4"}, {Cpus: "0.5"}}, first: 2, nodes: 2,
+			expected: []string{}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			violations := checkCapacity(1, capacity, tt.resources, tt.first, tt.nodes)
+			constraints := []string{}
+			for _, v := range violations {
+				constraints = append(constraints, v.Constraint)
+			}
+			if !reflect.DeepEqual(constraints, tt.expected) {
+				t.Errorf("return value of checkCapacity does not match expected value: %v", violations)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package keys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"golang.org/x/crypto/hkdf"
+	"io"
+	mrand "math/rand"
+	"time"
+)
+
+// epoch is the earliest time which Time derives, the times it derives fall within the year after it
+var epoch = time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Deriver derives the keys of a testnet from its seed. Each key is identified by its type, a label
+// naming what it is for, and an index, so that the same seed always gives the same keys regardless of
+// the order they are derived in. Without a seed, every key is random.
+type Deriver struct {
+	seed []byte
+}
+
+// NewDeriver creates a new Deriver for the given seed, which may be empty for random keys
+func NewDeriver(seed string) *Deriver {
+	return &Deriver{seed: []byte(seed)}
+}
+
+// Deterministic gets whether or not the keys are derived from a seed
+func (kd *Deriver) Deterministic() bool {
+	return len(kd.seed) > 0
+}
+
+func (kd *Deriver) source(keyType string, label string, index int) io.Reader {
+	if !kd.Deterministic() {
+		return rand.Reader
+	}
+	return hkdf.New(sha256.New, kd.seed, nil, []byte(fmt.Sprintf("genesis/%s/%s/%d", keyType, label, index)))
+}
+
+// Derive derives the key of the given type, with the given label and index
+func (kd *Deriver) Derive(keyType string, label string, index int) (Key, error) {
+	return Generate(keyType, kd.source(keyType, label, index))
+}
+
+// Int64 derives a non-negative number with the given label, for seeding the randomness of the testnet
+// other than its keys, such as its peering graph. Without a seed, the number is random.
+func (kd *Deriver) Int64(label string) int64 {
+	var buf [8]byte
+	io.ReadFull(kd.source("int64", label, 0), buf[:])
+	return int64(binary.BigEndian.Uint64(buf[:]) >> 1)
+}
+
+// Rand creates a source of randomness seeded with the number derived with the given label, see Int64
+func (kd *Deriver) Rand(label string) *mrand.Rand {
+	return mrand.New(mrand.NewSource(kd.Int64(label)))
+}
+
+// Time derives a time with the given label, to the second and within the year after epoch, for the
+// timestamps which end up in the genesis files of the testnet. Without a seed, it is the current time.
+func (kd *Deriver) Time(label string) time.Time {
+	if !kd.Deterministic() {
+		return time.Now().UTC()
+	}
+	year := int64(epoch.AddDate(1, 0, 0).Sub(epoch) / time.Second)
+	return epoch.Add(time.Duration(kd.Int64("time/"+label)%year) * time.Second)
+}
+
+// DeriveN derives n keys of the given type with the given label, with the indexes starting at offset
+func (kd *Deriver) DeriveN(keyType string, label string, offset int, n int) ([]Key, error) {
+	out := make([]Key, n)
+	for i := range out {
+		key, err := kd.Derive(keyType, label, offset+i)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = key
+	}
+	return out, nil
+}
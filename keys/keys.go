@@ -0,0 +1,152 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package keys provides the generation of the keys used by the nodes and accounts of a testnet, on the
+// curves used by the supported blockchains. Keys are either random, or derived from the seed of the
+// deployment so that a testnet can be reproduced with the same keys and addresses.
+package keys
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"github.com/whiteblock/genesis/util"
+	"golang.org/x/crypto/ed25519"
+	"io"
+	"math/big"
+)
+
+const (
+	// Secp256k1 is the key type of ECDSA keys on the secp256k1 curve, as used by ethereum and bitcoin
+	Secp256k1 = "secp256k1"
+	// Ed25519 is the key type of EdDSA keys on curve25519, as used by tendermint and libp2p
+	Ed25519 = "ed25519"
+	// BLS is the key type of BLS keys on the bn256 curve, with the public key in G2
+	BLS = "bls"
+)
+
+// Key is a private key and its public key, in the binary encoding of its type.
+// Secp256k1 keys are 32 bytes, with a 65 byte uncompressed public key. Ed25519 keys are
+// 64 bytes, the seed followed by the public key. BLS keys are a 32 byte scalar, with a 128 byte public key.
+type Key struct {
+	Type       string
+	PrivateKey []byte
+	PublicKey  []byte
+}
+
+// HexPrivateKey gets the private key in hex format
+func (key Key) HexPrivateKey() string {
+	return hex.EncodeToString(key.PrivateKey)
+}
+
+// HexPublicKey gets the public key in hex format
+func (key Key) HexPublicKey() string {
+	return hex.EncodeToString(key.PublicKey)
+}
+
+// KeyPair gets the key as a hex encoded key pair
+func (key Key) KeyPair() util.KeyPair {
+	return util.KeyPair{PrivateKey: key.HexPrivateKey(), PublicKey: key.HexPublicKey()}
+}
+
+// ToECDSA gets the secp256k1 key as an ECDSA private key
+func (key Key) ToECDSA() (*ecdsa.PrivateKey, error) {
+	if key.Type != Secp256k1 {
+		return nil, fmt.Errorf("a %s key is not an ECDSA key", key.Type)
+	}
+	return crypto.ToECDSA(key.PrivateKey)
+}
+
+// MarshalJSON handles the marshaling of Key into JSON, so that the keys are
+// exposed in their hex encodings
+func (key Key) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Type       string `json:"type"`
+		PrivateKey string `json:"privateKey"`
+		PublicKey  string `json:"publicKey"`
+	}{
+		Type:       key.Type,
+		PrivateKey: key.HexPrivateKey(),
+		PublicKey:  key.HexPublicKey(),
+	})
+}
+
+// readScalar reads a scalar in [1, order) from rand, by rejection sampling so that the same
+// source always gives the same scalar
+func readScalar(rand io.Reader, order *big.Int) (*big.Int, error) {
+	buf := make([]byte, 32)
+	for {
+		_, err := io.ReadFull(rand, buf)
+		if err != nil {
+			return nil, err
+		}
+		k := new(big.Int).SetBytes(buf)
+		if k.Sign() > 0 && k.Cmp(order) < 0 {
+			return k, nil
+		}
+	}
+}
+
+// Generate generates a key of the given type, reading its randomness from rand.
+// The same rand always gives the same key.
+func Generate(keyType string, rand io.Reader) (Key, error) {
+	out := Key{Type: keyType}
+	switch keyType {
+	case Secp256k1:
+		k, err := readScalar(rand, crypto.S256().Params().N)
+		if err != nil {
+			return out, util.LogError(err)
+		}
+		privKey, err := crypto.ToECDSA(padTo32(k.Bytes()))
+		if err != nil {
+			return out, util.LogError(err)
+		}
+		out.PrivateKey = crypto.FromECDSA(privKey)
+		out.PublicKey = crypto.FromECDSAPub(&privKey.PublicKey)
+	case Ed25519:
+		seed := make([]byte, ed25519.SeedSize)
+		_, err := io.ReadFull(rand, seed)
+		if err != nil {
+			return out, util.LogError(err)
+		}
+		privKey := ed25519.NewKeyFromSeed(seed)
+		out.PrivateKey = privKey
+		out.PublicKey = privKey.Public().(ed25519.PublicKey)
+	case BLS:
+		k, err := readScalar(rand, bn256.Order)
+		if err != nil {
+			return out, util.LogError(err)
+		}
+		out.PrivateKey = padTo32(k.Bytes())
+		out.PublicKey = new(bn256.G2).ScalarBaseMult(k).Marshal()
+	default:
+		return out, fmt.Errorf("unknown key type \"%s\"", keyType)
+	}
+	return out, nil
+}
+
+// padTo32 left pads b with zeros to 32 bytes
+func padTo32(b []byte) []byte {
+	if len(b) >= 32 {
+		return b
+	}
+	return append(make([]byte, 32-len(b)), b...)
+}
@@ -0,0 +1,167 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package keys
+
+import (
+	"bytes"
+	"crypto/rand"
+	"github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"golang.org/x/crypto/ed25519"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDeriver_Derive(t *testing.T) {
+	var test = []struct {
+		keyType    string
+		privLength int
+		pubLength  int
+	}{
+		{keyType: Secp256k1, privLength: 32, pubLength: 65},
+		{keyType: Ed25519, privLength: 64, pubLength: 32},
+		{keyType: BLS, privLength: 32, pubLength: 128},
+	}
+
+	kd := NewDeriver("test seed")
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			key, err := kd.Derive(tt.keyType, "nodes", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(key.PrivateKey) != tt.privLength || len(key.PublicKey) != tt.pubLength {
+				t.Errorf("unexpected key lengths %d and %d", len(key.PrivateKey), len(key.PublicKey))
+			}
+			again, err := NewDeriver("test seed").Derive(tt.keyType, "nodes", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(key.PrivateKey, again.PrivateKey) || !bytes.Equal(key.PublicKey, again.PublicKey) {
+				t.Error("the same seed derived different keys")
+			}
+			for _, other := range []*Deriver{NewDeriver("other seed"), NewDeriver("")} {
+				otherKey, err := other.Derive(tt.keyType, "nodes", 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if bytes.Equal(key.PrivateKey, otherKey.PrivateKey) {
+					t.Error("a different seed derived the same key")
+				}
+			}
+			otherKey, err := kd.Derive(tt.keyType, "accounts", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Equal(key.PrivateKey, otherKey.PrivateKey) {
+				t.Error("a different label derived the same key")
+			}
+		})
+	}
+}
+
+func TestDeriver_Int64(t *testing.T) {
+	kd := NewDeriver("test seed")
+	n := kd.Int64("topology")
+	if n < 0 {
+		t.Errorf("derived the negative number %d", n)
+	}
+	if NewDeriver("test seed").Int64("topology") != n {
+		t.Error("the same seed derived different numbers")
+	}
+	if kd.Int64("load") == n || NewDeriver("other seed").Int64("topology") == n {
+		t.Error("a different seed or label derived the same number")
+	}
+	if kd.Rand("topology").Int63() != NewDeriver("test seed").Rand("topology").Int63() {
+		t.Error("the same seed gave different randomness")
+	}
+}
+
+func TestDeriver_Time(t *testing.T) {
+	kd := NewDeriver("test seed")
+	at := kd.Time("genesis")
+	if !at.Equal(NewDeriver("test seed").Time("genesis")) {
+		t.Error("the same seed derived different times")
+	}
+	if at.Before(epoch) || !at.Before(epoch.AddDate(1, 0, 0)) {
+		t.Errorf("derived %v, outside of the year after the epoch", at)
+	}
+	if at.Equal(kd.Time("other")) {
+		t.Error("a different label derived the same time")
+	}
+	if time.Since(NewDeriver("").Time("genesis")) > time.Minute {
+		t.Error("without a seed, the time should be the current time")
+	}
+}
+
+func TestGenerate_PublicKeys(t *testing.T) {
+	key, err := Generate(Ed25519, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := ed25519.Sign(ed25519.PrivateKey(key.PrivateKey), []byte("genesis"))
+	if !ed25519.Verify(ed25519.PublicKey(key.PublicKey), []byte("genesis"), sig) {
+		t.Error("the ed25519 public key does not match its private key")
+	}
+
+	key, err = Generate(BLS, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = new(bn256.G2).Unmarshal(key.PublicKey)
+	if err != nil {
+		t.Errorf("the bls public key is not a point of G2: %s", err.Error())
+	}
+
+	_, err = Generate("rsa", rand.Reader)
+	if err == nil {
+		t.Error("expected an error for an unknown key type")
+	}
+}
+
+func TestEncryptKey(t *testing.T) {
+	key, err := NewDeriver("test seed").Derive(Secp256k1, "accounts", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystore, err := EncryptKey(key, "password", rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := DecryptKey(keystore, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.PrivateKey, decrypted.PrivateKey) {
+		t.Error("the decrypted key does not match the encrypted key")
+	}
+	_, err = DecryptKey(keystore, "wrong password")
+	if err == nil {
+		t.Error("expected an error when decrypting with the wrong password")
+	}
+
+	key, err = Generate(Ed25519, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = EncryptKey(key, "password", rand.Reader)
+	if err == nil {
+		t.Error("expected an error when encrypting a non secp256k1 key")
+	}
+}
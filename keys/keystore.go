@@ -0,0 +1,167 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package keys
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/whiteblock/genesis/util"
+	"golang.org/x/crypto/scrypt"
+	"io"
+	"strings"
+)
+
+const (
+	// the light scrypt parameters of geth, so that importing many keys does not slow the build down
+	scryptN     = 1 << 12
+	scryptR     = 8
+	scryptP     = 6
+	scryptDKLen = 32
+)
+
+type keystoreCrypto struct {
+	Cipher       string `json:"cipher"`
+	CipherText   string `json:"ciphertext"`
+	CipherParams struct {
+		IV string `json:"iv"`
+	} `json:"cipherparams"`
+	KDF       string `json:"kdf"`
+	KDFParams struct {
+		DKLen int    `json:"dklen"`
+		N     int    `json:"n"`
+		P     int    `json:"p"`
+		R     int    `json:"r"`
+		Salt  string `json:"salt"`
+	} `json:"kdfparams"`
+	MAC string `json:"mac"`
+}
+
+type keystoreJSON struct {
+	Address string         `json:"address"`
+	Crypto  keystoreCrypto `json:"crypto"`
+	ID      string         `json:"id"`
+	Version int            `json:"version"`
+}
+
+func aesCTR(key []byte, iv []byte, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+// EncryptKey encrypts the secp256k1 key with the given password into a version 3 keystore, as read
+// by geth, parity and pantheon. The salt, iv and id are read from rand.
+func EncryptKey(key Key, password string, rand io.Reader) ([]byte, error) {
+	privKey, err := key.ToECDSA()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	random := make([]byte, 32+aes.BlockSize+16)
+	_, err = io.ReadFull(rand, random)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	salt, iv, id := random[:32], random[32:32+aes.BlockSize], random[32+aes.BlockSize:]
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	derivedKey, err := scrypt.Key([]byte(password), salt, scryptN, scryptR, scryptP, scryptDKLen)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	cipherText, err := aesCTR(derivedKey[:16], iv, key.PrivateKey)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+
+	out := keystoreJSON{
+		Address: hex.EncodeToString(crypto.PubkeyToAddress(privKey.PublicKey).Bytes()),
+		ID:      fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]),
+		Version: 3,
+	}
+	out.Crypto.Cipher = "aes-128-ctr"
+	out.Crypto.CipherText = hex.EncodeToString(cipherText)
+	out.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	out.Crypto.KDF = "scrypt"
+	out.Crypto.KDFParams.DKLen = scryptDKLen
+	out.Crypto.KDFParams.N = scryptN
+	out.Crypto.KDFParams.P = scryptP
+	out.Crypto.KDFParams.R = scryptR
+	out.Crypto.KDFParams.Salt = hex.EncodeToString(salt)
+	out.Crypto.MAC = hex.EncodeToString(crypto.Keccak256(derivedKey[16:32], cipherText))
+	return json.Marshal(out)
+}
+
+// DecryptKey decrypts a version 3 keystore created with scrypt, with the given password
+func DecryptKey(keystore []byte, password string) (Key, error) {
+	var ks keystoreJSON
+	err := json.Unmarshal(keystore, &ks)
+	if err != nil {
+		return Key{}, util.LogError(err)
+	}
+	if ks.Version != 3 || ks.Crypto.KDF != "scrypt" || ks.Crypto.Cipher != "aes-128-ctr" {
+		return Key{}, fmt.Errorf("unsupported keystore")
+	}
+	salt, err := hex.DecodeString(ks.Crypto.KDFParams.Salt)
+	if err != nil {
+		return Key{}, util.LogError(err)
+	}
+	iv, err := hex.DecodeString(ks.Crypto.CipherParams.IV)
+	if err != nil {
+		return Key{}, util.LogError(err)
+	}
+	cipherText, err := hex.DecodeString(ks.Crypto.CipherText)
+	if err != nil {
+		return Key{}, util.LogError(err)
+	}
+	mac, err := hex.DecodeString(ks.Crypto.MAC)
+	if err != nil {
+		return Key{}, util.LogError(err)
+	}
+	params := ks.Crypto.KDFParams
+	derivedKey, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return Key{}, util.LogError(err)
+	}
+	if len(derivedKey) < 32 || !bytes.Equal(crypto.Keccak256(derivedKey[16:32], cipherText), mac) {
+		return Key{}, fmt.Errorf("could not decrypt the keystore with the given password")
+	}
+	plainText, err := aesCTR(derivedKey[:16], iv, cipherText)
+	if err != nil {
+		return Key{}, util.LogError(err)
+	}
+	privKey, err := crypto.ToECDSA(plainText)
+	if err != nil {
+		return Key{}, util.LogError(err)
+	}
+	out := Key{Type: Secp256k1, PrivateKey: plainText, PublicKey: crypto.FromECDSAPub(&privKey.PublicKey)}
+	if !strings.EqualFold(ks.Address, hex.EncodeToString(crypto.PubkeyToAddress(privKey.PublicKey).Bytes())) {
+		return Key{}, fmt.Errorf("the keystore address does not match its key")
+	}
+	return out, nil
+}
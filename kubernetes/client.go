@@ -0,0 +1,283 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// dialTimeout is how long connecting to a pod may take
+var dialTimeout = 10 * time.Second
+
+// client runs the commands for the nodes of a testnet on kubernetes. Commands for the node are run
+// in the node container of its pod with kubectl exec, while the commands for the server itself are run
+// on the machine genesis is on.
+type client struct {
+	cfg      Config
+	serverID int
+}
+
+// NewClient creates a client for the nodes of a testnet on kubernetes, which stands in for the
+// ssh client of the given server
+func NewClient(cfg Config, serverID int) ssh.Client {
+	return &client{cfg: cfg, serverID: serverID}
+}
+
+func (c *client) logger() *log.Entry {
+	entry := log.WithFields(log.Fields{"server": c.serverID, "namespace": c.cfg.Namespace})
+	bs := state.GetBuildStateByServerID(c.serverID)
+	if bs != nil {
+		entry = entry.WithFields(log.Fields{"build": bs.BuildID})
+	}
+	return entry
+}
+
+func (c *client) run(entry *log.Entry, command string) (string, error) {
+	bs := state.GetBuildStateByServerID(c.serverID)
+	if bs.Stop() {
+		return "", bs.GetError()
+	}
+	ctx, timeout := bs.CommandContext()
+	res, err := util.LocalExecContext(ctx, command, util.ExecOptions{Timeout: timeout})
+	bs.RecordLocalCommand(c.serverID, command, err)
+	entry = entry.WithFields(log.Fields{"command": command, "output": res.Combined})
+	if err != nil {
+		entry.WithFields(log.Fields{"error": err}).Info("command failed")
+		return res.Combined, err
+	}
+	entry.Info("executed command")
+	return res.Combined, nil
+}
+
+func (c *client) keepTryRun(entry *log.Entry, command string) (string, error) {
+	var res string
+	var err error
+	for i := 0; i < conf.MaxRunAttempts; i++ {
+		res, err = c.run(entry, command)
+		if err == nil {
+			break
+		}
+	}
+	return res, util.LogError(err)
+}
+
+// exec gives the command line to execute command in the node container of the given node
+func (c *client) exec(node ssh.Node, command string) string {
+	return fmt.Sprintf("%s exec %s -c %s -- %s", c.cfg.kubectl(), node.GetNodeName(), NodeContainer, command)
+}
+
+// execd gives the command line to start command in the background in the node container of the given node
+func (c *client) execd(node ssh.Node, command string) string {
+	return c.exec(node, "bash -c "+quote(fmt.Sprintf("nohup %s > /dev/null 2>&1 &", command)))
+}
+
+// MultiRun provides an easy shorthand for multiple calls to Run
+func (c *client) MultiRun(commands ...string) ([]string, error) {
+	out := []string{}
+	for _, command := range commands {
+		res, err := c.Run(command)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+// FastMultiRun runs the commands chained together
+func (c *client) FastMultiRun(commands ...string) (string, error) {
+	return c.Run(strings.Join(commands, "&&"))
+}
+
+// Run executes the given command on the machine genesis is on
+func (c *client) Run(command string) (string, error) {
+	return c.run(c.logger(), command)
+}
+
+// KeepTryRun is Run, attempting the command up to maxRunAttempts times
+func (c *client) KeepTryRun(command string) (string, error) {
+	return c.keepTryRun(c.logger(), command)
+}
+
+// DockerExec executes a command in the node
+func (c *client) DockerExec(node ssh.Node, command string) (string, error) {
+	return c.run(c.logger().WithFields(ssh.LogFields(node)), c.exec(node, command))
+}
+
+// DockerCp copies a file from the machine genesis is on into the node
+func (c *client) DockerCp(node ssh.Node, source string, dest string) error {
+	_, err := c.run(c.logger().WithFields(ssh.LogFields(node)), fmt.Sprintf("%s cp %s %s:%s -c %s",
+		c.cfg.kubectl(), source, node.GetNodeName(), dest, NodeContainer))
+	return util.LogError(err)
+}
+
+// Fetch writes the contents of the file at source on the machine genesis is on to dest
+func (c *client) Fetch(source string, dest io.Writer) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return util.LogError(err)
+	}
+	defer in.Close()
+	_, err = io.Copy(dest, in)
+	return util.LogError(err)
+}
+
+// DockerFetch writes the contents of the file at source in the node container of the given node to dest
+func (c *client) DockerFetch(node ssh.Node, source string, dest io.Writer) error {
+	command := c.exec(node, "cat "+quote(source))
+	c.logger().WithFields(ssh.LogFields(node)).WithField("command", command).Info("fetching a file from a node")
+	bs := state.GetBuildStateByServerID(c.serverID)
+	ctx, timeout := bs.CommandContext()
+	_, err := util.LocalExecContext(ctx, command, util.ExecOptions{Timeout: timeout, Stdout: dest})
+	bs.RecordLocalCommand(c.serverID, command, err)
+	return err
+}
+
+// KeepTryDockerExec is like KeepTryRun for nodes
+func (c *client) KeepTryDockerExec(node ssh.Node, command string) (string, error) {
+	return c.keepTryRun(c.logger().WithFields(ssh.LogFields(node)), c.exec(node, command))
+}
+
+// KeepTryDockerExecAll is like KeepTryDockerExec, but executes each of the given commands in order
+func (c *client) KeepTryDockerExecAll(node ssh.Node, commands ...string) ([]string, error) {
+	out := []string{}
+	for _, command := range commands {
+		res, err := c.KeepTryDockerExec(node, command)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+// DockerExecd starts the given command in the background in the node
+func (c *client) DockerExecd(node ssh.Node, command string) (string, error) {
+	return c.run(c.logger().WithFields(ssh.LogFields(node)), c.execd(node, command))
+}
+
+// DockerExecdit is DockerExecd, as there is no tty to attach to in a pod
+func (c *client) DockerExecdit(node ssh.Node, command string) (string, error) {
+	return c.DockerExecd(node, command)
+}
+
+// DockerRunMainDaemon starts the main daemon process of the node
+func (c *client) DockerRunMainDaemon(node ssh.Node, command string) error {
+	bs := state.GetBuildStateByServerID(c.serverID)
+	bs.Set(fmt.Sprintf("%d", node.GetAbsoluteNumber()), util.Command{Cmdline: command, ServerID: c.serverID,
+		Node: node.GetRelativeNumber()})
+	return c.DockerExecdLog(node, command)
+}
+
+// DockerExecdLog starts the given command in the background in the node, storing its output in the logs
+func (c *client) DockerExecdLog(node ssh.Node, command string) error {
+	_, err := c.DockerExecd(node, fmt.Sprintf("bash -c %s", quote(command+" 2>&1 > "+conf.DockerOutputFile)))
+	return util.LogError(err)
+}
+
+// DockerExecdLogAppend is DockerExecdLog, but appends to the existing logs
+func (c *client) DockerExecdLogAppend(node ssh.Node, command string) error {
+	_, err := c.DockerExecd(node, fmt.Sprintf("bash -c %s", quote(command+" 2>&1 >> "+conf.DockerOutputFile)))
+	return util.LogError(err)
+}
+
+// DockerRead reads a file in the node, if lines > -1 then only the last `lines` lines are read
+func (c *client) DockerRead(node ssh.Node, file string, lines int) (string, error) {
+	if lines > -1 {
+		return c.DockerExec(node, fmt.Sprintf("tail -n %d %s", lines, file))
+	}
+	return c.DockerExec(node, fmt.Sprintf("cat %s", file))
+}
+
+func (c *client) dockerMultiExec(node ssh.Node, commands []string, kt bool) (string, error) {
+	merged := []string{}
+	for _, command := range commands {
+		merged = append(merged, c.execd(node, command))
+	}
+	if kt {
+		return c.keepTryRun(c.logger().WithFields(ssh.LogFields(node)), strings.Join(merged, "&&"))
+	}
+	return c.run(c.logger().WithFields(ssh.LogFields(node)), strings.Join(merged, "&&"))
+}
+
+// DockerMultiExec starts each of the given commands in the background in the node
+func (c *client) DockerMultiExec(node ssh.Node, commands []string) (string, error) {
+	return c.dockerMultiExec(node, commands, false)
+}
+
+// KTDockerMultiExec is DockerMultiExec, attempting the commands up to maxRunAttempts times
+func (c *client) KTDockerMultiExec(node ssh.Node, commands []string) (string, error) {
+	return c.dockerMultiExec(node, commands, true)
+}
+
+// Scp copies the file to dest on the machine genesis is on, which stands in for the server
+func (c *client) Scp(src string, dest string) error {
+	bs := state.GetBuildStateByServerID(c.serverID)
+	src = bs.Workspace().Path(src)
+	err := util.CopyFile(src, dest)
+	bs.RecordLocalCommand(c.serverID, fmt.Sprintf("mkdir -p %s && cp %s %s", quote(filepath.Dir(dest)), quote(src),
+		quote(dest)), err)
+	return err
+}
+
+// Sync copies the file or directory at src to dest on the machine genesis is on, which stands in for the server
+func (c *client) Sync(src string, dest string) error {
+	bs := state.GetBuildStateByServerID(c.serverID)
+	src = bs.Workspace().Path(src)
+	if bs.Stop() {
+		return bs.GetError()
+	}
+	err := util.CopyTree(src, dest)
+	bs.RecordLocalCommand(c.serverID, fmt.Sprintf("mkdir -p %s && cp -rT %s %s", quote(filepath.Dir(dest)), quote(src),
+		quote(dest)), err)
+	return util.LogError(err)
+}
+
+// Shell opens an interactive shell in the node container of the given node with kubectl exec
+func (c *client) Shell(node ssh.Node, cols int, rows int) (ssh.Shell, error) {
+	command := fmt.Sprintf("%s exec -it %s -c %s -- %s", c.cfg.kubectl(), node.GetNodeName(), NodeContainer,
+		ssh.ShellCommand)
+	c.logger().WithFields(ssh.LogFields(node)).WithField("command", command).Info("opening an interactive shell")
+	return ssh.NewLocalShell(command, cols, rows)
+}
+
+// Dial opens a connection to the given address directly, as the pods are reachable from genesis when it
+// runs in the cluster
+func (c *client) Dial(network string, address string) (net.Conn, error) {
+	return net.DialTimeout(network, address, dialTimeout)
+}
+
+// Close does nothing, as there is no connection to close
+func (c *client) Close() {}
+
+// Runtime gets a rootless runtime named after kubernetes, as the networks of the pods are not on
+// the machine genesis is on, and there is no container cli to use
+func (c *client) Runtime() util.Runtime {
+	return util.Runtime{Name: "kubernetes", Rootless: true}
+}
@@ -0,0 +1,160 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package kubernetes provides an alternative backend which runs the nodes of a testnet as pods in a
+// Kubernetes cluster, controlled through kubectl, instead of as docker containers on servers reached over ssh.
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+)
+
+var conf = util.GetConfig()
+
+const (
+	// NodeContainer is the name of the container of the node within its pod
+	NodeContainer = "node"
+	// NetemContainer is the name of the side container which applies the network emulation for the node
+	NetemContainer = "netem"
+)
+
+// Config is the kubernetes configuration of a deployment, given in the extras of the deployment
+// details under "kubernetes"
+type Config struct {
+	// Enabled is whether or not to deploy the testnet on kubernetes
+	Enabled bool `json:"enabled"`
+	// Namespace is the namespace to create the pods in, defaults to kubeNamespace
+	Namespace string `json:"namespace"`
+	// Context is the kubectl context of the cluster, defaults to the current context
+	Context string `json:"context"`
+}
+
+// GetConfig gets the kubernetes configuration from the given deployment details
+func GetConfig(details *db.DeploymentDetails) (Config, error) {
+	out := Config{Namespace: conf.KubeNamespace}
+	if details == nil {
+		return out, nil
+	}
+	raw, ok := details.Extras["kubernetes"]
+	if !ok {
+		return out, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	err = json.Unmarshal(data, &out)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	if len(out.Namespace) == 0 {
+		out.Namespace = conf.KubeNamespace
+	}
+	return out, nil
+}
+
+// quote quotes s for the shell
+func quote(s string) string {
+	return util.ShellQuote(s)
+}
+
+// kubectl gives the start of a kubectl command line for the cluster
+func (cfg Config) kubectl() string {
+	out := conf.Kubectl
+	if len(cfg.Context) > 0 {
+		out += " --context " + quote(cfg.Context)
+	}
+	return out + " -n " + quote(cfg.Namespace)
+}
+
+// run executes a kubectl command against the cluster, with the given arguments
+func (cfg Config) run(args string) (string, error) {
+	command := cfg.kubectl() + " " + args
+	res, err := util.LocalExec(command)
+	return res.Combined, err
+}
+
+// Apply creates or updates the given objects in the cluster
+func Apply(cfg Config, objects ...interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": objects})
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = util.LocalExecContext(context.Background(), cfg.kubectl()+" apply -f -",
+		util.ExecOptions{Stdin: bytes.NewReader(data)})
+	return util.LogError(err)
+}
+
+// WaitForPods waits for all of the pods of the testnet to be ready, for at most timeout seconds
+func WaitForPods(cfg Config, testnetID string, timeout int) error {
+	_, err := cfg.run(fmt.Sprintf("wait --for=condition=Ready pod -l testnet=%s --timeout=%ds", testnetID, timeout))
+	return util.LogError(err)
+}
+
+// GetPodIPs gets the ip address of each of the pods of the testnet, by pod name
+func GetPodIPs(cfg Config, testnetID string) (map[string]string, error) {
+	res, err := cfg.run(fmt.Sprintf("get pods -l testnet=%s -o json", testnetID))
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	var pods struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Status struct {
+				PodIP string `json:"podIP"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	err = json.Unmarshal([]byte(res), &pods)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := map[string]string{}
+	for _, pod := range pods.Items {
+		out[pod.Metadata.Name] = pod.Status.PodIP
+	}
+	return out, nil
+}
+
+// DeleteTestNet removes all of the pods and network policies of the testnet from the cluster
+func DeleteTestNet(cfg Config, testnetID string) error {
+	_, err := cfg.run(fmt.Sprintf("delete pods,networkpolicies -l testnet=%s --ignore-not-found --wait=false",
+		testnetID))
+	return util.LogError(err)
+}
+
+// ApplyNetem applies the given netem options, such as "delay 100ms loss 1.0000", to the traffic leaving the
+// pod of the given node, through its netem side container
+func ApplyNetem(cfg Config, podName string, options string) error {
+	_, err := cfg.run(fmt.Sprintf("exec %s -c %s -- tc qdisc replace dev eth0 root netem %s",
+		podName, NetemContainer, options))
+	return util.LogError(err)
+}
+
+// RemoveNetem removes the network emulation from the pod of the given node
+func RemoveNetem(cfg Config, podName string) error {
+	_, err := cfg.run(fmt.Sprintf("exec %s -c %s -- tc qdisc del dev eth0 root", podName, NetemContainer))
+	return err
+}
@@ -0,0 +1,125 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package kubernetes
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+)
+
+func TestGetConfig(t *testing.T) {
+	var test = []struct {
+		details  *db.DeploymentDetails
+		expected Config
+	}{
+		{
+			details:  nil,
+			expected: Config{Namespace: conf.KubeNamespace},
+		},
+		{
+			details:  &db.DeploymentDetails{},
+			expected: Config{Namespace: conf.KubeNamespace},
+		},
+		{
+			details: &db.DeploymentDetails{Extras: map[string]interface{}{
+				"kubernetes": map[string]interface{}{"enabled": true}}},
+			expected: Config{Enabled: true, Namespace: conf.KubeNamespace},
+		},
+		{
+			details: &db.DeploymentDetails{Extras: map[string]interface{}{
+				"kubernetes": map[string]interface{}{"enabled": true, "namespace": "test", "context": "kind"}}},
+			expected: Config{Enabled: true, Namespace: "test", Context: "kind"},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			cfg, err := GetConfig(tt.details)
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(cfg, tt.expected) {
+				t.Errorf("GetConfig returned %+v, expected %+v", cfg, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNodePod(t *testing.T) {
+	var test = []struct {
+		resources util.Resources
+		env       map[string]string
+		limits    map[string]string
+		envVars   []map[string]string
+	}{
+		{
+			resources: util.Resources{},
+			env:       nil,
+			limits:    map[string]string{},
+			envVars:   []map[string]string{},
+		},
+		{
+			resources: util.Resources{Cpus: "2", Memory: "4gb"},
+			env:       map[string]string{"B": "2", "A": "1"},
+			limits:    map[string]string{"cpu": "2", "memory": "4000000000"},
+			envVars:   []map[string]string{{"name": "A", "value": "1"}, {"name": "B", "value": "2"}},
+		},
+		{
+			resources: util.Resources{GPUs: "2"},
+			env:       nil,
+			limits:    map[string]string{"nvidia.com/gpu": "2"},
+			envVars:   []map[string]string{},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			pod, err := NodePod("node0", "testnet", "image", tt.resources, tt.env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			node := pod["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})
+			limits := node["resources"].(map[string]interface{})["limits"]
+			if !reflect.DeepEqual(limits, tt.limits) {
+				t.Errorf("limits were %v, expected %v", limits, tt.limits)
+			}
+			if !reflect.DeepEqual(node["env"], tt.envVars) {
+				t.Errorf("env was %v, expected %v", node["env"], tt.envVars)
+			}
+		})
+	}
+}
+
+func TestNodePod_InvalidMemory(t *testing.T) {
+	_, err := NodePod("node0", "testnet", "image", util.Resources{Memory: "lots"}, nil)
+	if err == nil {
+		t.Error("expected an error for an invalid memory limit")
+	}
+}
+
+func TestNodePod_GPUDevices(t *testing.T) {
+	_, err := NodePod("node0", "testnet", "image", util.Resources{GPUs: "device=0"}, nil)
+	if err == nil {
+		t.Error("expected an error for gpu devices on kubernetes")
+	}
+}
@@ -0,0 +1,132 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"sort"
+	"strconv"
+)
+
+func labels(testnetID string) map[string]string {
+	return map[string]string{"app": "genesis", "testnet": testnetID}
+}
+
+// NodePod creates the manifest of the pod for a node. The pod contains the node container, which
+// idles like a node container started by docker would, and the netem side container, which is allowed
+// to alter the network of the pod.
+func NodePod(name string, testnetID string, image string, resources util.Resources,
+	env map[string]string) (map[string]interface{}, error) {
+
+	envVars := []map[string]string{}
+	keys := []string{}
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		envVars = append(envVars, map[string]string{"name": key, "value": env[key]})
+	}
+
+	limits := map[string]string{}
+	if !resources.NoCPULimits() {
+		limits["cpu"] = resources.Cpus
+	}
+	if !resources.NoMemoryLimits() {
+		mem, err := resources.GetMemory()
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		limits["memory"] = strconv.FormatInt(mem, 10)
+	}
+	if len(resources.Mounts) > 0 {
+		return nil, fmt.Errorf("mounts are not supported on kubernetes")
+	}
+	if !resources.NoGPUs() {
+		_, err := strconv.Atoi(resources.GPUs)
+		if err != nil {
+			return nil, fmt.Errorf("only a number of gpus can be given on kubernetes, not \"%s\"", resources.GPUs)
+		}
+		limits["nvidia.com/gpu"] = resources.GPUs
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":   name,
+			"labels": labels(testnetID),
+		},
+		"spec": map[string]interface{}{
+			"hostname":      name,
+			"restartPolicy": "Never",
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":      NodeContainer,
+					"image":     image,
+					"command":   []string{"/bin/sh"},
+					"stdin":     true,
+					"tty":       true,
+					"env":       envVars,
+					"resources": map[string]interface{}{"limits": limits},
+				},
+				map[string]interface{}{
+					"name":    NetemContainer,
+					"image":   conf.KubeNetemImage,
+					"command": []string{"sleep", "infinity"},
+					"securityContext": map[string]interface{}{
+						"capabilities": map[string]interface{}{"add": []string{"NET_ADMIN"}},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// NetworkPolicy creates the manifest of the network policy which isolates the pods of the testnet,
+// so that they only accept traffic from each other, and from genesis itself when it runs in a pod
+// labelled with app=genesis-controller
+func NetworkPolicy(testnetID string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "NetworkPolicy",
+		"metadata": map[string]interface{}{
+			"name":   "genesis-" + testnetID,
+			"labels": labels(testnetID),
+		},
+		"spec": map[string]interface{}{
+			"podSelector": map[string]interface{}{"matchLabels": labels(testnetID)},
+			"policyTypes": []string{"Ingress"},
+			"ingress": []interface{}{
+				map[string]interface{}{
+					"from": []interface{}{
+						map[string]interface{}{
+							"podSelector": map[string]interface{}{"matchLabels": labels(testnetID)},
+						},
+						map[string]interface{}{
+							"podSelector": map[string]interface{}{
+								"matchLabels": map[string]string{"app": "genesis-controller"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -19,16 +19,9 @@
 package main
 
 import (
-	"github.com/whiteblock/genesis/rest"
-	"github.com/whiteblock/genesis/util"
-	"log"
+	"github.com/whiteblock/genesis/server"
 )
 
-var conf *util.Config
-
 func main() {
-	util.DisplayBanner()
-	conf = util.GetConfig()
-	log.SetFlags(log.LstdFlags | log.Llongfile)
-	rest.StartServer()
+	server.Run()
 }
@@ -0,0 +1,419 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package maintenance provides routines for keeping the servers in a clean state, such as
+// the removal of resources left behind by testnets which no longer exist.
+package maintenance
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	netem "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"github.com/whiteblock/genesis/workspace"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var conf = util.GetConfig()
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+const (
+	// ContainerResource is a docker container
+	ContainerResource = "container"
+
+	// NetworkResource is a docker network
+	NetworkResource = "network"
+
+	// NetemResource is a tc qdisc
+	NetemResource = "netem"
+
+	// IPTablesResource is an iptables rule
+	IPTablesResource = "iptables"
+
+	// TempDirResource is a temporary directory on the controller
+	TempDirResource = "tmp"
+
+	// CacheResource is a file in the cache of copied files on a server
+	CacheResource = "cache"
+
+	// StagingResource is the staging directory of a build on a server
+	StagingResource = "staging"
+)
+
+// CleanedResource represents a resource which was found to be orphaned
+type CleanedResource struct {
+	// Server is the id of the server on which the resource was found. Omitted for resources
+	// found on the controller.
+	Server int `json:"server,omitempty"`
+
+	// Type is the type of the resource
+	Type string `json:"type"`
+
+	// Name identifies the resource
+	Name string `json:"name"`
+}
+
+// GCReport contains the results of a garbage collection run
+type GCReport struct {
+	Cleaned []CleanedResource `json:"cleaned"`
+	Errors  []string          `json:"errors"`
+}
+
+func (report *GCReport) clean(server int, resourceType string, name string, dryRun bool, fn func() error) {
+	if !dryRun {
+		err := fn()
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to remove %s \"%s\" on server %d: %s",
+				resourceType, name, server, err.Error()))
+			return
+		}
+	}
+	log.WithFields(log.Fields{"server": server, "type": resourceType, "name": name, "dryRun": dryRun}).Info(
+		"removed an orphaned resource")
+	report.Cleaned = append(report.Cleaned, CleanedResource{Server: server, Type: resourceType, Name: name})
+}
+
+// liveResources contains what is known to be in use
+type liveResources struct {
+	testnets map[string]bool
+	scopes   map[string]bool
+	// networks is the relative node numbers in use on each server
+	networks map[int]map[int]bool
+	// busy is the servers on which a build is in progress
+	busy map[int]bool
+}
+
+func (lr liveResources) networkInUse(server int, network int) bool {
+	if lr.busy[server] {
+		return true
+	}
+	nets, ok := lr.networks[server]
+	return ok && nets[network]
+}
+
+func getLiveResources() (liveResources, error) {
+	out := liveResources{
+		testnets: map[string]bool{},
+		scopes:   map[string]bool{},
+		networks: map[int]map[int]bool{},
+		busy:     map[int]bool{},
+	}
+	nodes, err := db.GetAllNodes()
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	for _, node := range nodes {
+		out.testnets[node.TestNetID] = true
+		out.scopes[util.GetNameScope(node.TestNetID)] = true
+		if _, ok := out.networks[node.Server]; !ok {
+			out.networks[node.Server] = map[int]bool{}
+		}
+		out.networks[node.Server][node.LocalID] = true
+	}
+	for _, bs := range state.GetAllBuildStates() {
+		out.testnets[bs.BuildID] = true
+		out.scopes[util.GetNameScope(bs.BuildID)] = true
+		if bs.Done() {
+			continue
+		}
+		for _, server := range bs.Servers {
+			out.busy[server] = true
+		}
+	}
+	return out, nil
+}
+
+// CollectGarbage scans all of the servers for containers, networks, tc and iptables rules, as well as
+// the controller for temporary build directories, which do not belong to any live testnet, and removes them.
+// The staging directories of builds which are not live, and the files in the cache of copied files on the
+// servers which have not been used for remoteCacheMaxAge hours, are removed as well.
+// If dryRun is true, the orphaned resources will be reported but not removed.
+func CollectGarbage(dryRun bool) (GCReport, error) {
+	report := GCReport{Cleaned: []CleanedResource{}, Errors: []string{}}
+	live, err := getLiveResources()
+	if err != nil {
+		return report, util.LogError(err)
+	}
+
+	servers, err := db.GetAllServers()
+	if err != nil {
+		return report, util.LogError(err)
+	}
+	for _, server := range servers {
+		client, err := status.GetClient(server.ID)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("unable to connect to server %d: %s",
+				server.ID, err.Error()))
+			continue
+		}
+		collectors := []func(ssh.Client, int, liveResources, *GCReport, bool) error{
+			collectContainers, collectNetworks, collectCache, collectNetem, collectOutages, collectMarks, collectTraffic,
+			collectFirewall}
+		if client.Runtime().Rootless {
+			// the networks of rootless containers cannot be altered from the host
+			collectors = collectors[:3]
+		}
+		for _, fn := range collectors {
+
+			err = fn(client, server.ID, live, &report, dryRun)
+			if err != nil {
+				report.Errors = append(report.Errors, err.Error())
+			}
+		}
+		err = collectStaging(client, server.ID, server.StagingDir, live, &report, dryRun)
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		}
+	}
+	collectTempDirs(live, &report, dryRun)
+	return report, nil
+}
+
+func collectContainers(client ssh.Client, server int, live liveResources, report *GCReport, dryRun bool) error {
+	cli := client.Runtime().CLI
+	res, err := client.Run(cli + " ps -a --format '{{.Names}}'")
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, name := range strings.Split(res, "\n") {
+		scope, node, err := util.ParseContainerName(strings.TrimSpace(name))
+		if err != nil {
+			continue
+		}
+		if len(scope) > 0 && live.scopes[scope] {
+			continue
+		}
+		if len(scope) == 0 && live.networkInUse(server, node) {
+			continue
+		}
+		report.clean(server, ContainerResource, name, dryRun, func() error {
+			_, err := client.Run(fmt.Sprintf("%s rm -f %s", cli, name))
+			return err
+		})
+	}
+	return nil
+}
+
+func collectNetworks(client ssh.Client, server int, live liveResources, report *GCReport, dryRun bool) error {
+	cli := client.Runtime().CLI
+	res, err := client.Run(fmt.Sprintf("%s network ls --format '{{.Name}}' | grep '^%s' || true",
+		cli, conf.NodeNetworkPrefix))
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, name := range strings.Split(res, "\n") {
+		name = strings.TrimSpace(name)
+		scope, network, err := util.ParseNetworkName(name)
+		if err != nil {
+			continue
+		}
+		if len(scope) > 0 && live.scopes[scope] {
+			continue
+		}
+		if len(scope) == 0 && live.networkInUse(server, network) {
+			continue
+		}
+		report.clean(server, NetworkResource, name, dryRun, func() error {
+			_, err := client.Run(fmt.Sprintf("%s network rm %s", cli, name))
+			return err
+		})
+	}
+	return nil
+}
+
+func collectCache(client ssh.Client, server int, live liveResources, report *GCReport, dryRun bool) error {
+	if len(conf.RemoteCacheDir) == 0 || conf.RemoteCacheMaxAge <= 0 {
+		return nil
+	}
+	res, err := client.Run(fmt.Sprintf("find %s -maxdepth 1 -type f -mmin +%d 2>/dev/null || true",
+		util.ShellQuote(conf.RemoteCacheDir), conf.RemoteCacheMaxAge*60))
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, file := range strings.Split(res, "\n") {
+		file = strings.TrimSpace(file)
+		if len(file) == 0 {
+			continue
+		}
+		report.clean(server, CacheResource, file, dryRun, func() error {
+			_, err := client.Run("rm -f " + util.ShellQuote(file))
+			return err
+		})
+	}
+	return nil
+}
+
+// collectStaging removes the staging directories of builds which are not live, which are only left behind
+// when genesis stops during a build. dir is the staging directory of the server.
+func collectStaging(client ssh.Client, server int, dir string, live liveResources, report *GCReport, dryRun bool) error {
+	if live.busy[server] {
+		// the builds in progress make other directories named by uuids in the staging directory
+		return nil
+	}
+	if len(dir) == 0 {
+		dir = conf.StagingDir
+	}
+	if len(dir) == 0 {
+		dir = "/tmp"
+	}
+	res, err := client.Run(fmt.Sprintf("find %s -mindepth 1 -maxdepth 1 -type d 2>/dev/null || true", util.ShellQuote(dir)))
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, path := range strings.Split(res, "\n") {
+		path = strings.TrimSpace(path)
+		name := filepath.Base(path)
+		if !uuidPattern.MatchString(name) || live.testnets[name] {
+			continue
+		}
+		report.clean(server, StagingResource, path, dryRun, func() error {
+			_, err := client.Run("rm -rf " + util.ShellQuote(path))
+			return err
+		})
+	}
+	return nil
+}
+
+func collectNetem(client ssh.Client, server int, live liveResources, report *GCReport, dryRun bool) error {
+	res, err := client.Run(fmt.Sprintf("sudo -n tc qdisc show | grep -o 'dev %s[0-9]* root' | awk '{print $2}' || true",
+		conf.BridgePrefix))
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, dev := range strings.Split(res, "\n") {
+		network, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(dev), conf.BridgePrefix))
+		if err != nil || live.networkInUse(server, network) {
+			continue
+		}
+		report.clean(server, NetemResource, dev, dryRun, func() error {
+			_, err := client.Run(fmt.Sprintf("sudo -n tc qdisc del dev %s root", dev))
+			return err
+		})
+	}
+	return nil
+}
+
+var outagePattern = regexp.MustCompile(`-i ([^ ]+) `)
+
+func collectOutages(client ssh.Client, server int, live liveResources, report *GCReport, dryRun bool) error {
+	res, err := client.Run(fmt.Sprintf("sudo -n iptables --list-rules FORWARD | grep '%s' | grep DROP || true",
+		conf.BridgePrefix))
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, rule := range strings.Split(res, "\n") {
+		matches := outagePattern.FindStringSubmatch(rule)
+		if len(matches) < 2 {
+			continue
+		}
+		network, err := strconv.Atoi(strings.TrimPrefix(matches[1], conf.BridgePrefix))
+		if err != nil || live.networkInUse(server, network) {
+			continue
+		}
+		rule = strings.TrimPrefix(strings.TrimSpace(rule), "-A ")
+		report.clean(server, IPTablesResource, rule, dryRun, func() error {
+			_, err := client.Run(fmt.Sprintf("sudo -n iptables -D %s", rule))
+			return err
+		})
+	}
+	return nil
+}
+
+var markPattern = regexp.MustCompile(`! -d ([0-9.]+)(/32)? `)
+
+func collectMarks(client ssh.Client, server int, live liveResources, report *GCReport, dryRun bool) error {
+	res, err := client.Run("sudo -n iptables -t mangle --list-rules PREROUTING | grep 'MARK --set-xmark 0x6/' || true")
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, rule := range strings.Split(res, "\n") {
+		matches := markPattern.FindStringSubmatch(rule)
+		if len(matches) < 2 {
+			continue
+		}
+		_, network, _ := util.GetInfoFromIP(matches[1])
+		if live.networkInUse(server, network) {
+			continue
+		}
+		rule = strings.TrimPrefix(strings.TrimSpace(rule), "-A ")
+		report.clean(server, IPTablesResource, "-t mangle "+rule, dryRun, func() error {
+			_, err := client.Run(fmt.Sprintf("sudo -n iptables -t mangle -D %s", rule))
+			return err
+		})
+	}
+	return nil
+}
+
+func collectTraffic(client ssh.Client, server int, live liveResources, report *GCReport, dryRun bool) error {
+	return collectChains(client, server, netem.TrafficChainPrefix, live, report, dryRun)
+}
+
+func collectFirewall(client ssh.Client, server int, live liveResources, report *GCReport, dryRun bool) error {
+	return collectChains(client, server, netem.FirewallChainPrefix, live, report, dryRun)
+}
+
+// collectChains removes the iptables chains with the given prefix, followed by the scope of a testnet,
+// which do not belong to a live testnet
+func collectChains(client ssh.Client, server int, prefix string, live liveResources, report *GCReport, dryRun bool) error {
+	res, err := client.Run(fmt.Sprintf("sudo -n iptables --list-rules | grep '^-N %s' | awk '{print $2}' || true",
+		prefix))
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, chain := range strings.Split(res, "\n") {
+		chain = strings.TrimSpace(chain)
+		scope := strings.TrimPrefix(chain, prefix)
+		if len(scope) == 0 || scope == chain || live.scopes[scope] {
+			continue
+		}
+		report.clean(server, IPTablesResource, "-N "+chain, dryRun, func() error {
+			_, err := client.Run(fmt.Sprintf("sudo -n iptables -D FORWARD -j %s; sudo -n iptables -F %s && sudo -n iptables -X %s",
+				chain, chain, chain))
+			return err
+		})
+	}
+	return nil
+}
+
+func collectTempDirs(live liveResources, report *GCReport, dryRun bool) {
+	base := workspace.Base()
+	files, err := ioutil.ReadDir(base)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return
+	}
+	for _, file := range files {
+		if !file.IsDir() || !uuidPattern.MatchString(file.Name()) || live.testnets[file.Name()] {
+			continue
+		}
+		dir := filepath.Join(base, file.Name())
+		report.clean(0, TempDirResource, dir, dryRun, func() error {
+			return os.RemoveAll(dir)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package maintenance
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/ssh/mocks"
+	"github.com/whiteblock/genesis/util"
+)
+
+func testLiveResources() liveResources {
+	return liveResources{
+		testnets: map[string]bool{"4ac9d3b2-1a2b-4c5d-9e8f-0123456789ab": true},
+		scopes:   map[string]bool{"4ac9d3b2": true},
+		networks: map[int]map[int]bool{1: {0: true, 1: true}},
+		busy:     map[int]bool{},
+	}
+}
+
+func TestCollectContainers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conf.NodePrefix = "whiteblock-node"
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Runtime().Return(util.Runtime{Name: util.DockerRuntime, CLI: "docker"})
+	client.EXPECT().Run("docker ps -a --format '{{.Names}}'").Return(
+		"whiteblock-node4ac9d3b2-0\nwhiteblock-node4ac9d3b2-0-1\nwhiteblock-node0\nwhiteblock-node5\n"+
+			"whiteblock-node11111111-0\nwb_service0\n", nil)
+	client.EXPECT().Run("docker rm -f whiteblock-node5").Return("", nil)
+	client.EXPECT().Run("docker rm -f whiteblock-node11111111-0").Return("", nil)
+
+	report := GCReport{}
+	err := collectContainers(client, 1, testLiveResources(), &report, false)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := []CleanedResource{
+		{Server: 1, Type: ContainerResource, Name: "whiteblock-node5"},
+		{Server: 1, Type: ContainerResource, Name: "whiteblock-node11111111-0"},
+	}
+	if !reflect.DeepEqual(report.Cleaned, expected) {
+		t.Errorf("cleaned resources do not match expected value: %v", report.Cleaned)
+	}
+}
+
+func TestCollectNetworks_DryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conf.NodeNetworkPrefix = "wb_vlan"
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Runtime().Return(util.Runtime{Name: util.DockerRuntime, CLI: "docker"})
+	client.EXPECT().Run("docker network ls --format '{{.Name}}' | grep '^wb_vlan' || true").Return(
+		"wb_vlan0\nwb_vlan1\nwb_vlan2\nwb_vlan4ac9d3b2-5\nwb_vlan11111111-0\n", nil)
+
+	report := GCReport{}
+	err := collectNetworks(client, 1, testLiveResources(), &report, true)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := []CleanedResource{
+		{Server: 1, Type: NetworkResource, Name: "wb_vlan2"},
+		{Server: 1, Type: NetworkResource, Name: "wb_vlan11111111-0"},
+	}
+	if !reflect.DeepEqual(report.Cleaned, expected) {
+		t.Errorf("cleaned resources do not match expected value: %v", report.Cleaned)
+	}
+}
+
+func TestCollectTraffic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Run("sudo -n iptables --list-rules | grep '^-N wb_traffic_' | awk '{print $2}' || true").Return(
+		"wb_traffic_4ac9d3b2\nwb_traffic_11111111\n", nil)
+	client.EXPECT().Run("sudo -n iptables -D FORWARD -j wb_traffic_11111111; sudo -n iptables -F wb_traffic_11111111 && "+
+		"sudo -n iptables -X wb_traffic_11111111").Return("", nil)
+
+	report := GCReport{}
+	err := collectTraffic(client, 1, testLiveResources(), &report, false)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := []CleanedResource{{Server: 1, Type: IPTablesResource, Name: "-N wb_traffic_11111111"}}
+	if !reflect.DeepEqual(report.Cleaned, expected) {
+		t.Errorf("cleaned resources do not match expected value: %v", report.Cleaned)
+	}
+}
+
+func TestCollectFirewall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Run("sudo -n iptables --list-rules | grep '^-N wb_ports_' | awk '{print $2}' || true").Return(
+		"wb_ports_4ac9d3b2\nwb_ports_11111111\n", nil)
+	client.EXPECT().Run("sudo -n iptables -D FORWARD -j wb_ports_11111111; sudo -n iptables -F wb_ports_11111111 && "+
+		"sudo -n iptables -X wb_ports_11111111").Return("", nil)
+
+	report := GCReport{}
+	err := collectFirewall(client, 1, testLiveResources(), &report, false)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := []CleanedResource{{Server: 1, Type: IPTablesResource, Name: "-N wb_ports_11111111"}}
+	if !reflect.DeepEqual(report.Cleaned, expected) {
+		t.Errorf("cleaned resources do not match expected value: %v", report.Cleaned)
+	}
+}
+
+func TestCollectCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conf.RemoteCacheDir = "/var/cache/genesis"
+	conf.RemoteCacheMaxAge = 72
+	defer func() { conf.RemoteCacheDir = "" }()
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Run("find '/var/cache/genesis' -maxdepth 1 -type f -mmin +4320 2>/dev/null || true").Return(
+		"/var/cache/genesis/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b\n", nil)
+	client.EXPECT().Run("rm -f '/var/cache/genesis/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b'").Return("", nil)
+
+	report := GCReport{}
+	err := collectCache(client, 1, testLiveResources(), &report, false)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := []CleanedResource{{Server: 1, Type: CacheResource,
+		Name: "/var/cache/genesis/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b"}}
+	if !reflect.DeepEqual(report.Cleaned, expected) {
+		t.Errorf("cleaned resources do not match expected value: %v", report.Cleaned)
+	}
+}
+
+func TestCollectStaging(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Run("find '/data/staging' -mindepth 1 -maxdepth 1 -type d 2>/dev/null || true").Return(
+		"/data/staging/4ac9d3b2-1a2b-4c5d-9e8f-0123456789ab\n/data/staging/11111111-1a2b-4c5d-9e8f-0123456789ab\n"+
+			"/data/staging/keep\n", nil)
+	client.EXPECT().Run("rm -rf '/data/staging/11111111-1a2b-4c5d-9e8f-0123456789ab'").Return("", nil)
+
+	report := GCReport{}
+	err := collectStaging(client, 1, "/data/staging", testLiveResources(), &report, false)
+	if err != nil {
+		t.Error(err)
+	}
+	expected := []CleanedResource{{Server: 1, Type: StagingResource,
+		Name: "/data/staging/11111111-1a2b-4c5d-9e8f-0123456789ab"}}
+	if !reflect.DeepEqual(report.Cleaned, expected) {
+		t.Errorf("cleaned resources do not match expected value: %v", report.Cleaned)
+	}
+}
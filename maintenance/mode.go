@@ -0,0 +1,76 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package maintenance
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+	"time"
+)
+
+// modeKey is the meta key the maintenance mode is kept under, so that it survives a restart
+const modeKey = "maintenance"
+
+// Mode is whether genesis is in maintenance mode, in which it rejects any request which would change
+// something, such as a new build, while still serving the requests which only read
+type Mode struct {
+	// Enabled is whether genesis is in maintenance mode
+	Enabled bool `json:"enabled"`
+	// Reason is what the operator gave as the reason for the maintenance
+	Reason string `json:"reason,omitempty"`
+	// Since is when the maintenance mode was enabled, as a unix timestamp
+	Since int64 `json:"since,omitempty"`
+}
+
+var (
+	mode       Mode
+	modeLoaded bool
+	modeMux    sync.Mutex
+)
+
+// GetMode gets the current maintenance mode, which is loaded from the database the first time it is needed
+func GetMode() Mode {
+	modeMux.Lock()
+	defer modeMux.Unlock()
+	if !modeLoaded {
+		modeLoaded = true
+		db.GetMetaP(modeKey, &mode) //An error here just means that maintenance mode was never enabled
+	}
+	return mode
+}
+
+// SetMode enables or disables maintenance mode, with the given reason
+func SetMode(enabled bool, reason string) (Mode, error) {
+	modeMux.Lock()
+	defer modeMux.Unlock()
+	out := Mode{}
+	if enabled {
+		out = Mode{Enabled: true, Reason: reason, Since: time.Now().Unix()}
+	}
+	err := db.SetMeta(modeKey, out)
+	if err != nil {
+		return mode, util.LogError(err)
+	}
+	mode = out
+	modeLoaded = true
+	log.WithFields(log.Fields{"enabled": out.Enabled, "reason": out.Reason}).Info("set the maintenance mode")
+	return out, nil
+}
@@ -0,0 +1,58 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package maintenance
+
+import (
+	"testing"
+)
+
+func TestSetMode(t *testing.T) {
+	original := GetMode()
+	defer SetMode(original.Enabled, original.Reason)
+
+	enabled, err := SetMode(true, "upgrading docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enabled.Enabled || enabled.Reason != "upgrading docker" || enabled.Since == 0 {
+		t.Errorf("unexpected mode once enabled: %+v", enabled)
+	}
+	if GetMode() != enabled {
+		t.Errorf("GetMode gave %+v, expected %+v", GetMode(), enabled)
+	}
+
+	modeMux.Lock()
+	modeLoaded = false
+	mode = Mode{}
+	modeMux.Unlock()
+	if GetMode() != enabled {
+		t.Errorf("the mode was not stored, GetMode gave %+v after loading it again", GetMode())
+	}
+
+	disabled, err := SetMode(false, "done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disabled != (Mode{}) {
+		t.Errorf("unexpected mode once disabled: %+v", disabled)
+	}
+	if GetMode().Enabled {
+		t.Error("the maintenance mode is still enabled")
+	}
+}
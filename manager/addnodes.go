@@ -20,12 +20,16 @@ package manager
 
 import (
 	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/artifacts"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/preflight"
 	"github.com/whiteblock/genesis/protocols/registrar"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
+	"github.com/whiteblock/genesis/webhook"
 )
 
 // AddNodes allows for nodes to be added to the network.
@@ -43,7 +47,12 @@ func AddNodes(details *db.DeploymentDetails, testnetID string) error {
 		buildState.ReportError(err)
 		return err
 	}
+	notifyOnCompletion(tn, details)
 	defer tn.FinishedBuilding()
+	defer artifacts.TakePending(testnetID)     //drop the artifacts registered by a failed build
+	defer util.Recover(buildState.ReportError) //fail the build on a panic, before it is finished
+	webhook.Emit(webhook.BuildStarted, testnetID, map[string]interface{}{
+		"blockchain": details.Blockchain, "nodes": details.Nodes})
 
 	err = tn.AddDetails(*details)
 	if err != nil {
@@ -51,6 +60,12 @@ func AddNodes(details *db.DeploymentDetails, testnetID string) error {
 		return err
 	}
 
+	err = buildState.SetTimeouts(details.Timeouts)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+
 	//STEP 2: VALIDATE
 	for i, res := range details.Resources {
 		err = res.ValidateAndSetDefaults()
@@ -61,6 +76,19 @@ func AddNodes(details *db.DeploymentDetails, testnetID string) error {
 		}
 	}
 
+	// the nodes of the startups are the absolute numbers of the nodes, counting those already in the testnet
+	err = db.ValidateStartups(details.Startup, len(tn.Nodes)+details.Nodes)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+
+	err = preflight.Check(details.Blockchain)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+
 	if len(tn.Nodes)+details.Nodes > conf.MaxNodes {
 		buildState.ReportError(fmt.Errorf("too many nodes"))
 		return fmt.Errorf("too many nodes")
@@ -72,12 +100,29 @@ func AddNodes(details *db.DeploymentDetails, testnetID string) error {
 		return err
 	}
 
+	err = deploy.SeedChainData(tn)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+
+	err = deploy.EnforceTopology(tn)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+
 	addNodesFn, err := registrar.GetAddNodeFunc(details.Blockchain)
 	if err != nil {
 		buildState.ReportError(err)
 		return err
 	}
-	err = addNodesFn(tn)
+	err = runBuildFn(tn, addNodesFn)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+	err = deploy.WaitForHealthy(tn)
 	if err != nil {
 		buildState.ReportError(err)
 		return err
@@ -89,11 +134,27 @@ func AddNodes(details *db.DeploymentDetails, testnetID string) error {
 		return err
 	}
 
+	err = deploy.CollectBuildArtifacts(tn)
+	if err != nil {
+		log.WithFields(log.Fields{"build": testnetID, "error": err}).Warn("failed to collect the artifacts")
+	}
+	watchConsensus(tn)
+
 	err = tn.StoreNodes()
 	if err != nil {
 		buildState.ReportError(err)
 		return err
 	}
 
+	_, err = db.InsertDeployment(testnetID, *details)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+	err = db.SetTestNetNodes(testnetID, len(tn.Nodes))
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
 	return nil
 }
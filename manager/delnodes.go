@@ -20,6 +20,7 @@ package manager
 
 import (
 	"fmt"
+	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/docker"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
@@ -40,15 +41,15 @@ func DelNodes(num int, testnetID string) error {
 	for i := len(tn.Nodes) - 1; i >= (len(tn.Nodes) - num); i-- {
 		node := tn.Nodes[i]
 		client := tn.Clients[node.GetServerID()]
-		err = docker.Kill(client, node.GetRelativeNumber())
+		err = docker.Kill(client, node)
 		if err != nil {
 			return util.LogError(err)
 		}
-		err = docker.NetworkDestroy(client, node.GetRelativeNumber())
+		err = docker.NetworkDestroy(tn, node.GetServerID(), node.GetRelativeNumber())
 		if err != nil {
 			return util.LogError(err)
 		}
 	}
 	tn.Nodes = tn.Nodes[:(len(tn.Nodes) - num)]
-	return nil
+	return util.LogError(db.SetTestNetNodes(testnetID, len(tn.Nodes)))
 }
@@ -0,0 +1,267 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/artifacts"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/soak"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"github.com/whiteblock/genesis/webhook"
+	"sync"
+	"time"
+)
+
+const expirationsKey = "expirations"
+
+// Expiration represents when a testnet is going to be torn down
+type Expiration struct {
+	// Expires is the unix timestamp of when the testnet will be torn down
+	Expires int64 `json:"expires"`
+
+	// Warned is whether or not the warning of the upcoming expiry has been sent
+	Warned bool `json:"warned"`
+
+	// Failures is the number of times tearing down the testnet has failed since it expired
+	Failures int `json:"failures,omitempty"`
+
+	// Retry is the unix timestamp of when tearing down the testnet will be attempted again,
+	// after it failed
+	Retry int64 `json:"retry,omitempty"`
+}
+
+// maxReapBackoff is the longest the reaper waits before trying again to tear down a testnet
+const maxReapBackoff = time.Hour
+
+var expirationsMux = sync.Mutex{}
+
+func getExpirations() map[string]Expiration {
+	out := map[string]Expiration{}
+	db.GetMetaP(expirationsKey, &out) //An error means there are no expirations
+	return out
+}
+
+// SetExpiry schedules the testnet to be torn down once the given ttl has elapsed
+func SetExpiry(testnetID string, ttl time.Duration) error {
+	expirationsMux.Lock()
+	defer expirationsMux.Unlock()
+	expirations := getExpirations()
+	expirations[testnetID] = Expiration{Expires: time.Now().Add(ttl).Unix()}
+	return db.SetMeta(expirationsKey, expirations)
+}
+
+// GetExpiry gets the expiration of the given testnet
+func GetExpiry(testnetID string) (Expiration, error) {
+	expirationsMux.Lock()
+	defer expirationsMux.Unlock()
+	expiration, ok := getExpirations()[testnetID]
+	if !ok {
+		return Expiration{}, fmt.Errorf("testnet \"%s\" does not expire", testnetID)
+	}
+	return expiration, nil
+}
+
+func removeExpiry(testnetID string) error {
+	expirationsMux.Lock()
+	defer expirationsMux.Unlock()
+	expirations := getExpirations()
+	if _, ok := expirations[testnetID]; !ok {
+		return nil
+	}
+	delete(expirations, testnetID)
+	return db.SetMeta(expirationsKey, expirations)
+}
+
+func handleTTL(details *db.DeploymentDetails, testnetID string) error {
+	if len(details.TTL) == 0 {
+		return nil
+	}
+	ttl, err := util.ParseDuration(details.TTL, time.Second)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return SetExpiry(testnetID, ttl)
+}
+
+// TearDownTestNet destroys a testnet, and removes all of the data stored about it.
+func TearDownTestNet(testnetID string) error {
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = destroyTestNet(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = db.DeleteNodesByTestNet(testnetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = db.DeleteBuildsByTestnet(testnetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = db.DeleteDeploymentsByTestNet(testnetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = db.DeleteTestNet(testnetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = tn.Destroy()
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = tn.BuildState.Destroy()
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = webhook.RemoveByTestNet(testnetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return removeExpiry(testnetID)
+}
+
+// destroyTestNet tears down everything the testnet has running and its artifacts, which both deleting
+// and tearing down a testnet do, leaving what is stored about it to the caller
+func destroyTestNet(tn *testnet.TestNet) error {
+	util.LogError(soak.Stop(tn.TestNetID))
+	err := deploy.Destroy(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+	status.ForgetTestNet(tn.TestNetID)
+	return artifacts.Remove(tn.TestNetID)
+}
+
+// StartReaper starts the routine which tears down the testnets whose ttl has expired
+func StartReaper() {
+	go func() {
+		for {
+			time.Sleep(time.Duration(conf.ReaperInterval) * time.Second)
+			reap()
+		}
+	}()
+}
+
+func reap() {
+	expirationsMux.Lock()
+	expirations := getExpirations()
+	expirationsMux.Unlock()
+
+	now := time.Now().Unix()
+	for testnetID, expiration := range expirations {
+		if expiration.Expires <= now && stillBuilding(testnetID) {
+			log.WithFields(log.Fields{"build": testnetID}).Debug("waiting for the build to finish to tear down the testnet")
+			continue
+		}
+		if expiration.Expires <= now && expiration.Retry > now {
+			continue
+		}
+		if expiration.Expires <= now {
+			log.WithFields(log.Fields{"build": testnetID}).Info("tearing down expired testnet")
+			err := TearDownTestNet(testnetID)
+			if err != nil && testnetGone(testnetID) {
+				removeExpiry(testnetID)
+				continue
+			}
+			if err != nil {
+				retry := markFailed(testnetID)
+				log.WithFields(log.Fields{"build": testnetID, "error": err, "retry": retry}).Error(
+					"failed to tear down expired testnet")
+				continue
+			}
+			webhook.Emit(webhook.TestNetExpired, testnetID, nil)
+			continue
+		}
+		if !expiration.Warned && expiration.Expires-int64(conf.ExpiryWarning) <= now {
+			webhook.Emit(webhook.TestNetExpiring, testnetID, map[string]interface{}{"expires": expiration.Expires})
+			markWarned(testnetID)
+		}
+	}
+}
+
+// stillBuilding checks if the given testnet is in the middle of a build, as it can only be torn down
+// once the build is done, whether or not it succeeded
+func stillBuilding(testnetID string) bool {
+	for _, bs := range state.GetAllBuildStates() {
+		if bs.BuildID == testnetID && !bs.Done() {
+			return true
+		}
+	}
+	return false
+}
+
+// testnetGone checks if the given testnet no longer exists or has been deleted, in which case there is
+// nothing left to tear down
+func testnetGone(testnetID string) bool {
+	testNet, err := db.GetTestNet(testnetID)
+	return err != nil || testNet.Status == db.TestNetDeleted
+}
+
+// reapBackoff gets how long to wait before trying again to tear down a testnet, once it has failed
+// the given number of times. The wait doubles with each failure, starting at the reaper interval.
+func reapBackoff(failures int) time.Duration {
+	backoff := time.Duration(conf.ReaperInterval) * time.Second
+	for i := 1; i < failures && backoff < maxReapBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxReapBackoff {
+		return maxReapBackoff
+	}
+	return backoff
+}
+
+func updateExpiry(testnetID string, fn func(*Expiration)) {
+	expirationsMux.Lock()
+	defer expirationsMux.Unlock()
+	expirations := getExpirations()
+	expiration, ok := expirations[testnetID]
+	if !ok {
+		return
+	}
+	fn(&expiration)
+	expirations[testnetID] = expiration
+	db.SetMeta(expirationsKey, expirations)
+}
+
+func markWarned(testnetID string) {
+	updateExpiry(testnetID, func(expiration *Expiration) {
+		expiration.Warned = true
+	})
+}
+
+// markFailed records a failed attempt at tearing down the testnet, and gives when the next attempt will be
+func markFailed(testnetID string) time.Time {
+	var retry time.Time
+	updateExpiry(testnetID, func(expiration *Expiration) {
+		expiration.Failures++
+		retry = time.Now().Add(reapBackoff(expiration.Failures))
+		expiration.Retry = retry.Unix()
+	})
+	return retry
+}
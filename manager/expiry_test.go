@@ -0,0 +1,72 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"errors"
+	"github.com/whiteblock/genesis/state"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_stillBuilding(t *testing.T) {
+	if stillBuilding("expiry-test") {
+		t.Error("a testnet which is not being built is reported as building")
+	}
+	err := state.AcquireBuilding([]int{90001}, "expiry-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stillBuilding("expiry-test") {
+		t.Error("the testnet is being built, but is not reported as building")
+	}
+	bs, err := state.GetBuildStateByID("expiry-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs.ReportError(errors.New("failed partway"))
+	bs.DoneBuilding()
+	if stillBuilding("expiry-test") {
+		t.Error("the failed build is still reported as building")
+	}
+}
+
+func Test_reapBackoff(t *testing.T) {
+	defer func(interval int) { conf.ReaperInterval = interval }(conf.ReaperInterval)
+	conf.ReaperInterval = 60
+	var test = []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 1, expected: time.Minute},
+		{failures: 2, expected: 2 * time.Minute},
+		{failures: 4, expected: 8 * time.Minute},
+		{failures: 7, expected: time.Hour},
+		{failures: 1000, expected: time.Hour},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if reapBackoff(tt.failures) != tt.expected {
+				t.Errorf("reapBackoff(%d) gave %v, expected %v", tt.failures, reapBackoff(tt.failures), tt.expected)
+			}
+		})
+	}
+}
@@ -24,13 +24,20 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/artifacts"
+	"github.com/whiteblock/genesis/consensus"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/notify"
+	"github.com/whiteblock/genesis/preflight"
 	"github.com/whiteblock/genesis/protocols/helpers"
 	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/protocols/services"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
+	"github.com/whiteblock/genesis/webhook"
 	"sync"
+	"time"
 	//Put the relative path to your blockchain/sidecar library below this line, otherwise it won't be compiled
 	//blockchains
 	_ "github.com/whiteblock/genesis/protocols/aion"
@@ -77,7 +84,13 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 		return err
 	}
 	buildState := tn.BuildState
+	notifyOnCompletion(tn, details)
+	recordTestNet(tn, details)
 	defer tn.FinishedBuilding()
+	defer artifacts.TakePending(testnetID)     //drop the artifacts registered by a failed build
+	defer util.Recover(buildState.ReportError) //fail the build on a panic, before it is finished
+	webhook.Emit(webhook.BuildStarted, testnetID, map[string]interface{}{
+		"blockchain": details.Blockchain, "nodes": details.Nodes})
 
 	//STEP 0: VALIDATE
 	err = validate(details)
@@ -87,6 +100,25 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 		return err
 	}
 
+	//the expiry is set before anything is provisioned, so that a build which fails partway is torn down as well
+	err = handleTTL(details, testnetID)
+	if err != nil {
+		tn.BuildState.ReportError(err)
+		return err
+	}
+
+	err = tn.BuildState.SetTimeouts(details.Timeouts)
+	if err != nil {
+		tn.BuildState.ReportError(err)
+		return err
+	}
+
+	err = preflight.Check(details.Blockchain)
+	if err != nil {
+		tn.BuildState.ReportError(err)
+		return err
+	}
+
 	tn.BuildState.Async(func() {
 		declareTestnet(testnetID, details)
 	})
@@ -97,16 +129,66 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 		tn.BuildState.ReportError(err)
 		return err
 	}
-	services := servicesFn()
+	servs := servicesFn()
+	explorer, err := services.RegisterExplorer(details)
+	if err != nil {
+		tn.BuildState.ReportError(err)
+		return err
+	}
+	if explorer != nil {
+		servs = append(servs, explorer)
+	}
+	monitoring, err := services.RegisterMonitoring(details)
+	if err != nil {
+		tn.BuildState.ReportError(err)
+		return err
+	}
+	if monitoring != nil {
+		servs = append(removeService(servs, services.PrometheusName), monitoring...)
+	}
 	//STEP 4: BUILD OUT THE DOCKER CONTAINERS AND THE NETWORK
 
-	err = deploy.Build(tn, services)
+	err = deploy.Build(tn, servs)
 	if err != nil {
 		tn.BuildState.ReportError(err)
 		return err
 	}
+	if explorer != nil {
+		url, err := explorer.GetURL(tn)
+		if err != nil {
+			tn.BuildState.ReportError(err)
+			return err
+		}
+		tn.BuildState.SetExt(services.ExplorerName, url)
+	}
+	if monitoring != nil {
+		url, err := services.GetServiceURL(tn, services.GrafanaName, conf.GrafanaPort, 3000)
+		if err != nil {
+			tn.BuildState.ReportError(err)
+			return err
+		}
+		tn.BuildState.SetExt(services.GrafanaName, url)
+		url, err = services.GetServiceURL(tn, services.PrometheusName, conf.PrometheusPort, 9090)
+		if err != nil {
+			tn.BuildState.ReportError(err)
+			return err
+		}
+		tn.BuildState.SetExt(services.PrometheusName, url)
+	}
 	log.WithFields(log.Fields{"build": testnetID}).Trace("Built the docker containers")
 
+	err = deploy.SeedChainData(tn)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+
+	err = deploy.EnforceTopology(tn)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+
 	buildFn, err := registrar.GetBuildFunc(details.Blockchain)
 	if err != nil {
 		buildState.ReportError(err)
@@ -117,7 +199,24 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 		tn.BuildState.SetSidecars(len(sidecars))
 	}
 
-	err = buildFn(tn)
+	err = helpers.RunHooks(tn, db.BeforeInitHook)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+
+	err = runBuildFn(tn, buildFn)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+	err = deploy.WaitForHealthy(tn)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+
+	err = helpers.RunHooks(tn, db.AfterStartHook)
 	if err != nil {
 		buildState.ReportError(err)
 		return err
@@ -146,11 +245,22 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 		return err
 	}
 
+	err = deploy.CollectBuildArtifacts(tn)
+	if err != nil {
+		log.WithFields(log.Fields{"build": testnetID, "error": err}).Warn("failed to collect the artifacts")
+	}
+	watchConsensus(tn)
+
 	err = db.InsertBuild(*details, testnetID)
 	if err != nil {
 		buildState.ReportError(err)
 		return err
 	}
+	_, err = db.InsertDeployment(testnetID, *details)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
 	err = tn.StoreNodes()
 	if err != nil {
 		buildState.ReportError(err)
@@ -159,6 +269,43 @@ func AddTestNet(details *db.DeploymentDetails, testnetID string) error {
 	return nil
 }
 
+// runBuildFn runs the blockchain specific build function, retrying it up to buildRetries times if
+// it fails, which is never by default. On each retry, the files which were already copied to a node with
+// the copy helpers and the main processes already started with helpers.StartMainDaemon are skipped, as well
+// as any other helpers.Step of the builder. The rest of the builder is run again.
+func runBuildFn(tn *testnet.TestNet, fn func(*testnet.TestNet) error) error {
+	err := fn(tn)
+	for i := 0; err != nil && i < conf.BuildRetries && !tn.BuildState.Stop(); i++ {
+		log.WithFields(log.Fields{"build": tn.TestNetID, "attempt": i + 1, "error": err}).Warn(
+			"the build failed, retrying")
+		tn.BuildState.ClearError()
+		err = fn(tn)
+	}
+	return err
+}
+
+// watchConsensus starts probing the consensus of the testnet, if its blockchain has a consensus probe
+func watchConsensus(tn *testnet.TestNet) {
+	if _, err := registrar.GetConsensusProbe(tn.LDD.Blockchain); err != nil {
+		return
+	}
+	err := consensus.Watch(tn)
+	if err != nil {
+		log.WithFields(log.Fields{"build": tn.TestNetID, "error": err}).Warn("failed to start probing the consensus")
+	}
+}
+
+// removeService removes the services with the given name, so that it can be replaced
+func removeService(servs []services.Service, name string) []services.Service {
+	out := []services.Service{}
+	for _, service := range servs {
+		if service.GetName() != name {
+			out = append(out, service)
+		}
+	}
+	return out
+}
+
 func handleSideCars(tn *testnet.TestNet, append bool) error {
 	sidecars, err := registrar.GetBlockchainSideCars(tn)
 	if err != nil || sidecars == nil || len(sidecars) == 0 {
@@ -183,7 +330,9 @@ func handleSideCars(tn *testnet.TestNet, append bool) error {
 		}
 		go func(i int) {
 			defer wg.Done()
-			err := buildFn(ad)
+			err := util.Safe(func() error {
+				return buildFn(ad)
+			})
 			if err != nil {
 				tn.BuildState.ReportError(err)
 			}
@@ -193,6 +342,33 @@ func handleSideCars(tn *testnet.TestNet, append bool) error {
 	return nil
 }
 
+// notifyOnCompletion sends out a summary of the build to the configured chat services
+// once the build has finished
+func notifyOnCompletion(tn *testnet.TestNet, details *db.DeploymentDetails) {
+	start := time.Now()
+	tn.BuildState.Defer(func() {
+		notify.BuildFinished(notify.Summary{
+			TestNetID:  tn.TestNetID,
+			Blockchain: details.Blockchain,
+			Nodes:      details.Nodes,
+			Duration:   time.Since(start),
+			Error:      tn.BuildState.GetError(),
+		})
+	})
+}
+
+// recordTestNet adds the testnet to the listing of testnets, and updates its status once the build is done
+func recordTestNet(tn *testnet.TestNet, details *db.DeploymentDetails) {
+	util.LogError(db.InsertTestNet(tn.TestNetID, *details))
+	tn.BuildState.Defer(func() {
+		status := db.TestNetRunning
+		if tn.BuildState.GetError() != nil {
+			status = db.TestNetFailed
+		}
+		util.LogError(db.SetTestNetStatus(tn.TestNetID, status))
+	})
+}
+
 func declareTestnet(testnetID string, details *db.DeploymentDetails) error {
 	if len(details.GetJwt()) == 0 || conf.DisableTestnetReporting {
 		return nil
@@ -217,8 +393,19 @@ func DeleteTestNet(testnetID string) error {
 	if err != nil {
 		return util.LogError(err)
 	}
-
-	return deploy.Destroy(tn)
+	err = destroyTestNet(tn)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = removeExpiry(testnetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = db.SetTestNetStatus(testnetID, db.TestNetDeleted)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return webhook.RemoveByTestNet(testnetID)
 }
 
 // GetParams fetches the name and type of each available
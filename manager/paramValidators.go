@@ -21,7 +21,11 @@ package manager
 import (
 	"fmt"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/topology"
 	"github.com/whiteblock/genesis/util"
+	"time"
 )
 
 func validateResources(details *db.DeploymentDetails) error {
@@ -88,6 +92,81 @@ func checkForNilOrMissing(details *db.DeploymentDetails) error {
 	return nil
 }
 
+func validateTTL(details *db.DeploymentDetails) error {
+	if len(details.TTL) == 0 {
+		return nil
+	}
+	ttl, err := util.ParseDuration(details.TTL, time.Second)
+	if err != nil {
+		return fmt.Errorf("invalid ttl \"%s\"", details.TTL)
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("ttl must be positive")
+	}
+	return nil
+}
+
+func validateTimeouts(details *db.DeploymentDetails) error {
+	return details.Timeouts.Validate()
+}
+
+func validateSeeds(details *db.DeploymentDetails) error {
+	_, err := deploy.GetSeeds(details)
+	return err
+}
+
+func validateArtifacts(details *db.DeploymentDetails) error {
+	_, err := deploy.GetArtifactPaths(details)
+	return err
+}
+
+func validateTopology(details *db.DeploymentDetails) error {
+	top, err := topology.Get(details)
+	if err != nil {
+		return err
+	}
+	return top.Check(details.Nodes)
+}
+
+func validateEnv(details *db.DeploymentDetails) error {
+	for name := range details.Env {
+		err := util.ValidateEnvName(name)
+		if err != nil {
+			return err
+		}
+	}
+	for i, env := range details.Environments {
+		for name := range env {
+			err := util.ValidateEnvName(name)
+			if err != nil {
+				return fmt.Errorf("%s. For node %d", err.Error(), i)
+			}
+		}
+	}
+	return nil
+}
+
+func validateStartup(details *db.DeploymentDetails) error {
+	return db.ValidateStartups(details.Startup, details.Nodes)
+}
+
+func validateHooks(details *db.DeploymentDetails) error {
+	for i, hook := range details.Hooks {
+		err := hook.Validate(details.Nodes)
+		if err != nil {
+			return fmt.Errorf("%s. For hook %d", err.Error(), i)
+		}
+		if hook.Stage == db.AfterGenesisHook && !helpers.SupportsGenesis(details.Blockchain) {
+			return fmt.Errorf("%s does not support %s hooks. For hook %d", details.Blockchain, hook.Stage, i)
+		}
+	}
+	return nil
+}
+
+func validateGenesis(details *db.DeploymentDetails) error {
+	return helpers.CheckGenesisFiles(details)
+}
+
 func validate(details *db.DeploymentDetails) error {
 	err := validateNumOfNodes(details)
 	if err != nil {
@@ -109,5 +188,50 @@ func validate(details *db.DeploymentDetails) error {
 		return util.LogError(err)
 	}
 
+	err = validateTTL(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateTimeouts(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateSeeds(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateArtifacts(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateTopology(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateGenesis(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateHooks(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateEnv(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	err = validateStartup(details)
+	if err != nil {
+		return util.LogError(err)
+	}
+
 	return validateBlockchain(details)
 }
@@ -19,6 +19,7 @@
 package manager
 
 import (
+	"encoding/base64"
 	"errors"
 	"reflect"
 	"strconv"
@@ -364,6 +365,348 @@ func Test_checkForNilOrMissing(t *testing.T) {
 	}
 }
 
+func Test_validateTTL(t *testing.T) {
+	var test = []struct {
+		ttl      string
+		expected error
+	}{
+		{ttl: "", expected: nil},
+		{ttl: "24h", expected: nil},
+		{ttl: "1h30m", expected: nil},
+		{ttl: "2d", expected: nil},
+		{ttl: "-5m", expected: errors.New("ttl must be positive")},
+		{ttl: "tomorrow", expected: errors.New("invalid ttl \"tomorrow\"")},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if !reflect.DeepEqual(validateTTL(&db.DeploymentDetails{TTL: tt.ttl}), tt.expected) {
+				t.Errorf("returned error of validateTTL does not match expected error")
+			}
+		})
+	}
+}
+
+func Test_validateTimeouts(t *testing.T) {
+	var test = []struct {
+		timeouts util.Timeouts
+		expected error
+	}{
+		{timeouts: util.Timeouts{}, expected: nil},
+		{timeouts: util.Timeouts{Stage: "30m", Command: "5m"}, expected: nil},
+		{timeouts: util.Timeouts{Stages: map[string]string{"Building": "2h"}}, expected: nil},
+		{timeouts: util.Timeouts{Command: "later"}, expected: errors.New("invalid command timeout: \"later\" is not a duration such as 30s, 5m or 2d")},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := validateTimeouts(&db.DeploymentDetails{Timeouts: tt.timeouts})
+			if !reflect.DeepEqual(err, tt.expected) {
+				t.Errorf("expected error %v but got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func Test_validateSeeds(t *testing.T) {
+	var test = []struct {
+		seeds    interface{}
+		expected error
+	}{
+		{seeds: nil, expected: nil},
+		{seeds: []interface{}{map[string]interface{}{"url": "https://example.com/chain.tar.gz"}}, expected: nil},
+		{
+			seeds: []interface{}{
+				map[string]interface{}{"url": "http://example.com/chain.tar"},
+				map[string]interface{}{"volume": "snapshot-0", "dir": "/geth"},
+			},
+			expected: nil,
+		},
+		{
+			seeds:    []interface{}{map[string]interface{}{"dir": "/geth"}},
+			expected: errors.New("a seed needs either a url or a volume. For seed 0"),
+		},
+		{
+			seeds: []interface{}{
+				map[string]interface{}{"url": "http://example.com/chain.tar", "volume": "snapshot"},
+			},
+			expected: errors.New("a seed needs either a url or a volume. For seed 0"),
+		},
+		{
+			seeds:    []interface{}{map[string]interface{}{"url": "ftp://example.com/chain.tar"}},
+			expected: errors.New("invalid seed url \"ftp://example.com/chain.tar\". For seed 0"),
+		},
+		{
+			seeds: []interface{}{
+				map[string]interface{}{"url": "http://example.com/chain.tar"},
+				map[string]interface{}{"volume": "snapshot", "dir": "geth"},
+			},
+			expected: errors.New("the seed dir \"geth\" must be an absolute path. For seed 1"),
+		},
+		{
+			seeds:    []interface{}{map[string]interface{}{"volume": "-snapshot"}},
+			expected: errors.New("invalid volume name \"-snapshot\". For seed 0"),
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			details := &db.DeploymentDetails{Extras: map[string]interface{}{}}
+			if tt.seeds != nil {
+				details.Extras["seeds"] = tt.seeds
+			}
+			if !reflect.DeepEqual(validateSeeds(details), tt.expected) {
+				t.Errorf("returned error of validateSeeds does not match expected error")
+			}
+		})
+	}
+}
+
+func Test_validateArtifacts(t *testing.T) {
+	var test = []struct {
+		artifacts interface{}
+		expected  error
+	}{
+		{artifacts: nil, expected: nil},
+		{artifacts: []interface{}{"/geth/bench.csv", "/geth/genesis.json"}, expected: nil},
+		{
+			artifacts: []interface{}{"bench.csv"},
+			expected:  errors.New("the artifact path \"bench.csv\" must be an absolute path. For artifact 0"),
+		},
+		{
+			artifacts: []interface{}{"/geth/bench.csv", "/geth/../etc/passwd"},
+			expected:  errors.New("invalid artifact path: cannot contain \"..\". For artifact 1"),
+		},
+		{
+			artifacts: "/geth/bench.csv",
+			expected:  errors.New("invalid artifacts: json: cannot unmarshal string into Go value of type []string"),
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			details := &db.DeploymentDetails{Extras: map[string]interface{}{}}
+			if tt.artifacts != nil {
+				details.Extras["artifacts"] = tt.artifacts
+			}
+			if !reflect.DeepEqual(validateArtifacts(details), tt.expected) {
+				t.Errorf("returned error of validateArtifacts does not match expected error")
+			}
+		})
+	}
+}
+
+func Test_validateTopology(t *testing.T) {
+	var test = []struct {
+		topology interface{}
+		nodes    int
+		expected error
+	}{
+		{topology: nil, nodes: 3, expected: nil},
+		{topology: map[string]interface{}{"type": "ring"}, nodes: 3, expected: nil},
+		{topology: map[string]interface{}{"type": "star", "hub": 2}, nodes: 3, expected: nil},
+		{topology: map[string]interface{}{"type": "regular", "degree": 3}, nodes: 4, expected: nil},
+		{topology: map[string]interface{}{"type": "random", "probability": 0.5, "seed": 7}, nodes: 5, expected: nil},
+		{
+			topology: map[string]interface{}{"type": "mesh"},
+			nodes:    3,
+			expected: errors.New("unknown topology type \"mesh\""),
+		},
+		{
+			topology: map[string]interface{}{"type": "star", "hub": 3},
+			nodes:    3,
+			expected: errors.New("the hub 3 does not exist with 3 nodes"),
+		},
+		{
+			topology: map[string]interface{}{"type": "regular", "degree": 3},
+			nodes:    5,
+			expected: errors.New("an odd degree requires an even number of nodes"),
+		},
+		{
+			topology: map[string]interface{}{"type": "random", "probability": 2},
+			nodes:    3,
+			expected: errors.New("probability must be between 0 and 1"),
+		},
+		{
+			topology: "ring",
+			nodes:    3,
+			expected: errors.New("invalid topology: json: cannot unmarshal string into Go value of type topology.Topology"),
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			details := &db.DeploymentDetails{Nodes: tt.nodes, Extras: map[string]interface{}{}}
+			if tt.topology != nil {
+				details.Extras["topology"] = tt.topology
+			}
+			if !reflect.DeepEqual(validateTopology(details), tt.expected) {
+				t.Errorf("returned error of validateTopology does not match expected error")
+			}
+		})
+	}
+}
+
+func Test_validateGenesis(t *testing.T) {
+	encode := func(data string) string { return base64.StdEncoding.EncodeToString([]byte(data)) }
+	validGenesis := `{"genesis_time":"2019-01-01T00:00:00Z","chain_id":"whiteblock","validators":[]}`
+	var test = []struct {
+		blockchain string
+		files      map[string]string
+		expected   error
+	}{
+		{blockchain: "eos", files: map[string]string{}, expected: nil},
+		{blockchain: "tendermint", files: map[string]string{"genesis.override.json": encode(validGenesis)}, expected: nil},
+		{blockchain: "tendermint", files: map[string]string{"genesis.patch.json": encode(`{"chain_id":"test"}`)}, expected: nil},
+		{
+			blockchain: "tendermint",
+			files: map[string]string{
+				"genesis.override.json": encode(validGenesis),
+				"genesis.patch.json":    encode(`{"validators":null}`),
+			},
+			expected: errors.New("invalid genesis file: the document is missing the required field \"validators\""),
+		},
+		{
+			blockchain: "tendermint",
+			files:      map[string]string{"genesis.override.json": encode(`{"chain_id":1}`)},
+			expected:   errors.New("invalid genesis file: the document is missing the required field \"genesis_time\""),
+		},
+		{
+			blockchain: "tendermint",
+			files:      map[string]string{"genesis.patch.json": encode(`{"chain_id"`)},
+			expected:   errors.New("genesis.patch.json is not valid json"),
+		},
+		{
+			blockchain: "eos",
+			files:      map[string]string{"genesis.override.json": encode(validGenesis)},
+			expected:   errors.New("eos does not support overriding its genesis file"),
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			details := &db.DeploymentDetails{Blockchain: tt.blockchain, Files: []map[string]string{tt.files}}
+			if !reflect.DeepEqual(validateGenesis(details), tt.expected) {
+				t.Errorf("returned error of validateGenesis does not match expected error: %v", validateGenesis(details))
+			}
+		})
+	}
+}
+
+func Test_validateEnv(t *testing.T) {
+	var test = []struct {
+		env          map[string]string
+		environments []map[string]string
+		expected     error
+	}{
+		{env: nil, environments: nil, expected: nil},
+		{
+			env:          map[string]string{"NETWORK": "testnet", "VERBOSITY": "3"},
+			environments: []map[string]string{nil, {"NODE_NAME": "node1", "EXTRA": "a 'quoted' value"}},
+			expected:     nil,
+		},
+		{env: map[string]string{"1NODE": "a"}, expected: errors.New("invalid environment variable name \"1NODE\"")},
+		{
+			environments: []map[string]string{{}, {"NODE-NAME": "node1"}},
+			expected:     errors.New("invalid environment variable name \"NODE-NAME\". For node 1"),
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			details := &db.DeploymentDetails{Env: tt.env, Environments: tt.environments}
+			if !reflect.DeepEqual(validateEnv(details), tt.expected) {
+				t.Errorf("returned error of validateEnv does not match expected error: %v", validateEnv(details))
+			}
+		})
+	}
+}
+
+func Test_validateStartup(t *testing.T) {
+	var test = []struct {
+		startup  []db.Startup
+		expected error
+	}{
+		{startup: nil, expected: nil},
+		{
+			startup: []db.Startup{
+				{Entrypoint: "/usr/bin/tini", Command: []string{"--", "/bin/sh"}},
+				{Nodes: []int{0, 1}, WorkDir: "/data"},
+			},
+			expected: nil,
+		},
+		{startup: []db.Startup{{Nodes: []int{0}}}, expected: errors.New(
+			"the startup must override the entrypoint, the command or the workdir. For startup 0")},
+		{startup: []db.Startup{{WorkDir: "data"}}, expected: errors.New(
+			"the workdir \"data\" must be an absolute path. For startup 0")},
+		{startup: []db.Startup{{Command: []string{"a\nb"}}}, expected: errors.New(
+			"invalid command: invalid character \n. For startup 0")},
+		{startup: []db.Startup{{Nodes: []int{3}, WorkDir: "/data"}}, expected: errors.New(
+			"the node 3 does not exist with 3 nodes. For startup 0")},
+		{startup: []db.Startup{{WorkDir: "/data"}, {WorkDir: "/root"}}, expected: errors.New(
+			"only one startup can be without nodes. For startup 1")},
+		{startup: []db.Startup{{Nodes: []int{1}, WorkDir: "/data"}, {Nodes: []int{2, 1}, WorkDir: "/root"}},
+			expected: errors.New("the node 1 is already in startup 0. For startup 1")},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			details := &db.DeploymentDetails{Nodes: 3, Startup: tt.startup}
+			if !reflect.DeepEqual(validateStartup(details), tt.expected) {
+				t.Errorf("returned error of validateStartup does not match expected error: %v", validateStartup(details))
+			}
+		})
+	}
+}
+
+func Test_validateHooks(t *testing.T) {
+	var test = []struct {
+		blockchain string
+		hooks      []db.Hook
+		expected   error
+	}{
+		{blockchain: "eos", hooks: nil, expected: nil},
+		{
+			blockchain: "eos",
+			hooks: []db.Hook{
+				{Stage: db.BeforeInitHook, Script: "apt-get install -y curl"},
+				{Stage: db.AfterStartHook, Script: "ls /", Nodes: []int{0, 2}},
+			},
+			expected: nil,
+		},
+		{blockchain: "tendermint", hooks: []db.Hook{{Stage: db.AfterGenesisHook, Script: "ls /"}}, expected: nil},
+		{
+			blockchain: "eos",
+			hooks:      []db.Hook{{Stage: db.AfterGenesisHook, Script: "ls /"}},
+			expected:   errors.New("eos does not support afterGenesis hooks. For hook 0"),
+		},
+		{
+			blockchain: "eos",
+			hooks:      []db.Hook{{Stage: db.BeforeInitHook, Script: "ls /"}, {Stage: "afterStop", Script: "ls /"}},
+			expected:   errors.New("unknown hook stage \"afterStop\", must be one of beforeInit, afterGenesis, afterStart. For hook 1"),
+		},
+		{
+			blockchain: "eos",
+			hooks:      []db.Hook{{Stage: db.AfterStartHook, Script: " \n"}},
+			expected:   errors.New("the hook script cannot be empty. For hook 0"),
+		},
+		{
+			blockchain: "eos",
+			hooks:      []db.Hook{{Stage: db.AfterStartHook, Script: "ls /", Nodes: []int{3}}},
+			expected:   errors.New("the node 3 does not exist with 3 nodes. For hook 0"),
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			details := &db.DeploymentDetails{Blockchain: tt.blockchain, Nodes: 3, Hooks: tt.hooks}
+			if !reflect.DeepEqual(validateHooks(details), tt.expected) {
+				t.Errorf("returned error of validateHooks does not match expected error: %v", validateHooks(details))
+			}
+		})
+	}
+}
+
 func Test_validate(t *testing.T) {
 	var test = []struct {
 		details  *db.DeploymentDetails
@@ -415,7 +758,7 @@ func Test_validate(t *testing.T) {
 				Logs:         []map[string]string{},
 				Extras:       map[string]interface{}{},
 			},
-			expected: errors.New("strconv.ParseInt: parsing \" \": invalid syntax. For node 0"),
+			expected: errors.New("\" \" is not a size such as 512mb or 2GB. For node 0"),
 		},
 	}
 
@@ -0,0 +1,151 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package manager
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"github.com/whiteblock/genesis/webhook"
+	"sync"
+)
+
+// UpgradeNodes switches the nodes of the testnet to a new image in batches, waiting for each batch to
+// become healthy before moving on to the next, so that a hard fork or a client upgrade can be rehearsed
+// on a live testnet. The upgrade stops at the first batch which fails, leaving the remaining nodes as
+// they were. Once done, the new images are recorded as a new deployment of the testnet.
+func UpgradeNodes(up deploy.Upgrade, testnetID string) error {
+	buildState, err := state.GetBuildStateByID(testnetID)
+	if err != nil {
+		return util.LogError(err)
+	}
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+	defer tn.FinishedBuilding()
+	defer util.Recover(buildState.ReportError) //fail the upgrade on a panic, before it is finished
+
+	batches := up.Batches(len(tn.Nodes))
+	webhook.Emit(webhook.BuildStarted, testnetID, map[string]interface{}{
+		"blockchain": tn.LDD.Blockchain, "image": up.Image, "batches": len(batches)})
+
+	err = deploy.PrepareUpgrade(tn, up.Image)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+	steps := 0
+	for _, batch := range batches {
+		steps += len(batch)
+	}
+	buildState.SetDeploySteps(1)
+	buildState.FinishDeploy()
+	buildState.SetBuildSteps(steps)
+
+	paths := up.PreservedPaths(tn.LDD.Blockchain)
+	for i, batch := range batches {
+		buildState.SetBuildStage(fmt.Sprintf("Upgrading batch %d of %d", i+1, len(batches)))
+		nodes, err := upgradeBatch(tn, batch, up.Image, paths)
+		if err != nil {
+			buildState.ReportError(err)
+			return err
+		}
+		err = deploy.WaitForUpgraded(tn, nodes)
+		if err != nil {
+			buildState.ReportError(err)
+			return err
+		}
+		log.WithFields(log.Fields{"build": testnetID, "nodes": batch, "image": up.Image}).Info("upgraded a batch of nodes")
+	}
+
+	tn.LDD.Images = nodeImages(tn.Nodes)
+	_, err = db.InsertDeployment(testnetID, *tn.LDD)
+	if err != nil {
+		buildState.ReportError(err)
+		return err
+	}
+	return nil
+}
+
+// upgradeBatch upgrades the nodes of the testnet with the given absolute numbers at the same time,
+// giving them once they are running the new image
+func upgradeBatch(tn *testnet.TestNet, batch []int, image string, paths []string) ([]db.Node, error) {
+	indexes := []int{}
+	for _, absNum := range batch {
+		found := false
+		for i := range tn.Nodes {
+			if tn.Nodes[i].AbsoluteNum == absNum {
+				indexes = append(indexes, i)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("node %d does not exist", absNum)
+		}
+	}
+	wg := sync.WaitGroup{}
+	for _, i := range indexes {
+		wg.Add(1)
+		go func(node *db.Node) {
+			defer wg.Done()
+			tn.BuildState.StartNodeStep(node.AbsoluteNum, node.GetNodeName())
+			err := deploy.UpgradeNode(tn, node, image, paths)
+			tn.BuildState.FinishNodeStep(node.GetNodeName(), err)
+			if err != nil {
+				tn.BuildState.ReportError(fmt.Errorf("node %d: %s", node.AbsoluteNum, err.Error()))
+				return
+			}
+			util.LogError(db.UpdateNodeImage(node.ID, image))
+			tn.BuildState.IncrementBuildProgress()
+		}(&tn.Nodes[i])
+	}
+	wg.Wait()
+	err := tn.BuildState.GetError()
+	if err != nil {
+		return nil, err
+	}
+	out := []db.Node{}
+	for _, i := range indexes {
+		out = append(out, tn.Nodes[i])
+	}
+	return out, nil
+}
+
+// nodeImages gives the images of the deployment details of a testnet with the given nodes, a single
+// image when all of the nodes share it
+func nodeImages(nodes []db.Node) []string {
+	out := make([]string, len(nodes))
+	same := true
+	for _, node := range nodes {
+		if node.AbsoluteNum < len(out) {
+			out[node.AbsoluteNum] = node.Image
+		}
+		same = same && node.Image == nodes[0].Image
+	}
+	if same && len(nodes) > 0 {
+		return out[:1]
+	}
+	return out
+}
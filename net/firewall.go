@@ -0,0 +1,136 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/util"
+	"net"
+	"strings"
+)
+
+// FirewallChainPrefix is the prefix of the iptables chains which restrict access to the ports of the nodes
+// of a testnet which are mapped to ports on the servers
+const FirewallChainPrefix = "wb_ports_"
+
+// GetFirewallChain gets the name of the iptables chain which restricts access to the exposed ports of the
+// given testnet
+func GetFirewallChain(testnetID string) string {
+	return FirewallChainPrefix + util.GetNameScope(testnetID)
+}
+
+// makeFirewallRules creates the rules which drop the connections made to the mapped ports of the given nodes
+// through their ports on the server, unless they come from one of the allowed sources. Connections between
+// the containers are not affected, as they do not go through the ports on the server.
+func makeFirewallRules(chain string, nodes []db.Node, allowed []string) []string {
+	out := []string{}
+	for _, node := range nodes {
+		for _, port := range node.Ports {
+			mapping, err := util.ParsePortMapping(port)
+			if err != nil || mapping.Host == 0 {
+				continue
+			}
+			match := fmt.Sprintf("%s -d %s -p %s --dport %d -m conntrack --ctstate DNAT --ctdir ORIGINAL --ctorigdstport %d",
+				chain, node.IP, mapping.Protocol, mapping.Container, mapping.Host)
+			for _, source := range allowed {
+				out = append(out, fmt.Sprintf("%s -s %s -j RETURN", match, source))
+			}
+			out = append(out, match+" -j DROP")
+		}
+	}
+	return out
+}
+
+// getAllowedSources gets the addresses which can reach the exposed ports of the nodes on the server. They are
+// given by exposedPortsAllow, otherwise it is only genesis, which is found from the ssh connection to the server.
+// Genesis does not need to be allowed if it is running on the server.
+func getAllowedSources(client ssh.Client) ([]string, error) {
+	out := []string{}
+	for _, source := range strings.Split(conf.ExposedPortsAllow, ",") {
+		source = strings.TrimSpace(source)
+		if len(source) == 0 {
+			continue
+		}
+		_, _, err := net.ParseCIDR(source)
+		if err != nil && net.ParseIP(source) == nil {
+			return nil, fmt.Errorf("invalid address \"%s\" in exposedPortsAllow", source)
+		}
+		out = append(out, source)
+	}
+	if len(out) > 0 {
+		return out, nil
+	}
+	res, err := client.Run("echo $SSH_CLIENT")
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	fields := strings.Fields(res)
+	if len(fields) > 0 && net.ParseIP(fields[0]) != nil {
+		out = append(out, fields[0])
+	}
+	return out, nil
+}
+
+// RestrictExposedPorts replaces the firewall chain of the testnet on the server with one which only allows
+// the allowed sources to reach the mapped ports of the given nodes, and jumps to it from the top of FORWARD.
+// Does nothing if restrictExposedPorts is not set, or the nodes do not have any mapped ports.
+func RestrictExposedPorts(client ssh.Client, testnetID string, nodes []db.Node) error {
+	chain := GetFirewallChain(testnetID)
+	if !conf.RestrictExposedPorts || client.Runtime().Rootless || len(makeFirewallRules(chain, nodes, nil)) == 0 {
+		return nil
+	}
+	allowed, err := getAllowedSources(client)
+	if err != nil {
+		return util.LogError(err)
+	}
+	rules := makeFirewallRules(chain, nodes, allowed)
+	_, err = client.Run(fmt.Sprintf("(sudo iptables -N %s 2>/dev/null || sudo iptables -F %s) && "+
+		"(sudo iptables -C FORWARD -j %s 2>/dev/null || sudo iptables -I FORWARD -j %s)", chain, chain, chain, chain))
+	if err != nil {
+		return util.LogError(err)
+	}
+	for i := 0; i < len(rules); i += maxRulesPerCommand {
+		end := i + maxRulesPerCommand
+		if end > len(rules) {
+			end = len(rules)
+		}
+		cmds := []string{}
+		for _, rule := range rules[i:end] {
+			cmds = append(cmds, "sudo iptables -A "+rule)
+		}
+		_, err = client.Run(strings.Join(cmds, " && "))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	return nil
+}
+
+// RemoveAllExposedPortRules removes the firewall chains of every testnet from the server
+func RemoveAllExposedPortRules(client ssh.Client) error {
+	if client.Runtime().Rootless {
+		return nil
+	}
+	_, err := client.Run(fmt.Sprintf("for chain in $(sudo iptables --list-rules | grep '^-N %s' | awk '{print $2}'); do "+
+		"while sudo iptables -D FORWARD -j $chain 2>/dev/null; do :; done; "+
+		"sudo iptables -F $chain && sudo iptables -X $chain; done; true", FirewallChainPrefix))
+	return util.LogError(err)
+}
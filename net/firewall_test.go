@@ -0,0 +1,119 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh/mocks"
+	"github.com/whiteblock/genesis/util"
+)
+
+func TestMakeFirewallRules(t *testing.T) {
+	nodes := []db.Node{
+		{AbsoluteNum: 0, IP: "10.1.0.2", Ports: []string{"30000:8545/tcp", "30001:30303/udp"}},
+		{AbsoluteNum: 1, IP: "10.1.0.6"},
+	}
+	expected := []string{
+		"wb_ports_4ac9d3b2 -d 10.1.0.2 -p tcp --dport 8545 -m conntrack --ctstate DNAT --ctdir ORIGINAL " +
+			"--ctorigdstport 30000 -s 192.168.1.0/24 -j RETURN",
+		"wb_ports_4ac9d3b2 -d 10.1.0.2 -p tcp --dport 8545 -m conntrack --ctstate DNAT --ctdir ORIGINAL " +
+			"--ctorigdstport 30000 -j DROP",
+		"wb_ports_4ac9d3b2 -d 10.1.0.2 -p udp --dport 30303 -m conntrack --ctstate DNAT --ctdir ORIGINAL " +
+			"--ctorigdstport 30001 -s 192.168.1.0/24 -j RETURN",
+		"wb_ports_4ac9d3b2 -d 10.1.0.2 -p udp --dport 30303 -m conntrack --ctstate DNAT --ctdir ORIGINAL " +
+			"--ctorigdstport 30001 -j DROP",
+	}
+	out := makeFirewallRules(GetFirewallChain("4ac9d3b2-1a2b-4c5d-9e8f-0123456789ab"), nodes, []string{"192.168.1.0/24"})
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %v, got %v", expected, out)
+	}
+}
+
+func TestGetAllowedSources(t *testing.T) {
+	var test = []struct {
+		allow     string
+		sshClient string
+		expected  []string
+		err       bool
+	}{
+		{allow: "10.0.0.0/8, 192.168.1.5", expected: []string{"10.0.0.0/8", "192.168.1.5"}},
+		{allow: "", sshClient: "172.16.0.4 51234 22\n", expected: []string{"172.16.0.4"}},
+		{allow: "", sshClient: "\n", expected: []string{}},
+		{allow: "10.0.0.0/8; true", err: true},
+	}
+
+	defer func() { conf.ExposedPortsAllow = "" }()
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			conf.ExposedPortsAllow = tt.allow
+			client := mocks.NewMockClient(ctrl)
+			client.EXPECT().Run("echo $SSH_CLIENT").Return(tt.sshClient, nil).AnyTimes()
+
+			out, err := getAllowedSources(client)
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestRestrictExposedPorts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nodes := []db.Node{{AbsoluteNum: 0, IP: "10.1.0.2", Ports: []string{"30000:8545/tcp"}}}
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Runtime().Return(util.Runtime{Name: util.DockerRuntime, CLI: "docker"}).AnyTimes()
+	gomock.InOrder(
+		client.EXPECT().Run("echo $SSH_CLIENT").Return("172.16.0.4 51234 22", nil),
+		client.EXPECT().Run("(sudo iptables -N wb_ports_4ac9d3b2 2>/dev/null || sudo iptables -F wb_ports_4ac9d3b2) && "+
+			"(sudo iptables -C FORWARD -j wb_ports_4ac9d3b2 2>/dev/null || sudo iptables -I FORWARD -j wb_ports_4ac9d3b2)").Return("", nil),
+		client.EXPECT().Run("sudo iptables -A wb_ports_4ac9d3b2 -d 10.1.0.2 -p tcp --dport 8545 -m conntrack --ctstate DNAT "+
+			"--ctdir ORIGINAL --ctorigdstport 30000 -s 172.16.0.4 -j RETURN && "+
+			"sudo iptables -A wb_ports_4ac9d3b2 -d 10.1.0.2 -p tcp --dport 8545 -m conntrack --ctstate DNAT "+
+			"--ctdir ORIGINAL --ctorigdstport 30000 -j DROP").Return("", nil),
+	)
+
+	err := RestrictExposedPorts(client, "4ac9d3b2-1a2b-4c5d-9e8f-0123456789ab", nodes)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = RestrictExposedPorts(client, "4ac9d3b2-1a2b-4c5d-9e8f-0123456789ab", []db.Node{{IP: "10.1.0.6"}})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/kubernetes"
+	"github.com/whiteblock/genesis/util"
+	"strings"
+)
+
+// ApplyAllOnKubernetes applies all of the given netconfs to the pods of the given nodes, continuing past
+// the nodes which fail
+func ApplyAllOnKubernetes(cfg kubernetes.Config, netconfs []Netconf, nodes []db.Node) *Report {
+	report := NewReport()
+	for _, netconf := range netconfs {
+		rule := strings.TrimSpace(NetemOptions(netconf))
+		node, err := db.GetNodeByLocalID(nodes, netconf.Node)
+		if err != nil {
+			report.add(netconf.Node, 0, rule, err)
+			continue
+		}
+		report.addNode(node, rule, applyOnKubernetes(cfg, netconf, node))
+	}
+	return report
+}
+
+// ApplyToAllOnKubernetes applies the given netconf to the pods of all of the given nodes, continuing past
+// the nodes which fail
+func ApplyToAllOnKubernetes(cfg kubernetes.Config, netconf Netconf, nodes []db.Node) *Report {
+	report := NewReport()
+	rule := strings.TrimSpace(NetemOptions(netconf))
+	for _, node := range nodes {
+		report.addNode(node, rule, applyOnKubernetes(cfg, netconf, node))
+	}
+	return report
+}
+
+// applyOnKubernetes applies the netconf to the pod of the given node. The netem options are given to
+// the pod as they are, so the conditions cannot be restricted to some of its traffic.
+func applyOnKubernetes(cfg kubernetes.Config, netconf Netconf, node db.Node) error {
+	if netconf.Filtered() {
+		return fmt.Errorf("restricting the network conditions to a port or protocol is not supported on kubernetes")
+	}
+	return util.LogError(kubernetes.ApplyNetem(cfg, node.GetNodeName(), strings.TrimSpace(NetemOptions(netconf))))
+}
+
+// RemoveAllOnKubernetes removes network conditions from the pods of the given nodes, continuing past
+// the nodes which fail
+func RemoveAllOnKubernetes(cfg kubernetes.Config, nodes []db.Node) *Report {
+	report := NewReport()
+	for _, node := range nodes {
+		report.addNode(node, "", util.LogError(kubernetes.RemoveNetem(cfg, node.GetNodeName())))
+	}
+	return report
+}
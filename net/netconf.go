@@ -57,6 +57,65 @@ type Netconf struct {
 	Duplication float64 `json:"duplicate"`
 	Corrupt     float64 `json:"corrupt"`
 	Reorder     float64 `json:"reorder"`
+	// Port restricts the conditions to the traffic to this destination port, if given
+	Port int `json:"port,omitempty"`
+	// Protocol restricts the conditions to the traffic of this protocol, one of tcp, udp or icmp, if given
+	Protocol string `json:"protocol,omitempty"`
+	// From restricts the conditions to the traffic from the node with this number, if given, so that the
+	// link between the two nodes is only impaired in one direction
+	From *int `json:"from,omitempty"`
+
+	// fromIP is the ip address of the node given by From, once it has been resolved
+	fromIP string
+	// invalidDelay is why the delay given to the netconf could not be read, if it could not
+	invalidDelay string
+}
+
+// protocols are the ip protocol numbers of the protocols which the conditions may be restricted to
+var protocols = map[string]int{
+	"icmp": 1,
+	"tcp":  6,
+	"udp":  17,
+}
+
+// Filtered checks whether the conditions of the netconf only apply to some of the traffic of the node
+func (netconf Netconf) Filtered() bool {
+	return netconf.Port > 0 || len(netconf.Protocol) > 0 || netconf.From != nil
+}
+
+// resolveFrom finds the ip address of the node the conditions are restricted to the traffic from,
+// among the given nodes
+func (netconf *Netconf) resolveFrom(nodes []db.Node) error {
+	if netconf.From == nil {
+		return nil
+	}
+	node, err := db.GetNodeByLocalID(nodes, *netconf.From)
+	if err != nil {
+		return util.LogError(err)
+	}
+	netconf.fromIP = node.IP
+	return nil
+}
+
+// filterCommand creates the tc filter which sends the traffic of the node to the netem qdisc.
+// Without filters this is all of the marked traffic, otherwise the marked traffic is further matched
+// on its protocol and destination port.
+func filterCommand(netconf Netconf, mark int) string {
+	cmd := fmt.Sprintf("sudo -n tc filter add dev %s%d parent 1:0 protocol ip pref 55", conf.BridgePrefix, netconf.Node)
+	if !netconf.Filtered() {
+		return cmd + fmt.Sprintf(" handle %d fw flowid 2:1", mark)
+	}
+	cmd += fmt.Sprintf(" u32 match mark %d 0xffffffff", mark)
+	if len(netconf.Protocol) > 0 {
+		cmd += fmt.Sprintf(" match ip protocol %d 0xff", protocols[netconf.Protocol])
+	}
+	if len(netconf.fromIP) > 0 {
+		cmd += fmt.Sprintf(" match ip src %s/32", netconf.fromIP)
+	}
+	if netconf.Port > 0 {
+		cmd += fmt.Sprintf(" match ip dport %d 0xffff", netconf.Port)
+	}
+	return cmd + " flowid 2:1"
 }
 
 // CreateCommands generates the commands needed to obtain the desired
@@ -67,45 +126,74 @@ func CreateCommands(netconf Netconf, serverID int) []string {
 		fmt.Sprintf("sudo -n tc qdisc del dev %s%d root", conf.BridgePrefix, netconf.Node),
 		fmt.Sprintf("sudo -n tc qdisc add dev %s%d root handle 1: prio", conf.BridgePrefix, netconf.Node),
 		fmt.Sprintf("sudo -n tc qdisc add dev %s%d parent 1:1 handle 2: netem", conf.BridgePrefix, netconf.Node), //unf
-		fmt.Sprintf("sudo -n tc filter add dev %s%d parent 1:0 protocol ip pref 55 handle %d fw flowid 2:1",
-			conf.BridgePrefix, netconf.Node, offset),
+		filterCommand(netconf, offset),
 		fmt.Sprintf("sudo -n iptables -t mangle -A PREROUTING  ! -d %s -j MARK --set-mark %d",
 			util.GetGateway(serverID, netconf.Node), offset),
 	}
 
+	out[2] += NetemOptions(netconf)
+	return out
+}
+
+// NetemOptions gets the netem options, each preceded by a space, which give the network
+// conditions of the given netconf
+func NetemOptions(netconf Netconf) string {
+	out := ""
 	if netconf.Limit > 0 {
-		out[2] += fmt.Sprintf(" limit %d", netconf.Limit)
+		out += fmt.Sprintf(" limit %d", netconf.Limit)
 	}
 
 	if netconf.Loss > 0 {
-		out[2] += fmt.Sprintf(" loss %.4f", netconf.Loss)
+		out += fmt.Sprintf(" loss %.4f", netconf.Loss)
 	}
 
 	if netconf.Delay > 0 {
-		out[2] += fmt.Sprintf(" delay %dus", netconf.Delay)
+		out += fmt.Sprintf(" delay %dus", netconf.Delay)
 	}
 
 	if len(netconf.Rate) > 0 {
-		out[2] += fmt.Sprintf(" rate %s", netconf.Rate)
+		out += fmt.Sprintf(" rate %s", netconf.Rate)
 	}
 
 	if netconf.Duplication > 0 {
-		out[2] += fmt.Sprintf(" duplicate %.4f", netconf.Duplication)
+		out += fmt.Sprintf(" duplicate %.4f", netconf.Duplication)
 	}
 
 	if netconf.Corrupt > 0 {
-		out[2] += fmt.Sprintf(" corrupt %.4f", netconf.Duplication)
+		out += fmt.Sprintf(" corrupt %.4f", netconf.Duplication)
 	}
 
 	if netconf.Reorder > 0 {
-		out[2] += fmt.Sprintf(" reorder %.4f", netconf.Reorder)
+		out += fmt.Sprintf(" reorder %.4f", netconf.Reorder)
 	}
-
 	return out
 }
 
+// checkHostNetwork ensures that the networks of the nodes on the server of the given client
+// can be altered from the host, which is not the case with a rootless runtime
+func checkHostNetwork(client ssh.Client) error {
+	if client.Runtime().Rootless {
+		return fmt.Errorf("the networks of the nodes cannot be altered with the %s runtime", client.Runtime().Name)
+	}
+	return nil
+}
+
 //Apply applies the given network config.
 func Apply(client ssh.Client, netconf Netconf, serverID int) error {
+	err := netconf.Validate()
+	if err != nil {
+		return util.LogError(err)
+	}
+	if netconf.From != nil && len(netconf.fromIP) == 0 {
+		return fmt.Errorf("the node %d, which the traffic is from, has not been found", *netconf.From)
+	}
+	if netconf.From != nil && *netconf.From == netconf.Node {
+		return fmt.Errorf("the conditions of node %d cannot be restricted to the traffic from itself", netconf.Node)
+	}
+	err = checkHostNetwork(client)
+	if err != nil {
+		return util.LogError(err)
+	}
 	cmds := CreateCommands(netconf, serverID)
 	for i, cmd := range cmds {
 		_, err := client.Run(cmd)
@@ -120,63 +208,80 @@ func Apply(client ssh.Client, netconf Netconf, serverID int) error {
 	return nil
 }
 
-//ApplyAll applies all of the given netconfs
-func ApplyAll(netconfs []Netconf, nodes []db.Node) error {
+// ApplyAll applies all of the given netconfs, continuing past the nodes which fail. The report gives
+// the outcome for each of the netconfs.
+func ApplyAll(netconfs []Netconf, nodes []db.Node) *Report {
+	report := NewReport()
 	for _, netconf := range netconfs {
+		rule := strings.TrimSpace(NetemOptions(netconf))
 		node, err := db.GetNodeByLocalID(nodes, netconf.Node)
 		if err != nil {
-			return util.LogError(err)
-		}
-		client, err := status.GetClient(node.Server)
-		if err != nil {
-			return util.LogError(err)
+			report.add(netconf.Node, 0, rule, err)
+			continue
 		}
-		err = Apply(client, netconf, node.Server)
-		if err != nil {
-			return util.LogError(err)
+		err = netconf.resolveFrom(nodes)
+		if err == nil {
+			err = applyToNode(netconf, node)
 		}
+		report.addNode(node, rule, err)
 	}
-	return nil
+	return report
 }
 
-//ApplyToAll applies the given netconf to `nodes` nodes in the network on the given server
-func ApplyToAll(netconf Netconf, nodes []db.Node) error {
+// ApplyToAll applies the given netconf to all of the given nodes, continuing past the nodes which fail.
+// When the netconf is restricted to the traffic from a node, that node is left as it is.
+func ApplyToAll(netconf Netconf, nodes []db.Node) *Report {
+	report := NewReport()
+	fromErr := netconf.resolveFrom(nodes)
 	for _, node := range nodes {
+		if netconf.From != nil && *netconf.From == node.LocalID {
+			continue // the traffic of a node to itself is never impaired
+		}
 		netconf.Node = node.LocalID
-		cmds := CreateCommands(netconf, node.Server)
-		for i, cmd := range cmds {
-			client, err := status.GetClient(node.Server)
-			if err != nil {
-				log.WithFields(log.Fields{"i": i, "cmd": cmd, "error": err}).Error("error running netem command")
-				return util.LogError(err)
-			}
-			_, err = client.Run(cmd)
-			if i == 0 {
-				//Don't check the success of the first command which clears
-				continue
-			}
-			if err != nil {
-				return util.LogError(err)
-			}
+		err := fromErr
+		if err == nil {
+			err = applyToNode(netconf, node)
 		}
+		report.addNode(node, strings.TrimSpace(NetemOptions(netconf)), err)
 	}
-	return nil
+	return report
+}
+
+// applyToNode applies the netconf to the given node, on its server
+func applyToNode(netconf Netconf, node db.Node) error {
+	client, err := status.GetClient(node.Server)
+	if err != nil {
+		log.WithFields(log.Fields{"node": node.AbsoluteNum, "error": err}).Error("error running netem command")
+		return util.LogError(err)
+	}
+	return Apply(client, netconf, node.Server)
 }
 
-//RemoveAll removes network conditions from the given nodes
-func RemoveAll(nodes []db.Node) error {
+// RemoveAll removes network conditions from the given nodes, continuing past the nodes which fail.
+// A node without any network conditions is not a failure.
+func RemoveAll(nodes []db.Node) *Report {
+	report := NewReport()
 	for _, node := range nodes {
 		client, err := status.GetClient(node.Server)
-		if err != nil {
-			return util.LogError(err)
-		}
-		_, err = client.Run(
-			fmt.Sprintf("sudo -n tc qdisc del dev %s%d root", conf.BridgePrefix, node.LocalID))
-		if err != nil {
-			log.Error(err)
+		if err == nil {
+			err = Remove(client, node.LocalID)
 		}
+		report.addNode(node, "", err)
 	}
-	return nil
+	return report
+}
+
+// Remove removes the network conditions from the node with the given local id on the server
+func Remove(client ssh.Client, node int) error {
+	err := checkHostNetwork(client)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = client.Run(fmt.Sprintf("sudo -n tc qdisc del dev %s%d root", conf.BridgePrefix, node))
+	if err != nil && strings.Contains(err.Error(), "handle of zero") {
+		return nil // there was nothing to remove
+	}
+	return util.LogError(err)
 }
 
 //RemoveAllOnServer removes network conditions from the given number of nodes on the given client
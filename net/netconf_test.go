@@ -26,6 +26,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/whiteblock/genesis/ssh/mocks"
+	"github.com/whiteblock/genesis/util"
 )
 
 func TestCreateCommands(t *testing.T) {
@@ -66,6 +67,90 @@ func TestCreateCommands(t *testing.T) {
 	}
 }
 
+func TestCreateCommands_Filtered(t *testing.T) {
+	from := 1
+	var test = []struct {
+		netconf  Netconf
+		expected string
+	}{
+		{
+			netconf:  Netconf{Node: 2, Protocol: "udp"},
+			expected: "sudo -n tc filter add dev wb_bridge2 parent 1:0 protocol ip pref 55 u32 match mark 6 0xffffffff match ip protocol 17 0xff flowid 2:1",
+		},
+		{
+			netconf:  Netconf{Node: 2, Port: 8545},
+			expected: "sudo -n tc filter add dev wb_bridge2 parent 1:0 protocol ip pref 55 u32 match mark 6 0xffffffff match ip dport 8545 0xffff flowid 2:1",
+		},
+		{
+			netconf:  Netconf{Node: 2, From: &from, fromIP: "10.1.0.6"},
+			expected: "sudo -n tc filter add dev wb_bridge2 parent 1:0 protocol ip pref 55 u32 match mark 6 0xffffffff match ip src 10.1.0.6/32 flowid 2:1",
+		},
+		{
+			netconf: Netconf{Node: 2, Port: 30303, Protocol: "tcp"},
+			expected: "sudo -n tc filter add dev wb_bridge2 parent 1:0 protocol ip pref 55 u32 match mark 6 0xffffffff " +
+				"match ip protocol 6 0xff match ip dport 30303 0xffff flowid 2:1",
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if got := CreateCommands(tt.netconf, 1)[3]; got != tt.expected {
+				t.Errorf("CreateCommands gave the filter \"%s\", expected \"%s\"", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNetconf_Validate(t *testing.T) {
+	var test = []struct {
+		netconf Netconf
+		valid   bool
+	}{
+		{netconf: Netconf{}, valid: true},
+		{netconf: Netconf{Port: 30303, Protocol: "tcp"}, valid: true},
+		{netconf: Netconf{Protocol: "icmp"}, valid: true},
+		{netconf: Netconf{Port: 70000}, valid: false},
+		{netconf: Netconf{Protocol: "sctp"}, valid: false},
+		{netconf: Netconf{Port: 80, Protocol: "icmp"}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if err := tt.netconf.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate returned %v, expected valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestNetemOptions(t *testing.T) {
+	var test = []struct {
+		netconf  Netconf
+		expected string
+	}{
+		{
+			netconf:  Netconf{},
+			expected: "",
+		},
+		{
+			netconf:  Netconf{Node: 1, Delay: 100, Rate: "1mbit"},
+			expected: " delay 100us rate 1mbit",
+		},
+		{
+			netconf:  Netconf{Limit: 10, Loss: 1.5},
+			expected: " limit 10 loss 1.5000",
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if got := NetemOptions(tt.netconf); got != tt.expected {
+				t.Errorf("NetemOptions returned \"%s\", expected \"%s\"", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestApply(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -73,6 +158,7 @@ func TestApply(t *testing.T) {
 	netconf := Netconf{Node: 3}
 	serverID := 1
 	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Runtime().Return(util.Runtime{Name: util.DockerRuntime, CLI: "docker"})
 
 	expectations := []string{
 		"sudo -n tc qdisc del dev wb_bridge3 root",
@@ -95,6 +181,46 @@ func TestApply(t *testing.T) {
 	Apply(client, netconf, serverID)
 }
 
+func TestApply_Rootless(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Runtime().Return(util.Runtime{Name: util.RootlessPodmanRuntime, CLI: "podman", Rootless: true}).Times(2)
+
+	err := Apply(client, Netconf{Node: 3}, 1)
+	if err == nil {
+		t.Error("expected an error when applying netem with a rootless runtime")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	var test = []struct {
+		err      error
+		expected bool
+	}{
+		{err: nil, expected: false},
+		{err: fmt.Errorf("RTNETLINK answers: No such file or directory\nCannot delete qdisc with handle of zero."), expected: false},
+		{err: fmt.Errorf("Cannot find device \"wb_bridge3\""), expected: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			client := mocks.NewMockClient(ctrl)
+			client.EXPECT().Runtime().Return(util.Runtime{Name: util.DockerRuntime, CLI: "docker"})
+			client.EXPECT().Run("sudo -n tc qdisc del dev wb_bridge3 root").Return("", tt.err)
+
+			err := Remove(client, 3)
+			if (err != nil) != tt.expected {
+				t.Errorf("Remove returned %v, expected an error: %v", err, tt.expected)
+			}
+		})
+	}
+}
+
 func TestRemoveAllOnServer(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
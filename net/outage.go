@@ -61,38 +61,45 @@ func RemoveAllOutages(client ssh.Client) error {
 	return nil
 }
 
-func makeOutageCommands(node1 db.Node, node2 db.Node) []string {
-	return []string{
-		fmt.Sprintf("FORWARD -i %s%d -d %s -j DROP", conf.BridgePrefix, node1.AbsoluteNum, node2.IP),
-		fmt.Sprintf("FORWARD -i %s%d -d %s -j DROP", conf.BridgePrefix, node2.AbsoluteNum, node1.IP),
-	}
+// makeOutageCommand creates the rule which drops the traffic from one node to another. As the rule
+// is on the bridge of the node the traffic is from, it belongs on the server of that node.
+func makeOutageCommand(from db.Node, to db.Node) string {
+	return fmt.Sprintf("FORWARD -i %s%d -d %s -j DROP", conf.BridgePrefix, from.AbsoluteNum, to.IP)
 }
 
-func mkrmOutage(node1 db.Node, node2 db.Node, create bool) error {
+// oneWayOutage adds or removes the rule which drops the traffic from one node to another, with the
+// client of the server of the node the traffic is from
+func oneWayOutage(client ssh.Client, from db.Node, to db.Node, create bool) error {
 	flag := "-I"
 	if !create {
 		flag = "-D"
 	}
-	cmds := makeOutageCommands(node1, node2)
-
-	client, err := status.GetClient(node1.Server)
+	err := checkHostNetwork(client)
 	if err != nil {
 		return util.LogError(err)
 	}
-	_, err = client.Run(fmt.Sprintf("sudo iptables %s %s", flag, cmds[0]))
+	_, err = client.Run(fmt.Sprintf("sudo iptables %s %s", flag, makeOutageCommand(from, to)))
+	return util.LogError(err)
+}
+
+func mkrmOneWayOutage(from db.Node, to db.Node, create bool) error {
+	client, err := status.GetClient(from.Server)
 	if err != nil {
 		return util.LogError(err)
 	}
-	client, err = status.GetClient(node2.Server)
+	err = oneWayOutage(client, from, to, create)
 	if err != nil {
-		return util.LogError(err)
+		return err
 	}
-	_, err = client.Run(fmt.Sprintf("sudo iptables %s %s", flag, cmds[1]))
+	return recordOutage(from.TestNetID, Connection{From: from.AbsoluteNum, To: to.AbsoluteNum}, create)
+}
+
+func mkrmOutage(node1 db.Node, node2 db.Node, create bool) error {
+	err := mkrmOneWayOutage(node1, node2, create)
 	if err != nil {
-		return util.LogError(err)
+		return err
 	}
-
-	return nil
+	return mkrmOneWayOutage(node2, node1, create)
 }
 
 //MakeOutage removes the ability for the given nodes to connect
@@ -105,6 +112,18 @@ func RemoveOutage(node1 db.Node, node2 db.Node) error {
 	return mkrmOutage(node1, node2, false)
 }
 
+// MakeOneWayOutage drops the traffic from one node to another, while the traffic in the
+// other direction is left intact
+func MakeOneWayOutage(from db.Node, to db.Node) error {
+	return mkrmOneWayOutage(from, to, true)
+}
+
+// RemoveOneWayOutage allows the traffic from one node to another again, leaving the traffic in
+// the other direction as it is
+func RemoveOneWayOutage(from db.Node, to db.Node) error {
+	return mkrmOneWayOutage(from, to, false)
+}
+
 //CreatePartitionOutage causes the two sides to be unable to communicate with one and the other
 func CreatePartitionOutage(side1 []db.Node, side2 []db.Node) { //Doesn't report errors yet
 	wg := sync.WaitGroup{}
@@ -123,9 +142,10 @@ func CreatePartitionOutage(side1 []db.Node, side2 []db.Node) { //Doesn't report
 	wg.Wait()
 }
 
-//GetCutConnections fetches the cut connections on a server
+// GetCutConnections fetches the cut connections on a server, whose nodes have addresses in the ip
+// blocks of the given subnet ids
 //TODO: Naive Implementation, does not yet take multiple servers into account
-func GetCutConnections(client ssh.Client) ([]Connection, error) {
+func GetCutConnections(client ssh.Client, subnets []int) ([]Connection, error) {
 	res, err := client.Run("sudo iptables --list-rules | grep wb_bridge | grep DROP | grep FORWARD | awk '{print $4,$6}' | sed -e 's/\\/32//g' || true")
 	if err != nil {
 		return nil, util.LogError(err)
@@ -145,7 +165,11 @@ func GetCutConnections(client ssh.Client) ([]Connection, error) {
 		if len(cutPair) != 2 {
 			return nil, fmt.Errorf("unexpected result \"%s\" for cut pair", cut)
 		}
-		_, toNode, _ := util.GetInfoFromIP(cutPair[0])
+		toNode, ok := util.GetNodeFromIP(subnets, cutPair[0])
+		if !ok {
+			log.WithFields(log.Fields{"to": cutPair[0]}).Debug("skipping a disconnection from a node of another server")
+			continue
+		}
 
 		if len(cutPair[1]) <= len(conf.BridgePrefix) {
 			return nil, fmt.Errorf("unexpected source interface, found \"%s\"", cutPair[1])
@@ -163,13 +187,17 @@ func GetCutConnections(client ssh.Client) ([]Connection, error) {
 
 //CalculatePartitions calculates the current partitions in the network
 func CalculatePartitions(nodes []db.Node) ([][]int, error) {
-	clients, err := status.GetClientsFromNodes(nodes)
+	servers, err := db.GetServers(db.GetUniqueServerIDs(nodes))
 	if err != nil {
 		return nil, util.LogError(err)
 	}
 	cutConnections := []Connection{}
-	for _, client := range clients {
-		conns, err := GetCutConnections(client)
+	for _, server := range servers {
+		client, err := status.GetClient(server.ID)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		conns, err := GetCutConnections(client, server.Subnets())
 		if err != nil {
 			return nil, util.LogError(err)
 		}
@@ -19,10 +19,13 @@
 package netconf
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/ssh/mocks"
+	"github.com/whiteblock/genesis/util"
 )
 
 func TestRemoveAllOutages(t *testing.T) {
@@ -35,3 +38,29 @@ func TestRemoveAllOutages(t *testing.T) {
 
 	RemoveAllOutages(client)
 }
+
+func TestOneWayOutage(t *testing.T) {
+	var test = []struct {
+		create   bool
+		expected string
+	}{
+		{create: true, expected: "sudo iptables -I FORWARD -i wb_bridge1 -d 10.1.0.14 -j DROP"},
+		{create: false, expected: "sudo iptables -D FORWARD -i wb_bridge1 -d 10.1.0.14 -j DROP"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			client := mocks.NewMockClient(ctrl)
+			client.EXPECT().Runtime().Return(util.Runtime{Name: util.DockerRuntime, CLI: "docker"})
+			client.EXPECT().Run(tt.expected)
+
+			err := oneWayOutage(client, db.Node{AbsoluteNum: 1, IP: "10.1.0.6"}, db.Node{AbsoluteNum: 2, IP: "10.1.0.14"}, tt.create)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
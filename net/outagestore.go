@@ -0,0 +1,210 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// outagesMux guards the read, modify and write of the recorded outages
+var outagesMux = sync.Mutex{}
+
+var outageRuleRegex = regexp.MustCompile(`-A FORWARD -i ` + regexp.QuoteMeta(conf.BridgePrefix) + `([0-9]+) -d ([0-9.]+)(?:/32)? -j DROP`)
+
+// Outage is a link between two nodes of a testnet which is down, in one direction
+type Outage struct {
+	// From is the absolute number of the node whose traffic is dropped
+	From int `json:"from"`
+	// To is the absolute number of the node the dropped traffic was sent to
+	To int `json:"to"`
+	// Recorded is whether the outage was made by genesis and recorded
+	Recorded bool `json:"recorded"`
+	// Installed is whether the rule which drops the traffic is in place on the server
+	Installed bool `json:"installed"`
+}
+
+// OutageState is the state of the links between the nodes of a testnet
+type OutageState struct {
+	// Outages are the links which are down, either according to the records or to the servers
+	Outages []Outage `json:"outages"`
+	// Partitions are the groups of nodes which cannot reach each other, given the installed outages.
+	// There is a single partition with every node when the network is whole.
+	Partitions [][]int `json:"partitions"`
+}
+
+func outagesKey(testnetID string) string {
+	return "outages_" + testnetID
+}
+
+// getRecordedOutages gets the outages which have been made in the given testnet, as connections
+// between the absolute numbers of the nodes
+func getRecordedOutages(testnetID string) []Connection {
+	out := []Connection{}
+	db.GetMetaP(outagesKey(testnetID), &out) //An error means no outages have been made
+	return out
+}
+
+// recordOutage records that the given outage has been made or removed
+func recordOutage(testnetID string, conn Connection, create bool) error {
+	outagesMux.Lock()
+	defer outagesMux.Unlock()
+	conns := []Connection{}
+	for _, recorded := range getRecordedOutages(testnetID) {
+		if recorded != conn {
+			conns = append(conns, recorded)
+		}
+	}
+	if create {
+		conns = append(conns, conn)
+	}
+	return util.LogError(db.SetMeta(outagesKey(testnetID), conns))
+}
+
+// ClearOutages forgets all of the outages recorded for the given testnet
+func ClearOutages(testnetID string) error {
+	outagesMux.Lock()
+	defer outagesMux.Unlock()
+	return db.DeleteMeta(outagesKey(testnetID))
+}
+
+// parseInstalledOutages finds the outages between the given nodes in the listing of the iptables rules
+// of a server
+func parseInstalledOutages(listing string, nodes []db.Node) []Connection {
+	byIP := map[string]int{}
+	byNum := map[int]bool{}
+	for _, node := range nodes {
+		byIP[node.IP] = node.AbsoluteNum
+		byNum[node.AbsoluteNum] = true
+	}
+	out := []Connection{}
+	for _, match := range outageRuleRegex.FindAllStringSubmatch(listing, -1) {
+		from, err := strconv.Atoi(match[1])
+		if err != nil || !byNum[from] {
+			continue
+		}
+		to, ok := byIP[match[2]]
+		if !ok {
+			continue
+		}
+		out = append(out, Connection{From: from, To: to})
+	}
+	return out
+}
+
+func getInstalledOutages(client ssh.Client, nodes []db.Node) ([]Connection, error) {
+	res, err := client.Run("sudo iptables --list-rules | grep wb_bridge | grep DROP | grep FORWARD || true")
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return parseInstalledOutages(res, nodes), nil
+}
+
+// reconcileOutages merges the recorded outages with those installed on the servers
+func reconcileOutages(recorded []Connection, installed []Connection, nodes []db.Node) OutageState {
+	outages := map[Connection]*Outage{}
+	for _, conn := range recorded {
+		outages[conn] = &Outage{From: conn.From, To: conn.To, Recorded: true}
+	}
+	for _, conn := range installed {
+		if _, ok := outages[conn]; !ok {
+			outages[conn] = &Outage{From: conn.From, To: conn.To}
+		}
+		outages[conn].Installed = true
+	}
+	out := OutageState{Outages: []Outage{}}
+	for _, outage := range outages {
+		out.Outages = append(out.Outages, *outage)
+	}
+	sort.Slice(out.Outages, func(i, j int) bool {
+		if out.Outages[i].From == out.Outages[j].From {
+			return out.Outages[i].To < out.Outages[j].To
+		}
+		return out.Outages[i].From < out.Outages[j].From
+	})
+	out.Partitions = calculatePartitions(installed, nodes)
+	return out
+}
+
+// calculatePartitions groups the absolute numbers of the given nodes into the partitions left by
+// the given outages
+func calculatePartitions(outages []Connection, nodes []db.Node) [][]int {
+	size := 0
+	present := map[int]bool{}
+	for _, node := range nodes {
+		present[node.AbsoluteNum] = true
+		if node.AbsoluteNum >= size {
+			size = node.AbsoluteNum + 1
+		}
+	}
+	conns := NewConnections(size)
+	conns.RemoveAll(outages)
+	for i := 0; i < size; i++ {
+		if present[i] {
+			continue
+		}
+		for j := 0; j < size; j++ { //nodes which were removed from the testnet leave gaps in the numbers
+			conns.RemoveAll([]Connection{{From: i, To: j}, {From: j, To: i}})
+		}
+	}
+	out := [][]int{}
+	for _, network := range conns.Networks() {
+		partition := []int{}
+		for _, num := range network {
+			if present[num] {
+				partition = append(partition, num)
+			}
+		}
+		if len(partition) > 0 {
+			sort.Ints(partition)
+			out = append(out, partition)
+		}
+	}
+	return out
+}
+
+// GetOutageState gets the outages of the given testnet, reconciling those recorded when they were made
+// with those actually installed on the servers of the nodes
+func GetOutageState(testnetID string, nodes []db.Node) (OutageState, error) {
+	installed := []Connection{}
+	servers := map[int]bool{}
+	for _, node := range nodes {
+		if servers[node.Server] {
+			continue
+		}
+		servers[node.Server] = true
+		client, err := status.GetClient(node.Server)
+		if err != nil {
+			return OutageState{}, util.LogError(err)
+		}
+		conns, err := getInstalledOutages(client, nodes)
+		if err != nil {
+			return OutageState{}, fmt.Errorf("could not get the outages on server %d: %s", node.Server, err.Error())
+		}
+		installed = append(installed, conns...)
+	}
+	return reconcileOutages(getRecordedOutages(testnetID), installed, nodes), nil
+}
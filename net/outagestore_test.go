@@ -0,0 +1,75 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+)
+
+func TestParseInstalledOutages(t *testing.T) {
+	nodes := []db.Node{
+		{AbsoluteNum: 0, IP: "10.1.0.2"},
+		{AbsoluteNum: 1, IP: "10.1.0.6"},
+	}
+	listing := "-A FORWARD -i wb_bridge0 -d 10.1.0.6/32 -j DROP\n" +
+		"-A FORWARD -i wb_bridge1 -d 10.1.0.2/32 -j DROP\n" +
+		"-A FORWARD -i wb_bridge5 -d 10.1.0.2/32 -j DROP\n" + // not a node of the testnet
+		"-A FORWARD -i wb_bridge0 -d 10.1.0.30/32 -j DROP\n" +
+		"-A FORWARD -i wb_bridge0 -j ACCEPT\n"
+
+	expected := []Connection{{From: 0, To: 1}, {From: 1, To: 0}}
+	if got := parseInstalledOutages(listing, nodes); !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseInstalledOutages returned %v, expected %v", got, expected)
+	}
+}
+
+func TestReconcileOutages(t *testing.T) {
+	nodes := []db.Node{{AbsoluteNum: 0}, {AbsoluteNum: 1}, {AbsoluteNum: 2}, {AbsoluteNum: 4}}
+	recorded := []Connection{{From: 0, To: 2}, {From: 2, To: 0}, {From: 1, To: 4}}
+	installed := []Connection{{From: 2, To: 0}, {From: 0, To: 2}, {From: 1, To: 2}, {From: 2, To: 1}, {From: 4, To: 2}, {From: 2, To: 4}}
+
+	state := reconcileOutages(recorded, installed, nodes)
+	expected := OutageState{
+		Outages: []Outage{
+			{From: 0, To: 2, Recorded: true, Installed: true},
+			{From: 1, To: 2, Recorded: false, Installed: true},
+			{From: 1, To: 4, Recorded: true, Installed: false},
+			{From: 2, To: 0, Recorded: true, Installed: true},
+			{From: 2, To: 1, Recorded: false, Installed: true},
+			{From: 2, To: 4, Recorded: false, Installed: true},
+			{From: 4, To: 2, Recorded: false, Installed: true},
+		},
+		Partitions: [][]int{{0, 1, 4}, {2}},
+	}
+	if !reflect.DeepEqual(state, expected) {
+		t.Errorf("reconcileOutages returned %+v, expected %+v", state, expected)
+	}
+}
+
+func TestCalculatePartitions_Whole(t *testing.T) {
+	nodes := []db.Node{{AbsoluteNum: 0}, {AbsoluteNum: 1}, {AbsoluteNum: 2}}
+	// a one way outage does not partition the network
+	partitions := calculatePartitions([]Connection{{From: 0, To: 1}}, nodes)
+	if !reflect.DeepEqual(partitions, [][]int{{0, 1, 2}}) {
+		t.Errorf("unexpected partitions %v", partitions)
+	}
+}
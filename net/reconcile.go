@@ -0,0 +1,209 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"math"
+	"strings"
+)
+
+// defaultLimit is the limit netem gives to the queue when none is given
+const defaultLimit = 1000
+
+// Same checks whether two netconfs give the same network conditions, allowing for the rounding
+// done by tc when it reports the conditions which are in place
+func Same(a Netconf, b Netconf) bool {
+	limit := func(n int) int {
+		if n <= 0 {
+			return defaultLimit
+		}
+		return n
+	}
+	near := func(x float64, y float64) bool {
+		return math.Abs(x-y) <= 0.001+0.01*math.Max(math.Abs(x), math.Abs(y))
+	}
+	rate := func(r string) string {
+		r = strings.ToLower(r)
+		if r == "0" {
+			return ""
+		}
+		return r
+	}
+	return a.Node == b.Node &&
+		limit(a.Limit) == limit(b.Limit) &&
+		near(a.Loss, b.Loss) &&
+		near(float64(a.Delay), float64(b.Delay)) &&
+		rate(a.Rate) == rate(b.Rate) &&
+		near(a.Duplication, b.Duplication) &&
+		near(a.Corrupt, b.Corrupt) &&
+		near(a.Reorder, b.Reorder) &&
+		sameFilter(a, b)
+}
+
+// sameFilter checks whether two netconfs apply to the same traffic
+func sameFilter(a Netconf, b Netconf) bool {
+	return a.Port == b.Port && a.Protocol == b.Protocol && a.fromIP == b.fromIP
+}
+
+// parseFilter reads the protocol, source and destination port matched by the u32 filter of a node, as given by
+// tc filter show, into the netconf. The fw filter used when there are no filters matches neither.
+func parseFilter(raw string, netconf *Netconf) error {
+	for _, line := range strings.Split(raw, "\n") {
+		var value, mask uint32
+		var offset int
+		_, err := fmt.Sscanf(strings.TrimSpace(line), "match %x/%x at %d", &value, &mask, &offset)
+		if err != nil {
+			continue
+		}
+		switch {
+		case offset == 8 && mask == 0x00ff0000:
+			number := int((value & mask) >> 16)
+			for name, protocol := range protocols {
+				if protocol == number {
+					netconf.Protocol = name
+				}
+			}
+			if len(netconf.Protocol) == 0 {
+				return fmt.Errorf("unexpected protocol %d in the filter of node %d", number, netconf.Node)
+			}
+		case offset == 12 && mask == 0xffffffff:
+			netconf.fromIP = util.InetNtoa(value)
+		case offset == 20 && mask == 0x0000ffff:
+			netconf.Port = int(value & mask)
+		}
+	}
+	return nil
+}
+
+// getFilter gets the filters of the conditions in place on a node
+func getFilter(client ssh.Client, netconf *Netconf) error {
+	res, err := client.Run(fmt.Sprintf("sudo -n tc filter show dev %s%d parent 1:", conf.BridgePrefix, netconf.Node))
+	if err != nil {
+		return util.LogError(err)
+	}
+	return util.LogError(parseFilter(res, netconf))
+}
+
+// Change changes the network conditions of a node which already has some in place, without
+// removing them first. The filters of the conditions are left as they are.
+func Change(client ssh.Client, netconf Netconf) error {
+	err := netconf.Validate()
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = checkHostNetwork(client)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = client.Run(fmt.Sprintf("sudo -n tc qdisc change dev %s%d parent 1:1 handle 2: netem%s",
+		conf.BridgePrefix, netconf.Node, NetemOptions(netconf)))
+	return util.LogError(err)
+}
+
+// reconcileNode brings the network conditions of a node from current to desired, either of which
+// may be nil when the node has no conditions. Returns whether anything was changed.
+func reconcileNode(client ssh.Client, serverID int, current *Netconf, desired *Netconf) (bool, error) {
+	switch {
+	case desired == nil && current == nil:
+		return false, nil
+	case desired == nil:
+		return true, Remove(client, current.Node)
+	case current == nil || !sameFilter(*current, *desired):
+		return true, Apply(client, *desired, serverID)
+	case Same(*current, *desired):
+		return false, nil
+	}
+	return true, Change(client, *desired)
+}
+
+// Reconcile brings the network conditions of the given nodes to the desired ones, only changing
+// the nodes whose conditions differ from those in place, so that the other nodes are never left without
+// their conditions. Conditions which only differ in their filters are removed and added again. Nodes which are not in desired have their conditions removed.
+func Reconcile(desired []Netconf, nodes []db.Node) *Report {
+	report := NewReport()
+	wanted := map[int]*Netconf{}
+	for i := range desired {
+		_, err := db.GetNodeByLocalID(nodes, desired[i].Node)
+		if err == nil {
+			err = desired[i].resolveFrom(nodes)
+		}
+		if err != nil {
+			report.add(desired[i].Node, 0, strings.TrimSpace(NetemOptions(desired[i])), err)
+			continue
+		}
+		wanted[desired[i].Node] = &desired[i]
+	}
+
+	servers := map[int][]db.Node{}
+	serverIDs := []int{}
+	for _, node := range nodes {
+		if _, ok := servers[node.Server]; !ok {
+			serverIDs = append(serverIDs, node.Server)
+		}
+		servers[node.Server] = append(servers[node.Server], node)
+	}
+
+	for _, serverID := range serverIDs {
+		client, err := status.GetClient(serverID)
+		if err != nil {
+			for _, node := range servers[serverID] {
+				report.addNode(node, wantedRule(wanted, node.LocalID), err)
+			}
+			continue
+		}
+		reconcileServer(client, serverID, servers[serverID], wanted, report)
+	}
+	return report
+}
+
+func wantedRule(wanted map[int]*Netconf, node int) string {
+	if want, ok := wanted[node]; ok {
+		return strings.TrimSpace(NetemOptions(*want))
+	}
+	return ""
+}
+
+// reconcileServer brings the network conditions of the given nodes, which are all on the server
+// of the client, to the wanted ones
+func reconcileServer(client ssh.Client, serverID int, nodes []db.Node, wanted map[int]*Netconf, report *Report) {
+	confs, err := GetConfigOnServer(client)
+	current := map[int]*Netconf{}
+	for i := 0; i < len(confs) && err == nil; i++ {
+		err = getFilter(client, &confs[i])
+		current[confs[i].Node] = &confs[i]
+	}
+	for _, node := range nodes {
+		rule := wantedRule(wanted, node.LocalID)
+		if err != nil {
+			report.addNode(node, rule, err)
+			continue
+		}
+		changed, err := reconcileNode(client, serverID, current[node.LocalID], wanted[node.LocalID])
+		if !changed && err == nil {
+			report.addUnchanged(node, rule)
+			continue
+		}
+		report.addNode(node, rule, err)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh/mocks"
+	"github.com/whiteblock/genesis/util"
+)
+
+func TestSame(t *testing.T) {
+	var test = []struct {
+		a        Netconf
+		b        Netconf
+		expected bool
+	}{
+		{a: Netconf{Node: 1}, b: Netconf{Node: 1, Limit: 1000}, expected: true},
+		{a: Netconf{Node: 1}, b: Netconf{Node: 2}, expected: false},
+		{a: Netconf{Node: 1, Delay: 415900000}, b: Netconf{Node: 1, Delay: 415900000}, expected: true},
+		{a: Netconf{Node: 1, Delay: 5000}, b: Netconf{Node: 1, Delay: 6000}, expected: false},
+		{a: Netconf{Node: 1, Loss: 0.5}, b: Netconf{Node: 1, Loss: 0.5001}, expected: true},
+		{a: Netconf{Node: 1, Loss: 0.5}, b: Netconf{Node: 1, Loss: 1}, expected: false},
+		{a: Netconf{Node: 1, Rate: "1mbit"}, b: Netconf{Node: 1, Rate: "1Mbit"}, expected: true},
+		{a: Netconf{Node: 1, Rate: "0"}, b: Netconf{Node: 1}, expected: true},
+		{a: Netconf{Node: 1, Reorder: 0.07}, b: Netconf{Node: 1}, expected: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if got := Same(tt.a, tt.b); got != tt.expected {
+				t.Errorf("Same returned %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	var test = []struct {
+		raw      string
+		expected Netconf
+	}{
+		{
+			raw:      "filter parent 1: protocol ip pref 55 fw chain 0 \nfilter parent 1: protocol ip pref 55 fw chain 0 handle 0x6 classid 2:1\n",
+			expected: Netconf{Node: 1},
+		},
+		{
+			raw: "filter parent 1: protocol ip pref 55 u32 chain 0 fh 800::800 order 2048 key ht 800 bkt 0 flowid 2:1 not_in_hw \n" +
+				"  mark 0x00000006 0xffffffff (success 0)\n" +
+				"  match 00060000/00ff0000 at 8\n" +
+				"  match 0000765f/0000ffff at 20\n",
+			expected: Netconf{Node: 1, Protocol: "tcp", Port: 30303},
+		},
+		{
+			raw:      "  match 00110000/00ff0000 at 8\n",
+			expected: Netconf{Node: 1, Protocol: "udp"},
+		},
+		{
+			raw:      "  match 0a010006/ffffffff at 12\n",
+			expected: Netconf{Node: 1, fromIP: "10.1.0.6"},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			netconf := Netconf{Node: 1}
+			err := parseFilter(tt.raw, &netconf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if netconf != tt.expected {
+				t.Errorf("parseFilter gave %+v, expected %+v", netconf, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReconcileServer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Runtime().Return(util.Runtime{Name: util.DockerRuntime, CLI: "docker"}).AnyTimes()
+	client.
+		EXPECT().
+		Run("sudo -n tc qdisc show | grep wb_bridge | grep netem || true").
+		Return("qdisc netem 2: dev wb_bridge0 parent 1:1 limit 1000 loss 0.5%\n"+
+			"qdisc netem 2: dev wb_bridge1 parent 1:1 limit 1000 loss 0.5%\n"+
+			"qdisc netem 2: dev wb_bridge2 parent 1:1 limit 1000 loss 0.5%\n"+
+			"qdisc netem 2: dev wb_bridge4 parent 1:1 limit 1000 loss 0.5%", nil)
+	for i := 0; i < 3; i++ {
+		client.EXPECT().Run(fmt.Sprintf("sudo -n tc filter show dev wb_bridge%d parent 1:", i))
+	}
+	client.EXPECT().Run("sudo -n tc filter show dev wb_bridge4 parent 1:").Return("  match 00110000/00ff0000 at 8\n", nil)
+
+	// node 0 is unchanged, node 1 is changed in place, node 2 is removed, node 3 is added
+	// and node 4 is added again with its new filter
+	client.EXPECT().Run("sudo -n tc qdisc change dev wb_bridge1 parent 1:1 handle 2: netem loss 1.0000")
+	client.EXPECT().Run("sudo -n tc qdisc del dev wb_bridge2 root")
+	for _, netconf := range []Netconf{{Node: 3, Loss: 0.5}, {Node: 4, Loss: 0.5, Protocol: "tcp"}} {
+		for _, cmd := range CreateCommands(netconf, 1) {
+			client.EXPECT().Run(cmd)
+		}
+	}
+
+	nodes := []db.Node{}
+	for i := 0; i < 5; i++ {
+		nodes = append(nodes, db.Node{LocalID: i, Server: 1})
+	}
+	wanted := map[int]*Netconf{
+		0: {Node: 0, Loss: 0.5},
+		1: {Node: 1, Loss: 1},
+		3: {Node: 3, Loss: 0.5},
+		4: {Node: 4, Loss: 0.5, Protocol: "tcp"},
+	}
+	report := NewReport()
+	reconcileServer(client, 1, nodes, wanted, report)
+
+	if !report.Succeeded() || len(report.Results) != 5 {
+		t.Fatalf("unexpected report %+v", *report)
+	}
+	for i, unchanged := range []bool{true, false, false, false, false} {
+		if report.Results[i].Unchanged != unchanged {
+			t.Errorf("node %d is unchanged: %v, expected %v", i, report.Results[i].Unchanged, unchanged)
+		}
+	}
+}
+
+func TestReconcileServer_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockClient(ctrl)
+	client.
+		EXPECT().
+		Run("sudo -n tc qdisc show | grep wb_bridge | grep netem || true").
+		Return("", fmt.Errorf("connection lost"))
+
+	report := NewReport()
+	reconcileServer(client, 1, []db.Node{{LocalID: 0, Server: 1}, {LocalID: 1, Server: 1}},
+		map[int]*Netconf{0: {Node: 0, Delay: 100}}, report)
+	if report.Failed != 2 || report.Results[0].Rule != "delay 100us" {
+		t.Errorf("unexpected report %+v", *report)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"github.com/whiteblock/genesis/db"
+)
+
+// NodeResult is the outcome of changing the network conditions of a single node
+type NodeResult struct {
+	// Node is the number of the node, as given in the netconf
+	Node int `json:"node"`
+	// Server is the id of the server the node is on, 0 if the node was not found
+	Server int `json:"server"`
+	// Rule is the netem options given to the node, empty when its conditions were removed
+	Rule string `json:"rule,omitempty"`
+	// Error is why the conditions of the node could not be changed, empty if they were
+	Error string `json:"error,omitempty"`
+	// Unchanged is whether the node already had the desired network conditions, so nothing was done
+	Unchanged bool `json:"unchanged,omitempty"`
+}
+
+// Report is the outcome of changing the network conditions of a set of nodes, where some of the nodes
+// may have been changed even though others failed
+type Report struct {
+	// Results has the outcome for each node, in the order they were given
+	Results []NodeResult `json:"results"`
+	// Failed is the number of nodes whose conditions could not be changed
+	Failed int `json:"failed"`
+}
+
+// NewReport creates an empty report
+func NewReport() *Report {
+	return &Report{Results: []NodeResult{}}
+}
+
+func (r *Report) add(node int, server int, rule string, err error) {
+	result := NodeResult{Node: node, Server: server, Rule: rule}
+	if err != nil {
+		result.Error = err.Error()
+		r.Failed++
+	}
+	r.Results = append(r.Results, result)
+}
+
+func (r *Report) addUnchanged(node db.Node, rule string) {
+	r.Results = append(r.Results, NodeResult{Node: node.LocalID, Server: node.Server, Rule: rule, Unchanged: true})
+}
+
+func (r *Report) addNode(node db.Node, rule string, err error) {
+	r.add(node.LocalID, node.Server, rule, err)
+}
+
+// Succeeded is whether the conditions of every node were changed
+func (r Report) Succeeded() bool {
+	return r.Failed == 0
+}
+
+// Partial is whether the conditions of some of the nodes were changed, while others failed
+func (r Report) Partial() bool {
+	return r.Failed > 0 && r.Failed < len(r.Results)
+}
@@ -0,0 +1,67 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+)
+
+func TestReport(t *testing.T) {
+	var test = []struct {
+		errs      []error
+		failed    int
+		succeeded bool
+		partial   bool
+	}{
+		{errs: []error{}, failed: 0, succeeded: true, partial: false},
+		{errs: []error{nil, nil}, failed: 0, succeeded: true, partial: false},
+		{errs: []error{nil, fmt.Errorf("err")}, failed: 1, succeeded: false, partial: true},
+		{errs: []error{fmt.Errorf("err"), fmt.Errorf("err")}, failed: 2, succeeded: false, partial: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			report := NewReport()
+			for j, err := range tt.errs {
+				report.addNode(db.Node{LocalID: j, Server: 1}, "delay 100us", err)
+			}
+			if report.Failed != tt.failed {
+				t.Errorf("report has %d failures, expected %d", report.Failed, tt.failed)
+			}
+			if report.Succeeded() != tt.succeeded {
+				t.Errorf("Succeeded returned %v, expected %v", report.Succeeded(), tt.succeeded)
+			}
+			if report.Partial() != tt.partial {
+				t.Errorf("Partial returned %v, expected %v", report.Partial(), tt.partial)
+			}
+			if len(report.Results) != len(tt.errs) {
+				t.Fatalf("report has %d results, expected %d", len(report.Results), len(tt.errs))
+			}
+			for j, err := range tt.errs {
+				if report.Results[j].Node != j || (err != nil) != (report.Results[j].Error != "") {
+					t.Errorf("unexpected result %+v for node %d", report.Results[j], j)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TrafficChainPrefix is the prefix of the iptables chains which count the traffic between the nodes of a testnet
+const TrafficChainPrefix = "wb_traffic_"
+
+// maxRulesPerCommand limits the number of rules added by a single command, to stay under the maximum
+// length of a command line
+const maxRulesPerCommand = 500
+
+// PairTraffic is the traffic sent from one node to another
+type PairTraffic struct {
+	// From is the absolute number of the sending node
+	From int `json:"from"`
+	// To is the absolute number of the receiving node
+	To int `json:"to"`
+	// Bytes is the total number of bytes sent since the accounting was started
+	Bytes uint64 `json:"bytes"`
+	// Packets is the total number of packets sent since the accounting was started
+	Packets uint64 `json:"packets"`
+	// BytesPerSecond is the rate bytes were sent at over the last sampling interval
+	BytesPerSecond float64 `json:"bytesPerSecond"`
+	// PacketsPerSecond is the rate packets were sent at over the last sampling interval
+	PacketsPerSecond float64 `json:"packetsPerSecond"`
+}
+
+// TrafficReport is the latest sample of the traffic between the nodes of a testnet
+type TrafficReport struct {
+	// Started is when the accounting was started
+	Started time.Time `json:"started"`
+	// Sampled is when the counters were last read
+	Sampled time.Time `json:"sampled"`
+	// Pairs has the traffic of each pair of nodes which have exchanged packets
+	Pairs []PairTraffic `json:"pairs"`
+}
+
+// GetTrafficChain gets the name of the iptables chain which counts the traffic of the given testnet
+func GetTrafficChain(testnetID string) string {
+	return TrafficChainPrefix + util.GetNameScope(testnetID)
+}
+
+// makeTrafficRules creates the rules which count the traffic sent from each of the local nodes to every
+// other node. Matching packets return to FORWARD, so that the rules only count.
+func makeTrafficRules(chain string, local []db.Node, all []db.Node) []string {
+	out := []string{}
+	for _, from := range local {
+		for _, to := range all {
+			if from.AbsoluteNum == to.AbsoluteNum {
+				continue
+			}
+			out = append(out, fmt.Sprintf("%s -s %s -d %s -j RETURN", chain, from.IP, to.IP))
+		}
+	}
+	return out
+}
+
+// parseTrafficCounters parses the listing of a traffic chain, given the absolute numbers of the nodes by ip
+func parseTrafficCounters(listing string, nodes map[string]int) []PairTraffic {
+	out := []PairTraffic{}
+	for _, line := range strings.Split(listing, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 9 || fields[2] != "RETURN" {
+			continue
+		}
+		packets, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		bytes, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		from, ok := nodes[strings.TrimSuffix(fields[7], "/32")]
+		if !ok {
+			continue
+		}
+		to, ok := nodes[strings.TrimSuffix(fields[8], "/32")]
+		if !ok {
+			continue
+		}
+		out = append(out, PairTraffic{From: from, To: to, Bytes: bytes, Packets: packets})
+	}
+	return out
+}
+
+// installTrafficAccounting creates the traffic chain of the testnet on the server, replacing it if it already
+// exists, and jumps to it from the top of FORWARD
+func installTrafficAccounting(client ssh.Client, chain string, local []db.Node, all []db.Node) error {
+	err := checkHostNetwork(client)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = client.Run(fmt.Sprintf("(sudo iptables -N %s 2>/dev/null || sudo iptables -F %s) && "+
+		"(sudo iptables -C FORWARD -j %s 2>/dev/null || sudo iptables -I FORWARD -j %s)", chain, chain, chain, chain))
+	if err != nil {
+		return util.LogError(err)
+	}
+	rules := makeTrafficRules(chain, local, all)
+	for i := 0; i < len(rules); i += maxRulesPerCommand {
+		end := i + maxRulesPerCommand
+		if end > len(rules) {
+			end = len(rules)
+		}
+		cmds := []string{}
+		for _, rule := range rules[i:end] {
+			cmds = append(cmds, "sudo iptables -A "+rule)
+		}
+		_, err = client.Run(strings.Join(cmds, " && "))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	return nil
+}
+
+// RemoveTrafficAccounting removes the traffic chain of the given testnet from the server, if it exists
+func RemoveTrafficAccounting(client ssh.Client, testnetID string) error {
+	chain := GetTrafficChain(testnetID)
+	_, err := client.Run(fmt.Sprintf("while sudo iptables -D FORWARD -j %s 2>/dev/null; do :; done; "+
+		"sudo iptables -F %s 2>/dev/null; sudo iptables -X %s 2>/dev/null; true", chain, chain, chain))
+	return util.LogError(err)
+}
+
+// readTrafficCounters reads the counters of the traffic chain on the server
+func readTrafficCounters(client ssh.Client, chain string, nodes map[string]int) ([]PairTraffic, error) {
+	res, err := client.Run(fmt.Sprintf("sudo iptables -L %s -n -v -x", chain))
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return parseTrafficCounters(res, nodes), nil
+}
+
+// trafficMonitor periodically samples the traffic counters of a testnet
+type trafficMonitor struct {
+	testnetID string
+	nodes     []db.Node
+	started   time.Time
+	stop      chan bool
+
+	mux      sync.RWMutex
+	sampled  time.Time
+	previous map[[2]int]PairTraffic
+	latest   []PairTraffic
+}
+
+var (
+	monitors   = map[string]*trafficMonitor{}
+	monitorMux = sync.Mutex{}
+)
+
+// sample reads the counters of every server, computing the rates since the previous sample
+func (tm *trafficMonitor) sample() error {
+	byIP := map[string]int{}
+	for _, node := range tm.nodes {
+		byIP[node.IP] = node.AbsoluteNum
+	}
+	now := time.Now()
+	pairs := []PairTraffic{}
+	for _, serverID := range db.GetUniqueServerIDs(tm.nodes) {
+		client, err := status.GetClient(serverID)
+		if err != nil {
+			return util.LogError(err)
+		}
+		res, err := readTrafficCounters(client, GetTrafficChain(tm.testnetID), byIP)
+		if err != nil {
+			return util.LogError(err)
+		}
+		pairs = append(pairs, res...)
+	}
+
+	tm.mux.Lock()
+	defer tm.mux.Unlock()
+	elapsed := now.Sub(tm.sampled).Seconds()
+	current := map[[2]int]PairTraffic{}
+	latest := []PairTraffic{}
+	for _, pair := range pairs {
+		key := [2]int{pair.From, pair.To}
+		if prev, ok := tm.previous[key]; ok && elapsed > 0 && pair.Bytes >= prev.Bytes {
+			pair.BytesPerSecond = float64(pair.Bytes-prev.Bytes) / elapsed
+			pair.PacketsPerSecond = float64(pair.Packets-prev.Packets) / elapsed
+		}
+		current[key] = pair
+		if pair.Packets > 0 {
+			latest = append(latest, pair)
+		}
+	}
+	sort.Slice(latest, func(i, j int) bool {
+		if latest[i].From != latest[j].From {
+			return latest[i].From < latest[j].From
+		}
+		return latest[i].To < latest[j].To
+	})
+	tm.previous = current
+	tm.latest = latest
+	tm.sampled = now
+	return nil
+}
+
+func (tm *trafficMonitor) run() {
+	ticker := time.NewTicker(time.Duration(conf.TrafficSampleInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-tm.stop:
+			return
+		case <-ticker.C:
+			err := tm.sample()
+			if err != nil {
+				log.WithFields(log.Fields{"testnet": tm.testnetID, "error": err}).Error(
+					"failed to sample the traffic")
+			}
+		}
+	}
+}
+
+// StartTrafficAccounting starts counting the traffic sent between each pair of the given nodes of the testnet,
+// sampling the counters every trafficSampleInterval seconds. If the accounting was already started, it is
+// restarted with the given nodes and the counters are reset.
+func StartTrafficAccounting(testnetID string, nodes []db.Node) error {
+	stopTrafficMonitor(testnetID)
+	chain := GetTrafficChain(testnetID)
+	for _, serverID := range db.GetUniqueServerIDs(nodes) {
+		client, err := status.GetClient(serverID)
+		if err != nil {
+			return util.LogError(err)
+		}
+		local := []db.Node{}
+		for _, node := range nodes {
+			if node.Server == serverID {
+				local = append(local, node)
+			}
+		}
+		err = installTrafficAccounting(client, chain, local, nodes)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	tm := &trafficMonitor{testnetID: testnetID, nodes: nodes, started: time.Now(), stop: make(chan bool)}
+	tm.sampled = tm.started
+	err := tm.sample()
+	if err != nil {
+		return util.LogError(err)
+	}
+	monitorMux.Lock()
+	monitors[testnetID] = tm
+	monitorMux.Unlock()
+	go tm.run()
+	log.WithFields(log.Fields{"testnet": testnetID, "nodes": len(nodes)}).Info("started the traffic accounting")
+	return nil
+}
+
+// stopTrafficMonitor stops sampling the traffic of the testnet
+func stopTrafficMonitor(testnetID string) {
+	monitorMux.Lock()
+	defer monitorMux.Unlock()
+	tm, ok := monitors[testnetID]
+	if !ok {
+		return
+	}
+	close(tm.stop)
+	delete(monitors, testnetID)
+}
+
+// GetTrafficReport gets the latest sample of the traffic between the nodes of the testnet
+func GetTrafficReport(testnetID string) (TrafficReport, error) {
+	monitorMux.Lock()
+	tm, ok := monitors[testnetID]
+	monitorMux.Unlock()
+	if !ok {
+		return TrafficReport{}, fmt.Errorf("the traffic accounting has not been started for testnet \"%s\"", testnetID)
+	}
+	tm.mux.RLock()
+	defer tm.mux.RUnlock()
+	return TrafficReport{Started: tm.started, Sampled: tm.sampled, Pairs: tm.latest}, nil
+}
+
+// StopTrafficAccounting stops counting the traffic between the given nodes of the testnet, removing the
+// counters from their servers
+func StopTrafficAccounting(testnetID string, nodes []db.Node) error {
+	stopTrafficMonitor(testnetID)
+	clients, err := status.GetClientsFromNodes(nodes)
+	if err != nil {
+		return util.LogError(err)
+	}
+	for _, client := range clients {
+		if client.Runtime().Rootless {
+			continue
+		}
+		err = RemoveTrafficAccounting(client, testnetID)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	return nil
+}
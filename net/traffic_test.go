@@ -0,0 +1,98 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh/mocks"
+	"github.com/whiteblock/genesis/util"
+)
+
+func TestMakeTrafficRules(t *testing.T) {
+	nodes := []db.Node{
+		{AbsoluteNum: 0, Server: 1, IP: "10.1.0.2"},
+		{AbsoluteNum: 1, Server: 1, IP: "10.1.0.6"},
+		{AbsoluteNum: 2, Server: 2, IP: "10.2.0.2"},
+	}
+	expected := []string{
+		"wb_traffic_4ac9d3b2 -s 10.1.0.2 -d 10.1.0.6 -j RETURN",
+		"wb_traffic_4ac9d3b2 -s 10.1.0.2 -d 10.2.0.2 -j RETURN",
+		"wb_traffic_4ac9d3b2 -s 10.1.0.6 -d 10.1.0.2 -j RETURN",
+		"wb_traffic_4ac9d3b2 -s 10.1.0.6 -d 10.2.0.2 -j RETURN",
+	}
+	out := makeTrafficRules(GetTrafficChain("4ac9d3b2-1a2b-4c5d-9e8f-0123456789ab"), nodes[:2], nodes)
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %v, got %v", expected, out)
+	}
+}
+
+func TestParseTrafficCounters(t *testing.T) {
+	nodes := map[string]int{"10.1.0.2": 0, "10.1.0.6": 1, "10.2.0.2": 2}
+	var test = []struct {
+		listing  string
+		expected []PairTraffic
+	}{
+		{listing: "", expected: []PairTraffic{}},
+		{
+			listing: "Chain wb_traffic_4ac9d3b2 (1 references)\n" +
+				"    pkts      bytes target     prot opt in     out     source               destination\n" +
+				"     120    10240 RETURN     all  --  *      *       10.1.0.2             10.1.0.6\n" +
+				"       0        0 RETURN     all  --  *      *       10.1.0.2             10.2.0.2\n" +
+				"      31     2654 RETURN     all  --  *      *       10.1.0.6             10.9.0.2\n",
+			expected: []PairTraffic{
+				{From: 0, To: 1, Bytes: 10240, Packets: 120},
+				{From: 0, To: 2, Bytes: 0, Packets: 0},
+			},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := parseTrafficCounters(tt.listing, nodes)
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestInstallTrafficAccounting(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nodes := []db.Node{{AbsoluteNum: 0, IP: "10.1.0.2"}, {AbsoluteNum: 1, IP: "10.1.0.6"}}
+	client := mocks.NewMockClient(ctrl)
+	client.EXPECT().Runtime().Return(util.Runtime{Name: util.DockerRuntime, CLI: "docker"}).AnyTimes()
+	gomock.InOrder(
+		client.EXPECT().Run("(sudo iptables -N wb_traffic_x 2>/dev/null || sudo iptables -F wb_traffic_x) && "+
+			"(sudo iptables -C FORWARD -j wb_traffic_x 2>/dev/null || sudo iptables -I FORWARD -j wb_traffic_x)").Return("", nil),
+		client.EXPECT().Run("sudo iptables -A wb_traffic_x -s 10.1.0.2 -d 10.1.0.6 -j RETURN && "+
+			"sudo iptables -A wb_traffic_x -s 10.1.0.6 -d 10.1.0.2 -j RETURN").Return("", nil),
+	)
+
+	err := installTrafficAccounting(client, "wb_traffic_x", nodes, nodes)
+	if err != nil {
+		t.Error(err)
+	}
+}
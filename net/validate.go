@@ -0,0 +1,202 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxDelay is the largest delay which may be given to a node
+	maxDelay = time.Hour
+	// maxRate is the largest rate which may be given to a node, in bits per second
+	maxRate = 100e9
+)
+
+// rateUnits are the units of rate understood by tc, in bits per second
+var rateUnits = map[string]float64{
+	"":      1,
+	"bit":   1,
+	"kbit":  1e3,
+	"mbit":  1e6,
+	"gbit":  1e9,
+	"tbit":  1e12,
+	"kibit": 1 << 10,
+	"mibit": 1 << 20,
+	"gibit": 1 << 30,
+	"tibit": 1 << 40,
+	"bps":   8,
+	"kbps":  8e3,
+	"mbps":  8e6,
+	"gbps":  8e9,
+	"tbps":  8e12,
+}
+
+var rateRegex = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)([a-z]*)$`)
+
+// FieldError is the problem with a single field of a netconf
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError has the problems with each of the fields of the netconf of a node
+type ValidationError struct {
+	Node   int          `json:"node"`
+	Errors []FieldError `json:"errors"`
+}
+
+func (err ValidationError) Error() string {
+	out := make([]string, len(err.Errors))
+	for i, fieldErr := range err.Errors {
+		out[i] = fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message)
+	}
+	return fmt.Sprintf("invalid network conditions for node %d: %s", err.Node, strings.Join(out, ", "))
+}
+
+func (err *ValidationError) add(field string, format string, args ...interface{}) {
+	err.Errors = append(err.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// UnmarshalJSON reads a netconf, where the delay is either a number of microseconds or a duration
+// with its unit, such as "100ms"
+func (netconf *Netconf) UnmarshalJSON(data []byte) error {
+	type plain Netconf
+	raw := struct {
+		*plain
+		Delay interface{} `json:"delay"`
+	}{plain: (*plain)(netconf)}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	netconf.invalidDelay = ""
+	netconf.Delay, err = parseDelay(raw.Delay)
+	if err != nil {
+		netconf.invalidDelay = err.Error()
+	}
+	return nil
+}
+
+// parseDelay gets a delay in microseconds from either a number of microseconds or a duration
+func parseDelay(delay interface{}) (int, error) {
+	switch val := delay.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		if val != math.Trunc(val) {
+			return 0, fmt.Errorf("expected a whole number of microseconds, got %v", val)
+		}
+		return int(val), nil
+	case string:
+		duration, err := util.ParseDuration(val, time.Microsecond)
+		if err != nil {
+			return 0, fmt.Errorf("\"%s\" is neither a number of microseconds nor a duration such as 100ms", val)
+		}
+		return int(duration / time.Microsecond), nil
+	}
+	return 0, fmt.Errorf("expected a number of microseconds or a duration such as 100ms")
+}
+
+// ParseRate gets the rate in bits per second of a rate given to tc, such as 5mbit
+func ParseRate(rate string) (float64, error) {
+	matches := rateRegex.FindStringSubmatch(strings.ToLower(rate))
+	if matches == nil {
+		return 0, fmt.Errorf("\"%s\" is not a rate such as 5mbit", rate)
+	}
+	unit, ok := rateUnits[matches[2]]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit \"%s\" in rate \"%s\"", matches[2], rate)
+	}
+	val, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	return val * unit, nil
+}
+
+// Validate checks that the netconf gives network conditions which tc will accept, returning a
+// ValidationError with the problem of each field which is not valid
+func (netconf Netconf) Validate() error {
+	err := ValidationError{Node: netconf.Node}
+	if netconf.Node < 0 {
+		err.add("node", "must not be negative")
+	}
+	if netconf.Limit < 0 {
+		err.add("limit", "must not be negative")
+	}
+	switch {
+	case len(netconf.invalidDelay) > 0:
+		err.add("delay", netconf.invalidDelay)
+	case netconf.Delay < 0:
+		err.add("delay", "must not be negative")
+	case time.Duration(netconf.Delay)*time.Microsecond > maxDelay:
+		err.add("delay", "must be at most %s", maxDelay)
+	}
+	if len(netconf.Rate) > 0 {
+		rate, rateErr := ParseRate(netconf.Rate)
+		switch {
+		case rateErr != nil:
+			err.add("rate", rateErr.Error())
+		case rate <= 0:
+			err.add("rate", "must be greater than zero, leave it empty for no limit")
+		case rate > maxRate:
+			err.add("rate", "must be at most 100gbit")
+		}
+	}
+	percentages := []struct {
+		field string
+		value float64
+	}{
+		{field: "loss", value: netconf.Loss},
+		{field: "duplicate", value: netconf.Duplication},
+		{field: "corrupt", value: netconf.Corrupt},
+		{field: "reorder", value: netconf.Reorder},
+	}
+	for _, percentage := range percentages {
+		if percentage.value < 0 || percentage.value > 100 {
+			err.add(percentage.field, "must be a percentage between 0 and 100, got %v", percentage.value)
+		}
+	}
+
+	if netconf.Port < 0 || netconf.Port > 65535 {
+		err.add("port", "invalid port %d", netconf.Port)
+	}
+	if _, ok := protocols[netconf.Protocol]; len(netconf.Protocol) > 0 && !ok {
+		err.add("protocol", "unsupported protocol \"%s\", expected tcp, udp or icmp", netconf.Protocol)
+	}
+	if netconf.From != nil && *netconf.From < 0 {
+		err.add("from", "must not be negative")
+	}
+	if netconf.Port > 0 && netconf.Protocol == "icmp" {
+		err.add("port", "a port cannot be given with icmp")
+	}
+
+	if len(err.Errors) > 0 {
+		return err
+	}
+	return nil
+}
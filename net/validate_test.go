@@ -0,0 +1,126 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package netconf
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+func TestNetconf_UnmarshalJSON(t *testing.T) {
+	var test = []struct {
+		raw      string
+		expected int
+		valid    bool
+	}{
+		{raw: `{"node":1,"delay":5000}`, expected: 5000, valid: true},
+		{raw: `{"node":1,"delay":"5000"}`, expected: 5000, valid: true},
+		{raw: `{"node":1,"delay":"100ms"}`, expected: 100000, valid: true},
+		{raw: `{"node":1,"delay":"1.5s"}`, expected: 1500000, valid: true},
+		{raw: `{"node":1}`, expected: 0, valid: true},
+		{raw: `{"node":1,"delay":"soon"}`, expected: 0, valid: false},
+		{raw: `{"node":1,"delay":1.5}`, expected: 0, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var netconf Netconf
+			err := json.Unmarshal([]byte(tt.raw), &netconf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if netconf.Node != 1 || netconf.Delay != tt.expected {
+				t.Errorf("unexpected netconf %+v", netconf)
+			}
+			if err = netconf.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate returned %v, expected valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	var test = []struct {
+		rate     string
+		expected float64
+		valid    bool
+	}{
+		{rate: "5mbit", expected: 5e6, valid: true},
+		{rate: "5Mbit", expected: 5e6, valid: true},
+		{rate: "1.5gbit", expected: 1.5e9, valid: true},
+		{rate: "100kbps", expected: 800e3, valid: true},
+		{rate: "1000", expected: 1000, valid: true},
+		{rate: "fast", valid: false},
+		{rate: "5 mbit", valid: false},
+		{rate: "5parsecs", valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			rate, err := ParseRate(tt.rate)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ParseRate returned %v, expected valid: %v", err, tt.valid)
+			}
+			if rate != tt.expected {
+				t.Errorf("ParseRate returned %v, expected %v", rate, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNetconf_Validate_Fields(t *testing.T) {
+	var test = []struct {
+		netconf Netconf
+		fields  []string
+	}{
+		{netconf: Netconf{Node: 1, Loss: 100, Delay: 1000, Rate: "10mbit", Limit: 1000}, fields: []string{}},
+		{netconf: Netconf{Node: 1, Loss: -1}, fields: []string{"loss"}},
+		{netconf: Netconf{Node: 1, Loss: 101, Corrupt: 200}, fields: []string{"loss", "corrupt"}},
+		{netconf: Netconf{Node: 1, Rate: "0"}, fields: []string{"rate"}},
+		{netconf: Netconf{Node: 1, Rate: "1tbit"}, fields: []string{"rate"}},
+		{netconf: Netconf{Node: 1, Delay: -5, Limit: -1}, fields: []string{"limit", "delay"}},
+		{netconf: Netconf{Node: 1, Delay: 2 * 3600 * 1000000}, fields: []string{"delay"}},
+		{netconf: Netconf{Node: -1, Duplication: 150, Reorder: -2}, fields: []string{"node", "duplicate", "reorder"}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.netconf.Validate()
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			verr, ok := err.(ValidationError)
+			if !ok {
+				t.Fatalf("expected a ValidationError, got %v", err)
+			}
+			if len(verr.Errors) != len(tt.fields) {
+				t.Fatalf("expected errors for %v, got %v", tt.fields, verr)
+			}
+			for j, field := range tt.fields {
+				if verr.Errors[j].Field != field {
+					t.Errorf("expected an error for %s, got %s", field, verr.Errors[j].Field)
+				}
+			}
+		})
+	}
+}
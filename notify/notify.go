@@ -0,0 +1,139 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package notify posts summaries of finished builds to chat services such as Slack and Discord,
+// and sends emails through the configured SMTP server
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+// Summary contains the information about a build which is sent out in a notification
+type Summary struct {
+	TestNetID  string
+	Blockchain string
+	Nodes      int
+	Duration   time.Duration
+	Error      error
+}
+
+// String gives a human readable representation of the summary
+func (s Summary) String() string {
+	if s.Error != nil {
+		return fmt.Sprintf(":x: Build %s of %s with %d nodes failed after %s: %s",
+			s.TestNetID, s.Blockchain, s.Nodes, s.Duration.Round(time.Second), s.Error.Error())
+	}
+	return fmt.Sprintf(":white_check_mark: Build %s of %s with %d nodes finished in %s",
+		s.TestNetID, s.Blockchain, s.Nodes, s.Duration.Round(time.Second))
+}
+
+// BuildFinished sends the summary of a build to all of the configured chat services.
+// Successful builds are only reported if notifyOnSuccess is set.
+func BuildFinished(summary Summary) {
+	if summary.Error == nil && !conf.NotifyOnSuccess {
+		return
+	}
+	msg := summary.String()
+	if len(conf.SlackWebhook) > 0 {
+		err := post(conf.SlackWebhook, map[string]string{"text": msg})
+		if err != nil {
+			log.WithFields(log.Fields{"build": summary.TestNetID, "error": err}).Error("failed to notify slack")
+		}
+	}
+	if len(conf.DiscordWebhook) > 0 {
+		err := post(conf.DiscordWebhook, map[string]string{"content": msg})
+		if err != nil {
+			log.WithFields(log.Fields{"build": summary.TestNetID, "error": err}).Error("failed to notify discord")
+		}
+	}
+}
+
+// Alert sends an alert about a testnet to all of the configured chat services
+func Alert(testnetID string, message string) {
+	msg := fmt.Sprintf(":rotating_light: Testnet %s: %s", testnetID, message)
+	if len(conf.SlackWebhook) > 0 {
+		err := post(conf.SlackWebhook, map[string]string{"text": msg})
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": testnetID, "error": err}).Error("failed to notify slack")
+		}
+	}
+	if len(conf.DiscordWebhook) > 0 {
+		err := post(conf.DiscordWebhook, map[string]string{"content": msg})
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": testnetID, "error": err}).Error("failed to notify discord")
+		}
+	}
+}
+
+func post(url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: time.Duration(conf.WebhookTimeout) * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("received status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailEnabled checks whether an SMTP server has been configured to send emails through
+func EmailEnabled() bool {
+	return len(conf.SMTPHost) > 0
+}
+
+// Email sends a plain text email with the given subject and body to the given addresses,
+// through the SMTP server of the configuration
+func Email(to []string, subject string, body string) error {
+	if !EmailEnabled() {
+		return fmt.Errorf("sending emails is disabled, as smtpHost is not set")
+	}
+	if len(to) == 0 {
+		return nil
+	}
+	var auth smtp.Auth
+	if len(conf.SMTPUser) > 0 {
+		auth = smtp.PlainAuth("", conf.SMTPUser, conf.SMTPPassword, conf.SMTPHost)
+	}
+	msg := &strings.Builder{}
+	fmt.Fprintf(msg, "From: %s\r\n", conf.SMTPFrom)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	return smtp.SendMail(conf.SMTPHost+":"+strconv.Itoa(conf.SMTPPort), auth, conf.SMTPFrom, to, []byte(msg.String()))
+}
@@ -0,0 +1,118 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withChat points the chat service webhooks at a test server which records the payloads it receives,
+// answering with the given status code
+func withChat(t *testing.T, code int) *[]map[string]string {
+	received := []map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		received = append(received, payload)
+		w.WriteHeader(code)
+	}))
+	original := *conf
+	conf.SlackWebhook = server.URL + "/slack"
+	conf.DiscordWebhook = server.URL + "/discord"
+	conf.NotifyOnSuccess = true
+	conf.WebhookTimeout = 1
+	t.Cleanup(func() {
+		server.Close()
+		*conf = original
+	})
+	return &received
+}
+
+func TestBuildFinished(t *testing.T) {
+	var test = []struct {
+		summary  Summary
+		expected string
+	}{
+		{
+			summary:  Summary{TestNetID: "1", Blockchain: "geth", Nodes: 3, Duration: 90 * time.Second},
+			expected: ":white_check_mark: Build 1 of geth with 3 nodes finished in 1m30s",
+		},
+		{
+			summary:  Summary{TestNetID: "2", Blockchain: "geth", Nodes: 3, Duration: time.Second, Error: errors.New("oops")},
+			expected: ":x: Build 2 of geth with 3 nodes failed after 1s: oops",
+		},
+	}
+
+	for _, tt := range test {
+		t.Run(tt.summary.TestNetID, func(t *testing.T) {
+			received := withChat(t, 200)
+			BuildFinished(tt.summary)
+			if len(*received) != 2 {
+				t.Fatalf("expected a payload for slack and one for discord, got %d", len(*received))
+			}
+			if (*received)[0]["text"] != tt.expected {
+				t.Errorf("slack got \"%s\", expected \"%s\"", (*received)[0]["text"], tt.expected)
+			}
+			if (*received)[1]["content"] != tt.expected {
+				t.Errorf("discord got \"%s\", expected \"%s\"", (*received)[1]["content"], tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildFinished_notifyOnSuccess(t *testing.T) {
+	received := withChat(t, 200)
+	conf.NotifyOnSuccess = false
+	BuildFinished(Summary{TestNetID: "1"})
+	if len(*received) != 0 {
+		t.Errorf("a successful build was reported with notifyOnSuccess unset")
+	}
+}
+
+func TestPost(t *testing.T) {
+	withChat(t, 500)
+	err := post(conf.SlackWebhook, map[string]string{"text": "hi"})
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("expected an error for the status code, got %v", err)
+	}
+}
+
+func TestPost_timeout(t *testing.T) {
+	withChat(t, 200)
+	release := make(chan struct{})
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer stalled.Close()
+	defer close(release)
+	start := time.Now()
+	err := post(stalled.URL, map[string]string{"text": "hi"})
+	if err == nil {
+		t.Error("expected an error from a stalled endpoint")
+	}
+	if time.Since(start) >= 2*time.Second {
+		t.Errorf("the post waited %v for the stalled endpoint", time.Since(start))
+	}
+}
@@ -0,0 +1,197 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+// Package preflight checks the command templates and resource files of the blockchain builders for
+// mistakes, so that they surface before any command is run on a server.
+package preflight
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/resources"
+	"github.com/whiteblock/genesis/util"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var conf = util.GetConfig()
+
+var portPattern = regexp.MustCompile(`(?i)(?:port[= ]|:)([0-9]+)`)
+
+// verb is a formatting verb of a command template, such as %03d
+type verb struct {
+	char  byte
+	zero  bool
+	width int
+}
+
+// parseVerbs gets the verbs of the given fmt format, skipping %%
+func parseVerbs(format string) ([]verb, error) {
+	out := []verb{}
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		v := verb{}
+		for ; i < len(format) && strings.IndexByte("+-# 0", format[i]) != -1; i++ {
+			v.zero = v.zero || format[i] == '0'
+		}
+		start := i
+		for ; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+		}
+		if i > start {
+			v.width, _ = strconv.Atoi(format[start:i])
+		}
+		if i < len(format) && format[i] == '.' {
+			for i++; i < len(format) && format[i] >= '0' && format[i] <= '9'; i++ {
+			}
+		}
+		if i >= len(format) {
+			return nil, fmt.Errorf("incomplete verb at the end of the template")
+		}
+		v.char = format[i]
+		if v.char == '%' {
+			continue
+		}
+		if strings.IndexByte("sdvqx", v.char) == -1 {
+			return nil, fmt.Errorf("unsupported verb %%%c", v.char)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// CheckQuotes checks that the quotes of the given shell command are balanced. Within single quotes
+// everything is literal, elsewhere a backslash escapes the next character.
+func CheckQuotes(cmd string) error {
+	var quote byte
+	for i := 0; i < len(cmd); i++ {
+		c := cmd[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			if i == len(cmd)-1 {
+				return fmt.Errorf("dangling backslash at the end of the command")
+			}
+			i++
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		}
+	}
+	if quote != 0 {
+		return fmt.Errorf("unbalanced %c quote", quote)
+	}
+	return nil
+}
+
+// CheckCommand checks that the given command template is well formed. It is rendered with the absolute
+// number of the last of maxNodes nodes for its integer verbs, which must fit within the width given to
+// them and within any port they are a part of, and the quotes of the result must be balanced.
+func CheckCommand(template string, maxNodes int) error {
+	verbs, err := parseVerbs(template)
+	if err != nil {
+		return err
+	}
+	last := maxNodes - 1
+	if last < 0 {
+		last = 0
+	}
+	args := make([]interface{}, len(verbs))
+	for i, v := range verbs {
+		if v.char != 'd' && v.char != 'x' {
+			args[i] = "x"
+			continue
+		}
+		if v.zero && v.width > 0 && len(strconv.Itoa(last)) > v.width {
+			return fmt.Errorf("absolute number %d overflows the width of %%0%dd", last, v.width)
+		}
+		args[i] = last
+	}
+	cmd := fmt.Sprintf(template, args...)
+	for _, match := range portPattern.FindAllStringSubmatch(cmd, -1) {
+		port, err := strconv.Atoi(match[1])
+		if err == nil && port > 65535 {
+			return fmt.Errorf("port %d is out of range with absolute number %d", port, last)
+		}
+	}
+	return CheckQuotes(cmd)
+}
+
+// CheckResources checks that each of the given files exists in the resources of the blockchain,
+// and that those which are templates parse
+func CheckResources(blockchain string, files []string) error {
+	for _, file := range files {
+		if strings.Contains(file, "..") {
+			return fmt.Errorf("invalid resource \"%s\": cannot contain \"..\"", file)
+		}
+		text, err := resources.ReadFile(blockchain, file)
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(file, ".tmpl") {
+			continue
+		}
+		_, err = util.ParseTemplate(file, string(text))
+		if err != nil {
+			return fmt.Errorf("invalid template \"%s\": %s", file, err.Error())
+		}
+	}
+	return nil
+}
+
+// Check checks the registered command templates and resource files of the given blockchain
+func Check(blockchain string) error {
+	for i, cmd := range registrar.GetCommands(blockchain) {
+		err := CheckCommand(cmd, conf.MaxNodes)
+		if err != nil {
+			return fmt.Errorf("%s. For command %d of %s", err.Error(), i, blockchain)
+		}
+	}
+	err := CheckResources(blockchain, registrar.GetResources(blockchain))
+	if err != nil {
+		return fmt.Errorf("%s. For %s", err.Error(), blockchain)
+	}
+	return nil
+}
+
+// CheckAll checks every supported blockchain, logging the problems found, and gets the
+// blockchains which have a problem
+func CheckAll() map[string]error {
+	blockchains := registrar.GetSupportedBlockchains()
+	sort.Strings(blockchains)
+	out := map[string]error{}
+	for _, blockchain := range blockchains {
+		err := Check(blockchain)
+		if err != nil {
+			log.WithFields(log.Fields{"blockchain": blockchain, "error": err}).Warn(
+				"blockchain failed the preflight checks")
+			out[blockchain] = err
+		}
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package preflight
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestCheckQuotes(t *testing.T) {
+	var test = []struct {
+		cmd   string
+		valid bool
+	}{
+		{cmd: "tendermint init", valid: true},
+		{cmd: `bash -c 'echo "x" > /geth/pk0'`, valid: true},
+		{cmd: `bash -c 'echo \"x\" > /geth/pk0'`, valid: true},
+		{cmd: `echo "it's"`, valid: true},
+		{cmd: `echo \'`, valid: true},
+		{cmd: `bash -c 'echo x`, valid: false},
+		{cmd: `echo "x`, valid: false},
+		{cmd: `bash -c 'echo 'x'' '`, valid: false},
+		{cmd: `echo x\`, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := CheckQuotes(tt.cmd)
+			if (err == nil) != tt.valid {
+				t.Errorf("unexpected result of CheckQuotes for \"%s\": %v", tt.cmd, err)
+			}
+		})
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	var test = []struct {
+		template string
+		maxNodes int
+		valid    bool
+	}{
+		{template: "tendermint node --proxy_app=kvstore --p2p.persistent_peers=%s", maxNodes: 100, valid: true},
+		{template: `bash -c 'echo "%s" > /geth/pk%d'`, maxNodes: 100, valid: true},
+		{template: "echo 100%% done", maxNodes: 100, valid: true},
+		{template: "hostname node%02d", maxNodes: 100, valid: true},
+		{template: "hostname node%02d", maxNodes: 101, valid: false},
+		{template: "geth --port 300%d", maxNodes: 100, valid: true},
+		{template: "geth --port 300%d", maxNodes: 1000, valid: false},
+		{template: "curl http://%s:855%d", maxNodes: 1000, valid: false},
+		{template: `bash -c 'echo "%s" > /geth/pk%d`, maxNodes: 100, valid: false},
+		{template: "echo %y", maxNodes: 100, valid: false},
+		{template: "echo %", maxNodes: 100, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := CheckCommand(tt.template, tt.maxNodes)
+			if (err == nil) != tt.valid {
+				t.Errorf("unexpected result of CheckCommand for \"%s\": %v", tt.template, err)
+			}
+		})
+	}
+}
+
+func TestCheckResources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preflight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	oldDir := conf.ResourceDir
+	conf.ResourceDir = dir
+	defer func() { conf.ResourceDir = oldDir }()
+
+	err = os.MkdirAll(filepath.Join(dir, "geth"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "geth", "genesis.json"), []byte("{}"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "geth", "genesis.json.tmpl"), []byte(`{"chainId": {{.Params.chainId}}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "geth", "config.toml.tmpl"), []byte(`port = {{.Node`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var test = []struct {
+		blockchain string
+		files      []string
+		valid      bool
+	}{
+		{blockchain: "geth", files: nil, valid: true},
+		{blockchain: "geth", files: []string{"genesis.json"}, valid: true},
+		{blockchain: "geth", files: []string{"genesis.json", "missing.json"}, valid: false},
+		{blockchain: "parity", files: []string{"genesis.json"}, valid: false},
+		{blockchain: "geth", files: []string{"../geth/genesis.json"}, valid: false},
+		{blockchain: "geth", files: []string{"genesis.json.tmpl"}, valid: true},
+		{blockchain: "geth", files: []string{"config.toml.tmpl"}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := CheckResources(tt.blockchain, tt.files)
+			if (err == nil) != tt.valid {
+				t.Errorf("unexpected result of CheckResources: %v", err)
+			}
+		})
+	}
+}
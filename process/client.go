@@ -0,0 +1,157 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package process
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"strings"
+)
+
+// client runs the commands for the nodes which run as processes on a server. The commands for the server
+// itself go through the ssh client of the server, while the commands for the nodes are run within them
+// with Enter.
+type client struct {
+	ssh.Client
+	cfg      Config
+	serverID int
+}
+
+// NewClient creates a client for the nodes which run as processes on a server, wrapping the given
+// client of that server
+func NewClient(cfg Config, serverID int, server ssh.Client) ssh.Client {
+	return &client{Client: server, cfg: cfg, serverID: serverID}
+}
+
+// exec gives the command line to execute command in the given node
+func (c *client) exec(node ssh.Node, command string) string {
+	return Enter(c.cfg, node.GetNodeName()) + " " + command
+}
+
+// execd gives the command line to start command in the background in the given node
+func (c *client) execd(node ssh.Node, command string) string {
+	return fmt.Sprintf("nohup %s > /dev/null 2>&1 < /dev/null &", c.exec(node, command))
+}
+
+// DockerExec executes a command in the node
+func (c *client) DockerExec(node ssh.Node, command string) (string, error) {
+	return c.Run(c.exec(node, command))
+}
+
+// DockerCp copies a file on the server from source to dest in the node
+func (c *client) DockerCp(node ssh.Node, source string, dest string) error {
+	_, err := c.Run(fmt.Sprintf("sudo -n cp -r %s %s%s", source, c.cfg.RootDir(node.GetNodeName()), dest))
+	return util.LogError(err)
+}
+
+// DockerFetch writes the contents of the file at source in the given node to dest. As the files of the
+// node belong to root, the file is read within the node rather than streamed from the server.
+func (c *client) DockerFetch(node ssh.Node, source string, dest io.Writer) error {
+	res, err := c.DockerExec(node, "cat "+quote(source))
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = io.WriteString(dest, res)
+	return util.LogError(err)
+}
+
+// KeepTryDockerExec is like KeepTryRun for nodes
+func (c *client) KeepTryDockerExec(node ssh.Node, command string) (string, error) {
+	return c.KeepTryRun(c.exec(node, command))
+}
+
+// KeepTryDockerExecAll is like KeepTryDockerExec, but executes each of the given commands in order
+func (c *client) KeepTryDockerExecAll(node ssh.Node, commands ...string) ([]string, error) {
+	out := []string{}
+	for _, command := range commands {
+		res, err := c.KeepTryDockerExec(node, command)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+// DockerExecd starts the given command in the background in the node
+func (c *client) DockerExecd(node ssh.Node, command string) (string, error) {
+	return c.Run(c.execd(node, command))
+}
+
+// DockerExecdit is DockerExecd, as there is no tty to attach to
+func (c *client) DockerExecdit(node ssh.Node, command string) (string, error) {
+	return c.DockerExecd(node, command)
+}
+
+// DockerRunMainDaemon starts the main daemon process of the node
+func (c *client) DockerRunMainDaemon(node ssh.Node, command string) error {
+	bs := state.GetBuildStateByServerID(c.serverID)
+	bs.Set(fmt.Sprintf("%d", node.GetAbsoluteNumber()), util.Command{Cmdline: command, ServerID: c.serverID,
+		Node: node.GetRelativeNumber()})
+	return c.DockerExecdLog(node, command)
+}
+
+// DockerExecdLog starts the given command in the background in the node, storing its output in the logs
+func (c *client) DockerExecdLog(node ssh.Node, command string) error {
+	_, err := c.DockerExecd(node, fmt.Sprintf("bash -c %s", quote(command+" 2>&1 > "+conf.DockerOutputFile)))
+	return util.LogError(err)
+}
+
+// DockerExecdLogAppend is DockerExecdLog, but appends to the existing logs
+func (c *client) DockerExecdLogAppend(node ssh.Node, command string) error {
+	_, err := c.DockerExecd(node, fmt.Sprintf("bash -c %s", quote(command+" 2>&1 >> "+conf.DockerOutputFile)))
+	return util.LogError(err)
+}
+
+// DockerRead reads a file in the node, if lines > -1 then only the last `lines` lines are read
+func (c *client) DockerRead(node ssh.Node, file string, lines int) (string, error) {
+	if lines > -1 {
+		return c.DockerExec(node, fmt.Sprintf("tail -n %d %s", lines, file))
+	}
+	return c.DockerExec(node, fmt.Sprintf("cat %s", file))
+}
+
+func (c *client) dockerMultiExec(node ssh.Node, commands []string, kt bool) (string, error) {
+	merged := []string{}
+	for _, command := range commands {
+		merged = append(merged, "("+c.execd(node, command)+")")
+	}
+	if kt {
+		return c.KeepTryRun(strings.Join(merged, "&&"))
+	}
+	return c.Run(strings.Join(merged, "&&"))
+}
+
+// DockerMultiExec starts each of the given commands in the background in the node
+func (c *client) DockerMultiExec(node ssh.Node, commands []string) (string, error) {
+	return c.dockerMultiExec(node, commands, false)
+}
+
+// KTDockerMultiExec is DockerMultiExec, attempting the commands up to maxRunAttempts times
+func (c *client) KTDockerMultiExec(node ssh.Node, commands []string) (string, error) {
+	return c.dockerMultiExec(node, commands, true)
+}
+
+// Shell is not supported for the nodes which run as processes
+func (c *client) Shell(node ssh.Node, cols int, rows int) (ssh.Shell, error) {
+	return nil, fmt.Errorf("interactive shells are not supported for nodes which run as processes")
+}
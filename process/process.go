@@ -0,0 +1,276 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package process provides a lightweight alternative to running the nodes as containers, which runs the
+// binaries of each node directly on the server, chrooted into the filesystem of its image, in a network
+// namespace and a cgroup of its own. This avoids most of the per node overhead of the container runtime,
+// at the cost of the isolation and the features which come with it.
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"sort"
+	"strings"
+)
+
+var conf = util.GetConfig()
+
+const (
+	// DefaultDir is the directory on the servers the images and the nodes are unpacked into, when it is not given
+	DefaultDir = "/var/lib/genesis/processes"
+	// cgroupRoot is the cgroup v2 hierarchy the cgroups of the nodes are created under
+	cgroupRoot = "/sys/fs/cgroup/genesis"
+	// envFile is the file in the root of a node holding its environment, one variable per line
+	envFile = "/etc/genesis.env"
+	// cpuPeriod is the period in microseconds the cpu limits of the nodes are enforced over
+	cpuPeriod = 100000
+)
+
+// Config is the process configuration of a deployment, given in the extras of the deployment
+// details under "process"
+type Config struct {
+	// Enabled is whether or not to run the nodes of the testnet as processes
+	Enabled bool `json:"enabled"`
+	// Dir is the directory on the servers the images and the nodes are unpacked into, defaults to DefaultDir
+	Dir string `json:"dir"`
+}
+
+// GetConfig gets the process configuration from the given deployment details
+func GetConfig(details *db.DeploymentDetails) (Config, error) {
+	out := Config{Dir: DefaultDir}
+	if details == nil {
+		return out, nil
+	}
+	raw, ok := details.Extras["process"]
+	if !ok {
+		return out, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	err = json.Unmarshal(data, &out)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	if len(out.Dir) == 0 {
+		out.Dir = DefaultDir
+	}
+	if !strings.HasPrefix(out.Dir, "/") {
+		return out, fmt.Errorf("the process dir must be an absolute path, not \"%s\"", out.Dir)
+	}
+	return out, nil
+}
+
+// quote quotes s for the shell
+func quote(s string) string {
+	return util.ShellQuote(s)
+}
+
+// asRoot gives the command line to run the given script as root on the server
+func asRoot(script string) string {
+	return "sudo -n sh -c " + quote(script)
+}
+
+// imageDir gets the directory the filesystem of the given image is unpacked into
+func (cfg Config) imageDir(image string) string {
+	return cfg.Dir + "/images/" + strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(image)
+}
+
+// nodeDir gets the directory of the node with the given name
+func (cfg Config) nodeDir(name string) string {
+	return cfg.Dir + "/nodes/" + name
+}
+
+// RootDir gets the directory the node with the given name is chrooted into, which holds its filesystem
+func (cfg Config) RootDir(name string) string {
+	return cfg.nodeDir(name) + "/root"
+}
+
+// cgroup gets the cgroup of the node with the given name
+func cgroup(name string) string {
+	return cgroupRoot + "/" + name
+}
+
+// Node is what is needed to provision a node
+type Node struct {
+	// Name is the name of the node, which is also the name of its network namespace and its cgroup
+	Name string
+	// Image is the image the filesystem of the node comes from
+	Image string
+	// IP is the ip address of the node
+	IP string
+	// Gateway is the address of the bridge the node is connected to
+	Gateway string
+	// Bridge is the name of the bridge the node is connected to
+	Bridge string
+	// Veth is the name of the server end of the veth pair connecting the node to its bridge
+	Veth string
+	// Resources are the resources of the node, only the cpus and the memory are supported
+	Resources util.Resources
+	// Env is the environment of the node, on top of the environment of its image
+	Env map[string]string
+}
+
+// PrepareImage gives the command line which unpacks the filesystem of the image on the server, along with
+// its environment, unless this was already done. The image is pulled with the given container cli if needed.
+func PrepareImage(cfg Config, cli string, image string) string {
+	dir := cfg.imageDir(image)
+	return strings.Join([]string{
+		"if ! sudo -n test -d " + dir + "; then",
+		"tmp=" + dir + ".$$",
+		fmt.Sprintf("id=$(%s create %s /bin/sh) &&", cli, quote(image)),
+		fmt.Sprintf("%s export $id | sudo -n sh -c 'mkdir -p \"$1\" && tar -x -C \"$1\"' - $tmp &&", cli),
+		fmt.Sprintf("%s image inspect -f '{{range .Config.Env}}{{println .}}{{end}}' %s | "+
+			"sudo -n sh -c 'mkdir -p \"$1/etc\" && sed \"/^$/d\" > \"$1%s\"' - $tmp", cli, quote(image), envFile),
+		"res=$?",
+		fmt.Sprintf("[ -z \"$id\" ] || %s rm $id > /dev/null", cli),
+		// another node of the same image may have finished first, in which case this copy is thrown away
+		"sudo -n sh -c '[ ! -d \"$1\" ] || mv -T \"$1\" \"$2\" 2>/dev/null || rm -rf \"$1\"' - $tmp " + dir,
+		"[ $res -eq 0 ]",
+		"fi",
+	}, "\n")
+}
+
+// envLines gets the lines of the environment file of a node, as the lines given for its image followed by
+// the given variables in order of name
+func envLines(env map[string]string) string {
+	names := []string{}
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := ""
+	for _, name := range names {
+		out += name + "=" + strings.Replace(env[name], "\n", " ", -1) + "\n"
+	}
+	return out
+}
+
+// limits gets the commands which set the limits of the cgroup of the node
+func limits(node Node) ([]string, error) {
+	out := []string{}
+	cg := cgroup(node.Name)
+	if !node.Resources.NoMemoryLimits() {
+		memory, err := node.Resources.GetMemory()
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, fmt.Sprintf("echo %d > %s/memory.max", memory, cg))
+	}
+	if !node.Resources.NoCPULimits() {
+		var cpus float64
+		_, err := fmt.Sscanf(node.Resources.Cpus, "%g", &cpus)
+		if err != nil || cpus <= 0 {
+			return nil, fmt.Errorf("invalid cpus \"%s\"", node.Resources.Cpus)
+		}
+		out = append(out, fmt.Sprintf("echo '%d %d' > %s/cpu.max", int64(cpus*cpuPeriod), cpuPeriod, cg))
+	}
+	return out, nil
+}
+
+// Provision gives the command line which creates the node on the server, from the filesystem of its image
+// unpacked by PrepareImage. The node is given an overlay of that filesystem as its root, its own network
+// namespace connected to the bridge of the node, and its own cgroup limited to its resources.
+func Provision(cfg Config, node Node) (string, error) {
+	if len(node.Resources.Ports) > 0 || len(node.Resources.Mounts) > 0 || len(node.Resources.Volumes) > 0 ||
+		!node.Resources.NoGPUs() {
+		return "", fmt.Errorf("only the cpus and the memory of the resources are supported for processes")
+	}
+	lim, err := limits(node)
+	if err != nil {
+		return "", err
+	}
+	dir := cfg.nodeDir(node.Name)
+	root := cfg.RootDir(node.Name)
+	image := cfg.imageDir(node.Image)
+	cg := cgroup(node.Name)
+	ns := "ip netns exec " + node.Name + " "
+	prefix := fmt.Sprintf("/%d", util.GetSubnet())
+
+	script := []string{
+		"set -e",
+		fmt.Sprintf("mkdir -p %s/upper %s/work %s", dir, dir, root),
+		fmt.Sprintf("echo %s > %s/bridge", node.Bridge, dir),
+		fmt.Sprintf("mount -t overlay overlay -o lowerdir=%s,upperdir=%s/upper,workdir=%s/work %s",
+			image, dir, dir, root),
+		fmt.Sprintf("mkdir -p %s/proc %s/dev %s/tmp %s/etc", root, root, root, root),
+		fmt.Sprintf("mount -t proc proc %s/proc", root),
+		fmt.Sprintf("mount --bind /dev %s/dev", root),
+		fmt.Sprintf("rm -f %s/etc/resolv.conf && cp /etc/resolv.conf %s/etc/resolv.conf", root, root),
+		fmt.Sprintf("printf %%s %s >> %s%s", quote(envLines(node.Env)), root, envFile),
+
+		"ip netns add " + node.Name,
+		fmt.Sprintf("ip link show %s > /dev/null 2>&1 || ip link add %s type bridge", node.Bridge, node.Bridge),
+		fmt.Sprintf("ip addr replace %s%s dev %s", node.Gateway, prefix, node.Bridge),
+		fmt.Sprintf("ip link set %s up", node.Bridge),
+		fmt.Sprintf("ip link add %s type veth peer name eth0 netns %s", node.Veth, node.Name),
+		fmt.Sprintf("ip link set %s master %s up", node.Veth, node.Bridge),
+		ns + "ip link set lo up",
+		fmt.Sprintf("%sip addr add %s%s dev eth0", ns, node.IP, prefix),
+		ns + "ip link set eth0 up",
+		fmt.Sprintf("%sip route add default via %s", ns, node.Gateway),
+		"sysctl -qw net.ipv4.ip_forward=1",
+
+		fmt.Sprintf("mkdir -p %s", cg),
+		fmt.Sprintf("echo '+cpu +memory' > %s/../cgroup.subtree_control", cgroupRoot),
+		fmt.Sprintf("echo '+cpu +memory' > %s/cgroup.subtree_control", cgroupRoot),
+	}
+	script = append(script, lim...)
+	return asRoot(strings.Join(script, "\n")), nil
+}
+
+// teardown is the script which removes the node whose name is in $n, along with all of its processes
+func teardown(cfg Config) string {
+	return strings.Join([]string{
+		fmt.Sprintf(`d=%s/nodes/$n; cg=%s/$n`, cfg.Dir, cgroupRoot),
+		`if [ -d $cg ]; then for i in 1 2 3 4 5; do pids=$(cat $cg/cgroup.procs); [ -n "$pids" ] || break; ` +
+			`kill -9 $pids 2>/dev/null; sleep 0.2; done; rmdir $cg; fi`,
+		`umount -l $d/root/dev $d/root/proc $d/root 2>/dev/null`,
+		`ip netns del $n 2>/dev/null`,
+		`[ ! -f $d/bridge ] || ip link del $(cat $d/bridge) 2>/dev/null`,
+		`rm -rf $d`,
+	}, "\n")
+}
+
+// Teardown gives the command line which removes the node with the given name from the server, killing
+// all of its processes
+func Teardown(cfg Config, name string) string {
+	return asRoot("n=" + quote(name) + "\n" + teardown(cfg) + "\ntrue")
+}
+
+// TeardownAll gives the command line which removes all of the nodes from the server, killing all of
+// their processes. The unpacked images are kept.
+func TeardownAll(cfg Config) string {
+	return asRoot(fmt.Sprintf("for n in $(ls %s/nodes 2>/dev/null | grep '^%s'); do\n%s\ndone\ntrue",
+		cfg.Dir, conf.NodePrefix, teardown(cfg)))
+}
+
+// Enter gives the start of a command line which runs a command within the node with the given name,
+// with the command and its arguments following. Like with the exec of the container runtimes, the
+// command is parsed by the shell of the server, then run from within the cgroup, the network namespace
+// and the root of the node, with the environment of the node.
+func Enter(cfg Config, name string) string {
+	script := fmt.Sprintf(`echo $$ > %s/cgroup.procs && ip=$(command -v ip) && ch=$(command -v chroot) && `+
+		`while IFS= read -r l; do export "$l"; done < %s%s && exec "$ip" netns exec %s "$ch" %s "$@"`,
+		cgroup(name), cfg.RootDir(name), envFile, name, cfg.RootDir(name))
+	return "sudo -n sh -c " + quote(script) + " " + name
+}
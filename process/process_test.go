@@ -0,0 +1,155 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package process
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+)
+
+func TestGetConfig(t *testing.T) {
+	var test = []struct {
+		details  *db.DeploymentDetails
+		expected Config
+		err      bool
+	}{
+		{
+			details:  nil,
+			expected: Config{Dir: DefaultDir},
+		},
+		{
+			details:  &db.DeploymentDetails{},
+			expected: Config{Dir: DefaultDir},
+		},
+		{
+			details: &db.DeploymentDetails{Extras: map[string]interface{}{
+				"process": map[string]interface{}{"enabled": true}}},
+			expected: Config{Enabled: true, Dir: DefaultDir},
+		},
+		{
+			details: &db.DeploymentDetails{Extras: map[string]interface{}{
+				"process": map[string]interface{}{"enabled": true, "dir": "/srv/nodes"}}},
+			expected: Config{Enabled: true, Dir: "/srv/nodes"},
+		},
+		{
+			details: &db.DeploymentDetails{Extras: map[string]interface{}{
+				"process": map[string]interface{}{"enabled": true, "dir": "nodes"}}},
+			err: true,
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			cfg, err := GetConfig(tt.details)
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(cfg, tt.expected) {
+				t.Errorf("GetConfig returned %+v, expected %+v", cfg, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	var test = []struct {
+		resources util.Resources
+		expected  []string
+		err       bool
+	}{
+		{
+			resources: util.Resources{},
+			expected:  []string{},
+		},
+		{
+			resources: util.Resources{Memory: "1GB"},
+			expected:  []string{"echo 1000000000 > " + cgroupRoot + "/node/memory.max"},
+		},
+		{
+			resources: util.Resources{Cpus: "1.5"},
+			expected:  []string{"echo '150000 100000' > " + cgroupRoot + "/node/cpu.max"},
+		},
+		{
+			resources: util.Resources{Cpus: "none"},
+			err:       true,
+		},
+		{
+			resources: util.Resources{Cpus: "-1"},
+			err:       true,
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := limits(Node{Name: "node", Resources: tt.resources})
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("limits returned %v, expected %v", out, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEnvLines(t *testing.T) {
+	out := envLines(map[string]string{"B": "two\nlines", "A": "it's 1"})
+	if out != "A=it's 1\nB=two lines\n" {
+		t.Errorf("envLines returned %q", out)
+	}
+	if envLines(nil) != "" {
+		t.Error("envLines gave lines for no variables")
+	}
+}
+
+func TestProvision(t *testing.T) {
+	cfg := Config{Dir: DefaultDir}
+	_, err := Provision(cfg, Node{Name: "node", Resources: util.Resources{Ports: []string{"8545"}}})
+	if err == nil {
+		t.Error("expected an error for the ports")
+	}
+	out, err := Provision(cfg, Node{Name: "node", Image: "org/image:tag", IP: "10.1.0.2", Gateway: "10.1.0.1",
+		Bridge: "wb_bridge0", Veth: "wb_veth0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"lowerdir=" + DefaultDir + "/images/org_image_tag,", "ip netns add node",
+		"master wb_bridge0", "ip addr add 10.1.0.2/", "default via 10.1.0.1"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("the provisioning does not contain %q", expected)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package profiling periodically takes heap and cpu profiles of genesis itself, along with a record of its memory
+// use, so that the growth of a long running server can be looked into after the fact.
+package profiling
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/util"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+const (
+	// HeapKind is the kind of the heap profiles
+	HeapKind = "heap"
+	// CPUKind is the kind of the cpu profiles
+	CPUKind = "cpu"
+
+	statsFile  = "stats.jsonl"
+	timeFormat = "20060102T150405Z"
+)
+
+var namePattern = regexp.MustCompile(`^(heap|cpu)-(\d{8}T\d{6}Z)\.pprof$`)
+
+// Profile is a profile taken by genesis of itself
+type Profile struct {
+	Name string    `json:"name"`
+	Kind string    `json:"kind"`
+	Size int64     `json:"size"`
+	Time time.Time `json:"time"`
+}
+
+// Stats is a record of the memory use of genesis, appended to stats.jsonl with each heap profile
+type Stats struct {
+	Time       time.Time `json:"time"`
+	HeapAlloc  uint64    `json:"heapAlloc"`
+	HeapInuse  uint64    `json:"heapInuse"`
+	Sys        uint64    `json:"sys"`
+	NumGC      uint32    `json:"numGC"`
+	Goroutines int       `json:"goroutines"`
+}
+
+// Dir gets the directory the profiles are written to
+func Dir() string {
+	if len(conf.ProfileDir) > 0 {
+		return conf.ProfileDir
+	}
+	return filepath.Join(conf.DataDirectory, "profiles")
+}
+
+// Start takes profiles every profileInterval seconds in the background, doing nothing if it is 0
+func Start() {
+	if conf.ProfileInterval <= 0 {
+		return
+	}
+	log.WithFields(log.Fields{"interval": conf.ProfileInterval, "dir": Dir()}).Info("taking periodic profiles")
+	go func() {
+		for {
+			time.Sleep(time.Duration(conf.ProfileInterval) * time.Second)
+			take()
+		}
+	}()
+}
+
+func take() {
+	err := os.MkdirAll(Dir(), 0750)
+	if err != nil {
+		log.WithFields(log.Fields{"dir": Dir(), "error": err}).Error("failed to create the profile directory")
+		return
+	}
+	now := time.Now().UTC()
+	err = writeHeap(now)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("failed to take a heap profile")
+	}
+	err = writeStats(now)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("failed to record the memory use")
+	}
+	err = writeCPU(now, time.Duration(conf.ProfileDuration)*time.Second)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Warn("failed to take a cpu profile")
+	}
+	err = prune(Dir(), conf.ProfileKeep)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("failed to remove old profiles")
+	}
+}
+
+func fileName(kind string, t time.Time) string {
+	return fmt.Sprintf("%s-%s.pprof", kind, t.UTC().Format(timeFormat))
+}
+
+func writeHeap(t time.Time) error {
+	f, err := os.Create(filepath.Join(Dir(), fileName(HeapKind, t)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return pprof.Lookup("heap").WriteTo(f, 0)
+}
+
+// writeCPU profiles the cpu for the given duration. It fails if a cpu profile is already being taken, such as
+// through /debug/pprof/profile, in which case that period is skipped.
+func writeCPU(t time.Time, duration time.Duration) error {
+	if duration <= 0 {
+		return nil
+	}
+	name := filepath.Join(Dir(), fileName(CPUKind, t))
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = pprof.StartCPUProfile(f)
+	if err != nil {
+		os.Remove(name)
+		return err
+	}
+	time.Sleep(duration)
+	pprof.StopCPUProfile()
+	return nil
+}
+
+func readStats(t time.Time) Stats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return Stats{Time: t, HeapAlloc: mem.HeapAlloc, HeapInuse: mem.HeapInuse, Sys: mem.Sys, NumGC: mem.NumGC,
+		Goroutines: runtime.NumGoroutine()}
+}
+
+func writeStats(t time.Time) error {
+	data, err := json.Marshal(readStats(t))
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(Dir(), statsFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// parseName gets the kind and time of a profile from its name
+func parseName(name string) (string, time.Time, error) {
+	match := namePattern.FindStringSubmatch(name)
+	if match == nil {
+		return "", time.Time{}, fmt.Errorf("\"%s\" is not the name of a profile", name)
+	}
+	t, err := time.Parse(timeFormat, match[2])
+	return match[1], t, err
+}
+
+func list(dir string) ([]Profile, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []Profile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []Profile{}
+	for _, file := range files {
+		kind, t, err := parseName(file.Name())
+		if err != nil || file.IsDir() {
+			continue
+		}
+		out = append(out, Profile{Name: file.Name(), Kind: kind, Size: file.Size(), Time: t})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Time.Equal(out[j].Time) {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Time.Before(out[j].Time)
+	})
+	return out, nil
+}
+
+// prune removes all but the latest keep profiles of each kind
+func prune(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	profiles, err := list(dir)
+	if err != nil {
+		return err
+	}
+	kept := map[string]int{}
+	for i := len(profiles) - 1; i >= 0; i-- {
+		kept[profiles[i].Kind]++
+		if kept[profiles[i].Kind] <= keep {
+			continue
+		}
+		err = os.Remove(filepath.Join(dir, profiles[i].Name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List gets the periodic profiles which have been kept, oldest first
+func List() ([]Profile, error) {
+	return list(Dir())
+}
+
+// Path gets the path of the profile or of stats.jsonl with the given name, checking that it exists
+func Path(name string) (string, error) {
+	if name != statsFile {
+		_, _, err := parseName(name)
+		if err != nil {
+			return "", err
+		}
+	}
+	path := filepath.Join(Dir(), name)
+	_, err := os.Stat(path)
+	return path, err
+}
@@ -0,0 +1,118 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package profiling
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseName(t *testing.T) {
+	var test = []struct {
+		name  string
+		kind  string
+		time  time.Time
+		valid bool
+	}{
+		{name: "heap-20191004T101112Z.pprof", kind: HeapKind, time: time.Date(2019, 10, 4, 10, 11, 12, 0, time.UTC), valid: true},
+		{name: "cpu-20191004T101112Z.pprof", kind: CPUKind, time: time.Date(2019, 10, 4, 10, 11, 12, 0, time.UTC), valid: true},
+		{name: "goroutine-20191004T101112Z.pprof", valid: false},
+		{name: "heap-20191004T101112Z.pprof.tmp", valid: false},
+		{name: "../heap-20191004T101112Z.pprof", valid: false},
+		{name: "stats.jsonl", valid: false},
+		{name: "", valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			kind, tm, err := parseName(tt.name)
+			if (err == nil) != tt.valid {
+				t.Fatalf("parseName(%q) returned %v", tt.name, err)
+			}
+			if !tt.valid {
+				return
+			}
+			if kind != tt.kind || !tm.Equal(tt.time) {
+				t.Errorf("parseName(%q) returned %s, %v", tt.name, kind, tm)
+			}
+			if fileName(kind, tm) != tt.name {
+				t.Errorf("fileName(%s, %v) returned %s", kind, tm, fileName(kind, tm))
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiling")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2019, 10, 4, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		for _, kind := range []string{HeapKind, CPUKind} {
+			err = ioutil.WriteFile(filepath.Join(dir, fileName(kind, start.Add(time.Duration(i)*time.Hour))), nil, 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, statsFile), nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = prune(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := list(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+	}
+	expected := []string{"cpu-20191004T120000Z.pprof", "heap-20191004T120000Z.pprof",
+		"cpu-20191004T130000Z.pprof", "heap-20191004T130000Z.pprof"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("prune kept %v, expected %v", names, expected)
+	}
+	_, err = os.Stat(filepath.Join(dir, statsFile))
+	if err != nil {
+		t.Errorf("prune removed %s: %v", statsFile, err)
+	}
+}
+
+func TestListMissingDir(t *testing.T) {
+	profiles, err := list(filepath.Join(os.TempDir(), "genesis-profiling-missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 0 {
+		t.Errorf("list returned %v for a missing directory", profiles)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/clients"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/protocols/helpers"
 	"github.com/whiteblock/genesis/protocols/registrar"
@@ -33,6 +34,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 )
 
 var conf = util.GetConfig()
@@ -54,6 +56,7 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json", "config.xml.mustache", "genesis.json.mustache")
 }
 
 // build builds out a fresh new artemis test network
@@ -408,21 +411,19 @@ func createAccount(client ssh.Client, node ssh.Node) (string, error) {
 // works but need to wait for some time before it actually works. Need to figure out what the reason for the needed delay is
 func unlockAllAccounts(tn *testnet.TestNet, accounts []aionAcc) error {
 	return helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		rpc := clients.NewJSONRPC(client, node.GetIP(), 8545)
 		wg := sync.WaitGroup{}
 		for _, acc := range accounts {
 			wg.Add(1)
 			go func(account aionAcc) {
 				defer wg.Done()
 				for {
-					_, err := client.Run(
-						fmt.Sprintf(
-							`curl -sS -X POST http://%s:8545 -H "Content-Type: application/json"  -d `+
-								`'{ "method": "personal_unlockAccount", "params": ["%s","%s",0], "id": 3, "jsonrpc": "2.0" }'`,
-							node.GetIP(), account.Address, password))
+					err := rpc.Call("personal_unlockAccount", []interface{}{account.Address, password, 0}, nil)
 					//pass = !(strings.Contains(out, ":true"))
-					if err == nil {
+					if _, answered := err.(clients.RPCError); err == nil || answered {
 						break
 					}
+					time.Sleep(time.Second)
 				}
 			}(acc)
 		}
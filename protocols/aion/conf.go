@@ -19,8 +19,6 @@
 package aion
 
 import (
-	"encoding/json"
-	"fmt"
 	"github.com/whiteblock/genesis/protocols/helpers"
 	"github.com/whiteblock/genesis/protocols/services"
 )
@@ -34,7 +32,7 @@ type AConf struct {
 	OracleEnabled  bool   `xml:"oracleEnabled" json:"oracleEnabled"`
 	BlocksQueueMax int64  `xml:"blocksQueueMax"` //TODO continue adding JSON tags
 	ShowStatus     bool   `xml:"showStatus"`
-	ShowStatistics bool   `xml:"showStatistics"`
+	ShowStatistics string `xml:"showStatistics"`
 	CompactEnabled bool   `xml:"compactEnabled"`
 	SlowImport     int64  `xml:"slowImport"`
 	Frequency      int64  `xml:"frequency"`
@@ -50,7 +48,7 @@ type AConf struct {
 	DBCompression  bool   `xml:"dbCompression"`
 	LogFile        bool   `xml:"logFile"`
 	LogPath        string `xml:"logPath"`
-	GenLogs        string `xml:"genLogs`
+	GenLogs        string `xml:"genLogs"`
 	VMLogs         string `xml:"vmLogs"`
 	APILogs        string `xml:"apiLogs"`
 	SyncLogs       string `xml:"syncLogs"`
@@ -73,23 +71,7 @@ type AConf struct {
  */
 func newConf(data map[string]interface{}) (*AConf, error) {
 	out := new(AConf)
-	err := helpers.HandleBlockchainConfig(blockchain, data, out)
-	if err != nil || data == nil {
-		return out, err
-	}
-
-	initBalance, exists := data["initBalance"]
-	if exists && initBalance != nil {
-		switch initBalance.(type) {
-		case json.Number:
-			out.InitBalance = initBalance.(json.Number).String()
-		case string:
-			out.InitBalance = initBalance.(string)
-		default:
-			return nil, fmt.Errorf("incorrect type for initBalance given")
-		}
-	}
-	return out, nil
+	return out, helpers.HandleBlockchainConfig(blockchain, data, out)
 }
 
 //NewAionConf creates the configuration for aion
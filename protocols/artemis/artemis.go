@@ -43,6 +43,7 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json", "artemis-config.toml.mustache")
 	registrar.RegisterAdditionalLogs(blockchain, map[string]string{
 		"json": "/artemis/data/log.json"})
 }
@@ -59,6 +60,7 @@ func build(tn *testnet.TestNet) error {
 		res, err := util.HTTPRequest("GET", aconf["constantsSource"].(string), "")
 		if err != nil {
 			tn.BuildState.ReportError(err)
+			close(fetchedConfChan)
 			return
 		}
 		fetchedConfChan <- string(res)
@@ -90,7 +92,10 @@ func build(tn *testnet.TestNet) error {
 
 	tn.BuildState.SetBuildStage("Creating node configuration files")
 	/**Create node config files**/
-	fetchedConf := <-fetchedConfChan
+	fetchedConf, ok := <-fetchedConfChan
+	if !ok {
+		return tn.BuildState.GetError()
+	}
 
 	constantsIndex := strings.Index(fetchedConf, "[constants]")
 	if constantsIndex == -1 {
@@ -152,6 +157,7 @@ func add(tn *testnet.TestNet) error {
 		res, err := util.HTTPRequest("GET", aconf["constantsSource"].(string), "")
 		if err != nil {
 			tn.BuildState.ReportError(err)
+			close(fetchedConfChan)
 			return
 		}
 		fetchedConfChan <- string(res)
@@ -202,7 +208,10 @@ func add(tn *testnet.TestNet) error {
 
 	tn.BuildState.SetBuildStage("Creating node configuration files")
 	/**Create node config files**/
-	fetchedConf := <-fetchedConfChan
+	fetchedConf, ok := <-fetchedConfChan
+	if !ok {
+		return tn.BuildState.GetError()
+	}
 
 	constantsIndex := strings.Index(fetchedConf, "[constants]")
 	if constantsIndex == -1 {
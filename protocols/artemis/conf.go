@@ -26,7 +26,6 @@ import (
 	"github.com/whiteblock/genesis/protocols/services"
 	"github.com/whiteblock/genesis/util"
 	"github.com/whiteblock/mustache"
-	"reflect"
 )
 
 type artemisConf map[string]interface{}
@@ -39,18 +38,24 @@ func newConf(data map[string]interface{}) (artemisConf, error) {
 	if err != nil {
 		return nil, util.LogError(err)
 	}
-	finalData := util.MergeStringMaps(defaults, data)
-	var val int64
-	err = util.GetJSONInt64(finalData, "validators", &val) //Check provided validators
-	if err == nil {
-		if val < 4 || val%2 != 0 {
-			return nil, fmt.Errorf("invalid number of validators (%d): must be an even number and greater than 3", val)
-		}
+	out := artemisConf(util.MergeStringMaps(defaults, data))
+	val, err := out.validators()
+	if err != nil {
+		return nil, err
+	}
+	if val < 4 || val%2 != 0 {
+		return nil, fmt.Errorf("invalid number of validators (%d): must be an even number and greater than 3", val)
 	}
-	out := new(artemisConf)
-	*out = artemisConf(finalData)
+	return out, nil
+}
 
-	return *out, nil
+// validators gets the number of validators in the configuration
+func (aconf artemisConf) validators() (int64, error) {
+	var out struct {
+		Validators int64 `json:"validators" param:"required"`
+	}
+	err := util.DecodeParams(aconf, &out)
+	return out.Validators, err
 }
 
 // GetServices returns the services which are used by artemis
@@ -70,38 +75,24 @@ func makeNodeConfig(aconf artemisConf, identity string, peers string, node int,
 	filler := util.ConvertToStringMap(artConf)
 	filler["peers"] = peers
 	filler["numNodes"] = fmt.Sprintf("%d", details.Nodes)
-	var outputFile string
-	obj := details.Params["outputFile"]
-	if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
-		outputFile = obj.(string)
-	}
-	if outputFile == "" {
-		outputFile = "/artemis/data/log.json"
-	}
-	filler["outputFile"] = outputFile
-	var providerType string
-	obj = details.Params["providerType"]
-	if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
-		providerType = obj.(string)
+	var params struct {
+		OutputFile  string `json:"outputFile" param:"default=/artemis/data/log.json"`
+		Provider    string `json:"providerType" param:"default=JSON"`
+		MetricsPort string `json:"prometheusInstrumentationPort" param:"default=8088"`
 	}
-	if providerType == "" {
-		providerType = "JSON"
-	}
-	var prometheusInstrumentationPort string
-	obj = details.Params["prometheusInstrumentationPort"]
-	if obj != nil && reflect.TypeOf(obj).Kind() == reflect.String {
-		prometheusInstrumentationPort = obj.(string)
-	}
-	if prometheusInstrumentationPort == "" {
-		prometheusInstrumentationPort = "8088"
+	err = util.DecodeParams(details.Params, &params)
+	if err != nil {
+		return "", err
 	}
-
-	filler["providerType"] = providerType
-	filler["metricsPort"] = prometheusInstrumentationPort
+	filler["outputFile"] = params.OutputFile
+	filler["providerType"] = params.Provider
+	filler["metricsPort"] = params.MetricsPort
 	filler["constants"] = constantsRaw
 
-	var validators int64
-	err = util.GetJSONInt64(aconf, "validators", &validators) //Check provided validators
+	validators, err := aconf.validators()
+	if err != nil {
+		return "", err
+	}
 	filler["validators"] = fmt.Sprintf("%d", validators)
 	dat, err := helpers.GetBlockchainConfig("artemis", node, "artemis-config.toml.mustache", details)
 	if err != nil {
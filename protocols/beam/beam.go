@@ -44,6 +44,7 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json", "beam-node.cfg.mustache")
 }
 
 const port int = 10000
@@ -152,7 +153,7 @@ func build(tn *testnet.TestNet) error {
 		if err != nil {
 			return util.LogError(err)
 		}
-		return client.DockerRunMainDaemon(node, fmt.Sprintf("beam-wallet --command listen -n 0.0.0.0:%d --pass password", port))
+		return helpers.StartMainDaemon(tn, client, node, fmt.Sprintf("beam-wallet --command listen -n 0.0.0.0:%d --pass password", port))
 	})
 
 	return err
@@ -0,0 +1,200 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package conformance runs the registered blockchain builders against the simulator, so that the
+// behaviors genesis relies on from every builder can be checked the same way for each of them.
+package conformance
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/simulator"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// firstServerID is the id of the first simulated server, each build is given a server of its own so that
+// builds do not contend for the build lock
+const firstServerID = 10000
+
+var lastServerID int64 = firstServerID
+
+// Options are the options of a build run against the simulator
+type Options struct {
+	// Nodes is the number of nodes to build
+	Nodes int
+	// Seed is the seed the keys of the build are derived from
+	Seed string
+	// Params are the blockchain specific parameters of the build
+	Params map[string]interface{}
+	// Rules are the simulator rules answering the commands of the build, checked before the default rules
+	Rules []simulator.Rule
+	// Stop signals the build to stop right before the builder is called
+	Stop bool
+	// Timeout is how long the builder is given to return. Once it runs out, the build is signalled to stop and
+	// the builder is given as long again to return.
+	Timeout time.Duration
+}
+
+// Build is a build of a blockchain which was run against the simulator
+type Build struct {
+	// Blockchain is the blockchain which was built
+	Blockchain string
+	// TestNet is the testnet which was built
+	TestNet *testnet.TestNet
+	// Simulator is the simulator which was given the commands of the build
+	Simulator *simulator.Simulator
+	// ServerID is the id of the simulated server the build was run on
+	ServerID int
+	// Err is the error given by the builder
+	Err error
+	// Returned is whether the builder returned, it does not if it ignores the signal to stop the build
+	Returned bool
+	// Elapsed is how long the builder took to return
+	Elapsed time.Duration
+	// BuildProgress is the number of steps of the build progress the builder reported
+	BuildProgress uint64
+	// Workspace is the directory the files of the build were written to on the machine running genesis
+	Workspace string
+}
+
+// store stands in for the genesis database, giving the simulated server of a build
+type store struct {
+	mux    sync.Mutex
+	server db.Server
+	meta   map[string]interface{}
+}
+
+func (s *store) GetServers(ids []int) ([]db.Server, error) {
+	return []db.Server{s.server}, nil
+}
+
+func (s *store) UpdateServerArch(id int, arch string) error {
+	return nil
+}
+
+func (s *store) InsertNode(node db.Node) (int, error) {
+	return 0, nil
+}
+
+func (s *store) GetAllNodesByServer(serverID int) ([]db.Node, error) {
+	return nil, nil
+}
+
+func (s *store) SetMeta(key string, value interface{}) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.meta[key] = value
+	return nil
+}
+
+func (s *store) GetMetaP(key string, v interface{}) error {
+	return fmt.Errorf("no entry found for \"%s\"", key)
+}
+
+func (s *store) DeleteMeta(key string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	delete(s.meta, key)
+	return nil
+}
+
+// Run builds the given blockchain on a simulated server of its own, and finishes the build once the builder
+// has returned. If the builder does not return, the build is left unfinished.
+func Run(blockchain string, opts Options) (*Build, error) {
+	buildFn, err := registrar.GetBuildFunc(blockchain)
+	if err != nil {
+		return nil, err
+	}
+	sim, err := simulator.New(opts.Rules)
+	if err != nil {
+		return nil, err
+	}
+	runtime, err := util.GetRuntime(util.DockerRuntime)
+	if err != nil {
+		return nil, err
+	}
+	buildID, err := util.GetUUIDString()
+	if err != nil {
+		return nil, err
+	}
+	serverID := int(atomic.AddInt64(&lastServerID, 1))
+	err = state.AcquireBuilding([]int{serverID}, buildID)
+	if err != nil {
+		return nil, err
+	}
+	details := db.DeploymentDetails{
+		Servers:    []int{serverID},
+		Blockchain: blockchain,
+		Nodes:      opts.Nodes,
+		Images:     []string{"gcr.io/whiteblock/" + blockchain + ":master"},
+		Params:     opts.Params,
+		Seed:       opts.Seed,
+	}
+	server := db.Server{ID: serverID, Addr: "10.0.0.1", Max: opts.Nodes, SubnetID: 1}
+	tn, err := testnet.NewTestNetWithClients(details, buildID, &store{server: server, meta: map[string]interface{}{}},
+		map[int]ssh.Client{serverID: simulator.NewClient(sim, serverID, runtime)})
+	if err != nil {
+		state.ForceUnlockServers([]int{serverID})
+		return nil, err
+	}
+	out := &Build{Blockchain: blockchain, TestNet: tn, Simulator: sim, ServerID: serverID,
+		Workspace: tn.BuildState.Workspace().Dir()}
+
+	err = deploy.Build(tn, nil)
+	if err != nil {
+		tn.BuildState.ReportError(err)
+		tn.FinishedBuilding()
+		return nil, fmt.Errorf("failed to deploy the nodes: %s", err.Error())
+	}
+	if opts.Stop {
+		tn.BuildState.SignalStop()
+	}
+
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		done <- util.Safe(func() error { return buildFn(tn) })
+	}()
+	select {
+	case out.Err = <-done:
+		out.Returned = true
+	case <-time.After(opts.Timeout):
+		tn.BuildState.SignalStop()
+		select {
+		case out.Err = <-done:
+			out.Returned = true
+		case <-time.After(opts.Timeout):
+			return out, nil
+		}
+	}
+	out.Elapsed = time.Since(start)
+	out.BuildProgress = atomic.LoadUint64(&tn.BuildState.BuildProgress)
+	if out.Err != nil {
+		tn.BuildState.ReportError(out.Err)
+	}
+	tn.FinishedBuilding()
+	return out, nil
+}
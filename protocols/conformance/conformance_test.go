@@ -0,0 +1,188 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package conformance
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/whiteblock/genesis/manager" // registers every blockchain genesis is built with
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/util"
+)
+
+// nodes is enough nodes for any of the blockchains to be built
+const nodes = 3
+
+// timeout is how long a builder is given to return, before and after being signalled to stop
+const timeout = 3 * time.Second
+
+func blockchains(t *testing.T) []string {
+	util.GetConfig().DisableNibbler = true
+	out := registrar.GetSupportedBlockchains()
+	if len(out) == 0 {
+		t.Fatal("no blockchains are registered")
+	}
+	sort.Strings(out)
+	return out
+}
+
+func run(t *testing.T, blockchain string, opts Options) *Build {
+	opts.Nodes = nodes
+	opts.Timeout = timeout
+	build, err := Run(blockchain, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !build.Returned {
+		t.Fatalf("the builder did not return within %v of being signalled to stop", timeout)
+	}
+	return build
+}
+
+func TestRespectsCancellation(t *testing.T) {
+	for _, blockchain := range blockchains(t) {
+		blockchain := blockchain
+		t.Run(blockchain, func(t *testing.T) {
+			t.Parallel()
+			build := run(t, blockchain, Options{Stop: true})
+			if build.Err == nil {
+				t.Error("the builder did not fail after the build was stopped")
+			}
+			if build.Elapsed > timeout {
+				t.Errorf("the builder took %v to return after the build was stopped", build.Elapsed)
+			}
+		})
+	}
+}
+
+func TestReportsProgress(t *testing.T) {
+	for _, blockchain := range blockchains(t) {
+		blockchain := blockchain
+		t.Run(blockchain, func(t *testing.T) {
+			t.Parallel()
+			build := run(t, blockchain, Options{})
+			total := build.TestNet.BuildState.BuildTotal
+			if build.BuildProgress >= total {
+				t.Errorf("the builder reported %d steps of progress, out of the %d it set", build.BuildProgress, total-1)
+			}
+			if build.Err == nil && build.BuildProgress == 0 {
+				t.Error("the builder finished without reporting any progress")
+			}
+		})
+	}
+}
+
+func TestCleansTemporaryFiles(t *testing.T) {
+	mkdir := regexp.MustCompile(`mkdir -p (/tmp/\S+?)/?$`)
+	for _, blockchain := range blockchains(t) {
+		blockchain := blockchain
+		t.Run(blockchain, func(t *testing.T) {
+			t.Parallel()
+			build := run(t, blockchain, Options{})
+			_, err := os.Stat(build.Workspace)
+			if !os.IsNotExist(err) {
+				t.Errorf("the workspace %s was not removed", build.Workspace)
+			}
+
+			dirs := map[string]bool{}
+			for _, cmd := range build.Simulator.Commands(build.ServerID) {
+				if match := mkdir.FindStringSubmatch(cmd); match != nil {
+					dirs[match[1]] = true
+				}
+			}
+			// the temporary directories are removed by deferred functions, which run once the build is finished
+			deadline := time.Now().Add(time.Second)
+			for len(dirs) > 0 && time.Now().Before(deadline) {
+				for _, cmd := range build.Simulator.Commands(build.ServerID) {
+					for dir := range dirs {
+						if strings.HasPrefix(cmd, "rm -rf "+dir) {
+							delete(dirs, dir)
+						}
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			for dir := range dirs {
+				t.Errorf("the temporary directory %s was not removed from the server", dir)
+			}
+		})
+	}
+}
+
+func TestProducesIdempotentConfigs(t *testing.T) {
+	for _, blockchain := range blockchains(t) {
+		blockchain := blockchain
+		t.Run(blockchain, func(t *testing.T) {
+			t.Parallel()
+			if fn, err := registrar.GetDefaultsFunc(blockchain); err == nil {
+				checkStable(t, "defaults", fn)
+			}
+			if fn, err := registrar.GetParamsFunc(blockchain); err == nil {
+				checkStable(t, "params", fn)
+			}
+
+			first := copiedFiles(run(t, blockchain, Options{Seed: "conformance"}))
+			second := copiedFiles(run(t, blockchain, Options{Seed: "conformance"}))
+			for file, sum := range first {
+				if second[file] != sum {
+					t.Errorf("%s differs between two builds from the same seed", file)
+				}
+			}
+			for file := range second {
+				if _, ok := first[file]; !ok {
+					t.Errorf("%s was only copied by one of two builds from the same seed", file)
+				}
+			}
+		})
+	}
+}
+
+// checkStable checks that fn gives the same valid json each time it is called
+func checkStable(t *testing.T, name string, fn func() string) {
+	out := fn()
+	if !json.Valid([]byte(out)) {
+		t.Errorf("the %s are not valid json", name)
+	}
+	if fn() != out {
+		t.Errorf("the %s differ between calls", name)
+	}
+}
+
+// copiedFiles gets the checksums of the files which were copied into the nodes of the build, by the
+// absolute number of their node and their path
+func copiedFiles(build *Build) map[string]string {
+	numbers := map[string]string{}
+	for _, node := range build.TestNet.Nodes {
+		numbers[node.GetNodeName()] = strconv.Itoa(node.AbsoluteNum)
+	}
+	out := map[string]string{}
+	for name, files := range build.TestNet.BuildState.ExpectedFiles {
+		for file, expected := range files {
+			out[numbers[name]+":"+file] = expected.Sha256
+		}
+	}
+	return out
+}
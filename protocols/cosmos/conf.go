@@ -0,0 +1,55 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package cosmos
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/util"
+)
+
+type cosmosConf struct {
+	// PowerDistribution is how the voting power is distributed among the validators. Only the first node
+	// is a validator at genesis, so it can only be equal.
+	PowerDistribution string `json:"powerDistribution" param:"oneof=equal|linear|zipf|explicit"`
+	// BasePower is the voting power of the validator
+	BasePower int64 `json:"basePower" param:"min=1"`
+	// Powers are the voting powers of the validators, for the explicit distribution. It must be empty.
+	Powers []int64 `json:"powers"`
+}
+
+func newConf(data map[string]interface{}) (*cosmosConf, error) {
+	out := new(cosmosConf)
+	err := helpers.HandleBlockchainConfig(blockchain, data, out)
+	if err != nil {
+		return nil, err
+	}
+	// the power can't be distributed among validators which don't exist, so the params are rejected
+	// instead of being ignored
+	if out.PowerDistribution != helpers.EqualPower {
+		return nil, util.ParamError{Param: "powerDistribution", Err: fmt.Errorf(
+			"must be %s, as cosmos only has one validator at genesis, got \"%s\"", helpers.EqualPower,
+			out.PowerDistribution)}
+	}
+	if len(out.Powers) > 0 {
+		return nil, util.ParamError{Param: "powers", Err: fmt.Errorf(
+			"cannot be given, as cosmos only has one validator at genesis, whose power is the basePower")}
+	}
+	return out, nil
+}
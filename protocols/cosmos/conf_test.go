@@ -0,0 +1,47 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package cosmos
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestNewConf(t *testing.T) {
+	var test = []struct {
+		params map[string]interface{}
+		err    bool
+	}{
+		{params: map[string]interface{}{}, err: false},
+		{params: map[string]interface{}{"basePower": 50}, err: false},
+		{params: map[string]interface{}{"powerDistribution": "equal"}, err: false},
+		{params: map[string]interface{}{"powerDistribution": "zipf"}, err: true},
+		{params: map[string]interface{}{"powerDistribution": "explicit", "powers": []interface{}{5}}, err: true},
+		{params: map[string]interface{}{"powers": []interface{}{5, 1}}, err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := newConf(tt.params)
+			if (err != nil) != tt.err {
+				t.Errorf("unexpected error result for newConf(%v): %v", tt.params, err)
+			}
+		})
+	}
+}
@@ -29,12 +29,20 @@ import (
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 	"strings"
-	"sync"
 )
 
 var conf = util.GetConfig()
 
-const blockchain = "cosmos"
+const (
+	blockchain = "cosmos"
+
+	// tokensPerPower is the number of staked tokens which give a validator one unit of voting power
+	tokensPerPower = 1000000
+
+	// startCmd starts gaiad with the persistent peers of the node, serving the rpc on all interfaces
+	// so that genesis can reach it
+	startCmd = "gaiad start --p2p.persistent_peers=%s --rpc.laddr=tcp://0.0.0.0:26657"
+)
 
 func init() {
 	registrar.RegisterBuild(blockchain, build)
@@ -42,17 +50,27 @@ func init() {
 	registrar.RegisterServices(blockchain, func() []services.Service { return nil })
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json", helpers.GenesisSchemaFile)
+	registrar.RegisterCommands(blockchain, startCmd)
+	registrar.RegisterConsensusProbe(blockchain, helpers.TendermintProbe(26657))
+	registrar.RegisterBroadcast(blockchain, helpers.TendermintBroadcast(26657))
 }
 
 // build builds out a fresh new cosmos test network
 func build(tn *testnet.TestNet) error {
+	cconf, err := newConf(tn.LDD.Params)
+	if err != nil {
+		return util.LogError(err)
+	}
+	// only the first node is a validator at genesis, whose power is given by the tokens it stakes
+	stake := fmt.Sprintf("%dstake", cconf.BasePower*tokensPerPower)
 	tn.BuildState.SetBuildSteps(4 + (tn.LDD.Nodes * 2))
 
 	tn.BuildState.SetBuildStage("Setting up the first node")
 	/**
 	 * Set up first node
 	 */
-	_, err := helpers.FirstNodeExec(tn, "gaiad init --chain-id=whiteblock whiteblock")
+	_, err = helpers.FirstNodeExec(tn, "gaiad init --chain-id=whiteblock whiteblock")
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -67,13 +85,14 @@ func build(tn *testnet.TestNet) error {
 		return util.LogError(err)
 	}
 	tn.BuildState.IncrementBuildProgress()
-	_, err = helpers.FirstNodeExec(tn, fmt.Sprintf("gaiad add-genesis-account %s 100000000stake,100000000validatortoken",
-		res[:len(res)-1]))
+	_, err = helpers.FirstNodeExec(tn, fmt.Sprintf("gaiad add-genesis-account %s %s,100000000validatortoken",
+		res[:len(res)-1], stake))
 	if err != nil {
 		return util.LogError(err)
 	}
 
-	_, err = helpers.FirstNodeExec(tn, "bash -c 'echo \"password\\n\" | gaiad gentx --name validator'")
+	_, err = helpers.FirstNodeExec(tn, fmt.Sprintf(
+		"bash -c 'echo \"password\\n\" | gaiad gentx --name validator --amount %s'", stake))
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -82,14 +101,17 @@ func build(tn *testnet.TestNet) error {
 	if err != nil {
 		return util.LogError(err)
 	}
-	genesisFile, err := helpers.FirstNodeExec(tn, "cat /root/.gaiad/config/genesis.json")
+	res, err = helpers.FirstNodeExec(tn, "cat /root/.gaiad/config/genesis.json")
+	if err != nil {
+		return util.LogError(err)
+	}
+	genesisFile, err := helpers.FinalizeGenesis(tn, []byte(res))
 	if err != nil {
 		return util.LogError(err)
 	}
 	tn.BuildState.IncrementBuildProgress()
 	tn.BuildState.SetBuildStage("Initializing the rest of the nodes")
-	peers := make([]string, tn.LDD.Nodes)
-	mux := sync.Mutex{}
+	peers := helpers.NewCollector()
 
 	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
 		ip := tn.Nodes[node.GetAbsoluteNumber()].IP
@@ -107,9 +129,7 @@ func build(tn *testnet.TestNet) error {
 			return util.LogError(err)
 		}
 		nodeID := res[:len(res)-1]
-		mux.Lock()
-		peers[node.GetAbsoluteNumber()] = fmt.Sprintf("%s@%s:26656", nodeID, ip)
-		mux.Unlock()
+		peers.Set(node, fmt.Sprintf("%s@%s:26656", nodeID, ip))
 		tn.BuildState.IncrementBuildProgress()
 		return nil
 	})
@@ -120,7 +140,11 @@ func build(tn *testnet.TestNet) error {
 
 	tn.BuildState.SetBuildStage("Copying the genesis file to each node")
 
-	err = helpers.CopyBytesToAllNodes(tn, genesisFile, "/root/.gaiad/config/genesis.json")
+	err = helpers.CopyBytesToAllNodes(tn, string(genesisFile), "/root/.gaiad/config/genesis.json")
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = helpers.RunHooks(tn, db.AfterGenesisHook)
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -129,10 +153,11 @@ func build(tn *testnet.TestNet) error {
 
 	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
 		defer tn.BuildState.IncrementBuildProgress()
-		peersCpy := make([]string, len(peers))
-		copy(peersCpy, peers)
-		_, err := client.DockerExecd(node, fmt.Sprintf("gaiad start --p2p.persistent_peers=%s",
-			strings.Join(append(peersCpy[:node.GetAbsoluteNumber()], peersCpy[node.GetAbsoluteNumber()+1:]...), ",")))
+		nodePeers, err := helpers.FilterPeers(tn, node, peers.Strings())
+		if err != nil {
+			return util.LogError(err)
+		}
+		_, err = client.DockerExecd(node, fmt.Sprintf(startCmd, strings.Join(nodePeers, ",")))
 		return err
 	})
 	return err
@@ -25,7 +25,6 @@ import (
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 	"github.com/whiteblock/mustache"
-	"time"
 )
 
 type eosConf struct {
@@ -53,12 +52,12 @@ type eosConf struct {
 	MaxTransactionLifetime         int64    `json:"maxTransactionLifetime"`
 	DeferredTrxExpirationWindow    int64    `json:"deferredTrxExpirationWindow"`
 	MaxTransactionDelay            int64    `json:"maxTransactionDelay"`
-	MaxInlineActionSize            int64    `json:"maxInlineActionSize"`
+	MaxInlineActionSize            int64    `json:"maxInlineActionSize" param:"size"`
 	MaxInlineActionDepth           int64    `json:"maxInlineActionDepth"`
 	MaxAuthorityDepth              int64    `json:"maxAuthorityDepth"`
 	InitialChainID                 string   `json:"initialChainId"`
-	ChainStateDbSizeMb             int64    `json:"chainStateDbSizeMb"`
-	ReversibleBlocksDbSizeMb       int64    `json:"reversibleBlocksDbSizeMb"`
+	ChainStateDbSizeMb             int64    `json:"chainStateDbSizeMb" param:"size=mb"`
+	ReversibleBlocksDbSizeMb       int64    `json:"reversibleBlocksDbSizeMb" param:"size=mb"`
 	ContractsConsole               bool     `json:"contractsConsole"`
 	P2pMaxNodesPerHost             int64    `json:"p2pMaxNodesPerHost"`
 	AllowedConnection              string   `json:"allowedConnection"`
@@ -69,7 +68,7 @@ type eosConf struct {
 	PauseOnStartup                 bool     `json:"pauseOnStartup"`
 	MaxTransactionTime             int64    `json:"maxTransactionTime"`
 	MaxIrreversibleBlockAge        int64    `json:"maxIrreversibleBlockAge"`
-	KeosdProviderTimeout           int64    `json:"keosdProviderTimeout"`
+	KeosdProviderTimeout           int64    `json:"keosdProviderTimeout" param:"duration=ms"`
 	TxnReferenceBlockLag           int64    `json:"txnReferenceBlockLag"`
 	Plugins                        []string `json:"plugins"`
 	ConfigExtras                   []string `json:"configExtras"`
@@ -83,7 +82,7 @@ func newConf(data map[string]interface{}) (*eosConf, error) {
 func (econf *eosConf) GenerateGenesis(masterPublicKey string, tn *testnet.TestNet) (string, error) {
 
 	filler := util.ConvertToStringMap(map[string]interface{}{
-		"initialTimestamp":               time.Now().Format("2006-01-02T15-04-05.000"),
+		"initialTimestamp":               tn.Keys().Time("eos/genesis").Format("2006-01-02T15-04-05.000"),
 		"initialKey":                     masterPublicKey,
 		"maxBlockNetUsage":               econf.MaxBlockNetUsage,
 		"targetBlockNetUsagePct":         econf.TargetBlockNetUsagePct,
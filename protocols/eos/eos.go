@@ -28,7 +28,6 @@ import (
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
-	"math/rand"
 	"strings"
 	"sync"
 )
@@ -43,6 +42,7 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json", "genesis.json.mustache", "privatekeys.json", "publickeys.json")
 }
 
 // build builds out a fresh new eos test network using geth
@@ -130,12 +130,13 @@ func build(tn *testnet.TestNet) error {
 		if err != nil {
 			return util.LogError(err)
 		}
-		mux.Lock()
-		clientPasswords[node.GetIP()], err = eosCreatewallet(client, node)
-		mux.Unlock()
+		password, err := eosCreatewallet(client, node)
 		if err != nil {
 			return util.LogError(err)
 		}
+		mux.Lock()
+		clientPasswords[node.GetIP()] = password
+		mux.Unlock()
 
 		cmds := []string{}
 		for _, name := range accountNames {
@@ -197,7 +198,7 @@ func build(tn *testnet.TestNet) error {
 		return util.LogError(err)
 	}
 
-	err = masterClient.DockerRunMainDaemon(masterNode,
+	err = helpers.StartMainDaemon(tn, masterClient, masterNode,
 		fmt.Sprintf(`nodeos -e -p eosio --genesis-json /datadir/genesis.json --config-dir /datadir --data-dir /datadir %s %s`,
 			eosGetkeypairflag(keyPairs[masterIP]),
 			eosGetptpflags(tn.Nodes, 0)))
@@ -337,7 +338,7 @@ func build(tn *testnet.TestNet) error {
 			prodFlags = " -p " + eosGetproducername(node.GetAbsoluteNumber()) + " "
 		}
 
-		return client.DockerRunMainDaemon(node,
+		return helpers.StartMainDaemon(tn, client, node,
 			fmt.Sprintf(`nodeos --genesis-json /datadir/genesis.json --config-dir /datadir --data-dir /datadir %s %s %s`,
 				prodFlags,
 				eosGetkeypairflag(keyPairs[node.GetIP()]),
@@ -428,11 +429,12 @@ func build(tn *testnet.TestNet) error {
 		masterClient.DockerExec(tn.Nodes[1], fmt.Sprintf("cleos -u http://%s:8889 wallet unlock --password %s", //BUG: bad assumption
 			masterIP, passwordNormal))
 		n := 0
+		rng := tn.Keys().Rand("eos/votes")
 		for _, name := range accountNames {
 			prod := 0
 			log.WithFields(log.Fields{"name": name, "n": n}).Trace("voting in producer")
 			if n > 0 {
-				prod = rand.Intn(100) % n
+				prod = rng.Intn(100) % n
 			}
 
 			prod = (prod % (node - 1)) + 1
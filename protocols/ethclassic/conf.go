@@ -19,8 +19,6 @@
 package ethclassic
 
 import (
-	"encoding/json"
-	"fmt"
 	"github.com/whiteblock/genesis/protocols/helpers"
 )
 
@@ -55,24 +53,7 @@ type EtcConf struct {
  */
 func newConf(data map[string]interface{}) (*EtcConf, error) {
 	out := new(EtcConf)
-	err := helpers.HandleBlockchainConfig(blockchain, data, out)
-	if err != nil || data == nil {
-		return out, err
-	}
-
-	initBalance, exists := data["initBalance"]
-	if exists && initBalance != nil {
-		switch initBalance.(type) {
-		case json.Number:
-			out.InitBalance = initBalance.(json.Number).String()
-		case string:
-			out.InitBalance = initBalance.(string)
-		default:
-			return nil, fmt.Errorf("incorrect type for initBalance given")
-		}
-	}
-
-	return out, nil
+	return out, helpers.HandleBlockchainConfig(blockchain, data, out)
 }
 
 //NewEtcConf creates the configuration for etc
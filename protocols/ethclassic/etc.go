@@ -22,6 +22,7 @@ package ethclassic
 import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/clients"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/protocols/ethereum"
 	"github.com/whiteblock/genesis/protocols/helpers"
@@ -52,6 +53,12 @@ func init() {
 	registrar.RegisterServices(blockchain, func() []services.Service { return nil })
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json", "chain.json")
+	registrar.RegisterHealthCheck(blockchain, helpers.RPCHealthCheck(ethereum.RPCPort, "eth_blockNumber"))
+	registrar.RegisterConsensusProbe(blockchain, ethereum.ConsensusProbe)
+	registrar.RegisterFund(blockchain, ethereum.Fund)
+	registrar.RegisterBroadcast(blockchain, ethereum.Broadcast)
+	registrar.RegisterDataDirectory(blockchain, "/geth")
 }
 
 // build builds out a fresh new ethereum test network using geth
@@ -75,7 +82,7 @@ func build(tn *testnet.TestNet) error {
 	/**Create the wallets**/
 	tn.BuildState.SetBuildStage("Creating the wallets")
 
-	accounts, err := ethereum.GenerateAccounts(tn.LDD.Nodes + int(etcconf.ExtraAccounts))
+	accounts, err := ethereum.DeriveAccounts(tn.Keys(), "accounts", 0, tn.LDD.Nodes+int(etcconf.ExtraAccounts))
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -156,6 +163,9 @@ func build(tn *testnet.TestNet) error {
 		tn.BuildState.IncrementBuildProgress()
 		return nil
 	})
+	if err != nil {
+		return util.LogError(err)
+	}
 
 	tn.BuildState.IncrementBuildProgress()
 	tn.BuildState.SetBuildStage("Starting geth")
@@ -164,10 +174,10 @@ func build(tn *testnet.TestNet) error {
 		//Load the CustomGenesis file
 		mux.Lock()
 		_, err := client.DockerExec(node, fmt.Sprintf("mv /geth/mainnet/keystore/ /geth/%s/", etcconf.Identity))
+		mux.Unlock()
 		if err != nil {
 			return util.LogError(err)
 		}
-		mux.Unlock()
 		log.WithFields(log.Fields{"node": node.GetAbsoluteNumber()}).Trace("adding accounts to right directory")
 
 		cont, err := client.DockerExec(node,
@@ -181,6 +191,9 @@ func build(tn *testnet.TestNet) error {
 		tn.BuildState.IncrementBuildProgress()
 		return nil
 	})
+	if err != nil {
+		return util.LogError(err)
+	}
 
 	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		tn.BuildState.IncrementBuildProgress()
@@ -241,11 +254,8 @@ func peerAllNodes(tn *testnet.TestNet, enodes []string) error {
 			}
 			var err error
 			for i := 0; i < peeringRetries; i++ { //give it some extra tries
-				_, err = client.KeepTryRun(
-					fmt.Sprintf(
-						`curl -sS -X POST http://%s:8545 -H "Content-Type: application/json"  -d `+
-							`'{ "method": "admin_addPeer", "params": ["%s"], "id": 1, "jsonrpc": "2.0" }'`,
-						node.GetIP(), enode))
+				err = clients.NewJSONRPC(client, node.GetIP(), ethereum.RPCPort).KeepTryCall("admin_addPeer",
+					[]interface{}{enode}, nil)
 				if err == nil {
 					break
 				}
@@ -263,14 +273,10 @@ func peerAllNodes(tn *testnet.TestNet, enodes []string) error {
 func unlockAllAccounts(tn *testnet.TestNet, accounts []*ethereum.Account) error {
 	return helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		tn.BuildState.Defer(func() { //Can happen eventually
+			rpc := clients.NewJSONRPC(client, node.GetIP(), ethereum.RPCPort)
 			for _, account := range accounts {
-
-				client.Run( //Doesn't really need to succeed, it is a nice to have, but not required.
-					fmt.Sprintf(
-						`curl -sS -X POST http://%s:8545 -H "Content-Type: application/json"  -d `+
-							`'{ "method": "personal_unlockAccount", "params": ["%s","%s",0], "id": 3, "jsonrpc": "2.0" }'`,
-						node.GetIP(), account.HexAddress(), password))
-
+				//Doesn't really need to succeed, it is a nice to have, but not required.
+				rpc.Call("personal_unlockAccount", []interface{}{account.HexAddress(), password, 0}, nil)
 			}
 		})
 		return nil
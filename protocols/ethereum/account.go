@@ -21,11 +21,13 @@ package ethereum
 
 import (
 	"crypto/ecdsa"
+	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/whiteblock/genesis/keys"
 	"github.com/whiteblock/genesis/util"
 	"strings"
 )
@@ -96,7 +98,16 @@ func NewAccount(privKey *ecdsa.PrivateKey) *Account {
 
 // GenerateEthereumAddress generates a new, random Ethereum account
 func GenerateEthereumAddress() (*Account, error) {
-	privKey, err := crypto.GenerateKey()
+	key, err := keys.Generate(keys.Secp256k1, rand.Reader)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return NewAccountFromKey(key)
+}
+
+// NewAccountFromKey creates an account from a secp256k1 key
+func NewAccountFromKey(key keys.Key) (*Account, error) {
+	privKey, err := key.ToECDSA()
 	if err != nil {
 		return nil, util.LogError(err)
 	}
@@ -126,16 +137,24 @@ func ImportAccounts(hexPks []string) ([]*Account, error) {
 	return out, nil
 }
 
-// GenerateAccounts is a convience function to generate an arbitrary number of accounts
-// using GenerateEthereumAddress
+// GenerateAccounts is a convience function to generate an arbitrary number of random accounts
 func GenerateAccounts(accounts int) ([]*Account, error) {
-	out := []*Account{}
-	for i := 0; i < accounts; i++ {
-		acc, err := GenerateEthereumAddress()
+	return DeriveAccounts(keys.NewDeriver(""), "accounts", 0, accounts)
+}
+
+// DeriveAccounts derives n accounts with the given label from the key deriver, with the indexes
+// starting at offset
+func DeriveAccounts(kd *keys.Deriver, label string, offset int, n int) ([]*Account, error) {
+	derived, err := kd.DeriveN(keys.Secp256k1, label, offset, n)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	out := make([]*Account, len(derived))
+	for i := range derived {
+		out[i], err = NewAccountFromKey(derived[i])
 		if err != nil {
 			return nil, util.LogError(err)
 		}
-		out = append(out, acc)
 	}
 	return out, nil
 }
@@ -0,0 +1,275 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/artifacts"
+	"github.com/whiteblock/genesis/clients"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// deployTimeout is how long to wait for a deployment to be mined by default
+	deployTimeout = 2 * time.Minute
+	// receiptPollInterval is how often the receipt of a deployment is checked for
+	receiptPollInterval = time.Second
+	// compileTimeout is how long solc may take to compile a contract
+	compileTimeout = time.Minute
+)
+
+// Contract is a contract to deploy, given either compiled or as solidity source
+type Contract struct {
+	// Name is the name of the contract, which picks the contract to deploy out of the source when it
+	// has more than one
+	Name string `json:"name"`
+	// Bytecode is the hex encoded creation bytecode of the contract
+	Bytecode string `json:"bytecode"`
+	// ABI is the abi of the contract, which is recorded along with it
+	ABI json.RawMessage `json:"abi,omitempty"`
+	// Source is the solidity source of the contract, which is compiled with solcPath in place of Bytecode
+	Source string `json:"source"`
+	// Args are the abi encoded arguments of the constructor, hex encoded
+	Args string `json:"args"`
+	// Value is the amount of wei sent to the constructor, as a base 10 string
+	Value string `json:"value"`
+	// Gas is the gas limit of the deployment, it is estimated when it is 0
+	Gas uint64 `json:"gas"`
+	// Account is the index of the genesis account which deploys the contract
+	Account int `json:"account"`
+	// Node is the node the deployment is sent to
+	Node int `json:"node"`
+	// Timeout is how long to wait for the deployment to be mined, such as 2m
+	Timeout string `json:"timeout"`
+}
+
+// Deployment is a deployed contract, which is recorded as an artifact of its testnet
+type Deployment struct {
+	Name     string          `json:"name"`
+	Address  string          `json:"address"`
+	Tx       string          `json:"tx"`
+	Block    uint64          `json:"block"`
+	GasUsed  uint64          `json:"gasUsed"`
+	Deployer string          `json:"deployer"`
+	Node     int             `json:"node"`
+	ABI      json.RawMessage `json:"abi,omitempty"`
+	Bytecode string          `json:"bytecode"`
+	Time     int64           `json:"time"`
+	// Artifact is the name of the artifact the deployment is recorded as
+	Artifact string `json:"artifact"`
+}
+
+// compiled is a contract in the output of solc --combined-json
+type compiled struct {
+	ABI json.RawMessage `json:"abi"`
+	Bin string          `json:"bin"`
+}
+
+// compile compiles the given solidity source with solc, giving the contract with the given name, or the only
+// contract in the source if name is empty
+func compile(source string, name string) (Contract, error) {
+	res, err := util.LocalExecContext(context.Background(), util.ShellQuote(conf.SolcPath)+" --combined-json abi,bin -",
+		util.ExecOptions{Stdin: strings.NewReader(source), Timeout: compileTimeout})
+	if err != nil {
+		return Contract{}, fmt.Errorf("could not compile the contract: %s", err.Error())
+	}
+	var out struct {
+		Contracts map[string]compiled `json:"contracts"`
+	}
+	err = json.Unmarshal([]byte(res.Stdout), &out)
+	if err != nil {
+		return Contract{}, fmt.Errorf("unexpected output from solc: %s", err.Error())
+	}
+	names := []string{}
+	byName := map[string]compiled{}
+	for key, contract := range out.Contracts {
+		short := key[strings.LastIndex(key, ":")+1:]
+		names = append(names, short)
+		byName[short] = contract
+	}
+	sort.Strings(names)
+	if len(name) == 0 {
+		if len(names) != 1 {
+			return Contract{}, fmt.Errorf("the source has the contracts %s, the name of the one to deploy is required",
+				strings.Join(names, ", "))
+		}
+		name = names[0]
+	}
+	contract, ok := byName[name]
+	if !ok {
+		return Contract{}, fmt.Errorf("the source does not have a contract named \"%s\"", name)
+	}
+	abi := contract.ABI
+	var encoded string
+	if json.Unmarshal(abi, &encoded) == nil { //before solc 0.8 the abi is given as a string
+		abi = json.RawMessage(encoded)
+	}
+	return Contract{Name: name, Bytecode: contract.Bin, ABI: abi}, nil
+}
+
+// decodeHex decodes a hex string, with or without a 0x prefix
+func decodeHex(field string, value string) ([]byte, error) {
+	if !strings.HasPrefix(value, "0x") {
+		value = "0x" + value
+	}
+	if value == "0x" {
+		return []byte{}, nil
+	}
+	out, err := hexutil.Decode(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", field, err.Error())
+	}
+	return out, nil
+}
+
+// DeployContract deploys the given contract from one of the genesis accounts of the testnet, waiting for its
+// deployment to be mined. The deployment is recorded as the contracts/{address}.json artifact of the testnet.
+func DeployContract(tn *testnet.TestNet, contract Contract) (Deployment, error) {
+	var err error
+	if len(contract.Source) > 0 {
+		compiled, err := compile(contract.Source, contract.Name)
+		if err != nil {
+			return Deployment{}, err
+		}
+		contract.Name, contract.Bytecode, contract.ABI = compiled.Name, compiled.Bytecode, compiled.ABI
+	}
+	code, err := decodeHex("bytecode", contract.Bytecode)
+	if err != nil {
+		return Deployment{}, err
+	}
+	if len(code) == 0 {
+		return Deployment{}, fmt.Errorf("either the bytecode or the source of the contract is required")
+	}
+	args, err := decodeHex("constructor arguments", contract.Args)
+	if err != nil {
+		return Deployment{}, err
+	}
+	data := append(code, args...)
+	value := big.NewInt(0)
+	if len(contract.Value) > 0 {
+		var ok bool
+		value, ok = new(big.Int).SetString(contract.Value, 10)
+		if !ok || value.Sign() < 0 {
+			return Deployment{}, fmt.Errorf("invalid value \"%s\"", contract.Value)
+		}
+	}
+	timeout := deployTimeout
+	if len(contract.Timeout) > 0 {
+		timeout, err = util.ParseDuration(contract.Timeout, time.Second)
+		if err != nil {
+			return Deployment{}, err
+		}
+	}
+	if contract.Node < 0 || contract.Node >= len(tn.Nodes) {
+		return Deployment{}, fmt.Errorf("node %d does not exist", contract.Node)
+	}
+	node := tn.Nodes[contract.Node]
+	acc, chainID, err := fundedAccount(tn, contract.Account)
+	if err != nil {
+		return Deployment{}, err
+	}
+	rpc := nodeRPC(tn.Clients[node.Server], node)
+
+	gas := contract.Gas
+	if gas == 0 {
+		var estimate string
+		err = rpc.Call("eth_estimateGas", []interface{}{map[string]string{"from": acc.HexAddress(),
+			"data": hexutil.Encode(data), "value": hexutil.EncodeBig(value)}}, &estimate)
+		if err != nil {
+			return Deployment{}, fmt.Errorf("could not estimate the gas of the deployment: %s", err.Error())
+		}
+		gas, err = hexutil.DecodeUint64(estimate)
+		if err != nil {
+			return Deployment{}, util.LogError(err)
+		}
+		gas += gas / 5 //leave some room, as the estimate is not always enough
+	}
+
+	transferMux.Lock()
+	nonce, gasPrice, err := nextTx(rpc, acc)
+	if err != nil {
+		transferMux.Unlock()
+		return Deployment{}, util.LogError(err)
+	}
+	tx, err := signTransaction(acc, []byte{}, value, data, gas, nonce, gasPrice, chainID)
+	if err != nil {
+		transferMux.Unlock()
+		return Deployment{}, util.LogError(err)
+	}
+	txHash, err := rpc.SendRawTransaction(tx)
+	transferMux.Unlock()
+	if err != nil {
+		return Deployment{}, util.LogError(err)
+	}
+	tn.BuildState.Logger().WithFields(log.Fields{"contract": contract.Name, "tx": txHash,
+		"node": contract.Node}).Info("sent the deployment of a contract")
+
+	out := Deployment{Name: contract.Name, Tx: txHash, Deployer: acc.HexAddress(), Node: contract.Node,
+		ABI: contract.ABI, Bytecode: hexutil.Encode(code), Time: time.Now().Unix()}
+	err = waitForReceipt(rpc, txHash, timeout, &out)
+	if err != nil {
+		return out, err
+	}
+	out.Artifact = "contracts/" + out.Address + ".json"
+	record, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	return out, util.LogError(artifacts.Store(tn.TestNetID, out.Artifact, record))
+}
+
+// waitForReceipt waits for the transaction which deploys a contract to be mined, filling in the address,
+// block and gas used of the deployment from its receipt
+func waitForReceipt(rpc *clients.JSONRPC, txHash string, timeout time.Duration, deployment *Deployment) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var receipt *struct {
+			ContractAddress string `json:"contractAddress"`
+			BlockNumber     string `json:"blockNumber"`
+			GasUsed         string `json:"gasUsed"`
+			Status          string `json:"status"`
+		}
+		err := rpc.Call("eth_getTransactionReceipt", []interface{}{txHash}, &receipt)
+		if err != nil {
+			return util.LogError(err)
+		}
+		if receipt != nil {
+			if receipt.Status == "0x0" {
+				return fmt.Errorf("the deployment %s failed", txHash)
+			}
+			deployment.Address = strings.ToLower(receipt.ContractAddress)
+			deployment.Block, _ = hexutil.DecodeUint64(receipt.BlockNumber)
+			deployment.GasUsed, _ = hexutil.DecodeUint64(receipt.GasUsed)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the deployment %s was not mined after %s", txHash, timeout)
+		}
+		time.Sleep(receiptPollInterval)
+	}
+}
@@ -0,0 +1,218 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ethereum
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/clients"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"math/big"
+	"sync"
+)
+
+// transferGas is the gas used by a plain value transfer
+const transferGas = 21000
+
+var (
+	conf        = util.GetConfig()
+	transferMux = sync.Mutex{} //Prevents concurrent transfers from using the same nonce
+)
+
+// FaucetConfig is the configuration of the faucet of a testnet, given in the extras
+// of the deployment details under "faucet"
+type FaucetConfig struct {
+	// Enabled is whether or not the faucet is available for the testnet
+	Enabled bool `json:"enabled"`
+	// Amount is the maximum amount of wei sent per drip, as a base 10 string
+	Amount string `json:"amount"`
+	// Account is the index of the funded genesis account which the faucet sends from
+	Account int `json:"account"`
+}
+
+// GetFaucetConfig gets the faucet configuration of the given testnet
+func GetFaucetConfig(tn *testnet.TestNet) (FaucetConfig, error) {
+	out := FaucetConfig{Amount: conf.FaucetAmount}
+	raw, ok := tn.CombinedDetails.Extras["faucet"]
+	if !ok {
+		return out, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	return out, util.LogError(json.Unmarshal(data, &out))
+}
+
+// SignTransfer creates a signed, RLP encoded EIP-155 transaction which sends value wei from acc to the given address
+func SignTransfer(acc *Account, to common.Address, value *big.Int, nonce uint64,
+	gasPrice *big.Int, chainID *big.Int) ([]byte, error) {
+	return signTransaction(acc, to.Bytes(), value, []byte{}, transferGas, nonce, gasPrice, chainID)
+}
+
+// signTransaction creates a signed, RLP encoded EIP-155 transaction from acc. to is empty for the
+// transactions which create a contract.
+func signTransaction(acc *Account, to []byte, value *big.Int, data []byte, gas uint64, nonce uint64,
+	gasPrice *big.Int, chainID *big.Int) ([]byte, error) {
+
+	hash := crypto.Keccak256(rlpOrPanic([]interface{}{
+		nonce, gasPrice, gas, to, value, data, chainID, uint(0), uint(0)}))
+
+	sig, err := crypto.Sign(hash, acc.PrivateKey)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	v := new(big.Int).Add(new(big.Int).Mul(chainID, big.NewInt(2)), big.NewInt(int64(sig[64])+35))
+
+	return rlp.EncodeToBytes([]interface{}{
+		nonce, gasPrice, gas, to, value, data, v, r, s})
+}
+
+func rlpOrPanic(v interface{}) []byte {
+	out, err := rlp.EncodeToBytes(v)
+	if err != nil {
+		panic(err) //only given values of types which can always be encoded
+	}
+	return out
+}
+
+// Drip sends the given amount of wei, or the configured amount if amount is nil, from the faucet account
+// of the testnet to the given address. Returns the hash of the transaction.
+func Drip(tn *testnet.TestNet, address string, amount *big.Int) (string, error) {
+	faucet, err := GetFaucetConfig(tn)
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	if !faucet.Enabled {
+		return "", fmt.Errorf("the faucet is not enabled for this testnet")
+	}
+	max, ok := new(big.Int).SetString(faucet.Amount, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid faucet amount \"%s\"", faucet.Amount)
+	}
+	if amount == nil {
+		amount = max
+	}
+	if amount.Sign() <= 0 || amount.Cmp(max) > 0 {
+		return "", fmt.Errorf("amount must be between 1 and %s wei", max.String())
+	}
+	return transfer(tn, faucet.Account, address, amount)
+}
+
+// Fund sends the given amount of wei, as a base 10 string, to the given address from the genesis account
+// which the faucet of the testnet sends from, whether or not the faucet is enabled. Returns the hash of the
+// transaction.
+func Fund(tn *testnet.TestNet, address string, amount string) (string, error) {
+	faucet, err := GetFaucetConfig(tn)
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok || value.Sign() <= 0 {
+		return "", fmt.Errorf("invalid amount \"%s\", it must be a positive number of wei", amount)
+	}
+	return transfer(tn, faucet.Account, address, value)
+}
+
+// Broadcast sends the given signed, RLP encoded transaction from the given node. Returns the hash of the
+// transaction.
+func Broadcast(tn *testnet.TestNet, node ssh.Node, tx []byte) (string, error) {
+	return nodeRPC(tn.Clients[node.GetServerID()], node).SendRawTransaction(tx)
+}
+
+// transfer sends amount wei from the genesis account with the given index to the given address, through
+// the first node of the testnet
+func transfer(tn *testnet.TestNet, account int, address string, amount *big.Int) (string, error) {
+	if !common.IsHexAddress(address) {
+		return "", fmt.Errorf("invalid address \"%s\"", address)
+	}
+
+	acc, chainID, err := fundedAccount(tn, account)
+	if err != nil {
+		return "", err
+	}
+	if len(tn.Nodes) == 0 {
+		return "", fmt.Errorf("the testnet does not have any nodes")
+	}
+	rpc := nodeRPC(tn.Clients[tn.Nodes[0].Server], tn.Nodes[0])
+
+	transferMux.Lock()
+	defer transferMux.Unlock()
+	nonce, gasPrice, err := nextTx(rpc, acc)
+	if err != nil {
+		return "", util.LogError(err)
+	}
+
+	tx, err := SignTransfer(acc, common.HexToAddress(address), amount, nonce, gasPrice, chainID)
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	txHash, err := rpc.SendRawTransaction(tx)
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	tn.BuildState.Logger().WithFields(log.Fields{
+		"from": acc.HexAddress(), "to": address, "amount": amount.String(), "tx": txHash}).Info("sent funds")
+	return txHash, nil
+}
+
+// fundedAccount gets the genesis account of the testnet with the given index, along with the chain id of the testnet
+func fundedAccount(tn *testnet.TestNet, account int) (*Account, *big.Int, error) {
+	var accounts []*Account
+	if !tn.BuildState.GetP("accounts", &accounts) || len(accounts) == 0 {
+		return nil, nil, fmt.Errorf("the testnet does not have any funded accounts")
+	}
+	if account < 0 || account >= len(accounts) {
+		return nil, nil, fmt.Errorf("genesis account %d does not exist", account)
+	}
+	var networkID int64
+	if !tn.BuildState.GetExtP("networkID", &networkID) {
+		return nil, nil, fmt.Errorf("unable to determine the chain id of the testnet")
+	}
+	return accounts[account], big.NewInt(networkID), nil
+}
+
+// nextTx gets the nonce of the next transaction from acc, and the gas price, from the node
+func nextTx(rpc *clients.JSONRPC, acc *Account) (uint64, *big.Int, error) {
+	var rawNonce string
+	err := rpc.Call("eth_getTransactionCount", []interface{}{acc.HexAddress(), "pending"}, &rawNonce)
+	if err != nil {
+		return 0, nil, err
+	}
+	nonce, err := hexutil.DecodeUint64(rawNonce)
+	if err != nil {
+		return 0, nil, err
+	}
+	var rawGasPrice string
+	err = rpc.Call("eth_gasPrice", nil, &rawGasPrice)
+	if err != nil {
+		return 0, nil, err
+	}
+	gasPrice, err := hexutil.DecodeBig(rawGasPrice)
+	return nonce, gasPrice, err
+}
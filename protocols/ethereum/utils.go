@@ -20,7 +20,11 @@ package ethereum
 
 import (
 	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/whiteblock/genesis/clients"
+	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 )
@@ -59,3 +63,44 @@ func ExposeAccounts(tn *testnet.TestNet, accounts []*Account) {
 		})
 	}
 }
+
+// HasPeers checks whether the node is connected to at least one peer. Meant to be used with helpers.WaitForAll.
+func HasPeers(client ssh.Client, _ *db.Server, node ssh.Node) (bool, error) {
+	peers, err := nodeRPC(client, node).PeerCount()
+	return peers > 0, err
+}
+
+// nodeRPC gets a client for the json rpc of the given node
+func nodeRPC(client ssh.Client, node ssh.Node) *clients.JSONRPC {
+	return clients.NewJSONRPC(client, node.GetIP(), RPCPort)
+}
+
+// getBlock gets the block of the node with the given number or tag, such as "latest"
+func getBlock(client ssh.Client, node ssh.Node, tag string) (helpers.Block, error) {
+	var block *struct {
+		Number string `json:"number"`
+		Hash   string `json:"hash"`
+	}
+	err := nodeRPC(client, node).Call("eth_getBlockByNumber", []interface{}{tag, false}, &block)
+	if err != nil {
+		return helpers.Block{}, err
+	}
+	if block == nil {
+		return helpers.Block{}, fmt.Errorf("block %s not found", tag)
+	}
+	height, err := hexutil.DecodeUint64(block.Number)
+	if err != nil {
+		return helpers.Block{}, util.LogError(err)
+	}
+	return helpers.Block{Height: int64(height), Hash: block.Hash}, nil
+}
+
+// ConsensusProbe reads the chains of the ethereum clients over json rpc. They do not have finality.
+var ConsensusProbe = helpers.ConsensusProbe{
+	Head: func(client ssh.Client, node ssh.Node) (helpers.Block, error) {
+		return getBlock(client, node, "latest")
+	},
+	BlockAt: func(client ssh.Client, node ssh.Node, height int64) (helpers.Block, error) {
+		return getBlock(client, node, hexutil.EncodeUint64(uint64(height)))
+	},
+}
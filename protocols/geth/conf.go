@@ -19,30 +19,28 @@
 package geth
 
 import (
-	"encoding/json"
-	"fmt"
 	"github.com/whiteblock/genesis/protocols/helpers"
 	"github.com/whiteblock/genesis/protocols/services"
 	"github.com/whiteblock/genesis/testnet"
 )
 
 type ethConf struct {
-	ExtraAccounts      int64  `json:"extraAccounts"`
-	NetworkID          int64  `json:"networkId"`
-	Difficulty         int64  `json:"difficulty"`
+	ExtraAccounts      int64  `json:"extraAccounts" param:"min=0"`
+	NetworkID          int64  `json:"networkId" param:"min=1"`
+	Difficulty         int64  `json:"difficulty" param:"min=1"`
 	InitBalance        string `json:"initBalance"`
-	MaxPeers           int64  `json:"maxPeers"`
-	GasLimit           int64  `json:"gasLimit"`
-	Consensus          string `json:"consensus"`
-	BlockPeriodSeconds int64  `json:"blockPeriodSeconds"`
-	Epoch              int64  `json:"epoch"`
-	HomesteadBlock     int64  `json:"homesteadBlock"`
-	Eip155Block        int64  `json:"eip155Block"`
-	Eip158Block        int64  `json:"eip158Block"`
+	MaxPeers           int64  `json:"maxPeers" param:"min=0"`
+	GasLimit           int64  `json:"gasLimit" param:"min=5000"`
+	Consensus          string `json:"consensus" param:"oneof=clique|ethash"`
+	BlockPeriodSeconds int64  `json:"blockPeriodSeconds" param:"min=0"`
+	Epoch              int64  `json:"epoch" param:"min=1"`
+	HomesteadBlock     int64  `json:"homesteadBlock" param:"min=0"`
+	Eip155Block        int64  `json:"eip155Block" param:"min=0"`
+	Eip158Block        int64  `json:"eip158Block" param:"min=0"`
 	Mode               string `json:"mode"`
-	Verbosity          int64  `json:"verbosity"`
+	Verbosity          int64  `json:"verbosity" param:"min=0,max=5"`
 	Unlock             bool   `json:"unlock"`
-	ExposedAccounts    int64  `json:"exposedAccounts"`
+	ExposedAccounts    int64  `json:"exposedAccounts" param:"min=-1"`
 }
 
 /**
@@ -52,21 +50,9 @@ func newConf(tn *testnet.TestNet) (*ethConf, error) {
 	data := tn.LDD.Params
 	out := new(ethConf)
 	err := helpers.HandleBlockchainConfig(blockchain, data, out)
-	if err != nil || data == nil {
+	if err != nil {
 		return out, err
 	}
-
-	initBalance, exists := data["initBalance"]
-	if exists && initBalance != nil {
-		switch initBalance.(type) {
-		case json.Number:
-			out.InitBalance = initBalance.(json.Number).String()
-		case string:
-			out.InitBalance = initBalance.(string)
-		default:
-			return nil, fmt.Errorf("incorrect type for initBalance given")
-		}
-	}
 	if out.ExposedAccounts != -1 && out.ExposedAccounts > out.ExtraAccounts+int64(tn.LDD.Nodes) {
 		out.ExtraAccounts = out.ExposedAccounts - int64(tn.LDD.Nodes)
 	}
@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/artifacts"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/protocols/ethereum"
 	"github.com/whiteblock/genesis/protocols/helpers"
@@ -32,6 +33,7 @@ import (
 	"github.com/whiteblock/genesis/util"
 	"github.com/whiteblock/mustache"
 	"sync"
+	"time"
 )
 
 var conf = util.GetConfig()
@@ -44,6 +46,11 @@ const (
 	defaultMode     = "default"
 	expansionMode   = "expand"
 	genesisFileName = "CustomGenesis.json"
+
+	// startCmd starts geth with the extra flags of the node, the p2p port and the output file
+	startCmd = `bash -ic 'geth --datadir /geth/ %s --rpc --nodiscover --rpcaddr 0.0.0.0` +
+		` --miner.gasprice=1 --rpcapi "admin,web3,db,eth,net,personal,miner,txpool" --rpccorsdomain "0.0.0.0" --mine` +
+		` --txpool.nolocals --port %d console  2>&1 | tee %s'`
 )
 
 func init() {
@@ -62,6 +69,21 @@ func init() {
 
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
 	registrar.RegisterParams(alias, helpers.DefaultGetParamsFn(blockchain))
+
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json", "genesis.json", helpers.GenesisSchemaFile)
+	registrar.RegisterCommands(blockchain, startCmd)
+
+	registrar.RegisterHealthCheck(blockchain, helpers.RPCHealthCheck(ethereum.RPCPort, "eth_blockNumber"))
+	registrar.RegisterHealthCheck(alias, helpers.RPCHealthCheck(ethereum.RPCPort, "eth_blockNumber"))
+	registrar.RegisterConsensusProbe(blockchain, ethereum.ConsensusProbe)
+	registrar.RegisterConsensusProbe(alias, ethereum.ConsensusProbe)
+	registrar.RegisterFund(blockchain, ethereum.Fund)
+	registrar.RegisterFund(alias, ethereum.Fund)
+	registrar.RegisterBroadcast(blockchain, ethereum.Broadcast)
+	registrar.RegisterBroadcast(alias, ethereum.Broadcast)
+
+	registrar.RegisterDataDirectory(blockchain, "/geth")
+	registrar.RegisterDataDirectory(alias, "/geth")
 }
 
 // build builds out a fresh new ethereum test network using geth
@@ -99,7 +121,9 @@ func build(tn *testnet.TestNet) error {
 	if err != nil {
 		return util.LogError(err)
 	}
-	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+	tn.BuildState.Set("accounts", accounts) //keep the same accounts if the build is retried
+
+	err = helpers.Step{Name: "wallets", Run: func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		for i, account := range accounts[:tn.LDD.Nodes] {
 			_, err := client.DockerExec(node, fmt.Sprintf("bash -c 'echo \"%s\" > /geth/pk%d'", account.HexPrivateKey(), i))
 			if err != nil {
@@ -112,7 +136,7 @@ func build(tn *testnet.TestNet) error {
 			}
 		}
 		return nil
-	})
+	}}.Exec(tn)
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -128,35 +152,41 @@ func build(tn *testnet.TestNet) error {
 
 	tn.BuildState.SetBuildStage("Initializing geth")
 
-	out, err := json.Marshal(staticNodes)
-	if err != nil {
-		return util.LogError(err)
-	}
-
 	tn.BuildState.IncrementBuildProgress()
 	tn.BuildState.SetBuildStage("Starting geth")
-	//Copy static-nodes to every server
-	err = helpers.CopyBytesToAllNodes(tn, string(out), "/geth/static-nodes.json")
+	//Copy the static-nodes of its peers to every node
+	err = helpers.CreateConfigs(tn, "/geth/static-nodes.json", func(node ssh.Node) ([]byte, error) {
+		peers, err := helpers.FilterPeers(tn, node, staticNodes)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(peers)
+	})
 	if err != nil {
 		return util.LogError(err)
 	}
 
-	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+	err = helpers.Step{Name: helpers.StartStep, Run: func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		tn.BuildState.IncrementBuildProgress()
 		account := accounts[node.GetAbsoluteNumber()]
-		gethCmd := fmt.Sprintf(
-			`geth --datadir /geth/ %s --rpc --nodiscover --rpcaddr 0.0.0.0`+
-				` --miner.gasprice=1 --rpcapi "admin,web3,db,eth,net,personal,miner,txpool" --rpccorsdomain "0.0.0.0" --mine`+
-				` --txpool.nolocals --port %d console  2>&1 | tee %s`,
-			getExtraFlags(ethconf, account, validFlags[node.GetAbsoluteNumber()]), ethereum.P2PPort, conf.DockerOutputFile)
-
-		_, err := client.DockerExecdit(node, fmt.Sprintf("bash -ic '%s'", gethCmd))
+		err := helpers.Relaunch(tn, client, node, "geth", func() error {
+			_, err := client.DockerExecdit(node, fmt.Sprintf(startCmd,
+				getExtraFlags(ethconf, account, validFlags[node.GetAbsoluteNumber()]), ethereum.P2PPort, conf.DockerOutputFile))
+			return err
+		})
 		tn.BuildState.IncrementBuildProgress()
 		return util.LogError(err)
-	})
+	}}.Exec(tn)
 	if err != nil {
 		return util.LogError(err)
 	}
+	if tn.LDD.Nodes > 1 {
+		tn.BuildState.SetBuildStage("Waiting for the nodes to peer")
+		err = helpers.WaitForAll(tn, ethereum.HasPeers, time.Duration(conf.HealthCheckTimeout)*time.Second)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
 	tn.BuildState.IncrementBuildProgress()
 	tn.BuildState.Set("staticNodes", staticNodes)
 	tn.BuildState.Set("geth-conf", *ethconf)
@@ -207,15 +237,16 @@ func add(tn *testnet.TestNet) error {
 
 	tn.BuildState.SetBuildStage("Initializing geth")
 
-	out, err := json.Marshal(staticNodes)
-	if err != nil {
-		return util.LogError(err)
-	}
-
 	tn.BuildState.IncrementBuildProgress()
 	tn.BuildState.SetBuildStage("Starting geth")
-	//Copy static-nodes to every server
-	err = helpers.CopyBytesToAllNewNodes(tn, string(out), "/geth/static-nodes.json")
+	//Copy the static-nodes of its peers to every node
+	err = helpers.CreateConfigsNewNodes(tn, "/geth/static-nodes.json", func(node ssh.Node) ([]byte, error) {
+		peers, err := helpers.FilterPeers(tn, node, staticNodes)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(peers)
+	})
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -238,13 +269,11 @@ func add(tn *testnet.TestNet) error {
 	err = helpers.AllNewNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		tn.BuildState.IncrementBuildProgress()
 		account := accounts[node.GetAbsoluteNumber()]
-		gethCmd := fmt.Sprintf(
-			`geth --datadir /geth/ %s --rpc --nodiscover --rpcaddr 0.0.0.0`+
-				` --miner.gasprice=1 --rpcapi "admin,web3,db,eth,net,personal,miner,txpool" --rpccorsdomain "0.0.0.0" --mine`+
-				` --txpool.nolocals --port %d console  2>&1 | tee %s`,
-			getExtraFlags(ethconf, account, validFlags[node.GetAbsoluteNumber()]), ethereum.P2PPort, conf.DockerOutputFile)
-
-		_, err := client.DockerExecdit(node, fmt.Sprintf("bash -ic '%s'", gethCmd))
+		err := helpers.Relaunch(tn, client, node, "geth", func() error {
+			_, err := client.DockerExecdit(node, fmt.Sprintf(startCmd,
+				getExtraFlags(ethconf, account, validFlags[node.GetAbsoluteNumber()]), ethereum.P2PPort, conf.DockerOutputFile))
+			return err
+		})
 		tn.BuildState.IncrementBuildProgress()
 		return util.LogError(err)
 	})
@@ -324,8 +353,12 @@ func createGenesisfile(ethconf *ethConf, tn *testnet.TestNet, accounts []*ethere
 	if err != nil {
 		return "", util.LogError(err)
 	}
-	tn.BuildState.Set("genesis-file", data)
-	return data, nil
+	final, err := helpers.FinalizeGenesis(tn, []byte(data))
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	tn.BuildState.Set("genesis-file", string(final))
+	return string(final), nil
 }
 
 func handleGenesisFileDist(tn *testnet.TestNet, ethconf *ethConf, accounts []*ethereum.Account) error {
@@ -337,6 +370,10 @@ func handleGenesisFileDist(tn *testnet.TestNet, ethconf *ethConf, accounts []*et
 	if err != nil {
 		return util.LogError(err)
 	}
+	err = artifacts.Store(tn.TestNetID, genesisFileName, []byte(genesisData))
+	if err != nil {
+		return util.LogError(err)
+	}
 
 	tn.BuildState.IncrementBuildProgress()
 	tn.BuildState.SetBuildStage("Bootstrapping network")
@@ -371,7 +408,11 @@ func handleGenesisFileDist(tn *testnet.TestNet, ethconf *ethConf, accounts []*et
 			return util.LogError(err)
 		}
 	}
-	return helpers.AllNewNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+	err = helpers.RunHooks(tn, db.AfterGenesisHook)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return helpers.Step{Name: "init", Run: func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		//Load the CustomGenesis file
 		if ethconf.Mode != expansionMode {
 			_, err := client.DockerExec(node,
@@ -383,7 +424,7 @@ func handleGenesisFileDist(tn *testnet.TestNet, ethconf *ethConf, accounts []*et
 		log.WithFields(log.Fields{"node": node.GetAbsoluteNumber()}).Trace("creating block directory")
 		tn.BuildState.IncrementBuildProgress()
 		return nil
-	})
+	}}.ExecNew(tn)
 }
 
 func loadForExpand(tn *testnet.TestNet, ethconf *ethConf) error {
@@ -422,6 +463,9 @@ func loadForExpand(tn *testnet.TestNet, ethconf *ethConf) error {
 
 func getAccountPool(tn *testnet.TestNet, numOfAccounts int) ([]*ethereum.Account, error) {
 	accounts := []*ethereum.Account{}
+	if tn.Keys().Deterministic() {
+		return ethereum.DeriveAccounts(tn.Keys(), "accounts", 0, numOfAccounts)
+	}
 	rawPreGen, err := helpers.FetchPreGeneratedPrivateKeys(tn)
 	if err != nil {
 		log.Debug("There are not any pregenerated accounts availible")
@@ -442,7 +486,7 @@ func getAccountPool(tn *testnet.TestNet, numOfAccounts int) ([]*ethereum.Account
 		log.Info("Fetched all the accounts from the build state store")
 		return accounts, nil
 	}
-	fillerAccounts, err := ethereum.GenerateAccounts(numOfAccounts - len(accounts))
+	fillerAccounts, err := ethereum.DeriveAccounts(tn.Keys(), "accounts", len(accounts), numOfAccounts-len(accounts))
 	if err != nil {
 		return nil, util.LogError(err)
 	}
@@ -0,0 +1,33 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"github.com/whiteblock/genesis/clients"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+)
+
+// TendermintBroadcast creates a function which broadcasts transactions through the tendermint RPC on the
+// given port of a node, for blockchains built on tendermint
+func TendermintBroadcast(port int) func(*testnet.TestNet, ssh.Node, []byte) (string, error) {
+	return func(tn *testnet.TestNet, node ssh.Node, tx []byte) (string, error) {
+		return clients.NewTendermint(tn.Clients[node.GetServerID()], node.GetIP(), port).BroadcastTx(tx)
+	}
+}
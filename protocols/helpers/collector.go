@@ -0,0 +1,86 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package helpers
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/ssh"
+	"sort"
+	"sync"
+)
+
+// Collector gathers a result from each node, such as its node id or enode url, which is safe to
+// use from the concurrent functions of AllNodeExecCon and the like. The results are given back
+// ordered by the absolute number of their node.
+type Collector struct {
+	mux     sync.Mutex
+	results map[int]interface{}
+}
+
+// NewCollector creates a new, empty Collector
+func NewCollector() *Collector {
+	return &Collector{results: map[int]interface{}{}}
+}
+
+// Set sets the result of the given node, replacing any previous result
+func (c *Collector) Set(node ssh.Node, value interface{}) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.results[node.GetAbsoluteNumber()] = value
+}
+
+// Get gets the result of the node with the given absolute number
+func (c *Collector) Get(absNum int) (interface{}, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	value, ok := c.results[absNum]
+	return value, ok
+}
+
+// Len gets the number of nodes which have a result
+func (c *Collector) Len() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return len(c.results)
+}
+
+// Values gets the results, ordered by the absolute number of their node
+func (c *Collector) Values() []interface{} {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	keys := make([]int, 0, len(c.results))
+	for key := range c.results {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	out := make([]interface{}, len(keys))
+	for i, key := range keys {
+		out[i] = c.results[key]
+	}
+	return out
+}
+
+// Strings gets the results as strings, ordered by the absolute number of their node
+func (c *Collector) Strings() []string {
+	values := c.Values()
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = fmt.Sprint(value)
+	}
+	return out
+}
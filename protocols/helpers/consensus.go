@@ -0,0 +1,62 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"github.com/whiteblock/genesis/clients"
+	"github.com/whiteblock/genesis/ssh"
+)
+
+// Block identifies a block in the chain of a node
+type Block struct {
+	Height int64  `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// ConsensusProbe reads the chain of a node, so that the progress of the consensus can be followed
+// and the nodes can be checked to agree on the blocks
+type ConsensusProbe struct {
+	// Head gets the latest block of the node
+	Head func(client ssh.Client, node ssh.Node) (Block, error)
+	// BlockAt gets the block of the node at the given height
+	BlockAt func(client ssh.Client, node ssh.Node, height int64) (Block, error)
+	// Finalized gets the height of the latest finalized block of the node. It is nil for the blockchains
+	// without finality.
+	Finalized func(client ssh.Client, node ssh.Node) (int64, error)
+}
+
+// TendermintProbe creates a consensus probe for blockchains built on tendermint, which serve the
+// tendermint RPC on the given port. Blocks are final as soon as they are committed.
+func TendermintProbe(port int) ConsensusProbe {
+	head := func(client ssh.Client, node ssh.Node) (Block, error) {
+		status, err := clients.NewTendermint(client, node.GetIP(), port).Status()
+		return Block{Height: status.Height, Hash: status.Hash}, err
+	}
+	return ConsensusProbe{
+		Head: head,
+		BlockAt: func(client ssh.Client, node ssh.Node, height int64) (Block, error) {
+			hash, err := clients.NewTendermint(client, node.GetIP(), port).BlockHash(height)
+			return Block{Height: height, Hash: hash}, err
+		},
+		Finalized: func(client ssh.Client, node ssh.Node) (int64, error) {
+			block, err := head(client, node)
+			return block.Height, err
+		},
+	}
+}
@@ -20,11 +20,14 @@ package helpers
 
 import (
 	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/secrets"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 	"sync"
+	"time"
 )
 
 type settings struct {
@@ -33,6 +36,21 @@ type settings struct {
 	reportError bool
 }
 
+// stage gets the staging directory of the build on the given server, which files are copied to on their
+// way into the nodes. It is created the first time it is needed during the build, and removed once the
+// build is done.
+func stage(client ssh.Client, buildState *state.BuildState, server int) (string, error) {
+	return buildState.Stage(server, func(dir string) error {
+		buildState.Defer(func() { client.Run("rm -rf " + dir) })
+		mode := ""
+		if secrets.Enabled() {
+			mode = "-m 700 "
+		}
+		_, err := client.Run(fmt.Sprintf("mkdir -p %s%s", mode, dir))
+		return util.LogError(err)
+	})
+}
+
 // CopyAllToServers copies all of the src files to all of the servers within the given testnet.
 // This can handle multiple pairs in form of ...,source,destination,source2,destination2
 func CopyAllToServers(tn *testnet.TestNet, srcDst ...string) error {
@@ -45,6 +63,7 @@ func CopyAllToServers(tn *testnet.TestNet, srcDst ...string) error {
 			wg.Add(1)
 			go func(client ssh.Client, j int) {
 				defer wg.Done()
+				defer util.Recover(tn.BuildState.ReportError)
 				tn.BuildState.Defer(func() { client.Run(fmt.Sprintf("rm -rf %s", srcDst[2*j+1])) })
 				err := client.Scp(srcDst[2*j], srcDst[2*j+1])
 				if err != nil {
@@ -58,34 +77,103 @@ func CopyAllToServers(tn *testnet.TestNet, srcDst ...string) error {
 	return tn.BuildState.GetError()
 }
 
+// SyncAllToServers pushes all of the src files or directories to all of the servers within the given testnet,
+// with rsync when enableRsync is set, so that repeated deployments only send what has changed. Unlike
+// CopyAllToServers, the copies are left on the servers for the next deployment.
+// This can handle multiple pairs in form of ...,source,destination,source2,destination2
+func SyncAllToServers(tn *testnet.TestNet, srcDst ...string) error {
+	if len(srcDst)%2 != 0 {
+		return fmt.Errorf("invalid number of variadic arguments, must be given an even number of them")
+	}
+	wg := sync.WaitGroup{}
+	for _, client := range tn.Clients {
+		for j := 0; j < len(srcDst)/2; j++ {
+			wg.Add(1)
+			go func(client ssh.Client, j int) {
+				defer wg.Done()
+				defer util.Recover(tn.BuildState.ReportError)
+				err := client.Sync(srcDst[2*j], srcDst[2*j+1])
+				if err != nil {
+					tn.BuildState.ReportError(err)
+				}
+			}(client, j)
+		}
+	}
+	wg.Wait()
+	return tn.BuildState.GetError()
+}
+
+// expectFiles gets the files which are expected to be in the nodes once each of the src and dst pairs
+// have been copied to them, for each pair. A pair whose files can't be read is left without any, as
+// the copy itself will report the problem.
+func expectFiles(buildState *state.BuildState, srcDst []string) [][]state.ExpectedFile {
+	out := make([][]state.ExpectedFile, len(srcDst)/2)
+	for j := range out {
+		files, err := buildState.ExpectFiles(srcDst[2*j], srcDst[2*j+1])
+		if err != nil {
+			buildState.Logger().WithFields(log.Fields{"src": srcDst[2*j], "error": err}).Warn(
+				"couldn't read the file to record it as expected in the nodes")
+			continue
+		}
+		out[j] = files
+	}
+	return out
+}
+
 func copyToAllNodes(tn *testnet.TestNet, s settings, srcDst ...string) error {
 	if len(srcDst)%2 != 0 {
 		return fmt.Errorf("invalid number of variadic arguments, must be given an even number of them")
 	}
+	if shouldBatch(tn, srcDst) {
+		return copyToAllNodesBatched(tn, s, srcDst...)
+	}
+	expected := expectFiles(tn.BuildState, srcDst)
 	wg := sync.WaitGroup{}
 	preOrderedNodes := tn.PreOrderNodes(s.useNew, s.sidecar != -1, s.sidecar)
 
 	for sid, nodes := range preOrderedNodes {
+		dir, err := stage(tn.Clients[sid], tn.BuildState, sid)
+		if err != nil {
+			return util.LogError(err)
+		}
 		for j := 0; j < len(srcDst)/2; j++ {
+			pending := pendingCopies(tn.BuildState, nodes, srcDst[2*j+1], expected[j])
+			if len(pending) == 0 {
+				continue
+			}
 			rdy := make(chan bool, 1)
 			wg.Add(1)
-			intermediateDst := "/tmp/" + srcDst[2*j]
+			intermediateDst := dir + "/" + srcDst[2*j]
 
 			go func(sid int, j int, rdy chan bool) {
 				defer wg.Done()
+				defer func() { rdy <- true }()
+				defer util.Recover(func(err error) { s.report(tn, err) })
 				ScpAndDeferRemoval(tn.Clients[sid], tn.BuildState, srcDst[2*j], intermediateDst)
-				rdy <- true
 			}(sid, j, rdy)
 
 			wg.Add(1)
-			go func(nodes []ssh.Node, j int, intermediateDst string, rdy chan bool) {
+			go func(client ssh.Client, nodes []ssh.Node, j int, intermediateDst string, rdy chan bool) {
 				defer wg.Done()
 				<-rdy
+				nodeWg := sync.WaitGroup{}
+				if secrets.Enabled() {
+					// the file may hold secrets, so it is wiped from the server as soon as it has been copied
+					defer client.Run(fmt.Sprintf("rm -f %s", intermediateDst))
+					defer nodeWg.Wait()
+				}
 				for i := range nodes {
 					wg.Add(1)
+					nodeWg.Add(1)
 					go func(node ssh.Node, j int, intermediateDst string) {
 						defer wg.Done()
+						defer nodeWg.Done()
+						defer util.Recover(func(err error) { s.report(tn, err) })
+						start := time.Now()
+						tn.BuildState.StartNodeStep(node.GetAbsoluteNumber(), node.GetNodeName())
 						err := tn.Clients[node.GetServerID()].DockerCp(node, intermediateDst, srcDst[2*j+1])
+						tn.BuildState.RecordNodeStep(node.GetNodeName(), time.Since(start))
+						tn.BuildState.FinishNodeStep(node.GetNodeName(), err)
 						if err != nil {
 							if s.reportError {
 								tn.BuildState.ReportError(err)
@@ -95,9 +183,11 @@ func copyToAllNodes(tn *testnet.TestNet, s settings, srcDst ...string) error {
 
 							return
 						}
+						tn.BuildState.RecordFiles(node.GetNodeName(), expected[j]...)
+						markCopied(tn.BuildState, node, srcDst[2*j+1], expected[j])
 					}(nodes[i], j, intermediateDst)
 				}
-			}(nodes, j, intermediateDst, rdy)
+			}(tn.Clients[sid], pending, j, intermediateDst, rdy)
 		}
 	}
 
@@ -179,6 +269,10 @@ func CopyBytesToAllNewNodesSC(ad *testnet.Adjunct, dataDst ...string) error {
 
 // SingleCp copies over data to the given dest on node localNodeID.
 func SingleCp(client ssh.Client, buildState *state.BuildState, node ssh.Node, data []byte, dest string) error {
+	expected := []state.ExpectedFile{state.NewExpectedFile(dest, data)}
+	if copied(buildState, node, dest, expected) {
+		return nil
+	}
 	tmpFilename, err := util.GetUUIDString()
 	if err != nil {
 		return util.LogError(err)
@@ -189,14 +283,30 @@ func SingleCp(client ssh.Client, buildState *state.BuildState, node ssh.Node, da
 		return util.LogError(err)
 	}
 
-	intermediateDst := "/tmp/" + tmpFilename
-	buildState.Defer(func() { client.Run("rm " + intermediateDst) })
+	dir, err := stage(client, buildState, node.GetServerID())
+	if err != nil {
+		return util.LogError(err)
+	}
+	intermediateDst := dir + "/" + tmpFilename
+	if secrets.Enabled() {
+		// the data may hold secrets, so the copies are wiped as soon as it is in the node
+		defer buildState.Workspace().RemoveFile(tmpFilename)
+		defer client.Run("rm -f " + intermediateDst)
+	} else {
+		buildState.Defer(func() { client.Run("rm " + intermediateDst) })
+	}
 	err = client.Scp(tmpFilename, intermediateDst)
 	if err != nil {
 		return util.LogError(err)
 	}
 
-	return client.DockerCp(node, intermediateDst, dest)
+	err = client.DockerCp(node, intermediateDst, dest)
+	if err != nil {
+		return err
+	}
+	buildState.RecordFiles(node.GetNodeName(), expected...)
+	markCopied(buildState, node, dest, expected)
+	return nil
 }
 
 /*
@@ -209,7 +319,18 @@ func createConfigs(tn *testnet.TestNet, dest string, s settings, fn func(ssh.Nod
 		wg.Add(1)
 		go func(client ssh.Client, node ssh.Node) {
 			defer wg.Done()
-			data, err := fn(node)
+			start := time.Now()
+			tn.BuildState.StartNodeStep(node.GetAbsoluteNumber(), node.GetNodeName())
+			var err error
+			defer func() {
+				tn.BuildState.RecordNodeStep(node.GetNodeName(), time.Since(start))
+				tn.BuildState.FinishNodeStep(node.GetNodeName(), err)
+			}()
+			var data []byte
+			err = util.Safe(func() (err error) {
+				data, err = fn(node)
+				return err
+			})
 			if err != nil {
 				tn.BuildState.ReportError(err)
 				return
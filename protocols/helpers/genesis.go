@@ -0,0 +1,137 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+const (
+	// GenesisOverrideFile is the name of the file in which the user can give a complete genesis file, which is
+	// used instead of the one generated by the builder
+	GenesisOverrideFile = "genesis.override.json"
+	// GenesisPatchFile is the name of the file in which the user can give a patch to the genesis file,
+	// either a JSON merge patch or a JSON patch, which is applied to the generated or overriding genesis file
+	GenesisPatchFile = "genesis.patch.json"
+	// GenesisSchemaFile is the name of the resource holding the JSON Schema the genesis file of a
+	// blockchain is validated against. Only the blockchains which have one support overriding the genesis file.
+	GenesisSchemaFile = "genesis.schema.json"
+)
+
+// SupportsGenesis checks if the builder of the given blockchain finalizes its genesis file with FinalizeGenesis,
+// which is the case for those which have a genesis schema
+func SupportsGenesis(blockchain string) bool {
+	_, err := GetStaticBlockchainConfig(blockchain, GenesisSchemaFile)
+	return err == nil
+}
+
+// getGenesisFile gets a genesis file given by the user, either in the file defaults or in the files of the first node
+func getGenesisFile(details *db.DeploymentDetails, file string) ([]byte, bool, error) {
+	res, exists := GetFileDefault(details, file)
+	if (!exists || len(res) == 0) && len(details.Files) > 0 && details.Files[0] != nil {
+		res, exists = details.Files[0][file]
+	}
+	if !exists || len(res) == 0 {
+		return nil, false, nil
+	}
+	out, err := base64.StdEncoding.DecodeString(res)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s is not base64 encoded: %s", file, err.Error())
+	}
+	return out, true, nil
+}
+
+// CheckGenesisFiles checks the genesis override and patch given in the deployment details, if any, so that
+// mistakes in them are reported before the build starts
+func CheckGenesisFiles(details *db.DeploymentDetails) error {
+	override, hasOverride, err := getGenesisFile(details, GenesisOverrideFile)
+	if err != nil {
+		return err
+	}
+	patch, hasPatch, err := getGenesisFile(details, GenesisPatchFile)
+	if err != nil {
+		return err
+	}
+	if !hasOverride && !hasPatch {
+		return nil
+	}
+	if !SupportsGenesis(details.Blockchain) {
+		return fmt.Errorf("%s does not support overriding its genesis file", details.Blockchain)
+	}
+	schema, err := GetStaticBlockchainConfig(details.Blockchain, GenesisSchemaFile)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if !hasOverride {
+		// the patch can only be fully checked once it is applied to the generated genesis file
+		if !json.Valid(patch) {
+			return fmt.Errorf("%s is not valid json", GenesisPatchFile)
+		}
+		return nil
+	}
+	if hasPatch {
+		override, err = util.PatchJSON(override, patch)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", GenesisPatchFile, err.Error())
+		}
+	}
+	err = util.ValidateJSONSchema(schema, override)
+	if err != nil {
+		return fmt.Errorf("invalid genesis file: %s", err.Error())
+	}
+	return nil
+}
+
+// FinalizeGenesis gives the genesis file to distribute to the nodes, from the one generated by the builder.
+// The generated file is replaced with the user's override if given, the user's patch is then applied, and the
+// result is validated against the genesis schema of the blockchain.
+func FinalizeGenesis(tn *testnet.TestNet, generated []byte) ([]byte, error) {
+	out := generated
+	override, ok, err := getGenesisFile(&tn.CombinedDetails, GenesisOverrideFile)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	if ok {
+		out = override
+	}
+	patch, ok, err := getGenesisFile(&tn.CombinedDetails, GenesisPatchFile)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	if ok {
+		out, err = util.PatchJSON(out, patch)
+		if err != nil {
+			return nil, util.LogError(fmt.Errorf("failed to apply %s: %s", GenesisPatchFile, err.Error()))
+		}
+	}
+	schema, err := GetStaticBlockchainConfig(tn.LDD.Blockchain, GenesisSchemaFile)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	err = util.ValidateJSONSchema(schema, out)
+	if err != nil {
+		return nil, util.LogError(fmt.Errorf("invalid genesis file: %s", err.Error()))
+	}
+	return out, nil
+}
@@ -0,0 +1,50 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/clients"
+	"github.com/whiteblock/genesis/ssh"
+	"strings"
+)
+
+// RPCHealthCheck creates a health check which considers a node healthy once it successfully answers
+// a call to the given JSON-RPC method on the given port
+func RPCHealthCheck(port int, method string) func(ssh.Client, ssh.Node) error {
+	return func(client ssh.Client, node ssh.Node) error {
+		return clients.NewJSONRPC(client, node.GetIP(), port).Call(method, nil, nil)
+	}
+}
+
+// LogHealthCheck creates a health check which considers a node healthy once the output of its
+// blockchain process matches the given extended regular expression
+func LogHealthCheck(pattern string) func(ssh.Client, ssh.Node) error {
+	return func(client ssh.Client, node ssh.Node) error {
+		res, err := client.DockerExec(node, fmt.Sprintf("grep -c -E '%s' %s || true",
+			strings.Replace(pattern, "'", "", -1), conf.DockerOutputFile))
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(res) == "0" || len(strings.TrimSpace(res)) == 0 {
+			return fmt.Errorf("the output does not yet match \"%s\"", pattern)
+		}
+		return nil
+	}
+}
@@ -20,14 +20,15 @@
 package helpers
 
 import (
-	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/clients"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 	"sync"
+	"time"
 )
 
 var conf = util.GetConfig()
@@ -37,30 +38,69 @@ var conf = util.GetConfig()
 */
 func allNodeExecCon(tn *testnet.TestNet, s settings, fn func(ssh.Client, *db.Server, ssh.Node) error) error {
 	nodes := tn.GetSSHNodes(s.useNew, s.sidecar != -1, s.sidecar)
-	wg := sync.WaitGroup{}
-	for _, node := range nodes {
+	workers := conf.ThreadLimit
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(nodes) {
+		workers = len(nodes)
+	}
 
+	jobs := make(chan ssh.Node)
+	mux := sync.Mutex{}
+	errs := util.MultiError{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func(fwdClient ssh.Client, fwdServer *db.Server, fwdNode ssh.Node) {
+		go func() {
 			defer wg.Done()
-			err := fn(fwdClient, fwdServer, fwdNode)
-			if err != nil {
-				tn.BuildState.ReportError(err)
-				return
+			for node := range jobs {
+				start := time.Now()
+				tn.BuildState.StartNodeStep(node.GetAbsoluteNumber(), node.GetNodeName())
+				err := util.Safe(func() error {
+					return fn(tn.Clients[node.GetServerID()], tn.GetServer(node.GetServerID()), node)
+				})
+				tn.BuildState.RecordNodeStep(node.GetNodeName(), time.Since(start))
+				tn.BuildState.FinishNodeStep(node.GetNodeName(), err)
+				if err == nil {
+					continue
+				}
+				err = util.NodeError{Node: node.GetAbsoluteNumber(), Server: node.GetServerID(), Err: err}
+				if s.reportError {
+					tn.BuildState.ReportError(err)
+				}
+				mux.Lock()
+				errs = append(errs, err)
+				mux.Unlock()
 			}
-		}(tn.Clients[node.GetServerID()], tn.GetServer(node.GetServerID()), node)
+		}()
+	}
 
+	stopped := false
+	for _, node := range nodes {
+		if tn.BuildState.Stop() {
+			stopped = true
+			break
+		}
+		jobs <- node
 	}
+	close(jobs)
 	wg.Wait()
-	return tn.BuildState.GetError()
+
+	if len(errs) > 0 {
+		return errs
+	}
+	if stopped {
+		return fmt.Errorf("the build was stopped")
+	}
+	return nil
 }
 
-// AllNodeExecCon executes fn for every node concurrently. Will return once all of the calls to fn
-// have been completely.
+// AllNodeExecCon executes fn for every node concurrently, on at most threadLimit nodes at a time. Will return
+// once all of the calls to fn have been completed. No more calls to fn are started once the build is stopped.
 // Each call to fn is provided with, in order, the relevant ssh client, the server where the node exists, the local
 // number of that node on the server and the absolute number of the node in the testnet. If any of the calls to fn
-// return a non-nil error value, one of those errors will be returned. Currently there is no guarantee as to which one,
-// however this should be implemented in the future.
+// return a non-nil error value, a util.MultiError containing a util.NodeError for each of them will be returned.
 func AllNodeExecCon(tn *testnet.TestNet, fn func(ssh.Client, *db.Server, ssh.Node) error) error {
 
 	return allNodeExecCon(tn, settings{useNew: false, sidecar: -1, reportError: true}, fn)
@@ -100,7 +140,9 @@ func AllServerExecCon(tn *testnet.TestNet, fn func(ssh.Client, *db.Server) error
 		wg.Add(1)
 		go func(server *db.Server) {
 			defer wg.Done()
-			err := fn(tn.Clients[server.ID], server)
+			err := util.Safe(func() error {
+				return fn(tn.Clients[server.ID], server)
+			})
 			if err != nil {
 				tn.BuildState.ReportError(err)
 				return
@@ -169,36 +211,24 @@ func JSONRPCAllNodes(tn *testnet.TestNet, call string, port int) ([]interface{},
 	mux := sync.Mutex{}
 	out := make([]interface{}, tn.LDD.Nodes)
 	err := AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		rpc := clients.NewJSONRPC(client, node.GetIP(), port)
+		var result interface{}
 		for {
-			res, err := client.KeepTryRun(
-				fmt.Sprintf(
-					`curl -sS -X POST http://%s:%d -H "Content-Type: application/json" `+
-						` -d '{ "method": "%s", "params": [], "id": 1, "jsonrpc": "2.0" }'`,
-					node.GetIP(), port, call))
-
-			if err != nil {
-				continue //could be infinite
+			err := rpc.Call(call, nil, &result)
+			if err == nil {
+				break
 			}
-			var result map[string]interface{}
-
-			err = json.Unmarshal([]byte(res), &result)
-			if err != nil {
+			if _, failed := err.(clients.RPCError); failed {
 				return util.LogError(err)
 			}
-			_, ok := result["result"]
-			if !ok {
-				_, hasError := result["error"]
-				if hasError {
-					return fmt.Errorf("%v", result["error"])
-				}
-				return fmt.Errorf(res)
-
+			if tn.BuildState.Stop() {
+				return tn.BuildState.GetError()
 			}
-			mux.Lock()
-			out[node.GetAbsoluteNumber()] = result["result"]
-			mux.Unlock()
-			break
+			time.Sleep(time.Second) //could be infinite
 		}
+		mux.Lock()
+		out[node.GetAbsoluteNumber()] = result
+		mux.Unlock()
 		return nil
 	})
 	return out, err
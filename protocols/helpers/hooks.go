@@ -0,0 +1,83 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/artifacts"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+// hookDir is the directory in the nodes the hook scripts are copied to
+const hookDir = "/tmp/hooks"
+
+// hookArtifact gets the name of the artifact the output of a hook is stored as, by the index of the hook
+// in the deployment details and the absolute number of the node it was run in
+func hookArtifact(stage string, index int, node int) string {
+	return fmt.Sprintf("hooks/%s/%d/node%d.log", stage, index, node)
+}
+
+// RunHooks runs the hooks of the given stage in their nodes, one hook after another in the order they were
+// given. The output of each run, along with its error if it failed, is stored as an artifact.
+func RunHooks(tn *testnet.TestNet, stage string) error {
+	for i, hook := range tn.LDD.Hooks {
+		if hook.Stage != stage {
+			continue
+		}
+		tn.BuildState.SetBuildStage(fmt.Sprintf("running the %s hook %d", stage, i))
+		err := runHook(tn, hook, i)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	return nil
+}
+
+func runHook(tn *testnet.TestNet, hook db.Hook, index int) error {
+	script := fmt.Sprintf("%s/%s-%d.sh", hookDir, hook.Stage, index)
+	return AllNewNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		if !hook.RunsOn(node.GetAbsoluteNumber()) {
+			return nil
+		}
+		_, err := client.DockerExec(node, "mkdir -p "+hookDir)
+		if err != nil {
+			return util.LogError(err)
+		}
+		err = SingleCp(client, tn.BuildState, node, []byte(hook.Script), script)
+		if err != nil {
+			return util.LogError(err)
+		}
+		res, runErr := client.DockerExec(node, "sh "+script)
+		if runErr != nil {
+			res += "\n" + runErr.Error()
+		}
+		err = artifacts.Store(tn.TestNetID, hookArtifact(hook.Stage, index, node.GetAbsoluteNumber()), []byte(res))
+		if err != nil {
+			return util.LogError(err)
+		}
+		if runErr != nil {
+			return fmt.Errorf("the %s hook %d failed on node %d: %s", hook.Stage, index, node.GetAbsoluteNumber(),
+				runErr.Error())
+		}
+		return nil
+	})
+}
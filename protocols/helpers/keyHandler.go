@@ -21,6 +21,7 @@ package helpers
 import (
 	"fmt"
 	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
 )
@@ -108,3 +109,47 @@ func (km *KeyMaster) GetServerKeyPairs(tn *testnet.TestNet) (map[string]util.Key
 	}
 	return km.GetMappedKeyPairs(ips, clients[0])
 }
+
+// DeliverKey copies the key material in data to dest on the node, readable only by its owner. Unlike
+// SingleCp, the intermediate copies are only readable by their owner and are removed as soon
+// as the key has been delivered, rather than once the build is done.
+func DeliverKey(client ssh.Client, buildState *state.BuildState, node ssh.Node, data []byte, dest string) error {
+	tmpFilename, err := util.GetUUIDString()
+	if err != nil {
+		return util.LogError(err)
+	}
+	ws := buildState.Workspace()
+	err = ws.Write(tmpFilename, data, 0600)
+	if err != nil {
+		return util.LogError(err)
+	}
+	defer ws.RemoveFile(tmpFilename)
+	src := ws.Path(tmpFilename)
+
+	dir, err := stage(client, buildState, node.GetServerID())
+	if err != nil {
+		return util.LogError(err)
+	}
+	intermediateDir := dir + "/" + tmpFilename
+	_, err = client.Run(fmt.Sprintf("mkdir -m 700 %s", intermediateDir))
+	if err != nil {
+		return util.LogError(err)
+	}
+	defer client.Run(fmt.Sprintf("rm -rf %s", intermediateDir))
+
+	intermediateDst := intermediateDir + "/key"
+	err = client.Scp(src, intermediateDst)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = client.Run(fmt.Sprintf("chmod 600 %s", intermediateDst))
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = client.DockerCp(node, intermediateDst, dest)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = client.DockerExec(node, fmt.Sprintf("chmod 600 %s", dest))
+	return util.LogError(err)
+}
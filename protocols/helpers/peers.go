@@ -0,0 +1,53 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package helpers
+
+import (
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+// GetPeers gets the absolute numbers of the nodes which the given node is permitted to peer with,
+// according to the topology of the testnet
+func GetPeers(tn *testnet.TestNet, node ssh.Node) ([]int, error) {
+	graph, err := tn.GetGraph()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	if node.GetAbsoluteNumber() >= len(graph) {
+		return []int{}, nil
+	}
+	return graph[node.GetAbsoluteNumber()], nil
+}
+
+// FilterPeers takes the addresses of all of the nodes, indexed by absolute number, and gives only the
+// addresses of the peers of the given node. Empty addresses are skipped.
+func FilterPeers(tn *testnet.TestNet, node ssh.Node, addrs []string) ([]string, error) {
+	peers, err := GetPeers(tn, node)
+	if err != nil {
+		return nil, err
+	}
+	out := []string{}
+	for _, peer := range peers {
+		if peer < len(addrs) && len(addrs[peer]) > 0 {
+			out = append(out, addrs[peer])
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,71 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"fmt"
+)
+
+// The distributions of the voting power among the validators of a blockchain
+const (
+	// EqualPower gives every validator the base power
+	EqualPower = "equal"
+	// LinearPower gives the validator i from the last the base power times i
+	LinearPower = "linear"
+	// ZipfPower gives the validator i from the first the base power times the number of validators over i,
+	// following zipf's law
+	ZipfPower = "zipf"
+	// ExplicitPower gives the validators the powers listed for them
+	ExplicitPower = "explicit"
+)
+
+// VotingPowers gets the voting powers of the given number of validators in the given distribution, from the
+// first validator to the last. The last validator is given the base power, except for ExplicitPower, which gives
+// the validators the given powers.
+func VotingPowers(distribution string, basePower int64, powers []int64, validators int) ([]int64, error) {
+	if basePower < 1 {
+		return nil, fmt.Errorf("the base power must be at least 1, got %d", basePower)
+	}
+	switch distribution {
+	case EqualPower, LinearPower, ZipfPower:
+	case ExplicitPower:
+		if len(powers) != validators {
+			return nil, fmt.Errorf("%d powers were given for %d validators", len(powers), validators)
+		}
+	default:
+		return nil, fmt.Errorf("unknown power distribution \"%s\"", distribution)
+	}
+	out := make([]int64, validators)
+	for i := range out {
+		switch distribution {
+		case EqualPower:
+			out[i] = basePower
+		case LinearPower:
+			out[i] = basePower * int64(validators-i)
+		case ZipfPower:
+			out[i] = basePower * int64(validators) / int64(i+1)
+		case ExplicitPower:
+			if powers[i] < 1 {
+				return nil, fmt.Errorf("the power of validator %d must be at least 1, got %d", i, powers[i])
+			}
+			out[i] = powers[i]
+		}
+	}
+	return out, nil
+}
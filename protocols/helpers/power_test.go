@@ -0,0 +1,63 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestVotingPowers(t *testing.T) {
+	var test = []struct {
+		distribution string
+		basePower    int64
+		powers       []int64
+		validators   int
+		expected     []int64
+		err          bool
+	}{
+		{distribution: EqualPower, basePower: 10, validators: 3, expected: []int64{10, 10, 10}},
+		{distribution: LinearPower, basePower: 10, validators: 3, expected: []int64{30, 20, 10}},
+		{distribution: ZipfPower, basePower: 10, validators: 4, expected: []int64{40, 20, 13, 10}},
+		{distribution: ExplicitPower, basePower: 1, powers: []int64{5, 1, 3}, validators: 3,
+			expected: []int64{5, 1, 3}},
+		{distribution: EqualPower, basePower: 10, validators: 0, expected: []int64{}},
+		{distribution: LinearPower, basePower: 7, validators: 1, expected: []int64{7}},
+		{distribution: EqualPower, basePower: 0, validators: 3, err: true},
+		{distribution: ZipfPower, basePower: -1, validators: 3, err: true},
+		{distribution: ExplicitPower, basePower: 1, powers: []int64{5, 1}, validators: 3, err: true},
+		{distribution: ExplicitPower, basePower: 1, powers: []int64{5, 0, 3}, validators: 3, err: true},
+		{distribution: ExplicitPower, basePower: 1, powers: []int64{5}, validators: 0, err: true},
+		{distribution: "quadratic", basePower: 10, validators: 3, err: true},
+		{distribution: "quadratic", basePower: 10, validators: 0, err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			powers, err := VotingPowers(tt.distribution, tt.basePower, tt.powers, tt.validators)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error result for VotingPowers: %v", err)
+			}
+			if !tt.err && !reflect.DeepEqual(powers, tt.expected) {
+				t.Errorf("VotingPowers gave %v, expected %v", powers, tt.expected)
+			}
+		})
+	}
+}
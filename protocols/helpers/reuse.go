@@ -23,11 +23,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/resources"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
-	"io/ioutil"
 ) //log "github.com/sirupsen/logrus"
 
 // ScpAndDeferRemoval Copy a file over to a server, and then defer it for removal after the build is completed
@@ -100,7 +100,7 @@ func GetFileDefault(details *db.DeploymentDetails, file string) (string, bool) {
 
 // GetStaticBlockchainConfig fetches a static file resource for a blockchain, which will never change
 func GetStaticBlockchainConfig(blockchain string, file string) ([]byte, error) {
-	return ioutil.ReadFile(fmt.Sprintf("%s/%s/%s", conf.ResourceDir, blockchain, file))
+	return resources.ReadFile(blockchain, file)
 }
 
 // GetGlobalBlockchainConfig fetches a static file resource for a blockchain, which will be the same for all of the nodes
@@ -129,25 +129,22 @@ func GetBlockchainConfig(blockchain string, node int, file string, details *db.D
 			}
 		}
 	}
-	return ioutil.ReadFile(fmt.Sprintf("%s/%s/%s", conf.ResourceDir, blockchain, file))
+	return resources.ReadFile(blockchain, file)
 }
 
 // HandleBlockchainConfig handles the creation of a blockchain configuration from the defaults and given
-// data from the deployment details
+// data from the deployment details. The params are decoded and validated with util.DecodeParams, so the
+// fields of out can be given param tags.
 func HandleBlockchainConfig(blockchain string, data map[string]interface{}, out interface{}) error {
 	dat, err := GetStaticBlockchainConfig(blockchain, "defaults.json")
 	if err != nil {
 		return util.LogError(err)
 	}
 	err = json.Unmarshal(dat, out)
-	if data == nil {
-		return util.LogError(err)
-	}
-	tmp, err := json.Marshal(data)
 	if err != nil {
 		return util.LogError(err)
 	}
-	return json.Unmarshal(tmp, out)
+	return util.LogError(util.DecodeParams(data, out))
 }
 
 // getError retrieves the error value from the build state, depending on the settings.
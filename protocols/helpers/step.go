@@ -0,0 +1,180 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"strings"
+)
+
+// StartStep is the step which starts the main process of a node. A node is started again when the build is
+// retried if one of the files which were copied to it has changed since.
+const StartStep = "start"
+
+// Step is a step of a build which is run on every node, and which is checkpointed in the build state
+// so that, if the build is retried, it is skipped on the nodes it has already been completed on.
+type Step struct {
+	// Name identifies the step, it must be unique within the build
+	Name string
+	// Run executes the step on a node
+	Run func(ssh.Client, *db.Server, ssh.Node) error
+	// Done optionally checks whether the step is already done on a node, for steps whose completion
+	// can be seen on the node itself. It is only called for nodes without a checkpoint.
+	Done func(ssh.Client, *db.Server, ssh.Node) (bool, error)
+}
+
+func (step Step) run(client ssh.Client, server *db.Server, node ssh.Node, tn *testnet.TestNet) error {
+	if tn.BuildState.IsDone(step.Name, node.GetNodeName()) {
+		tn.BuildState.Logger().WithFields(ssh.LogFields(node)).WithField("step", step.Name).Debug(
+			"skipping a step which is already done")
+		return nil
+	}
+	if step.Done != nil {
+		done, err := step.Done(client, server, node)
+		if err != nil {
+			return util.LogError(err)
+		}
+		if done {
+			tn.BuildState.MarkDone(step.Name, node.GetNodeName())
+			return nil
+		}
+	}
+	err := step.Run(client, server, node)
+	if err != nil {
+		return err
+	}
+	tn.BuildState.MarkDone(step.Name, node.GetNodeName())
+	return nil
+}
+
+// Exec runs the step on every node which has not already completed it, like AllNodeExecCon
+func (step Step) Exec(tn *testnet.TestNet) error {
+	return AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
+		return step.run(client, server, node, tn)
+	})
+}
+
+// ExecNew runs the step on every new node which has not already completed it, like AllNewNodeExecCon
+func (step Step) ExecNew(tn *testnet.TestNet) error {
+	return AllNewNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
+		return step.run(client, server, node, tn)
+	})
+}
+
+// StartMainDaemon starts the main process of the node with the given command, like DockerRunMainDaemon,
+// as a StartStep. It is only run again on a retry of the build if the command has changed as well,
+// in which case the process started by the previous attempt is killed first, see Relaunch.
+func StartMainDaemon(tn *testnet.TestNet, client ssh.Client, node ssh.Node, command string) error {
+	started := StartStep + " " + command
+	if !tn.BuildState.IsDone(started, node.GetNodeName()) {
+		tn.BuildState.Undo(StartStep, node.GetNodeName())
+	}
+	return Step{Name: StartStep, Run: func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		err := Relaunch(tn, client, node, strings.Split(command, " ")[0], func() error {
+			return client.DockerRunMainDaemon(node, command)
+		})
+		if err != nil {
+			return err
+		}
+		tn.BuildState.MarkDone(started, node.GetNodeName())
+		return nil
+	}}.run(client, tn.GetServer(node.GetServerID()), node, tn)
+}
+
+// launchedKey is the key of the build state which holds the name of the main process the node was started with
+func launchedKey(node ssh.Node) string {
+	return StartStep + " " + node.GetNodeName()
+}
+
+// Relaunch starts the main process of the node, named process, with launch. If the node was already started
+// by a previous attempt of the build, the process it was started with is killed first, so that the node
+// is never left running two of them.
+func Relaunch(tn *testnet.TestNet, client ssh.Client, node ssh.Node, process string, launch func() error) error {
+	var previous string
+	if tn.BuildState.GetP(launchedKey(node), &previous) && len(previous) > 0 {
+		tn.BuildState.Logger().WithFields(ssh.LogFields(node)).WithField("process", previous).Debug(
+			"killing the process started by the previous attempt")
+		_, err := client.DockerExec(node, fmt.Sprintf(`bash -c 'ps aux | grep "%s" | grep -v grep | grep -v nibbler`+
+			` | awk "{print \$2}" | xargs -r kill -9'`, previous))
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	err := launch()
+	if err != nil {
+		return err
+	}
+	tn.BuildState.Set(launchedKey(node), process)
+	return nil
+}
+
+// copyStep gives the name of the step which copies the given files to dst, which is the same
+// for as long as their content is. It is empty if the files are not known.
+func copyStep(dst string, files []state.ExpectedFile) string {
+	if len(files) == 0 {
+		return ""
+	}
+	sums := make([]string, len(files))
+	for i, file := range files {
+		sums[i] = file.Sha256
+	}
+	return "copy " + dst + " " + strings.Join(sums, ",")
+}
+
+// copied checks whether the given files were already copied to dst in the node, by a previous attempt
+// of the build. If so, they are recorded as expected in the node again.
+func copied(bs *state.BuildState, node ssh.Node, dst string, files []state.ExpectedFile) bool {
+	step := copyStep(dst, files)
+	if len(step) == 0 || !bs.IsDone(step, node.GetNodeName()) {
+		return false
+	}
+	bs.Logger().WithFields(ssh.LogFields(node)).WithField("dest", dst).Debug("skipping a copy which is already done")
+	bs.RecordFiles(node.GetNodeName(), files...)
+	return true
+}
+
+// pendingCopies gets the nodes which the given files have not already been copied to at dst
+func pendingCopies(bs *state.BuildState, nodes []ssh.Node, dst string, files []state.ExpectedFile) []ssh.Node {
+	out := []ssh.Node{}
+	for _, node := range nodes {
+		if !copied(bs, node, dst, files) {
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+// markCopied checkpoints the copy of the given files to dst in the node. If different files were copied
+// to dst before, the node is started again once the build is retried.
+func markCopied(bs *state.BuildState, node ssh.Node, dst string, files []state.ExpectedFile) {
+	step := copyStep(dst, files)
+	if len(step) == 0 {
+		return
+	}
+	if bs.IsDone("copy "+dst, node.GetNodeName()) && !bs.IsDone(step, node.GetNodeName()) {
+		bs.Undo(StartStep, node.GetNodeName())
+	}
+	bs.MarkDone("copy "+dst, node.GetNodeName())
+	bs.MarkDone(step, node.GetNodeName())
+}
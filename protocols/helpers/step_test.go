@@ -0,0 +1,133 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	dbmocks "github.com/whiteblock/genesis/db/mocks"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/ssh/mocks"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/testnet"
+)
+
+func newStepTestNet(t *testing.T, ctrl *gomock.Controller, serverID int, buildID string) (*testnet.TestNet,
+	*mocks.MockClient) {
+	store := dbmocks.NewMockStore(ctrl)
+	store.EXPECT().GetServers([]int{serverID}).Return([]db.Server{{ID: serverID, Addr: "10.0.0.1"}}, nil)
+	client := mocks.NewMockClient(ctrl)
+
+	err := state.AcquireBuilding([]int{serverID}, buildID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tn, err := testnet.NewTestNetWithClients(db.DeploymentDetails{Servers: []int{serverID}, Nodes: 2,
+		Images: []string{"alpine"}}, buildID, store, map[int]ssh.Client{serverID: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		tn.AddNode(db.Node{Server: serverID, TestNetID: buildID, LocalID: i, IP: "10.1.0.2"})
+	}
+	return tn, client
+}
+
+// killing matches the command which kills the given process
+type killing string
+
+func (k killing) Matches(x interface{}) bool {
+	cmd, ok := x.(string)
+	return ok && strings.Contains(cmd, fmt.Sprintf(`grep "%s"`, string(k))) && strings.Contains(cmd, "kill -9")
+}
+
+func (k killing) String() string {
+	return "kills " + string(k)
+}
+
+func startAll(t *testing.T, tn *testnet.TestNet, client ssh.Client, command string) {
+	for _, node := range tn.Nodes {
+		err := StartMainDaemon(tn, client, node, command)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStartMainDaemon(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tn, client := newStepTestNet(t, ctrl, 90101, "step-start")
+
+	client.EXPECT().DockerRunMainDaemon(gomock.Any(), "node --run").Times(2)
+	startAll(t, tn, client, "node --run")
+	startAll(t, tn, client, "node --run") //already started, as on a retry
+
+	for _, node := range tn.Nodes { //the command changed, so the old process is killed before the relaunch
+		gomock.InOrder(
+			client.EXPECT().DockerExec(node, killing("node")).Return("", nil),
+			client.EXPECT().DockerRunMainDaemon(node, "node --run --verbose"),
+		)
+	}
+	startAll(t, tn, client, "node --run --verbose")
+}
+
+func TestSingleCp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	tn, client := newStepTestNet(t, ctrl, 90102, "step-copy")
+	node := tn.Nodes[0]
+	client.EXPECT().Run(gomock.Any()).AnyTimes()
+	client.EXPECT().Scp(gomock.Any(), gomock.Any()).AnyTimes()
+
+	client.EXPECT().DockerCp(node, gomock.Any(), "/config.json").Times(1)
+	client.EXPECT().DockerRunMainDaemon(node, "node").Times(1)
+	for i := 0; i < 2; i++ { //the second attempt is a retry, which skips both
+		err := SingleCp(client, tn.BuildState, node, []byte(`{"a":1}`), "/config.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = StartMainDaemon(tn, client, node, "node")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(tn.BuildState.ExpectedFiles[node.GetNodeName()]) != 1 {
+		t.Errorf("expected the copied file to be recorded once, got %v", tn.BuildState.ExpectedFiles[node.GetNodeName()])
+	}
+
+	//the file changed, so the node is started again with it
+	client.EXPECT().DockerCp(node, gomock.Any(), "/config.json").Times(1)
+	gomock.InOrder(
+		client.EXPECT().DockerExec(node, killing("node")).Return("", nil),
+		client.EXPECT().DockerRunMainDaemon(node, "node").Times(1),
+	)
+	err := SingleCp(client, tn.BuildState, node, []byte(`{"a":2}`), "/config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = StartMainDaemon(tn, client, node, "node")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
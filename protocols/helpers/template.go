@@ -0,0 +1,124 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package helpers
+
+import (
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+)
+
+// TemplateData is what the resource templates of a blockchain are rendered with. Templates are
+// text/template files in the resource directory of the blockchain, named with a .tmpl suffix.
+type TemplateData struct {
+	// TestNetID is the id of the testnet
+	TestNetID string
+	// Params are the blockchain parameters of the deployment
+	Params map[string]interface{}
+	// Nodes is the number of nodes in the testnet
+	Nodes int
+	// IPs are the ip addresses of all of the nodes, by absolute number
+	IPs []string
+	// Node is the absolute number of the node the template is rendered for, or -1 for a file
+	// which is the same for all of the nodes
+	Node int
+	// IP is the ip address of the node, empty for a file which is the same for all of the nodes
+	IP string
+	// Peers are the absolute numbers of the peers of the node, according to the topology of the testnet
+	Peers []int
+	// PeerIPs are the ip addresses of the peers of the node
+	PeerIPs []string
+	// Extra holds the values given by the builder, such as the validators gathered from the nodes
+	Extra map[string]interface{}
+}
+
+// NewTemplateData gets the data to render a template for the given node with. If node is nil, the data is
+// for a file which is the same for all of the nodes.
+func NewTemplateData(tn *testnet.TestNet, node ssh.Node, extra map[string]interface{}) (TemplateData, error) {
+	out := TemplateData{
+		TestNetID: tn.TestNetID,
+		Params:    tn.LDD.Params,
+		Nodes:     len(tn.Nodes),
+		IPs:       make([]string, len(tn.Nodes)),
+		Node:      -1,
+		Peers:     []int{},
+		PeerIPs:   []string{},
+		Extra:     extra,
+	}
+	if out.Params == nil {
+		out.Params = map[string]interface{}{}
+	}
+	if out.Extra == nil {
+		out.Extra = map[string]interface{}{}
+	}
+	for i, n := range tn.Nodes {
+		out.IPs[i] = n.IP
+	}
+	if node == nil {
+		return out, nil
+	}
+	out.Node = node.GetAbsoluteNumber()
+	out.IP = node.GetIP()
+	peers, err := GetPeers(tn, node)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	out.Peers = peers
+	for _, peer := range peers {
+		if peer < len(out.IPs) {
+			out.PeerIPs = append(out.PeerIPs, out.IPs[peer])
+		}
+	}
+	return out, nil
+}
+
+// RenderBlockchainTemplate renders the given template of the blockchain of the testnet for a node. Like
+// GetBlockchainConfig, the template can be replaced with a file given in the deployment details.
+func RenderBlockchainTemplate(tn *testnet.TestNet, node ssh.Node, file string, extra map[string]interface{}) ([]byte, error) {
+	text, err := GetBlockchainConfig(tn.LDD.Blockchain, node.GetAbsoluteNumber(), file, tn.LDD)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	data, err := NewTemplateData(tn, node, extra)
+	if err != nil {
+		return nil, err
+	}
+	return util.RenderTemplate(file, string(text), data)
+}
+
+// RenderGlobalBlockchainTemplate renders the given template of the blockchain of the testnet, for a file
+// which is the same for all of the nodes, such as a genesis file
+func RenderGlobalBlockchainTemplate(tn *testnet.TestNet, file string, extra map[string]interface{}) ([]byte, error) {
+	text, err := GetGlobalBlockchainConfig(tn, file)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	data, err := NewTemplateData(tn, nil, extra)
+	if err != nil {
+		return nil, err
+	}
+	return util.RenderTemplate(file, string(text), data)
+}
+
+// CreateConfigsFromTemplate renders the given template of the blockchain for each node, and copies
+// the result to dest on the node
+func CreateConfigsFromTemplate(tn *testnet.TestNet, file string, dest string, extra map[string]interface{}) error {
+	return CreateConfigs(tn, dest, func(node ssh.Node) ([]byte, error) {
+		return RenderBlockchainTemplate(tn, node, file, extra)
+	})
+}
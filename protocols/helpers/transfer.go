@@ -0,0 +1,146 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package helpers
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/secrets"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// minCompressSize is the size in bytes from which a lone file is compressed before it is sent to the servers.
+// Smaller files are not worth the extra command to unpack them.
+const minCompressSize = 64 * 1024
+
+// localPath gives where the given source of a copy is on the machine genesis is on, the same way Scp finds it
+func localPath(bs *state.BuildState, src string) string {
+	return bs.Workspace().Path(src)
+}
+
+// shouldBatch checks whether the given files should be sent to the servers together in a gzipped tarball
+func shouldBatch(tn *testnet.TestNet, srcDst []string) bool {
+	if !conf.CompressTransfers || len(srcDst) == 0 {
+		return false
+	}
+	if len(srcDst) > 2 {
+		return true
+	}
+	info, err := os.Stat(localPath(tn.BuildState, srcDst[0]))
+	return err == nil && info.Size() >= minCompressSize
+}
+
+func (s settings) report(tn *testnet.TestNet, err error) {
+	if s.reportError {
+		tn.BuildState.ReportError(err)
+	} else {
+		tn.BuildState.Set("error", err)
+	}
+}
+
+// copyToAllNodesBatched is copyToAllNodes, except that the files are sent to each server at once in a
+// gzipped tarball, which is unpacked there before the files are copied into each of the nodes in parallel
+func copyToAllNodesBatched(tn *testnet.TestNet, s settings, srcDst ...string) error {
+	expected := expectFiles(tn.BuildState, srcDst)
+	id, err := util.GetUUIDString()
+	if err != nil {
+		return util.LogError(err)
+	}
+	names := []string{}
+	srcs := []string{}
+	for j := 0; j < len(srcDst)/2; j++ {
+		names = append(names, strconv.Itoa(j))
+		srcs = append(srcs, localPath(tn.BuildState, srcDst[2*j]))
+	}
+	archive := id + ".tar.gz"
+	err = util.WriteTarGz(localPath(tn.BuildState, archive), names, srcs)
+	if err != nil {
+		return util.LogError(err)
+	}
+	defer os.Remove(localPath(tn.BuildState, archive))
+
+	wg := sync.WaitGroup{}
+	for sid, nodes := range tn.PreOrderNodes(s.useNew, s.sidecar != -1, s.sidecar) {
+		pending := make([][]ssh.Node, len(srcDst)/2)
+		copies := 0
+		for j := range pending {
+			pending[j] = pendingCopies(tn.BuildState, nodes, srcDst[2*j+1], expected[j])
+			copies += len(pending[j])
+		}
+		if copies == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(sid int, client ssh.Client, pending [][]ssh.Node) {
+			defer wg.Done()
+			defer util.Recover(func(err error) { s.report(tn, err) })
+			dir, err := stage(client, tn.BuildState, sid)
+			if err != nil {
+				s.report(tn, err)
+				return
+			}
+			remoteDir := dir + "/" + id
+			cleanup := fmt.Sprintf("rm -rf %s %s.tar.gz", remoteDir, remoteDir)
+			if secrets.Enabled() {
+				// the files may hold secrets, so they are wiped from the server as soon as they have been copied
+				defer client.Run(cleanup)
+			} else {
+				tn.BuildState.Defer(func() { client.Run(cleanup) })
+			}
+			err = client.Scp(archive, remoteDir+".tar.gz")
+			if err == nil {
+				_, err = client.Run(fmt.Sprintf("mkdir -p %s && tar -xzf %s.tar.gz -C %s && rm -f %s.tar.gz",
+					remoteDir, remoteDir, remoteDir, remoteDir))
+			}
+			if err != nil {
+				s.report(tn, util.LogError(err))
+				return
+			}
+			nodeWg := sync.WaitGroup{}
+			for j, nodes := range pending {
+				for _, node := range nodes {
+					nodeWg.Add(1)
+					go func(node ssh.Node, j int) {
+						defer nodeWg.Done()
+						defer util.Recover(func(err error) { s.report(tn, err) })
+						start := time.Now()
+						tn.BuildState.StartNodeStep(node.GetAbsoluteNumber(), node.GetNodeName())
+						err := client.DockerCp(node, fmt.Sprintf("%s/%d", remoteDir, j), srcDst[2*j+1])
+						tn.BuildState.RecordNodeStep(node.GetNodeName(), time.Since(start))
+						tn.BuildState.FinishNodeStep(node.GetNodeName(), err)
+						if err != nil {
+							s.report(tn, err)
+							return
+						}
+						tn.BuildState.RecordFiles(node.GetNodeName(), expected[j]...)
+						markCopied(tn.BuildState, node, srcDst[2*j+1], expected[j])
+					}(node, j)
+				}
+			}
+			nodeWg.Wait()
+		}(sid, tn.Clients[sid], pending)
+	}
+	wg.Wait()
+	return getError(tn, s)
+}
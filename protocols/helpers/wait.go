@@ -0,0 +1,114 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package helpers
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// minWaitBackoff is the delay before the second attempt of a check
+	minWaitBackoff = 250 * time.Millisecond
+	// maxWaitBackoff is the longest delay between two attempts of a check
+	maxWaitBackoff = 5 * time.Second
+)
+
+func waitForAll(tn *testnet.TestNet, s settings, check func(ssh.Client, *db.Server, ssh.Node) (bool, error),
+	timeout time.Duration) error {
+
+	nodes := tn.GetSSHNodes(s.useNew, s.sidecar != -1, s.sidecar)
+	deadline := time.Now().Add(timeout)
+	mux := sync.Mutex{}
+	failures := map[int]string{}
+
+	wg := sync.WaitGroup{}
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(client ssh.Client, server *db.Server, node ssh.Node) {
+			defer wg.Done()
+			defer util.Recover(func(err error) {
+				mux.Lock()
+				failures[node.GetAbsoluteNumber()] = err.Error()
+				mux.Unlock()
+			})
+			backoff := minWaitBackoff
+			for {
+				ready, err := check(client, server, node)
+				if ready {
+					return
+				}
+				if time.Now().Add(backoff).After(deadline) || tn.BuildState.Stop() {
+					reason := "not ready"
+					if err != nil {
+						reason = err.Error()
+					}
+					mux.Lock()
+					failures[node.GetAbsoluteNumber()] = reason
+					mux.Unlock()
+					return
+				}
+				time.Sleep(backoff)
+				backoff *= 2
+				if backoff > maxWaitBackoff {
+					backoff = maxWaitBackoff
+				}
+			}
+		}(tn.Clients[node.GetServerID()], tn.GetServer(node.GetServerID()), node)
+	}
+	wg.Wait()
+
+	if len(failures) == 0 {
+		return nil
+	}
+	nums := []int{}
+	for num := range failures {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	reasons := []string{}
+	for _, num := range nums {
+		reasons = append(reasons, fmt.Sprintf("node %d: %s", num, failures[num]))
+	}
+	return fmt.Errorf("%d node(s) were not ready within %v: %s", len(failures), timeout, strings.Join(reasons, "; "))
+}
+
+// WaitForAll polls check on every node concurrently, backing off exponentially between attempts on each node,
+// until check has returned true for all of them. An error returned by check is treated as the node not being
+// ready yet. If the timeout elapses first, or the build is stopped, the returned error identifies each of the
+// nodes which were not ready along with the last error check gave for it.
+func WaitForAll(tn *testnet.TestNet, check func(ssh.Client, *db.Server, ssh.Node) (bool, error),
+	timeout time.Duration) error {
+
+	return waitForAll(tn, settings{useNew: false, sidecar: -1, reportError: false}, check, timeout)
+}
+
+// WaitForAllNew is WaitForAll but polls only the new nodes
+func WaitForAllNew(tn *testnet.TestNet, check func(ssh.Client, *db.Server, ssh.Node) (bool, error),
+	timeout time.Duration) error {
+
+	return waitForAll(tn, settings{useNew: true, sidecar: -1, reportError: false}, check, timeout)
+}
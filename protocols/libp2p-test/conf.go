@@ -27,7 +27,7 @@ type libp2pTestConf struct {
 	Connections int    `json:"connections"`
 	Interval    int    `json:"interval"`
 	Senders     int    `json:"senders"`
-	PayloadSize int64  `json:"payloadSize"`
+	PayloadSize int64  `json:"payloadSize" param:"size"`
 	UseValgrind bool   `json:"useValgrind"`
 }
 
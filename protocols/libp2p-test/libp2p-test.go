@@ -46,6 +46,7 @@ func init() {
 	registrar.RegisterServices(blockchain, func() []services.Service { return []services.Service{} })
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json")
 }
 
 type serialPeerInfo struct {
@@ -96,7 +97,8 @@ func build(tn *testnet.TestNet) error {
 		return util.LogError(err)
 	}
 
-	mesh, err := util.GenerateDependentMeshNetwork(tn.LDD.Nodes, testConf.Connections)
+	mesh, err := util.GenerateDependentMeshNetworkWithSeed(tn.LDD.Nodes, testConf.Connections,
+		tn.Keys().Int64("libp2p-test/peers"))
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -45,6 +45,7 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json")
 }
 
 // build builds out a fresh new lighthouse test network
@@ -67,7 +68,7 @@ func build(tn *testnet.TestNet) error {
 		defer tn.BuildState.IncrementBuildProgress()
 
 		lighthouseCmd := "RUST_LOG=libp2p=debug beacon_node --listen-address 0.0.0.0 --port 9000 " + peers + " 2>&1 | tee /output.log"
-		return client.DockerRunMainDaemon(node, lighthouseCmd)
+		return helpers.StartMainDaemon(tn, client, node, lighthouseCmd)
 	})
 	return util.LogError(err)
 }
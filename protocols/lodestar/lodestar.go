@@ -44,6 +44,7 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json")
 }
 
 // build builds out a fresh new lighthouse test network
@@ -70,7 +71,7 @@ func build(tn *testnet.TestNet) error {
 		} else {
 			logFolder = ""
 		}
-		return client.DockerRunMainDaemon(node, fmt.Sprintf("lodestar --listen-address 0.0.0.0 --port 9000 %s | tee %s/output%d.log", peers, logFolder, node.GetAbsoluteNumber()))
+		return helpers.StartMainDaemon(tn, client, node, fmt.Sprintf("lodestar --listen-address 0.0.0.0 --port 9000 %s | tee %s/output%d.log", peers, logFolder, node.GetAbsoluteNumber()))
 	})
 }
 
@@ -33,7 +33,7 @@ type panConf struct {
 	FixedDifficulty       int64  `json:"fixedDifficulty"`
 	BlockPeriodSeconds    int64  `json:"blockPeriodSeconds"`
 	Epoch                 int64  `json:"epoch"`
-	RequestTimeoutSeconds int64  `json:"requesttimeoutseconds"`
+	RequestTimeoutSeconds int64  `json:"requesttimeoutseconds" param:"duration"`
 	Accounts              int64  `json:"accounts"`
 	Orion                 bool   `json:"orion"`
 	Validators            int    `json:"validators"`
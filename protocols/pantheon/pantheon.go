@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/artifacts"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/protocols/ethereum"
 	"github.com/whiteblock/genesis/protocols/helpers"
@@ -42,6 +43,11 @@ const (
 	genesisFile     = "genesis.json"
 	genesisFilePath = "/pantheon/genesis/"
 	p2pPort         = 30303
+
+	// startCmd starts pantheon with the genesis file, the p2p port and the extra flags of the node
+	startCmd = `pantheon --config-file=/pantheon/config.toml --data-path=/pantheon/data --genesis-file=%s  ` +
+		`--rpc-http-enabled --rpc-http-api="ADMIN,CLIQUE,DEBUG,EEA,ETH,IBFT,MINER,NET,TXPOOL,WEB3" ` +
+		` --p2p-port=%d --rpc-http-port=8545 --rpc-http-host="0.0.0.0" --host-whitelist=all %s`
 )
 
 func init() {
@@ -50,6 +56,14 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json", "genesis.json", "config.toml",
+		helpers.GenesisSchemaFile)
+	registrar.RegisterCommands(blockchain, startCmd)
+	registrar.RegisterHealthCheck(blockchain, helpers.RPCHealthCheck(ethereum.RPCPort, "eth_blockNumber"))
+	registrar.RegisterConsensusProbe(blockchain, ethereum.ConsensusProbe)
+	registrar.RegisterFund(blockchain, ethereum.Fund)
+	registrar.RegisterBroadcast(blockchain, ethereum.Broadcast)
+	registrar.RegisterDataDirectory(blockchain, "/pantheon/data")
 	registrar.RegisterBlockchainSideCars(blockchain, func(tn *testnet.TestNet) []string {
 		return []string{"orion"}
 	})
@@ -71,7 +85,7 @@ func build(tn *testnet.TestNet) error {
 
 	accounts := make([]*ethereum.Account, tn.LDD.Nodes)
 
-	extraAccounts, err := ethereum.GenerateAccounts(int(panconf.Accounts))
+	extraAccounts, err := ethereum.DeriveAccounts(tn.Keys(), "accounts", 0, int(panconf.Accounts))
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -115,26 +129,25 @@ func build(tn *testnet.TestNet) error {
 		return util.LogError(err)
 	}
 
-	enodes := "["
+	enodes := make([]string, len(tn.Nodes))
 	for i, node := range tn.Nodes {
-		enodeAddress := fmt.Sprintf("enode://%s@%s:%d",
+		enodes[i] = fmt.Sprintf("enode://%s@%s:%d",
 			accounts[i].HexPublicKey(),
 			node.IP,
 			p2pPort)
-		if i != 0 {
-			enodes = enodes + ",\"" + enodeAddress + "\""
-		} else {
-			enodes = enodes + "\"" + enodeAddress + "\""
-		}
 		tn.BuildState.IncrementBuildProgress()
 	}
 
-	enodes = enodes + "]"
-
 	/* Create Static Nodes File */
 	tn.BuildState.SetBuildStage("Setting Up Static Peers")
 	tn.BuildState.IncrementBuildProgress()
-	err = helpers.CopyBytesToAllNodes(tn, enodes, "/pantheon/data/static-nodes.json")
+	err = helpers.CreateConfigs(tn, "/pantheon/data/static-nodes.json", func(node ssh.Node) ([]byte, error) {
+		peers, err := helpers.FilterPeers(tn, node, enodes)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(peers)
+	})
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -151,6 +164,10 @@ func build(tn *testnet.TestNet) error {
 	if err != nil {
 		return util.LogError(err)
 	}
+	err = helpers.RunHooks(tn, db.AfterGenesisHook)
+	if err != nil {
+		return util.LogError(err)
+	}
 
 	/* Start the nodes */
 	tn.BuildState.SetBuildStage("Starting Pantheon")
@@ -160,11 +177,7 @@ func build(tn *testnet.TestNet) error {
 		if err != nil {
 			return util.LogError(err)
 		}
-		return client.DockerRunMainDaemon(node, fmt.Sprintf(
-			`pantheon --config-file=/pantheon/config.toml --data-path=/pantheon/data --genesis-file=%s  `+
-				`--rpc-http-enabled --rpc-http-api="ADMIN,CLIQUE,DEBUG,EEA,ETH,IBFT,MINER,NET,TXPOOL,WEB3" `+
-				` --p2p-port=%d --rpc-http-port=8545 --rpc-http-host="0.0.0.0" --host-whitelist=all %s`,
-			genesisFileLoc, p2pPort, flags))
+		return helpers.StartMainDaemon(tn, client, node, fmt.Sprintf(startCmd, genesisFileLoc, p2pPort, flags))
 	})
 
 	if err != nil {
@@ -239,8 +252,16 @@ func createGenesisfile(panconf *panConf, tn *testnet.TestNet, accounts []*ethere
 	if err != nil {
 		return util.LogError(err)
 	}
+	final, err := helpers.FinalizeGenesis(tn, []byte(data))
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = artifacts.Store(tn.TestNetID, genesisFile, final)
+	if err != nil {
+		return util.LogError(err)
+	}
 	log.WithFields(log.Fields{"file": genesisFile}).Trace("writing the genesis file")
-	return util.LogError(tn.BuildState.Write(genesisFile, data))
+	return util.LogError(tn.BuildState.Write(genesisFile, string(final)))
 
 }
 
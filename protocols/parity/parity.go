@@ -20,9 +20,10 @@
 package parity
 
 import (
-	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/artifacts"
+	"github.com/whiteblock/genesis/clients"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/protocols/ethclassic"
 	"github.com/whiteblock/genesis/protocols/ethereum"
@@ -42,6 +43,9 @@ const (
 	blockchain   = "parity"
 	password     = "password"
 	passwordFile = "/parity/passwd"
+
+	// startCmd starts parity with the wallet of the node as its author
+	startCmd = `parity --author=%s -c /parity/config.toml --chain=/parity/spec.json`
 )
 
 func init() {
@@ -50,6 +54,14 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json", "spec.json.mustache",
+		"spec.json.poa.mustache", "config.toml.template", "config.toml.poa.mustache")
+	registrar.RegisterCommands(blockchain, startCmd)
+	registrar.RegisterHealthCheck(blockchain, helpers.RPCHealthCheck(ethereum.RPCPort, "eth_blockNumber"))
+	registrar.RegisterConsensusProbe(blockchain, ethereum.ConsensusProbe)
+	registrar.RegisterFund(blockchain, ethereum.Fund)
+	registrar.RegisterBroadcast(blockchain, ethereum.Broadcast)
+	registrar.RegisterDataDirectory(blockchain, "/parity")
 
 	registrar.RegisterBlockchainSideCars(blockchain, func(tn *testnet.TestNet) []string {
 		pconf, err := newConf(tn.LDD.Extras)
@@ -66,7 +78,6 @@ func init() {
 
 // build builds out a fresh new ethereum test network using parity
 func build(tn *testnet.TestNet) error {
-	mux := sync.Mutex{}
 	pconf, err := newConf(tn.LDD.Params)
 	if err != nil {
 		return util.LogError(err)
@@ -88,8 +99,8 @@ func build(tn *testnet.TestNet) error {
 	tn.BuildState.IncrementBuildProgress()
 
 	/**Create the wallets**/
-	wallets := make([]string, tn.LDD.Nodes)
-	rawWallets := make([]string, tn.LDD.Nodes)
+	walletCollector := helpers.NewCollector()
+	rawWalletCollector := helpers.NewCollector()
 	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		res, err := client.DockerExec(node, "parity --base-path=/parity/ --password=/parity/passwd account new")
 		if err != nil {
@@ -100,9 +111,7 @@ func build(tn *testnet.TestNet) error {
 			return fmt.Errorf("account new returned an empty response")
 		}
 
-		mux.Lock()
-		wallets[node.GetAbsoluteNumber()] = res[:len(res)-1]
-		mux.Unlock()
+		walletCollector.Set(node, res[:len(res)-1])
 
 		res, err = client.DockerExec(node, "bash -c 'cat /parity/keys/ethereum/*'")
 		if err != nil {
@@ -110,14 +119,14 @@ func build(tn *testnet.TestNet) error {
 		}
 		tn.BuildState.IncrementBuildProgress()
 
-		mux.Lock()
-		rawWallets[node.GetAbsoluteNumber()] = strings.Replace(res, "\"", "\\\"", -1)
-		mux.Unlock()
+		rawWalletCollector.Set(node, strings.Replace(res, "\"", "\\\"", -1))
 		return nil
 	})
 	if err != nil {
 		return util.LogError(err)
 	}
+	wallets := walletCollector.Strings()
+	rawWallets := rawWalletCollector.Strings()
 	/***********************************************************SPLIT************************************************************/
 	switch pconf.Consensus {
 	case "ethash":
@@ -155,8 +164,8 @@ func build(tn *testnet.TestNet) error {
 
 	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		defer tn.BuildState.IncrementBuildProgress()
-		return client.DockerRunMainDaemon(node,
-			fmt.Sprintf(`parity --author=%s -c /parity/config.toml --chain=/parity/spec.json`, wallets[node.GetAbsoluteNumber()]))
+		return helpers.StartMainDaemon(tn, client, node,
+			fmt.Sprintf(startCmd, wallets[node.GetAbsoluteNumber()]))
 	})
 	if err != nil {
 		return util.LogError(err)
@@ -164,41 +173,20 @@ func build(tn *testnet.TestNet) error {
 	//Start peering via curl
 	time.Sleep(time.Duration(5 * time.Second))
 	//Get the enode addresses
-	enodes := make([]string, tn.LDD.Nodes)
+	enodeCollector := helpers.NewCollector()
 	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
-		enode := ""
-		for len(enode) == 0 {
-			res, err := client.KeepTryRun(
-				fmt.Sprintf(
-					`curl -sS -X POST http://%s:8545 -H "Content-Type: application/json" `+
-						` -d '{ "method": "parity_enode", "params": [], "id": 1, "jsonrpc": "2.0" }'`,
-					node.GetIP()))
-
-			if err != nil {
-				return util.LogError(err)
-			}
-			var result map[string]interface{}
-
-			err = json.Unmarshal([]byte(res), &result)
-			if err != nil {
-				return util.LogError(err)
-			}
-			log.WithFields(log.Fields{"result": result}).Trace("fetched enode addr from parity_enode")
-
-			err = util.GetJSONString(result, "result", &enode)
-			if err != nil {
-				return util.LogError(err)
-			}
+		enode, err := getEnode(client, node)
+		if err != nil {
+			return util.LogError(err)
 		}
 		tn.BuildState.IncrementBuildProgress()
-		mux.Lock()
-		enodes[node.GetAbsoluteNumber()] = enode
-		mux.Unlock()
+		enodeCollector.Set(node, enode)
 		return nil
 	})
 	if err != nil {
 		return util.LogError(err)
 	}
+	enodes := enodeCollector.Strings()
 	storeParameters(tn, pconf, wallets, enodes)
 	tn.BuildState.IncrementBuildProgress()
 	return peerAllNodes(tn, enodes)
@@ -345,8 +333,8 @@ func add(tn *testnet.TestNet) error {
 
 	err = helpers.AllNewNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		defer tn.BuildState.IncrementBuildProgress()
-		return client.DockerRunMainDaemon(node,
-			fmt.Sprintf(`parity --author=%s -c /parity/config.toml --chain=/parity/spec.json`, wallets[node.GetAbsoluteNumber()%tn.LDD.Nodes]))
+		return helpers.StartMainDaemon(tn, client, node,
+			fmt.Sprintf(startCmd, wallets[node.GetAbsoluteNumber()%tn.LDD.Nodes]))
 	})
 	if err != nil {
 		return util.LogError(err)
@@ -354,8 +342,7 @@ func add(tn *testnet.TestNet) error {
 
 	var snodes []string
 	tn.BuildState.GetP("staticNodes", &snodes)
-	log.WithFields(log.Fields{"enodes": snodes}).Debug("Fetched the enodes from the previous build")
-	fmt.Println(fmt.Sprintf("enode address : %+v", snodes))
+	tn.BuildState.Logger().WithFields(log.Fields{"enodes": snodes}).Debug("Fetched the enodes from the previous build")
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -365,29 +352,9 @@ func add(tn *testnet.TestNet) error {
 	//Get the enode addresses
 	enodes := make([]string, tn.LDD.Nodes)
 	err = helpers.AllNewNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
-		enode := ""
-		for len(enode) == 0 {
-			res, err := client.KeepTryRun(
-				fmt.Sprintf(
-					`curl -sS -X POST http://%s:8545 -H "Content-Type: application/json" `+
-						` -d '{ "method": "parity_enode", "params": [], "id": 1, "jsonrpc": "2.0" }'`,
-					node.GetIP()))
-
-			if err != nil {
-				return util.LogError(err)
-			}
-			var result map[string]interface{}
-
-			err = json.Unmarshal([]byte(res), &result)
-			if err != nil {
-				return util.LogError(err)
-			}
-			log.WithFields(log.Fields{"result": result}).Trace("fetched enode addr from parity_enode")
-
-			err = util.GetJSONString(result, "result", &enode)
-			if err != nil {
-				return util.LogError(err)
-			}
+		enode, err := getEnode(client, node)
+		if err != nil {
+			return util.LogError(err)
 		}
 		tn.BuildState.IncrementBuildProgress()
 		mux.Lock()
@@ -406,17 +373,29 @@ func add(tn *testnet.TestNet) error {
 	return peerAllNodes(tn, snodes)
 }
 
+// getEnode gets the enode address of the node, waiting for parity to give one
+func getEnode(client ssh.Client, node ssh.Node) (string, error) {
+	rpc := clients.NewJSONRPC(client, node.GetIP(), ethereum.RPCPort)
+	enode := ""
+	for len(enode) == 0 {
+		err := rpc.KeepTryCall("parity_enode", nil, &enode)
+		if err != nil {
+			return "", err
+		}
+		log.WithFields(log.Fields{"result": enode}).Trace("fetched enode addr from parity_enode")
+	}
+	return enode, nil
+}
+
 func peerAllNodes(tn *testnet.TestNet, enodes []string) error {
 	return helpers.AllNewNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
-		for i, enode := range enodes {
-			if i == node.GetAbsoluteNumber() {
-				continue
-			}
-			_, err := client.Run(
-				fmt.Sprintf(
-					`curl -sS -X POST http://%s:8545 -H "Content-Type: application/json"  -d `+
-						`'{ "method": "parity_addReservedPeer", "params": ["%s"], "id": 1, "jsonrpc": "2.0" }'`,
-					node.GetIP(), enode))
+		peers, err := helpers.FilterPeers(tn, node, enodes)
+		if err != nil {
+			return util.LogError(err)
+		}
+		for _, enode := range peers {
+			err := clients.NewJSONRPC(client, node.GetIP(), ethereum.RPCPort).Call("parity_addReservedPeer",
+				[]interface{}{enode}, nil)
 			tn.BuildState.IncrementBuildProgress()
 			if err != nil {
 				return util.LogError(err)
@@ -427,7 +406,7 @@ func peerAllNodes(tn *testnet.TestNet, enodes []string) error {
 }
 
 func storeParameters(tn *testnet.TestNet, pconf *parityConf, wallets []string, enodes []string) {
-	accounts, err := ethereum.GenerateAccounts(tn.LDD.Nodes)
+	accounts, err := ethereum.DeriveAccounts(tn.Keys(), "accounts", 0, tn.LDD.Nodes)
 	if err != nil {
 		log.WithFields(log.Fields{"error": err}).Warn("couldn't create geth accounts")
 	}
@@ -466,6 +445,10 @@ func setupPOA(tn *testnet.TestNet, pconf *parityConf, wallets []string) error {
 	if err != nil {
 		return util.LogError(err)
 	}
+	err = artifacts.Store(tn.TestNetID, "spec.json", []byte(spec))
+	if err != nil {
+		return util.LogError(err)
+	}
 
 	err = helpers.CopyBytesToAllNodes(tn, spec, "/parity/spec.json")
 	if err != nil {
@@ -491,6 +474,10 @@ func setupPOW(tn *testnet.TestNet, pconf *parityConf, wallets []string) error {
 	if err != nil {
 		return util.LogError(err)
 	}
+	err = artifacts.Store(tn.TestNetID, "spec.json", []byte(spec))
+	if err != nil {
+		return util.LogError(err)
+	}
 	//create config file
 	err = helpers.CreateConfigs(tn, "/parity/config.toml", func(node ssh.Node) ([]byte, error) {
 		configToml, err := buildConfig(pconf, tn.LDD, wallets, "/parity/passwd", node.GetAbsoluteNumber())
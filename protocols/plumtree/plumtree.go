@@ -45,6 +45,7 @@ func init() {
 	registrar.RegisterServices(blockchain, getServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json")
 	registrar.RegisterAdditionalLogs(blockchain, map[string]string{
 		"json": "/plumtree/data/log.json"})
 }
@@ -56,7 +57,7 @@ func getServices() []services.Service {
 // build builds out a fresh new plumtree test network
 func build(tn *testnet.TestNet) error {
 
-	tn.BuildState.SetBuildSteps(0 + (tn.LDD.Nodes * 1))
+	tn.BuildState.SetBuildSteps(tn.LDD.Nodes * tn.LDD.Nodes)
 	tn.BuildState.SetBuildStage("Starting plumtree")
 
 	return util.LogError(helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
@@ -68,7 +69,7 @@ func build(tn *testnet.TestNet) error {
 				tn.BuildState.IncrementBuildProgress()
 			}
 		}
-		return client.DockerRunMainDaemon(node, "gossip --sending --payloadSize 5000 --sendInterval 100 --numberOfMessages 10000 -n 0.0.0.0 -l 9000 -r 9001 -m /plumtree/data/log.json "+peers)
+		return helpers.StartMainDaemon(tn, client, node, "gossip --sending --payloadSize 5000 --sendInterval 100 --numberOfMessages 10000 -n 0.0.0.0 -l 9000 -r 9001 -m /plumtree/data/log.json "+peers)
 	}))
 }
 
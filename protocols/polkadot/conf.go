@@ -35,7 +35,7 @@ type dotConf struct {
 	PoolKbytes              int64  `json:"poolKbytes"`
 	PoolLimit               int64  `json:"poolLimit"`
 	Pruning                 int64  `json:"pruning"`
-	StateCacheSize          int64  `json:"stateCacheSize"`
+	StateCacheSize          int64  `json:"stateCacheSize" param:"size"`
 	TelemetryURL            int64  `json:"telemetryUrl"`
 }
 
@@ -54,6 +54,8 @@ func init() {
 
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
 	registrar.RegisterParams(alias, helpers.DefaultGetParamsFn(blockchain))
+
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json")
 }
 
 // build builds out a fresh new polkadot test network
@@ -94,11 +96,11 @@ func build(tn *testnet.TestNet) error {
 		loop := true
 		for loop {
 			reNodeID := regexp.MustCompile(`(?m)Local node identity is: (.{46})`)
-			fmt.Println(reNodeID)
 			regNodeID := reNodeID.FindAllString(output, 1)[0]
 			splitNodeID := strings.Split(regNodeID, ":")
 			nodeID := strings.Replace(splitNodeID[1], " ", "", -1)
-			fmt.Println(nodeID)
+			tn.BuildState.Logger().WithFields(ssh.LogFields(node)).WithFields(
+				log.Fields{"nodeID": nodeID}).Debug("found the node identity")
 			if len(reNodeID.FindAllString(output, 1)) != 0 {
 				loop = false
 			}
@@ -126,7 +128,7 @@ func build(tn *testnet.TestNet) error {
 
 	nid := strings.Join(nodeIDList, " ")
 
-	fmt.Println(nid)
+	tn.BuildState.Logger().WithFields(log.Fields{"nodes": nid}).Debug("collected the node urls")
 
 	var vmode string
 
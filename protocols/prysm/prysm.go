@@ -20,12 +20,12 @@
 package prysm
 
 import (
-	"crypto/rand"
 	"fmt"
 	"github.com/libp2p/go-libp2p-crypto"
 	peer "github.com/libp2p/go-libp2p-peer"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/keys"
 	"github.com/whiteblock/genesis/protocols/helpers"
 	"github.com/whiteblock/genesis/protocols/registrar"
 	"github.com/whiteblock/genesis/ssh"
@@ -50,6 +50,7 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json")
 }
 
 // build builds out a fresh new prysm test network
@@ -62,7 +63,14 @@ func build(tn *testnet.TestNet) error {
 
 	nodeKeyPairs := map[string]crypto.PrivKey{}
 	for _, node := range tn.Nodes {
-		prvKey, _, _ := crypto.GenerateKeyPairWithReader(crypto.RSA, 2048, rand.Reader)
+		key, err := tn.Keys().Derive(keys.Secp256k1, "identity", node.AbsoluteNum)
+		if err != nil {
+			return util.LogError(err)
+		}
+		prvKey, err := crypto.UnmarshalSecp256k1PrivateKey(key.PrivateKey)
+		if err != nil {
+			return util.LogError(err)
+		}
 		nodeKeyPairs[node.ID] = prvKey
 	}
 
@@ -76,7 +84,7 @@ func build(tn *testnet.TestNet) error {
 		peers := ""
 
 		for _, peerNode := range tn.Nodes {
-			if node == peerNode {
+			if node.GetID() == peerNode.GetID() {
 				continue
 			}
 			peers += fmt.Sprintf(" --peer=/ip4/%s/tcp/%d/p2p/%s:%d", peerNode.IP, p2pPort, idString(nodeKeyPairs[peerNode.GetID()]), p2pPort)
@@ -90,7 +98,7 @@ func build(tn *testnet.TestNet) error {
 		}
 		keyStr := crypto.ConfigEncodeKey(marshaled)
 
-		err = helpers.SingleCp(client, tn.BuildState, node, []byte(keyStr), "/etc/identity.key")
+		err = helpers.DeliverKey(client, tn.BuildState, node, []byte(keyStr), "/etc/identity.key")
 		if err != nil {
 			log.WithError(err).Error("Could not marshal key")
 			return err
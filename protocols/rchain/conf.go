@@ -27,8 +27,8 @@ import (
 type rChainConf struct {
 	NoUpnp               bool   `json:"noUpnp"`
 	DefaultTimeout       int64  `json:"defaultTimeout"`
-	MapSize              int64  `json:"mapSize"`
-	CasperBlockStoreSize int64  `json:"casperBlockStoreSize"`
+	MapSize              int64  `json:"mapSize" param:"size"`
+	CasperBlockStoreSize int64  `json:"casperBlockStoreSize" param:"size"`
 	InMemoryStore        bool   `json:"inMemoryStore"`
 	MaxNumOfConnections  int64  `json:"maxNumOfConnections"`
 	Validators           int    `json:"validators"`
@@ -45,6 +45,7 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json", "rchain.conf.mustache")
 }
 
 // build builds out a fresh new rchain test network
@@ -147,7 +148,7 @@ func build(tn *testnet.TestNet) error {
 	buildState.SetBuildStage("Starting the boot node")
 	var enode string
 	{
-		err = masterClient.DockerRunMainDaemon(masterNode,
+		err = helpers.StartMainDaemon(tn, masterClient, masterNode,
 			fmt.Sprintf("%s run --standalone --data-dir \"/datadir\" --host %s --bonds-file /bonds.txt --allow-private-addresses",
 				rConf.Command, masterNode.IP))
 		buildState.IncrementBuildProgress()
@@ -213,11 +214,11 @@ func build(tn *testnet.TestNet) error {
 		validators++
 		mux.Unlock()
 		if isValidator {
-			return client.DockerRunMainDaemon(node,
+			return helpers.StartMainDaemon(tn, client, node,
 				fmt.Sprintf("%s run --data-dir \"/datadir\" --bootstrap \"%s\" --validator-private-key %s --host %s --allow-private-addresses",
 					rConf.Command, enode, keyPairs[node.GetAbsoluteNumber()-1].PrivateKey, node.GetIP()))
 		}
-		return client.DockerRunMainDaemon(node,
+		return helpers.StartMainDaemon(tn, client, node,
 			fmt.Sprintf("%s run --data-dir \"/datadir\" --bootstrap \"%s\" --host %s --allow-private-addresses",
 				rConf.Command, enode, node.GetIP()))
 	})
@@ -321,12 +322,12 @@ func add(tn *testnet.TestNet) error {
 		mux.Unlock()
 
 		if isValidator {
-			err = client.DockerRunMainDaemon(node,
+			err = helpers.StartMainDaemon(tn, client, node,
 				fmt.Sprintf("%s run --data-dir \"/datadir\" --bootstrap \"%s\" --validator-private-key %s --host %s --allow-private-addresses",
 					rConf.Command, enode, keyPairs[node.GetAbsoluteNumber()-1].PrivateKey, node.GetIP()))
 			return err
 		}
-		return client.DockerRunMainDaemon(node,
+		return helpers.StartMainDaemon(tn, client, node,
 			fmt.Sprintf("%s run --data-dir \"/datadir\" --bootstrap \"%s\" --host %s --allow-private-addresses",
 				rConf.Command, enode, node.GetIP()))
 	})
@@ -21,7 +21,9 @@ package registrar
 
 import (
 	"fmt"
+	"github.com/whiteblock/genesis/protocols/helpers"
 	"github.com/whiteblock/genesis/protocols/services"
+	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
 	"sync"
 )
@@ -38,6 +40,13 @@ var (
 	paramsFuncs   = map[string]func() string{}
 	defaultsFuncs = map[string]func() string{}
 	logFiles      = map[string]map[string]string{}
+	healthChecks  = map[string]func(ssh.Client, ssh.Node) error{}
+	probes        = map[string]helpers.ConsensusProbe{}
+	fundFuncs     = map[string]func(*testnet.TestNet, string, string) (string, error){}
+	broadcasts    = map[string]func(*testnet.TestNet, ssh.Node, []byte) (string, error){}
+	dataDirs      = map[string]string{}
+	commands      = map[string][]string{}
+	resources     = map[string][]string{}
 )
 
 // RegisterBuild associates a blockchain name with a build process
@@ -82,6 +91,64 @@ func RegisterAdditionalLogs(blockchain string, logs map[string]string) {
 	logFiles[blockchain] = logs
 }
 
+// RegisterHealthCheck associates a blockchain name with a function that checks whether a node is healthy,
+// returning nil if it is. It is polled on every node once they have been started.
+func RegisterHealthCheck(blockchain string, fn func(ssh.Client, ssh.Node) error) {
+	mux.Lock()
+	defer mux.Unlock()
+	healthChecks[blockchain] = fn
+}
+
+// RegisterConsensusProbe associates a blockchain name with the probe which reads the chains of its nodes,
+// to follow the progress of the consensus and detect forks
+func RegisterConsensusProbe(blockchain string, probe helpers.ConsensusProbe) {
+	mux.Lock()
+	defer mux.Unlock()
+	probes[blockchain] = probe
+}
+
+// RegisterFund associates a blockchain name with a function that sends the given amount, in the smallest unit of
+// the currency of the blockchain, from one of the accounts funded at genesis to the given address. It gives the
+// hash of the transaction.
+func RegisterFund(blockchain string, fn func(tn *testnet.TestNet, address string, amount string) (string, error)) {
+	mux.Lock()
+	defer mux.Unlock()
+	fundFuncs[blockchain] = fn
+}
+
+// RegisterBroadcast associates a blockchain name with a function that broadcasts the given signed transaction
+// from the given node, giving its hash
+func RegisterBroadcast(blockchain string, fn func(tn *testnet.TestNet, node ssh.Node, tx []byte) (string, error)) {
+	mux.Lock()
+	defer mux.Unlock()
+	broadcasts[blockchain] = fn
+}
+
+// RegisterDataDirectory associates a blockchain name with the directory in which its nodes keep their
+// chain data, which is where chain data seeds are extracted to
+func RegisterDataDirectory(blockchain string, dir string) {
+	mux.Lock()
+	defer mux.Unlock()
+	dataDirs[blockchain] = dir
+}
+
+// RegisterCommands associates a blockchain name with the templates of the commands its builder runs on the
+// nodes, in the format of fmt. Their integer verbs are taken to be given the absolute number of a node. These are
+// checked for mistakes before any build of the blockchain.
+func RegisterCommands(blockchain string, cmds ...string) {
+	mux.Lock()
+	defer mux.Unlock()
+	commands[blockchain] = append(commands[blockchain], cmds...)
+}
+
+// RegisterResources associates a blockchain name with the files its builder reads from its resource
+// directory, which are checked to exist before any build of the blockchain.
+func RegisterResources(blockchain string, files ...string) {
+	mux.Lock()
+	defer mux.Unlock()
+	resources[blockchain] = append(resources[blockchain], files...)
+}
+
 // GetBuildFunc gets the build function associated with the given blockchain name or error != nil if
 // it is not found
 func GetBuildFunc(blockchain string) (func(*testnet.TestNet) error, error) {
@@ -142,6 +209,54 @@ func GetDefaultsFunc(blockchain string) (func() string, error) {
 	return out, nil
 }
 
+// GetHealthCheckFunc gets the health check function associated with the given blockchain name or error != nil if
+// it is not found
+func GetHealthCheckFunc(blockchain string) (func(ssh.Client, ssh.Node) error, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+	out, ok := healthChecks[blockchain]
+	if !ok {
+		return nil, fmt.Errorf("no entry found for blockchain \"%s\"", blockchain)
+	}
+	return out, nil
+}
+
+// GetConsensusProbe gets the consensus probe associated with the given blockchain name or error != nil if
+// it is not found
+func GetConsensusProbe(blockchain string) (helpers.ConsensusProbe, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+	out, ok := probes[blockchain]
+	if !ok {
+		return helpers.ConsensusProbe{}, fmt.Errorf("no entry found for blockchain \"%s\"", blockchain)
+	}
+	return out, nil
+}
+
+// GetFundFunc gets the function which funds accounts associated with the given blockchain name or error != nil if
+// it is not found
+func GetFundFunc(blockchain string) (func(*testnet.TestNet, string, string) (string, error), error) {
+	mux.RLock()
+	defer mux.RUnlock()
+	out, ok := fundFuncs[blockchain]
+	if !ok {
+		return nil, fmt.Errorf("no entry found for blockchain \"%s\"", blockchain)
+	}
+	return out, nil
+}
+
+// GetBroadcastFunc gets the function which broadcasts transactions associated with the given blockchain name or
+// error != nil if it is not found
+func GetBroadcastFunc(blockchain string) (func(*testnet.TestNet, ssh.Node, []byte) (string, error), error) {
+	mux.RLock()
+	defer mux.RUnlock()
+	out, ok := broadcasts[blockchain]
+	if !ok {
+		return nil, fmt.Errorf("no entry found for blockchain \"%s\"", blockchain)
+	}
+	return out, nil
+}
+
 // GetAdditionalLogs gets additional logs of the blockchain if there are any
 func GetAdditionalLogs(blockchain string) map[string]string {
 	mux.RLock()
@@ -149,6 +264,28 @@ func GetAdditionalLogs(blockchain string) map[string]string {
 	return logFiles[blockchain]
 }
 
+// GetDataDirectory gets the chain data directory of the blockchain, or an empty string if it does
+// not have one registered
+func GetDataDirectory(blockchain string) string {
+	mux.RLock()
+	defer mux.RUnlock()
+	return dataDirs[blockchain]
+}
+
+// GetCommands gets the command templates registered for the blockchain
+func GetCommands(blockchain string) []string {
+	mux.RLock()
+	defer mux.RUnlock()
+	return append([]string{}, commands[blockchain]...)
+}
+
+// GetResources gets the resource files registered for the blockchain
+func GetResources(blockchain string) []string {
+	mux.RLock()
+	defer mux.RUnlock()
+	return append([]string{}, resources[blockchain]...)
+}
+
 // GetSupportedBlockchains gets the blockchains which have a registered
 // Build function
 func GetSupportedBlockchains() []string {
@@ -27,6 +27,9 @@ import (
 type SideCar struct {
 	// Image is the docker image to build the side car from
 	Image string
+	// GetImage gets the docker image to build the side car from for the given testnet, it takes the
+	// place of Image when it is set
+	GetImage func(*testnet.TestNet) string
 	// BuildStepsCalc calculates the number of times the sidecar will be calling IncrementSideCarProgress
 	BuildStepsCalc func(int, int) int //(nodes,servers)
 }
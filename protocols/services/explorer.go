@@ -0,0 +1,107 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+)
+
+// ExplorerName is the name of the block explorer service
+const ExplorerName = "explorer"
+
+// ExplorerConfig is the configuration of the block explorer of a testnet, given in the extras
+// of the deployment details under "explorer"
+type ExplorerConfig struct {
+	// Enabled is whether or not to deploy a block explorer
+	Enabled bool `json:"enabled"`
+	// Node is the absolute number of the node the explorer will read the chain from
+	Node int `json:"node"`
+	// Image is the docker image of the explorer
+	Image string `json:"image"`
+	// Port is the port the explorer serves its web interface on, within its container
+	Port int `json:"port"`
+	// RPCPort is the port of the rpc interface of the node
+	RPCPort int `json:"rpcPort"`
+	// NodeURLEnv is the environment variable through which the explorer is given the url of the node
+	NodeURLEnv string `json:"nodeUrlEnv"`
+}
+
+// defaultExplorers contains the explorer configurations for the blockchains which have a known explorer
+var defaultExplorers = map[string]ExplorerConfig{
+	"geth":     {Image: "alethio/ethereum-lite-explorer", Port: 80, RPCPort: 8545, NodeURLEnv: "APP_NODE_URL"},
+	"ethereum": {Image: "alethio/ethereum-lite-explorer", Port: 80, RPCPort: 8545, NodeURLEnv: "APP_NODE_URL"},
+	"parity":   {Image: "alethio/ethereum-lite-explorer", Port: 80, RPCPort: 8545, NodeURLEnv: "APP_NODE_URL"},
+	"pantheon": {Image: "alethio/ethereum-lite-explorer", Port: 80, RPCPort: 8545, NodeURLEnv: "APP_NODE_URL"},
+}
+
+// GetExplorerConfig gets the block explorer configuration from the given deployment details, filling
+// in the defaults for the blockchain
+func GetExplorerConfig(details *db.DeploymentDetails) (ExplorerConfig, error) {
+	out := defaultExplorers[details.Blockchain]
+	raw, ok := details.Extras[ExplorerName]
+	if !ok {
+		return out, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	err = json.Unmarshal(data, &out)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	if !out.Enabled {
+		return out, nil
+	}
+	if len(out.Image) == 0 || out.Port == 0 || out.RPCPort == 0 || len(out.NodeURLEnv) == 0 {
+		return out, fmt.Errorf("there is no default block explorer for %s, an image, port, rpcPort and nodeUrlEnv "+
+			"must be given", details.Blockchain)
+	}
+	if out.Node < 0 || out.Node >= details.Nodes {
+		return out, fmt.Errorf("explorer node %d does not exist", out.Node)
+	}
+	return out, nil
+}
+
+// ExplorerService represents a block explorer pointed at one of the nodes
+type ExplorerService struct {
+	SimpleService
+	config ExplorerConfig
+}
+
+// Prepare points the explorer to its node
+func (e ExplorerService) Prepare(client ssh.Client, tn *testnet.TestNet) error {
+	if e.config.Node >= len(tn.Nodes) {
+		return fmt.Errorf("explorer node %d does not exist", e.config.Node)
+	}
+	e.Env[e.config.NodeURLEnv] = fmt.Sprintf("http://%s:%d", tn.Nodes[e.config.Node].IP, e.config.RPCPort)
+	return nil
+}
+
+// GetURL gets the url of the web interface of the explorer, once it has been started
+func (e ExplorerService) GetURL(tn *testnet.TestNet) (string, error) {
+	return GetServiceURL(tn, ExplorerName, conf.ExplorerPort, e.config.Port)
+}
+
+// RegisterExplorer creates the block explorer service requested in the given deployment details. Returns nil
+// if a block explorer was not requested.
+func RegisterExplorer(details *db.DeploymentDetails) (*ExplorerService, error) {
+	config, err := GetExplorerConfig(details)
+	if err != nil || !config.Enabled {
+		return nil, err
+	}
+	return &ExplorerService{
+		SimpleService: SimpleService{
+			Name:    ExplorerName,
+			Image:   config.Image,
+			Env:     map[string]string{},
+			Ports:   []string{strconv.Itoa(conf.ExplorerPort) + ":" + strconv.Itoa(config.Port)},
+			Volumes: []string{},
+		},
+		config: config,
+	}, nil
+}
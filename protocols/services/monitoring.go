@@ -0,0 +1,159 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"github.com/whiteblock/mustache"
+	"strconv"
+	"strings"
+)
+
+const (
+	// CAdvisorName is the name of the cadvisor service
+	CAdvisorName = "cadvisor"
+	// PrometheusName is the name of the prometheus service
+	PrometheusName = "prometheus"
+	// GrafanaName is the name of the grafana service
+	GrafanaName = "grafana"
+)
+
+// MonitoringConfig is the configuration of the monitoring stack of a testnet, given in the extras
+// of the deployment details under "monitoring"
+type MonitoringConfig struct {
+	// Enabled is whether or not to deploy the monitoring stack
+	Enabled bool `json:"enabled"`
+}
+
+// GetMonitoringConfig gets the monitoring configuration from the given deployment details
+func GetMonitoringConfig(details *db.DeploymentDetails) (MonitoringConfig, error) {
+	out := MonitoringConfig{}
+	raw, ok := details.Extras["monitoring"]
+	if !ok {
+		return out, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	return out, util.LogError(json.Unmarshal(data, &out))
+}
+
+func cadvisorScrapeConfig(tn *testnet.TestNet) string {
+	targets := []string{}
+	for _, server := range tn.Servers {
+		targets = append(targets, fmt.Sprintf("'%s:%d'", server.Addr, conf.CAdvisorPort))
+	}
+	return fmt.Sprintf(`
+- job_name:       'cadvisor'
+  scrape_interval: 5s
+  metrics_path: /metrics
+  static_configs:
+    - targets: [%s]
+      labels:
+        testnet: '%s'
+
+`, strings.Join(targets, ", "), tn.TestNetID)
+}
+
+// CAdvisorService represents the cadvisor instance which runs on each server, exposing
+// the resource usage of the node containers
+type CAdvisorService struct {
+	SimpleService
+}
+
+// GetCommand gets the cadvisor command line options
+func (c CAdvisorService) GetCommand() string {
+	return fmt.Sprintf("--port=%d", conf.CAdvisorPort)
+}
+
+// GrafanaService represents a grafana instance with the prometheus data source and the testnet
+// dashboards already provisioned
+type GrafanaService struct {
+	SimpleService
+}
+
+// Prepare places the provisioning files for grafana on the server
+func (g GrafanaService) Prepare(client ssh.Client, tn *testnet.TestNet) error {
+	datasource, err := helpers.GetStaticBlockchainConfig(GrafanaName, "datasource.yml.mustache")
+	if err != nil {
+		return util.LogError(err)
+	}
+	datasourceTxt, err := mustache.Render(string(datasource), map[string]string{
+		"prometheus": util.GetServiceName(tn.TestNetID, PrometheusName),
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+	dashboards, err := helpers.GetStaticBlockchainConfig(GrafanaName, "dashboards.yml")
+	if err != nil {
+		return util.LogError(err)
+	}
+	dashboard, err := helpers.GetStaticBlockchainConfig(GrafanaName, "testnet.json")
+	if err != nil {
+		return util.LogError(err)
+	}
+	dashboardTxt := strings.NewReplacer(
+		"__NODE_PREFIX__", util.GetContainerPrefix(tn.TestNetID),
+		"__TESTNET__", tn.TestNetID).Replace(string(dashboard))
+
+	_, err = client.Run("rm -rf " + conf.GrafanaProvisioning)
+	if err != nil {
+		return util.LogError(err)
+	}
+	dir, err := tn.BuildState.WriteFiles(map[string][]byte{
+		"datasources/prometheus.yml": []byte(datasourceTxt),
+		"dashboards/dashboards.yml":  dashboards,
+		"dashboards/testnet.json":    []byte(dashboardTxt),
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+	return util.LogError(client.Sync(dir, conf.GrafanaProvisioning))
+}
+
+// RegisterMonitoring creates the services of the monitoring stack requested in the given deployment details:
+// cadvisor on each server, prometheus scraping the nodes and cadvisor, and grafana. Returns nil
+// if monitoring was not requested. Cadvisor is left out when conf.EnableCAdvisor is set, as the build
+// then already runs it on each server.
+func RegisterMonitoring(details *db.DeploymentDetails) ([]Service, error) {
+	config, err := GetMonitoringConfig(details)
+	if err != nil || !config.Enabled {
+		return nil, err
+	}
+	prometheus := RegisterPrometheus().(PrometheusService)
+	prometheus.scrapeCAdvisor = true
+	out := []Service{
+		prometheus,
+		GrafanaService{
+			SimpleService{
+				Name:    GrafanaName,
+				Image:   "grafana/grafana",
+				Env:     map[string]string{"GF_AUTH_ANONYMOUS_ENABLED": "true"},
+				Ports:   []string{strconv.Itoa(conf.GrafanaPort) + ":3000"},
+				Volumes: []string{conf.GrafanaProvisioning + ":/etc/grafana/provisioning"},
+			},
+		},
+	}
+	if conf.EnableCAdvisor {
+		return out, nil
+	}
+	return append([]Service{
+		CAdvisorService{
+			SimpleService{
+				Name:    CAdvisorName,
+				Image:   "google/cadvisor",
+				Env:     map[string]string{},
+				Network: "host",
+				Ports:   []string{},
+				Volumes: []string{"/:/rootfs:ro", "/var/run:/var/run:ro", "/sys:/sys:ro",
+					"/var/lib/docker/:/var/lib/docker:ro"},
+				Scope: ServerScope,
+			},
+		},
+	}, out...), nil
+}
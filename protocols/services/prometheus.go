@@ -16,6 +16,8 @@ import (
 // PrometheusService represents the Prometheus service
 type PrometheusService struct {
 	SimpleService
+	// scrapeCAdvisor is whether or not to also scrape the cadvisor instance on each server
+	scrapeCAdvisor bool
 }
 
 // Prepare prepares the prometheus service
@@ -62,6 +64,9 @@ func (p PrometheusService) Prepare(client ssh.Client, tn *testnet.TestNet) error
 		}
 
 	}
+	if p.scrapeCAdvisor {
+		configTxt += cadvisorScrapeConfig(tn)
+	}
 	log.Debug(configTxt)
 	log.Debug(conf.PrometheusConfig)
 
@@ -84,8 +89,8 @@ func (p PrometheusService) Prepare(client ssh.Client, tn *testnet.TestNet) error
 // RegisterPrometheus exposes a Prometheus service on the testnet.
 func RegisterPrometheus() Service {
 	return PrometheusService{
-		SimpleService{
-			Name:    "prometheus",
+		SimpleService: SimpleService{
+			Name:    PrometheusName,
 			Image:   "prom/prometheus",
 			Env:     map[string]string{},
 			Ports:   []string{strconv.Itoa(conf.PrometheusPort) + ":9090"},
@@ -35,8 +35,20 @@ type Service interface {
 
 	// GetCommand gets the command to run for the service with Docker.
 	GetCommand() string
+
+	// GetScope gets where the service should run, either TestNetScope or ServerScope
+	GetScope() string
 }
 
+const (
+	// TestNetScope is for services which run as a single container for the whole testnet,
+	// on the first server of the testnet
+	TestNetScope = "testnet"
+
+	// ServerScope is for services which run as a container on each of the servers of the testnet
+	ServerScope = "server"
+)
+
 // SimpleService represents a service for a blockchain.
 // All env variables will be passed to the container.
 type SimpleService struct {
@@ -46,6 +58,8 @@ type SimpleService struct {
 	Network string            `json:"network"`
 	Ports   []string          `json:"ports"`
 	Volumes []string          `json:"volumes"`
+	// Scope is where the service should run. Defaults to TestNetScope
+	Scope string `json:"scope,omitempty"`
 }
 
 // Prepare just returns nil. Simple service has no prepare step
@@ -88,6 +102,27 @@ func (s SimpleService) GetCommand() string {
 	return ""
 }
 
+// GetScope gets where the service should run
+func (s SimpleService) GetScope() string {
+	if len(s.Scope) == 0 {
+		return TestNetScope
+	}
+	return s.Scope
+}
+
+// GetServiceURL gets the url of the web interface of a service which has been started. If port forwarding
+// is enabled, the url will be through the host port on the server, otherwise it will be directly to the container.
+func GetServiceURL(tn *testnet.TestNet, name string, hostPort int, containerPort int) (string, error) {
+	details, err := tn.GetService(name)
+	if err != nil {
+		return "", err
+	}
+	if conf.EnablePortForwarding {
+		return fmt.Sprintf("http://%s:%d", tn.GetServer(details.Server).Addr, hostPort), nil
+	}
+	return fmt.Sprintf("http://%s:%d", details.IP, containerPort), nil
+}
+
 // GetServiceIps creates a map of the service names to their ip addresses. Useful
 // for determining the ip address of a service.
 func GetServiceIps(services []Service) (map[string]string, error) {
@@ -75,7 +75,7 @@ func (p SysethereumService) GetCommand() string {
 func RegisterSysethereum() Service {
 	return SysethereumService{
 		SimpleService{
-			Name:    "ganache",
+			Name:    "sysethereum",
 			Image:   "gcr.io/whiteblock/sysethereum-agents",
 			Env:     map[string]string{},
 			Ports:   []string{},
@@ -42,6 +42,7 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json")
 }
 
 // regTest sets up Syscoin Testnet in Regtest mode
@@ -69,7 +70,7 @@ func regTest(tn *testnet.TestNet) error {
 
 	return helpers.AllNodeExecCon(tn, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
 		defer tn.BuildState.IncrementBuildProgress()
-		return client.DockerRunMainDaemon(node,
+		return helpers.StartMainDaemon(tn, client, node,
 			"syscoind -conf=\"/syscoin/datadir/regtest.conf\" -datadir=\"/syscoin/datadir/\"")
 	})
 }
@@ -110,7 +111,7 @@ func handleConf(tn *testnet.TestNet, sysconf *sysConf) error {
 		}
 	}
 
-	connsDist, err := util.Distribute(ips, connDistModel)
+	connsDist, err := util.DistributeWithSeed(ips, connDistModel, tn.Keys().Int64("syscoin/peers"))
 	if err != nil {
 		return util.LogError(err)
 	}
@@ -19,9 +19,81 @@
 package tendermint
 
 import (
+	"fmt"
+	"github.com/whiteblock/genesis/protocols/helpers"
 	"github.com/whiteblock/genesis/protocols/services"
 )
 
+// customApp is the abci app which is given by the user as an image, rather than being built into tendermint
+const customApp = "image"
+
+type tendermintConf struct {
+	// ABCIApp is the abci app the nodes run, either one of the apps built into tendermint or customApp
+	ABCIApp string `json:"abciApp" param:"oneof=kvstore|noop|persistent_kvstore|image"`
+	// ABCIImage is the image of the custom abci app, which is run as a sidecar of each node
+	ABCIImage string `json:"abciImage"`
+	// ABCICommand is the command which starts the custom abci app in its sidecar. The app must serve
+	// the abci socket protocol over tcp on all interfaces, on ABCIPort.
+	ABCICommand string `json:"abciCommand"`
+	// ABCIPort is the port of the custom abci app
+	ABCIPort int64 `json:"abciPort" param:"min=1,max=65535"`
+	// FullNodes is the number of nodes which follow the chain without being validators
+	FullNodes int64 `json:"fullNodes" param:"min=0"`
+	// SeedNodes is the number of nodes which run in seed mode, only crawling the network to give
+	// the other nodes their peers
+	SeedNodes int64 `json:"seedNodes" param:"min=0"`
+	// PowerDistribution is how the voting power is distributed among the validators
+	PowerDistribution string `json:"powerDistribution" param:"oneof=equal|linear|zipf|explicit"`
+	// BasePower is the voting power of the weakest validator
+	BasePower int64 `json:"basePower" param:"min=1"`
+	// Powers are the voting powers of the validators, for the explicit distribution
+	Powers []int64 `json:"powers"`
+}
+
+// node roles, the validators come first, followed by the full nodes and then by the seed nodes
+const (
+	validatorRole = "validator"
+	fullNodeRole  = "full"
+	seedNodeRole  = "seed"
+)
+
+func newConf(data map[string]interface{}) (*tendermintConf, error) {
+	out := new(tendermintConf)
+	err := helpers.HandleBlockchainConfig(blockchain, data, out)
+	if err != nil {
+		return nil, err
+	}
+	if out.ABCIApp == customApp && (len(out.ABCIImage) == 0 || len(out.ABCICommand) == 0) {
+		return nil, fmt.Errorf("abciImage and abciCommand must be given for the abci app \"%s\"", customApp)
+	}
+	return out, nil
+}
+
+// validators gets the number of validators among the given number of nodes
+func (tc tendermintConf) validators(nodes int) int {
+	return nodes - int(tc.FullNodes) - int(tc.SeedNodes)
+}
+
+// checkRoles checks that there is at least one validator among the given number of nodes
+func (tc tendermintConf) checkRoles(nodes int) error {
+	if tc.validators(nodes) < 1 {
+		return fmt.Errorf("%d full nodes and %d seed nodes leave no validators among %d nodes",
+			tc.FullNodes, tc.SeedNodes, nodes)
+	}
+	return nil
+}
+
+// role gets the role of the node with the given absolute number, among the given number of nodes
+func (tc tendermintConf) role(absNum int, nodes int) string {
+	switch {
+	case absNum < tc.validators(nodes):
+		return validatorRole
+	case absNum < nodes-int(tc.SeedNodes):
+		return fullNodeRole
+	}
+	return seedNodeRole
+}
+
 // GetServices returns the services which are used by tendermint
 func GetServices() []services.Service {
 	return nil
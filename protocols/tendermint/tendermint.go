@@ -28,9 +28,8 @@ import (
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
+	"strconv"
 	"strings"
-	"sync"
-	"time"
 )
 
 type validatorPubKey struct {
@@ -47,7 +46,17 @@ type validator struct {
 
 var conf *util.Config
 
-const blockchain = "tendermint"
+const (
+	blockchain = "tendermint"
+
+	// abciSideCar is the sidecar which runs the custom abci app of a node
+	abciSideCar = "abci"
+
+	// startCmd starts tendermint with the abci app, persistent peers, seeds and seed mode of the node,
+	// serving the rpc on all interfaces so that genesis can reach it
+	startCmd = "tendermint node --proxy_app=%s --p2p.persistent_peers=%s --p2p.seeds=%s --p2p.seed_mode=%s " +
+		"--rpc.laddr=tcp://0.0.0.0:26657"
+)
 
 func init() {
 	conf = util.GetConfig()
@@ -56,20 +65,54 @@ func init() {
 	registrar.RegisterServices(blockchain, GetServices)
 	registrar.RegisterDefaults(blockchain, helpers.DefaultGetDefaultsFn(blockchain))
 	registrar.RegisterParams(blockchain, helpers.DefaultGetParamsFn(blockchain))
+	registrar.RegisterResources(blockchain, "defaults.json", "params.json", "genesis.json.tmpl",
+		helpers.GenesisSchemaFile)
+	registrar.RegisterCommands(blockchain, startCmd)
+	registrar.RegisterConsensusProbe(blockchain, helpers.TendermintProbe(26657))
+	registrar.RegisterBroadcast(blockchain, helpers.TendermintBroadcast(26657))
+
+	registrar.RegisterSideCar(abciSideCar, registrar.SideCar{GetImage: func(tn *testnet.TestNet) string {
+		tconf, err := newConf(tn.LDD.Params)
+		if err != nil {
+			util.LogError(err)
+			return ""
+		}
+		return tconf.ABCIImage
+	}})
+	registrar.RegisterBuildSideCar(abciSideCar, startedWithNodes)
+	registrar.RegisterAddSideCar(abciSideCar, startedWithNodes)
+	registrar.RegisterBlockchainSideCars(blockchain, func(tn *testnet.TestNet) []string {
+		tconf, err := newConf(tn.LDD.Params)
+		if err != nil {
+			util.LogError(err)
+			return nil
+		}
+		if tconf.ABCIApp == customApp {
+			return []string{abciSideCar}
+		}
+		return nil
+	})
 }
 
 //ExecStart=/usr/bin/tendermint node --proxy_app=kvstore --p2p.persistent_peers=167b80242c300bf0ccfb3ced3dec60dc2a81776e@165.227.41.206:26656,3c7a5920811550c04bf7a0b2f1e02ab52317b5e6@165.227.43.146:26656,303a1a4312c30525c99ba66522dd81cca56a361a@159.89.115.32:26656,b686c2a7f4b1b46dca96af3a0f31a6a7beae0be4@159.89.119.125:26656
 
 //Build builds out a fresh new tendermint test network
 func Build(tn *testnet.TestNet) error {
+	tconf, err := newConf(tn.LDD.Params)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = tconf.checkRoles(tn.LDD.Nodes)
+	if err != nil {
+		return util.LogError(err)
+	}
 	//Ensure that genesis file has same chain_id
-	peers := []string{}
-	validators := []validator{}
+	peers := helpers.NewCollector()
+	validators := helpers.NewCollector()
 	tn.BuildState.SetBuildSteps(1 + (tn.LDD.Nodes * 4))
 	tn.BuildState.SetBuildStage("Initializing the nodes")
 
-	mux := sync.Mutex{}
-	err := helpers.AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
+	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
 		//init everything
 		_, err := client.DockerExec(node, "tendermint init")
 		if err != nil {
@@ -83,9 +126,7 @@ func Build(tn *testnet.TestNet) error {
 		}
 		nodeID := res[:len(res)-1]
 
-		mux.Lock()
-		peers = append(peers, fmt.Sprintf("%s@%s:26656", nodeID, node.GetIP()))
-		mux.Unlock()
+		peers.Set(node, fmt.Sprintf("%s@%s:26656", nodeID, node.GetIP()))
 
 		//Get the validators
 		res, err = client.DockerExec(node, "cat /root/.tendermint/config/genesis.json")
@@ -93,46 +134,15 @@ func Build(tn *testnet.TestNet) error {
 			return util.LogError(err)
 		}
 		tn.BuildState.IncrementBuildProgress()
-		var genesis map[string]interface{}
+		var genesis struct {
+			Validators []validator `json:"validators"`
+		}
 		err = json.Unmarshal([]byte(res), &genesis)
 		if err != nil {
 			return util.LogError(err)
 		}
-		validatorsRaw := genesis["validators"].([]interface{})
-		for _, validatorRaw := range validatorsRaw {
-			vdtr := validator{}
-
-			validatorData := validatorRaw.(map[string]interface{})
-
-			err = util.GetJSONString(validatorData, "address", &vdtr.Address)
-			if err != nil {
-				return util.LogError(err)
-			}
-
-			validatorPubKeyData := validatorData["pub_key"].(map[string]interface{})
-
-			err = util.GetJSONString(validatorPubKeyData, "type", &vdtr.PubKey.Type)
-			if err != nil {
-				return util.LogError(err)
-			}
-
-			err = util.GetJSONString(validatorPubKeyData, "value", &vdtr.PubKey.Value)
-			if err != nil {
-				return util.LogError(err)
-			}
-
-			err = util.GetJSONString(validatorData, "power", &vdtr.Power)
-			if err != nil {
-				return util.LogError(err)
-			}
-
-			err = util.GetJSONString(validatorData, "name", &vdtr.Name)
-			if err != nil {
-				return util.LogError(err)
-			}
-			mux.Lock()
-			validators = append(validators, vdtr)
-			mux.Unlock()
+		if tconf.role(node.GetAbsoluteNumber(), tn.LDD.Nodes) == validatorRole {
+			validators.Set(node, genesis.Validators)
 		}
 		tn.BuildState.IncrementBuildProgress()
 		return nil
@@ -142,50 +152,118 @@ func Build(tn *testnet.TestNet) error {
 	}
 	tn.BuildState.SetBuildStage("Propogating the genesis file")
 
+	genesisValidators := flattenValidators(validators)
+	powers, err := helpers.VotingPowers(tconf.PowerDistribution, tconf.BasePower, tconf.Powers, len(genesisValidators))
+	if err != nil {
+		return util.LogError(err)
+	}
+	for i := range genesisValidators {
+		genesisValidators[i].Power = strconv.FormatInt(powers[i], 10)
+	}
+
 	//distribute the created genensis file among the nodes
-	err = helpers.CopyBytesToAllNodes(tn, getGenesisFile(validators), "/root/.tendermint/config/genesis.json")
+	genesis, err := helpers.RenderGlobalBlockchainTemplate(tn, "genesis.json.tmpl", map[string]interface{}{
+		"genesisTime": tn.Keys().Time("tendermint/genesis").Format("2006-01-02T15:04:05.000000000Z"),
+		"validators":  genesisValidators,
+	})
 	if err != nil {
 		return util.LogError(err)
 	}
+	genesis, err = helpers.FinalizeGenesis(tn, genesis)
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = helpers.CopyBytesToAllNodes(tn, string(genesis), "/root/.tendermint/config/genesis.json")
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = helpers.RunHooks(tn, db.AfterGenesisHook)
+	if err != nil {
+		return util.LogError(err)
+	}
+
+	if tconf.ABCIApp == customApp {
+		tn.BuildState.SetBuildStage("Starting the abci apps")
+		err = startABCIApps(tn, tconf)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+
+	// the seed nodes are only given as seeds, never as persistent peers
+	persistentPeers := peers.Strings()
+	seeds := []string{}
+	roles := make([]string, len(persistentPeers))
+	for i := range persistentPeers {
+		roles[i] = tconf.role(i, tn.LDD.Nodes)
+		if roles[i] == seedNodeRole {
+			seeds = append(seeds, persistentPeers[i])
+			persistentPeers[i] = ""
+		}
+	}
+	tn.BuildState.SetExt("roles", roles)
 
 	tn.BuildState.SetBuildStage("Starting tendermint")
 	err = helpers.AllNodeExecCon(tn, func(client ssh.Client, server *db.Server, node ssh.Node) error {
 		defer tn.BuildState.IncrementBuildProgress()
-		peersCpy := make([]string, len(peers))
-		copy(peersCpy, peers)
-		return client.DockerRunMainDaemon(node, fmt.Sprintf("tendermint node --proxy_app=kvstore --p2p.persistent_peers=%s",
-			strings.Join(append(peersCpy[:node.GetAbsoluteNumber()], peersCpy[node.GetAbsoluteNumber()+1:]...), ",")))
+		proxyApp, err := getProxyApp(tn, tconf, node)
+		if err != nil {
+			return util.LogError(err)
+		}
+		if tconf.role(node.GetAbsoluteNumber(), tn.LDD.Nodes) == seedNodeRole {
+			return helpers.StartMainDaemon(tn, client, node, fmt.Sprintf(startCmd, proxyApp, "", "", "true"))
+		}
+		nodePeers, err := helpers.FilterPeers(tn, node, persistentPeers)
+		if err != nil {
+			return util.LogError(err)
+		}
+		return helpers.StartMainDaemon(tn, client, node, fmt.Sprintf(startCmd, proxyApp, strings.Join(nodePeers, ","),
+			strings.Join(seeds, ","), "false"))
 	})
 	return util.LogError(err)
 }
 
+// startABCIApps starts the custom abci app in the sidecar of each node. They are started by the build of
+// tendermint rather than by the sidecar build, as tendermint does not start without its app.
+func startABCIApps(tn *testnet.TestNet, tconf *tendermintConf) error {
+	ad, err := tn.SpawnAdjunct(false, 0)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return helpers.AllNodeExecConSC(ad, func(client ssh.Client, _ *db.Server, node ssh.Node) error {
+		return client.DockerExecdLog(node, tconf.ABCICommand)
+	})
+}
+
+// getProxyApp gets the abci app the given node connects to, which is either the name of an app built into
+// tendermint or the address of the custom app in the sidecar of the node
+func getProxyApp(tn *testnet.TestNet, tconf *tendermintConf, node ssh.Node) (string, error) {
+	if tconf.ABCIApp != customApp {
+		return tconf.ABCIApp, nil
+	}
+	sidecar, err := tn.GetNodesSideCar(node, abciSideCar)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tcp://%s:%d", sidecar.GetIP(), tconf.ABCIPort), nil
+}
+
+// startedWithNodes is the build of the abci sidecar, whose apps are started along with the nodes
+func startedWithNodes(ad *testnet.Adjunct) error {
+	return nil
+}
+
 // Add handles adding a node to the tendermint testnet
 // TODO
 func Add(tn *testnet.TestNet) error {
 	return nil
 }
 
-func getGenesisFile(vdtrs []validator) string {
-	validatorsStr, _ := json.Marshal(vdtrs)
-	return fmt.Sprintf(`{
-	  "genesis_time": "%s",
-	  "chain_id": "whiteblock",
-	  "consensus_params": {
-		"block_size": {
-		  "max_bytes": "22020096",
-		  "max_gas": "-1"
-		},
-		"evidence": {
-		  "max_age": "100000"
-		},
-		"validator": {
-		  "pub_key_types": [
-			"ed25519"
-		  ]
-		}
-	  },
-	  "validators": %s,
-	  "app_hash": "" 
-	}`, time.Now().Format("2006-01-02T15:04:05.000000000Z"),
-		validatorsStr)
+// flattenValidators gets the validators collected from each node, in the order of the nodes
+func flattenValidators(collected *helpers.Collector) []validator {
+	out := []validator{}
+	for _, vdtrs := range collected.Values() {
+		out = append(out, vdtrs.([]validator)...)
+	}
+	return out
 }
@@ -0,0 +1,58 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tendermint_test
+
+import (
+	"github.com/whiteblock/genesis/protocols/conformance"
+	"github.com/whiteblock/genesis/simulator"
+	"testing"
+	"time"
+)
+
+const genesisFile = "/root/.tendermint/config/genesis.json"
+
+// rules answer the commands whose output is used to build the genesis file
+var rules = []simulator.Rule{
+	{Pattern: `tendermint show_node_id`, Output: "d3b8e1c2a9f04e7b8c6d5a4f3e2d1c0b9a8f7e6d\n"},
+	{Pattern: `cat /root/\.tendermint/config/genesis\.json`, Output: `{"validators":[{"address":"B5A6A8E0","pub_key":` +
+		`{"type":"tendermint/PubKeyEd25519","value":"cOQZvh/h9ZioSeUMZB/1Vy1Xo5x2sjrVjlE/qHnYifM="},"power":"10","name":""}]}`},
+}
+
+func genesisOf(t *testing.T, seed string) string {
+	build, err := conformance.Run("tendermint", conformance.Options{Nodes: 2, Seed: seed, Rules: rules,
+		Timeout: 3 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, files := range build.TestNet.BuildState.ExpectedFiles {
+		if expected, ok := files[genesisFile]; ok && len(expected.Content) > 0 {
+			return expected.Content
+		}
+	}
+	t.Fatalf("the genesis file was not copied to the nodes, the build failed with %v", build.Err)
+	return ""
+}
+
+func TestBuild_SeededGenesis(t *testing.T) {
+	first := genesisOf(t, "tendermint")
+	time.Sleep(time.Second) // so that the genesis time would differ, were it the time of the build
+	if second := genesisOf(t, "tendermint"); second != first {
+		t.Errorf("two builds from the same seed gave different genesis files:\n%s\n%s", first, second)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package queue
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type delivery struct {
+	job    Job
+	worker string
+	seen   time.Time
+}
+
+type memoryBroker struct {
+	mux          sync.Mutex
+	claimTimeout time.Duration
+	next         int
+	jobs         []Job
+	pending      map[string]*delivery
+	statuses     map[string]Status
+	servers      map[int]string
+	wake         chan struct{}
+}
+
+// NewMemoryBroker creates a broker which keeps the queue in memory, for workers in the same process.
+// The jobs of workers without a heartbeat for the claim timeout are given to other workers.
+func NewMemoryBroker(claimTimeout time.Duration) Broker {
+	return &memoryBroker{
+		claimTimeout: claimTimeout,
+		pending:      map[string]*delivery{},
+		statuses:     map[string]Status{},
+		servers:      map[int]string{},
+		wake:         make(chan struct{}),
+	}
+}
+
+func (mb *memoryBroker) Publish(job Job) error {
+	mb.mux.Lock()
+	defer mb.mux.Unlock()
+	mb.next++
+	job.ID = strconv.Itoa(mb.next)
+	mb.jobs = append(mb.jobs, job)
+	close(mb.wake) //wake up the waiting workers
+	mb.wake = make(chan struct{})
+	return nil
+}
+
+// take gives the next job, preferring the jobs abandoned by other workers
+func (mb *memoryBroker) take(worker string) (*Job, <-chan struct{}) {
+	mb.mux.Lock()
+	defer mb.mux.Unlock()
+	now := time.Now()
+	for _, d := range mb.pending {
+		if now.Sub(d.seen) >= mb.claimTimeout {
+			d.worker = worker
+			d.seen = now
+			job := d.job
+			return &job, nil
+		}
+	}
+	if len(mb.jobs) == 0 {
+		return nil, mb.wake
+	}
+	job := mb.jobs[0]
+	mb.jobs = mb.jobs[1:]
+	mb.pending[job.ID] = &delivery{job: job, worker: worker, seen: now}
+	return &job, nil
+}
+
+func (mb *memoryBroker) Receive(worker string, wait time.Duration) (*Job, error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		job, wake := mb.take(worker)
+		if job != nil {
+			return job, nil
+		}
+		select {
+		case <-wake:
+		case <-timer.C:
+			job, _ = mb.take(worker)
+			return job, nil
+		}
+	}
+}
+
+func (mb *memoryBroker) Heartbeat(worker string, job Job) error {
+	mb.mux.Lock()
+	defer mb.mux.Unlock()
+	d, ok := mb.pending[job.ID]
+	if !ok || d.worker != worker {
+		return fmt.Errorf("job %s is not held by %s", job.ID, worker)
+	}
+	d.seen = time.Now()
+	return nil
+}
+
+func (mb *memoryBroker) Ack(job Job) error {
+	mb.mux.Lock()
+	defer mb.mux.Unlock()
+	delete(mb.pending, job.ID)
+	return nil
+}
+
+func (mb *memoryBroker) SetStatus(testnetID string, status Status) error {
+	mb.mux.Lock()
+	defer mb.mux.Unlock()
+	mb.statuses[testnetID] = status
+	return nil
+}
+
+func (mb *memoryBroker) GetStatus(testnetID string) (Status, error) {
+	mb.mux.Lock()
+	defer mb.mux.Unlock()
+	status, ok := mb.statuses[testnetID]
+	if !ok {
+		return Status{}, fmt.Errorf("no job for testnet %s", testnetID)
+	}
+	return status, nil
+}
+
+func (mb *memoryBroker) AcquireServers(servers []int, testnetID string) error {
+	mb.mux.Lock()
+	defer mb.mux.Unlock()
+	for _, server := range servers {
+		if holder, ok := mb.servers[server]; ok && holder != testnetID {
+			return fmt.Errorf("build in progress on server %d", server)
+		}
+	}
+	for _, server := range servers {
+		mb.servers[server] = testnetID
+	}
+	return nil
+}
+
+func (mb *memoryBroker) ReleaseServers(servers []int, testnetID string) error {
+	mb.mux.Lock()
+	defer mb.mux.Unlock()
+	for _, server := range servers {
+		if len(testnetID) == 0 || mb.servers[server] == testnetID {
+			delete(mb.servers, server)
+		}
+	}
+	return nil
+}
+
+func (mb *memoryBroker) Close() error {
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryBroker_Receive(t *testing.T) {
+	mb := NewMemoryBroker(time.Hour)
+	job, err := mb.Receive("a", 10*time.Millisecond)
+	if err != nil || job != nil {
+		t.Fatalf("expected no job, got %v and %v", job, err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		mb.Publish(Job{TestNetID: "1"})
+	}()
+	job, err = mb.Receive("a", time.Second)
+	if err != nil || job == nil || job.TestNetID != "1" {
+		t.Fatalf("expected the published job, got %v and %v", job, err)
+	}
+	other, _ := mb.Receive("b", 10*time.Millisecond)
+	if other != nil {
+		t.Errorf("expected the job to only be given once, got %v", other)
+	}
+	if mb.Heartbeat("b", *job) == nil {
+		t.Error("expected a heartbeat from another worker to fail")
+	}
+	if err := mb.Heartbeat("a", *job); err != nil {
+		t.Error(err)
+	}
+	mb.Ack(*job)
+	if mb.Heartbeat("a", *job) == nil {
+		t.Error("expected a heartbeat of an acknowledged job to fail")
+	}
+}
+
+func TestMemoryBroker_Reclaim(t *testing.T) {
+	mb := NewMemoryBroker(20 * time.Millisecond)
+	mb.Publish(Job{TestNetID: "1"})
+	job, _ := mb.Receive("a", 0)
+	if job == nil {
+		t.Fatal("expected a job")
+	}
+	time.Sleep(30 * time.Millisecond)
+	claimed, _ := mb.Receive("b", 0)
+	if claimed == nil || claimed.ID != job.ID {
+		t.Fatalf("expected the abandoned job to be given to another worker, got %v", claimed)
+	}
+	if mb.Heartbeat("a", *job) == nil {
+		t.Error("expected the job to no longer be held by the first worker")
+	}
+}
+
+func TestMemoryBroker_Servers(t *testing.T) {
+	mb := NewMemoryBroker(time.Hour)
+	if err := mb.AcquireServers([]int{1, 2}, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if mb.AcquireServers([]int{2, 3}, "b") == nil {
+		t.Error("expected server 2 to be reserved")
+	}
+	if err := mb.AcquireServers([]int{3}, "b"); err != nil {
+		t.Error(err)
+	}
+	mb.ReleaseServers([]int{1, 2, 3}, "a")
+	if mb.AcquireServers([]int{3}, "c") == nil {
+		t.Error("expected server 3 to still be reserved by b")
+	}
+	if err := mb.AcquireServers([]int{1, 2}, "c"); err != nil {
+		t.Error(err)
+	}
+	mb.ReleaseServers([]int{1, 2, 3}, "")
+	if err := mb.AcquireServers([]int{1, 2, 3}, "d"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package queue dispatches the builds of testnets as jobs to worker processes through a message queue,
+// so that the builds of many testnets are spread across several genesis workers rather than all running
+// in the api server, and so that the build of a worker which goes away is picked up by another worker.
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+// The states of a job
+const (
+	QueuedState   = "queued"
+	BuildingState = "building"
+	DoneState     = "done"
+	FailedState   = "failed"
+)
+
+// Job is the build of a testnet, dispatched to a worker
+type Job struct {
+	// ID identifies the job in the queue, it is given by the broker
+	ID string `json:"-"`
+	// TestNetID is the id of the testnet to build
+	TestNetID string `json:"testnetId"`
+	// Details are the deployment details of the testnet
+	Details db.DeploymentDetails `json:"details"`
+	// Jwt is the jwt of the creator of the testnet, which is not part of the serialized details
+	Jwt string `json:"jwt,omitempty"`
+	// Enqueued is when the job was added to the queue
+	Enqueued time.Time `json:"enqueued"`
+}
+
+// Status is the latest status of a job, as reported by its worker
+type Status struct {
+	State string `json:"state"`
+	// Worker is the name of the worker running the build
+	Worker string `json:"worker,omitempty"`
+	// Build is the build status of the testnet on the worker, as given by GET /status/build/{id}
+	Build json.RawMessage `json:"build,omitempty"`
+	// Updated is when the status was last reported
+	Updated time.Time `json:"updated"`
+}
+
+// Broker is the message queue which carries the jobs to the workers, and the state shared between
+// the api server and the workers
+type Broker interface {
+	// Publish adds a job to the queue
+	Publish(job Job) error
+	// Receive gives the next job for the given worker, waiting up to wait for one, or nil when there is none.
+	// The jobs of workers which have not sent a heartbeat within queueClaimTimeout are given out again.
+	Receive(worker string, wait time.Duration) (*Job, error)
+	// Heartbeat signals that the worker is still running the job
+	Heartbeat(worker string, job Job) error
+	// Ack removes a finished job from the queue
+	Ack(job Job) error
+	// SetStatus stores the status of the job of a testnet
+	SetStatus(testnetID string, status Status) error
+	// GetStatus gets the status of the job of a testnet
+	GetStatus(testnetID string) (Status, error)
+	// AcquireServers reserves the servers for the build of a testnet, failing if any of them
+	// is already reserved by another build
+	AcquireServers(servers []int, testnetID string) error
+	// ReleaseServers releases the servers reserved by the build of a testnet, or by any build if the testnet
+	// id is empty
+	ReleaseServers(servers []int, testnetID string) error
+	// Close releases the resources held by the broker
+	Close() error
+}
+
+var (
+	broker    Broker
+	brokerMux = sync.Mutex{}
+)
+
+// Enabled checks if the builds are dispatched to workers
+func Enabled() bool {
+	return len(conf.QueueBackend) > 0
+}
+
+func claimTimeout() time.Duration {
+	return time.Duration(conf.QueueClaimTimeout) * time.Second
+}
+
+// Connect creates a new broker for the configured queue backend
+func Connect() (Broker, error) {
+	switch conf.QueueBackend {
+	case "memory":
+		return NewMemoryBroker(claimTimeout()), nil
+	case "redis":
+		return NewRedisBroker(conf.QueueAddress, conf.QueueStream, claimTimeout())
+	}
+	return nil, fmt.Errorf("unsupported queue backend \"%s\"", conf.QueueBackend)
+}
+
+// Get gets the broker shared by this process, connecting to it the first time
+func Get() (Broker, error) {
+	brokerMux.Lock()
+	defer brokerMux.Unlock()
+	if broker != nil {
+		return broker, nil
+	}
+	var err error
+	broker, err = Connect()
+	return broker, err
+}
+
+// Enqueue dispatches the build of a testnet to the workers, after reserving its servers
+func Enqueue(details db.DeploymentDetails, testnetID string) error {
+	b, err := Get()
+	if err != nil {
+		return err
+	}
+	err = b.AcquireServers(details.Servers, testnetID)
+	if err != nil {
+		return err
+	}
+	err = b.SetStatus(testnetID, Status{State: QueuedState, Updated: time.Now()})
+	if err == nil {
+		err = b.Publish(Job{TestNetID: testnetID, Details: details, Jwt: details.GetJwt(), Enqueued: time.Now()})
+	}
+	if err != nil {
+		util.LogError(b.ReleaseServers(details.Servers, testnetID))
+		return err
+	}
+	return nil
+}
+
+// ForceRelease releases the given servers, whichever build reserved them
+func ForceRelease(servers []int) error {
+	b, err := Get()
+	if err != nil {
+		return err
+	}
+	return b.ReleaseServers(servers, "")
+}
+
+// GetStatus gets the status of the job of a testnet
+func GetStatus(testnetID string) (Status, error) {
+	b, err := Get()
+	if err != nil {
+		return Status{}, err
+	}
+	return b.GetStatus(testnetID)
+}
+
+// BuildStatus gives the build status of a testnet built by a worker, in the format of GET /status/build/{id}
+func BuildStatus(testnetID string) (string, error) {
+	status, err := GetStatus(testnetID)
+	if err != nil {
+		return "", err
+	}
+	if len(status.Build) == 0 {
+		return `{"progress":0,"error":null,"stage":"Queued"}`, nil
+	}
+	return string(status.Build), nil
+}
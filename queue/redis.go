@@ -0,0 +1,289 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package queue
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	group        = "workers"
+	dialTimeout  = 10 * time.Second
+	statusExpiry = 7 * 24 * time.Hour
+)
+
+// releaseScript only deletes the reservation of a server if it is held by the given build
+const releaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) end return 0`
+
+type redisError string
+
+func (re redisError) Error() string {
+	return string(re)
+}
+
+// redisConn is a connection to redis, speaking just enough of its protocol for the commands genesis needs
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func newRedisConn(conn net.Conn) *redisConn {
+	return &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (rc *redisConn) write(args ...string) error {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("*%d\r\n", len(args)))
+	for _, arg := range args {
+		sb.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg))
+	}
+	_, err := rc.conn.Write([]byte(sb.String()))
+	return err
+}
+
+// read reads a reply, which is either nil, a string, an int64 or a slice of replies
+func (rc *redisConn) read() (interface{}, error) {
+	line, err := rc.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if len(line) == 0 {
+		return nil, fmt.Errorf("empty reply from redis")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		_, err = io.ReadFull(rc.reader, buf)
+		if err != nil {
+			return nil, err
+		}
+		return string(buf[:size]), nil
+	case '*':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		out := make([]interface{}, size)
+		for i := range out {
+			out[i], err = rc.read()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unexpected reply from redis: %s", line)
+}
+
+func (rc *redisConn) do(args ...string) (interface{}, error) {
+	err := rc.write(args...)
+	if err != nil {
+		return nil, err
+	}
+	return rc.read()
+}
+
+type redisBroker struct {
+	address      string
+	stream       string
+	claimTimeout time.Duration
+}
+
+// NewRedisBroker creates a broker on a redis stream, which is shared by the api servers and the workers
+func NewRedisBroker(address string, stream string, claimTimeout time.Duration) (Broker, error) {
+	rb := &redisBroker{address: address, stream: stream, claimTimeout: claimTimeout}
+	_, err := rb.do(0, "XGROUP", "CREATE", stream, group, "0", "MKSTREAM")
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, err
+	}
+	return rb, nil
+}
+
+// do runs a command on a new connection, which may block for up to wait
+func (rb *redisBroker) do(wait time.Duration, args ...string) (interface{}, error) {
+	conn, err := net.DialTimeout("tcp", rb.address, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(dialTimeout + wait))
+	return newRedisConn(conn).do(args...)
+}
+
+func (rb *redisBroker) key(kind string, id string) string {
+	return rb.stream + ":" + kind + ":" + id
+}
+
+func (rb *redisBroker) Publish(job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = rb.do(0, "XADD", rb.stream, "*", "job", string(data))
+	return err
+}
+
+// parseEntries parses the job out of the first of the given stream entries
+func parseEntries(reply interface{}) (*Job, error) {
+	entries, ok := reply.([]interface{})
+	if !ok || len(entries) == 0 {
+		return nil, nil
+	}
+	entry, ok := entries[0].([]interface{})
+	if !ok || len(entry) != 2 {
+		return nil, fmt.Errorf("unexpected stream entry %v", entries[0])
+	}
+	id, _ := entry[0].(string)
+	fields, _ := entry[1].([]interface{})
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] != "job" {
+			continue
+		}
+		data, _ := fields[i+1].(string)
+		job := &Job{}
+		err := json.Unmarshal([]byte(data), job)
+		if err != nil {
+			return nil, err
+		}
+		job.ID = id
+		return job, nil
+	}
+	return nil, fmt.Errorf("stream entry %s has no job", id)
+}
+
+func (rb *redisBroker) Receive(worker string, wait time.Duration) (*Job, error) {
+	claimed, err := rb.do(0, "XAUTOCLAIM", rb.stream, group, worker,
+		strconv.FormatInt(rb.claimTimeout.Milliseconds(), 10), "0-0", "COUNT", "1")
+	if err != nil {
+		return nil, err
+	}
+	if reply, ok := claimed.([]interface{}); ok && len(reply) > 1 {
+		job, err := parseEntries(reply[1])
+		if job != nil || err != nil {
+			return job, err
+		}
+	}
+	read, err := rb.do(wait, "XREADGROUP", "GROUP", group, worker, "COUNT", "1",
+		"BLOCK", strconv.FormatInt(wait.Milliseconds(), 10), "STREAMS", rb.stream, ">")
+	if err != nil || read == nil {
+		return nil, err
+	}
+	streams, ok := read.([]interface{})
+	if !ok || len(streams) == 0 {
+		return nil, nil
+	}
+	stream, ok := streams[0].([]interface{})
+	if !ok || len(stream) != 2 {
+		return nil, fmt.Errorf("unexpected reply from redis %v", streams[0])
+	}
+	return parseEntries(stream[1])
+}
+
+func (rb *redisBroker) Heartbeat(worker string, job Job) error {
+	_, err := rb.do(0, "XCLAIM", rb.stream, group, worker, "0", job.ID, "JUSTID")
+	return err
+}
+
+func (rb *redisBroker) Ack(job Job) error {
+	_, err := rb.do(0, "XACK", rb.stream, group, job.ID)
+	if err != nil {
+		return err
+	}
+	_, err = rb.do(0, "XDEL", rb.stream, job.ID)
+	return err
+}
+
+func (rb *redisBroker) SetStatus(testnetID string, status Status) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	_, err = rb.do(0, "SET", rb.key("status", testnetID), string(data),
+		"EX", strconv.Itoa(int(statusExpiry.Seconds())))
+	return err
+}
+
+func (rb *redisBroker) GetStatus(testnetID string) (Status, error) {
+	var status Status
+	reply, err := rb.do(0, "GET", rb.key("status", testnetID))
+	if err != nil {
+		return status, err
+	}
+	data, ok := reply.(string)
+	if !ok {
+		return status, fmt.Errorf("no job for testnet %s", testnetID)
+	}
+	return status, json.Unmarshal([]byte(data), &status)
+}
+
+func (rb *redisBroker) AcquireServers(servers []int, testnetID string) error {
+	for i, server := range servers {
+		key := rb.key("server", strconv.Itoa(server))
+		reply, err := rb.do(0, "SET", key, testnetID, "NX")
+		if err == nil && reply == nil {
+			reply, err = rb.do(0, "GET", key)
+			if err == nil && reply != testnetID {
+				err = fmt.Errorf("build in progress on server %d", server)
+			}
+		}
+		if err != nil {
+			rb.ReleaseServers(servers[:i], testnetID)
+			return err
+		}
+	}
+	return nil
+}
+
+func (rb *redisBroker) ReleaseServers(servers []int, testnetID string) error {
+	for _, server := range servers {
+		key := rb.key("server", strconv.Itoa(server))
+		var err error
+		if len(testnetID) == 0 {
+			_, err = rb.do(0, "DEL", key)
+		} else {
+			_, err = rb.do(0, "EVAL", releaseScript, "1", key, testnetID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rb *redisBroker) Close() error {
+	return nil
+}
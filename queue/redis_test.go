@@ -0,0 +1,101 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package queue
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestRedisConn_read(t *testing.T) {
+	var test = []struct {
+		reply    string
+		expected interface{}
+		err      bool
+	}{
+		{reply: "+OK\r\n", expected: "OK"},
+		{reply: ":42\r\n", expected: int64(42)},
+		{reply: "$5\r\nhe\r\no\r\n", expected: "he\r\no"},
+		{reply: "$-1\r\n", expected: nil},
+		{reply: "*-1\r\n", expected: nil},
+		{reply: "*2\r\n$1\r\na\r\n*1\r\n:1\r\n", expected: []interface{}{"a", []interface{}{int64(1)}}},
+		{reply: "-BUSYGROUP Consumer Group name already exists\r\n", err: true},
+		{reply: "?\r\n", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			go func() {
+				server.Write([]byte(tt.reply))
+				server.Close()
+			}()
+			reply, err := newRedisConn(client).read()
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(reply, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, reply)
+			}
+		})
+	}
+}
+
+func TestRedisConn_write(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		newRedisConn(client).write("SET", "key", "a b")
+		client.Close()
+	}()
+	data, err := ioutil.ReadAll(bufio.NewReader(server))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$3\r\na b\r\n"
+	if string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, string(data))
+	}
+}
+
+func TestParseEntries(t *testing.T) {
+	entries := []interface{}{
+		[]interface{}{"1-0", []interface{}{"job", `{"testnetId":"abc","details":{"blockchain":"geth"}}`}},
+	}
+	job, err := parseEntries(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID != "1-0" || job.TestNetID != "abc" || job.Details.Blockchain != "geth" {
+		t.Errorf("unexpected job %+v", job)
+	}
+
+	job, err = parseEntries([]interface{}{})
+	if job != nil || err != nil {
+		t.Errorf("expected no job, got %v and %v", job, err)
+	}
+	_, err = parseEntries([]interface{}{[]interface{}{"2-0", nil}})
+	if err == nil {
+		t.Error("expected an error for an entry without a job")
+	}
+}
@@ -0,0 +1,149 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package queue
+
+import (
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/manager"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+	"time"
+)
+
+var (
+	// receiveWait is how long a worker waits for a job before checking if it should stop
+	receiveWait = 5 * time.Second
+	// heartbeatInterval is how often a worker reports the progress of its build
+	heartbeatInterval = 5 * time.Second
+)
+
+// build runs the build of a job on this worker, returning once it has finished
+var build = func(job Job) error {
+	details := job.Details
+	details.SetJwt(job.Jwt)
+	err := state.AcquireBuilding(details.Servers, job.TestNetID)
+	if err != nil {
+		return err
+	}
+	return manager.AddTestNet(&details, job.TestNetID)
+}
+
+// buildStatus gets the build status of a testnet built on this worker
+var buildStatus = status.CheckBuildStatus
+
+// Work runs the jobs from the broker, running up to concurrency of them at once, until stop is closed.
+// The builds in progress are finished before it returns.
+func Work(broker Broker, worker string, concurrency int, stop <-chan struct{}) {
+	log.WithFields(log.Fields{"worker": worker, "concurrency": concurrency}).Info("waiting for build jobs")
+	wg := sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				job, err := broker.Receive(worker, receiveWait)
+				if err != nil {
+					log.WithFields(log.Fields{"worker": worker, "error": err}).Error("failed to receive a job")
+					time.Sleep(receiveWait)
+					continue
+				}
+				if job != nil {
+					run(broker, worker, *job)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Start runs the workers of the memory backend in this process. The jobs of the other backends
+// are run by genesis worker.
+func Start() {
+	if conf.QueueBackend != "memory" {
+		return
+	}
+	b, err := Get()
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("failed to start the build workers")
+		return
+	}
+	go Work(b, "local", conf.QueueWorkers, nil)
+}
+
+func run(broker Broker, worker string, job Job) {
+	log.WithFields(log.Fields{"worker": worker, "build": job.TestNetID}).Info("starting a build job")
+	done := make(chan error, 1)
+	go func() {
+		done <- build(job)
+	}()
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	report(broker, worker, job, nil, false)
+	for {
+		select {
+		case err := <-done:
+			report(broker, worker, job, err, true)
+			util.LogError(broker.ReleaseServers(job.Details.Servers, job.TestNetID))
+			util.LogError(broker.Ack(job))
+			log.WithFields(log.Fields{"worker": worker, "build": job.TestNetID, "error": err}).Info(
+				"finished a build job")
+			return
+		case <-ticker.C:
+			util.LogError(broker.Heartbeat(worker, job))
+			report(broker, worker, job, nil, false)
+		}
+	}
+}
+
+// report stores the build status of the job, failing it if err is given
+func report(broker Broker, worker string, job Job, err error, finished bool) {
+	out := Status{State: BuildingState, Worker: worker, Updated: time.Now()}
+	res, statusErr := buildStatus(job.TestNetID)
+	if statusErr == nil {
+		out.Build = json.RawMessage(res)
+	}
+	if !finished {
+		if statusErr != nil {
+			return //the build has not started yet
+		}
+		util.LogError(broker.SetStatus(job.TestNetID, out))
+		return
+	}
+	var parsed struct {
+		Error interface{} `json:"error"`
+	}
+	json.Unmarshal(out.Build, &parsed)
+	out.State = DoneState
+	if err != nil || parsed.Error != nil {
+		out.State = FailedState
+	}
+	if err != nil && statusErr != nil {
+		out.Build, _ = json.Marshal(map[string]interface{}{"progress": 0, "error": map[string]string{
+			"what": err.Error()}, "stage": ""})
+	}
+	util.LogError(broker.SetStatus(job.TestNetID, out))
+}
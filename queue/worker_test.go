@@ -0,0 +1,125 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"testing"
+	"time"
+)
+
+func mockBuild(t *testing.T, fn func(job Job) error, statuses map[string]string) {
+	originalBuild, originalStatus := build, buildStatus
+	originalWait, originalInterval := receiveWait, heartbeatInterval
+	build = fn
+	buildStatus = func(id string) (string, error) {
+		res, ok := statuses[id]
+		if !ok {
+			return "", fmt.Errorf("no build %s", id)
+		}
+		return res, nil
+	}
+	receiveWait, heartbeatInterval = 10*time.Millisecond, 5*time.Millisecond
+	t.Cleanup(func() {
+		build, buildStatus = originalBuild, originalStatus
+		receiveWait, heartbeatInterval = originalWait, originalInterval
+	})
+}
+
+func waitFor(t *testing.T, mb Broker, id string, state string) Status {
+	for i := 0; i < 200; i++ {
+		status, err := mb.GetStatus(id)
+		if err == nil && status.State == state {
+			return status
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s never reached %s", id, state)
+	return Status{}
+}
+
+func TestWork(t *testing.T) {
+	built := make(chan string, 2)
+	mockBuild(t, func(job Job) error {
+		built <- job.TestNetID
+		time.Sleep(20 * time.Millisecond)
+		if job.TestNetID == "broken" {
+			return fmt.Errorf("no servers")
+		}
+		return nil
+	}, map[string]string{"ok": `{"progress":100,"error":null,"stage":"Finished"}`})
+
+	mb := NewMemoryBroker(time.Hour)
+	original := broker
+	broker = mb
+	defer func() { broker = original }()
+
+	for _, id := range []string{"ok", "broken"} {
+		if err := Enqueue(dbDetails(), id); err != nil && id == "ok" {
+			t.Fatal(err)
+		}
+	}
+	status, _ := mb.GetStatus("broken")
+	if status.State != "" {
+		t.Fatalf("expected the second build to be refused while the server is reserved, got %+v", status)
+	}
+	if out, _ := BuildStatus("ok"); out != `{"progress":0,"error":null,"stage":"Queued"}` {
+		t.Errorf("unexpected status of a queued build %s", out)
+	}
+
+	stop := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		Work(mb, "w", 2, stop)
+		close(finished)
+	}()
+	status = waitFor(t, mb, "ok", DoneState)
+	if status.Worker != "w" || string(status.Build) != `{"progress":100,"error":null,"stage":"Finished"}` {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	if err := Enqueue(dbDetails(), "broken"); err != nil {
+		t.Fatal(err)
+	}
+	status = waitFor(t, mb, "broken", FailedState)
+	var res map[string]interface{}
+	json.Unmarshal(status.Build, &res)
+	if res["error"].(map[string]interface{})["what"] != "no servers" {
+		t.Errorf("expected the error of the build, got %s", string(status.Build))
+	}
+	if err := mb.AcquireServers([]int{1}, "next"); err != nil {
+		t.Errorf("expected the servers to be released, got %v", err)
+	}
+
+	close(stop)
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Error("the workers did not stop")
+	}
+	if len(built) != 2 {
+		t.Errorf("expected 2 builds, got %d", len(built))
+	}
+}
+
+func dbDetails() db.DeploymentDetails {
+	return db.DeploymentDetails{Servers: []int{1}, Blockchain: "geth", Nodes: 2}
+}
@@ -0,0 +1,108 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+// Package resources holds the resource files of the blockchains, such as their config templates and
+// default genesis files, which are embedded into the binary so that genesis can be deployed without
+// a resources directory. The files in resourceDir, when it is given, take precedence over the
+// embedded files.
+package resources
+
+import (
+	"embed"
+	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//go:embed */*
+var embedded embed.FS
+
+var conf = util.GetConfig()
+
+// aliases maps the blockchains which share the resources of another blockchain to it
+var aliases = map[string]string{
+	"ethereum": "geth",
+}
+
+func resolve(blockchain string, file string) (string, error) {
+	if strings.Contains(blockchain, "..") || strings.Contains(file, "..") {
+		return "", fmt.Errorf("invalid resource: cannot contain \"..\"")
+	}
+	if alias, ok := aliases[blockchain]; ok {
+		blockchain = alias
+	}
+	return blockchain, nil
+}
+
+// ReadFile reads the given resource file of the blockchain, from resourceDir if it has the file,
+// otherwise from the embedded resources
+func ReadFile(blockchain string, file string) ([]byte, error) {
+	blockchain, err := resolve(blockchain, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.ResourceDir) > 0 {
+		data, err := ioutil.ReadFile(filepath.Join(conf.ResourceDir, blockchain, file))
+		if err == nil || !os.IsNotExist(err) {
+			return data, err
+		}
+	}
+	data, err := fs.ReadFile(embedded, path.Join(blockchain, file))
+	if err != nil {
+		return nil, fmt.Errorf("resource \"%s\" of %s not found", file, blockchain)
+	}
+	return data, nil
+}
+
+// List lists the names of the resource files of the blockchain, from both resourceDir
+// and the embedded resources
+func List(blockchain string) ([]string, error) {
+	blockchain, err := resolve(blockchain, "")
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]bool{}
+	if len(conf.ResourceDir) > 0 {
+		filepath.Walk(filepath.Join(conf.ResourceDir, blockchain), func(name string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				found[info.Name()] = true
+			}
+			return nil
+		})
+	}
+	fs.WalkDir(embedded, blockchain, func(name string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			found[path.Base(name)] = true
+		}
+		return nil
+	})
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no resources for \"%s\"", blockchain)
+	}
+	out := []string{}
+	for file := range found {
+		out = append(out, file)
+	}
+	sort.Strings(out)
+	return out, nil
+}
@@ -0,0 +1,111 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package resources
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	oldDir := conf.ResourceDir
+	conf.ResourceDir = dir
+	defer func() { conf.ResourceDir = oldDir }()
+
+	embeddedData, err := ReadFile("geth", "params.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embeddedData) == 0 {
+		t.Error("the embedded params.json of geth is empty")
+	}
+	aliasData, err := ReadFile("ethereum", "params.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(embeddedData, aliasData) {
+		t.Error("ethereum does not share the resources of geth")
+	}
+
+	err = os.MkdirAll(filepath.Join(dir, "geth"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "geth", "params.json"), []byte("[]"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadFile("geth", "params.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[]" {
+		t.Error("the file in resourceDir did not take precedence over the embedded file")
+	}
+
+	_, err = ReadFile("geth", "missing.json")
+	if err == nil {
+		t.Error("expected an error for a missing resource")
+	}
+	_, err = ReadFile("geth", "../tendermint/params.json")
+	if err == nil {
+		t.Error("expected an error for a relative path")
+	}
+}
+
+func TestList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	oldDir := conf.ResourceDir
+	conf.ResourceDir = dir
+	defer func() { conf.ResourceDir = oldDir }()
+
+	err = os.MkdirAll(filepath.Join(dir, "tendermint"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "tendermint", "config.toml"), []byte(""), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := List("tendermint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"config.toml", "defaults.json", "genesis.json.tmpl", "genesis.schema.json", "params.json"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("return value of List %v does not match expected value %v", files, expected)
+	}
+
+	_, err = List("doesnotexist")
+	if err == nil {
+		t.Error("expected an error for a blockchain without resources")
+	}
+}
@@ -0,0 +1,92 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/artifacts"
+	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"os"
+	"path"
+)
+
+func getTestNetArtifacts(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	out, err := artifacts.List(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func getTestNetArtifact(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	file, err := artifacts.Open(params["id"], params["name"])
+	if os.IsNotExist(err) {
+		http.Error(w, fmt.Sprintf("artifact \"%s\" not found", params["name"]), 404)
+		return
+	}
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	if info.IsDir() {
+		http.Error(w, fmt.Sprintf("artifact \"%s\" not found", params["name"]), 404)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", path.Base(params["name"])))
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+func collectTestNetArtifacts(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var sources []artifacts.Source
+	err := json.NewDecoder(r.Body).Decode(&sources)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	tn, err := testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	collected, err := deploy.CollectArtifacts(tn, sources)
+	if err != nil && collected == nil { //the sources were invalid
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(collected)
+}
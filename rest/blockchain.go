@@ -26,10 +26,10 @@ import (
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/manager"
 	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/resources"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/util"
-	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
@@ -47,18 +47,13 @@ func getConfFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	files, err := util.Lsr(fmt.Sprintf("./resources/" + params["blockchain"]))
+	files, err := resources.List(params["blockchain"])
 	if err != nil {
 		log.WithFields(log.Fields{"error": err, "blockchain": params["blockchain"]}).Error("not found")
 		http.Error(w, fmt.Sprintf("Nothing available for \"%s\"", params["blockchain"]), 500)
 		return
 	}
 
-	for i, file := range files {
-		index := strings.LastIndex(file, "/")
-		files[i] = file[index+1:]
-	}
-
 	json.NewEncoder(w).Encode(files)
 }
 
@@ -82,13 +77,10 @@ func getConfFile(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "relative path operators not allowed", 401)
 		return
 	}
-	path := "./resources/" + params["blockchain"] + "/" + params["file"]
-
-	log.WithFields(log.Fields{"path": path, "blockchain": params["blockchain"], "file": params["file"]}).Debug("got the file path")
-
-	data, err := ioutil.ReadFile(path)
+	data, err := resources.ReadFile(params["blockchain"], params["file"])
 	if err != nil {
-		log.WithFields(log.Fields{"path": path, "error": err}).Error("error reading the requested config")
+		log.WithFields(log.Fields{"blockchain": params["blockchain"], "file": params["file"],
+			"error": err}).Error("error reading the requested config")
 		http.Error(w, "File not found", 404)
 		return
 	}
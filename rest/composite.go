@@ -0,0 +1,82 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/composite"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+func createCompositeDeployment(w http.ResponseWriter, r *http.Request) {
+	var req composite.Request
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err := decoder.Decode(&req)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	jwt, err := util.ExtractJwt(r)
+	if err != nil && conf.RequireAuth {
+		http.Error(w, util.LogError(err).Error(), 403)
+		return
+	}
+	id, err := composite.Build(req, jwt)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte(id))
+}
+
+func getCompositeDeployments(w http.ResponseWriter, r *http.Request) {
+	util.LogError(json.NewEncoder(w).Encode(composite.List()))
+}
+
+func getCompositeDeployment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	out, err := composite.Get(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(out))
+}
+
+func deleteCompositeDeployment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	dep, err := composite.Get(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	if dep.State() == composite.BuildingState {
+		http.Error(w, "the composite deployment is still being built", 409)
+		return
+	}
+	err = composite.Destroy(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,80 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/consensus"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+func getTestNetConsensus(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	if !consensus.Watching(params["id"]) {
+		//The probes are not running if genesis was restarted since the testnet was built
+		tn, err := testnet.RestoreTestNet(params["id"])
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 404)
+			return
+		}
+		err = consensus.Watch(tn)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+	report, err := consensus.GetReport(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(report))
+}
+
+func getConsensusRules(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	util.LogError(json.NewEncoder(w).Encode(consensus.GetRules(params["id"])))
+}
+
+func setConsensusRules(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var rules []consensus.Rule
+	err := json.NewDecoder(r.Body).Decode(&rules)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	_, err = testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	if rules == nil {
+		rules = []consensus.Rule{}
+	}
+	err = consensus.SetRules(params["id"], rules)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,154 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/federation"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+func getFederationAgents(w http.ResponseWriter, r *http.Request) {
+	util.LogError(json.NewEncoder(w).Encode(federation.GetAgents()))
+}
+
+func setFederationAgent(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var agent federation.Agent
+	err := json.NewDecoder(r.Body).Decode(&agent)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	agent.Region = params["region"]
+	err = federation.SetAgent(agent)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func deleteFederationAgent(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	err := federation.RemoveAgent(params["region"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func createFederatedTestNet(w http.ResponseWriter, r *http.Request) {
+	var req federation.Request
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err := decoder.Decode(&req)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	_, err = util.ExtractJwt(r)
+	if err != nil && conf.RequireAuth {
+		http.Error(w, util.LogError(err).Error(), 403)
+		return
+	}
+	err = req.Validate(federation.GetAgents())
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	id, err := federation.Build(req, r.Header.Get("Authorization"))
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 502)
+		return
+	}
+	w.Write([]byte(id))
+}
+
+func getFederatedTestNets(w http.ResponseWriter, r *http.Request) {
+	util.LogError(json.NewEncoder(w).Encode(federation.List()))
+}
+
+func getFederatedTestNet(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	out, err := federation.GetStatus(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(out))
+}
+
+func deleteFederatedTestNet(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	_, err := federation.Get(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	err = federation.Destroy(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 502)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func agentCreateTestNet(w http.ResponseWriter, r *http.Request) {
+	err := federation.Authorize(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 403)
+		return
+	}
+	createTestNet(w, r)
+}
+
+func agentGetTestNet(w http.ResponseWriter, r *http.Request) {
+	err := federation.Authorize(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 403)
+		return
+	}
+	params := mux.Vars(r)
+	build, err := status.CheckBuildStatus(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	nodes, err := db.GetAllNodesByTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(federation.PartStatus{Build: json.RawMessage(build), Nodes: nodes}))
+}
+
+func agentDeleteTestNet(w http.ResponseWriter, r *http.Request) {
+	err := federation.Authorize(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 403)
+		return
+	}
+	deleteTestNet(w, r)
+}
@@ -0,0 +1,108 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"github.com/whiteblock/genesis/idempotency"
+	"github.com/whiteblock/genesis/util"
+	"io/ioutil"
+	"net/http"
+)
+
+// idempotencyKeyHeader is the header a client gives the idempotency key of a request in
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyReplayedHeader is set on a response which was given again to a retried request
+const idempotencyReplayedHeader = "Idempotent-Replayed"
+
+// responseRecorder keeps a copy of the response written through it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(data []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(data)
+	return rr.ResponseWriter.Write(data)
+}
+
+// idempotent makes a handler honor the Idempotency-Key header. The successful response to the first
+// request made with a key is kept, and given again to any retry of the request with the same key,
+// rather than having the request carried out again. A failed request is not kept, so it can be retried.
+func idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if len(key) == 0 {
+			next(w, r)
+			return
+		}
+		err := idempotency.ValidateKey(key)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		hash := idempotency.Hash(r.Method, r.URL.Path, body)
+
+		rec, ok, err := idempotency.Begin(key)
+		if err != nil {
+			http.Error(w, err.Error(), 409)
+			return
+		}
+		if ok {
+			if rec.Hash != hash {
+				http.Error(w, "the idempotency key was already used for a different request", 422)
+				return
+			}
+			if len(rec.ContentType) > 0 {
+				w.Header().Set("Content-Type", rec.ContentType)
+			}
+			w.Header().Set(idempotencyReplayedHeader, "true")
+			w.WriteHeader(rec.Status)
+			w.Write(rec.Body)
+			return
+		}
+
+		rr := &responseRecorder{ResponseWriter: w}
+		defer func() {
+			if rr.status < 200 || rr.status > 299 {
+				idempotency.Finish(key, nil)
+				return
+			}
+			idempotency.Finish(key, &idempotency.Record{Hash: hash, Status: rr.status,
+				ContentType: rr.Header().Get("Content-Type"), Body: rr.body.Bytes()})
+		}()
+		next(rr, r)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/maintenance"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maintenancePrefix is the prefix of the maintenance endpoints, which are still served in maintenance mode
+const maintenancePrefix = "/maintenance/"
+
+// maintenanceRetryAfter is how many seconds clients are told to wait before retrying a request rejected
+// because of maintenance mode
+const maintenanceRetryAfter = 300
+
+// rejectInMaintenance responds with 503 to the requests which would change something while genesis is in
+// maintenance mode. Only the requests which read, and those to the maintenance endpoints, are let through.
+func rejectInMaintenance(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, maintenancePrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		mode := maintenance.GetMode()
+		if !mode.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		msg := "genesis is in maintenance mode"
+		if len(mode.Reason) > 0 {
+			msg = fmt.Sprintf("%s: %s", msg, mode.Reason)
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(maintenanceRetryAfter))
+		http.Error(w, msg, 503)
+	})
+}
+
+func getMaintenanceMode(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(maintenance.GetMode())
+}
+
+func setMaintenanceMode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+	}
+	mode, err := maintenance.SetMode(true, req.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(mode)
+}
+
+func unsetMaintenanceMode(w http.ResponseWriter, r *http.Request) {
+	mode, err := maintenance.SetMode(false, "")
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(mode)
+}
+
+func collectGarbage(w http.ResponseWriter, r *http.Request) {
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	report, err := maintenance.CollectGarbage(dryRun)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(report)
+}
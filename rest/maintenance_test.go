@@ -0,0 +1,75 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"github.com/whiteblock/genesis/maintenance"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestRejectInMaintenance(t *testing.T) {
+	original := maintenance.GetMode()
+	defer maintenance.SetMode(original.Enabled, original.Reason)
+
+	handler := rejectInMaintenance(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+	var test = []struct {
+		method   string
+		path     string
+		enabled  bool
+		expected int
+	}{
+		{method: http.MethodPost, path: "/testnets/", enabled: true, expected: 503},
+		{method: http.MethodPut, path: "/servers/1", enabled: true, expected: 503},
+		{method: http.MethodDelete, path: "/testnets/4ac9d3b2", enabled: true, expected: 503},
+		{method: http.MethodPatch, path: "/servers/1", enabled: true, expected: 503},
+		{method: http.MethodGet, path: "/testnets/", enabled: true, expected: 200},
+		{method: http.MethodHead, path: "/testnets/", enabled: true, expected: 200},
+		{method: http.MethodOptions, path: "/testnets/", enabled: true, expected: 200},
+		{method: http.MethodDelete, path: "/maintenance/mode", enabled: true, expected: 200},
+		{method: http.MethodPost, path: "/maintenance/gc", enabled: true, expected: 200},
+		{method: http.MethodPost, path: "/testnets/", enabled: false, expected: 200},
+		{method: http.MethodDelete, path: "/testnets/4ac9d3b2", enabled: false, expected: 200},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := maintenance.SetMode(tt.enabled, "upgrading docker")
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.expected {
+				t.Errorf("%s %s gave %d, expected %d", tt.method, tt.path, w.Code, tt.expected)
+			}
+			retryAfter := w.Header().Get("Retry-After")
+			if tt.expected == 503 && retryAfter != strconv.Itoa(maintenanceRetryAfter) {
+				t.Errorf("expected a Retry-After of %d, got \"%s\"", maintenanceRetryAfter, retryAfter)
+			}
+			if tt.expected != 503 && len(retryAfter) > 0 {
+				t.Errorf("unexpected Retry-After on a request which was let through: %s", retryAfter)
+			}
+		})
+	}
+}
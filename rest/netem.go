@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/kubernetes"
 	netem "github.com/whiteblock/genesis/net"
 	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/util"
@@ -30,6 +31,51 @@ import (
 	"strconv"
 )
 
+// getKubernetesConfig gets the kubernetes configuration of the given testnet
+func getKubernetesConfig(testnetID string) (kubernetes.Config, error) {
+	details, err := db.GetBuildByTestnet(testnetID)
+	if err != nil {
+		return kubernetes.Config{}, err
+	}
+	return kubernetes.GetConfig(&details)
+}
+
+// writeNetemReport responds with the outcome of changing the network conditions of each node. The
+// status is 207 when only some of the nodes were changed and 500 when none of them were.
+func writeNetemReport(w http.ResponseWriter, report *netem.Report) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case report.Succeeded():
+		w.WriteHeader(http.StatusOK)
+	case report.Partial():
+		w.WriteHeader(http.StatusMultiStatus)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	util.LogError(json.NewEncoder(w).Encode(report))
+}
+
+// validateNetconfs checks the given netconfs, responding with a 422 giving the problems with each
+// of their fields if any of them are invalid. Returns whether they are all valid.
+func validateNetconfs(w http.ResponseWriter, netconfs []netem.Netconf) bool {
+	out := []netem.ValidationError{}
+	for _, netconf := range netconfs {
+		err := netconf.Validate()
+		if err == nil {
+			continue
+		}
+		util.LogError(err)
+		out = append(out, err.(netem.ValidationError))
+	}
+	if len(out) == 0 {
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	util.LogError(json.NewEncoder(w).Encode(out))
+	return false
+}
+
 func handleNet(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
@@ -41,6 +87,9 @@ func handleNet(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
+	if !validateNetconfs(w, netConf) {
+		return
+	}
 
 	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
@@ -48,12 +97,26 @@ func handleNet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = netem.ApplyAll(netConf, nodes)
+	cfg, err := getKubernetesConfig(params["testnetID"])
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 500)
 		return
 	}
-	w.Write([]byte("Success"))
+	reconcile := r.URL.Query().Get("reconcile") == "true"
+	if cfg.Enabled && reconcile {
+		http.Error(w, "reconciling network conditions is not supported on kubernetes", 400)
+		return
+	}
+	var report *netem.Report
+	switch {
+	case cfg.Enabled:
+		report = netem.ApplyAllOnKubernetes(cfg, netConf, nodes)
+	case reconcile:
+		report = netem.Reconcile(netConf, nodes)
+	default:
+		report = netem.ApplyAll(netConf, nodes)
+	}
+	writeNetemReport(w, report)
 }
 
 func handleNetAll(w http.ResponseWriter, r *http.Request) {
@@ -68,6 +131,9 @@ func handleNetAll(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
+	if !validateNetconfs(w, []netem.Netconf{netConf}) {
+		return
+	}
 
 	nodes, err := db.GetAllNodesByTestNet(params["testnetID"])
 	if err != nil {
@@ -75,12 +141,34 @@ func handleNetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	netem.RemoveAll(nodes)
-	err = netem.ApplyToAll(netConf, nodes)
+	cfg, err := getKubernetesConfig(params["testnetID"])
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 500)
+		return
 	}
-	w.Write([]byte("Success"))
+	reconcile := r.URL.Query().Get("reconcile") == "true"
+	if cfg.Enabled && reconcile {
+		http.Error(w, "reconciling network conditions is not supported on kubernetes", 400)
+		return
+	}
+	var report *netem.Report
+	switch {
+	case cfg.Enabled:
+		report = netem.ApplyToAllOnKubernetes(cfg, netConf, nodes)
+	case reconcile:
+		desired := []netem.Netconf{}
+		for _, node := range nodes {
+			if netConf.From != nil && *netConf.From == node.LocalID {
+				continue
+			}
+			netConf.Node = node.LocalID
+			desired = append(desired, netConf)
+		}
+		report = netem.Reconcile(desired, nodes)
+	default:
+		report = netem.ApplyToAll(netConf, nodes)
+	}
+	writeNetemReport(w, report)
 }
 
 func stopNet(w http.ResponseWriter, r *http.Request) {
@@ -92,9 +180,18 @@ func stopNet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	netem.RemoveAll(nodes)
-
-	w.Write([]byte("Success"))
+	cfg, err := getKubernetesConfig(params["testnetID"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	var report *netem.Report
+	if cfg.Enabled {
+		report = netem.RemoveAllOnKubernetes(cfg, nodes)
+	} else {
+		report = netem.RemoveAll(nodes)
+	}
+	writeNetemReport(w, report)
 }
 
 func getNet(w http.ResponseWriter, r *http.Request) {
@@ -154,10 +251,15 @@ func removeOrAddOutage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
-	switch r.Method {
-	case "POST":
+	oneWay := r.URL.Query().Get("oneWay") == "true"
+	switch {
+	case r.Method == "POST" && oneWay:
+		err = netem.MakeOneWayOutage(node1, node2)
+	case r.Method == "POST":
 		err = netem.MakeOutage(node1, node2)
-	case "DELETE":
+	case r.Method == "DELETE" && oneWay:
+		err = netem.RemoveOneWayOutage(node1, node2)
+	case r.Method == "DELETE":
 		err = netem.RemoveOutage(node1, node2)
 	default:
 		err = fmt.Errorf("unexpected http method")
@@ -215,9 +317,39 @@ func removeAllOutages(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	util.LogError(netem.ClearOutages(params["testnetID"]))
 	w.Write([]byte("Success"))
 }
 
+func getTestNetOutages(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	nodes, err := db.GetAllNodesByTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	if len(nodes) == 0 {
+		http.Error(w, fmt.Sprintf("testnet \"%s\" has no nodes", params["id"]), 404)
+		return
+	}
+	cfg, err := getKubernetesConfig(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	if cfg.Enabled {
+		http.Error(w, "outages are not supported on kubernetes", 400)
+		return
+	}
+	state, err := netem.GetOutageState(params["id"], nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(state))
+}
+
 func getAllOutages(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
@@ -233,7 +365,7 @@ func getAllOutages(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, util.LogError(err).Error(), 404)
 			return
 		}
-		conns, err := netem.GetCutConnections(client)
+		conns, err := netem.GetCutConnections(client, server.Subnets())
 		if err != nil {
 			http.Error(w, util.LogError(err).Error(), 500)
 			return
@@ -274,3 +406,58 @@ func getAllPartitions(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewEncoder(w).Encode(out)
 }
+
+func startTraffic(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	nodes, err := db.GetAllNodesByTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	if len(nodes) == 0 {
+		http.Error(w, fmt.Sprintf("testnet \"%s\" has no nodes", params["id"]), 404)
+		return
+	}
+	cfg, err := getKubernetesConfig(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	if cfg.Enabled {
+		http.Error(w, "traffic accounting is not supported on kubernetes", 400)
+		return
+	}
+	err = netem.StartTrafficAccounting(params["id"], nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func getTraffic(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	report, err := netem.GetTrafficReport(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+func stopTraffic(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	nodes, err := db.GetAllNodesByTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	err = netem.StopTrafficAccounting(params["id"], nodes)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,91 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/profiling"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"net/http/pprof"
+	"os"
+)
+
+// pprofTokenHeader is the header in which the pprofToken is given to the profiling endpoints
+const pprofTokenHeader = "X-Genesis-Pprof-Token"
+
+// requirePprofToken only lets through requests which give the pprofToken, either in the X-Genesis-Pprof-Token
+// header or, so that the endpoints can be given directly to go tool pprof, in the token query parameter.
+func requirePprofToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(conf.PprofToken) == 0 {
+			http.Error(w, "profiling endpoints are disabled, pprofToken is not set", 403)
+			return
+		}
+		token := r.Header.Get(pprofTokenHeader)
+		if len(token) == 0 {
+			token = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(conf.PprofToken)) != 1 {
+			http.Error(w, "invalid pprof token", 401)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// addPprofRoutes adds the runtime profiling endpoints of net/http/pprof and the endpoints serving the periodic
+// profiles to the router
+func addPprofRoutes(router *mux.Router) {
+	router.HandleFunc("/debug/pprof/cmdline", requirePprofToken(pprof.Cmdline)).Methods("GET")
+	router.HandleFunc("/debug/pprof/profile", requirePprofToken(pprof.Profile)).Methods("GET")
+	router.HandleFunc("/debug/pprof/symbol", requirePprofToken(pprof.Symbol)).Methods("GET", "POST")
+	router.HandleFunc("/debug/pprof/trace", requirePprofToken(pprof.Trace)).Methods("GET")
+	router.PathPrefix("/debug/pprof").HandlerFunc(requirePprofToken(pprof.Index)).Methods("GET")
+
+	router.HandleFunc("/debug/profiles", requirePprofToken(getProfiles)).Methods("GET")
+	router.HandleFunc("/debug/profiles/{name}", requirePprofToken(getProfile)).Methods("GET")
+}
+
+func getProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := profiling.List()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(profiles))
+}
+
+func getProfile(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	path, err := profiling.Path(name)
+	if os.IsNotExist(err) {
+		http.Error(w, fmt.Sprintf("profile \"%s\" not found", name), 404)
+		return
+	}
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", name))
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,42 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/queue"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+func getQueueJob(w http.ResponseWriter, r *http.Request) {
+	if !queue.Enabled() {
+		http.Error(w, util.LogError(fmt.Errorf("builds are not dispatched to workers")).Error(), 404)
+		return
+	}
+	params := mux.Vars(r)
+	out, err := queue.GetStatus(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(out))
+}
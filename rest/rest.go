@@ -24,8 +24,10 @@ import (
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/queue"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/tracing"
 	"github.com/whiteblock/genesis/util"
 	"net/http"
 	"strings"
@@ -48,17 +50,62 @@ func StartServer() {
 	router.HandleFunc("/servers/{id}", deleteServer).Methods("DELETE")
 	router.HandleFunc("/servers/{id}", updateServerInfo).Methods("UPDATE")
 
-	router.HandleFunc("/testnets", createTestNet).Methods("POST") //Create new test net
+	router.HandleFunc("/testnets", idempotent(createTestNet)).Methods("POST") //Create new test net
+	router.HandleFunc("/testnets", getTestNets).Methods("GET")
 
-	router.HandleFunc("/testnets/{id}", deleteTestNet).Methods("DELETE")
+	router.HandleFunc("/testnets/{id}", idempotent(deleteTestNet)).Methods("DELETE")
 
-	router.HandleFunc("/testnets/{id}/nodes", getTestNetNodes).Methods("GET")
+	router.HandleFunc("/testnets/{id}/nodes", getTestNetNodeStates).Methods("GET")
+	router.HandleFunc("/testnets/{id}/nodes/{n}/signal", signalTestNetNode).Methods("POST")
+	router.HandleFunc("/testnets/{id}/upgrade", upgradeNodes).Methods("POST")
+
+	router.HandleFunc("/testnets/{id}/expiry", getTestNetExpiry).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/history", getTestNetHistory).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/services", getTestNetServices).Methods("GET")
+	router.HandleFunc("/testnets/{id}/health", getTestNetHealth).Methods("GET")
+	router.HandleFunc("/testnets/{id}/verify", verifyTestNet).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/faucet", dripFaucet).Methods("POST")
+	router.HandleFunc("/testnets/{id}/fund", fundAccount).Methods("POST")
+	router.HandleFunc("/testnets/{id}/transactions", broadcastTransaction).Methods("POST")
+	router.HandleFunc("/testnets/{id}/contracts", deployContract).Methods("POST")
+
+	router.HandleFunc("/testnets/{id}/artifacts", getTestNetArtifacts).Methods("GET")
+	router.HandleFunc("/testnets/{id}/artifacts", collectTestNetArtifacts).Methods("POST")
+	router.HandleFunc("/testnets/{id}/artifacts/{name:.+}", getTestNetArtifact).Methods("GET")
+
+	router.HandleFunc("/testnets/{id}/capture", handleCapture).Methods("POST")
+
+	router.HandleFunc("/testnets/{id}/traffic", getTraffic).Methods("GET")
+	router.HandleFunc("/testnets/{id}/traffic", startTraffic).Methods("POST")
+	router.HandleFunc("/testnets/{id}/traffic", stopTraffic).Methods("DELETE")
+
+	router.HandleFunc("/testnets/{id}/outages", getTestNetOutages).Methods("GET")
+	router.HandleFunc("/testnets/{id}/consensus", getTestNetConsensus).Methods("GET")
+	router.HandleFunc("/testnets/{id}/consensus/rules", getConsensusRules).Methods("GET")
+	router.HandleFunc("/testnets/{id}/consensus/rules", setConsensusRules).Methods("PUT")
+
+	router.HandleFunc("/testnets/{id}/soak", getSoak).Methods("GET")
+	router.HandleFunc("/testnets/{id}/soak", startSoak).Methods("POST")
+	router.HandleFunc("/testnets/{id}/soak", stopSoak).Methods("DELETE")
+
+	router.HandleFunc("/testnets/{id}/scenarios", getScenarios).Methods("GET")
+	router.HandleFunc("/testnets/{id}/scenarios", startScenario).Methods("POST")
+	router.HandleFunc("/testnets/{id}/scenarios/{run}", getScenario).Methods("GET")
+	router.HandleFunc("/testnets/{id}/scenarios/{run}", stopScenario).Methods("DELETE")
+
+	router.HandleFunc("/testnets/{id}/diff", getTestNetDiff).Methods("GET")
+	router.HandleFunc("/testnets/{id}/diff/{from}/{to}", getTestNetDiff).Methods("GET")
 
 	/**Management Functions**/
 	router.HandleFunc("/status/nodes/{testnetID}", nodesStatus).Methods("GET")
 
 	router.HandleFunc("/status/build/{id}", buildStatus).Methods("GET")
 
+	router.HandleFunc("/status/ssh", sshStatus).Methods("GET")
+
 	router.HandleFunc("/params/{blockchain}", getBlockChainParams).Methods("GET")
 
 	router.HandleFunc("/state/{buildID}", getBlockChainState).Methods("GET")
@@ -81,12 +128,21 @@ func StartServer() {
 
 	router.HandleFunc("/nodes/kill/{testnetID}/{node}", killNode).Methods("POST")
 
+	router.HandleFunc("/nodes/exec/{testnetID}/{node}", execNode).Methods("POST")
+
+	router.HandleFunc("/ws/testnets/{id}/nodes/{node}/shell", nodeShell).Methods("GET")
+
 	router.HandleFunc("/build/{id}", stopBuild).Methods("DELETE")
 
 	router.HandleFunc("/build", getPreviousBuild).Methods("GET")
 
 	router.HandleFunc("/build/{id}", getBuild).Methods("GET")
 
+	router.HandleFunc("/build/{id}/trace", getBuildTrace).Methods("GET")
+
+	router.HandleFunc("/build/{id}/transcript", getBuildTranscript).Methods("GET")
+	router.HandleFunc("/builds/{id}/transcript", getBuildTranscript).Methods("GET")
+
 	router.HandleFunc("/build/freeze/{id}", freezeBuild).Methods("POST")
 
 	router.HandleFunc("/build/thaw/{id}", thawBuild).Methods("POST")
@@ -119,8 +175,59 @@ func StartServer() {
 	router.HandleFunc("/partition/{testnetID}", getAllPartitions).Methods("GET")
 
 	router.HandleFunc("/blockchains", getAllSupportedBlockchains).Methods("GET")
+
+	router.HandleFunc("/maintenance/gc", collectGarbage).Methods("POST")
+	router.HandleFunc("/maintenance/mode", getMaintenanceMode).Methods("GET")
+	router.HandleFunc("/maintenance/mode", setMaintenanceMode).Methods("PUT")
+	router.HandleFunc("/maintenance/mode", unsetMaintenanceMode).Methods("DELETE")
+
+	router.HandleFunc("/config", getConfig).Methods("GET")
+
+	router.HandleFunc("/simulator/commands", getSimulatorCommands).Methods("GET")
+	router.HandleFunc("/simulator/commands", resetSimulatorCommands).Methods("DELETE")
+
+	router.HandleFunc("/templates", getAllTemplates).Methods("GET")
+	router.HandleFunc("/templates", createTemplate).Methods("POST")
+	router.HandleFunc("/templates/{name}", getTemplate).Methods("GET")
+	router.HandleFunc("/templates/{name}", updateTemplate).Methods("PUT")
+	router.HandleFunc("/templates/{name}", deleteTemplate).Methods("DELETE")
+	router.HandleFunc("/templates/{name}/build", idempotent(buildTemplate)).Methods("POST")
+
+	router.HandleFunc("/webhooks", getAllWebhooks).Methods("GET")
+	router.HandleFunc("/webhooks", addWebhook).Methods("POST")
+	router.HandleFunc("/webhooks/{id}", getWebhook).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", deleteWebhook).Methods("DELETE")
+	router.HandleFunc("/testnets/{testnetID}/webhooks", addWebhook).Methods("POST")
+
+	router.HandleFunc("/federation/agents", getFederationAgents).Methods("GET")
+	router.HandleFunc("/federation/agents/{region}", setFederationAgent).Methods("PUT")
+	router.HandleFunc("/federation/agents/{region}", deleteFederationAgent).Methods("DELETE")
+	router.HandleFunc("/federation/testnets", getFederatedTestNets).Methods("GET")
+	router.HandleFunc("/federation/testnets", idempotent(createFederatedTestNet)).Methods("POST")
+	router.HandleFunc("/federation/testnets/{id}", getFederatedTestNet).Methods("GET")
+	router.HandleFunc("/federation/testnets/{id}", idempotent(deleteFederatedTestNet)).Methods("DELETE")
+
+	router.HandleFunc("/federation/agent/testnets", agentCreateTestNet).Methods("POST")
+	router.HandleFunc("/federation/agent/testnets/{id}", agentGetTestNet).Methods("GET")
+	router.HandleFunc("/federation/agent/testnets/{id}", agentDeleteTestNet).Methods("DELETE")
+
+	router.HandleFunc("/composites", getCompositeDeployments).Methods("GET")
+	router.HandleFunc("/composites", idempotent(createCompositeDeployment)).Methods("POST")
+	router.HandleFunc("/composites/{id}", getCompositeDeployment).Methods("GET")
+	router.HandleFunc("/composites/{id}", idempotent(deleteCompositeDeployment)).Methods("DELETE")
+
+	router.HandleFunc("/sweeps", getSweeps).Methods("GET")
+	router.HandleFunc("/sweeps", idempotent(startSweep)).Methods("POST")
+	router.HandleFunc("/sweeps/{id}", getSweep).Methods("GET")
+	router.HandleFunc("/sweeps/{id}/report", getSweepReport).Methods("GET")
+	router.HandleFunc("/sweeps/{id}", deleteSweep).Methods("DELETE")
+
+	router.HandleFunc("/queue/jobs/{id}", getQueueJob).Methods("GET")
+
+	addPprofRoutes(router)
+
 	log.WithFields(log.Fields{"socket": conf.Listen}).Info("listening for requests")
-	log.Fatal(http.ListenAndServe(conf.Listen, removeTrailingSlash(router)))
+	log.Fatal(http.ListenAndServe(conf.Listen, recoverPanics(removeTrailingSlash(rejectInMaintenance(router)))))
 }
 
 func removeTrailingSlash(next http.Handler) http.Handler {
@@ -130,6 +237,16 @@ func removeTrailingSlash(next http.Handler) http.Handler {
 	})
 }
 
+// recoverPanics responds with an error when a handler panics, rather than dropping the connection
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer util.Recover(func(err error) {
+			http.Error(w, err.Error(), 500)
+		})
+		next.ServeHTTP(w, r)
+	})
+}
+
 func nodesStatus(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	testnetID, ok := params["testnetID"]
@@ -160,6 +277,9 @@ func buildStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	res, err := status.CheckBuildStatus(buildID)
+	if err != nil && queue.Enabled() {
+		res, err = queue.BuildStatus(buildID) //built by a worker
+	}
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
@@ -167,6 +287,10 @@ func buildStatus(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(res))
 }
 
+func sshStatus(w http.ResponseWriter, r *http.Request) {
+	util.LogError(json.NewEncoder(w).Encode(status.GetPoolStats()))
+}
+
 func stopBuild(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	buildID, ok := params["id"]
@@ -232,7 +356,7 @@ func getPreviousBuild(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
-	json.NewEncoder(w).Encode(build)
+	json.NewEncoder(w).Encode(build.Redacted())
 }
 
 func getBuild(w http.ResponseWriter, r *http.Request) {
@@ -245,7 +369,21 @@ func getBuild(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
-	err = json.NewEncoder(w).Encode(build)
+	err = json.NewEncoder(w).Encode(build.Redacted())
+	if err != nil {
+		util.LogError(err)
+	}
+}
+
+func getBuildTrace(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	trace, err := tracing.GetTrace(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	err = json.NewEncoder(w).Encode(trace)
 	if err != nil {
 		util.LogError(err)
 	}
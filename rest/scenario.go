@@ -0,0 +1,89 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/scenario"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+func startScenario(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var s scenario.Scenario
+	err := json.NewDecoder(r.Body).Decode(&s)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	tn, err := testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	cfg, err := tn.GetKubernetesConfig()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	if cfg.Enabled {
+		http.Error(w, "scenarios are not supported on kubernetes", 400)
+		return
+	}
+	err = s.Validate(len(tn.Nodes))
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	run, err := scenario.Start(tn.TestNetID, s, scenario.NewTarget(tn))
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 409)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	util.LogError(json.NewEncoder(w).Encode(run))
+}
+
+func getScenarios(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	util.LogError(json.NewEncoder(w).Encode(scenario.List(params["id"])))
+}
+
+func getScenario(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	run, err := scenario.Get(params["id"], params["run"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(run))
+}
+
+func stopScenario(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	err := scenario.Stop(params["id"], params["run"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	w.Write([]byte("Success"))
+}
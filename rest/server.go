@@ -23,6 +23,7 @@ import (
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/secrets"
 	"github.com/whiteblock/genesis/util"
 	"net/http"
 	"strconv"
@@ -34,6 +35,9 @@ func getAllServerInfo(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 204)
 		return
 	}
+	for name, server := range servers {
+		servers[name] = server.Redacted()
+	}
 	json.NewEncoder(w).Encode(servers)
 }
 
@@ -50,7 +54,7 @@ func addNewServer(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
-	log.WithFields(log.Fields{"server": server}).Debug("adding server")
+	log.WithFields(log.Fields{"server": server.Redacted()}).Debug("adding server")
 
 	id, err := db.InsertServer(params["name"], server)
 	if err != nil {
@@ -73,7 +77,7 @@ func getServerInfo(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 404)
 		return
 	}
-	util.LogError(json.NewEncoder(w).Encode(server))
+	util.LogError(json.NewEncoder(w).Encode(server.Redacted()))
 }
 
 func deleteServer(w http.ResponseWriter, r *http.Request) {
@@ -109,6 +113,15 @@ func updateServerInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if server.SSHPrivateKey == secrets.Redacted { //keep the stored key when the server is sent back as given
+		old, _, err := db.GetServer(id)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 404)
+			return
+		}
+		server.SSHPrivateKey = old.SSHPrivateKey
+	}
+
 	err = db.UpdateServer(id, server)
 	if err != nil {
 		http.Error(w, util.LogError(err).Error(), 500)
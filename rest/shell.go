@@ -0,0 +1,279 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultShellCols = 80
+	defaultShellRows = 24
+)
+
+var shellUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+// shellResize is the control message a client sends to change the dimensions of its terminal
+type shellResize struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+}
+
+// shellAuditRecord is an entry in the shell audit log
+type shellAuditRecord struct {
+	Time    time.Time `json:"time"`
+	Session string    `json:"session"`
+	Testnet string    `json:"testnet"`
+	Node    int       `json:"node"`
+	Kid     string    `json:"kid,omitempty"`
+	Remote  string    `json:"remote"`
+	Event   string    `json:"event"`
+	Data    string    `json:"data,omitempty"`
+}
+
+// shellAudit records the lifetime of a shell session, along with each line of input given to it
+type shellAudit struct {
+	record shellAuditRecord
+	line   []byte
+	mux    sync.Mutex
+}
+
+var shellAuditMux = sync.Mutex{}
+
+func shellAuditLogPath() string {
+	if len(conf.ShellAuditLog) > 0 {
+		return conf.ShellAuditLog
+	}
+	return filepath.Join(conf.DataDirectory, "shell-audit.log")
+}
+
+func (audit *shellAudit) write(event string, data string) {
+	record := audit.record
+	record.Time = time.Now()
+	record.Event = event
+	record.Data = data
+	raw, err := json.Marshal(record)
+	if err != nil {
+		util.LogError(err)
+		return
+	}
+	shellAuditMux.Lock()
+	defer shellAuditMux.Unlock()
+	file, err := os.OpenFile(shellAuditLogPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		util.LogError(err)
+		return
+	}
+	defer file.Close()
+	_, err = file.Write(append(raw, '\n'))
+	util.LogError(err)
+}
+
+// input records the given input, once a full line of it has been given
+func (audit *shellAudit) input(data []byte) {
+	audit.mux.Lock()
+	defer audit.mux.Unlock()
+	for _, b := range data {
+		if b != '\r' && b != '\n' {
+			audit.line = append(audit.line, b)
+			continue
+		}
+		if len(audit.line) > 0 {
+			audit.write("input", string(audit.line))
+			audit.line = nil
+		}
+	}
+}
+
+// end records the end of the session, along with any input which was not followed by a newline
+func (audit *shellAudit) end(reason string) {
+	audit.mux.Lock()
+	defer audit.mux.Unlock()
+	if len(audit.line) > 0 {
+		audit.write("input", string(audit.line))
+		audit.line = nil
+	}
+	audit.write("end", reason)
+}
+
+// getShellKid gets the kid of the user opening the shell. As browsers cannot set the headers
+// of a websocket, the token may also be given with the token query parameter.
+func getShellKid(r *http.Request) (string, error) {
+	jwt, err := util.ExtractJwt(r)
+	if err != nil {
+		jwt = r.URL.Query().Get("token")
+	}
+	return util.GetKidFromJwt(jwt)
+}
+
+func getShellDimension(r *http.Request, name string, def int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if len(raw) == 0 {
+		return def, nil
+	}
+	out, err := strconv.Atoi(raw)
+	if err != nil || out <= 0 {
+		return 0, fmt.Errorf("invalid %s \"%s\"", name, raw)
+	}
+	return out, nil
+}
+
+// nodeShell upgrades the connection to a websocket bridged to an interactive shell in the node
+func nodeShell(w http.ResponseWriter, r *http.Request) {
+	if !conf.EnableShell {
+		http.Error(w, "node shells are disabled", 403)
+		return
+	}
+	params := mux.Vars(r)
+	testnetID := params["id"]
+	nodeNum, err := strconv.Atoi(params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	kid, err := getShellKid(r)
+	if err != nil && conf.RequireAuth {
+		http.Error(w, util.LogError(err).Error(), 403)
+		return
+	}
+	cols, err := getShellDimension(r, "cols", defaultShellCols)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	rows, err := getShellDimension(r, "rows", defaultShellRows)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	if nodeNum < 0 || nodeNum >= len(tn.Nodes) {
+		http.Error(w, fmt.Sprintf("Node %d does not exist. Try node 0 through node %d", nodeNum, len(tn.Nodes)-1), 400)
+		return
+	}
+	node := tn.Nodes[nodeNum]
+
+	shell, err := tn.Clients[node.GetServerID()].Shell(node, cols, rows)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	defer shell.Close()
+
+	conn, err := shellUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		util.LogError(err) //the upgrader has already responded with the error
+		return
+	}
+	defer conn.Close()
+
+	session, err := util.GetUUIDString()
+	if err != nil {
+		util.LogError(err)
+		return
+	}
+	audit := &shellAudit{record: shellAuditRecord{
+		Session: session,
+		Testnet: testnetID,
+		Node:    nodeNum,
+		Kid:     kid,
+		Remote:  r.RemoteAddr,
+	}}
+	entry := log.WithFields(log.Fields{"session": session, "testnet": testnetID,
+		"node": nodeNum, "kid": kid, "remote": r.RemoteAddr})
+	entry.Info("opened a node shell")
+	audit.write("start", "")
+
+	reason := make(chan string, 2)
+	go func() {
+		reason <- pipeShellOutput(conn, shell)
+	}()
+	go func() {
+		reason <- pipeShellInput(conn, shell, audit)
+	}()
+	ended := <-reason
+	audit.end(ended)
+	entry.WithField("reason", ended).Info("closed a node shell")
+}
+
+// pipeShellOutput sends the output of the shell to the client, until the shell exits
+func pipeShellOutput(conn *websocket.Conn, shell ssh.Shell) string {
+	buf := make([]byte, 4096)
+	for {
+		n, err := shell.Read(buf)
+		if n > 0 {
+			if werr := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); werr != nil {
+				return "disconnected: " + werr.Error()
+			}
+		}
+		if err != nil {
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, "shell exited"),
+				time.Now().Add(time.Second))
+			return "shell exited"
+		}
+	}
+}
+
+// pipeShellInput sends the input of the client to the shell, until the client disconnects.
+// Binary messages are input, and text messages are resize requests.
+func pipeShellInput(conn *websocket.Conn, shell ssh.Shell, audit *shellAudit) string {
+	for {
+		msgType, data, err := conn.ReadMessage()
+		if err != nil {
+			return "disconnected: " + err.Error()
+		}
+		if msgType == websocket.TextMessage {
+			var resize shellResize
+			err = json.Unmarshal(data, &resize)
+			if err != nil || resize.Cols <= 0 || resize.Rows <= 0 {
+				log.WithFields(log.Fields{"message": string(data)}).Warn("ignoring an invalid shell resize")
+				continue
+			}
+			util.LogError(shell.Resize(resize.Cols, resize.Rows))
+			continue
+		}
+		audit.input(data)
+		_, err = shell.Write(data)
+		if err != nil {
+			return "shell exited"
+		}
+	}
+}
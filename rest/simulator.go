@@ -0,0 +1,67 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/simulator"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"strconv"
+)
+
+// getSimulator gets the simulator standing in for the servers, failing the request if it is not enabled
+func getSimulator(w http.ResponseWriter) *simulator.Simulator {
+	if !simulator.Enabled() {
+		http.Error(w, "the simulator is disabled", 404)
+		return nil
+	}
+	sim, err := simulator.Default()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return nil
+	}
+	return sim
+}
+
+func getSimulatorCommands(w http.ResponseWriter, r *http.Request) {
+	sim := getSimulator(w)
+	if sim == nil {
+		return
+	}
+	server := -1
+	if raw := r.URL.Query().Get("server"); len(raw) > 0 {
+		var err error
+		server, err = strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, util.LogError(fmt.Errorf("invalid server \"%s\"", raw)).Error(), 400)
+			return
+		}
+	}
+	util.LogError(json.NewEncoder(w).Encode(sim.Records(server)))
+}
+
+func resetSimulatorCommands(w http.ResponseWriter, r *http.Request) {
+	sim := getSimulator(w)
+	if sim == nil {
+		return
+	}
+	sim.Reset()
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,82 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/soak"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+func getSoak(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	status, err := soak.Get(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(status))
+}
+
+func startSoak(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var cfg soak.Config
+	err := json.NewDecoder(r.Body).Decode(&cfg)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	err = cfg.ValidateAndSetDefaults()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	tn, err := testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	if _, err := soak.Get(tn.TestNetID); err == nil {
+		http.Error(w, "the testnet is already being soak tested", 409)
+		return
+	}
+	session, err := soak.Start(tn, cfg)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(session))
+}
+
+func stopSoak(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	if _, err := soak.Get(params["id"]); err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	err := soak.Stop(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,98 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/sweep"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+func startSweep(w http.ResponseWriter, r *http.Request) {
+	var req sweep.Request
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err := decoder.Decode(&req)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	jwt, err := util.ExtractJwt(r)
+	if err != nil && conf.RequireAuth {
+		http.Error(w, util.LogError(err).Error(), 403)
+		return
+	}
+	id, err := sweep.Start(req, jwt)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte(id))
+}
+
+func getSweeps(w http.ResponseWriter, r *http.Request) {
+	util.LogError(json.NewEncoder(w).Encode(sweep.List()))
+}
+
+func getSweep(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	out, err := sweep.Get(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(out))
+}
+
+func getSweepReport(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	sw, err := sweep.Get(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	report := sweep.Compare(sw)
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		util.LogError(report.WriteCSV(w))
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(report))
+}
+
+func deleteSweep(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	sw, err := sweep.Get(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	if sw.State() == sweep.RunningState {
+		http.Error(w, "the sweep is still running", 409)
+		return
+	}
+	err = sweep.Delete(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Write([]byte("Success"))
+}
@@ -0,0 +1,105 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/templates"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"net/http"
+)
+
+func getAllTemplates(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(templates.GetAll())
+}
+
+func createTemplate(w http.ResponseWriter, r *http.Request) {
+	var tmpl templates.Template
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err := decoder.Decode(&tmpl)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	err = templates.Create(tmpl)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func getTemplate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tmpl, err := templates.Get(params["name"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	json.NewEncoder(w).Encode(tmpl)
+}
+
+func updateTemplate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var tmpl templates.Template
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err := decoder.Decode(&tmpl)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	err = templates.Update(params["name"], tmpl)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func deleteTemplate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	err := templates.Remove(params["name"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	w.Write([]byte("Success"))
+}
+
+func buildTemplate(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tn, err := templates.Instantiate(params["name"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	//Any fields given in the body override those of the template
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	err = decoder.Decode(&tn)
+	if err != nil && err != io.EOF {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	buildTestNet(w, r, &tn)
+}
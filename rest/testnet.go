@@ -24,16 +24,21 @@ import (
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/inventory"
 	"github.com/whiteblock/genesis/manager"
 	"github.com/whiteblock/genesis/protocols/helpers"
+	"github.com/whiteblock/genesis/queue"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/status"
 	"github.com/whiteblock/genesis/testnet"
 	"github.com/whiteblock/genesis/util"
+	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func createTestNet(w http.ResponseWriter, r *http.Request) {
@@ -45,6 +50,12 @@ func createTestNet(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, util.LogError(err).Error(), 400)
 		return
 	}
+	buildTestNet(w, r, tn)
+}
+
+// buildTestNet starts the build of a new testnet with the given deployment details, and
+// responds with the id of the new testnet
+func buildTestNet(w http.ResponseWriter, r *http.Request, tn *db.DeploymentDetails) {
 	jwt, err := util.ExtractJwt(r)
 	if err != nil && conf.RequireAuth {
 		http.Error(w, util.LogError(err).Error(), 403)
@@ -58,8 +69,29 @@ func createTestNet(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error Generating a new UUID", 500)
 		return
 	}
+	violations := inventory.Check(*tn)
+	if len(violations) > 0 {
+		log.WithFields(log.Fields{"violations": violations}).Error("the build does not fit on its servers")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		util.LogError(json.NewEncoder(w).Encode(violations))
+		return
+	}
 	_, ok := tn.Extras["forceUnlock"]
-	if ok && tn.Extras["forceUnlock"].(bool) {
+	forceUnlock := ok && tn.Extras["forceUnlock"].(bool)
+	if queue.Enabled() {
+		if forceUnlock {
+			util.LogError(queue.ForceRelease(tn.Servers))
+		}
+		err = queue.Enqueue(*tn, id)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 409)
+			return
+		}
+		w.Write([]byte(id))
+		return
+	}
+	if forceUnlock {
 		state.ForceUnlockServers(tn.Servers)
 	}
 	err = state.AcquireBuilding(tn.Servers, id)
@@ -74,6 +106,84 @@ func createTestNet(w http.ResponseWriter, r *http.Request) {
 
 }
 
+const (
+	// defaultTestNetsLimit is how many testnets are listed at once when no limit is given
+	defaultTestNetsLimit = 50
+	// maxTestNetsLimit is the most testnets which can be listed at once
+	maxTestNetsLimit = 500
+)
+
+// parseTestNetFilter gets the filter for the listing of the testnets from the query of the request
+func parseTestNetFilter(r *http.Request) (db.TestNetFilter, error) {
+	query := r.URL.Query()
+	filter := db.TestNetFilter{
+		Blockchain: query.Get("blockchain"),
+		Status:     query.Get("status"),
+		Owner:      query.Get("owner"),
+		Limit:      defaultTestNetsLimit,
+	}
+	validStatus := len(filter.Status) == 0
+	for _, status := range db.TestNetStatuses {
+		validStatus = validStatus || status == filter.Status
+	}
+	if !validStatus {
+		return filter, fmt.Errorf("invalid status \"%s\", expected one of %s", filter.Status,
+			strings.Join(db.TestNetStatuses, ", "))
+	}
+	if filter.Owner == "me" {
+		jwt, err := util.ExtractJwt(r)
+		if err != nil {
+			return filter, err
+		}
+		filter.Owner, err = util.GetKidFromJwt(jwt)
+		if err != nil {
+			return filter, err
+		}
+	}
+	if raw := query.Get("createdAfter"); len(raw) > 0 {
+		created, err := time.Parse(time.RFC3339, raw)
+		if err == nil {
+			filter.CreatedAfter = created.Unix()
+		} else if filter.CreatedAfter, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return filter, fmt.Errorf("invalid createdAfter \"%s\", expected a unix timestamp or an RFC 3339 time", raw)
+		}
+	}
+	for name, dest := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		raw := query.Get(name)
+		if len(raw) == 0 {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return filter, fmt.Errorf("invalid %s \"%s\"", name, raw)
+		}
+		*dest = value
+	}
+	if filter.Limit == 0 || filter.Limit > maxTestNetsLimit {
+		filter.Limit = maxTestNetsLimit
+	}
+	return filter, nil
+}
+
+func getTestNets(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTestNetFilter(r)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	testnets, total, err := db.ListTestNets(filter)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(map[string]interface{}{
+		"testnets": testnets,
+		"total":    total,
+		"limit":    filter.Limit,
+		"offset":   filter.Offset,
+	}))
+}
+
 func deleteTestNet(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	err := manager.DeleteTestNet(params["id"])
@@ -85,6 +195,128 @@ func deleteTestNet(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Success"))
 }
 
+func getTestNetExpiry(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	expiry, err := manager.GetExpiry(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	json.NewEncoder(w).Encode(expiry)
+}
+
+func getTestNetHistory(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	deployments, err := db.GetDeployments(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	if len(deployments) == 0 {
+		http.Error(w, fmt.Sprintf("no deployments found for testnet \"%s\"", params["id"]), 404)
+		return
+	}
+	for i := range deployments {
+		deployments[i].Details = deployments[i].Details.Redacted()
+	}
+	json.NewEncoder(w).Encode(deployments)
+}
+
+func getTestNetDiff(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var from, to db.Deployment
+	if _, ok := params["from"]; ok {
+		fromRev, err := strconv.Atoi(params["from"])
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+		toRev, err := strconv.Atoi(params["to"])
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 400)
+			return
+		}
+		from, err = db.GetDeployment(params["id"], fromRev)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 404)
+			return
+		}
+		to, err = db.GetDeployment(params["id"], toRev)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 404)
+			return
+		}
+	} else {
+		deployments, err := db.GetDeployments(params["id"])
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
+		}
+		if len(deployments) < 2 {
+			http.Error(w, "need at least two deployments to compare", 404)
+			return
+		}
+		from = deployments[len(deployments)-2]
+		to = deployments[len(deployments)-1]
+	}
+
+	changes, err := util.Diff(from.Details.Redacted(), to.Details.Redacted())
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":    from.Revision,
+		"to":      to.Revision,
+		"changes": changes,
+	})
+}
+
+func getTestNetServices(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tn, err := testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	json.NewEncoder(w).Encode(tn.Services)
+}
+
+func getTestNetHealth(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tn, err := testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	health, err := deploy.CheckHealth(tn)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	json.NewEncoder(w).Encode(health)
+}
+
+func verifyTestNet(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tn, err := testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(deploy.VerifyFiles(tn)))
+}
+
+func getTestNetNodeStates(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	tn, err := testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(deploy.GetNodeStates(tn)))
+}
+
 func getTestNetNodes(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
@@ -126,6 +358,44 @@ func addNodes(w http.ResponseWriter, r *http.Request) {
 	go manager.AddNodes(&tn, testnetID)
 }
 
+func upgradeNodes(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var up deploy.Upgrade
+	err := json.NewDecoder(r.Body).Decode(&up)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	details, err := db.GetBuildByTestnet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	nodes, err := db.GetAllNodesByTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	err = up.Validate(details.Blockchain, len(nodes))
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	bs, err := state.GetBuildStateByID(params["id"])
+	if err != nil {
+		util.LogError(err)
+		http.Error(w, "Testnet is down, build a new one", 409)
+		return
+	}
+	if !bs.Done() {
+		http.Error(w, "There is a build already in progress on the testnet", 409)
+		return
+	}
+	bs.Reset()
+	w.Write([]byte("Upgrading the nodes"))
+	go manager.UpgradeNodes(up, params["id"])
+}
+
 func delNodes(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	num, err := strconv.Atoi(params["num"])
@@ -239,6 +509,55 @@ func restartNode(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Success"))
 }
 
+func signalTestNetNode(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nodeNum, err := strconv.Atoi(params["n"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	var req struct {
+		Signal string `json:"signal"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	signal, err := util.ParseSignal(req.Signal)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	tn, err := testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	var node *db.Node
+	for i := range tn.Nodes {
+		if tn.Nodes[i].AbsoluteNum == nodeNum {
+			node = &tn.Nodes[i]
+		}
+	}
+	if node == nil {
+		http.Error(w, fmt.Sprintf("node %d does not exist", nodeNum), 404)
+		return
+	}
+	res, err := deploy.SignalNode(tn, *node, signal)
+	if _, ok := err.(deploy.NotRunningError); ok {
+		http.Error(w, err.Error(), 409)
+		return
+	}
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	log.WithFields(log.Fields{"testnet": tn.TestNetID, "node": nodeNum, "pid": res.PID, "signal": signal,
+		"state": res.State}).Info("sent a signal to a node")
+	util.LogError(json.NewEncoder(w).Encode(res))
+}
+
 func signalNode(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	testnetID := params["testnetID"]
@@ -331,3 +650,41 @@ func killNode(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Write([]byte(fmt.Sprintf("Killed node %s", params["node"])))
 }
+
+func execNode(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	testnetID := params["testnetID"]
+	nodeNum, err := strconv.Atoi(params["node"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	command, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if len(strings.TrimSpace(string(command))) == 0 {
+		http.Error(w, "missing the command to execute", 400)
+		return
+	}
+	log.WithFields(log.Fields{"testnet": testnetID, "node": nodeNum, "command": string(command)}).Info(
+		"executing a command in a node")
+
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	if nodeNum < 0 || nodeNum >= len(tn.Nodes) {
+		http.Error(w, fmt.Sprintf("Node %d does not exist. Try node 0 through node %d", nodeNum, len(tn.Nodes)-1), 400)
+		return
+	}
+	node := tn.Nodes[nodeNum]
+	res, err := tn.Clients[node.GetServerID()].DockerExec(node, "sh -c "+util.ShellQuote(string(command)))
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	w.Write([]byte(res))
+}
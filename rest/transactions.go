@@ -0,0 +1,118 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/protocols/registrar"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+	"strings"
+)
+
+// decodeTx decodes a transaction given either hex encoded with a 0x prefix, or as a plain string
+func decodeTx(tx string) ([]byte, error) {
+	if !strings.HasPrefix(tx, "0x") {
+		return []byte(tx), nil
+	}
+	out, err := hex.DecodeString(tx[2:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction: %s", err.Error())
+	}
+	return out, nil
+}
+
+func broadcastTransaction(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var req struct {
+		Tx   string `json:"tx"`
+		Node int    `json:"node"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	tx, err := decodeTx(req.Tx)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if len(tx) == 0 {
+		http.Error(w, "a transaction is required", 400)
+		return
+	}
+	tn, err := testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	broadcast, err := registrar.GetBroadcastFunc(tn.LDD.Blockchain)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s does not support broadcasting transactions", tn.LDD.Blockchain), 400)
+		return
+	}
+	if req.Node < 0 || req.Node >= len(tn.Nodes) {
+		http.Error(w, fmt.Sprintf("node %d does not exist", req.Node), 404)
+		return
+	}
+	txHash, err := broadcast(tn, tn.Nodes[req.Node], tx)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(map[string]string{"tx": txHash}))
+}
+
+func fundAccount(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	var req struct {
+		Address string `json:"address"`
+		Amount  string `json:"amount"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	if len(req.Address) == 0 || len(req.Amount) == 0 {
+		http.Error(w, "both an address and an amount are required", 400)
+		return
+	}
+	tn, err := testnet.RestoreTestNet(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	fund, err := registrar.GetFundFunc(tn.LDD.Blockchain)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("%s does not support funding accounts", tn.LDD.Blockchain), 400)
+		return
+	}
+	txHash, err := fund(tn, req.Address, req.Amount)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	util.LogError(json.NewEncoder(w).Encode(map[string]string{"tx": txHash}))
+}
@@ -0,0 +1,59 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/util"
+	"net/http"
+)
+
+// getBuildTranscript gives the commands which were run for a build, as a bash script which runs them again,
+// or as json when the format query parameter is json. The commands are redacted when secrets mode is enabled.
+func getBuildTranscript(w http.ResponseWriter, r *http.Request) {
+	buildID := mux.Vars(r)["id"]
+	bs, err := state.GetBuildStateByID(buildID)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	transcript := bs.GetRedactedTranscript()
+	if r.URL.Query().Get("format") == "json" {
+		util.LogError(json.NewEncoder(w).Encode(transcript))
+		return
+	}
+	servers := map[int]db.Server{}
+	for _, entry := range transcript {
+		if _, ok := servers[entry.Server]; ok || entry.Local {
+			continue
+		}
+		server, _, err := db.GetServer(entry.Server)
+		if err != nil {
+			http.Error(w, util.LogError(err).Error(), 500)
+			return
+		}
+		servers[entry.Server] = server
+	}
+	w.Header().Set("Content-Type", "text/x-shellscript")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.sh\"", buildID))
+	w.Write([]byte(bs.TranscriptScript(servers)))
+}
@@ -0,0 +1,78 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/whiteblock/genesis/util"
+	"github.com/whiteblock/genesis/webhook"
+	"net/http"
+)
+
+func getAllWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := webhook.GetAll()
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 500)
+		return
+	}
+	for i := range hooks {
+		hooks[i] = hooks[i].Redacted()
+	}
+	json.NewEncoder(w).Encode(hooks)
+}
+
+func addWebhook(w http.ResponseWriter, r *http.Request) {
+	var hook webhook.Webhook
+	err := json.NewDecoder(r.Body).Decode(&hook)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	params := mux.Vars(r)
+	if testnetID, ok := params["testnetID"]; ok {
+		hook.TestNetID = testnetID
+	}
+	id, err := webhook.Register(hook)
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 400)
+		return
+	}
+	w.Write([]byte(id))
+}
+
+func getWebhook(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	hook, err := webhook.Get(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	json.NewEncoder(w).Encode(hook.Redacted())
+}
+
+func deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	err := webhook.Remove(params["id"])
+	if err != nil {
+		http.Error(w, util.LogError(err).Error(), 404)
+		return
+	}
+	w.Write([]byte("Success"))
+}
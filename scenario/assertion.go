@@ -0,0 +1,101 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package scenario
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var assertionRegex = regexp.MustCompile(`^\s*(?:(min|max|avg|last)\(\s*([a-zA-Z][a-zA-Z0-9_]*)\s*\)|([a-zA-Z][a-zA-Z0-9_]*))\s*(>=|<=|==|!=|>|<)\s*(-?[0-9]+(?:\.[0-9]+)?)\s*$`)
+
+// Assertion is a condition on a metric, such as "tps > 500". The samples of the metric taken during the
+// phase are reduced to a single value by the aggregate, which is the last sample unless one of min, max
+// or avg is given, as in "min(healthy) >= 4"
+type Assertion struct {
+	Aggregate string
+	Metric    string
+	Op        string
+	Value     float64
+}
+
+// ParseAssertion parses an assertion of the form "[aggregate(]metric[)] op value"
+func ParseAssertion(raw string) (Assertion, error) {
+	matches := assertionRegex.FindStringSubmatch(raw)
+	if matches == nil {
+		return Assertion{}, fmt.Errorf("invalid assertion \"%s\", expected something like \"tps > 500\"", raw)
+	}
+	out := Assertion{Aggregate: matches[1], Metric: matches[2], Op: matches[4]}
+	if len(out.Aggregate) == 0 {
+		out.Aggregate = "last"
+		out.Metric = matches[3]
+	}
+	var err error
+	out.Value, err = strconv.ParseFloat(matches[5], 64)
+	return out, err
+}
+
+// Reduce reduces the samples of the metric to the value which is compared
+func (a Assertion) Reduce(samples []float64) (float64, error) {
+	if len(samples) == 0 {
+		return 0, fmt.Errorf("no samples of \"%s\" were taken", a.Metric)
+	}
+	out := samples[len(samples)-1]
+	switch a.Aggregate {
+	case "min":
+		for _, sample := range samples {
+			if sample < out {
+				out = sample
+			}
+		}
+	case "max":
+		for _, sample := range samples {
+			if sample > out {
+				out = sample
+			}
+		}
+	case "avg":
+		out = 0
+		for _, sample := range samples {
+			out += sample
+		}
+		out /= float64(len(samples))
+	}
+	return out, nil
+}
+
+// Holds checks whether the assertion holds for the given value
+func (a Assertion) Holds(value float64) bool {
+	switch a.Op {
+	case ">":
+		return value > a.Value
+	case ">=":
+		return value >= a.Value
+	case "<":
+		return value < a.Value
+	case "<=":
+		return value <= a.Value
+	case "==":
+		return value == a.Value
+	case "!=":
+		return value != a.Value
+	}
+	return false
+}
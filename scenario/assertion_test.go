@@ -0,0 +1,109 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package scenario
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseAssertion(t *testing.T) {
+	var test = []struct {
+		raw      string
+		expected Assertion
+		valid    bool
+	}{
+		{raw: "tps > 500", expected: Assertion{Aggregate: "last", Metric: "tps", Op: ">", Value: 500}, valid: true},
+		{raw: "min(healthy)>=4", expected: Assertion{Aggregate: "min", Metric: "healthy", Op: ">=", Value: 4}, valid: true},
+		{raw: " avg( block_time ) <= 1.5 ", expected: Assertion{Aggregate: "avg", Metric: "block_time", Op: "<=", Value: 1.5}, valid: true},
+		{raw: "restarts == -1", expected: Assertion{Aggregate: "last", Metric: "restarts", Op: "==", Value: -1}, valid: true},
+		{raw: "sum(tps) > 1", valid: false},
+		{raw: "tps => 1", valid: false},
+		{raw: "tps > many", valid: false},
+		{raw: "", valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assertion, err := ParseAssertion(tt.raw)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ParseAssertion returned %v, expected valid: %v", err, tt.valid)
+			}
+			if tt.valid && !reflect.DeepEqual(assertion, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, assertion)
+			}
+		})
+	}
+}
+
+func TestAssertion_Reduce(t *testing.T) {
+	samples := []float64{3, 1, 5, 3}
+	var test = []struct {
+		aggregate string
+		expected  float64
+	}{
+		{aggregate: "last", expected: 3},
+		{aggregate: "min", expected: 1},
+		{aggregate: "max", expected: 5},
+		{aggregate: "avg", expected: 3},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			value, err := Assertion{Aggregate: tt.aggregate}.Reduce(samples)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, value)
+			}
+		})
+	}
+
+	_, err := Assertion{Aggregate: "last"}.Reduce(nil)
+	if err == nil {
+		t.Error("expected an error without any samples")
+	}
+}
+
+func TestAssertion_Holds(t *testing.T) {
+	var test = []struct {
+		op       string
+		value    float64
+		expected bool
+	}{
+		{op: ">", value: 5, expected: true},
+		{op: ">", value: 4, expected: false},
+		{op: ">=", value: 4, expected: true},
+		{op: "<", value: 4, expected: false},
+		{op: "<=", value: 4, expected: true},
+		{op: "==", value: 4, expected: true},
+		{op: "!=", value: 4, expected: false},
+		{op: "!=", value: 3, expected: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if (Assertion{Op: tt.op, Value: 4}).Holds(tt.value) != tt.expected {
+				t.Errorf("expected %v %s 4 to be %v", tt.value, tt.op, tt.expected)
+			}
+		})
+	}
+}
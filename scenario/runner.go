@@ -0,0 +1,361 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package scenario
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+	"time"
+)
+
+const (
+	// RunningStatus is the status of a scenario which is still running
+	RunningStatus = "running"
+	// PassedStatus is the status of a scenario whose phases all passed
+	PassedStatus = "passed"
+	// FailedStatus is the status of a scenario with a phase which failed
+	FailedStatus = "failed"
+	// StoppedStatus is the status of a scenario which was stopped before it was done
+	StoppedStatus = "stopped"
+)
+
+var (
+	runsMux = sync.Mutex{}
+	running = map[string]*runner{} //by testnet
+)
+
+// Run is the report of a scenario run against a testnet
+type Run struct {
+	ID        string        `json:"id"`
+	TestNetID string        `json:"testnetId"`
+	Scenario  Scenario      `json:"scenario"`
+	Status    string        `json:"status"`
+	Started   time.Time     `json:"started"`
+	Finished  time.Time     `json:"finished"`
+	Phases    []PhaseReport `json:"phases"`
+	// Error is why the testnet could not be restored once the scenario was done
+	Error string `json:"error,omitempty"`
+}
+
+// PhaseReport is the outcome of a phase of a scenario
+type PhaseReport struct {
+	Name     string    `json:"name"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Passed is whether all of the actions of the phase succeeded and all of its assertions held
+	Passed bool `json:"passed"`
+	// Errors are the actions of the phase which failed, along with the metrics which could not be measured
+	Errors     []string          `json:"errors"`
+	Samples    []Sample          `json:"samples"`
+	Assertions []AssertionResult `json:"assertions"`
+}
+
+// Sample is the value of each of the metrics at a point in time. Metrics which could not be measured are
+// left out.
+type Sample struct {
+	Time   time.Time          `json:"time"`
+	Values map[string]float64 `json:"values"`
+}
+
+// AssertionResult is whether an assertion held
+type AssertionResult struct {
+	Assertion string  `json:"assertion"`
+	Value     float64 `json:"value"`
+	Passed    bool    `json:"passed"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type runner struct {
+	mux    sync.Mutex
+	run    Run
+	target Target
+	ctx    context.Context
+	cancel context.CancelFunc
+	// done is closed once the run is finished and stored
+	done chan struct{}
+}
+
+func runsKey(testnetID string) string {
+	return "scenarios_" + testnetID
+}
+
+// getStored gets the finished runs of the testnet
+func getStored(testnetID string) []Run {
+	out := []Run{}
+	db.GetMetaP(runsKey(testnetID), &out) //An error means that no scenario has been run
+	return out
+}
+
+// store records a finished run
+var store = func(run Run) error {
+	runsMux.Lock()
+	defer runsMux.Unlock()
+	return db.SetMeta(runsKey(run.TestNetID), append(getStored(run.TestNetID), run))
+}
+
+// Start starts running the scenario against the testnet in the background. Only one scenario can
+// be run against a testnet at a time.
+func Start(testnetID string, scenario Scenario, target Target) (Run, error) {
+	runsMux.Lock()
+	defer runsMux.Unlock()
+	if r, ok := running[testnetID]; ok {
+		return Run{}, fmt.Errorf("the scenario %s is already running against the testnet", r.get().ID)
+	}
+	id, err := util.GetUUIDString()
+	if err != nil {
+		return Run{}, util.LogError(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &runner{
+		run: Run{
+			ID:        id,
+			TestNetID: testnetID,
+			Scenario:  scenario,
+			Status:    RunningStatus,
+			Started:   time.Now(),
+			Phases:    []PhaseReport{},
+		},
+		target: target,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	running[testnetID] = r
+	go r.execute()
+	return r.get(), nil
+}
+
+// Stop stops the scenario running against the testnet, restoring it unless the scenario keeps its changes
+func Stop(testnetID string, id string) error {
+	runsMux.Lock()
+	defer runsMux.Unlock()
+	r, ok := running[testnetID]
+	if !ok || r.get().ID != id {
+		return fmt.Errorf("the scenario %s is not running", id)
+	}
+	r.cancel()
+	return nil
+}
+
+// Wait waits for the scenario run against the testnet to finish, giving its report
+func Wait(testnetID string, id string) (Run, error) {
+	runsMux.Lock()
+	r, ok := running[testnetID]
+	runsMux.Unlock()
+	if ok && r.get().ID == id {
+		<-r.done
+		return r.get(), nil
+	}
+	return Get(testnetID, id)
+}
+
+// Get gets the report of a scenario run against the testnet, which may still be running
+func Get(testnetID string, id string) (Run, error) {
+	for _, run := range List(testnetID) {
+		if run.ID == id {
+			return run, nil
+		}
+	}
+	return Run{}, fmt.Errorf("scenario %s not found", id)
+}
+
+// List gets the reports of all of the scenarios run against the testnet, oldest first
+func List(testnetID string) []Run {
+	runsMux.Lock()
+	defer runsMux.Unlock()
+	out := getStored(testnetID)
+	if r, ok := running[testnetID]; ok {
+		out = append(out, r.get())
+	}
+	return out
+}
+
+func (r *runner) get() Run {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	out := r.run
+	out.Phases = make([]PhaseReport, len(r.run.Phases))
+	copy(out.Phases, r.run.Phases)
+	return out
+}
+
+func (r *runner) update(fn func(run *Run)) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	fn(&r.run)
+}
+
+func (r *runner) execute() {
+	scenario := r.get().Scenario
+	log.WithFields(log.Fields{"testnet": r.run.TestNetID, "scenario": scenario.Name}).Info("running a scenario")
+	status := PassedStatus
+	for i, phase := range scenario.Phases {
+		if r.ctx.Err() != nil {
+			break
+		}
+		r.update(func(run *Run) {
+			run.Phases = append(run.Phases, PhaseReport{Name: phase.Name, Started: time.Now(),
+				Errors: []string{}, Samples: []Sample{}, Assertions: []AssertionResult{}})
+		})
+		r.runPhase(i, phase, scenario)
+		if !r.get().Phases[i].Passed {
+			status = FailedStatus
+		}
+	}
+	if r.ctx.Err() != nil {
+		status = StoppedStatus
+	}
+	var restoreErr error
+	if !scenario.Keep {
+		restoreErr = r.target.Restore()
+	}
+	r.update(func(run *Run) {
+		run.Status = status
+		run.Finished = time.Now()
+		if restoreErr != nil {
+			run.Error = restoreErr.Error()
+		}
+	})
+	log.WithFields(log.Fields{"testnet": r.run.TestNetID, "scenario": scenario.Name, "status": status}).Info("finished a scenario")
+
+	runsMux.Lock()
+	delete(running, r.run.TestNetID)
+	runsMux.Unlock()
+	util.LogError(store(r.get()))
+	r.cancel()
+	close(r.done)
+}
+
+func (r *runner) phaseError(i int, err error) {
+	util.LogError(err)
+	r.update(func(run *Run) {
+		run.Phases[i].Errors = append(run.Phases[i].Errors, err.Error())
+	})
+}
+
+// loadID identifies the given load of a phase, on the given node
+func (r *runner) loadID(phase int, load int, node int) string {
+	return fmt.Sprintf("%s-%d-%d-%d", r.run.ID, phase, load, node)
+}
+
+func (r *runner) runPhase(i int, phase Phase, scenario Scenario) {
+	end := time.Now().Add(phase.GetDuration())
+	if phase.Netem != nil {
+		err := r.target.SetNetem(phase.Netem)
+		if err != nil {
+			r.phaseError(i, err)
+		}
+	}
+	for _, fault := range phase.Faults {
+		err := r.target.InjectFault(fault)
+		if err != nil {
+			r.phaseError(i, fmt.Errorf("%s fault: %s", fault.Type, err.Error()))
+		}
+	}
+	for j, load := range phase.Load {
+		for _, node := range load.Nodes {
+			err := r.target.StartLoad(r.loadID(i, j, node), node, load.Command)
+			if err != nil {
+				r.phaseError(i, fmt.Errorf("load on node %d: %s", node, err.Error()))
+			}
+		}
+	}
+
+	ticker := time.NewTicker(scenario.GetInterval())
+	defer ticker.Stop()
+	timer := time.NewTimer(time.Until(end))
+	defer timer.Stop()
+	for done := false; !done; {
+		select {
+		case <-ticker.C:
+			r.sample(i, scenario)
+		case <-timer.C:
+			done = true
+		case <-r.ctx.Done():
+			done = true
+		}
+	}
+	r.sample(i, scenario)
+
+	for j, load := range phase.Load {
+		for _, node := range load.Nodes {
+			err := r.target.StopLoad(r.loadID(i, j, node), node)
+			if err != nil {
+				r.phaseError(i, fmt.Errorf("stopping the load on node %d: %s", node, err.Error()))
+			}
+		}
+	}
+	r.evaluate(i, phase)
+}
+
+// sample measures all of the metrics
+func (r *runner) sample(i int, scenario Scenario) {
+	sample := Sample{Time: time.Now(), Values: map[string]float64{}}
+	builtins, err := r.target.Builtins()
+	if err != nil {
+		r.phaseError(i, fmt.Errorf("measuring the built in metrics: %s", err.Error()))
+	}
+	for name, value := range builtins {
+		sample.Values[name] = value
+	}
+	for _, metric := range scenario.Metrics {
+		value, err := r.target.Measure(metric)
+		if err != nil {
+			r.phaseError(i, fmt.Errorf("measuring %s: %s", metric.Name, err.Error()))
+			continue
+		}
+		sample.Values[metric.Name] = value
+	}
+	r.update(func(run *Run) {
+		run.Phases[i].Samples = append(run.Phases[i].Samples, sample)
+	})
+}
+
+// evaluate checks the assertions of the phase against its samples
+func (r *runner) evaluate(i int, phase Phase) {
+	r.update(func(run *Run) {
+		report := &run.Phases[i]
+		report.Finished = time.Now()
+		report.Passed = len(report.Errors) == 0
+		for _, raw := range phase.Assert {
+			result := AssertionResult{Assertion: raw}
+			assertion, err := ParseAssertion(raw)
+			if err == nil {
+				samples := []float64{}
+				for _, sample := range report.Samples {
+					if value, ok := sample.Values[assertion.Metric]; ok {
+						samples = append(samples, value)
+					}
+				}
+				result.Value, err = assertion.Reduce(samples)
+			}
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Passed = assertion.Holds(result.Value)
+			}
+			report.Passed = report.Passed && result.Passed
+			report.Assertions = append(report.Assertions, result)
+		}
+	})
+}
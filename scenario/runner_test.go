@@ -0,0 +1,235 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package scenario
+
+import (
+	"fmt"
+	netem "github.com/whiteblock/genesis/net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeTarget struct {
+	mux      sync.Mutex
+	calls    []string
+	loads    map[string]bool
+	healthy  float64
+	failLoad bool
+	restored bool
+}
+
+func (f *fakeTarget) record(call string) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeTarget) SetNetem(netconfs []netem.Netconf) error {
+	f.record(fmt.Sprintf("netem %d", len(netconfs)))
+	return nil
+}
+
+func (f *fakeTarget) InjectFault(fault Fault) error {
+	f.record("fault " + fault.Type)
+	if fault.Type == PauseFault {
+		f.mux.Lock()
+		f.healthy -= float64(len(fault.Nodes))
+		f.mux.Unlock()
+	}
+	return nil
+}
+
+func (f *fakeTarget) StartLoad(id string, node int, command string) error {
+	if f.failLoad {
+		return fmt.Errorf("no such command")
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.loads[id] = true
+	return nil
+}
+
+func (f *fakeTarget) StopLoad(id string, node int) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	delete(f.loads, id)
+	return nil
+}
+
+func (f *fakeTarget) Builtins() (map[string]float64, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return map[string]float64{HealthyMetric: f.healthy}, nil
+}
+
+func (f *fakeTarget) Measure(metric Metric) (float64, error) {
+	if metric.Name == "broken" {
+		return 0, fmt.Errorf("not a number")
+	}
+	return 42, nil
+}
+
+func (f *fakeTarget) Restore() error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.restored = true
+	return nil
+}
+
+func mockStore(t *testing.T) chan Run {
+	out := make(chan Run, 1)
+	original := store
+	store = func(run Run) error {
+		out <- run
+		return nil
+	}
+	t.Cleanup(func() { store = original })
+	return out
+}
+
+func waitForRun(t *testing.T, runs chan Run) Run {
+	select {
+	case run := <-runs:
+		return run
+	case <-time.After(5 * time.Second):
+		t.Fatal("the scenario did not finish")
+	}
+	return Run{}
+}
+
+func TestStart(t *testing.T) {
+	runs := mockStore(t)
+	target := &fakeTarget{loads: map[string]bool{}, healthy: 4}
+	scenario := Scenario{
+		Interval: "5ms",
+		Metrics:  []Metric{{Name: "tps", Command: "echo 42"}},
+		Phases: []Phase{
+			{
+				Name:     "baseline",
+				Duration: "20ms",
+				Netem:    []netem.Netconf{{Node: 1, Loss: 10}},
+				Load:     []Load{{Nodes: []int{0, 1}, Command: "spam"}},
+				Assert:   []string{"min(healthy) >= 4", "tps == 42"},
+			},
+			{
+				Name:     "pause",
+				Duration: "20ms",
+				Faults:   []Fault{{Type: PauseFault, Nodes: []int{2}}},
+				Assert:   []string{"max(healthy) >= 4"},
+			},
+		},
+	}
+	run, err := Start("test1", scenario, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != RunningStatus {
+		t.Errorf("expected the scenario to be running, got %s", run.Status)
+	}
+	_, err = Start("test1", scenario, target)
+	if err == nil {
+		t.Error("expected an error starting a second scenario against the testnet")
+	}
+
+	run = waitForRun(t, runs)
+	if run.Status != FailedStatus {
+		t.Errorf("expected the scenario to fail, got %s", run.Status)
+	}
+	if len(run.Phases) != 2 {
+		t.Fatalf("expected 2 phases, got %d", len(run.Phases))
+	}
+	if !run.Phases[0].Passed || run.Phases[1].Passed {
+		t.Errorf("expected only the first phase to pass: %+v", run.Phases)
+	}
+	if len(run.Phases[0].Samples) == 0 || len(run.Phases[0].Assertions) != 2 {
+		t.Errorf("unexpected report of the first phase %+v", run.Phases[0])
+	}
+	if run.Phases[1].Assertions[0].Value != 3 {
+		t.Errorf("expected healthy to be 3 during the second phase, got %v", run.Phases[1].Assertions[0].Value)
+	}
+	if len(target.loads) != 0 {
+		t.Errorf("expected the loads to be stopped, %d are still running", len(target.loads))
+	}
+	if !target.restored {
+		t.Error("expected the testnet to be restored")
+	}
+}
+
+func TestStart_Keep(t *testing.T) {
+	runs := mockStore(t)
+	target := &fakeTarget{loads: map[string]bool{}, healthy: 4, failLoad: true}
+	scenario := Scenario{
+		Interval: "5ms",
+		Keep:     true,
+		Metrics:  []Metric{{Name: "broken", Command: "echo nope"}},
+		Phases:   []Phase{{Duration: "10ms", Load: []Load{{Nodes: []int{0}, Command: "spam"}}}},
+	}
+	_, err := Start("test2", scenario, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := waitForRun(t, runs)
+	if run.Status != FailedStatus {
+		t.Errorf("expected the scenario to fail, got %s", run.Status)
+	}
+	if len(run.Phases[0].Errors) < 2 {
+		t.Errorf("expected errors for the load and the metric, got %v", run.Phases[0].Errors)
+	}
+	if target.restored {
+		t.Error("expected the testnet to be kept as is")
+	}
+}
+
+func TestStop(t *testing.T) {
+	runs := mockStore(t)
+	target := &fakeTarget{loads: map[string]bool{}, healthy: 4}
+	scenario := Scenario{
+		Interval: "5ms",
+		Phases:   []Phase{{Duration: "1h"}, {Duration: "1h"}},
+	}
+	run, err := Start("test3", scenario, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Stop("test3", "other") == nil {
+		t.Error("expected an error stopping a scenario which is not running")
+	}
+	err = Stop("test3", run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waited, err := Wait("test3", run.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run = waitForRun(t, runs)
+	if waited.ID != run.ID || waited.Status != run.Status {
+		t.Errorf("Wait gave the run %s %s instead of %s %s", waited.ID, waited.Status, run.ID, run.Status)
+	}
+	if run.Status != StoppedStatus {
+		t.Errorf("expected the scenario to be stopped, got %s", run.Status)
+	}
+	if len(run.Phases) > 1 {
+		t.Errorf("expected the scenario to stop before the second phase, got %d phases", len(run.Phases))
+	}
+	if !target.restored {
+		t.Error("expected the testnet to be restored")
+	}
+}
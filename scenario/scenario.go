@@ -0,0 +1,267 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package scenario runs declarative experiments against a testnet. A scenario is a sequence of phases,
+// each of which changes the network conditions, injects faults and generates load for its duration, while
+// metrics are sampled from the testnet. The assertions of each phase on those metrics decide whether the
+// scenario passed.
+package scenario
+
+import (
+	"fmt"
+	netem "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/util"
+	"regexp"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+const (
+	// PauseFault freezes the containers of the nodes
+	PauseFault = "pause"
+	// UnpauseFault resumes the containers of the nodes
+	UnpauseFault = "unpause"
+	// SignalFault sends a signal to the blockchain process of the nodes
+	SignalFault = "signal"
+	// OutageFault cuts the links between each pair of the nodes
+	OutageFault = "outage"
+	// PartitionFault cuts the nodes off from the rest of the testnet
+	PartitionFault = "partition"
+	// HealFault removes all of the outages of the testnet
+	HealFault = "heal"
+
+	// HealthyMetric is the number of nodes which pass the health check of the blockchain
+	HealthyMetric = "healthy"
+	// RunningMetric is the number of nodes whose container is running
+	RunningMetric = "running"
+	// RestartsMetric is the total number of times the containers of the nodes have restarted
+	RestartsMetric = "restarts"
+
+	defaultInterval = "10s"
+	minInterval     = time.Second
+)
+
+var (
+	nameRegex   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+	signalRegex = regexp.MustCompile(`^[A-Z0-9]+$`)
+)
+
+// Scenario is a declarative experiment to run against a testnet
+type Scenario struct {
+	// Name describes the scenario
+	Name string `json:"name"`
+	// Interval is how often the metrics are sampled, such as "10s"
+	Interval string `json:"interval,omitempty"`
+	// Metrics are measured by running a command on a node, in addition to the built in metrics
+	Metrics []Metric `json:"metrics,omitempty"`
+	// Phases are run one after the other
+	Phases []Phase `json:"phases"`
+	// Keep leaves the network conditions, outages and paused nodes in place once the scenario is done,
+	// instead of restoring the testnet
+	Keep bool `json:"keep,omitempty"`
+}
+
+// Metric is a number measured by running a command on a node, which outputs the value
+type Metric struct {
+	// Name is how the metric is referred to by the assertions
+	Name string `json:"name"`
+	// Node is the absolute number of the node to run the command on
+	Node int `json:"node"`
+	// Command is run in the node with sh, and must output a number
+	Command string `json:"command"`
+}
+
+// Phase is a step of a scenario
+type Phase struct {
+	// Name describes the phase
+	Name string `json:"name"`
+	// Duration is how long the phase lasts, such as "30s"
+	Duration string `json:"duration"`
+	// Netem are the network conditions of the nodes throughout the phase. Those of the nodes which are
+	// not given are removed. The conditions of the previous phase are kept when none are given.
+	Netem []netem.Netconf `json:"netem,omitempty"`
+	// Faults are injected at the start of the phase
+	Faults []Fault `json:"faults,omitempty"`
+	// Load is generated for the duration of the phase
+	Load []Load `json:"load,omitempty"`
+	// Assert are the conditions on the metrics which must hold at the end of the phase,
+	// such as "tps > 500" or "min(healthy) >= 4"
+	Assert []string `json:"assert,omitempty"`
+}
+
+// Fault is a failure injected into the testnet
+type Fault struct {
+	// Type is one of pause, unpause, signal, outage, partition or heal
+	Type string `json:"type"`
+	// Nodes are the absolute numbers of the nodes the fault is injected into
+	Nodes []int `json:"nodes,omitempty"`
+	// Signal is the signal sent by a signal fault, defaults to KILL
+	Signal string `json:"signal,omitempty"`
+}
+
+// Load is a command generating load, which is run on nodes for the duration of a phase
+type Load struct {
+	// Nodes are the absolute numbers of the nodes the command is run on
+	Nodes []int `json:"nodes"`
+	// Command is run in each of the nodes with sh, and is stopped at the end of the phase
+	Command string `json:"command"`
+}
+
+// GetInterval gets how often the metrics are sampled
+func (s Scenario) GetInterval() time.Duration {
+	interval := s.Interval
+	if len(interval) == 0 {
+		interval = defaultInterval
+	}
+	out, _ := util.ParseDuration(interval, time.Second)
+	return out
+}
+
+// GetDuration gets how long the phase lasts
+func (p Phase) GetDuration() time.Duration {
+	out, _ := util.ParseDuration(p.Duration, time.Second)
+	return out
+}
+
+// Validate ensures that the scenario can be run against a testnet with the given number of nodes
+func (s Scenario) Validate(nodes int) error {
+	if len(s.Phases) == 0 {
+		return fmt.Errorf("the scenario has no phases")
+	}
+	if len(s.Interval) > 0 {
+		interval, err := util.ParseDuration(s.Interval, time.Second)
+		if err != nil {
+			return fmt.Errorf("invalid interval: %s", err.Error())
+		}
+		if interval < minInterval {
+			return fmt.Errorf("the interval must be at least %s", minInterval)
+		}
+	}
+	checkNode := func(node int) error {
+		if node < 0 || node >= nodes {
+			return fmt.Errorf("node %d does not exist, there are %d nodes", node, nodes)
+		}
+		return nil
+	}
+
+	metrics := map[string]bool{HealthyMetric: true, RunningMetric: true, RestartsMetric: true}
+	for _, metric := range s.Metrics {
+		if !nameRegex.MatchString(metric.Name) {
+			return fmt.Errorf("invalid metric name \"%s\"", metric.Name)
+		}
+		if metrics[metric.Name] {
+			return fmt.Errorf("the metric \"%s\" is given more than once", metric.Name)
+		}
+		metrics[metric.Name] = true
+		if len(metric.Command) == 0 {
+			return fmt.Errorf("the metric \"%s\" has no command", metric.Name)
+		}
+		err := checkNode(metric.Node)
+		if err != nil {
+			return fmt.Errorf("metric \"%s\": %s", metric.Name, err.Error())
+		}
+	}
+
+	var total time.Duration
+	for i, phase := range s.Phases {
+		err := phase.validate(metrics, checkNode)
+		if err != nil {
+			return fmt.Errorf("phase %d: %s", i, err.Error())
+		}
+		total += phase.GetDuration()
+	}
+	if total > time.Duration(conf.MaxScenarioDuration)*time.Second {
+		return fmt.Errorf("the scenario lasts %s, which is longer than the maximum of %d seconds",
+			total, conf.MaxScenarioDuration)
+	}
+	return nil
+}
+
+func (p Phase) validate(metrics map[string]bool, checkNode func(int) error) error {
+	duration, err := util.ParseDuration(p.Duration, time.Second)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %s", err.Error())
+	}
+	if duration <= 0 {
+		return fmt.Errorf("the duration must be positive")
+	}
+	for _, netconf := range p.Netem {
+		err = netconf.Validate()
+		if err != nil {
+			return err
+		}
+		err = checkNode(netconf.Node)
+		if err != nil {
+			return err
+		}
+	}
+	for _, fault := range p.Faults {
+		err = fault.validate(checkNode)
+		if err != nil {
+			return err
+		}
+	}
+	for _, load := range p.Load {
+		if len(load.Command) == 0 || len(load.Nodes) == 0 {
+			return fmt.Errorf("the load needs both a command and nodes to run it on")
+		}
+		for _, node := range load.Nodes {
+			err = checkNode(node)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, raw := range p.Assert {
+		assertion, err := ParseAssertion(raw)
+		if err != nil {
+			return err
+		}
+		if !metrics[assertion.Metric] {
+			return fmt.Errorf("unknown metric \"%s\" in assertion \"%s\"", assertion.Metric, raw)
+		}
+	}
+	return nil
+}
+
+func (f Fault) validate(checkNode func(int) error) error {
+	switch f.Type {
+	case PauseFault, UnpauseFault, SignalFault, PartitionFault:
+		if len(f.Nodes) == 0 {
+			return fmt.Errorf("the %s fault needs at least one node", f.Type)
+		}
+	case OutageFault:
+		if len(f.Nodes) < 2 {
+			return fmt.Errorf("the outage fault needs at least two nodes")
+		}
+	case HealFault:
+	default:
+		return fmt.Errorf("unknown fault type \"%s\"", f.Type)
+	}
+	if len(f.Signal) > 0 && !signalRegex.MatchString(f.Signal) {
+		return fmt.Errorf("invalid signal \"%s\", see `man 7 signal` for help", f.Signal)
+	}
+	for _, node := range f.Nodes {
+		err := checkNode(node)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
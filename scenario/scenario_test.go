@@ -0,0 +1,82 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package scenario
+
+import (
+	netem "github.com/whiteblock/genesis/net"
+	"strconv"
+	"testing"
+)
+
+func TestScenario_Validate(t *testing.T) {
+	var test = []struct {
+		scenario Scenario
+		valid    bool
+	}{
+		{scenario: Scenario{}, valid: false},
+		{
+			scenario: Scenario{Phases: []Phase{{Name: "baseline", Duration: "30s", Assert: []string{"min(healthy) >= 4"}}}},
+			valid:    true,
+		},
+		{
+			scenario: Scenario{
+				Interval: "5s",
+				Metrics:  []Metric{{Name: "height", Node: 3, Command: "cat /height"}},
+				Phases: []Phase{
+					{Duration: "1m", Netem: []netem.Netconf{{Node: 1, Loss: 10}}, Assert: []string{"height > 10"}},
+					{Duration: "1m", Faults: []Fault{{Type: SignalFault, Nodes: []int{2}, Signal: "TERM"}}},
+					{Duration: "1m", Faults: []Fault{{Type: HealFault}}, Load: []Load{{Nodes: []int{0}, Command: "spam"}}},
+				},
+			},
+			valid: true,
+		},
+		{scenario: Scenario{Interval: "10ms", Phases: []Phase{{Duration: "1s"}}}, valid: false},
+		{scenario: Scenario{Interval: "often", Phases: []Phase{{Duration: "1s"}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "forever"}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "0s"}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "2h"}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "1s", Assert: []string{"tps > 500"}}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "1s", Assert: []string{"healthy >"}}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "1s", Netem: []netem.Netconf{{Node: 4}}}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "1s", Netem: []netem.Netconf{{Node: 1, Loss: 200}}}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "1s", Faults: []Fault{{Type: "explode"}}}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "1s", Faults: []Fault{{Type: PauseFault}}}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "1s", Faults: []Fault{{Type: OutageFault, Nodes: []int{1}}}}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "1s", Faults: []Fault{{Type: PauseFault, Nodes: []int{5}}}}}}, valid: false},
+		{
+			scenario: Scenario{Phases: []Phase{{Duration: "1s", Faults: []Fault{{Type: SignalFault, Nodes: []int{1}, Signal: "kill; rm"}}}}},
+			valid:    false,
+		},
+		{scenario: Scenario{Phases: []Phase{{Duration: "1s", Load: []Load{{Nodes: []int{1}}}}}}, valid: false},
+		{scenario: Scenario{Phases: []Phase{{Duration: "1s", Load: []Load{{Nodes: []int{9}, Command: "spam"}}}}}, valid: false},
+		{scenario: Scenario{Metrics: []Metric{{Name: "1tps", Command: "echo 1"}}, Phases: []Phase{{Duration: "1s"}}}, valid: false},
+		{scenario: Scenario{Metrics: []Metric{{Name: "healthy", Command: "echo 1"}}, Phases: []Phase{{Duration: "1s"}}}, valid: false},
+		{scenario: Scenario{Metrics: []Metric{{Name: "tps"}}, Phases: []Phase{{Duration: "1s"}}}, valid: false},
+		{scenario: Scenario{Metrics: []Metric{{Name: "tps", Node: 4, Command: "echo 1"}}, Phases: []Phase{{Duration: "1s"}}}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.scenario.Validate(4)
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate returned %v, expected valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
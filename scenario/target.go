@@ -0,0 +1,262 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package scenario
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/deploy"
+	netem "github.com/whiteblock/genesis/net"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Target is what a scenario acts upon and measures
+type Target interface {
+	// SetNetem reconciles the network conditions of the nodes with the given ones
+	SetNetem(netconfs []netem.Netconf) error
+	// InjectFault injects the given fault
+	InjectFault(fault Fault) error
+	// StartLoad starts running the command on the given node in the background, identified by id
+	StartLoad(id string, node int, command string) error
+	// StopLoad stops the command started with StartLoad
+	StopLoad(id string, node int) error
+	// Builtins measures the built in metrics
+	Builtins() (map[string]float64, error)
+	// Measure measures a metric by running its command
+	Measure(metric Metric) (float64, error)
+	// Restore removes the network conditions and outages, and resumes the paused nodes
+	Restore() error
+}
+
+type testnetTarget struct {
+	tn     *testnet.TestNet
+	mux    sync.Mutex
+	paused map[int]bool
+}
+
+// NewTarget creates the target of a scenario run against the given testnet
+func NewTarget(tn *testnet.TestNet) Target {
+	return &testnetTarget{tn: tn, paused: map[int]bool{}}
+}
+
+func (t *testnetTarget) getNode(absNum int) (db.Node, ssh.Client, error) {
+	node, err := db.GetNodeByAbsNum(t.tn.Nodes, absNum)
+	if err != nil {
+		return db.Node{}, nil, err
+	}
+	client, ok := t.tn.Clients[node.Server]
+	if !ok {
+		return db.Node{}, nil, fmt.Errorf("no client for server %d", node.Server)
+	}
+	return node, client, nil
+}
+
+func (t *testnetTarget) SetNetem(netconfs []netem.Netconf) error {
+	report := netem.Reconcile(netconfs, t.tn.Nodes)
+	if report.Succeeded() {
+		return nil
+	}
+	errs := []string{}
+	for _, result := range report.Results {
+		if len(result.Error) > 0 {
+			errs = append(errs, fmt.Sprintf("node %d: %s", result.Node, result.Error))
+		}
+	}
+	return fmt.Errorf("could not change the network conditions of %s", strings.Join(errs, ", "))
+}
+
+// setPaused pauses or unpauses the container of the node
+func (t *testnetTarget) setPaused(absNum int, paused bool) error {
+	node, client, err := t.getNode(absNum)
+	if err != nil {
+		return err
+	}
+	action := "pause"
+	if !paused {
+		action = "unpause"
+	}
+	_, err = client.Run(fmt.Sprintf("%s %s %s", client.Runtime().CLI, action, node.GetNodeName()))
+	if err != nil {
+		return util.LogError(err)
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if paused {
+		t.paused[absNum] = true
+	} else {
+		delete(t.paused, absNum)
+	}
+	return nil
+}
+
+func (t *testnetTarget) getNodes(absNums []int) ([]db.Node, error) {
+	out := []db.Node{}
+	for _, absNum := range absNums {
+		node, err := db.GetNodeByAbsNum(t.tn.Nodes, absNum)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, node)
+	}
+	return out, nil
+}
+
+func (t *testnetTarget) heal() error {
+	for _, client := range t.tn.Clients {
+		err := netem.RemoveAllOutages(client)
+		if err != nil {
+			return util.LogError(err)
+		}
+	}
+	return netem.ClearOutages(t.tn.TestNetID)
+}
+
+func (t *testnetTarget) InjectFault(fault Fault) error {
+	switch fault.Type {
+	case PauseFault, UnpauseFault:
+		for _, absNum := range fault.Nodes {
+			err := t.setPaused(absNum, fault.Type == PauseFault)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case SignalFault:
+		signal := fault.Signal
+		if len(signal) == 0 {
+			signal = "KILL"
+		}
+		nodes, err := t.getNodes(fault.Nodes)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			_, err = deploy.SignalNode(t.tn, node, signal)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutageFault:
+		nodes, err := t.getNodes(fault.Nodes)
+		if err != nil {
+			return err
+		}
+		for i := range nodes {
+			for j := i + 1; j < len(nodes); j++ {
+				err = netem.MakeOutage(nodes[i], nodes[j])
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case PartitionFault:
+		side1, side2, err := db.DivideNodesByAbsMatch(t.tn.Nodes, fault.Nodes)
+		if err != nil {
+			return err
+		}
+		netem.CreatePartitionOutage(side1, side2)
+		return nil
+	case HealFault:
+		return t.heal()
+	}
+	return fmt.Errorf("unknown fault type \"%s\"", fault.Type)
+}
+
+func loadPIDFile(id string) string {
+	return fmt.Sprintf("/tmp/scenario-load-%s.pid", id)
+}
+
+func (t *testnetTarget) StartLoad(id string, absNum int, command string) error {
+	node, client, err := t.getNode(absNum)
+	if err != nil {
+		return err
+	}
+	seed := t.tn.Keys().Int64(fmt.Sprintf("load/%d/%s", absNum, command))
+	_, err = client.DockerExecd(node, "sh -c "+util.ShellQuote(fmt.Sprintf("echo $$ > %s; export GENESIS_SEED=%d; exec %s",
+		loadPIDFile(id), seed, command)))
+	return util.LogError(err)
+}
+
+func (t *testnetTarget) StopLoad(id string, absNum int) error {
+	node, client, err := t.getNode(absNum)
+	if err != nil {
+		return err
+	}
+	pidFile := loadPIDFile(id)
+	_, err = client.DockerExec(node, "sh -c "+util.ShellQuote(
+		fmt.Sprintf("kill $(cat %s) 2>/dev/null; rm -f %s", pidFile, pidFile)))
+	return util.LogError(err)
+}
+
+func (t *testnetTarget) Builtins() (map[string]float64, error) {
+	out := map[string]float64{HealthyMetric: 0, RunningMetric: 0, RestartsMetric: 0}
+	for _, state := range deploy.GetNodeStates(t.tn) {
+		if state.Health != nil && state.Health.Healthy {
+			out[HealthyMetric]++
+		}
+		if state.State == "running" {
+			out[RunningMetric]++
+		}
+		out[RestartsMetric] += float64(state.RestartCount)
+	}
+	return out, nil
+}
+
+func (t *testnetTarget) Measure(metric Metric) (float64, error) {
+	node, client, err := t.getNode(metric.Node)
+	if err != nil {
+		return 0, err
+	}
+	res, err := client.DockerExec(node, "sh -c "+util.ShellQuote(metric.Command))
+	if err != nil {
+		return 0, util.LogError(err)
+	}
+	out, err := strconv.ParseFloat(strings.TrimSpace(res), 64)
+	if err != nil {
+		return 0, fmt.Errorf("the command of \"%s\" did not output a number: %s", metric.Name, strings.TrimSpace(res))
+	}
+	return out, nil
+}
+
+func (t *testnetTarget) Restore() error {
+	t.mux.Lock()
+	paused := []int{}
+	for absNum := range t.paused {
+		paused = append(paused, absNum)
+	}
+	t.mux.Unlock()
+	for _, absNum := range paused {
+		err := t.setPaused(absNum, false)
+		if err != nil {
+			return err
+		}
+	}
+	err := t.heal()
+	if err != nil {
+		return err
+	}
+	return t.SetNetem([]netem.Netconf{})
+}
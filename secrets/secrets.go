@@ -0,0 +1,162 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package secrets handles sensitive payloads, such as uploaded files and keys. When secrets mode is
+// enabled, they are encrypted at rest with AES-GCM under a master key, given either in the
+// configuration or by a command such as a kms decrypt.
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"strings"
+	"sync"
+)
+
+var conf = util.GetConfig()
+
+// Redacted replaces the sensitive values which are redacted from the api
+const Redacted = "REDACTED"
+
+// prefix marks a value as encrypted, so that values stored before secrets mode was enabled can
+// still be read
+var prefix = []byte("enc:v1:")
+
+var (
+	keyOnce   sync.Once
+	masterKey []byte
+	keyErr    error
+)
+
+// Enabled gets whether or not secrets mode is enabled
+func Enabled() bool {
+	return conf.Secrets
+}
+
+// loadKey gets the master key from secretsKey, or from the output of secretsKeyCommand
+func loadKey() ([]byte, error) {
+	encoded := conf.SecretsKey
+	if len(encoded) == 0 {
+		if len(conf.SecretsKeyCommand) == 0 {
+			return nil, fmt.Errorf("secrets mode requires either secretsKey or secretsKeyCommand")
+		}
+		res, err := util.LocalExec(conf.SecretsKeyCommand)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the master key from secretsKeyCommand: %s", err.Error())
+		}
+		encoded = res.Stdout
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("the master key is not valid base64")
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("the master key must be 256 bits, not %d", len(key)*8)
+	}
+	return key, nil
+}
+
+func getKey() ([]byte, error) {
+	keyOnce.Do(func() {
+		masterKey, keyErr = loadKey()
+	})
+	return masterKey, keyErr
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encrypt(key []byte, plainText []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	sealed := gcm.Seal(nonce, nonce, plainText, nil)
+	out := make([]byte, len(prefix)+base64.StdEncoding.EncodedLen(len(sealed)))
+	copy(out, prefix)
+	base64.StdEncoding.Encode(out[len(prefix):], sealed)
+	return out, nil
+}
+
+func decrypt(key []byte, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(data[len(prefix):]))
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("the encrypted value is too short")
+	}
+	out, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt a secret, the master key may have changed")
+	}
+	return out, nil
+}
+
+// IsEncrypted gets whether or not the given data was encrypted by Encrypt
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, prefix)
+}
+
+// Encrypt encrypts the given data with the master key if secrets mode is enabled,
+// otherwise it is returned as is
+func Encrypt(plainText []byte) ([]byte, error) {
+	if !Enabled() {
+		return plainText, nil
+	}
+	key, err := getKey()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return encrypt(key, plainText)
+}
+
+// Decrypt decrypts data which was encrypted by Encrypt. Data which is not encrypted is returned as is.
+func Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	key, err := getKey()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return decrypt(key, data)
+}
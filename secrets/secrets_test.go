@@ -0,0 +1,85 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package secrets
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func TestEncrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	var test = []struct {
+		plainText []byte
+	}{
+		{plainText: []byte("")},
+		{plainText: []byte(`[{"genesis.json":"eyJjb25maWciOnt9fQ=="}]`)},
+		{plainText: bytes.Repeat([]byte{0}, 4096)},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			data, err := encrypt(key, tt.plainText)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !IsEncrypted(data) {
+				t.Error("the encrypted data is not marked as encrypted")
+			}
+			if len(tt.plainText) > 0 && bytes.Contains(data, tt.plainText) {
+				t.Error("the encrypted data contains the plain text")
+			}
+			out, err := decrypt(key, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, tt.plainText) {
+				t.Errorf("decrypted %q, expected %q", out, tt.plainText)
+			}
+			_, err = decrypt(bytes.Repeat([]byte{8}, 32), data)
+			if err == nil {
+				t.Error("expected an error when decrypting with another key")
+			}
+		})
+	}
+}
+
+func TestDecrypt_PlainText(t *testing.T) {
+	out, err := Decrypt([]byte(`{"servers":[1]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"servers":[1]}` {
+		t.Errorf("plain text was altered to %q", out)
+	}
+}
+
+func TestDecrypt_Tampered(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	data, err := encrypt(key, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-3] ^= 1
+	_, err = decrypt(key, data)
+	if err == nil {
+		t.Error("expected an error when decrypting tampered data")
+	}
+}
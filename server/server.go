@@ -0,0 +1,47 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package server starts every part of the genesis server, so that each of the ways of running it starts
+// the same parts
+package server
+
+import (
+	"github.com/whiteblock/genesis/manager"
+	"github.com/whiteblock/genesis/preflight"
+	"github.com/whiteblock/genesis/profiling"
+	"github.com/whiteblock/genesis/queue"
+	"github.com/whiteblock/genesis/rest"
+	"github.com/whiteblock/genesis/soak"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/util"
+	"log"
+)
+
+// Run runs the genesis server, after checking that its dependencies are available and starting its
+// background routines. It only returns once the REST API stops being served.
+func Run() {
+	util.DisplayBanner()
+	log.SetFlags(log.LstdFlags | log.Llongfile)
+	preflight.CheckAll()
+	manager.StartReaper()
+	status.StartCrashWatcher()
+	soak.Resume()
+	profiling.Start()
+	queue.Start()
+	rest.StartServer()
+}
@@ -22,6 +22,7 @@ package geth
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/whiteblock/genesis/clients"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/protocols/ethereum"
 	"github.com/whiteblock/genesis/protocols/helpers"
@@ -159,15 +160,12 @@ func Build(tn *testnet.Adjunct) error {
 		if err != nil {
 			return util.LogError(err)
 		}
+		rpc := clients.NewJSONRPC(client, node.GetIP(), ethereum.RPCPort)
 		for i := 0; i < 10; i++ {
 			if mine {
-				_, err = client.KeepTryRun(
-					fmt.Sprintf(`curl -sS -X POST http://%s:8545 -H "Content-Type: application/json" `+
-						` -d '{ "method": "miner_start", "params": [1], "id": 3, "jsonrpc": "2.0" }'`, node.GetIP()))
+				err = rpc.KeepTryCall("miner_start", []interface{}{1}, nil)
 			} else {
-				_, err = client.KeepTryRun(
-					fmt.Sprintf(`curl -sS -X POST http://%s:8545 -H "Content-Type: application/json" `+
-						` -d '{ "method": "miner_stop", "params": [], "id": 3, "jsonrpc": "2.0" }'`, node.GetIP()))
+				err = rpc.KeepTryCall("miner_stop", nil, nil)
 			}
 			if err == nil {
 				break
@@ -0,0 +1,304 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package simulator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// client stands in for the ssh client of a server, giving the commands it would run on the server
+// to the simulator instead
+type client struct {
+	sim      *Simulator
+	serverID int
+	runtime  util.Runtime
+}
+
+// NewClient creates a client which runs the commands for the given server on the simulator
+func NewClient(sim *Simulator, serverID int, runtime util.Runtime) ssh.Client {
+	return &client{sim: sim, serverID: serverID, runtime: runtime}
+}
+
+func (c *client) logger() *log.Entry {
+	entry := log.WithFields(log.Fields{"server": c.serverID, "simulated": true})
+	bs := state.GetBuildStateByServerID(c.serverID)
+	if bs != nil {
+		entry = entry.WithFields(log.Fields{"build": bs.BuildID})
+	}
+	return entry
+}
+
+func (c *client) run(entry *log.Entry, command string) (string, error) {
+	bs := state.GetBuildStateByServerID(c.serverID)
+	if bs.Stop() {
+		return "", bs.GetError()
+	}
+	out, err := c.sim.Exec(c.serverID, command)
+	bs.RecordCommand(c.serverID, command, err)
+	entry = entry.WithFields(log.Fields{"command": command, "output": out})
+	if err != nil {
+		entry.WithFields(log.Fields{"error": err}).Info("command failed")
+		return out, err
+	}
+	entry.Info("executed command")
+	return out, nil
+}
+
+func (c *client) keepTryRun(entry *log.Entry, command string) (string, error) {
+	var res string
+	var err error
+	for i := 0; i < conf.MaxRunAttempts; i++ {
+		res, err = c.run(entry, command)
+		if err == nil {
+			break
+		}
+	}
+	return res, util.LogError(err)
+}
+
+// exec gives the command line to execute command in the given node
+func (c *client) exec(node ssh.Node, command string) string {
+	return fmt.Sprintf("%s exec %s %s", c.runtime.CLI, node.GetNodeName(), command)
+}
+
+// execd gives the command line to start command in the background in the given node
+func (c *client) execd(node ssh.Node, command string) string {
+	return fmt.Sprintf("%s exec -d %s %s", c.runtime.CLI, node.GetNodeName(), command)
+}
+
+// MultiRun provides an easy shorthand for multiple calls to Run
+func (c *client) MultiRun(commands ...string) ([]string, error) {
+	out := []string{}
+	for _, command := range commands {
+		res, err := c.Run(command)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+// FastMultiRun runs the commands chained together
+func (c *client) FastMultiRun(commands ...string) (string, error) {
+	return c.Run(strings.Join(commands, "&&"))
+}
+
+// Run simulates the given command on the server
+func (c *client) Run(command string) (string, error) {
+	return c.run(c.logger(), command)
+}
+
+// KeepTryRun is Run, attempting the command up to maxRunAttempts times
+func (c *client) KeepTryRun(command string) (string, error) {
+	return c.keepTryRun(c.logger(), command)
+}
+
+// DockerExec simulates a command in the node
+func (c *client) DockerExec(node ssh.Node, command string) (string, error) {
+	return c.run(c.logger().WithFields(ssh.LogFields(node)), c.exec(node, command))
+}
+
+// DockerCp simulates copying a file on the server into the node
+func (c *client) DockerCp(node ssh.Node, source string, dest string) error {
+	_, err := c.run(c.logger().WithFields(ssh.LogFields(node)), fmt.Sprintf("%s cp %s %s:%s",
+		c.runtime.CLI, source, node.GetNodeName(), dest))
+	return util.LogError(err)
+}
+
+// Fetch writes the simulated output of reading the file at source on the server to dest
+func (c *client) Fetch(source string, dest io.Writer) error {
+	out, err := c.run(c.logger(), "cat "+util.ShellQuote(source))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(dest, out)
+	return util.LogError(err)
+}
+
+// DockerFetch writes the simulated output of reading the file at source in the given node to dest
+func (c *client) DockerFetch(node ssh.Node, source string, dest io.Writer) error {
+	out, err := c.run(c.logger().WithFields(ssh.LogFields(node)), c.exec(node, "cat "+util.ShellQuote(source)))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(dest, out)
+	return util.LogError(err)
+}
+
+// KeepTryDockerExec is like KeepTryRun for nodes
+func (c *client) KeepTryDockerExec(node ssh.Node, command string) (string, error) {
+	return c.keepTryRun(c.logger().WithFields(ssh.LogFields(node)), c.exec(node, command))
+}
+
+// KeepTryDockerExecAll is like KeepTryDockerExec, but executes each of the given commands in order
+func (c *client) KeepTryDockerExecAll(node ssh.Node, commands ...string) ([]string, error) {
+	out := []string{}
+	for _, command := range commands {
+		res, err := c.KeepTryDockerExec(node, command)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+// DockerExecd simulates starting the given command in the background in the node
+func (c *client) DockerExecd(node ssh.Node, command string) (string, error) {
+	return c.run(c.logger().WithFields(ssh.LogFields(node)), c.execd(node, command))
+}
+
+// DockerExecdit simulates starting the given command in the background in the node, behind a tty
+func (c *client) DockerExecdit(node ssh.Node, command string) (string, error) {
+	return c.run(c.logger().WithFields(ssh.LogFields(node)), fmt.Sprintf("%s exec -itd %s %s",
+		c.runtime.CLI, node.GetNodeName(), command))
+}
+
+// DockerRunMainDaemon simulates starting the main daemon process of the node
+func (c *client) DockerRunMainDaemon(node ssh.Node, command string) error {
+	bs := state.GetBuildStateByServerID(c.serverID)
+	if bs != nil {
+		bs.Set(fmt.Sprintf("%d", node.GetAbsoluteNumber()), util.Command{Cmdline: command, ServerID: c.serverID,
+			Node: node.GetRelativeNumber()})
+	}
+	return c.DockerExecdLog(node, command)
+}
+
+// DockerExecdLog simulates starting the given command in the background in the node, storing its output in the logs
+func (c *client) DockerExecdLog(node ssh.Node, command string) error {
+	_, err := c.DockerExecd(node, fmt.Sprintf("bash -c '%s 2>&1 > %s'", command, conf.DockerOutputFile))
+	return util.LogError(err)
+}
+
+// DockerExecdLogAppend is DockerExecdLog, but appends to the existing logs
+func (c *client) DockerExecdLogAppend(node ssh.Node, command string) error {
+	_, err := c.DockerExecd(node, fmt.Sprintf("bash -c '%s 2>&1 >> %s'", command, conf.DockerOutputFile))
+	return util.LogError(err)
+}
+
+// DockerRead simulates reading a file in the node, if lines > -1 then only the last `lines` lines are read
+func (c *client) DockerRead(node ssh.Node, file string, lines int) (string, error) {
+	if lines > -1 {
+		return c.DockerExec(node, fmt.Sprintf("tail -n %d %s", lines, file))
+	}
+	return c.DockerExec(node, fmt.Sprintf("cat %s", file))
+}
+
+func (c *client) dockerMultiExec(node ssh.Node, commands []string, kt bool) (string, error) {
+	merged := []string{}
+	for _, command := range commands {
+		merged = append(merged, c.execd(node, command))
+	}
+	if kt {
+		return c.keepTryRun(c.logger().WithFields(ssh.LogFields(node)), strings.Join(merged, "&&"))
+	}
+	return c.run(c.logger().WithFields(ssh.LogFields(node)), strings.Join(merged, "&&"))
+}
+
+// DockerMultiExec simulates starting each of the given commands in the background in the node
+func (c *client) DockerMultiExec(node ssh.Node, commands []string) (string, error) {
+	return c.dockerMultiExec(node, commands, false)
+}
+
+// KTDockerMultiExec is DockerMultiExec, attempting the commands up to maxRunAttempts times
+func (c *client) KTDockerMultiExec(node ssh.Node, commands []string) (string, error) {
+	return c.dockerMultiExec(node, commands, true)
+}
+
+// Scp simulates copying a file over to the server
+func (c *client) Scp(src string, dest string) error {
+	c.logger().WithFields(log.Fields{"src": src, "dst": dest}).Info("remote copying file")
+	bs := state.GetBuildStateByServerID(c.serverID)
+	src = bs.Workspace().Path(src)
+	_, err := c.sim.Exec(c.serverID, fmt.Sprintf("scp %s %s", src, dest))
+	bs.RecordCopy(c.serverID, src, dest, err)
+	return util.LogError(err)
+}
+
+// Sync simulates pushing a file or directory over to the server
+func (c *client) Sync(src string, dest string) error {
+	c.logger().WithFields(log.Fields{"src": src, "dst": dest}).Info("syncing")
+	bs := state.GetBuildStateByServerID(c.serverID)
+	src = bs.Workspace().Path(src)
+	_, err := c.sim.Exec(c.serverID, fmt.Sprintf("rsync %s %s", src, dest))
+	bs.RecordCopy(c.serverID, src, dest, err)
+	return util.LogError(err)
+}
+
+// Shell fails, as there is no node to open a shell in
+func (c *client) Shell(node ssh.Node, cols int, rows int) (ssh.Shell, error) {
+	return nil, errors.New("shells are not available on simulated servers")
+}
+
+// Dial gives a connection which answers the http requests made over it from the rules of the simulator.
+// Each request is run as a command such as `POST http://10.1.0.2:8545/ {"method":"eth_blockNumber"}`,
+// whose output is the body of the response, and whose error fails it with a 502.
+func (c *client) Dial(network string, address string) (net.Conn, error) {
+	local, remote := net.Pipe()
+	go c.serveHTTP(address, remote)
+	return local, nil
+}
+
+func (c *client) serveHTTP(address string, conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		req, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(fmt.Sprintf("%s http://%s%s %s", req.Method, address, req.URL.RequestURI(),
+			string(body)))
+		out, err := c.run(c.logger(), command)
+		res := &http.Response{StatusCode: http.StatusOK, ProtoMajor: 1, ProtoMinor: 1, Request: req,
+			Header: http.Header{"Content-Type": []string{"application/json"}}}
+		if err != nil {
+			res.StatusCode = http.StatusBadGateway
+			out = err.Error()
+		}
+		res.Body = ioutil.NopCloser(strings.NewReader(out))
+		res.ContentLength = int64(len(out))
+		if res.Write(conn) != nil || req.Close {
+			return
+		}
+	}
+}
+
+// Close does nothing, as there is no connection to close
+func (c *client) Close() {}
+
+// Runtime gets the container runtime of the server
+func (c *client) Runtime() util.Runtime {
+	return c.runtime
+}
@@ -0,0 +1,178 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+// Package simulator provides a backend which pretends to execute the commands genesis runs on its
+// servers. The commands are recorded instead, and answered with canned outputs chosen by regular
+// expressions, so that builds and the REST API can be run without any servers, and so that the
+// commands of a build can be asserted in tests.
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"io/ioutil"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+// Rule gives the canned response to the commands which match its pattern
+type Rule struct {
+	// Pattern is the regular expression the command is matched against
+	Pattern string `json:"pattern"`
+	// Output is the output of the command
+	Output string `json:"output"`
+	// Error makes the command fail with the given error when it is not empty
+	Error string `json:"error,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Record is a command which was executed by the simulator
+type Record struct {
+	// Server is the id of the server the command was run on
+	Server int `json:"server"`
+	// Command is the command, as it would have been run on the server
+	Command string `json:"command"`
+	// Output is the canned output the command was given
+	Output string `json:"output"`
+	// Error is the error the command failed with, if it failed
+	Error string `json:"error,omitempty"`
+	// Time is when the command was executed
+	Time time.Time `json:"time"`
+}
+
+// DefaultRules are the rules which answer the commands whose output genesis parses during a build,
+// they are checked after any other rules
+var DefaultRules = []Rule{
+	{Pattern: `^uname -m$`, Output: "x86_64\n"},
+	{Pattern: `image inspect --format '\{\{\.Os\}\}/\{\{\.Architecture\}\}'`, Output: "linux/amd64\n"},
+	{Pattern: `nvidia-smi`, Output: "0\n"},
+	{Pattern: `^nproc && `, Output: "64\n263921000\n1000000000\n"},
+}
+
+// Simulator executes commands by recording them and answering them from its rules
+type Simulator struct {
+	mux     sync.Mutex
+	rules   []Rule
+	records []Record
+}
+
+// New creates a simulator with the given rules, which are checked in order before the default rules
+func New(rules []Rule) (*Simulator, error) {
+	out := &Simulator{}
+	for _, rule := range append(append([]Rule{}, rules...), DefaultRules...) {
+		var err error
+		rule.re, err = regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid simulator rule \"%s\": %s", rule.Pattern, err.Error())
+		}
+		out.rules = append(out.rules, rule)
+	}
+	return out, nil
+}
+
+// LoadRules reads a json array of rules from the given file
+func LoadRules(file string) ([]Rule, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	var out []Rule
+	return out, util.LogError(json.Unmarshal(data, &out))
+}
+
+var (
+	defaultSim  *Simulator
+	defaultOnce sync.Once
+	defaultErr  error
+)
+
+// Default gets the simulator used for every server when simulator is enabled, which has the
+// rules in simulatorRules
+func Default() (*Simulator, error) {
+	defaultOnce.Do(func() {
+		var rules []Rule
+		if len(conf.SimulatorRules) > 0 {
+			rules, defaultErr = LoadRules(conf.SimulatorRules)
+			if defaultErr != nil {
+				return
+			}
+		}
+		defaultSim, defaultErr = New(rules)
+	})
+	return defaultSim, defaultErr
+}
+
+// Enabled gets whether the simulator stands in for the servers
+func Enabled() bool {
+	return conf.Simulator
+}
+
+// Exec executes the given command for the given server, recording it and giving it
+// the response of the first rule it matches. Commands which match no rule succeed
+// with no output.
+func (sim *Simulator) Exec(serverID int, command string) (string, error) {
+	sim.mux.Lock()
+	defer sim.mux.Unlock()
+	record := Record{Server: serverID, Command: command, Time: time.Now()}
+	for _, rule := range sim.rules {
+		if rule.re.MatchString(command) {
+			record.Output = rule.Output
+			record.Error = rule.Error
+			break
+		}
+	}
+	sim.records = append(sim.records, record)
+	if len(record.Error) > 0 {
+		return record.Output, util.CommandError{Command: command, Output: record.Output, Err: fmt.Errorf(record.Error)}
+	}
+	return record.Output, nil
+}
+
+// Records gets the commands which have been executed, in order. If server is not -1, only
+// the commands for that server are given.
+func (sim *Simulator) Records(server int) []Record {
+	sim.mux.Lock()
+	defer sim.mux.Unlock()
+	out := []Record{}
+	for _, record := range sim.records {
+		if server == -1 || record.Server == server {
+			out = append(out, record)
+		}
+	}
+	return out
+}
+
+// Commands gets the commands which have been executed on the given server, in order
+func (sim *Simulator) Commands(server int) []string {
+	out := []string{}
+	for _, record := range sim.Records(server) {
+		out = append(out, record.Command)
+	}
+	return out
+}
+
+// Reset forgets all of the commands which have been executed
+func (sim *Simulator) Reset() {
+	sim.mux.Lock()
+	defer sim.mux.Unlock()
+	sim.records = nil
+}
@@ -0,0 +1,156 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package simulator
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/whiteblock/genesis/clients"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+)
+
+func TestExec(t *testing.T) {
+	sim, err := New([]Rule{
+		{Pattern: `^docker exec \S+ cat /genesis.json$`, Output: "{}"},
+		{Pattern: `^docker pull .*:broken$`, Output: "not found", Error: "manifest not found"},
+		{Pattern: `uname`, Output: "aarch64\n"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var test = []struct {
+		command  string
+		expected string
+		fails    bool
+	}{
+		{command: "docker exec whiteblock-node0 cat /genesis.json", expected: "{}"},
+		{command: "docker pull gcr.io/whiteblock/geth:broken", expected: "not found", fails: true},
+		{command: "docker pull gcr.io/whiteblock/geth:master", expected: ""},
+		{command: "uname -m", expected: "aarch64\n"},
+		{command: "docker image inspect --format '{{.Os}}/{{.Architecture}}' geth", expected: "linux/amd64\n"},
+		{command: "nvidia-smi --query-gpu=name --format=csv,noheader | wc -l", expected: "0\n"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := sim.Exec(1, tt.command)
+			if (err != nil) != tt.fails {
+				t.Errorf("return value of Exec did not match expected value")
+			}
+			if out != tt.expected {
+				t.Errorf("return value of Exec %q does not match expected value %q", out, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewInvalidRule(t *testing.T) {
+	_, err := New([]Rule{{Pattern: "docker exec ("}})
+	if err == nil {
+		t.Error("return value of New did not match expected value")
+	}
+}
+
+func TestClientCommands(t *testing.T) {
+	sim, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt, err := util.GetRuntime(util.DockerRuntime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := db.Node{TestNetID: "test", LocalID: 0, AbsoluteNum: 0}
+	client := NewClient(sim, 2, rt)
+	other := NewClient(sim, 3, rt)
+
+	_, err = client.Run("uname -m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = other.DockerExec(node, "ls /")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.DockerCp(node, "/tmp/genesis.json", "/genesis.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.DockerRunMainDaemon(node, "geth --datadir /geth/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	err = client.Fetch("/tmp/data", buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := node.GetNodeName()
+	expected := []string{
+		"uname -m",
+		fmt.Sprintf("docker cp /tmp/genesis.json %s:/genesis.json", name),
+		fmt.Sprintf("docker exec -d %s bash -c 'geth --datadir /geth/ 2>&1 > %s'", name, conf.DockerOutputFile),
+		"cat '/tmp/data'",
+	}
+	if !reflect.DeepEqual(sim.Commands(2), expected) {
+		t.Errorf("return value of Commands %v does not match expected value %v", sim.Commands(2), expected)
+	}
+	if !reflect.DeepEqual(sim.Commands(3), []string{fmt.Sprintf("docker exec %s ls /", name)}) {
+		t.Errorf("return value of Commands %v did not match expected value", sim.Commands(3))
+	}
+	if len(sim.Records(-1)) != 5 {
+		t.Errorf("return value of Records did not match expected value")
+	}
+
+	sim.Reset()
+	if len(sim.Records(-1)) != 0 {
+		t.Errorf("Reset did not clear the records")
+	}
+}
+
+func TestClientDial(t *testing.T) {
+	sim, err := New([]Rule{
+		{Pattern: `^POST http://10\.1\.0\.2:8545/ .*"eth_blockNumber"`, Output: `{"result":"0x10"}`},
+		{Pattern: `^POST http://10\.1\.0\.3:8545/`, Error: "connection refused"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(sim, 1, util.Runtime{})
+
+	height, err := clients.NewJSONRPC(client, "10.1.0.2", 8545).BlockNumber()
+	if err != nil || height != 16 {
+		t.Errorf("return value of BlockNumber %d does not match expected value: %v", height, err)
+	}
+	_, err = clients.NewJSONRPC(client, "10.1.0.3", 8545).BlockNumber()
+	if err == nil {
+		t.Error("expected the call to fail")
+	}
+	commands := sim.Commands(1)
+	if len(commands) != 2 || !strings.HasPrefix(commands[0], `POST http://10.1.0.2:8545/ {"id":1,"jsonrpc":"2.0"`) {
+		t.Errorf("return value of Commands %v did not match expected value", commands)
+	}
+}
@@ -0,0 +1,284 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package soak
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/artifacts"
+	"github.com/whiteblock/genesis/consensus"
+	"github.com/whiteblock/genesis/notify"
+	"github.com/whiteblock/genesis/webhook"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxDigestErrors limits the number of log lines kept for each node in a digest
+const maxDigestErrors = 10
+
+// NodeDigest summarizes the samples of a node over the period of a digest
+type NodeDigest struct {
+	// Node is the absolute number of the node
+	Node int `json:"node"`
+	// Uptime is the percentage of the samples in which the container of the node was running
+	Uptime float64 `json:"uptime"`
+	// Health is the percentage of the health checks of the node which passed, left out if there were none
+	Health *float64 `json:"health,omitempty"`
+	// Restarts is the number of times the container of the node was restarted
+	Restarts int     `json:"restarts"`
+	AvgCPU   float64 `json:"avgCpu"`
+	MaxCPU   float64 `json:"maxCpu"`
+	// MaxMemory is the highest resident set size of the node in KB
+	MaxMemory float64 `json:"maxMemory"`
+	// MemoryGrowth is how much the resident set size of the node grew over the period in KB,
+	// which is useful for spotting leaks
+	MemoryGrowth float64 `json:"memoryGrowth"`
+	// LogErrors is the number of lines of the log of the node which matched the log pattern
+	LogErrors int `json:"logErrors"`
+	// Errors are the first few of those lines
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Digest summarizes the samples of a soak test over a period
+type Digest struct {
+	TestNetID string `json:"testnetId"`
+	// Number is the number of the digest, starting from 1
+	Number int       `json:"number"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	// Samples is the number of samples taken over the period
+	Samples int `json:"samples"`
+	// Live is the percentage of the samples in which the chain was producing blocks, left out
+	// if the consensus was not probed
+	Live *float64 `json:"live,omitempty"`
+	// Blocks is the number of blocks produced over the period, left out if the consensus was not probed
+	Blocks *int64 `json:"blocks,omitempty"`
+	// Forks, Reorgs and Stalls are the number of each detected over the period
+	Forks  int          `json:"forks"`
+	Reorgs int          `json:"reorgs"`
+	Stalls int          `json:"stalls"`
+	Nodes  []NodeDigest `json:"nodes"`
+	// Captures are the artifact directories of the kept packet captures
+	Captures []string `json:"captures,omitempty"`
+}
+
+// summarize creates the digest of the given samples, which are in the order they were taken
+func summarize(testnetID string, number int, from time.Time, to time.Time, samples []Sample) Digest {
+	out := Digest{TestNetID: testnetID, Number: number, From: from, To: to, Samples: len(samples), Nodes: []NodeDigest{}}
+	type totals struct {
+		samples, running, checks, healthy int
+		cpu                               float64
+		firstMemory, lastMemory           float64
+		firstRestarts, lastRestarts       int
+	}
+	byNode := map[int]*totals{}
+	nodes := map[int]*NodeDigest{}
+	live, probed := 0, 0
+	var firstHeight, lastHeight *int64
+	for _, sample := range samples {
+		if sample.Live != nil {
+			probed++
+			if *sample.Live {
+				live++
+			}
+		}
+		if sample.Height != nil {
+			if firstHeight == nil {
+				firstHeight = sample.Height
+			}
+			lastHeight = sample.Height
+		}
+		for _, ns := range sample.Nodes {
+			t, ok := byNode[ns.Node]
+			if !ok {
+				t = &totals{firstRestarts: ns.RestartCount, firstMemory: -1}
+				byNode[ns.Node] = t
+				nodes[ns.Node] = &NodeDigest{Node: ns.Node}
+			}
+			nd := nodes[ns.Node]
+			t.samples++
+			t.lastRestarts = ns.RestartCount
+			if ns.Healthy != nil {
+				t.checks++
+				if *ns.Healthy {
+					t.healthy++
+				}
+			}
+			nd.LogErrors += ns.LogErrors
+			for _, line := range ns.Errors {
+				if len(nd.Errors) < maxDigestErrors {
+					nd.Errors = append(nd.Errors, line)
+				}
+			}
+			if ns.State != "running" {
+				continue
+			}
+			t.running++
+			t.cpu += ns.CPU
+			if ns.CPU > nd.MaxCPU {
+				nd.MaxCPU = ns.CPU
+			}
+			if ns.Memory > nd.MaxMemory {
+				nd.MaxMemory = ns.Memory
+			}
+			if t.firstMemory < 0 {
+				t.firstMemory = ns.Memory
+			}
+			t.lastMemory = ns.Memory
+		}
+	}
+	if probed > 0 {
+		percent := percentage(live, probed)
+		out.Live = &percent
+	}
+	if firstHeight != nil {
+		blocks := *lastHeight - *firstHeight
+		out.Blocks = &blocks
+	}
+	for node, nd := range nodes {
+		t := byNode[node]
+		nd.Uptime = percentage(t.running, t.samples)
+		if t.checks > 0 {
+			health := percentage(t.healthy, t.checks)
+			nd.Health = &health
+		}
+		if t.lastRestarts > t.firstRestarts {
+			nd.Restarts = t.lastRestarts - t.firstRestarts
+		}
+		if t.running > 0 {
+			nd.AvgCPU = t.cpu / float64(t.running)
+			nd.MemoryGrowth = t.lastMemory - t.firstMemory
+		}
+		out.Nodes = append(out.Nodes, *nd)
+	}
+	sort.Slice(out.Nodes, func(i, j int) bool { return out.Nodes[i].Node < out.Nodes[j].Node })
+	return out
+}
+
+func percentage(part int, total int) float64 {
+	return float64(part) * 100 / float64(total)
+}
+
+// addConsensus counts the forks, reorgs and stalls of the report which were detected over the period of the digest
+func (d *Digest) addConsensus(report consensus.Report) {
+	within := func(t time.Time) bool { return !t.Before(d.From) && t.Before(d.To) }
+	for _, fork := range report.Forks {
+		if within(fork.Detected) {
+			d.Forks++
+		}
+	}
+	for _, reorg := range report.Reorgs {
+		if within(reorg.Detected) {
+			d.Reorgs++
+		}
+	}
+	for _, stall := range report.Stalls {
+		if within(stall.Started) {
+			d.Stalls++
+		}
+	}
+}
+
+// Subject gets the subject of the email of the digest
+func (d Digest) Subject() string {
+	return fmt.Sprintf("Soak test digest #%d of testnet %s", d.Number, d.TestNetID)
+}
+
+// String gives a human readable representation of the digest
+func (d Digest) String() string {
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "Soak test digest #%d of testnet %s\n", d.Number, d.TestNetID)
+	fmt.Fprintf(out, "From %s to %s, %d samples\n\n", d.From.UTC().Format(time.RFC1123),
+		d.To.UTC().Format(time.RFC1123), d.Samples)
+	if d.Live != nil {
+		fmt.Fprintf(out, "Chain live in %.1f%% of the samples", *d.Live)
+		if d.Blocks != nil {
+			fmt.Fprintf(out, ", %d blocks produced", *d.Blocks)
+		}
+		fmt.Fprintf(out, "\n%d forks, %d reorgs and %d stalls detected\n\n", d.Forks, d.Reorgs, d.Stalls)
+	}
+	for _, node := range d.Nodes {
+		fmt.Fprintf(out, "Node %d: up %.1f%%", node.Node, node.Uptime)
+		if node.Health != nil {
+			fmt.Fprintf(out, ", healthy %.1f%%", *node.Health)
+		}
+		fmt.Fprintf(out, ", %d restarts, cpu %.1f%% avg %.1f%% max, memory %.0fKB max %+.0fKB growth, %d log errors\n",
+			node.Restarts, node.AvgCPU, node.MaxCPU, node.MaxMemory, node.MemoryGrowth, node.LogErrors)
+		for _, line := range node.Errors {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+	if len(d.Captures) > 0 {
+		fmt.Fprintf(out, "\nPacket captures: %s\n", strings.Join(d.Captures, ", "))
+	}
+	return out.String()
+}
+
+// parseSamples parses the samples, one json object per line, which were taken after from and up until to
+func parseSamples(data []byte, from time.Time, to time.Time) []Sample {
+	out := []Sample{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		var sample Sample
+		if json.Unmarshal([]byte(line), &sample) != nil {
+			continue
+		}
+		if sample.Time.After(from) && !sample.Time.After(to) {
+			out = append(out, sample)
+		}
+	}
+	return out
+}
+
+// digest summarizes the samples taken since the previous digest, and delivers the digest to the webhooks,
+// by email, and stores it as an artifact of the testnet
+func (s *Session) digest(now time.Time) {
+	data, err := artifacts.Read(s.TestNetID, samplesArtifact)
+	if err != nil {
+		log.WithFields(log.Fields{"testnet": s.TestNetID, "error": err}).Error("couldn't read the samples to digest")
+	}
+	d := summarize(s.TestNetID, s.Digests+1, s.Digested, now, parseSamples(data, s.Digested, now))
+	report, err := consensus.GetReport(s.TestNetID)
+	if err == nil {
+		d.addConsensus(report)
+	}
+	d.Captures = s.Captures
+
+	out, err := json.Marshal(d)
+	if err == nil {
+		err = artifacts.Store(s.TestNetID, fmt.Sprintf("%s/%d.json", digestsArtifacts, d.Number), out)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"testnet": s.TestNetID, "error": err}).Error("failed to store the digest")
+	}
+	webhook.Emit(webhook.SoakDigest, s.TestNetID, d)
+	if len(s.Config.Emails) > 0 {
+		err = notify.Email(s.Config.Emails, d.Subject(), d.String())
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": s.TestNetID, "error": err}).Error("failed to email the digest")
+		}
+	}
+	s.Digests = d.Number
+	s.Digested = now
+	log.WithFields(log.Fields{"testnet": s.TestNetID, "digest": d.Number}).Info("delivered a soak test digest")
+}
@@ -0,0 +1,159 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package soak
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/consensus"
+	"github.com/whiteblock/genesis/deploy"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxErrorLines limits the number of matching log lines kept for each node in each sample
+	maxErrorLines = 5
+	// maxErrorLength limits the length of each of the kept log lines
+	maxErrorLength = 300
+)
+
+// NodeSample is the state of a node when it was sampled
+type NodeSample struct {
+	// Node is the absolute number of the node
+	Node int `json:"node"`
+	// State is the state of the container of the node, such as running or exited
+	State string `json:"state"`
+	// RestartCount is the number of times the container of the node has been restarted
+	RestartCount int `json:"restartCount"`
+	// Healthy is the result of the health check of the node, left out if its blockchain does not have one
+	Healthy *bool `json:"healthy,omitempty"`
+	// CPU is the percentage of cpu used by the node
+	CPU float64 `json:"cpu"`
+	// Memory is the resident set size of the node in KB
+	Memory float64 `json:"memory"`
+	// LogErrors is the number of lines which matched the log pattern since the previous sample
+	LogErrors int `json:"logErrors"`
+	// Errors are the first few of those lines
+	Errors []string `json:"errors,omitempty"`
+	// Error is why the node could not be fully sampled
+	Error string `json:"error,omitempty"`
+}
+
+// Sample is the state of the nodes of a testnet at a point in time
+type Sample struct {
+	Time time.Time `json:"time"`
+	// Height is the highest height of the chains of the nodes, left out if the consensus is not probed
+	Height *int64 `json:"height,omitempty"`
+	// Live is whether the chain is producing blocks, left out if the consensus is not probed
+	Live  *bool        `json:"live,omitempty"`
+	Nodes []NodeSample `json:"nodes"`
+}
+
+// take samples the nodes of the testnet, counting the lines of their logs matching the given pattern which are
+// past the given offsets, which are moved to the end of the logs
+func take(tn *testnet.TestNet, pattern string, offsets map[int]int64) Sample {
+	states := deploy.GetNodeStates(tn)
+	out := Sample{Nodes: make([]NodeSample, len(states))}
+	mux := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for i, state := range states {
+		out.Nodes[i] = NodeSample{Node: state.AbsoluteNum, State: state.State, RestartCount: state.RestartCount,
+			Error: state.Error}
+		if state.Health != nil {
+			healthy := state.Health.Healthy
+			out.Nodes[i].Healthy = &healthy
+		}
+		client, ok := tn.Clients[state.Server]
+		if !ok || state.State != "running" {
+			continue
+		}
+		mux.Lock()
+		offset := offsets[state.AbsoluteNum]
+		mux.Unlock()
+		wg.Add(1)
+		go func(client ssh.Client, node ssh.Node, sample *NodeSample, offset int64) {
+			defer wg.Done()
+			usage, err := status.GetResUsage(client, node.GetNodeName())
+			if err == nil {
+				sample.CPU = usage.CPU
+				sample.Memory = usage.RSS
+			}
+			res, err := client.DockerExec(node, "sh -c "+util.ShellQuote(logCommand(pattern, offset)))
+			if err == nil {
+				offset, sample.LogErrors, sample.Errors, err = parseLogSample(res)
+			}
+			if err != nil {
+				sample.Error = util.LogError(err).Error()
+				return
+			}
+			mux.Lock()
+			offsets[node.GetAbsoluteNumber()] = offset
+			mux.Unlock()
+		}(client, state.Node, &out.Nodes[i], offset)
+	}
+	wg.Wait()
+
+	report, err := consensus.GetReport(tn.TestNetID)
+	if err == nil {
+		out.Height = &report.Height
+		out.Live = &report.Live
+	}
+	return out
+}
+
+// logCommand gets the shell script which gives the size of the log of a node, followed by the number of its
+// lines past the given offset which match the pattern, and then the first few of them. The offset is
+// started over from if the log has since been truncated.
+func logCommand(pattern string, offset int64) string {
+	return fmt.Sprintf("f=%s; size=$(wc -c < $f 2>/dev/null || echo 0); off=%d; "+
+		"if [ $size -lt $off ]; then off=0; fi; echo $size; "+
+		"tail -c +$((off+1)) $f 2>/dev/null | head -c $((size-off)) | grep -iE %s | "+
+		"awk '{n++; if (n<=%d) l[n]=$0} END {print n+0; for (i=1; i<=n && i<=%d; i++) print l[i]}'",
+		util.ShellQuote(conf.DockerOutputFile), offset, util.ShellQuote(pattern), maxErrorLines, maxErrorLines)
+}
+
+// parseLogSample parses the output of logCommand
+func parseLogSample(res string) (int64, int, []string, error) {
+	lines := strings.Split(strings.TrimRight(res, "\n"), "\n")
+	if len(lines) < 2 {
+		return 0, 0, nil, fmt.Errorf("unexpected output from sampling the log: %s", res)
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(lines[0]), 10, 64)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("unexpected size of the log: %s", lines[0])
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("unexpected number of errors in the log: %s", lines[1])
+	}
+	var errs []string
+	for _, line := range lines[2:] {
+		if len(line) > maxErrorLength {
+			line = line[:maxErrorLength]
+		}
+		errs = append(errs, line)
+	}
+	return size, count, errs, nil
+}
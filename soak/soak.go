@@ -0,0 +1,446 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package soak runs long stability tests of testnets, periodically sampling the state, health, resource use and
+// logs of their nodes, rotating packet captures of them, and delivering a digest of the samples to the webhooks
+// and by email once every digest interval.
+package soak
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/artifacts"
+	"github.com/whiteblock/genesis/capture"
+	"github.com/whiteblock/genesis/consensus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/notify"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"net/mail"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+const (
+	sessionsKey = "soak_tests"
+
+	samplesArtifact  = "soak/samples.jsonl"
+	digestsArtifacts = "soak/digests"
+	capturesArtifact = "soak/captures"
+
+	// minInterval is the shortest number of seconds allowed between samples
+	minInterval = 10
+
+	defaultLogPattern      = "error|panic|fatal"
+	defaultCaptureInterval = 3600
+	defaultCaptureKeep     = 24
+)
+
+// Capture configures the packet captures which are rotated during a soak test
+type Capture struct {
+	// Nodes are the absolute numbers of the nodes to capture the traffic of
+	Nodes []int `json:"nodes"`
+	// Interval is the number of seconds between the start of each capture, defaults to 3600
+	Interval int `json:"interval"`
+	// Duration is the number of seconds each capture runs for, defaults to 60
+	Duration int `json:"duration"`
+	// MaxSize is the maximum size of each capture in megabytes, defaults to 100
+	MaxSize int `json:"maxSize"`
+	// Filter is a pcap filter expression, such as "tcp port 30303"
+	Filter string `json:"filter"`
+	// Keep is the number of the latest captures which are kept, older ones are removed. Defaults to 24
+	Keep int `json:"keep"`
+}
+
+func (c Capture) request() capture.Request {
+	return capture.Request{Action: capture.StartAction, Nodes: c.Nodes, Duration: c.Duration,
+		MaxSize: c.MaxSize, Filter: c.Filter}
+}
+
+// Config configures a soak test
+type Config struct {
+	// Interval is the number of seconds between each sample of the nodes, defaults to soakSampleInterval
+	Interval int `json:"interval"`
+	// DigestInterval is the number of seconds between each digest, defaults to soakDigestInterval
+	DigestInterval int `json:"digestInterval"`
+	// Duration is the number of seconds after which the soak test stops by itself, 0 for it to run until it is
+	// stopped or the testnet is torn down
+	Duration int `json:"duration"`
+	// LogPattern is the extended regular expression, matched case insensitively, of the lines of the logs of the
+	// nodes which are counted as errors. Defaults to "error|panic|fatal"
+	LogPattern string `json:"logPattern"`
+	// Capture configures the packet captures to rotate, left out to not capture any packets
+	Capture *Capture `json:"capture,omitempty"`
+	// Emails are the addresses the digests are emailed to
+	Emails []string `json:"emails"`
+}
+
+// ValidateAndSetDefaults ensures that the configuration is valid, filling in the defaults of the missing fields
+func (c *Config) ValidateAndSetDefaults() error {
+	if c.Interval == 0 {
+		c.Interval = conf.SoakSampleInterval
+	}
+	if c.DigestInterval == 0 {
+		c.DigestInterval = conf.SoakDigestInterval
+	}
+	if len(c.LogPattern) == 0 {
+		c.LogPattern = defaultLogPattern
+	}
+	if c.Interval < minInterval {
+		return fmt.Errorf("the interval must be at least %d seconds", minInterval)
+	}
+	if c.DigestInterval < c.Interval {
+		return fmt.Errorf("the digest interval must be at least the interval of %d seconds", c.Interval)
+	}
+	if c.Duration < 0 {
+		return fmt.Errorf("the duration cannot be negative")
+	}
+	if strings.ContainsAny(c.LogPattern, "\n\r") {
+		return fmt.Errorf("invalid log pattern \"%s\"", c.LogPattern)
+	}
+	_, err := regexp.Compile(c.LogPattern)
+	if err != nil {
+		return fmt.Errorf("invalid log pattern \"%s\": %s", c.LogPattern, err.Error())
+	}
+	if len(c.Emails) > 0 && !notify.EmailEnabled() {
+		return fmt.Errorf("the digests cannot be emailed, as smtpHost is not set")
+	}
+	for _, email := range c.Emails {
+		_, err := mail.ParseAddress(email)
+		if err != nil {
+			return fmt.Errorf("invalid email address \"%s\"", email)
+		}
+	}
+	if c.Capture == nil {
+		return nil
+	}
+	if c.Capture.Interval == 0 {
+		c.Capture.Interval = defaultCaptureInterval
+	}
+	if c.Capture.Keep == 0 {
+		c.Capture.Keep = defaultCaptureKeep
+	}
+	req := c.Capture.request()
+	err = req.ValidateAndSetDefaults()
+	if err != nil {
+		return err
+	}
+	c.Capture.Duration = req.Duration
+	c.Capture.MaxSize = req.MaxSize
+	if c.Capture.Keep < 0 {
+		return fmt.Errorf("the number of captures to keep cannot be negative")
+	}
+	if c.Capture.Interval < c.Capture.Duration+minInterval {
+		return fmt.Errorf("the capture interval must be at least %d seconds longer than their duration", minInterval)
+	}
+	return nil
+}
+
+// Session is the state of the soak test of a testnet
+type Session struct {
+	TestNetID string    `json:"testnetId"`
+	Config    Config    `json:"config"`
+	Started   time.Time `json:"started"`
+	// Sampled is when the nodes were last sampled
+	Sampled time.Time `json:"sampled"`
+	// Digested is when the latest digest was delivered, or when the soak test started if none have been
+	Digested time.Time `json:"digested"`
+	// Digests is the number of digests which have been delivered
+	Digests int `json:"digests"`
+	// Captured is when the latest packet capture was started
+	Captured time.Time `json:"captured"`
+	// Captures are the artifact directories of the kept packet captures, oldest first
+	Captures []string `json:"captures"`
+	// Offsets is how much of the log of each node, by absolute number, has already been sampled
+	Offsets map[int]int64 `json:"offsets"`
+}
+
+// Status is the state of a soak test, along with its latest sample
+type Status struct {
+	Session
+	Latest *Sample `json:"latest,omitempty"`
+}
+
+// runner runs the soak test of a testnet
+type runner struct {
+	mux     sync.Mutex
+	session Session
+	latest  *Sample
+	stop    chan bool
+	done    chan bool
+}
+
+var (
+	runners     = map[string]*runner{}
+	runnersMux  = sync.Mutex{}
+	sessionsMux = sync.Mutex{}
+)
+
+func getSessions() map[string]Session {
+	out := map[string]Session{}
+	db.GetMetaP(sessionsKey, &out) //An error means there are no soak tests
+	return out
+}
+
+func saveSession(session Session) error {
+	sessionsMux.Lock()
+	defer sessionsMux.Unlock()
+	sessions := getSessions()
+	sessions[session.TestNetID] = session
+	return db.SetMeta(sessionsKey, sessions)
+}
+
+func removeSession(testnetID string) error {
+	sessionsMux.Lock()
+	defer sessionsMux.Unlock()
+	sessions := getSessions()
+	if _, ok := sessions[testnetID]; !ok {
+		return nil
+	}
+	delete(sessions, testnetID)
+	return db.SetMeta(sessionsKey, sessions)
+}
+
+// Start starts a soak test of the testnet with the given configuration, which should already have been validated.
+// Returns an error if the testnet is already being soak tested.
+func Start(tn *testnet.TestNet, cfg Config) (Session, error) {
+	kcfg, err := tn.GetKubernetesConfig()
+	if err != nil {
+		return Session{}, util.LogError(err)
+	}
+	if kcfg.Enabled {
+		return Session{}, fmt.Errorf("soak tests are not supported on kubernetes")
+	}
+	runnersMux.Lock()
+	defer runnersMux.Unlock()
+	if _, ok := runners[tn.TestNetID]; ok {
+		return Session{}, fmt.Errorf("testnet \"%s\" is already being soak tested", tn.TestNetID)
+	}
+	now := time.Now()
+	session := Session{TestNetID: tn.TestNetID, Config: cfg, Started: now, Digested: now,
+		Captures: []string{}, Offsets: map[int]int64{}}
+	err = saveSession(session)
+	if err != nil {
+		return Session{}, util.LogError(err)
+	}
+	launch(session)
+	log.WithFields(log.Fields{"testnet": tn.TestNetID, "interval": cfg.Interval}).Info("started a soak test")
+	return session, nil
+}
+
+// launch starts running the given session, runnersMux must be held
+func launch(session Session) {
+	r := &runner{session: session, stop: make(chan bool), done: make(chan bool)}
+	runners[session.TestNetID] = r
+	go r.run()
+}
+
+// Resume restarts the soak tests which were running when genesis was last stopped
+func Resume() {
+	sessionsMux.Lock()
+	sessions := getSessions()
+	sessionsMux.Unlock()
+
+	runnersMux.Lock()
+	defer runnersMux.Unlock()
+	for testnetID, session := range sessions {
+		if _, ok := runners[testnetID]; ok {
+			continue
+		}
+		if session.Offsets == nil {
+			session.Offsets = map[int]int64{}
+		}
+		launch(session)
+		log.WithFields(log.Fields{"testnet": testnetID}).Info("resumed a soak test")
+	}
+}
+
+// Get gets the state of the soak test of the testnet
+func Get(testnetID string) (Status, error) {
+	runnersMux.Lock()
+	r, ok := runners[testnetID]
+	runnersMux.Unlock()
+	if !ok {
+		return Status{}, fmt.Errorf("testnet \"%s\" is not being soak tested", testnetID)
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return Status{Session: r.session, Latest: r.latest}, nil
+}
+
+// Stop stops the soak test of the testnet, collecting its running packet captures and delivering a final
+// digest of the samples taken since the last one. Does nothing if the testnet is not being soak tested.
+func Stop(testnetID string) error {
+	runnersMux.Lock()
+	r, ok := runners[testnetID]
+	if ok {
+		delete(runners, testnetID)
+	}
+	runnersMux.Unlock()
+	if !ok {
+		return nil
+	}
+	close(r.stop)
+	<-r.done
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.session.Config.Capture != nil {
+		tn, err := testnet.RestoreTestNet(testnetID)
+		if err == nil {
+			_, err = capture.Stop(tn, r.session.Config.Capture.Nodes, nil)
+		}
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": testnetID, "error": err}).Warn("failed to collect the packet captures")
+		}
+		r.session.archiveCaptures()
+	}
+	if r.session.Sampled.After(r.session.Digested) {
+		r.session.digest(time.Now())
+	}
+	log.WithFields(log.Fields{"testnet": testnetID, "digests": r.session.Digests}).Info("stopped a soak test")
+	return removeSession(testnetID)
+}
+
+func (r *runner) run() {
+	defer close(r.done)
+	r.tick(time.Now())
+	ticker := time.NewTicker(time.Duration(r.session.Config.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case now := <-ticker.C:
+			if r.tick(now) {
+				go Stop(r.session.TestNetID)
+				<-r.stop
+				return
+			}
+		}
+	}
+}
+
+// copy gets a copy of the session which can be modified without affecting it
+func (s Session) copy() Session {
+	s.Captures = append([]string{}, s.Captures...)
+	offsets := map[int]int64{}
+	for node, offset := range s.Offsets {
+		offsets[node] = offset
+	}
+	s.Offsets = offsets
+	return s
+}
+
+// tick samples the nodes, and then rotates the captures and delivers a digest if they are due. The work is done
+// on a copy of the session, so that its state can be read in the meantime. Gives whether the soak test has run
+// for its duration.
+func (r *runner) tick(now time.Time) bool {
+	r.mux.Lock()
+	session := r.session.copy()
+	r.mux.Unlock()
+
+	sample, err := session.tick(now)
+	r.mux.Lock()
+	r.session = session
+	if err == nil {
+		r.latest = &sample
+	}
+	r.mux.Unlock()
+	util.LogError(saveSession(session))
+	return session.Config.Duration > 0 && now.Sub(session.Started) >= time.Duration(session.Config.Duration)*time.Second
+}
+
+func (s *Session) tick(now time.Time) (Sample, error) {
+	tn, err := testnet.RestoreTestNet(s.TestNetID)
+	if err != nil {
+		log.WithFields(log.Fields{"testnet": s.TestNetID, "error": err}).Error("couldn't restore the testnet to sample it")
+		return Sample{}, err
+	}
+	if !consensus.Watching(tn.TestNetID) {
+		//The probes are not running if genesis was restarted since the soak test started
+		err = consensus.Watch(tn)
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": s.TestNetID, "error": err}).Debug(
+				"the samples will not include the consensus")
+		}
+	}
+	sample := take(tn, s.Config.LogPattern, s.Offsets)
+	sample.Time = now
+	data, err := json.Marshal(sample)
+	if err == nil {
+		err = artifacts.Append(s.TestNetID, samplesArtifact, append(data, '\n'))
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"testnet": s.TestNetID, "error": err}).Error("failed to store the sample")
+	}
+	s.Sampled = now
+
+	capCfg := s.Config.Capture
+	if capCfg != nil && now.Sub(s.Captured) >= time.Duration(capCfg.Interval)*time.Second {
+		s.archiveCaptures()
+		_, err = capture.Start(tn, capCfg.request())
+		if err != nil {
+			log.WithFields(log.Fields{"testnet": s.TestNetID, "error": err}).Error("failed to start the packet captures")
+		} else {
+			s.Captured = now
+		}
+	}
+	if now.Sub(s.Digested) >= time.Duration(s.Config.DigestInterval)*time.Second {
+		s.digest(now)
+	}
+	return sample, nil
+}
+
+// archiveCaptures moves the collected packet captures of the latest rotation into their own directory,
+// and removes the oldest directories beyond the number of captures to keep
+func (s *Session) archiveCaptures() {
+	if s.Captured.IsZero() {
+		return
+	}
+	dir := fmt.Sprintf("%s/%s", capturesArtifact, s.Captured.UTC().Format("2006-01-02T15-04-05"))
+	moved := false
+	for _, node := range s.Config.Capture.Nodes {
+		name := fmt.Sprintf("node%d.pcap", node)
+		if artifacts.Rename(s.TestNetID, "captures/"+name, dir+"/"+name) == nil {
+			moved = true
+		}
+	}
+	if !moved {
+		return
+	}
+	var removed []string
+	s.Captures, removed = prune(append(s.Captures, dir), s.Config.Capture.Keep)
+	for _, old := range removed {
+		util.LogError(artifacts.Delete(s.TestNetID, old))
+	}
+}
+
+// prune keeps the last keep of the given directories, giving those kept and those to remove
+func prune(dirs []string, keep int) ([]string, []string) {
+	if len(dirs) <= keep {
+		return dirs, nil
+	}
+	cut := len(dirs) - keep
+	return append([]string{}, dirs[cut:]...), dirs[:cut]
+}
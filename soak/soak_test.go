@@ -0,0 +1,212 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package soak
+
+import (
+	"github.com/whiteblock/genesis/consensus"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfig_ValidateAndSetDefaults(t *testing.T) {
+	var test = []struct {
+		cfg   Config
+		valid bool
+	}{
+		{cfg: Config{}, valid: true},
+		{cfg: Config{Interval: 60, DigestInterval: 3600, Duration: 604800}, valid: true},
+		{cfg: Config{Interval: 5}, valid: false},
+		{cfg: Config{Interval: 600, DigestInterval: 60}, valid: false},
+		{cfg: Config{Duration: -1}, valid: false},
+		{cfg: Config{LogPattern: "error|warn"}, valid: true},
+		{cfg: Config{LogPattern: "error(("}, valid: false},
+		{cfg: Config{LogPattern: "error\nwarn"}, valid: false},
+		{cfg: Config{Capture: &Capture{Nodes: []int{0, 1}}}, valid: true},
+		{cfg: Config{Capture: &Capture{}}, valid: false},
+		{cfg: Config{Capture: &Capture{Nodes: []int{0}, Interval: 60, Duration: 60}}, valid: false},
+		{cfg: Config{Capture: &Capture{Nodes: []int{0}, Keep: -1}}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.cfg.ValidateAndSetDefaults()
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateAndSetDefaults returned %v", err)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateAndSetDefaults_Defaults(t *testing.T) {
+	cfg := Config{Capture: &Capture{Nodes: []int{0}}}
+	err := cfg.ValidateAndSetDefaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Interval != conf.SoakSampleInterval || cfg.DigestInterval != conf.SoakDigestInterval {
+		t.Errorf("unexpected intervals %d and %d", cfg.Interval, cfg.DigestInterval)
+	}
+	if cfg.LogPattern != defaultLogPattern {
+		t.Errorf("unexpected log pattern %s", cfg.LogPattern)
+	}
+	if cfg.Capture.Interval != defaultCaptureInterval || cfg.Capture.Keep != defaultCaptureKeep ||
+		cfg.Capture.Duration == 0 || cfg.Capture.MaxSize == 0 {
+		t.Errorf("the defaults of the capture were not set: %+v", *cfg.Capture)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	var test = []struct {
+		dirs    []string
+		keep    int
+		kept    []string
+		removed []string
+	}{
+		{dirs: []string{"a", "b"}, keep: 2, kept: []string{"a", "b"}, removed: nil},
+		{dirs: []string{"a", "b", "c"}, keep: 2, kept: []string{"b", "c"}, removed: []string{"a"}},
+		{dirs: []string{"a", "b"}, keep: 0, kept: []string{}, removed: []string{"a", "b"}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			kept, removed := prune(tt.dirs, tt.keep)
+			if !reflect.DeepEqual(kept, tt.kept) || !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("prune gave %v and %v, expected %v and %v", kept, removed, tt.kept, tt.removed)
+			}
+		})
+	}
+}
+
+func TestParseLogSample(t *testing.T) {
+	var test = []struct {
+		res    string
+		size   int64
+		count  int
+		errs   []string
+		failed bool
+	}{
+		{res: "1024\n0\n", size: 1024, count: 0, errs: nil},
+		{res: "2048\n7\nERROR a\nFATAL b\n", size: 2048, count: 7, errs: []string{"ERROR a", "FATAL b"}},
+		{res: "2048\n", failed: true},
+		{res: "big\n0\n", failed: true},
+		{res: "2048\nmany\n", failed: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			size, count, errs, err := parseLogSample(tt.res)
+			if (err != nil) != tt.failed {
+				t.Fatalf("parseLogSample returned %v", err)
+			}
+			if tt.failed {
+				return
+			}
+			if size != tt.size || count != tt.count || !reflect.DeepEqual(errs, tt.errs) {
+				t.Errorf("parseLogSample gave %d, %d, %v", size, count, errs)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Unix(1000, 0)
+	yes, no := true, false
+	height := func(h int64) *int64 { return &h }
+	samples := []Sample{
+		{
+			Time: start.Add(time.Minute), Height: height(10), Live: &yes,
+			Nodes: []NodeSample{
+				{Node: 0, State: "running", Healthy: &yes, CPU: 10, Memory: 1000, LogErrors: 1, Errors: []string{"error a"}},
+				{Node: 1, State: "running", RestartCount: 1, Healthy: &yes, CPU: 20, Memory: 2000},
+			},
+		},
+		{
+			Time: start.Add(2 * time.Minute), Height: height(15), Live: &no,
+			Nodes: []NodeSample{
+				{Node: 0, State: "running", Healthy: &no, CPU: 30, Memory: 1500, LogErrors: 2, Errors: []string{"error b"}},
+				{Node: 1, State: "exited", RestartCount: 3},
+			},
+		},
+	}
+	d := summarize("tn1", 2, start, start.Add(3*time.Minute), samples)
+	if d.Number != 2 || d.Samples != 2 || *d.Live != 50 || *d.Blocks != 5 {
+		t.Errorf("unexpected digest %+v", d)
+	}
+	if len(d.Nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(d.Nodes))
+	}
+	n0, n1 := d.Nodes[0], d.Nodes[1]
+	if n0.Uptime != 100 || *n0.Health != 50 || n0.AvgCPU != 20 || n0.MaxCPU != 30 || n0.MaxMemory != 1500 ||
+		n0.MemoryGrowth != 500 || n0.LogErrors != 3 || !reflect.DeepEqual(n0.Errors, []string{"error a", "error b"}) {
+		t.Errorf("unexpected digest of node 0 %+v", n0)
+	}
+	if n1.Uptime != 50 || *n1.Health != 100 || n1.Restarts != 2 || n1.AvgCPU != 20 || n1.MemoryGrowth != 0 {
+		t.Errorf("unexpected digest of node 1 %+v", n1)
+	}
+
+	empty := summarize("tn1", 1, start, start.Add(time.Minute), []Sample{})
+	if empty.Live != nil || empty.Blocks != nil || len(empty.Nodes) != 0 {
+		t.Errorf("unexpected digest of no samples %+v", empty)
+	}
+}
+
+func TestDigest_AddConsensus(t *testing.T) {
+	start := time.Unix(1000, 0)
+	d := Digest{From: start, To: start.Add(time.Hour)}
+	d.addConsensus(consensus.Report{
+		Forks:  []consensus.Fork{{Detected: start.Add(-time.Minute)}, {Detected: start.Add(time.Minute)}},
+		Reorgs: []consensus.Reorg{{Detected: start}, {Detected: start.Add(time.Hour)}},
+		Stalls: []consensus.Stall{{Started: start.Add(30 * time.Minute)}},
+	})
+	if d.Forks != 1 || d.Reorgs != 1 || d.Stalls != 1 {
+		t.Errorf("counted %d forks, %d reorgs and %d stalls", d.Forks, d.Reorgs, d.Stalls)
+	}
+}
+
+func TestDigest_String(t *testing.T) {
+	live := 100.0
+	health := 99.5
+	d := Digest{TestNetID: "tn1", Number: 3, Samples: 288, Live: &live,
+		Nodes:    []NodeDigest{{Node: 0, Uptime: 100, Health: &health, LogErrors: 1, Errors: []string{"ERROR lost peer"}}},
+		Captures: []string{"soak/captures/2019-10-17T00-00-00"}}
+	out := d.String()
+	for _, expected := range []string{"digest #3 of testnet tn1", "288 samples", "Chain live in 100.0%",
+		"Node 0: up 100.0%, healthy 99.5%", "    ERROR lost peer", "soak/captures/2019-10-17T00-00-00"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in the digest:\n%s", expected, out)
+		}
+	}
+}
+
+func TestParseSamples(t *testing.T) {
+	data := []byte(`{"time":"2019-10-17T00:00:00Z","nodes":[]}
+{"time":"2019-10-17T00:05:00Z","nodes":[{"node":0,"state":"running"}]}
+not json
+{"time":"2019-10-17T00:10:00Z","nodes":[]}
+`)
+	from, _ := time.Parse(time.RFC3339, "2019-10-17T00:00:00Z")
+	to, _ := time.Parse(time.RFC3339, "2019-10-17T00:05:00Z")
+	samples := parseSamples(data, from, to)
+	if len(samples) != 1 || !samples[0].Time.Equal(to) || len(samples[0].Nodes) != 1 {
+		t.Errorf("unexpected samples %+v", samples)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package ssh
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/secrets"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"os"
+	"strings"
+)
+
+// fileHash gives the hex encoded SHA-256 of the contents of the given file
+func fileHash(file string) (string, error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	hash := sha256.New()
+	_, err = io.Copy(hash, in)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// useCache checks whether the files copied to the server go through the cache in remoteCacheDir. Files
+// are not cached when secrets are enabled, as they may hold secrets which must not be left on the server.
+func (sshClient *client) useCache() bool {
+	return !sshClient.local && len(conf.RemoteCacheDir) > 0 && !secrets.Enabled()
+}
+
+// cachedCopy copies src to dest on the server through the cache in remoteCacheDir, where the files are named
+// by the SHA-256 of their contents. If the server already has a file with the same contents, it is copied from
+// the cache instead of being sent again. The cached file is copied rather than linked, so that changes to dest
+// do not corrupt the cache.
+func (sshClient *client) cachedCopy(src string, dest string) error {
+	hash, err := fileHash(src)
+	if err != nil {
+		return util.LogError(err)
+	}
+	cached := strings.TrimSuffix(conf.RemoteCacheDir, "/") + "/" + hash
+	entry := sshClient.logger().WithFields(log.Fields{"src": src, "dst": dest, "hash": hash})
+
+	// the cached file is touched so that it is not collected while it is in use
+	out, err := sshClient.Run(fmt.Sprintf("mkdir -p %s && if [ -f %s ]; then touch %s && cp -f %s %s && echo hit; fi",
+		util.ShellQuote(conf.RemoteCacheDir), cached, cached, cached, util.ShellQuote(dest)))
+	if err == nil && strings.TrimSpace(out) == "hit" {
+		entry.Info("copied the file from the cache on the server")
+		return nil
+	}
+
+	id, err := util.GetUUIDString()
+	if err != nil {
+		return util.LogError(err)
+	}
+	// the file is sent under a temporary name, so that a partially sent file is never in the cache
+	partial := cached + "." + id
+	err = sshClient.scp(src, partial)
+	if err != nil {
+		sshClient.Run("rm -f " + partial)
+		return err
+	}
+	_, err = sshClient.Run(fmt.Sprintf("mv -f %s %s && cp -f %s %s", partial, cached, cached, util.ShellQuote(dest)))
+	return util.LogError(err)
+}
@@ -21,17 +21,21 @@
 package ssh
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/tracing"
 	"github.com/whiteblock/genesis/util"
 	"github.com/whiteblock/scp"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/sync/semaphore"
+	"io"
 	"io/ioutil"
+	"net"
+	"os"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -60,6 +64,12 @@ type Client interface {
 	// DockerCp copies a file on a remote machine from source to the dest in the node
 	DockerCp(node Node, source string, dest string) error
 
+	// Fetch writes the contents of the file at source on the server to dest
+	Fetch(source string, dest io.Writer) error
+
+	// DockerFetch writes the contents of the file at source in the given node to dest
+	DockerFetch(node Node, source string, dest io.Writer) error
+
 	// KeepTryDockerExec is like KeepTryRun for nodes
 	KeepTryDockerExec(node Node, command string) (string, error)
 
@@ -105,73 +115,121 @@ type Client interface {
 	// a file over to a remote machine.
 	Scp(src string, dest string) error
 
+	// Sync pushes the file or directory at src over to dest on the remote machine, only sending
+	// what differs from what is already there when rsync is enabled
+	Sync(src string, dest string) error
+
+	// Runtime gets the container runtime of the server
+	Runtime() util.Runtime
+
+	// Shell opens an interactive shell inside of the given node, behind a virtual terminal
+	// with the given dimensions
+	Shell(node Node, cols int, rows int) (Shell, error)
+
+	// Dial opens a connection to the given address from the server, such as to the rpc port of a node
+	Dial(network string, address string) (net.Conn, error)
+
 	// Close cleans up the resources used by sshClient object
 	Close()
 }
 
+// Credentials are what is used to ssh into a server. The empty fields fall back to
+// the sshUser and sshKey config options, and to port 22.
+type Credentials struct {
+	// User is the user to log in as
+	User string
+	// Key is the location of the private key
+	Key string
+	// PrivateKey is the contents of the private key, which takes the place of Key
+	PrivateKey string
+	// Port is the port of the ssh server
+	Port int
+}
+
+func (creds Credentials) user() string {
+	if len(creds.User) == 0 {
+		return conf.SSHUser
+	}
+	return creds.User
+}
+
+func (creds Credentials) keyLocation() string {
+	if len(creds.PrivateKey) > 0 {
+		return "(given)"
+	}
+	if len(creds.Key) == 0 {
+		return conf.SSHKey
+	}
+	return creds.Key
+}
+
+func (creds Credentials) key() ([]byte, error) {
+	if len(creds.PrivateKey) > 0 {
+		return []byte(creds.PrivateKey), nil
+	}
+	return ioutil.ReadFile(creds.keyLocation())
+}
+
+func (creds Credentials) port() int {
+	if creds.Port == 0 {
+		return 22
+	}
+	return creds.Port
+}
+
 type client struct {
-	clients  []*ssh.Client
+	pool     *pool
 	host     string
+	creds    Credentials
 	serverID int
-	mux      *sync.RWMutex
 	sem      *semaphore.Weighted
+	// local is whether the commands are run directly on this machine instead of over ssh
+	local bool
+	// runtime is the container runtime of the server
+	runtime util.Runtime
 }
 
 // NewClient creates an instance of Client, with a connection to the
-// host server given.
-func NewClient(host string, serverID int) (Client, error) {
+// host server given, which runs its containers with the given runtime. If the host
+// is this machine and localBackend is enabled, the commands will be executed directly, without ssh.
+// Otherwise, the connection is made with the given credentials.
+func NewClient(host string, serverID int, runtime util.Runtime, creds Credentials) (Client, error) {
 	out := new(client)
-	for i := conf.MaxConnections; i > 0; i -= 5 {
-		c, err := sshConnect(host)
+	out.runtime = runtime
+	if conf.LocalBackend && isLocalHost(host) {
+		log.WithFields(log.Fields{"host": host, "server": serverID}).Info("using the local backend")
+		out.local = true
+	}
+	if !out.local {
+		var err error
+		out.pool, err = newPool(host, creds)
 		if err != nil {
 			return nil, util.LogError(err)
 		}
-		out.clients = append(out.clients, c)
 	}
 	out.host = host
+	out.creds = creds
 	out.serverID = serverID
-	out.mux = &sync.RWMutex{}
 	out.sem = semaphore.NewWeighted(int64(conf.MaxConnections))
 	return out, nil
 }
 
 func (sshClient *client) getSession() (*Session, error) {
-	sshClient.mux.RLock()
-	ctx := context.TODO()
-	sshClient.sem.Acquire(ctx, 1)
-	for _, client := range sshClient.clients {
-		session, err := client.NewSession()
-		if err != nil {
-			continue
-		}
-		sshClient.mux.RUnlock()
-		return NewSession(session, sshClient.sem), nil
-	}
-	sshClient.mux.RUnlock()
-
-	client, err := sshConnect(sshClient.host)
-	for err != nil && (strings.Contains(err.Error(), "connection reset by peer") || strings.Contains(err.Error(), "EOF")) {
-		log.WithFields(log.Fields{"error": err}).Error("error connecting to remote host,retrying once")
-		time.Sleep(50 * time.Millisecond)
-		client, err = sshConnect(sshClient.host)
-	}
-	if client == nil {
-		sshClient.sem.Release(1)
-		return nil, fmt.Errorf("error(\"%s\"): client is nil", err.Error())
-	}
+	sshClient.sem.Acquire(context.TODO(), 1)
+	session, done, err := sshClient.pool.get()
 	if err != nil {
 		sshClient.sem.Release(1)
 		return nil, util.LogError(err)
 	}
-	session, err := client.NewSession()
-	if err != nil {
-		sshClient.sem.Release(1)
-		return nil, util.LogError(err)
+	return &Session{sess: session, sem: sshClient.sem, done: done}, nil
+}
+
+// PoolStats gets the metrics of the pool of connections to the server
+func (sshClient *client) PoolStats() PoolStats {
+	if sshClient.pool == nil {
+		return PoolStats{Host: sshClient.host}
 	}
-	sshClient.mux.Lock()
-	sshClient.clients = append(sshClient.clients, client)
-	sshClient.mux.Unlock()
-	return NewSession(session, sshClient.sem), nil
+	return sshClient.pool.currentStats()
 }
 
 // MultiRun provides an easy shorthand for multiple calls to sshExec
@@ -204,34 +262,98 @@ func (sshClient *client) FastMultiRun(commands ...string) (string, error) {
 
 // Run executes a given command on the connected remote machine.
 func (sshClient *client) Run(command string) (string, error) {
+	return sshClient.run(sshClient.logger(), command)
+}
+
+// logger gets a logger annotated with the details of the server, and the build currently
+// in progress on it, if there is one.
+func (sshClient *client) logger() *log.Entry {
+	entry := log.WithFields(log.Fields{"host": sshClient.host, "server": sshClient.serverID})
+	bs := state.GetBuildStateByServerID(sshClient.serverID)
+	if bs != nil {
+		entry = entry.WithFields(log.Fields{"build": bs.BuildID})
+	}
+	return entry
+}
+
+func (sshClient *client) nodeLogger(node Node) *log.Entry {
+	return sshClient.logger().WithFields(LogFields(node))
+}
+
+func spanAttributes(entry *log.Entry, command string) map[string]string {
+	out := map[string]string{"command": command}
+	for key, value := range entry.Data {
+		out[key] = fmt.Sprint(value)
+	}
+	return out
+}
+
+// combinedOutput executes the command on the server, returning its combined stdout and stderr. The
+// command is given up on once ctx is done or it has taken longer than timeout.
+func (sshClient *client) combinedOutput(ctx context.Context, timeout time.Duration, command string) ([]byte, error) {
+	if sshClient.local {
+		sshClient.sem.Acquire(context.TODO(), 1)
+		defer sshClient.sem.Release(1)
+		return localExec(ctx, timeout, command)
+	}
 	session, err := sshClient.getSession()
 	if err != nil {
-		return "", util.LogError(err)
+		return nil, util.LogError(err)
 	}
-	log.WithFields(log.Fields{"host": sshClient.host, "command": command}).Trace("executing command")
+	defer session.Close()
+	var out []byte
+	err = runSession(ctx, timeout, session.Get(), func() (err error) {
+		out, err = session.Get().CombinedOutput(command)
+		return err
+	})
+	return out, err
+}
+
+// record adds the command to the transcript of the build, as a local command when on the local backend
+func (sshClient *client) record(bs *state.BuildState, command string, err error) {
+	if sshClient.local {
+		bs.RecordLocalCommand(sshClient.serverID, command, err)
+		return
+	}
+	bs.RecordCommand(sshClient.serverID, command, err)
+}
+
+func (sshClient *client) run(entry *log.Entry, command string) (string, error) {
+	entry.WithFields(log.Fields{"command": command}).Trace("executing command")
 
 	bs := state.GetBuildStateByServerID(sshClient.serverID)
-	defer session.Close()
 	if bs.Stop() {
 		return "", bs.GetError()
 	}
-
-	out, err := session.Get().CombinedOutput(command)
-	if conf.MaxCommandOutputLogSize == -1 || len(out) <= conf.MaxCommandOutputLogSize {
-		log.Infof("$ %s\n%s\n", command, out)
-	} else {
-		log.Infof("$ %s\n%s...\n", command, out[:conf.MaxCommandOutputLogSize])
+	var span *tracing.Span
+	if bs != nil {
+		span = tracing.StartSpan(bs.BuildID, "ssh", spanAttributes(entry, command))
 	}
 
+	ctx, timeout := bs.CommandContext()
+	out, err := sshClient.combinedOutput(ctx, timeout, command)
+	span.Finish(err)
+	sshClient.record(bs, command, err)
+	output := string(out)
+	if conf.MaxCommandOutputLogSize != -1 && len(out) > conf.MaxCommandOutputLogSize {
+		output = string(out[:conf.MaxCommandOutputLogSize]) + "..."
+	}
+	entry = entry.WithFields(log.Fields{"command": command, "output": output})
 	if err != nil {
-		return string(out), util.FormatError(string(out), err)
+		entry.WithFields(log.Fields{"error": err}).Info("command failed")
+		return string(out), util.CommandError{Command: command, Output: string(out), Err: err}
 	}
+	entry.Info("executed command")
 	return string(out), nil
 }
 
 // KeepTryRun attempts to run a command successfully multiple times. It will
 // keep trying until it reaches the max amount of tries or it is successful once.
 func (sshClient *client) KeepTryRun(command string) (string, error) {
+	return sshClient.keepTryRun(sshClient.logger(), command)
+}
+
+func (sshClient *client) keepTryRun(entry *log.Entry, command string) (string, error) {
 	var res string
 	var err error
 	bs := state.GetBuildStateByServerID(sshClient.serverID)
@@ -239,7 +361,7 @@ func (sshClient *client) KeepTryRun(command string) (string, error) {
 		return "", bs.GetError()
 	}
 	for i := 0; i < conf.MaxRunAttempts; i++ {
-		res, err = sshClient.Run(command)
+		res, err = sshClient.run(entry, command)
 		if err == nil {
 			break
 		}
@@ -249,18 +371,60 @@ func (sshClient *client) KeepTryRun(command string) (string, error) {
 
 // DockerExec executes a command inside of a node
 func (sshClient *client) DockerExec(node Node, command string) (string, error) {
-	return sshClient.Run(fmt.Sprintf("docker exec %s %s", node.GetNodeName(), command))
+	return sshClient.run(sshClient.nodeLogger(node), fmt.Sprintf("%s exec %s %s", sshClient.runtime.CLI, node.GetNodeName(), command))
 }
 
 // DockerCp copies a file on a remote machine from source to the dest in the node
 func (sshClient *client) DockerCp(node Node, source string, dest string) error {
-	_, err := sshClient.Run(fmt.Sprintf("docker cp %s %s:%s", source, node.GetNodeName(), dest))
+	_, err := sshClient.run(sshClient.nodeLogger(node), fmt.Sprintf("%s cp %s %s:%s", sshClient.runtime.CLI, source, node.GetNodeName(), dest))
 	return util.LogError(err)
 }
 
+// stream executes the command on the server, writing its stdout to dest
+func (sshClient *client) stream(entry *log.Entry, command string, dest io.Writer) error {
+	entry.WithFields(log.Fields{"command": command}).Info("streaming the output of a command")
+	stderr := &bytes.Buffer{}
+	bs := state.GetBuildStateByServerID(sshClient.serverID)
+	ctx, timeout := bs.CommandContext()
+	var err error
+	if sshClient.local {
+		sshClient.sem.Acquire(context.TODO(), 1)
+		err = localStream(ctx, timeout, command, dest, stderr)
+		sshClient.sem.Release(1)
+	} else {
+		var session *Session
+		session, err = sshClient.getSession()
+		if err != nil {
+			return util.LogError(err)
+		}
+		defer session.Close()
+		session.Get().Stdout = dest
+		session.Get().Stderr = stderr
+		err = runSession(ctx, timeout, session.Get(), func() error {
+			return session.Get().Run(command)
+		})
+	}
+	sshClient.record(bs, command, err)
+	if err != nil {
+		return util.CommandError{Command: command, Output: stderr.String(), Err: err}
+	}
+	return nil
+}
+
+// Fetch writes the contents of the file at source on the server to dest
+func (sshClient *client) Fetch(source string, dest io.Writer) error {
+	return sshClient.stream(sshClient.logger(), "cat "+util.ShellQuote(source), dest)
+}
+
+// DockerFetch writes the contents of the file at source in the given node to dest
+func (sshClient *client) DockerFetch(node Node, source string, dest io.Writer) error {
+	return sshClient.stream(sshClient.nodeLogger(node), fmt.Sprintf("%s exec %s cat %s",
+		sshClient.runtime.CLI, node.GetNodeName(), util.ShellQuote(source)), dest)
+}
+
 // KeepTryDockerExec is like KeepTryRun for nodes
 func (sshClient *client) KeepTryDockerExec(node Node, command string) (string, error) {
-	return sshClient.KeepTryRun(fmt.Sprintf("docker exec %s %s", node.GetNodeName(), command))
+	return sshClient.keepTryRun(sshClient.nodeLogger(node), fmt.Sprintf("%s exec %s %s", sshClient.runtime.CLI, node.GetNodeName(), command))
 }
 
 // KeepTryDockerExecAll is like KeepTryRun for nodes, but can handle more than one command.
@@ -268,7 +432,7 @@ func (sshClient *client) KeepTryDockerExec(node Node, command string) (string, e
 func (sshClient *client) KeepTryDockerExecAll(node Node, commands ...string) ([]string, error) {
 	out := []string{}
 	for _, command := range commands {
-		res, err := sshClient.KeepTryRun(fmt.Sprintf("docker exec %s %s", node.GetNodeName(), command))
+		res, err := sshClient.keepTryRun(sshClient.nodeLogger(node), fmt.Sprintf("%s exec %s %s", sshClient.runtime.CLI, node.GetNodeName(), command))
 		if err != nil {
 			return nil, util.LogError(err)
 		}
@@ -281,7 +445,7 @@ func (sshClient *client) KeepTryDockerExecAll(node Node, commands ...string) ([]
 // This function will not return the output of the command.
 // This is useful if you are starting a persistent process inside a container
 func (sshClient *client) DockerExecd(node Node, command string) (string, error) {
-	return sshClient.Run(fmt.Sprintf("docker exec -d %s %s", node.GetNodeName(), command))
+	return sshClient.run(sshClient.nodeLogger(node), fmt.Sprintf("%s exec -d %s %s", sshClient.runtime.CLI, node.GetNodeName(), command))
 }
 
 // DockerExecdit runs the given command, and then returns immediately.
@@ -289,7 +453,7 @@ func (sshClient *client) DockerExecd(node Node, command string) (string, error)
 // This is useful if you are starting a persistent process inside a container.
 // Also flags the session as interactive and sets up a virtual tty.
 func (sshClient *client) DockerExecdit(node Node, command string) (string, error) {
-	return sshClient.Run(fmt.Sprintf("docker exec -itd %s %s", node.GetNodeName(), command))
+	return sshClient.run(sshClient.nodeLogger(node), fmt.Sprintf("%s exec -itd %s %s", sshClient.runtime.CLI, node.GetNodeName(), command))
 }
 
 func (sshClient *client) logSanitizeAndStore(node Node, command string) {
@@ -309,7 +473,8 @@ func (sshClient *client) DockerRunMainDaemon(node Node, command string) error {
 // DockerExecdLog will cause the stdout and stderr of the command to be stored in the logs.
 // Should only be used for the blockchain process.
 func (sshClient *client) DockerExecdLog(node Node, command string) error {
-	_, err := sshClient.Run(fmt.Sprintf("docker exec -d %s bash -c '%s 2>&1 > %s'", node.GetNodeName(),
+	_, err := sshClient.run(sshClient.nodeLogger(node), fmt.Sprintf("%s exec -d %s bash -c '%s 2>&1 > %s'",
+		sshClient.runtime.CLI, node.GetNodeName(),
 		command, conf.DockerOutputFile))
 	return util.LogError(err)
 }
@@ -317,7 +482,8 @@ func (sshClient *client) DockerExecdLog(node Node, command string) error {
 // DockerExecdLogAppend will cause the stdout and stderr of the command to be stored in the logs.
 // Should only be used for the blockchain process. Will append to existing logs.
 func (sshClient *client) DockerExecdLogAppend(node Node, command string) error {
-	_, err := sshClient.Run(fmt.Sprintf("docker exec -d %s bash -c '%s 2>&1 >> %s'", node.GetNodeName(),
+	_, err := sshClient.run(sshClient.nodeLogger(node), fmt.Sprintf("%s exec -d %s bash -c '%s 2>&1 >> %s'",
+		sshClient.runtime.CLI, node.GetNodeName(),
 		command, conf.DockerOutputFile))
 	return util.LogError(err)
 }
@@ -338,12 +504,12 @@ func (sshClient *client) dockerMultiExec(node Node, commands []string, kt bool)
 		if len(mergedCommand) != 0 {
 			mergedCommand += "&&"
 		}
-		mergedCommand += fmt.Sprintf("docker exec -d %s %s", node.GetNodeName(), command)
+		mergedCommand += fmt.Sprintf("%s exec -d %s %s", sshClient.runtime.CLI, node.GetNodeName(), command)
 	}
 	if kt {
-		return sshClient.KeepTryRun(mergedCommand)
+		return sshClient.keepTryRun(sshClient.nodeLogger(node), mergedCommand)
 	}
-	return sshClient.Run(mergedCommand)
+	return sshClient.run(sshClient.nodeLogger(node), mergedCommand)
 }
 
 // DockerMultiExec will run all of the given commands strung together with && on
@@ -358,64 +524,74 @@ func (sshClient *client) KTDockerMultiExec(node Node, commands []string) (string
 	return sshClient.dockerMultiExec(node, commands, true)
 }
 
+// Runtime gets the container runtime of the server
+func (sshClient *client) Runtime() util.Runtime {
+	return sshClient.runtime
+}
+
 // Scp is a wrapper for the scp command. Can be used to copy
 // a file over to a remote machine.
 func (sshClient *client) Scp(src string, dest string) error {
-	log.WithFields(log.Fields{"src": src, "dst": dest}).Info("remote copying file")
+	sshClient.logger().WithFields(log.Fields{"src": src, "dst": dest}).Info("remote copying file")
 
-	if !strings.HasPrefix(src, "./") && src[0] != '/' {
-		bs := state.GetBuildStateByServerID(sshClient.serverID)
-		src = "/tmp/" + bs.BuildID + "/" + src
+	bs := state.GetBuildStateByServerID(sshClient.serverID)
+	src = bs.Workspace().Path(src)
+	if sshClient.local {
+		err := util.CopyFile(src, dest)
+		bs.RecordLocalCommand(sshClient.serverID, fmt.Sprintf("cp %s %s", util.ShellQuote(src), util.ShellQuote(dest)), err)
+		return err
 	}
 
-	session, err := sshClient.getSession()
-	if err != nil {
-		return util.LogError(err)
+	if sshClient.useCache() {
+		return sshClient.cachedCopy(src, dest)
 	}
-	defer session.Close()
-
-	return scp.CopyPath(src, dest, session.Get())
+	return sshClient.scp(src, dest)
 }
 
-/*
-   Scpr copies over a directory to a specified path on a remote host
-
-func (sshClient Client) Scpr(dir string) error {
-
-	path := GetPath(dir)
-	_, err := sshClient.Run("mkdir -p " + path)
+// scp copies the file at src to dest on the server over an ssh session, reporting the progress of the
+// copy in the status of the build
+func (sshClient *client) scp(src string, dest string) error {
+	bs := state.GetBuildStateByServerID(sshClient.serverID)
+	f, err := os.Open(src)
 	if err != nil {
 		return util.LogError(err)
 	}
-
-	file := fmt.Sprintf("%s.tar.gz", dir)
-	_, err = BashExec(fmt.Sprintf("tar cfz %s %s", file, dir))
+	defer f.Close()
+	info, err := f.Stat()
 	if err != nil {
 		return util.LogError(err)
 	}
-	err = sshClient.Scp(file, file)
+	transfer := bs.StartTransfer(sshClient.serverID, src, dest, info.Size())
+	defer bs.FinishTransfer(transfer)
+
+	err = sshClient.scpWithProgress(f, info, dest, transfer.Progress)
+	bs.RecordCopy(sshClient.serverID, src, dest, err)
+	return err
+}
+
+// scpWithProgress copies the contents of f to dest on the server over an ssh session, calling fn
+// as the contents are sent
+func (sshClient *client) scpWithProgress(f io.Reader, info os.FileInfo, dest string, fn ProgressFunc) error {
+	session, err := sshClient.getSession()
 	if err != nil {
 		return util.LogError(err)
 	}
-	_, err = sshClient.Run(fmt.Sprintf("tar xfz %s && rm %s", file, file))
-	return err
-}*/
+	defer session.Close()
+
+	return scp.Copy(info.Size(), info.Mode().Perm(), info.Name(),
+		&progressReader{r: f, total: info.Size(), fn: fn}, dest, session.Get())
+}
 
 // Close cleans up the resources used by sshClient object
 func (sshClient *client) Close() {
-	sshClient.mux.Lock()
-	defer sshClient.mux.Unlock()
-	for _, client := range sshClient.clients {
-		if client == nil {
-			continue
-		}
-		client.Close()
+	if sshClient.pool != nil {
+		sshClient.pool.close()
 	}
 }
 
-func sshConnect(host string) (*ssh.Client, error) {
+func sshConnect(host string, creds Credentials) (*ssh.Client, error) {
 
-	key, err := ioutil.ReadFile(conf.SSHKey)
+	key, err := creds.key()
 	if err != nil {
 		return nil, util.LogError(err)
 	}
@@ -424,22 +600,22 @@ func sshConnect(host string) (*ssh.Client, error) {
 		return nil, util.LogError(err)
 	}
 	sshConfig := &ssh.ClientConfig{
-		User: conf.SSHUser,
+		User: creds.user(),
 		Auth: []ssh.AuthMethod{
 			// Use the PublicKeys method for remote authentication.
 			ssh.PublicKeys(signer),
 		},
 	}
 	sshConfig.HostKeyCallback = ssh.InsecureIgnoreHostKey()
-	client, err := ssh.Dial("tcp", fmt.Sprintf("%s:22", host), sshConfig)
+	client, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", host, creds.port()), sshConfig)
 	i := 0
 	for err != nil && i < 10 {
-		client, err = ssh.Dial("tcp", fmt.Sprintf("%s:22", host), sshConfig)
+		client, err = ssh.Dial("tcp", fmt.Sprintf("%s:%d", host, creds.port()), sshConfig)
 		i++
 	}
 	if err != nil {
 		log.WithFields(log.Fields{"host": host, "user": sshConfig.User,
-			"keyLoc": conf.SSHKey}).Error("unable to establish an ssh connection")
+			"keyLoc": creds.keyLocation(), "port": creds.port()}).Error("unable to establish an ssh connection")
 		return nil, util.LogError(err)
 	}
 
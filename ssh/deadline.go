@@ -0,0 +1,51 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"time"
+)
+
+// runSession calls run, which runs a command in the given session, killing the command and closing
+// the session once ctx is done or timeout has passed, so that a wedged command cannot hang the build
+func runSession(ctx context.Context, timeout time.Duration, session *ssh.Session, run func() error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- run()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+	session.Signal(ssh.SIGKILL)
+	session.Close()
+	<-done // the output is written to until run returns
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return fmt.Errorf("cancelled")
+}
@@ -0,0 +1,58 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ssh
+
+import (
+	"context"
+	"github.com/whiteblock/genesis/util"
+	"io"
+	"net"
+	"time"
+)
+
+// isLocalHost checks whether the given host refers to the machine genesis is running on
+func isLocalHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// localExec runs the given command on this machine, in the same manner in which it would be run over ssh,
+// killing it once ctx is done or it has taken longer than timeout
+func localExec(ctx context.Context, timeout time.Duration, command string) ([]byte, error) {
+	res, err := util.LocalExecContext(ctx, command, util.ExecOptions{Timeout: timeout})
+	return []byte(res.Combined), unwrapCommandError(err)
+}
+
+// localStream runs the given command on this machine, writing its stdout and stderr to the given writers
+func localStream(ctx context.Context, timeout time.Duration, command string, stdout io.Writer, stderr io.Writer) error {
+	_, err := util.LocalExecContext(ctx, command, util.ExecOptions{Timeout: timeout, Stdout: stdout, Stderr: stderr})
+	return unwrapCommandError(err)
+}
+
+// unwrapCommandError gives the underlying error of a failed local command, as the callers add the
+// output of the command themselves, just as they do for commands run over ssh
+func unwrapCommandError(err error) error {
+	if ce, ok := err.(util.CommandError); ok {
+		return ce.Err
+	}
+	return err
+}
@@ -18,6 +18,10 @@
 
 package ssh
 
+import (
+	log "github.com/sirupsen/logrus"
+)
+
 // Node represents the interface which all nodes must follow.
 type Node interface {
 	GetID() string
@@ -28,3 +32,13 @@ type Node interface {
 	GetTestNetID() string
 	GetNodeName() string
 }
+
+// LogFields gets the fields which identify the given node in log entries
+func LogFields(node Node) log.Fields {
+	return log.Fields{
+		"build":  node.GetTestNetID(),
+		"server": node.GetServerID(),
+		"node":   node.GetAbsoluteNumber(),
+		"name":   node.GetNodeName(),
+	}
+}
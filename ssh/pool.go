@@ -0,0 +1,339 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package ssh
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/util"
+	"golang.org/x/crypto/ssh"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PoolStats are the metrics of the pool of ssh connections to a server
+type PoolStats struct {
+	// Host is the server the connections are to
+	Host string `json:"host"`
+	// Connections is the number of open connections
+	Connections int `json:"connections"`
+	// Sessions is the number of sessions in use
+	Sessions int `json:"sessions"`
+	// Peak is the highest number of connections which were open at once
+	Peak int `json:"peak"`
+	// Opened is the number of connections opened over the life of the pool
+	Opened uint64 `json:"opened"`
+	// Closed is the number of connections closed over the life of the pool
+	Closed uint64 `json:"closed"`
+	// Failed is the number of connections which could not be opened
+	Failed uint64 `json:"failed"`
+	// MaxSessions is the number of sessions which are opened on a connection before
+	// another connection is opened
+	MaxSessions int `json:"maxSessions"`
+}
+
+// PoolStater is implemented by the clients which keep a pool of connections
+type PoolStater interface {
+	// PoolStats gets the metrics of the pool of connections
+	PoolStats() PoolStats
+}
+
+type conn struct {
+	client   *ssh.Client
+	sessions int
+	// limit is the number of sessions the server allows on this connection
+	limit    int
+	lastUsed time.Time
+}
+
+// pool multiplexes sessions over the connections to a server, opening up to sshMaxSessions
+// sessions on a connection before another one is opened. Connections are opened as they are needed,
+// up to sshPoolMax of them, and closed once they have been idle for sshPoolIdleTimeout, down to sshPoolMin.
+type pool struct {
+	host        string
+	creds       Credentials
+	min         int
+	max         int
+	idleTimeout time.Duration
+	// limit is the number of sessions opened on a new connection, it is lowered when the server
+	// rejects a session for going over its MaxSessions
+	limit int
+
+	mux     sync.Mutex
+	cond    *sync.Cond
+	conns   []*conn
+	dialing int
+	waiting int
+	stats   PoolStats
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func newPool(host string, creds Credentials) (*pool, error) {
+	p := &pool{
+		host:        host,
+		creds:       creds,
+		min:         conf.SSHPoolMin,
+		max:         conf.SSHPoolMax,
+		idleTimeout: time.Duration(conf.SSHPoolIdleTimeout) * time.Second,
+		limit:       conf.SSHMaxSessions,
+		stats:       PoolStats{Host: host},
+		stop:        make(chan struct{}),
+	}
+	p.cond = sync.NewCond(&p.mux)
+	if p.max < 1 {
+		p.max = 1
+	}
+	if p.limit < 1 {
+		p.limit = 1
+	}
+	if p.min > p.max {
+		p.min = p.max
+	}
+	for i := 0; i < p.min; i++ {
+		client, err := p.dial()
+		if err != nil {
+			p.close()
+			return nil, util.LogError(err)
+		}
+		p.add(client)
+	}
+	if p.idleTimeout > 0 {
+		go p.reap()
+	}
+	return p, nil
+}
+
+func (p *pool) dial() (*ssh.Client, error) {
+	client, err := sshConnect(p.host, p.creds)
+	for err != nil && (strings.Contains(err.Error(), "connection reset by peer") || strings.Contains(err.Error(), "EOF")) {
+		log.WithFields(log.Fields{"error": err}).Error("error connecting to remote host,retrying once")
+		time.Sleep(50 * time.Millisecond)
+		client, err = sshConnect(p.host, p.creds)
+	}
+	if err == nil && client == nil {
+		err = fmt.Errorf("client is nil")
+	}
+	return client, err
+}
+
+// add adds a newly opened connection to the pool, p.mux must be held
+func (p *pool) add(client *ssh.Client) *conn {
+	c := &conn{client: client, limit: p.limit, lastUsed: time.Now()}
+	p.conns = append(p.conns, c)
+	p.stats.Opened++
+	if len(p.conns) > p.stats.Peak {
+		p.stats.Peak = len(p.conns)
+	}
+	return c
+}
+
+// remove closes a connection and removes it from the pool, p.mux must be held
+func (p *pool) remove(c *conn) {
+	for i := range p.conns {
+		if p.conns[i] == c {
+			p.conns = append(p.conns[:i], p.conns[i+1:]...)
+			p.stats.Closed++
+			c.client.Close()
+			return
+		}
+	}
+}
+
+// pick chooses the connection for a new session, p.mux must be held. The busiest connection
+// which has room for another session is preferred, so that the others can go idle. If every
+// connection is full, nil is returned.
+func (p *pool) pick() *conn {
+	var out *conn
+	for _, c := range p.conns {
+		if c.sessions < c.limit && (out == nil || c.sessions > out.sessions) {
+			out = c
+		}
+	}
+	return out
+}
+
+// connection gets a connection for a new session and reserves the session on it. If every
+// connection is full, another one is opened if there is room in the pool for it and the
+// connections already being opened will not be enough, otherwise it waits for a session to
+// be given back.
+func (p *pool) connection() (*conn, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.waiting++
+	defer func() { p.waiting-- }()
+	dialFailed := false
+	for {
+		c := p.pick()
+		if c == nil && !dialFailed && len(p.conns)+p.dialing < p.max && p.waiting > p.dialing*p.limit {
+			p.dialing++
+			p.mux.Unlock()
+			client, err := p.dial()
+			p.mux.Lock()
+			p.dialing--
+			p.cond.Broadcast()
+			if err != nil {
+				p.stats.Failed++
+				if len(p.conns) == 0 && p.dialing == 0 {
+					return nil, util.LogError(err)
+				}
+				log.WithFields(log.Fields{"host": p.host, "error": err}).Warn(
+					"could not open another ssh connection, waiting for a session on an existing one")
+				dialFailed = true
+				continue
+			}
+			c = p.add(client)
+		}
+		if c == nil {
+			p.cond.Wait()
+			continue
+		}
+		c.sessions++
+		p.stats.Sessions++
+		return c, nil
+	}
+}
+
+// release gives back the session reserved on the given connection
+func (p *pool) release(c *conn) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	c.sessions--
+	p.stats.Sessions--
+	c.lastUsed = time.Now()
+	p.cond.Broadcast()
+}
+
+// get opens a new session on one of the connections of the pool. The returned function
+// must be called once the session is closed.
+func (p *pool) get() (*ssh.Session, func(), error) {
+	var session *ssh.Session
+	done, err := p.open(func(client *ssh.Client) (err error) {
+		session, err = client.NewSession()
+		return err
+	})
+	return session, done, err
+}
+
+// tunnel opens a connection to the given address from the server, through one of the connections of
+// the pool, on which it takes up a session. The returned function must be called once it is closed.
+func (p *pool) tunnel(network string, address string) (net.Conn, func(), error) {
+	var out net.Conn
+	done, err := p.open(func(client *ssh.Client) (err error) {
+		out, err = client.Dial(network, address)
+		return err
+	})
+	return out, done, err
+}
+
+// open opens a channel with fn on one of the connections of the pool. The returned function
+// must be called once the channel is closed.
+func (p *pool) open(fn func(*ssh.Client) error) (func(), error) {
+	var err error
+	for i := 0; i <= 2*p.max; i++ {
+		var c *conn
+		c, err = p.connection()
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		err = fn(c.client)
+		if err == nil {
+			return func() { p.release(c) }, nil
+		}
+		rejection, rejected := err.(*ssh.OpenChannelError)
+		if rejected && rejection.Reason == ssh.ConnectionFailed {
+			p.release(c) //the server could not reach the address, the connection is fine
+			return nil, err
+		}
+		p.mux.Lock()
+		c.sessions--
+		p.stats.Sessions--
+		if rejected {
+			p.lower(c)
+		} else {
+			log.WithFields(log.Fields{"host": p.host, "error": err}).Warn("dropping a broken ssh connection")
+			p.remove(c)
+		}
+		p.cond.Broadcast()
+		p.mux.Unlock()
+	}
+	return nil, util.LogError(err)
+}
+
+// lower lowers the session limit to the number of sessions open on the given connection, as the
+// server has rejected another one on it, most likely because of its MaxSessions. p.mux must be held.
+func (p *pool) lower(c *conn) {
+	c.limit = c.sessions
+	if c.limit < 1 {
+		c.limit = 1
+	}
+	if c.limit < p.limit {
+		log.WithFields(log.Fields{"host": p.host, "sessions": c.limit}).Info(
+			"the server limits the sessions per ssh connection, lowering the limit")
+		p.limit = c.limit
+	}
+}
+
+// reap periodically closes the connections which have been idle for longer than the idle timeout
+func (p *pool) reap() {
+	ticker := time.NewTicker(p.idleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+		p.mux.Lock()
+		for i := len(p.conns) - 1; i >= 0 && len(p.conns) > p.min; i-- {
+			c := p.conns[i]
+			if c.sessions == 0 && time.Since(c.lastUsed) > p.idleTimeout {
+				p.remove(c)
+			}
+		}
+		stats := p.statsLocked()
+		p.mux.Unlock()
+		log.WithFields(log.Fields{"stats": stats}).Trace("reaped the idle ssh connections")
+	}
+}
+
+func (p *pool) statsLocked() PoolStats {
+	out := p.stats
+	out.Connections = len(p.conns)
+	out.MaxSessions = p.limit
+	return out
+}
+
+// currentStats gets the current metrics of the pool
+func (p *pool) currentStats() PoolStats {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.statsLocked()
+}
+
+// close stops the reaper and closes all of the connections
+func (p *pool) close() {
+	p.once.Do(func() { close(p.stop) })
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	for len(p.conns) > 0 {
+		p.remove(p.conns[0])
+	}
+}
@@ -28,6 +28,8 @@ import (
 type Session struct {
 	sess *ssh.Session
 	sem  *semaphore.Weighted
+	// done is called on close to give the session back to the pool it came from
+	done func()
 }
 
 // NewSession creates a new session from a native library ssh session and a semaphore
@@ -44,4 +46,7 @@ func (session Session) Get() *ssh.Session {
 func (session Session) Close() {
 	session.sem.Release(1)
 	session.sess.Close()
+	if session.done != nil {
+		session.done()
+	}
 }
@@ -0,0 +1,171 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ssh
+
+import (
+	"fmt"
+	"github.com/creack/pty"
+	"github.com/whiteblock/genesis/util"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// ShellCommand is the command run inside of a node to open an interactive shell. It prefers bash,
+// but falls back to sh for images which do not include it.
+const ShellCommand = "sh -c 'command -v bash > /dev/null && exec bash || exec sh'"
+
+// Shell is an interactive command running behind a virtual terminal. Reads give the output
+// of the terminal, and writes are sent to it as input.
+type Shell interface {
+	io.ReadWriteCloser
+
+	// Resize changes the dimensions of the virtual terminal
+	Resize(cols int, rows int) error
+
+	// Wait waits for the command to exit
+	Wait() error
+}
+
+type remoteShell struct {
+	session *Session
+	stdin   io.WriteCloser
+	stdout  io.Reader
+	once    *sync.Once
+}
+
+// Read reads the output of the terminal
+func (shell remoteShell) Read(p []byte) (int, error) {
+	return shell.stdout.Read(p)
+}
+
+// Write writes input to the terminal
+func (shell remoteShell) Write(p []byte) (int, error) {
+	return shell.stdin.Write(p)
+}
+
+// Resize changes the dimensions of the virtual terminal
+func (shell remoteShell) Resize(cols int, rows int) error {
+	return shell.session.Get().WindowChange(rows, cols)
+}
+
+// Wait waits for the command to exit
+func (shell remoteShell) Wait() error {
+	return shell.session.Get().Wait()
+}
+
+// Close ends the session, killing the command if it is still running
+func (shell remoteShell) Close() error {
+	shell.once.Do(shell.session.Close)
+	return nil
+}
+
+// newRemoteShell starts the given command behind a virtual terminal in the given session
+func newRemoteShell(session *Session, command string, cols int, rows int) (Shell, error) {
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	shell := remoteShell{session: session, once: &sync.Once{}}
+	err := session.Get().RequestPty("xterm", rows, cols, modes)
+	if err != nil {
+		shell.Close()
+		return nil, util.LogError(err)
+	}
+	shell.stdin, err = session.Get().StdinPipe()
+	if err != nil {
+		shell.Close()
+		return nil, util.LogError(err)
+	}
+	shell.stdout, err = session.Get().StdoutPipe()
+	if err != nil {
+		shell.Close()
+		return nil, util.LogError(err)
+	}
+	err = session.Get().Start(command)
+	if err != nil {
+		shell.Close()
+		return nil, util.LogError(err)
+	}
+	return shell, nil
+}
+
+type localShell struct {
+	cmd  *exec.Cmd
+	tty  *os.File
+	once *sync.Once
+}
+
+// Read reads the output of the terminal
+func (shell localShell) Read(p []byte) (int, error) {
+	return shell.tty.Read(p)
+}
+
+// Write writes input to the terminal
+func (shell localShell) Write(p []byte) (int, error) {
+	return shell.tty.Write(p)
+}
+
+// Resize changes the dimensions of the virtual terminal
+func (shell localShell) Resize(cols int, rows int) error {
+	return pty.Setsize(shell.tty, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// Wait waits for the command to exit
+func (shell localShell) Wait() error {
+	return shell.cmd.Wait()
+}
+
+// Close kills the command if it is still running, and releases the terminal
+func (shell localShell) Close() error {
+	shell.once.Do(func() {
+		shell.cmd.Process.Kill()
+		shell.tty.Close()
+	})
+	return nil
+}
+
+// NewLocalShell starts the given command on this machine behind a virtual terminal with
+// the given dimensions
+func NewLocalShell(command string, cols int, rows int) (Shell, error) {
+	cmd := exec.Command(util.Shell(), "-c", command)
+	tty, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return localShell{cmd: cmd, tty: tty, once: &sync.Once{}}, nil
+}
+
+// Shell opens an interactive shell inside of the given node, behind a virtual terminal with
+// the given dimensions. The shell holds one of the connections to the server until it is closed.
+func (sshClient *client) Shell(node Node, cols int, rows int) (Shell, error) {
+	command := fmt.Sprintf("%s exec -it %s %s", sshClient.runtime.CLI, node.GetNodeName(), ShellCommand)
+	sshClient.nodeLogger(node).WithField("command", command).Info("opening an interactive shell")
+	if sshClient.local {
+		return NewLocalShell(command, cols, rows)
+	}
+	session, err := sshClient.getSession()
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return newRemoteShell(session, command, cols, rows)
+}
@@ -0,0 +1,141 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package ssh
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/util"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// rsyncArgs gives the arguments of rsync which push src to dest on the server over ssh, with the
+// key at the given location. The contents of a directory are synced into dest, rather than the directory itself,
+// and the parent directories of dest are created as needed.
+func (sshClient *client) rsyncArgs(src string, dest string, key string, dir bool) []string {
+	parent := filepath.Dir(dest)
+	if dir {
+		src = strings.TrimSuffix(src, "/") + "/"
+		parent = dest
+	}
+	remoteShell := fmt.Sprintf("ssh -p %d -i %s -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null "+
+		"-o BatchMode=yes", sshClient.creds.port(), util.ShellQuote(key))
+	return []string{"-az", "--partial", "-e", remoteShell,
+		"--rsync-path", fmt.Sprintf("mkdir -p %s && rsync", util.ShellQuote(parent)), src,
+		fmt.Sprintf("%s@%s:%s", sshClient.creds.user(), sshClient.host, dest)}
+}
+
+// rsync pushes src to dest on the server with rsync, which only sends the parts of the files which differ
+func (sshClient *client) rsync(src string, dest string, dir bool) error {
+	key := sshClient.creds.keyLocation()
+	if len(sshClient.creds.PrivateKey) > 0 {
+		tmp, err := ioutil.TempFile("", "genesis-key")
+		if err != nil {
+			return util.LogError(err)
+		}
+		defer os.Remove(tmp.Name())
+		_, err = tmp.WriteString(sshClient.creds.PrivateKey)
+		tmp.Close()
+		if err != nil {
+			return util.LogError(err)
+		}
+		key = tmp.Name()
+	}
+	args := sshClient.rsyncArgs(src, dest, key, dir)
+	sshClient.sem.Acquire(context.TODO(), 1)
+	out, err := exec.Command("rsync", args...).CombinedOutput()
+	sshClient.sem.Release(1)
+	if err != nil {
+		return util.CommandError{Command: "rsync " + strings.Join(args, " "), Output: string(out), Err: err}
+	}
+	return nil
+}
+
+// scpDir copies the directory at src to dest on the server, in a gzipped tarball which is unpacked there
+func (sshClient *client) scpDir(src string, dest string) error {
+	names := []string{}
+	srcs := []string{}
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		name, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		srcs = append(srcs, path)
+		return nil
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+	tmp, err := ioutil.TempFile("", "genesis-sync")
+	if err != nil {
+		return util.LogError(err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	err = util.WriteTarGz(tmp.Name(), names, srcs)
+	if err != nil {
+		return util.LogError(err)
+	}
+	archive := strings.TrimSuffix(dest, "/") + ".tar.gz"
+	err = sshClient.Scp(tmp.Name(), archive)
+	if err != nil {
+		return util.LogError(err)
+	}
+	_, err = sshClient.Run(fmt.Sprintf("mkdir -p %s && tar -xzf %s -C %s && rm -f %s", util.ShellQuote(dest),
+		util.ShellQuote(archive), util.ShellQuote(dest), util.ShellQuote(archive)))
+	return util.LogError(err)
+}
+
+// Sync pushes the file or directory at src to dest on the server. When enableRsync is set, rsync is used,
+// so that only what differs from what is already at dest is sent, falling back to scp if rsync fails.
+func (sshClient *client) Sync(src string, dest string) error {
+	entry := sshClient.logger().WithFields(log.Fields{"src": src, "dst": dest})
+	src = state.GetBuildStateByServerID(sshClient.serverID).Workspace().Path(src)
+	info, err := os.Stat(src)
+	if err != nil {
+		return util.LogError(err)
+	}
+	if conf.EnableRsync && !sshClient.local {
+		entry.Info("syncing with rsync")
+		err = sshClient.rsync(src, dest, info.IsDir())
+		state.GetBuildStateByServerID(sshClient.serverID).RecordCopy(sshClient.serverID, src, dest, err)
+		if err == nil {
+			return nil
+		}
+		entry.WithFields(log.Fields{"error": err}).Warn("rsync failed, falling back to scp")
+	}
+	// scp does not create the directories dest is in
+	_, err = sshClient.Run("mkdir -p " + util.ShellQuote(filepath.Dir(strings.TrimSuffix(dest, "/"))))
+	if err != nil {
+		return util.LogError(err)
+	}
+	if info.IsDir() {
+		return sshClient.scpDir(src, dest)
+	}
+	return sshClient.Scp(src, dest)
+}
@@ -0,0 +1,63 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ssh
+
+import (
+	"context"
+	"github.com/whiteblock/genesis/util"
+	"net"
+	"sync"
+	"time"
+)
+
+// dialTimeout is how long connecting to an address from this machine may take
+var dialTimeout = 10 * time.Second
+
+// tunnelConn is a connection tunneled through ssh, which gives back its session once closed
+type tunnelConn struct {
+	net.Conn
+	once *sync.Once
+	done func()
+}
+
+// Close closes the connection and gives back its session
+func (tc tunnelConn) Close() error {
+	err := tc.Conn.Close()
+	tc.once.Do(tc.done)
+	return err
+}
+
+// Dial opens a connection to the given address from the server, so that the services of the nodes
+// can be reached from genesis. Over ssh, the connection is tunneled through one of the connections to
+// the server, and it takes up one of its sessions until it is closed.
+func (sshClient *client) Dial(network string, address string) (net.Conn, error) {
+	if sshClient.local {
+		return net.DialTimeout(network, address, dialTimeout)
+	}
+	sshClient.sem.Acquire(context.TODO(), 1)
+	conn, done, err := sshClient.pool.tunnel(network, address)
+	if err != nil {
+		sshClient.sem.Release(1)
+		return nil, util.LogError(err)
+	}
+	return tunnelConn{Conn: conn, once: &sync.Once{}, done: func() {
+		done()
+		sshClient.sem.Release(1)
+	}}, nil
+}
@@ -75,6 +75,15 @@ func ForceUnlockServers(serverIDs []int) {
 	cleanBuildStates(serverIDs)
 }
 
+// GetAllBuildStates gets all of the build states which are currently being tracked
+func GetAllBuildStates() []*BuildState {
+	mux.RLock()
+	defer mux.RUnlock()
+	out := make([]*BuildState, len(buildStates))
+	copy(out, buildStates)
+	return out
+}
+
 // GetBuildStateByServerID gets the current build state on a server. DEPRECATED, use
 // GetBuildStateByID instead.
 func GetBuildStateByServerID(serverID int) *BuildState {
@@ -20,24 +20,31 @@
 package state
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
-	"io/ioutil"
-	"os"
+	"github.com/whiteblock/genesis/secrets"
+	"github.com/whiteblock/genesis/tracing"
+	"github.com/whiteblock/genesis/util"
+	"github.com/whiteblock/genesis/webhook"
+	"github.com/whiteblock/genesis/workspace"
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 //This code is full of potential race conditions but these race conditons are extremely rare
 
 // CustomError is a custom wrapper for a go error, which
-// has What containing error.Error()
+// has What containing error.Error(), and Failures containing the breakdown of
+// every error reported during the build
 type CustomError struct {
-	What string `json:"what"`
-	err  error
+	What     string    `json:"what"`
+	Failures []Failure `json:"failures,omitempty"`
+	err      error
 }
 
 // BuildState packages the build state nicely into an object
@@ -54,16 +61,32 @@ type BuildState struct {
 	breakpoints       []float64              //must be in ascending order
 	ExternExtras      map[string]interface{} //will be exported
 	Extras            map[string]interface{}
-	files             []string
+	workspace         *workspace.Workspace
 	defers            []func() //Array of functions to run at the end of the build
 	errorCleanupFuncs []func()
 	asyncWaiter       *sync.WaitGroup
+	stagingDirs       map[int]string
+	staged            map[int]*staging
+	transfers         map[*Transfer]bool
+	nodeProgress      map[string]*NodeProgress
+	timeouts          util.Timeouts
+	stageSeq          uint64
+	stageCtx          context.Context
+	stageTimer        *time.Timer
 
 	Servers []int
 	BuildID string
 
 	BuildError CustomError
 	BuildStage string
+	Timings    []StageTiming
+	// Checkpoints contains the nodes each checkpointed step has been completed on
+	Checkpoints map[string]map[string]bool
+	// Transcript contains the commands which have been run for the build, including those of
+	// earlier builds of the testnet
+	Transcript []TranscriptEntry
+	// ExpectedFiles contains the files which have been copied into each of the nodes, by node name and path
+	ExpectedFiles map[string]map[string]ExpectedFile
 
 	DeployProgress uint64
 	DeployTotal    uint64
@@ -93,7 +116,6 @@ func NewBuildState(servers []int, buildID string) *BuildState {
 	out.breakpoints = []float64{}
 	out.ExternExtras = map[string]interface{}{}
 	out.Extras = map[string]interface{}{}
-	out.files = []string{}
 	out.defers = []func(){}
 	out.errorCleanupFuncs = []func(){}
 
@@ -101,6 +123,11 @@ func NewBuildState(servers []int, buildID string) *BuildState {
 	out.BuildID = buildID
 	out.BuildError = CustomError{What: "", err: nil}
 	out.BuildStage = ""
+	out.Timings = []StageTiming{}
+	out.Checkpoints = map[string]map[string]bool{}
+	out.Transcript = []TranscriptEntry{}
+	out.ExpectedFiles = map[string]map[string]ExpectedFile{}
+	out.nodeProgress = map[string]*NodeProgress{}
 
 	out.DeployProgress = 0
 	out.DeployTotal = 0
@@ -109,10 +136,12 @@ func NewBuildState(servers []int, buildID string) *BuildState {
 	out.SideCarProgress = 0
 	out.SideCarTotal = 1
 
-	err := os.MkdirAll("/tmp/"+buildID, 0755)
+	var err error
+	out.workspace, err = workspace.New(buildID)
 	if err != nil {
-		log.WithFields(log.Fields{"build": out.BuildID, "error": err}).Panic("couldn't create the tmp folder")
+		log.WithFields(log.Fields{"build": out.BuildID, "error": err}).Panic("couldn't create the workspace")
 	}
+	tracing.StartBuild(buildID)
 
 	return out
 }
@@ -136,6 +165,11 @@ func RestoreBuildState(buildID string) (*BuildState, error) {
 	return out, nil
 }
 
+// Logger gets a logger which annotates each entry with the build id
+func (bs *BuildState) Logger() *log.Entry {
+	return log.WithFields(log.Fields{"build": bs.BuildID})
+}
+
 // Async Set a function to be executed at some point during the build.
 // All these functions must complete before the build is considered finished.
 func (bs *BuildState) Async(fn func()) {
@@ -228,11 +262,19 @@ func (bs *BuildState) DoneBuilding() {
 
 	bs.mutex.Lock()
 	bs.BuildStage = "Finished"
+	bs.finishStage()
+	bs.stopDeadline()
 	bs.mutex.Unlock()
 	bs.errorCleanupFuncs = []func(){}
 	atomic.StoreInt32(&bs.building, 0)
 	atomic.StoreInt32(&bs.stopping, 0)
-	os.RemoveAll("/tmp/" + bs.BuildID)
+	bs.workspace.Remove()
+	tracing.FinishBuild(bs.BuildID, bs.GetError())
+	if bs.ErrorFree() {
+		webhook.Emit(webhook.BuildCompleted, bs.BuildID, nil)
+	} else {
+		webhook.Emit(webhook.BuildFailed, bs.BuildID, map[string]interface{}{"error": bs.BuildError})
+	}
 	log.WithFields(log.Fields{"build": bs.BuildID}).Debug("running the defered functions")
 	for _, fn := range bs.defers {
 		go fn() //No need to wait to confirm completion
@@ -247,16 +289,32 @@ func (bs *BuildState) Done() bool {
 // ReportError stores the given error to be passed onto any
 // who query the build status.
 func (bs *BuildState) ReportError(err error) {
+	bs.mutex.RLock()
+	stage := bs.BuildStage
+	bs.mutex.RUnlock()
+
 	bs.errMutex.Lock()
 	defer bs.errMutex.Unlock()
-	bs.BuildError = CustomError{What: err.Error(), err: err}
+	reported := err
+	if timeout, ok := bs.BuildError.err.(StageTimeoutError); ok {
+		reported = timeout // the commands killed by the timeout fail after it, which must not hide it
+	}
+	bs.BuildError = CustomError{What: reported.Error(), err: reported,
+		Failures: mergeFailures(bs.BuildError.Failures, extractFailures(err, stage))}
 
 	_, file, line, ok := runtime.Caller(1)
 	if !ok {
 		file = "???"
 		line = 0
 	}
-	log.WithFields(log.Fields{"build": bs.BuildID, "file": file, "line": line, "error": err}).Error("an error was reported")
+	bs.Logger().WithFields(log.Fields{"file": file, "line": line, "error": err}).Error("an error was reported")
+}
+
+// ClearError clears the reported error, so that a failed part of the build can be retried
+func (bs *BuildState) ClearError() {
+	bs.errMutex.Lock()
+	defer bs.errMutex.Unlock()
+	bs.BuildError = CustomError{What: "", err: nil}
 }
 
 // Stop checks if the stop signal has been sent. If bs returns true,
@@ -405,11 +463,16 @@ func (bs *BuildState) GetExtras() map[string]interface{} {
 // io library as bs one provides automatic file cleanup and separation of files among
 // different builds.
 func (bs *BuildState) Write(file string, data string) error {
-	bs.mutex.Lock()
-	bs.files = append(bs.files, file)
-	bs.mutex.Unlock()
-	filepath := "/tmp/" + bs.BuildID + "/" + file
-	return ioutil.WriteFile(filepath, []byte(data), 0664)
+	if secrets.Enabled() {
+		return bs.workspace.Write(file, []byte(data), 0600)
+	}
+	return bs.workspace.Write(file, []byte(data), 0664)
+}
+
+// Workspace gets the working directory of the build on the machine genesis is on, in which its files
+// are written before they are sent to the servers
+func (bs *BuildState) Workspace() *workspace.Workspace {
+	return bs.workspace
 }
 
 // Defer adds a function to be executed asynchronously after the build is completed.
@@ -532,8 +595,14 @@ func (bs *BuildState) GetProgress() float64 {
 func (bs *BuildState) SetBuildStage(stage string) {
 	bs.mutex.Lock()
 	defer bs.mutex.Unlock()
+	if bs.BuildStage == stage {
+		return
+	}
 	bs.BuildStage = stage
-
+	bs.startStage(stage)
+	bs.startDeadline(stage)
+	tracing.SetStage(bs.BuildID, stage)
+	webhook.Emit(webhook.StageChanged, bs.BuildID, map[string]interface{}{"stage": stage})
 }
 
 // Reset sets the build state back the beginning. Used for when
@@ -545,34 +614,44 @@ func (bs *BuildState) Reset() {
 
 	bs.breakpoints = []float64{}
 
-	bs.files = []string{}
 	bs.defers = []func(){}
+	bs.staged = map[int]*staging{}
 
 	bs.BuildError = CustomError{What: "", err: nil}
 	bs.BuildStage = ""
+	bs.Timings = []StageTiming{}
+	bs.Checkpoints = map[string]map[string]bool{}
+	bs.nodeProgress = map[string]*NodeProgress{}
+	bs.stopDeadline()
 
 	atomic.StoreUint64(&bs.DeployProgress, 0)
 	atomic.StoreUint64(&bs.DeployTotal, 1)
 	atomic.StoreUint64(&bs.BuildProgress, 0)
 	atomic.StoreUint64(&bs.BuildTotal, 1)
 
-	err := os.MkdirAll("/tmp/"+bs.BuildID, 0755)
+	var err error
+	bs.workspace, err = workspace.New(bs.BuildID)
 	if err != nil {
-		log.WithFields(log.Fields{"build": bs.BuildID, "error": err}).Panic("couldn't create the tmp folder")
+		log.WithFields(log.Fields{"build": bs.BuildID, "error": err}).Panic("couldn't create the workspace")
 	}
+	tracing.StartBuild(bs.BuildID)
 	log.WithFields(log.Fields{"build": bs.BuildID}).Info("build has been reset!")
 }
 
 //Marshal turns the BuildState into json representing the current progress of the build
 func (bs *BuildState) Marshal() string {
+	timings := bs.GetTimings()
+	transfers := bs.GetTransfers()
+	nodes := bs.GetNodeProgress()
 	bs.mutex.RLock()
 	defer bs.mutex.RUnlock()
-	if bs.ErrorFree() { //error should be null if there is not an error
-		return fmt.Sprintf("{\"progress\":%f,\"error\":null,\"stage\":\"%s\",\"frozen\":%v}", bs.GetProgress(), bs.BuildStage, bs.IsFrozen())
+	var buildErr interface{} //error should be null if there is not an error
+	if !bs.ErrorFree() {
+		buildErr = bs.BuildError //otherwise give the error as an object
 	}
-	//otherwise give the error as an object
-	out, _ := json.Marshal(
-		map[string]interface{}{"progress": bs.GetProgress(), "error": bs.BuildError, "stage": bs.BuildStage, "frozen": bs.IsFrozen()})
+	out, _ := json.Marshal(map[string]interface{}{"progress": bs.GetProgress(), "error": buildErr,
+		"stage": bs.BuildStage, "frozen": bs.IsFrozen(), "timings": timings, "transfers": transfers,
+		"nodes": nodes})
 	return string(out)
 }
 
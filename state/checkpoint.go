@@ -0,0 +1,46 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+// MarkDone records that the given step of the build has been completed for key, which is
+// usually the name of a node. Checkpoints are kept when the build is retried, and cleared when
+// the build state is reset.
+func (bs *BuildState) MarkDone(step string, key string) {
+	bs.extraMux.Lock()
+	defer bs.extraMux.Unlock()
+	if _, ok := bs.Checkpoints[step]; !ok {
+		bs.Checkpoints[step] = map[string]bool{}
+	}
+	bs.Checkpoints[step][key] = true
+}
+
+// IsDone checks whether the given step of the build has already been completed for key
+func (bs *BuildState) IsDone(step string, key string) bool {
+	bs.extraMux.RLock()
+	defer bs.extraMux.RUnlock()
+	return bs.Checkpoints[step][key]
+}
+
+// Undo forgets that the given step of the build was completed for key, so that it is run again
+// if the build is retried
+func (bs *BuildState) Undo(step string, key string) {
+	bs.extraMux.Lock()
+	defer bs.extraMux.Unlock()
+	delete(bs.Checkpoints[step], key)
+}
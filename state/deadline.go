@@ -0,0 +1,160 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package state
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/util"
+	"sort"
+	"strings"
+	"time"
+)
+
+// slowestNodesReported is the most nodes named by a StageTimeoutError
+const slowestNodesReported = 3
+
+// StageTimeoutError is the error a build fails with when one of its stages takes longer than its timeout
+type StageTimeoutError struct {
+	Stage   string
+	Timeout time.Duration
+	// Nodes are the names of the slowest nodes during the stage, slowest first
+	Nodes []string
+}
+
+func (err StageTimeoutError) Error() string {
+	out := fmt.Sprintf("stage \"%s\" timed out after %s", err.Stage, err.Timeout)
+	if len(err.Nodes) > 0 {
+		out += ", the slowest nodes were " + strings.Join(err.Nodes, ", ")
+	}
+	return out
+}
+
+// SetTimeouts sets the timeouts of the stages of the build and of the commands run for it, which take
+// effect from the next stage on
+func (bs *BuildState) SetTimeouts(timeouts util.Timeouts) error {
+	err := timeouts.Validate()
+	if err != nil {
+		return err
+	}
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+	bs.timeouts = timeouts
+	return nil
+}
+
+// CommandContext gets the context the commands of the build are to be run in, which is cancelled if the
+// current stage times out, along with how long each command may take, 0 being no limit
+func (bs *BuildState) CommandContext() (context.Context, time.Duration) {
+	if bs == nil {
+		timeout, _ := util.Timeouts{}.CommandTimeout()
+		return context.Background(), timeout
+	}
+	bs.mutex.RLock()
+	defer bs.mutex.RUnlock()
+	timeout, err := bs.timeouts.CommandTimeout()
+	if err != nil {
+		log.WithFields(log.Fields{"build": bs.BuildID, "error": err}).Warn("invalid command timeout")
+	}
+	if bs.stageCtx == nil {
+		return context.Background(), timeout
+	}
+	return bs.stageCtx, timeout
+}
+
+// startDeadline starts the deadline of the given stage, replacing that of the previous stage. The caller
+// must hold bs.mutex
+func (bs *BuildState) startDeadline(stage string) {
+	bs.stopDeadline()
+	bs.stageSeq++
+
+	timeout, err := bs.timeouts.StageTimeout(stage)
+	if err != nil {
+		log.WithFields(log.Fields{"build": bs.BuildID, "stage": stage, "error": err}).Warn("invalid stage timeout")
+	}
+	if timeout <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	bs.stageCtx = ctx
+	seq := bs.stageSeq
+	bs.stageTimer = time.AfterFunc(timeout, func() {
+		bs.mutex.RLock()
+		current := bs.stageSeq == seq
+		nodes := bs.slowestNodes(slowestNodesReported)
+		bs.mutex.RUnlock()
+		if !current || bs.Done() {
+			return
+		}
+		bs.ReportError(StageTimeoutError{Stage: stage, Timeout: timeout, Nodes: nodes})
+		cancel() // kills the commands which are stuck, so that the build can fail
+	})
+}
+
+// stopDeadline stops the deadline of the current stage, without cancelling the commands which
+// are still running in it. The caller must hold bs.mutex
+func (bs *BuildState) stopDeadline() {
+	if bs.stageTimer != nil {
+		bs.stageTimer.Stop()
+		bs.stageTimer = nil
+	}
+	bs.stageCtx = nil
+}
+
+// slowestNodes gets the names of up to n of the slowest nodes during the current stage, starting with
+// the nodes which have been running a step for the longest, followed by those which have spent the most
+// time on steps. The caller must hold bs.mutex
+func (bs *BuildState) slowestNodes(n int) []string {
+	running := []*NodeProgress{}
+	for _, progress := range bs.nodeProgress {
+		if progress.Running {
+			running = append(running, progress)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool {
+		return running[i].Updated.Before(running[j].Updated)
+	})
+	out := []string{}
+	seen := map[string]bool{}
+	for _, progress := range running {
+		out = append(out, progress.Name)
+		seen[progress.Name] = true
+	}
+
+	if len(bs.Timings) > 0 {
+		spent := bs.Timings[len(bs.Timings)-1].Nodes
+		names := []string{}
+		for name := range spent {
+			if !seen[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if spent[names[i]] != spent[names[j]] {
+				return spent[names[i]] > spent[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		out = append(out, names...)
+	}
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
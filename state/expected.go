@@ -0,0 +1,119 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/whiteblock/genesis/secrets"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// maxExpectedContent is the largest file whose content is kept along with its checksum
+const maxExpectedContent = 64 * 1024
+
+// ExpectedFile is a file which was copied into a node during a build, as it was when it was copied
+type ExpectedFile struct {
+	// Path is where the file is in the node
+	Path string `json:"path"`
+	// Sha256 is the hex encoded sha256 checksum of the file
+	Sha256 string `json:"sha256"`
+	// Size is the size of the file in bytes
+	Size int `json:"size"`
+	// Content is the content of the file. It is only kept for small text files, and never
+	// when secrets are enabled
+	Content string `json:"content,omitempty"`
+	// Stage is the build stage during which the file was copied
+	Stage string `json:"stage"`
+	// Time is when the file was copied
+	Time time.Time `json:"time"`
+}
+
+// NewExpectedFile creates the ExpectedFile for the given data being copied to dest
+func NewExpectedFile(dest string, data []byte) ExpectedFile {
+	sum := sha256.Sum256(data)
+	out := ExpectedFile{Path: dest, Sha256: hex.EncodeToString(sum[:]), Size: len(data)}
+	if !secrets.Enabled() && len(data) <= maxExpectedContent && utf8.Valid(data) {
+		out.Content = string(data)
+	}
+	return out
+}
+
+// ExpectFiles creates the ExpectedFiles for the file or directory src of the build being copied to dest.
+// The files of a directory are expected to be at the same paths under dest.
+func (bs *BuildState) ExpectFiles(src string, dest string) ([]ExpectedFile, error) {
+	root := bs.workspace.Path(src)
+	out := []ExpectedFile{}
+	err := filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		out = append(out, NewExpectedFile(path.Join(dest, filepath.ToSlash(rel)), data))
+		return nil
+	})
+	return out, err
+}
+
+// RecordFiles records that the given files have been copied into the node with the given name,
+// replacing what was recorded for the same paths before
+func (bs *BuildState) RecordFiles(node string, files ...ExpectedFile) {
+	if bs == nil {
+		return
+	}
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+	if bs.ExpectedFiles == nil {
+		bs.ExpectedFiles = map[string]map[string]ExpectedFile{}
+	}
+	if _, ok := bs.ExpectedFiles[node]; !ok {
+		bs.ExpectedFiles[node] = map[string]ExpectedFile{}
+	}
+	for _, file := range files {
+		file.Stage = bs.BuildStage
+		file.Time = time.Now()
+		bs.ExpectedFiles[node][file.Path] = file
+	}
+}
+
+// GetExpectedFiles gets the files which have been copied into the node with the given name
+// by the builds of the testnet, sorted by path
+func (bs *BuildState) GetExpectedFiles(node string) []ExpectedFile {
+	bs.mutex.RLock()
+	defer bs.mutex.RUnlock()
+	out := []ExpectedFile{}
+	for _, file := range bs.ExpectedFiles[node] {
+		out = append(out, file)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
@@ -0,0 +1,92 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"github.com/whiteblock/genesis/util"
+)
+
+// Failure gives the details of one of the errors which caused a build to fail
+type Failure struct {
+	// Node is the absolute number of the node the failure occurred on, if it occurred on a node
+	Node *int `json:"node,omitempty"`
+	// Server is the id of the server the failure occurred on, if it is known
+	Server *int `json:"server,omitempty"`
+	// Stage is the build stage during which the failure was reported
+	Stage string `json:"stage"`
+	// Command is the command which failed, if the failure was from a command
+	Command string `json:"command,omitempty"`
+	// Output is the output of the failed command
+	Output string `json:"output,omitempty"`
+	// Message is the error message
+	Message string `json:"message"`
+	// Stack is the stack trace of the goroutine, if the failure was a panic
+	Stack string `json:"stack,omitempty"`
+}
+
+func (f Failure) same(other Failure) bool {
+	return f.Message == other.Message && f.Command == other.Command &&
+		((f.Node == nil && other.Node == nil) || (f.Node != nil && other.Node != nil && *f.Node == *other.Node)) &&
+		((f.Server == nil && other.Server == nil) || (f.Server != nil && other.Server != nil && *f.Server == *other.Server))
+}
+
+// extractFailures breaks down the given error into the individual failures it is made of
+func extractFailures(err error, stage string) []Failure {
+	switch e := err.(type) {
+	case util.MultiError:
+		out := []Failure{}
+		for _, sub := range e {
+			out = append(out, extractFailures(sub, stage)...)
+		}
+		return out
+	case util.NodeError:
+		node := e.Node
+		server := e.Server
+		out := extractFailures(e.Err, stage)
+		for i := range out {
+			out[i].Node = &node
+			out[i].Server = &server
+		}
+		return out
+	case util.CommandError:
+		return []Failure{{Stage: stage, Command: e.Command, Output: e.Output, Message: e.Err.Error()}}
+	case util.PanicError:
+		return []Failure{{Stage: stage, Message: e.Error(), Stack: e.Stack}}
+	}
+	return []Failure{{Stage: stage, Message: err.Error()}}
+}
+
+// mergeFailures adds the new failures onto the existing ones, skipping any which were already reported,
+// as the same error is often reported again as it makes its way up.
+func mergeFailures(existing []Failure, failures []Failure) []Failure {
+	out := append([]Failure{}, existing...)
+	for _, failure := range failures {
+		found := false
+		for _, other := range existing {
+			if other.same(failure) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, failure)
+		}
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/secrets"
+	"github.com/whiteblock/genesis/util"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// treePath checks that name is a relative path which stays within the directory it is written to,
+// and gives it in its clean form
+func treePath(name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." ||
+		strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file name \"%s\", must be a relative path within the directory", name)
+	}
+	return clean, nil
+}
+
+// WriteFiles writes a directory tree, given as a map of relative paths to their contents, into a new
+// uuid named directory of the build, and returns the name of that directory, which can be given to
+// the clients like the files created with Write. The tree is written in full before it is moved into place,
+// so the directory either has all of the files or does not exist. Like Write, the files are removed
+// when the build is done.
+func (bs *BuildState) WriteFiles(files map[string][]byte) (string, error) {
+	paths := map[string][]byte{}
+	for name, data := range files {
+		clean, err := treePath(name)
+		if err != nil {
+			return "", err
+		}
+		paths[clean] = data
+	}
+	dir, err := util.GetUUIDString()
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	dirMode, fileMode := os.FileMode(0755), os.FileMode(0664)
+	if secrets.Enabled() {
+		dirMode, fileMode = 0700, 0600
+	}
+	buildDir := bs.workspace.Dir()
+	tmp := filepath.Join(buildDir, "."+dir)
+	err = os.Mkdir(tmp, dirMode)
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	for name, data := range paths {
+		path := filepath.Join(tmp, name)
+		err = os.MkdirAll(filepath.Dir(path), dirMode)
+		if err == nil {
+			err = ioutil.WriteFile(path, data, fileMode)
+		}
+		if err != nil {
+			os.RemoveAll(tmp)
+			return "", util.LogError(err)
+		}
+	}
+	err = os.Rename(tmp, filepath.Join(buildDir, dir))
+	if err != nil {
+		os.RemoveAll(tmp)
+		return "", util.LogError(err)
+	}
+	err = bs.workspace.Track(dir)
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	return dir, nil
+}
+
+// WriteTemplates is WriteFiles, with each of the files being a text/template which is
+// first rendered with the given data
+func (bs *BuildState) WriteTemplates(files map[string]string, data interface{}) (string, error) {
+	rendered := map[string][]byte{}
+	for name, text := range files {
+		out, err := util.RenderTemplate(name, text, data)
+		if err != nil {
+			return "", util.LogError(err)
+		}
+		rendered[name] = out
+	}
+	return bs.WriteFiles(rendered)
+}
@@ -0,0 +1,106 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package state
+
+import (
+	"sort"
+	"time"
+)
+
+// NodeProgress is how far along the build is on a single node, so that the nodes which are lagging
+// behind or stuck can be told apart from the rest
+type NodeProgress struct {
+	// Node is the absolute number of the node
+	Node int    `json:"node"`
+	Name string `json:"name"`
+	// Stage is the stage of the build the last step on the node was started in
+	Stage string `json:"stage"`
+	// Step is the number of steps which have been completed on the node
+	Step uint64 `json:"step"`
+	// Behind is the number of steps the node is behind the node furthest along
+	Behind uint64 `json:"behind"`
+	// Percentage is the progress of the build, scaled down by how far behind the node is
+	Percentage float64 `json:"percentage"`
+	// Running is whether a step is currently being run on the node
+	Running bool `json:"running"`
+	// Failed is whether the last step run on the node failed
+	Failed bool `json:"failed"`
+	// Updated is when a step was last started or completed on the node
+	Updated time.Time `json:"updated"`
+}
+
+// StartNodeStep records that a step of the build has been started on the given node
+func (bs *BuildState) StartNodeStep(node int, name string) {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+	progress, ok := bs.nodeProgress[name]
+	if !ok {
+		progress = &NodeProgress{Node: node, Name: name}
+		bs.nodeProgress[name] = progress
+	}
+	progress.Stage = bs.BuildStage
+	progress.Running = true
+	progress.Updated = time.Now()
+}
+
+// FinishNodeStep records that the step which was running on the given node has been completed, with
+// err being the error it failed with, if any
+func (bs *BuildState) FinishNodeStep(name string, err error) {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+	progress, ok := bs.nodeProgress[name]
+	if !ok {
+		return
+	}
+	progress.Running = false
+	progress.Failed = err != nil
+	if err == nil {
+		progress.Step++
+	}
+	progress.Updated = time.Now()
+}
+
+// GetNodeProgress gets the progress of the build on each node it has run steps on, ordered by node
+func (bs *BuildState) GetNodeProgress() []NodeProgress {
+	total := bs.GetProgress()
+	bs.mutex.RLock()
+	defer bs.mutex.RUnlock()
+	out := make([]NodeProgress, 0, len(bs.nodeProgress))
+	var furthest uint64
+	for _, progress := range bs.nodeProgress {
+		if progress.Step > furthest {
+			furthest = progress.Step
+		}
+	}
+	for _, progress := range bs.nodeProgress {
+		np := *progress
+		np.Behind = furthest - np.Step
+		np.Percentage = total
+		if furthest > 0 {
+			np.Percentage = total * float64(np.Step) / float64(furthest)
+		}
+		out = append(out, np)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Node != out[j].Node {
+			return out[i].Node < out[j].Node
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
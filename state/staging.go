@@ -0,0 +1,73 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package state
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// staging is the staging directory of the build on a server
+type staging struct {
+	once sync.Once
+	err  error
+}
+
+// SetStagingDir sets the directory on the given server under which the files of the build are staged,
+// the empty string giving stagingDir
+func (bs *BuildState) SetStagingDir(server int, dir string) {
+	bs.extraMux.Lock()
+	defer bs.extraMux.Unlock()
+	if bs.stagingDirs == nil {
+		bs.stagingDirs = map[int]string{}
+	}
+	bs.stagingDirs[server] = dir
+}
+
+// StagingDir gets the directory on the given server where the files of the build are staged on their
+// way into the nodes
+func (bs *BuildState) StagingDir(server int) string {
+	bs.extraMux.RLock()
+	base := bs.stagingDirs[server]
+	bs.extraMux.RUnlock()
+	if len(base) == 0 {
+		base = conf.StagingDir
+	}
+	if len(base) == 0 {
+		base = "/tmp"
+	}
+	return filepath.Join(base, bs.BuildID)
+}
+
+// Stage gets the staging directory of the build on the given server, calling create to create it the first
+// time it is needed during the build. Later calls wait for the first to finish, and give its error.
+func (bs *BuildState) Stage(server int, create func(dir string) error) (string, error) {
+	dir := bs.StagingDir(server)
+	bs.extraMux.Lock()
+	if bs.staged == nil {
+		bs.staged = map[int]*staging{}
+	}
+	stage, ok := bs.staged[server]
+	if !ok {
+		stage = &staging{}
+		bs.staged[server] = stage
+	}
+	bs.extraMux.Unlock()
+	stage.once.Do(func() { stage.err = create(dir) })
+	return dir, stage.err
+}
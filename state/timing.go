@@ -0,0 +1,90 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"time"
+)
+
+// StageTiming records how long a stage of the build took, along with the
+// time spent on each node during that stage
+type StageTiming struct {
+	Stage    string     `json:"stage"`
+	Start    time.Time  `json:"start"`
+	End      *time.Time `json:"end,omitempty"` //nil while the stage is in progress
+	Duration float64    `json:"duration"`      //seconds
+	// Nodes is the cumulative time spent on each node in seconds, keyed by node name
+	Nodes map[string]float64 `json:"nodes,omitempty"`
+}
+
+func (st *StageTiming) finish() {
+	if st.End != nil {
+		return
+	}
+	end := time.Now()
+	st.End = &end
+	st.Duration = end.Sub(st.Start).Seconds()
+}
+
+// startStage ends the timing of the current stage and begins timing the given stage.
+// The caller must hold bs.mutex
+func (bs *BuildState) startStage(stage string) {
+	bs.finishStage()
+	bs.Timings = append(bs.Timings, StageTiming{Stage: stage, Start: time.Now(), Nodes: map[string]float64{}})
+}
+
+// finishStage ends the timing of the current stage. The caller must hold bs.mutex
+func (bs *BuildState) finishStage() {
+	if len(bs.Timings) > 0 {
+		bs.Timings[len(bs.Timings)-1].finish()
+	}
+}
+
+// RecordNodeStep adds the given duration to the time spent on the given node during the current stage
+func (bs *BuildState) RecordNodeStep(node string, duration time.Duration) {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+	if len(bs.Timings) == 0 {
+		return
+	}
+	current := &bs.Timings[len(bs.Timings)-1]
+	if current.Nodes == nil {
+		current.Nodes = map[string]float64{}
+	}
+	current.Nodes[node] += duration.Seconds()
+}
+
+// GetTimings gets a copy of the timing breakdown of the build. The duration of a stage which
+// is still in progress is the time elapsed so far.
+func (bs *BuildState) GetTimings() []StageTiming {
+	bs.mutex.RLock()
+	defer bs.mutex.RUnlock()
+	out := make([]StageTiming, len(bs.Timings))
+	for i, timing := range bs.Timings {
+		out[i] = timing
+		out[i].Nodes = map[string]float64{}
+		for node, duration := range timing.Nodes {
+			out[i].Nodes[node] = duration
+		}
+		if timing.End == nil {
+			out[i].Duration = time.Since(timing.Start).Seconds()
+		}
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package state
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/secrets"
+	"github.com/whiteblock/genesis/util"
+	"sort"
+	"strings"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+// TranscriptEntry is a command which was run for a build
+type TranscriptEntry struct {
+	// Server is the id of the server the command was run for
+	Server int `json:"server"`
+	// Stage is the build stage the command was run during
+	Stage string `json:"stage"`
+	// Command is the command which was run, it is empty for a copy
+	Command string `json:"command,omitempty"`
+	// Source is the file on the machine genesis is on which was copied to the server
+	Source string `json:"source,omitempty"`
+	// Dest is where on the server the file was copied to
+	Dest string `json:"dest,omitempty"`
+	// Local is whether the command was run on the machine genesis is on, instead of on the server
+	Local bool `json:"local,omitempty"`
+	// Failed is whether the command failed
+	Failed bool `json:"failed,omitempty"`
+	// Time is when the command finished
+	Time time.Time `json:"time"`
+}
+
+// Redacted gets a copy of the entry with its command replaced, as commands can contain keys, such as the
+// private keys written to the nodes. Nothing is replaced unless secrets mode is enabled.
+func (entry TranscriptEntry) Redacted() TranscriptEntry {
+	if secrets.Enabled() && len(entry.Command) > 0 {
+		entry.Command = secrets.Redacted
+	}
+	return entry
+}
+
+func (bs *BuildState) record(entry TranscriptEntry) {
+	if bs == nil {
+		return
+	}
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+	entry.Stage = bs.BuildStage
+	entry.Time = time.Now()
+	bs.Transcript = append(bs.Transcript, entry)
+}
+
+// RecordCommand adds the given command, which was run on the given server, to the transcript of the build
+func (bs *BuildState) RecordCommand(server int, command string, err error) {
+	bs.record(TranscriptEntry{Server: server, Command: command, Failed: err != nil})
+}
+
+// RecordLocalCommand adds the given command, which was run on the machine genesis is on for the given server,
+// to the transcript of the build
+func (bs *BuildState) RecordLocalCommand(server int, command string, err error) {
+	bs.record(TranscriptEntry{Server: server, Command: command, Local: true, Failed: err != nil})
+}
+
+// RecordCopy adds the copying of src to dest on the given server to the transcript of the build
+func (bs *BuildState) RecordCopy(server int, src string, dest string, err error) {
+	bs.record(TranscriptEntry{Server: server, Source: src, Dest: dest, Failed: err != nil})
+}
+
+// GetTranscript gets a copy of the commands which have been run for the build, in the order they finished
+func (bs *BuildState) GetTranscript() []TranscriptEntry {
+	bs.mutex.RLock()
+	defer bs.mutex.RUnlock()
+	return append([]TranscriptEntry{}, bs.Transcript...)
+}
+
+// GetRedactedTranscript gets a copy of the transcript of the build with every entry redacted,
+// for giving it out through the api
+func (bs *BuildState) GetRedactedTranscript() []TranscriptEntry {
+	transcript := bs.GetTranscript()
+	for i := range transcript {
+		transcript[i] = transcript[i].Redacted()
+	}
+	return transcript
+}
+
+// TranscriptScript gives the transcript of the build as a bash script which runs the commands again over ssh,
+// with the given servers. Failed commands are left in as comments, and so are the commands which were redacted
+// because secrets mode is enabled.
+func (bs *BuildState) TranscriptScript(servers map[int]db.Server) string {
+	transcript := bs.GetRedactedTranscript()
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "#!/bin/bash\n")
+	fmt.Fprintf(out, "# The commands genesis ran for build %s, in the order they were run.\n", bs.BuildID)
+	fmt.Fprintf(out, "# Commands which failed are commented out. The files copied from genesis are\n")
+	fmt.Fprintf(out, "# removed from it once the build is finished, so they need to be provided.\n")
+	if secrets.Enabled() {
+		fmt.Fprintf(out, "# Secrets mode is enabled, so the commands themselves are left out.\n")
+	}
+	fmt.Fprintf(out, "set -e\n\n")
+
+	ids := []int{}
+	for id := range servers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		user := servers[id].SSHUser
+		if len(user) == 0 {
+			user = conf.SSHUser
+		}
+		port := servers[id].SSHPort
+		if port == 0 {
+			port = 22
+		}
+		fmt.Fprintf(out, "SERVER_%d=%s\n", id, util.ShellQuote(user+"@"+servers[id].Addr))
+		fmt.Fprintf(out, "PORT_%d=%d\n", id, port)
+	}
+
+	stage := ""
+	for i, entry := range transcript {
+		if i == 0 || entry.Stage != stage {
+			stage = entry.Stage
+			fmt.Fprintf(out, "\n# %s\n", stage)
+		}
+		var line string
+		switch {
+		case entry.Command == secrets.Redacted:
+			line = fmt.Sprintf("# redacted: a command on server %d", entry.Server)
+		case len(entry.Command) == 0:
+			line = fmt.Sprintf("scp -r -P \"$PORT_%d\" %s \"$SERVER_%d\":%s", entry.Server, util.ShellQuote(entry.Source),
+				entry.Server, util.ShellQuote(entry.Dest))
+		case entry.Local:
+			line = entry.Command
+		default:
+			line = fmt.Sprintf("ssh -p \"$PORT_%d\" \"$SERVER_%d\" %s", entry.Server, entry.Server,
+				util.ShellQuote(entry.Command))
+		}
+		if entry.Failed {
+			line = "# failed: " + strings.Replace(line, "\n", "\n# ", -1)
+		}
+		fmt.Fprintln(out, line)
+	}
+	return out.String()
+}
@@ -0,0 +1,85 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"errors"
+	"github.com/whiteblock/genesis/db"
+	"strings"
+	"testing"
+)
+
+const privateKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+func transcriptWithKey() *BuildState {
+	bs := NewBuildState([]int{1}, "transcript-test")
+	bs.RecordCommand(1, `bash -c 'echo "`+privateKey+`" > /geth/pk0'`, nil)
+	bs.RecordCommand(1, "docker pull "+privateKey, errors.New("failed"))
+	bs.RecordCopy(1, "/tmp/genesis.json", "/home/appo/genesis.json", nil)
+	return bs
+}
+
+func TestGetRedactedTranscript(t *testing.T) {
+	defer func(enabled bool) { conf.Secrets = enabled }(conf.Secrets)
+
+	conf.Secrets = false
+	transcript := transcriptWithKey().GetRedactedTranscript()
+	if !strings.Contains(transcript[0].Command, privateKey) {
+		t.Errorf("the command was redacted without secrets mode: %q", transcript[0].Command)
+	}
+
+	conf.Secrets = true
+	transcript = transcriptWithKey().GetRedactedTranscript()
+	if len(transcript) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(transcript))
+	}
+	for i, entry := range transcript[:2] {
+		if strings.Contains(entry.Command, privateKey) {
+			t.Errorf("entry %d contains the private key: %q", i, entry.Command)
+		}
+	}
+	if !transcript[1].Failed {
+		t.Error("the failed entry is no longer marked as failed")
+	}
+	if transcript[2].Source != "/tmp/genesis.json" || transcript[2].Dest != "/home/appo/genesis.json" {
+		t.Errorf("the copy was changed: %+v", transcript[2])
+	}
+}
+
+func TestTranscriptScript_secrets(t *testing.T) {
+	defer func(enabled bool) { conf.Secrets = enabled }(conf.Secrets)
+	servers := map[int]db.Server{1: {Addr: "172.16.6.5", SSHUser: "appo"}}
+
+	conf.Secrets = false
+	if script := transcriptWithKey().TranscriptScript(servers); !strings.Contains(script, privateKey) {
+		t.Errorf("the script is missing the commands without secrets mode:\n%s", script)
+	}
+
+	conf.Secrets = true
+	script := transcriptWithKey().TranscriptScript(servers)
+	if strings.Contains(script, privateKey) {
+		t.Errorf("the script contains the private key:\n%s", script)
+	}
+	if !strings.Contains(script, "# redacted: a command on server 1") {
+		t.Errorf("the redacted commands are not left in as comments:\n%s", script)
+	}
+	if !strings.Contains(script, `scp -r -P "$PORT_1" '/tmp/genesis.json' "$SERVER_1":'/home/appo/genesis.json'`) {
+		t.Errorf("the copy is missing from the script:\n%s", script)
+	}
+}
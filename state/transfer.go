@@ -0,0 +1,90 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package state
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Transfer is the progress of a file which is being copied to a server
+type Transfer struct {
+	// Server is the id of the server the file is being copied to
+	Server int `json:"server"`
+	// Source is the file on the machine genesis is on which is being copied
+	Source string `json:"source"`
+	// Dest is where on the server the file is being copied to
+	Dest string `json:"dest"`
+	// Sent is the number of bytes which have been sent so far
+	Sent int64 `json:"sent"`
+	// Total is the size of the file in bytes
+	Total int64 `json:"total"`
+	// Start is when the transfer started
+	Start time.Time `json:"start"`
+}
+
+// Progress updates the number of bytes of the transfer which have been sent. It is safe to
+// call on a nil transfer.
+func (t *Transfer) Progress(sent int64, total int64) {
+	if t == nil {
+		return
+	}
+	atomic.StoreInt64(&t.Sent, sent)
+	atomic.StoreInt64(&t.Total, total)
+}
+
+// StartTransfer registers a copy of src to dest on the given server, to be reported in the status of the build
+// until FinishTransfer is called with it. Returns nil if bs is nil.
+func (bs *BuildState) StartTransfer(server int, src string, dest string, total int64) *Transfer {
+	if bs == nil {
+		return nil
+	}
+	out := &Transfer{Server: server, Source: src, Dest: dest, Total: total, Start: time.Now()}
+	bs.extraMux.Lock()
+	defer bs.extraMux.Unlock()
+	if bs.transfers == nil {
+		bs.transfers = map[*Transfer]bool{}
+	}
+	bs.transfers[out] = true
+	return out
+}
+
+// FinishTransfer removes the given transfer from those in progress
+func (bs *BuildState) FinishTransfer(transfer *Transfer) {
+	if bs == nil || transfer == nil {
+		return
+	}
+	bs.extraMux.Lock()
+	defer bs.extraMux.Unlock()
+	delete(bs.transfers, transfer)
+}
+
+// GetTransfers gets the transfers which are in progress, oldest first
+func (bs *BuildState) GetTransfers() []Transfer {
+	bs.extraMux.RLock()
+	out := make([]Transfer, 0, len(bs.transfers))
+	for transfer := range bs.transfers {
+		out = append(out, Transfer{Server: transfer.Server, Source: transfer.Source, Dest: transfer.Dest,
+			Sent: atomic.LoadInt64(&transfer.Sent), Total: atomic.LoadInt64(&transfer.Total), Start: transfer.Start})
+	}
+	bs.extraMux.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
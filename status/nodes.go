@@ -21,9 +21,11 @@ package status
 import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/clients"
 	"github.com/whiteblock/genesis/db"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/util"
+	"github.com/whiteblock/genesis/webhook"
 	"strconv"
 	"strings"
 	"sync"
@@ -31,6 +33,12 @@ import (
 
 var conf *util.Config
 
+var (
+	// nodesUp tracks which nodes were up during the last status check, by testnet id and then node id
+	nodesUp    = map[string]map[string]bool{}
+	nodesUpMux = sync.Mutex{}
+)
+
 func init() {
 	conf = util.GetConfig()
 }
@@ -41,6 +49,8 @@ type Comp struct {
 	CPU float64 `json:"cpu"`
 	VSZ float64 `json:"virtualMemorySize"`
 	RSS float64 `json:"residentSetSize"`
+	// Source is where the usage was read from, either ps or cadvisor
+	Source string `json:"source,omitempty"`
 }
 
 // NodeStatus represents the status of the node
@@ -67,13 +77,14 @@ func FindNodeIndex(status []NodeStatus, name string, serverID int) int {
 
 // SumResUsage gets the cpu usage of a node
 func SumResUsage(c ssh.Client, name string) (Comp, error) {
-	res, err := c.Run(fmt.Sprintf("docker exec %s ps aux --no-headers | grep -v nibbler | awk '{print $3,$5,$6}'", name))
+	res, err := c.Run(fmt.Sprintf("%s exec %s ps aux --no-headers | grep -v nibbler | awk '{print $3,$5,$6}'",
+		c.Runtime().CLI, name))
 	if err != nil {
-		return Comp{-1, -1, -1}, util.LogError(err)
+		return Comp{-1, -1, -1, ""}, util.LogError(err)
 	}
 	procs := strings.Split(res, "\n")
 	log.WithFields(log.Fields{"name": name, "nprocs": len(res)}).Trace("found processes")
-	var out Comp
+	out := Comp{Source: "ps"}
 	for _, proc := range procs {
 		if len(proc) == 0 {
 			continue
@@ -82,19 +93,19 @@ func SumResUsage(c ssh.Client, name string) (Comp, error) {
 
 		cpu, err := strconv.ParseFloat(values[0], 64)
 		if err != nil {
-			return Comp{-1, -1, -1}, util.LogError(err)
+			return Comp{-1, -1, -1, ""}, util.LogError(err)
 		}
 		out.CPU += cpu
 
 		vsz, err := strconv.ParseFloat(values[1], 64)
 		if err != nil {
-			return Comp{-1, -1, -1}, util.LogError(err)
+			return Comp{-1, -1, -1, ""}, util.LogError(err)
 		}
 		out.VSZ += vsz
 
 		rss, err := strconv.ParseFloat(values[2], 64)
 		if err != nil {
-			return Comp{-1, -1, -1}, util.LogError(err)
+			return Comp{-1, -1, -1, ""}, util.LogError(err)
 		}
 		out.RSS += rss
 
@@ -102,6 +113,35 @@ func SumResUsage(c ssh.Client, name string) (Comp, error) {
 	return out, nil
 }
 
+// CAdvisorResUsage gets the resource usage of a node from the cadvisor instance on its server. The cpu
+// usage is over the last sampling interval of cadvisor, and the memory usage is its working set, in KiB as
+// given by ps. There is no equivalent of the virtual memory size, so it is given as the total memory usage.
+func CAdvisorResUsage(c ssh.Client, name string) (Comp, error) {
+	usage, err := clients.NewCAdvisor(c, "127.0.0.1", conf.CAdvisorPort).Usage(name)
+	if err != nil {
+		return Comp{-1, -1, -1, ""}, err
+	}
+	return Comp{
+		CPU:    usage.CPU,
+		VSZ:    float64(usage.Memory) / 1024,
+		RSS:    float64(usage.WorkingSet) / 1024,
+		Source: "cadvisor",
+	}, nil
+}
+
+// GetResUsage gets the resource usage of a node, from cadvisor when it runs on the servers, falling
+// back on the processes in the node otherwise
+func GetResUsage(c ssh.Client, name string) (Comp, error) {
+	if conf.EnableCAdvisor {
+		out, err := CAdvisorResUsage(c, name)
+		if err == nil {
+			return out, nil
+		}
+		log.WithFields(log.Fields{"name": name, "error": err}).Debug("falling back on ps for the resource usage")
+	}
+	return SumResUsage(c, name)
+}
+
 // CheckNodeStatus checks the status of the nodes in the current testnet
 func CheckNodeStatus(nodes []db.Node) ([]NodeStatus, error) {
 
@@ -111,14 +151,14 @@ func CheckNodeStatus(nodes []db.Node) ([]NodeStatus, error) {
 	for _, node := range nodes {
 		log.WithFields(log.Fields{"node": node.AbsoluteNum, "id": node.ID, "server": node.Server}).Trace("adding node to be check")
 		out[node.AbsoluteNum] = NodeStatus{
-			Name:      fmt.Sprintf("%s%d", conf.NodePrefix, node.LocalID),
+			Name:      node.GetNodeName(),
 			IP:        node.IP,
 			Server:    node.Server,
 			Up:        false,
 			ID:        node.ID,
 			Protocol:  node.Protocol,
 			Image:     node.Image,
-			Resources: Comp{-1, -1, -1},
+			Resources: Comp{-1, -1, -1, ""},
 		}
 	}
 	servers, err := db.GetServers(serverIDs)
@@ -134,7 +174,7 @@ func CheckNodeStatus(nodes []db.Node) ([]NodeStatus, error) {
 			return nil, util.LogError(err)
 		}
 		res, err := client.Run(
-			fmt.Sprintf("docker ps | egrep -o '%s[0-9]*' | sort", conf.NodePrefix))
+			fmt.Sprintf("%s ps --format '{{.Names}}' | grep '^%s' | sort", client.Runtime().CLI, conf.NodePrefix))
 		if err != nil {
 			return nil, util.LogError(err)
 		}
@@ -146,13 +186,13 @@ func CheckNodeStatus(nodes []db.Node) ([]NodeStatus, error) {
 
 			index := FindNodeIndex(out, name, server.ID)
 			if index == -1 {
-				log.WithFields(log.Fields{"name": name, "server": server.ID}).Warn("unable to find a node")
+				log.WithFields(log.Fields{"name": name, "server": server.ID}).Trace("unable to find a node")
 				continue
 			}
 			wg.Add(1)
 			go func(client ssh.Client, name string, index int) {
 				defer wg.Done()
-				resUsage, err := SumResUsage(client, name)
+				resUsage, err := GetResUsage(client, name)
 				if err != nil {
 					log.Error(err)
 				}
@@ -164,5 +204,33 @@ func CheckNodeStatus(nodes []db.Node) ([]NodeStatus, error) {
 		}
 	}
 	wg.Wait()
+	reportCrashedNodes(nodes, out)
 	return out, nil
 }
+
+// reportCrashedNodes sends out a webhook event for each node which was up during
+// the previous status check, but is no longer up
+func reportCrashedNodes(nodes []db.Node, statuses []NodeStatus) {
+	nodesUpMux.Lock()
+	defer nodesUpMux.Unlock()
+	for _, node := range nodes {
+		stat := statuses[node.AbsoluteNum]
+		if _, ok := nodesUp[node.TestNetID]; !ok {
+			nodesUp[node.TestNetID] = map[string]bool{}
+		}
+		if nodesUp[node.TestNetID][node.ID] && !stat.Up {
+			log.WithFields(log.Fields{"build": node.TestNetID, "node": node.AbsoluteNum}).Warn("node has gone down")
+			webhook.Emit(webhook.NodeCrashed, node.TestNetID, map[string]interface{}{
+				"node": node.AbsoluteNum, "name": stat.Name, "server": node.Server})
+		}
+		nodesUp[node.TestNetID][node.ID] = stat.Up
+	}
+}
+
+// ForgetTestNet forgets which nodes of the given testnet were up, so that its nodes are not
+// reported as crashed once it is torn down
+func ForgetTestNet(testnetID string) {
+	nodesUpMux.Lock()
+	defer nodesUpMux.Unlock()
+	delete(nodesUp, testnetID)
+}
@@ -20,6 +20,7 @@ package status
 
 import (
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/simulator"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/util"
 	"sync"
@@ -42,7 +43,25 @@ func GetClient(id int) (ssh.Client, error) {
 		if err != nil {
 			return nil, util.LogError(err)
 		}
-		cli, err = ssh.NewClient(server.Addr, id)
+		runtime, err := util.GetRuntime(server.Runtime)
+		if err != nil {
+			return nil, util.LogError(err)
+		}
+		if simulator.Enabled() {
+			sim, err := simulator.Default()
+			if err != nil {
+				return nil, util.LogError(err)
+			}
+			cli = simulator.NewClient(sim, id, runtime)
+			_clients[id] = cli
+			return cli, nil
+		}
+		cli, err = ssh.NewClient(server.Addr, id, runtime, ssh.Credentials{
+			User:       server.SSHUser,
+			Key:        server.SSHKey,
+			PrivateKey: server.SSHPrivateKey,
+			Port:       server.SSHPort,
+		})
 		if err != nil {
 			return nil, util.LogError(err)
 		}
@@ -72,3 +91,19 @@ func GetClientsFromNodes(nodes []db.Node) ([]ssh.Client, error) {
 	serverIds := db.GetUniqueServerIDs(nodes)
 	return GetClients(serverIds)
 }
+
+// GetPoolStats gets the metrics of the ssh connection pools of the servers which
+// have been connected to, indexed by server id
+func GetPoolStats() map[int]ssh.PoolStats {
+	_mux.Lock()
+	defer _mux.Unlock()
+	out := map[int]ssh.PoolStats{}
+	for id, cli := range _clients {
+		stater, ok := cli.(ssh.PoolStater)
+		if !ok {
+			continue
+		}
+		out[id] = stater.PoolStats()
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package status
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"time"
+)
+
+// StartCrashWatcher starts the routine which checks the status of the nodes of every running testnet
+// every nodeCheckInterval seconds, so that crashed nodes are reported even when nobody is polling
+// their status. It does nothing if nodeCheckInterval is 0.
+func StartCrashWatcher() {
+	if conf.NodeCheckInterval <= 0 {
+		return
+	}
+	go func() {
+		for {
+			time.Sleep(time.Duration(conf.NodeCheckInterval) * time.Second)
+			checkRunningTestNets()
+		}
+	}()
+}
+
+func checkRunningTestNets() {
+	testnets, _, err := db.ListTestNets(db.TestNetFilter{Status: db.TestNetRunning})
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("failed to list the running testnets")
+		return
+	}
+	for _, tn := range testnets {
+		nodes, err := db.GetAllNodesByTestNet(tn.ID)
+		if err != nil || len(nodes) == 0 {
+			continue
+		}
+		err = util.Safe(func() error {
+			_, err := CheckNodeStatus(nodes)
+			return err
+		})
+		if err != nil {
+			log.WithFields(log.Fields{"build": tn.ID, "error": err}).Error("failed to check the status of the nodes")
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sweep
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Row is a variant in the comparison of the variants of a sweep
+type Row struct {
+	Variant int `json:"variant"`
+	// Values are the values of the variations for the variant, by name
+	Values map[string]json.RawMessage `json:"values"`
+	// Status is the status of the run of the scenario, or failed if the variant could not be built
+	Status string `json:"status"`
+	// Metrics are the averages of the metrics over the whole scenario, by name
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// Comparison lines the variants of a sweep up against each other
+type Comparison struct {
+	// Variations are the names of the variations
+	Variations []string `json:"variations"`
+	// Metrics are the names of the metrics sampled from any of the variants
+	Metrics []string `json:"metrics"`
+	// Rows are the variants which are done or failed, in order
+	Rows []Row `json:"rows"`
+}
+
+// Compare lines the variants of the sweep which are done or failed up against each other, by the average
+// of each metric over the whole scenario run against them
+func Compare(sw Sweep) Comparison {
+	out := Comparison{Variations: sw.Variations, Metrics: []string{}, Rows: []Row{}}
+	summaries := []map[string]Summary{}
+	for _, variant := range sw.Variants {
+		switch variant.State {
+		case DoneState, FailedState:
+		default:
+			continue
+		}
+		row := Row{Variant: variant.Index, Values: variant.Values, Status: variant.Status, Metrics: map[string]float64{}}
+		if variant.State == FailedState {
+			row.Status = FailedState
+		}
+		for name, sum := range variant.Metrics {
+			row.Metrics[name] = sum.Avg
+		}
+		summaries = append(summaries, variant.Metrics)
+		out.Rows = append(out.Rows, row)
+	}
+	out.Metrics = metricNames(summaries...)
+	return out
+}
+
+// WriteCSV writes the comparison as csv, with a column for the variant, each of the variations, the status
+// and each of the metrics. The metrics which were not sampled from a variant are left empty.
+func (cmp Comparison) WriteCSV(dest io.Writer) error {
+	writer := csv.NewWriter(dest)
+	header := append([]string{"variant"}, cmp.Variations...)
+	header = append(header, "status")
+	err := writer.Write(append(header, cmp.Metrics...))
+	if err != nil {
+		return err
+	}
+	for _, row := range cmp.Rows {
+		record := []string{strconv.Itoa(row.Variant)}
+		for _, name := range cmp.Variations {
+			record = append(record, csvValue(row.Values[name]))
+		}
+		record = append(record, row.Status)
+		for _, name := range cmp.Metrics {
+			value, ok := row.Metrics[name]
+			if !ok {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, strconv.FormatFloat(value, 'f', -1, 64))
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvValue gives the value of a variation as it goes in a csv, which is the string itself for a string
+func csvValue(raw json.RawMessage) string {
+	var str string
+	if json.Unmarshal(raw, &str) == nil {
+		return str
+	}
+	return string(raw)
+}
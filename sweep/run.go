@@ -0,0 +1,279 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sweep
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/manager"
+	"github.com/whiteblock/genesis/scenario"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/testnet"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+	"time"
+)
+
+const sweepsKey = "sweeps"
+
+const (
+	// PendingState is the state of a variant which is waiting for a set of servers
+	PendingState = "pending"
+	// BuildingState is the state of a variant which is being built
+	BuildingState = "building"
+	// RunningState is the state of a variant which the scenario is running against, or of a sweep with
+	// variants which are not done yet
+	RunningState = "running"
+	// DoneState is the state of a variant which the scenario was run against, whether or not it passed,
+	// or of a sweep whose variants are all done or failed
+	DoneState = "done"
+	// FailedState is the state of a variant which could not be built, or could not have the scenario run against it
+	FailedState = "failed"
+)
+
+var sweepsMux = sync.Mutex{}
+
+// VariantState is the state of a variant of a sweep
+type VariantState struct {
+	Index int `json:"index"`
+	// Values are the values of the variations for the variant, by name
+	Values map[string]json.RawMessage `json:"values"`
+	State  string                     `json:"state"`
+	// TestNetID is the id of the testnet of the variant, once its build has started
+	TestNetID string `json:"testnetId,omitempty"`
+	// ScenarioID is the id of the run of the scenario against the variant, once it has started
+	ScenarioID string `json:"scenarioId,omitempty"`
+	// Status is the status of the run of the scenario, once it is done
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Phases sum up the metrics of each phase of the scenario
+	Phases []PhaseSummary `json:"phases,omitempty"`
+	// Metrics sum up the metrics over the whole scenario
+	Metrics  map[string]Summary `json:"metrics,omitempty"`
+	Started  int64              `json:"started,omitempty"`
+	Finished int64              `json:"finished,omitempty"`
+}
+
+// Sweep is a sweep over the variants of a testnet
+type Sweep struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Created int64  `json:"created"`
+	// Variations are the names of the variations
+	Variations []string       `json:"variations"`
+	Variants   []VariantState `json:"variants"`
+}
+
+// State gets the state of the sweep as a whole, running until each of its variants is done or failed
+func (sw Sweep) State() string {
+	for _, variant := range sw.Variants {
+		switch variant.State {
+		case PendingState, BuildingState, RunningState:
+			return RunningState
+		}
+	}
+	return DoneState
+}
+
+// MarshalJSON gives the sweep along with its state
+func (sw Sweep) MarshalJSON() ([]byte, error) {
+	type sweep Sweep
+	return json.Marshal(struct {
+		sweep
+		State string `json:"state"`
+	}{sweep: sweep(sw), State: sw.State()})
+}
+
+func getSweeps() []Sweep {
+	out := []Sweep{}
+	db.GetMetaP(sweepsKey, &out) //An error here just means that there are no sweeps
+	return out
+}
+
+// List gets all of the sweeps
+func List() []Sweep {
+	sweepsMux.Lock()
+	defer sweepsMux.Unlock()
+	return getSweeps()
+}
+
+// Get gets the sweep with the given id
+func Get(id string) (Sweep, error) {
+	for _, sw := range List() {
+		if sw.ID == id {
+			return sw, nil
+		}
+	}
+	return Sweep{}, fmt.Errorf("sweep \"%s\" not found", id)
+}
+
+// updateVariant applies fn to the state of the given variant of the sweep, and stores it
+func updateVariant(id string, index int, fn func(*VariantState)) {
+	sweepsMux.Lock()
+	defer sweepsMux.Unlock()
+	sweeps := getSweeps()
+	for i := range sweeps {
+		if sweeps[i].ID == id && index < len(sweeps[i].Variants) {
+			fn(&sweeps[i].Variants[index])
+		}
+	}
+	util.LogError(db.SetMeta(sweepsKey, sweeps))
+}
+
+// Start validates the request and starts building its variants and running the scenario against each of
+// them, giving the id of the sweep. jwt is that of the caller, which each build is made with.
+func Start(req Request, jwt string) (string, error) {
+	err := req.Validate()
+	if err != nil {
+		return "", err
+	}
+	variants, err := req.Variants()
+	if err != nil {
+		return "", err
+	}
+	sets, err := req.GetServers()
+	if err != nil {
+		return "", err
+	}
+	id, err := util.GetUUIDString()
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	sw := Sweep{ID: id, Name: req.Name, Created: time.Now().Unix(), Variations: []string{}}
+	for _, v := range req.Variations {
+		sw.Variations = append(sw.Variations, v.GetName())
+	}
+	for i, variant := range variants {
+		sw.Variants = append(sw.Variants, VariantState{Index: i, Values: variant.Values, State: PendingState})
+	}
+	sweepsMux.Lock()
+	err = db.SetMeta(sweepsKey, append(getSweeps(), sw))
+	sweepsMux.Unlock()
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	log.WithFields(log.Fields{"sweep": id, "variants": len(variants), "concurrency": len(sets)}).Info("started a sweep")
+	go run(id, variants, sets, req.Keep, jwt)
+	return id, nil
+}
+
+// run runs the variants in order, each on the first set of servers which is free
+func run(id string, variants []Variant, sets [][]int, keep bool, jwt string) {
+	next := make(chan int, len(variants))
+	for i := range variants {
+		next <- i
+	}
+	close(next)
+	wg := sync.WaitGroup{}
+	for _, servers := range sets {
+		wg.Add(1)
+		go func(servers []int) {
+			defer wg.Done()
+			for i := range next {
+				runVariant(id, i, variants[i], servers, keep, jwt)
+			}
+		}(servers)
+	}
+	wg.Wait()
+	log.WithFields(log.Fields{"sweep": id}).Info("finished the sweep")
+}
+
+// runVariant builds the variant on the given servers and runs the scenario against it, recording the
+// summary of its metrics. The testnet is torn down afterwards unless it is to be kept.
+func runVariant(id string, index int, variant Variant, servers []int, keep bool, jwt string) {
+	testnetID, res, err := buildAndRun(id, index, variant, servers, jwt)
+	if len(testnetID) > 0 && !keep {
+		util.LogError(manager.DeleteTestNet(testnetID))
+	}
+	updateVariant(id, index, func(vs *VariantState) {
+		vs.Finished = time.Now().Unix()
+		if err != nil {
+			vs.State = FailedState
+			vs.Error = err.Error()
+			return
+		}
+		vs.State = DoneState
+		vs.Status = res.Status
+		vs.Error = res.Error
+		vs.Phases, vs.Metrics = Summarize(res)
+	})
+}
+
+// buildAndRun builds the variant on the given servers, then runs the scenario against it until it is done,
+// giving the id of the testnet once its build has started
+func buildAndRun(id string, index int, variant Variant, servers []int, jwt string) (string, scenario.Run, error) {
+	details := variant.Details
+	details.Servers = servers
+	details.SetJwt(jwt)
+	testnetID, err := util.GetUUIDString()
+	if err != nil {
+		return "", scenario.Run{}, util.LogError(err)
+	}
+	err = state.AcquireBuilding(details.Servers, testnetID)
+	if err != nil {
+		return "", scenario.Run{}, err
+	}
+	updateVariant(id, index, func(vs *VariantState) {
+		vs.State = BuildingState
+		vs.TestNetID = testnetID
+		vs.Started = time.Now().Unix()
+	})
+	log.WithFields(log.Fields{"sweep": id, "variant": index, "build": testnetID}).Info("building a variant")
+	err = manager.AddTestNet(&details, testnetID)
+	if err != nil {
+		return testnetID, scenario.Run{}, err
+	}
+	tn, err := testnet.RestoreTestNet(testnetID)
+	if err != nil {
+		return testnetID, scenario.Run{}, util.LogError(err)
+	}
+	res, err := scenario.Start(testnetID, variant.Scenario, scenario.NewTarget(tn))
+	if err != nil {
+		return testnetID, scenario.Run{}, util.LogError(err)
+	}
+	updateVariant(id, index, func(vs *VariantState) {
+		vs.State = RunningState
+		vs.ScenarioID = res.ID
+	})
+	res, err = scenario.Wait(testnetID, res.ID)
+	return testnetID, res, util.LogError(err)
+}
+
+// Delete forgets the sweep. A sweep which is still running cannot be deleted. The testnets of the variants
+// which were kept are left as they are.
+func Delete(id string) error {
+	sw, err := Get(id)
+	if err != nil {
+		return err
+	}
+	if sw.State() == RunningState {
+		return fmt.Errorf("sweep \"%s\" is still running", id)
+	}
+	sweepsMux.Lock()
+	defer sweepsMux.Unlock()
+	out := []Sweep{}
+	for _, other := range getSweeps() {
+		if other.ID != id {
+			out = append(out, other)
+		}
+	}
+	return db.SetMeta(sweepsKey, out)
+}
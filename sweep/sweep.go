@@ -0,0 +1,310 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package sweep builds a variant of a testnet for each combination of the values of the parameters being
+// varied, runs the same scenario against each of them and compares the metrics sampled from each. The
+// variants are built one after the other, or several at a time when they are given several sets of servers.
+package sweep
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/kubernetes"
+	"github.com/whiteblock/genesis/scenario"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxVariants is the most variants a sweep can have
+const maxVariants = 256
+
+// Variation is a parameter which is varied by a sweep
+type Variation struct {
+	// Name is how the parameter is referred to in the report, defaults to the path
+	Name string `json:"name,omitempty"`
+	// Path is where the values go, as dot separated keys within either the deployment details or the scenario,
+	// such as details.params.blockTime or scenario.phases.0.netem.0.delay. Numbers index into lists.
+	Path string `json:"path"`
+	// Values are the values the parameter takes
+	Values []json.RawMessage `json:"values"`
+}
+
+// Request is a request for a sweep
+type Request struct {
+	// Name describes the sweep
+	Name string `json:"name"`
+	// Details are the deployment details the variants are built from
+	Details json.RawMessage `json:"details"`
+	// Scenario is run against each variant once it is built
+	Scenario json.RawMessage `json:"scenario"`
+	// Variations are the parameters to vary. A variant is built for each combination of their values.
+	Variations []Variation `json:"variations"`
+	// Servers are the sets of servers the variants are built on, one variant on each set at a time.
+	// Defaults to the servers of the details, so that the variants are built one after the other.
+	Servers [][]int `json:"servers,omitempty"`
+	// Keep leaves the testnet of each variant in place once its scenario is done, instead of tearing it
+	// down. Only the last variant built on each set of servers is left, as the next one replaces it.
+	Keep bool `json:"keep,omitempty"`
+}
+
+// Variant is one of the combinations of the values of the variations of a sweep
+type Variant struct {
+	// Values are the values of the variations, by name
+	Values map[string]json.RawMessage `json:"values"`
+	// Details are the deployment details of the variant
+	Details db.DeploymentDetails `json:"-"`
+	// Scenario is the scenario run against the variant
+	Scenario scenario.Scenario `json:"-"`
+}
+
+// GetName gets the name of the variation, which defaults to its path
+func (v Variation) GetName() string {
+	if len(v.Name) == 0 {
+		return v.Path
+	}
+	return v.Name
+}
+
+// GetServers gets the sets of servers the variants are built on
+func (req Request) GetServers() ([][]int, error) {
+	if len(req.Servers) > 0 {
+		return req.Servers, nil
+	}
+	var details db.DeploymentDetails
+	err := json.Unmarshal(req.Details, &details)
+	if err != nil {
+		return nil, fmt.Errorf("invalid details: %s", err.Error())
+	}
+	return [][]int{details.Servers}, nil
+}
+
+// decode decodes the given json, keeping the numbers as they are given
+func decode(data []byte) (interface{}, error) {
+	var out interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return out, decoder.Decode(&out)
+}
+
+// set sets the value at the given dot separated path within tree, creating the objects on the way
+// which do not exist
+func set(tree interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	switch node := tree.(type) {
+	case nil:
+		child, err := set(nil, path[1:], value)
+		return map[string]interface{}{path[0]: child}, err
+	case map[string]interface{}:
+		child, err := set(node[path[0]], path[1:], value)
+		node[path[0]] = child
+		return node, err
+	case []interface{}:
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || i >= len(node) {
+			return node, fmt.Errorf("there is no item %s in a list of %d", path[0], len(node))
+		}
+		node[i], err = set(node[i], path[1:], value)
+		return node, err
+	}
+	return tree, fmt.Errorf("cannot set %s within a %T", path[0], tree)
+}
+
+// Variants gets the variants of the sweep, one for each combination of the values of its variations,
+// with the values of the first variation changing the slowest
+func (req Request) Variants() ([]Variant, error) {
+	total := 1
+	for _, v := range req.Variations {
+		total *= len(v.Values)
+		if total > maxVariants {
+			return nil, fmt.Errorf("the sweep has more than %d variants", maxVariants)
+		}
+	}
+	out := []Variant{}
+	for n := 0; n < total; n++ {
+		doc := map[string]interface{}{}
+		for key, raw := range map[string]json.RawMessage{"details": req.Details, "scenario": req.Scenario} {
+			tree, err := decode(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", key, err.Error())
+			}
+			doc[key] = tree
+		}
+		variant := Variant{Values: map[string]json.RawMessage{}}
+		rest := n
+		for i := len(req.Variations) - 1; i >= 0; i-- {
+			v := req.Variations[i]
+			raw := v.Values[rest%len(v.Values)]
+			rest /= len(v.Values)
+			value, err := decode(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %s", v.GetName(), err.Error())
+			}
+			_, err = set(doc, strings.Split(v.Path, "."), value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %s: %s", v.Path, err.Error())
+			}
+			variant.Values[v.GetName()] = raw
+		}
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		var decoded struct {
+			Details  db.DeploymentDetails `json:"details"`
+			Scenario scenario.Scenario    `json:"scenario"`
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&decoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid variant %d: %s", n, err.Error())
+		}
+		variant.Details = decoded.Details
+		variant.Scenario = decoded.Scenario
+		out = append(out, variant)
+	}
+	return out, nil
+}
+
+// Validate ensures that each variant of the sweep can be built and have its scenario run against it, and
+// that no server is in more than one of the sets of servers
+func (req Request) Validate() error {
+	if len(req.Variations) == 0 {
+		return fmt.Errorf("a sweep needs at least one variation")
+	}
+	names := map[string]bool{}
+	for _, v := range req.Variations {
+		if !strings.HasPrefix(v.Path, "details.") && !strings.HasPrefix(v.Path, "scenario.") {
+			return fmt.Errorf("the path %s is neither within the details nor the scenario", v.Path)
+		}
+		if names[v.GetName()] {
+			return fmt.Errorf("the variation %s is given more than once", v.GetName())
+		}
+		names[v.GetName()] = true
+		if len(v.Values) == 0 {
+			return fmt.Errorf("the variation %s has no values", v.GetName())
+		}
+	}
+	sets, err := req.GetServers()
+	if err != nil {
+		return err
+	}
+	seen := map[int]bool{}
+	for _, servers := range sets {
+		if len(servers) == 0 {
+			return fmt.Errorf("each set of servers needs at least one server")
+		}
+		for _, server := range servers {
+			if seen[server] {
+				return fmt.Errorf("server %d is in more than one set of servers", server)
+			}
+			seen[server] = true
+		}
+	}
+	variants, err := req.Variants()
+	if err != nil {
+		return err
+	}
+	for i, variant := range variants {
+		cfg, err := kubernetes.GetConfig(&variant.Details)
+		if err != nil {
+			return err
+		}
+		if cfg.Enabled {
+			return fmt.Errorf("scenarios are not supported on kubernetes")
+		}
+		err = variant.Scenario.Validate(variant.Details.Nodes)
+		if err != nil {
+			return fmt.Errorf("invalid scenario for variant %d: %s", i, err.Error())
+		}
+	}
+	return nil
+}
+
+// Summary sums up the samples of a metric
+type Summary struct {
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Avg     float64 `json:"avg"`
+	Last    float64 `json:"last"`
+	Samples int     `json:"samples"`
+}
+
+// PhaseSummary sums up a phase of the scenario run against a variant
+type PhaseSummary struct {
+	Name    string             `json:"name"`
+	Passed  bool               `json:"passed"`
+	Metrics map[string]Summary `json:"metrics"`
+}
+
+// summarize sums up the given samples of each metric
+func summarize(samples []scenario.Sample) map[string]Summary {
+	out := map[string]Summary{}
+	for _, sample := range samples {
+		for name, value := range sample.Values {
+			sum, ok := out[name]
+			if !ok {
+				sum = Summary{Min: math.Inf(1), Max: math.Inf(-1)}
+			}
+			sum.Min = math.Min(sum.Min, value)
+			sum.Max = math.Max(sum.Max, value)
+			sum.Avg += value
+			sum.Last = value
+			sum.Samples++
+			out[name] = sum
+		}
+	}
+	for name, sum := range out {
+		sum.Avg /= float64(sum.Samples)
+		out[name] = sum
+	}
+	return out
+}
+
+// Summarize sums up the metrics of the run of a scenario, for each phase and over the whole run
+func Summarize(run scenario.Run) ([]PhaseSummary, map[string]Summary) {
+	phases := []PhaseSummary{}
+	all := []scenario.Sample{}
+	for _, phase := range run.Phases {
+		phases = append(phases, PhaseSummary{Name: phase.Name, Passed: phase.Passed, Metrics: summarize(phase.Samples)})
+		all = append(all, phase.Samples...)
+	}
+	return phases, summarize(all)
+}
+
+// metricNames gets the names of all of the metrics in the given summaries, in order
+func metricNames(summaries ...map[string]Summary) []string {
+	names := map[string]bool{}
+	for _, summary := range summaries {
+		for name := range summary {
+			names[name] = true
+		}
+	}
+	out := []string{}
+	for name := range names {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,183 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sweep
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/scenario"
+)
+
+func values(raw ...string) []json.RawMessage {
+	out := []json.RawMessage{}
+	for _, value := range raw {
+		out = append(out, json.RawMessage(value))
+	}
+	return out
+}
+
+func request(variations ...Variation) Request {
+	return Request{
+		Details:    json.RawMessage(`{"blockchain":"geth","nodes":3,"servers":[1],"params":{"blockTime":1}}`),
+		Scenario:   json.RawMessage(`{"phases":[{"duration":"1m","netem":[{"node":0,"delay":50}]}]}`),
+		Variations: variations,
+	}
+}
+
+func TestRequest_Variants(t *testing.T) {
+	req := request(
+		Variation{Name: "blockTime", Path: "details.params.blockTime", Values: values("1", "5", "10")},
+		Variation{Path: "scenario.phases.0.netem.0.delay", Values: values("50", "200")},
+	)
+	variants, err := req.Variants()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != 6 {
+		t.Fatalf("expected 6 variants, got %d", len(variants))
+	}
+	var test = []struct {
+		blockTime string
+		delay     int
+	}{
+		{"1", 50}, {"1", 200}, {"5", 50}, {"5", 200}, {"10", 50}, {"10", 200},
+	}
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			variant := variants[i]
+			if string(variant.Values["blockTime"]) != tt.blockTime {
+				t.Errorf("expected the block time %s, got %s", tt.blockTime, variant.Values["blockTime"])
+			}
+			if got := string(variant.Values["scenario.phases.0.netem.0.delay"]); got != strconv.Itoa(tt.delay) {
+				t.Errorf("expected the delay %d to be named after its path, got %s", tt.delay, got)
+			}
+			if got := variant.Details.Params["blockTime"].(json.Number).String(); got != tt.blockTime {
+				t.Errorf("the details have the block time %s instead of %s", got, tt.blockTime)
+			}
+			if got := variant.Scenario.Phases[0].Netem[0].Delay; got != tt.delay {
+				t.Errorf("the scenario has the delay %d instead of %d", got, tt.delay)
+			}
+			if variant.Details.Blockchain != "geth" || variant.Details.Nodes != 3 {
+				t.Errorf("the rest of the details were not kept: %+v", variant.Details)
+			}
+		})
+	}
+}
+
+func TestRequest_Validate(t *testing.T) {
+	valid := Variation{Path: "details.params.blockTime", Values: values("1", "5")}
+	withServers := request(valid)
+	withServers.Servers = [][]int{{1}, {2, 3}}
+	sharedServer := request(valid)
+	sharedServer.Servers = [][]int{{1}, {2, 1}}
+	emptyServers := request(valid)
+	emptyServers.Servers = [][]int{{1}, {}}
+	tooMany := []Variation{}
+	for i := 0; i < 9; i++ {
+		tooMany = append(tooMany, Variation{Name: strconv.Itoa(i), Path: "details.params.x", Values: values("1", "2")})
+	}
+
+	var test = []struct {
+		req   Request
+		valid bool
+	}{
+		{req: request(valid), valid: true},
+		{req: withServers, valid: true},
+		{req: request(valid, Variation{Path: "details.extras.topology.degree", Values: values("2")}), valid: true},
+		{req: request(), valid: false},
+		{req: request(Variation{Path: "params.blockTime", Values: values("1")}), valid: false},
+		{req: request(Variation{Path: "details.params.blockTime"}), valid: false},
+		{req: request(valid, valid), valid: false},
+		{req: request(Variation{Path: "scenario.phases.1.duration", Values: values(`"1m"`)}), valid: false},
+		{req: request(Variation{Path: "scenario.phases.0.duration", Values: values(`"1 minute"`)}), valid: false},
+		{req: request(Variation{Path: "details.nodes", Values: values(`"three"`)}), valid: false},
+		{req: request(Variation{Path: "details.extras.kubernetes.enabled", Values: values("true")}), valid: false},
+		{req: sharedServer, valid: false},
+		{req: emptyServers, valid: false},
+		{req: request(tooMany...), valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate returned %v, expected valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	sample := func(values map[string]float64) scenario.Sample {
+		return scenario.Sample{Values: values}
+	}
+	run := scenario.Run{Phases: []scenario.PhaseReport{
+		{Name: "first", Passed: true, Samples: []scenario.Sample{
+			sample(map[string]float64{"tps": 10, "healthy": 4}), sample(map[string]float64{"tps": 30, "healthy": 4})}},
+		{Name: "second", Samples: []scenario.Sample{sample(map[string]float64{"tps": 20})}},
+	}}
+	phases, all := Summarize(run)
+	if len(phases) != 2 || !phases[0].Passed || phases[1].Passed {
+		t.Fatalf("unexpected phases %+v", phases)
+	}
+	expected := Summary{Min: 10, Max: 30, Avg: 20, Last: 30, Samples: 2}
+	if !reflect.DeepEqual(phases[0].Metrics["tps"], expected) {
+		t.Errorf("the first phase has the summary %+v instead of %+v", phases[0].Metrics["tps"], expected)
+	}
+	expected = Summary{Min: 10, Max: 30, Avg: 20, Last: 20, Samples: 3}
+	if !reflect.DeepEqual(all["tps"], expected) {
+		t.Errorf("the run has the summary %+v instead of %+v", all["tps"], expected)
+	}
+	if all["healthy"].Samples != 2 {
+		t.Errorf("expected 2 samples of healthy, got %d", all["healthy"].Samples)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	sw := Sweep{
+		Variations: []string{"blockTime", "image"},
+		Variants: []VariantState{
+			{Index: 0, Values: map[string]json.RawMessage{"blockTime": json.RawMessage("1"), "image": json.RawMessage(`"a,b"`)},
+				State: DoneState, Status: scenario.PassedStatus, Metrics: map[string]Summary{"tps": {Avg: 12.5}}},
+			{Index: 1, Values: map[string]json.RawMessage{"blockTime": json.RawMessage("5"), "image": json.RawMessage(`"c"`)},
+				State: FailedState, Error: "no servers"},
+			{Index: 2, Values: map[string]json.RawMessage{"blockTime": json.RawMessage("10")}, State: RunningState},
+		},
+	}
+	cmp := Compare(sw)
+	if len(cmp.Rows) != 2 || cmp.Rows[1].Status != FailedState {
+		t.Fatalf("unexpected rows %+v", cmp.Rows)
+	}
+	if !reflect.DeepEqual(cmp.Metrics, []string{"tps"}) {
+		t.Errorf("unexpected metrics %v", cmp.Metrics)
+	}
+	buf := &bytes.Buffer{}
+	err := cmp.WriteCSV(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "variant,blockTime,image,status,tps\n0,1,\"a,b\",passed,12.5\n1,5,c,failed,\n"
+	if buf.String() != expected {
+		t.Errorf("WriteCSV wrote %q instead of %q", buf.String(), expected)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package templates manages named deployment specifications, which can be used to launch
+// builds of common topologies without having to give the full deployment details each time.
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+const metaKey = "templates"
+
+var (
+	namePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+	mux         = sync.Mutex{}
+)
+
+// Template is a named deployment specification, such as "10-node-tendermint-100ms"
+type Template struct {
+	// Name is the unique name of the template
+	Name string `json:"name"`
+
+	// Description is an optional description of what the template deploys
+	Description string `json:"description,omitempty"`
+
+	// Details are the deployment details which will be used for builds of this template
+	Details db.DeploymentDetails `json:"details"`
+}
+
+// Validate ensures that the template is valid
+func (tmpl Template) Validate() error {
+	if !namePattern.MatchString(tmpl.Name) {
+		return fmt.Errorf("template name must only contain letters, numbers, '.', '_' and '-'")
+	}
+	if len(tmpl.Details.Blockchain) == 0 {
+		return fmt.Errorf("template must specify a blockchain")
+	}
+	if tmpl.Details.Nodes <= 0 {
+		return fmt.Errorf("template must have at least one node")
+	}
+	return nil
+}
+
+func getTemplates() map[string]Template {
+	out := map[string]Template{}
+	db.GetMetaP(metaKey, &out) //An error means there are no templates
+	return out
+}
+
+// GetAll gets all of the templates, sorted by name
+func GetAll() []Template {
+	mux.Lock()
+	defer mux.Unlock()
+	out := []Template{}
+	for _, tmpl := range getTemplates() {
+		out = append(out, tmpl)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Get gets the template with the given name
+func Get(name string) (Template, error) {
+	mux.Lock()
+	defer mux.Unlock()
+	tmpl, ok := getTemplates()[name]
+	if !ok {
+		return Template{}, fmt.Errorf("template \"%s\" not found", name)
+	}
+	return tmpl, nil
+}
+
+// Create stores a new template. Returns an error if a template with the same name already exists.
+func Create(tmpl Template) error {
+	err := tmpl.Validate()
+	if err != nil {
+		return err
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	all := getTemplates()
+	if _, exists := all[tmpl.Name]; exists {
+		return fmt.Errorf("template \"%s\" already exists", tmpl.Name)
+	}
+	all[tmpl.Name] = tmpl
+	return db.SetMeta(metaKey, all)
+}
+
+// Update replaces the template with the given name
+func Update(name string, tmpl Template) error {
+	tmpl.Name = name
+	err := tmpl.Validate()
+	if err != nil {
+		return err
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	all := getTemplates()
+	if _, exists := all[name]; !exists {
+		return fmt.Errorf("template \"%s\" not found", name)
+	}
+	all[name] = tmpl
+	return db.SetMeta(metaKey, all)
+}
+
+// Remove deletes the template with the given name
+func Remove(name string) error {
+	mux.Lock()
+	defer mux.Unlock()
+	all := getTemplates()
+	if _, exists := all[name]; !exists {
+		return fmt.Errorf("template \"%s\" not found", name)
+	}
+	delete(all, name)
+	return db.SetMeta(metaKey, all)
+}
+
+// Instantiate gets a copy of the deployment details of the given template, which
+// is safe to modify with overrides before being built.
+func Instantiate(name string) (db.DeploymentDetails, error) {
+	tmpl, err := Get(name)
+	if err != nil {
+		return db.DeploymentDetails{}, err
+	}
+	return tmpl.Instantiate()
+}
+
+// Instantiate gets a deep copy of the deployment details of the template
+func (tmpl Template) Instantiate() (db.DeploymentDetails, error) {
+	var out db.DeploymentDetails
+	data, err := json.Marshal(tmpl.Details)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	return out, util.LogError(json.Unmarshal(data, &out))
+}
@@ -0,0 +1,65 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package templates
+
+import (
+	"github.com/whiteblock/genesis/db"
+	"strconv"
+	"testing"
+)
+
+func TestTemplate_Validate(t *testing.T) {
+	var test = []struct {
+		tmpl Template
+		err  bool
+	}{
+		{tmpl: Template{Name: "10-node-tendermint-100ms", Details: db.DeploymentDetails{Blockchain: "tendermint", Nodes: 10}},
+			err: false},
+		{tmpl: Template{Name: "geth_v1.8", Details: db.DeploymentDetails{Blockchain: "geth", Nodes: 1}}, err: false},
+		{tmpl: Template{Name: "", Details: db.DeploymentDetails{Blockchain: "geth", Nodes: 1}}, err: true},
+		{tmpl: Template{Name: "a/b", Details: db.DeploymentDetails{Blockchain: "geth", Nodes: 1}}, err: true},
+		{tmpl: Template{Name: "geth", Details: db.DeploymentDetails{Nodes: 1}}, err: true},
+		{tmpl: Template{Name: "geth", Details: db.DeploymentDetails{Blockchain: "geth"}}, err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.tmpl.Validate()
+			if (err != nil) != tt.err {
+				t.Errorf("unexpected result from Validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestTemplate_Instantiate(t *testing.T) {
+	tmpl := Template{Name: "geth", Details: db.DeploymentDetails{Blockchain: "geth", Nodes: 2,
+		Params: map[string]interface{}{"blockTime": 5.0}}}
+	details, err := tmpl.Instantiate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	details.Params["blockTime"] = 10.0
+	if tmpl.Details.Params["blockTime"] != 5.0 {
+		t.Errorf("modifying the instantiated details modified the template")
+	}
+	if details.Blockchain != "geth" || details.Nodes != 2 {
+		t.Errorf("instantiated details do not match the template")
+	}
+}
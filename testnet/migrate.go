@@ -0,0 +1,65 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package testnet
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/util"
+)
+
+// migrateContainerNames renames the containers of a testnet which was built using the legacy
+// naming scheme, so that they can be found under their testnet scoped names.
+// Containers which no longer exist are skipped.
+func (tn *TestNet) migrateContainerNames() {
+	log.WithFields(log.Fields{"build": tn.TestNetID}).Info("migrating the testnet to scoped container names")
+	for _, node := range tn.Nodes {
+		tn.renameContainer(node.Server, util.GetLegacyNodeName(node.LocalID), node.GetNodeName())
+	}
+	for _, sideCars := range tn.SideCars {
+		for _, sideCar := range sideCars {
+			tn.renameContainer(sideCar.Server, util.GetLegacySideCarName(sideCar.LocalID, sideCar.NetworkIndex),
+				sideCar.GetNodeName())
+		}
+	}
+	tn.ScopedNames = true
+	tn.Store()
+}
+
+func (tn *TestNet) renameContainer(serverID int, oldName string, newName string) {
+	client, ok := tn.Clients[serverID]
+	if !ok {
+		return
+	}
+	_, err := client.Run(fmt.Sprintf("%s rename %s %s", client.Runtime().CLI, oldName, newName))
+	if err != nil {
+		log.WithFields(log.Fields{"build": tn.TestNetID, "server": serverID, "container": oldName,
+			"error": err}).Warn("unable to rename container")
+	}
+}
+
+// GetNetworkNames gets the names the docker network of the node with the given relative number can have.
+// Docker networks cannot be renamed, so unlike the containers, the networks of a testnet created before
+// the networks were scoped are not migrated and may still have their legacy names.
+func (tn *TestNet) GetNetworkNames(node int) []string {
+	if tn.ScopedNetworks {
+		return []string{util.GetNetworkName(tn.TestNetID, node)}
+	}
+	return []string{util.GetNetworkName(tn.TestNetID, node), util.GetLegacyNetworkName(node)}
+}
@@ -0,0 +1,65 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package testnet
+
+import (
+	"fmt"
+)
+
+// ServiceDetails records where a service container of the testnet is running and how to reach it
+type ServiceDetails struct {
+	// Name is the name of the service, as declared by the blockchain
+	Name string `json:"name"`
+	// Image is the docker image of the service
+	Image string `json:"image"`
+	// Container is the name of the service container
+	Container string `json:"container"`
+	// Server is the id of the server the container is running on
+	Server int `json:"server"`
+	// IP is the ip address of the container on its network. Empty if the service runs on a custom network.
+	IP string `json:"ip,omitempty"`
+	// Ports are the ports published on the server by the container, in the form hostPort:containerPort
+	Ports []string `json:"ports,omitempty"`
+}
+
+// AddService records a service container which has been started for the testnet
+func (tn *TestNet) AddService(service ServiceDetails) {
+	tn.mux.Lock()
+	defer tn.mux.Unlock()
+	tn.Services = append(tn.Services, service)
+}
+
+// ClearServices forgets all of the service containers of the testnet
+func (tn *TestNet) ClearServices() {
+	tn.mux.Lock()
+	defer tn.mux.Unlock()
+	tn.Services = []ServiceDetails{}
+}
+
+// GetService gets the details of the first container of the service with the given name
+func (tn *TestNet) GetService(name string) (ServiceDetails, error) {
+	tn.mux.RLock()
+	defer tn.mux.RUnlock()
+	for _, service := range tn.Services {
+		if service.Name == name {
+			return service, nil
+		}
+	}
+	return ServiceDetails{}, fmt.Errorf("service \"%s\" not found", name)
+}
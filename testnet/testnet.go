@@ -24,9 +24,13 @@ import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/keys"
+	"github.com/whiteblock/genesis/kubernetes"
+	"github.com/whiteblock/genesis/process"
 	"github.com/whiteblock/genesis/ssh"
 	"github.com/whiteblock/genesis/state"
 	"github.com/whiteblock/genesis/status"
+	"github.com/whiteblock/genesis/topology"
 	"sync"
 )
 
@@ -45,6 +49,9 @@ type TestNet struct {
 
 	NewlyBuiltSideCars [][]db.SideCar
 
+	// Services contains the service containers which are running for the testnet
+	Services []ServiceDetails
+
 	// Clients is a map of server ids to ssh clients
 	Clients map[int]ssh.Client `json:"-"`
 	// BuildState is the build state for the test net
@@ -55,13 +62,22 @@ type TestNet struct {
 	CombinedDetails db.DeploymentDetails
 	// LDD is a pointer to latest deployment details
 	LDD *db.DeploymentDetails `json:"-"`
-	mux *sync.RWMutex
+	// DB is the database the testnet and its nodes are stored in
+	DB db.Store `json:"-"`
+	// ScopedNames indicates whether the containers of this testnet are named using the testnet id.
+	// Testnets created before the scoped naming scheme will have this set to false.
+	ScopedNames bool
+	// ScopedNetworks indicates whether the docker networks of this testnet are named using the testnet id.
+	// Testnets created before the networks were scoped will have this set to false.
+	ScopedNetworks bool
+	mux            *sync.RWMutex
 }
 
 // RestoreTestNet fetches a testnet which already exists.
 func RestoreTestNet(buildID string) (*TestNet, error) {
 	out := new(TestNet)
-	err := db.GetMetaP("testnet_"+buildID, out)
+	out.DB = db.NewStore()
+	err := out.DB.GetMetaP("testnet_"+buildID, out)
 	if err != nil {
 		log.WithFields(log.Fields{"build": buildID}).Error("failed to restore the testnet")
 		return nil, err
@@ -74,25 +90,54 @@ func RestoreTestNet(buildID string) (*TestNet, error) {
 	out.BuildState = bs
 	out.mux = &sync.RWMutex{}
 	out.LDD = out.GetLastestDeploymentDetails()
+	out.setStagingDirs()
 
-	out.Clients = map[int]ssh.Client{}
-	for _, server := range out.Servers {
-		out.Clients[server.ID], err = status.GetClient(server.ID)
-		if err != nil {
-			log.WithFields(log.Fields{"build": buildID, "server": server.ID}).Error("failed to get ssh connection")
-			out.BuildState.ReportError(err)
-			return nil, err
-		}
+	err = out.openClients()
+	if err != nil {
+		return nil, err
+	}
+	if !out.ScopedNames {
+		out.migrateContainerNames()
 	}
 	return out, nil
 }
 
 // NewTestNet creates a new TestNet
 func NewTestNet(details db.DeploymentDetails, buildID string) (*TestNet, error) {
+	out, err := newTestNet(details, buildID, db.NewStore())
+	if err != nil {
+		return nil, err
+	}
+
+	//OPEN UP THE RELEVANT SSH CONNECTIONS
+	err = out.openClients()
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NewTestNetWithClients creates a new TestNet which uses the given store and clients, indexed
+// by server id, instead of the database and connections to its servers, such as mocks in tests.
+// The build must have been acquired with state.AcquireBuilding.
+func NewTestNetWithClients(details db.DeploymentDetails, buildID string, store db.Store,
+	clients map[int]ssh.Client) (*TestNet, error) {
+	out, err := newTestNet(details, buildID, store)
+	if err != nil {
+		return nil, err
+	}
+	out.Clients = clients
+	return out, nil
+}
+
+func newTestNet(details db.DeploymentDetails, buildID string, store db.Store) (*TestNet, error) {
 	var err error
 	out := new(TestNet)
 
 	out.TestNetID = buildID
+	out.DB = store
+	out.ScopedNames = true
+	out.ScopedNetworks = true
 	out.Nodes = []db.Node{}
 	out.NewlyBuiltNodes = []db.Node{}
 	out.Details = []db.DeploymentDetails{details}
@@ -107,26 +152,100 @@ func NewTestNet(details db.DeploymentDetails, buildID string) (*TestNet, error)
 	}
 
 	// FETCH THE SERVERS
-	out.Servers, err = db.GetServers(details.Servers)
+	out.Servers, err = out.DB.GetServers(details.Servers)
 	if err != nil {
 		log.WithFields(log.Fields{"build": buildID}).Error("failed to fetch the servers")
 		out.BuildState.ReportError(err)
 		return nil, err
 	}
 	log.WithFields(log.Fields{"build": buildID}).Trace("fetched the servers")
+	out.setStagingDirs()
+	return out, nil
+}
 
-	//OPEN UP THE RELEVANT SSH CONNECTIONS
-	out.Clients = map[int]ssh.Client{}
+// setStagingDirs gives the build state the staging directories of the servers
+func (tn *TestNet) setStagingDirs() {
+	for _, server := range tn.Servers {
+		tn.BuildState.SetStagingDir(server.ID, server.StagingDir)
+	}
+}
+
+// GetKubernetesConfig gets the kubernetes configuration of the testnet, which is decided by
+// its first deployment
+func (tn *TestNet) GetKubernetesConfig() (kubernetes.Config, error) {
+	if len(tn.Details) == 0 {
+		return kubernetes.GetConfig(nil)
+	}
+	return kubernetes.GetConfig(&tn.Details[0])
+}
+
+// GetProcessConfig gets the process configuration of the testnet, which is decided by its first deployment
+func (tn *TestNet) GetProcessConfig() (process.Config, error) {
+	if len(tn.Details) == 0 {
+		return process.GetConfig(nil)
+	}
+	return process.GetConfig(&tn.Details[0])
+}
+
+// Keys gets the deriver of the keys of the testnet, which derives them from the seed of its
+// first deployment, so that nodes added later get keys from the same seed
+func (tn *TestNet) Keys() *keys.Deriver {
+	if len(tn.Details) == 0 {
+		return keys.NewDeriver("")
+	}
+	return keys.NewDeriver(tn.Details[0].Seed)
+}
+
+// GetTopology gets the peering topology of the testnet, which is decided by its first deployment
+func (tn *TestNet) GetTopology() (topology.Topology, error) {
+	if len(tn.Details) == 0 {
+		return topology.Get(nil)
+	}
+	return topology.Get(&tn.Details[0])
+}
+
+// GetGraph gets the peering graph over all of the nodes of the testnet, as the sorted absolute
+// numbers of the peers of each node. Nodes added later are placed in a graph recomputed over all
+// of the nodes.
+func (tn *TestNet) GetGraph() ([][]int, error) {
+	top, err := tn.GetTopology()
+	if err != nil {
+		return nil, err
+	}
+	return top.Graph(len(tn.Nodes))
+}
 
-	for _, server := range out.Servers {
-		out.Clients[server.ID], err = status.GetClient(server.ID)
+// openClients gets a client for each server of the testnet. For a testnet on kubernetes, the clients
+// go through kubectl instead of ssh, and for a testnet whose nodes run as processes, the ssh clients are
+// wrapped to run the commands for the nodes within them.
+func (tn *TestNet) openClients() error {
+	cfg, err := tn.GetKubernetesConfig()
+	if err != nil {
+		tn.BuildState.ReportError(err)
+		return err
+	}
+	pcfg, err := tn.GetProcessConfig()
+	if err != nil {
+		tn.BuildState.ReportError(err)
+		return err
+	}
+	tn.Clients = map[int]ssh.Client{}
+	for _, server := range tn.Servers {
+		if cfg.Enabled {
+			tn.Clients[server.ID] = kubernetes.NewClient(cfg, server.ID)
+			continue
+		}
+		tn.Clients[server.ID], err = status.GetClient(server.ID)
 		if err != nil {
-			log.WithFields(log.Fields{"build": buildID, "server": server.ID}).Error("failed to get ssh connection")
-			out.BuildState.ReportError(err)
-			return nil, err
+			log.WithFields(log.Fields{"build": tn.TestNetID, "server": server.ID}).Error("failed to get ssh connection")
+			tn.BuildState.ReportError(err)
+			return err
+		}
+		if pcfg.Enabled {
+			tn.Clients[server.ID] = process.NewClient(pcfg, server.ID, tn.Clients[server.ID])
 		}
 	}
-	return out, nil
+	return nil
 }
 
 // AddNode adds a node to the testnet and returns a pointer to that node.
@@ -139,16 +258,27 @@ func (tn *TestNet) AddNode(node db.Node) *db.Node {
 		node.Image = tn.LDD.Images[node.AbsoluteNum]
 		log.WithFields(log.Fields{"image": node.Image, "node": node.AbsoluteNum}).Trace("using given image")
 	}
+	startup := db.GetStartup(tn.LDD.Startup, node.AbsoluteNum)
+	node.Entrypoint = startup.Entrypoint
+	node.Command = startup.Command
+	node.WorkDir = startup.WorkDir
 	log.WithFields(log.Fields{"node": node}).Debug("adding a node")
 	tn.NewlyBuiltNodes = append(tn.NewlyBuiltNodes, node)
 	tn.Nodes = append(tn.Nodes, node)
 	return &tn.NewlyBuiltNodes[len(tn.NewlyBuiltNodes)-1]
 }
 
-// AddSideCar adds a side car to the testnet
+// AddSideCar adds a side car to the testnet, and adds its type to the side cars of the node it belongs to
 func (tn *TestNet) AddSideCar(node db.SideCar, index int) {
 	tn.mux.Lock()
 	defer tn.mux.Unlock()
+	for _, nodes := range [][]db.Node{tn.Nodes, tn.NewlyBuiltNodes} {
+		for i := range nodes {
+			if nodes[i].ID == node.NodeID {
+				nodes[i].SideCars = append(nodes[i].SideCars, node.Type)
+			}
+		}
+	}
 	if len(tn.NewlyBuiltSideCars) <= index {
 		tn.NewlyBuiltSideCars = append(tn.NewlyBuiltSideCars, []db.SideCar{node})
 	} else {
@@ -300,7 +430,7 @@ func (tn *TestNet) PreOrderNewNodes(sidecar bool) map[int][]ssh.Node {
 
 // Store stores the TestNets data for later retrieval
 func (tn *TestNet) Store() {
-	db.SetMeta("testnet_"+tn.TestNetID, *tn)
+	tn.DB.SetMeta("testnet_"+tn.TestNetID, *tn)
 }
 
 // UpdateAllImages switches all of the nodes to the given docker
@@ -316,7 +446,7 @@ func (tn *TestNet) UpdateAllImages(newImage string) {
 
 // Destroy removes all the testnets data
 func (tn *TestNet) Destroy() error {
-	return db.DeleteMeta("testnet_" + tn.TestNetID)
+	return tn.DB.DeleteMeta("testnet_" + tn.TestNetID)
 }
 
 // StoreNodes stores the newly built nodes into the database with their labels.
@@ -324,7 +454,7 @@ func (tn *TestNet) StoreNodes() error {
 	var err error
 	for _, node := range tn.NewlyBuiltNodes {
 		log.WithFields(log.Fields{"node": node}).Debug("storing a node")
-		_, er := db.InsertNode(node)
+		_, er := tn.DB.InsertNode(node)
 		if er != nil {
 			log.WithFields(log.Fields{"build": tn.TestNetID, "error": er,
 				"node": node.ID}).Error("failed to store a node into db")
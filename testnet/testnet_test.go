@@ -0,0 +1,111 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package testnet
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/whiteblock/genesis/db"
+	dbmocks "github.com/whiteblock/genesis/db/mocks"
+	"github.com/whiteblock/genesis/ssh"
+	"github.com/whiteblock/genesis/ssh/mocks"
+	"github.com/whiteblock/genesis/state"
+	"github.com/whiteblock/genesis/util"
+)
+
+func newMockTestNet(t *testing.T, ctrl *gomock.Controller, buildID string) (*TestNet, *dbmocks.MockStore) {
+	store := dbmocks.NewMockStore(ctrl)
+	store.EXPECT().GetServers([]int{1}).Return([]db.Server{{ID: 1, Addr: "10.0.0.1"}}, nil)
+
+	err := state.AcquireBuilding([]int{1}, buildID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tn, err := NewTestNetWithClients(db.DeploymentDetails{Servers: []int{1}, Nodes: 2, Images: []string{"alpine"}},
+		buildID, store, map[int]ssh.Client{1: mocks.NewMockClient(ctrl)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tn, store
+}
+
+func TestTestNet_StoreNodes(t *testing.T) {
+	var test = []struct {
+		err   error
+		valid bool
+	}{
+		{err: nil, valid: true},
+		{err: fmt.Errorf("database is locked"), valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			buildID := "store-nodes-" + strconv.Itoa(i)
+			tn, store := newMockTestNet(t, ctrl, buildID)
+			defer state.ForceUnlockServers([]int{1})
+			for j := 0; j < 2; j++ {
+				node := tn.AddNode(db.Node{TestNetID: buildID, Server: 1, LocalID: j})
+				store.EXPECT().InsertNode(*node).Return(j, tt.err)
+			}
+
+			err := tn.StoreNodes()
+			if (err == nil) != tt.valid {
+				t.Errorf("unexpected result of StoreNodes: %v", err)
+			}
+		})
+	}
+}
+
+func TestTestNet_Destroy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tn, store := newMockTestNet(t, ctrl, "destroy")
+	defer state.ForceUnlockServers([]int{1})
+	store.EXPECT().DeleteMeta("testnet_destroy").Return(nil)
+
+	if err := tn.Destroy(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTestNet_GetNetworkNames(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	util.GetConfig().NodeNetworkPrefix = "wb_vlan"
+	tn, _ := newMockTestNet(t, ctrl, "4ac9d3b2-network-names")
+	defer state.ForceUnlockServers([]int{1})
+
+	names := tn.GetNetworkNames(3)
+	if len(names) != 1 || names[0] != "wb_vlan4ac9d3b2-3" {
+		t.Errorf("unexpected network names for a new testnet: %v", names)
+	}
+
+	tn.ScopedNetworks = false
+	names = tn.GetNetworkNames(3)
+	if len(names) != 2 || names[0] != "wb_vlan4ac9d3b2-3" || names[1] != "wb_vlan3" {
+		t.Errorf("unexpected network names for a testnet created before the networks were scoped: %v", names)
+	}
+}
@@ -0,0 +1,195 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+// Package topology computes the peering graph of a testnet, which restricts each node to peering with,
+// and being able to reach, only its neighbors in the graph.
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/keys"
+	"github.com/whiteblock/genesis/util"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// Full is the default topology, in which every node peers with every other node
+	Full = "full"
+	// Ring connects each node to the nodes before and after it
+	Ring = "ring"
+	// Star connects every node to the hub, and only to the hub
+	Star = "star"
+	// Regular connects each node to degree other nodes, chosen at random
+	Regular = "regular"
+	// Random is an Erdős–Rényi graph, in which each pair of nodes is connected with the given probability
+	Random = "random"
+)
+
+// Topology describes the peering graph of a testnet, given in the extras of the deployment
+// details under "topology"
+type Topology struct {
+	// Type is the kind of graph, one of full, ring, star, regular and random
+	Type string `json:"type"`
+	// Degree is the number of peers of each node, for a regular graph
+	Degree int `json:"degree"`
+	// Probability is the chance of any two nodes being peers, for a random graph
+	Probability float64 `json:"probability"`
+	// Hub is the absolute number of the center node of a star
+	Hub int `json:"hub"`
+	// Seed seeds the randomness of the regular and random graphs, so that the same graph is
+	// computed every time. Defaults to a number derived from the seed of the deployment.
+	Seed int64 `json:"seed"`
+}
+
+// Validate checks the parameters of the topology which do not depend on the number of nodes
+func (top Topology) Validate() error {
+	switch top.Type {
+	case Full, Ring:
+	case Star:
+		if top.Hub < 0 {
+			return fmt.Errorf("hub cannot be negative")
+		}
+	case Regular:
+		if top.Degree < 1 {
+			return fmt.Errorf("degree must be positive")
+		}
+	case Random:
+		if top.Probability < 0 || top.Probability > 1 {
+			return fmt.Errorf("probability must be between 0 and 1")
+		}
+	default:
+		return fmt.Errorf("unknown topology type \"%s\"", top.Type)
+	}
+	return nil
+}
+
+// Check checks that a graph of the topology can be made with the given number of nodes
+func (top Topology) Check(nodes int) error {
+	switch top.Type {
+	case Star:
+		if top.Hub >= nodes {
+			return fmt.Errorf("the hub %d does not exist with %d nodes", top.Hub, nodes)
+		}
+	case Regular:
+		if top.Degree >= nodes {
+			return fmt.Errorf("degree %d is too large for %d nodes", top.Degree, nodes)
+		}
+		if (top.Degree*nodes)%2 != 0 {
+			return fmt.Errorf("an odd degree requires an even number of nodes")
+		}
+	}
+	return nil
+}
+
+// Graph computes the adjacency list of the topology over the given number of nodes. The graph is
+// undirected, so j is in graph[i] if and only if i is in graph[j], and each list is sorted.
+func (top Topology) Graph(nodes int) ([][]int, error) {
+	err := top.Check(nodes)
+	if err != nil {
+		return nil, err
+	}
+	edges := make([]map[int]bool, nodes)
+	for i := range edges {
+		edges[i] = map[int]bool{}
+	}
+	connect := func(i, j int) {
+		if i == j {
+			return
+		}
+		edges[i][j] = true
+		edges[j][i] = true
+	}
+	rng := rand.New(rand.NewSource(top.Seed))
+
+	switch top.Type {
+	case Full:
+		for i := 0; i < nodes; i++ {
+			for j := i + 1; j < nodes; j++ {
+				connect(i, j)
+			}
+		}
+	case Ring:
+		for i := 0; i < nodes; i++ {
+			connect(i, (i+1)%nodes)
+		}
+	case Star:
+		for i := 0; i < nodes; i++ {
+			connect(top.Hub, i)
+		}
+	case Regular:
+		// a circulant graph over a random ordering of the nodes
+		order := rng.Perm(nodes)
+		for i := 0; i < nodes; i++ {
+			for k := 1; k <= top.Degree/2; k++ {
+				connect(order[i], order[(i+k)%nodes])
+			}
+			if top.Degree%2 != 0 {
+				connect(order[i], order[(i+nodes/2)%nodes])
+			}
+		}
+	case Random:
+		for i := 0; i < nodes; i++ {
+			for j := i + 1; j < nodes; j++ {
+				if rng.Float64() < top.Probability {
+					connect(i, j)
+				}
+			}
+		}
+	}
+
+	out := make([][]int, nodes)
+	for i, peers := range edges {
+		out[i] = []int{}
+		for peer := range peers {
+			out[i] = append(out[i], peer)
+		}
+		sort.Ints(out[i])
+	}
+	return out, nil
+}
+
+// Get gets the topology from the given deployment details. If none is given, the topology
+// is full. Unless the topology has a seed of its own, its graph is seeded from the seed of the deployment.
+func Get(details *db.DeploymentDetails) (Topology, error) {
+	out := Topology{Type: Full}
+	if details == nil {
+		return out, nil
+	}
+	raw, ok := details.Extras["topology"]
+	if !ok {
+		return out, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return out, util.LogError(err)
+	}
+	err = json.Unmarshal(data, &out)
+	if err != nil {
+		return out, fmt.Errorf("invalid topology: %s", err.Error())
+	}
+	var given struct {
+		Seed *int64 `json:"seed"`
+	}
+	json.Unmarshal(data, &given)
+	if given.Seed == nil && len(details.Seed) > 0 {
+		out.Seed = keys.NewDeriver(details.Seed).Int64("topology")
+	}
+	return out, out.Validate()
+}
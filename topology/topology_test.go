@@ -0,0 +1,152 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package topology
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/keys"
+)
+
+func TestTopology_Graph(t *testing.T) {
+	var test = []struct {
+		top      Topology
+		nodes    int
+		expected [][]int
+	}{
+		{top: Topology{Type: Full}, nodes: 3, expected: [][]int{{1, 2}, {0, 2}, {0, 1}}},
+		{top: Topology{Type: Ring}, nodes: 4, expected: [][]int{{1, 3}, {0, 2}, {1, 3}, {0, 2}}},
+		{top: Topology{Type: Ring}, nodes: 2, expected: [][]int{{1}, {0}}},
+		{top: Topology{Type: Ring}, nodes: 1, expected: [][]int{{}}},
+		{top: Topology{Type: Star, Hub: 1}, nodes: 4, expected: [][]int{{1}, {0, 2, 3}, {1}, {1}}},
+		{top: Topology{Type: Random, Probability: 0}, nodes: 3, expected: [][]int{{}, {}, {}}},
+		{top: Topology{Type: Random, Probability: 1}, nodes: 3, expected: [][]int{{1, 2}, {0, 2}, {0, 1}}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			graph, err := tt.top.Graph(tt.nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(graph, tt.expected) {
+				t.Errorf("return value of Graph %v does not match expected value %v", graph, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTopology_Graph_Random(t *testing.T) {
+	var test = []struct {
+		top   Topology
+		nodes int
+	}{
+		{top: Topology{Type: Regular, Degree: 2, Seed: 1}, nodes: 7},
+		{top: Topology{Type: Regular, Degree: 3, Seed: 2}, nodes: 10},
+		{top: Topology{Type: Regular, Degree: 4, Seed: 3}, nodes: 9},
+		{top: Topology{Type: Random, Probability: 0.3, Seed: 4}, nodes: 20},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			graph, err := tt.top.Graph(tt.nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for node, peers := range graph {
+				if tt.top.Type == Regular && len(peers) != tt.top.Degree {
+					t.Errorf("node %d has %d peers instead of %d", node, len(peers), tt.top.Degree)
+				}
+				for _, peer := range peers {
+					if peer == node {
+						t.Errorf("node %d peers with itself", node)
+					}
+					found := false
+					for _, back := range graph[peer] {
+						found = found || back == node
+					}
+					if !found {
+						t.Errorf("node %d peers with %d, but not the other way around", node, peer)
+					}
+				}
+			}
+			again, err := tt.top.Graph(tt.nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(graph, again) {
+				t.Error("the same seed gave a different graph")
+			}
+		})
+	}
+}
+
+func TestTopology_Check(t *testing.T) {
+	var test = []struct {
+		top   Topology
+		nodes int
+		valid bool
+	}{
+		{top: Topology{Type: Star, Hub: 2}, nodes: 3, valid: true},
+		{top: Topology{Type: Star, Hub: 3}, nodes: 3, valid: false},
+		{top: Topology{Type: Regular, Degree: 3}, nodes: 3, valid: false},
+		{top: Topology{Type: Regular, Degree: 3}, nodes: 7, valid: false},
+		{top: Topology{Type: Regular, Degree: 3}, nodes: 8, valid: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.top.Check(tt.nodes)
+			if (err == nil) != tt.valid {
+				t.Errorf("unexpected result of Check: %v", err)
+			}
+		})
+	}
+}
+
+func TestGet_Seed(t *testing.T) {
+	details := func(seed string, top map[string]interface{}) *db.DeploymentDetails {
+		return &db.DeploymentDetails{Seed: seed, Extras: map[string]interface{}{"topology": top}}
+	}
+	var test = []struct {
+		details  *db.DeploymentDetails
+		expected int64
+	}{
+		{details: details("", map[string]interface{}{"type": Regular, "degree": 2}), expected: 0},
+		{details: details("", map[string]interface{}{"type": Regular, "degree": 2, "seed": 7}), expected: 7},
+		{details: details("test seed", map[string]interface{}{"type": Regular, "degree": 2, "seed": 7}), expected: 7},
+		{details: details("test seed", map[string]interface{}{"type": Regular, "degree": 2, "seed": 0}), expected: 0},
+		{details: details("test seed", map[string]interface{}{"type": Regular, "degree": 2}),
+			expected: keys.NewDeriver("test seed").Int64("topology")},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			top, err := Get(tt.details)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if top.Seed != tt.expected {
+				t.Errorf("the topology has the seed %d instead of %d", top.Seed, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Status            otlpStatus      `json:"status"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOk         = 1
+	otlpStatusError      = 2
+)
+
+func toAttributes(attributes map[string]string) []otlpAttribute {
+	out := []otlpAttribute{}
+	for key, value := range attributes {
+		out = append(out, otlpAttribute{Key: key, Value: otlpValue{StringValue: value}})
+	}
+	return out
+}
+
+// toOTLP converts the trace into the JSON encoding of an OTLP ExportTraceServiceRequest
+func (trace *Trace) toOTLP() map[string]interface{} {
+	trace.mux.Lock()
+	defer trace.mux.Unlock()
+	spans := []otlpSpan{}
+	for _, span := range trace.Spans {
+		end := span.End
+		if end.IsZero() {
+			end = time.Now()
+		}
+		status := otlpStatus{Code: otlpStatusOk}
+		if len(span.Error) > 0 {
+			status = otlpStatus{Code: otlpStatusError, Message: span.Error}
+		}
+		spans = append(spans, otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentID,
+			Name:              span.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+			Attributes:        toAttributes(span.Attributes),
+			Status:            status,
+		})
+	}
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": toAttributes(map[string]string{"service.name": "genesis"}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]string{"name": "github.com/whiteblock/genesis"},
+						"spans": spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+// export sends the trace to the configured OTLP/HTTP endpoint, such as http://localhost:4318/v1/traces
+func export(trace *Trace) error {
+	data, err := json.Marshal(trace.toOTLP())
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(conf.TracingEndpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("trace collector responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
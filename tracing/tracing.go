@@ -0,0 +1,200 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package tracing records the stages and remote commands of each build as a trace, made up of timed spans,
+// which can be exported to any collector supporting OTLP over HTTP, such as Jaeger.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/util"
+	"sync"
+	"time"
+)
+
+var conf = util.GetConfig()
+
+// maxStoredTraces is the number of finished traces which are kept in memory
+const maxStoredTraces = 20
+
+// Span represents a single timed operation within a build
+type Span struct {
+	TraceID    string            `json:"traceId"`
+	SpanID     string            `json:"spanId"`
+	ParentID   string            `json:"parentSpanId,omitempty"`
+	Name       string            `json:"name"`
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	trace      *Trace
+}
+
+// Trace contains all of the spans of a single build
+type Trace struct {
+	ID      string  `json:"id"`
+	BuildID string  `json:"build"`
+	Spans   []*Span `json:"spans"`
+	root    *Span
+	stage   *Span
+	mux     sync.Mutex
+}
+
+var (
+	traces   = map[string]*Trace{}
+	finished = []string{}
+	mux      = sync.RWMutex{}
+)
+
+func randomID(size int) string {
+	buf := make([]byte, size)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func (trace *Trace) newSpan(parent *Span, name string, attributes map[string]string) *Span {
+	span := &Span{
+		TraceID:    trace.ID,
+		SpanID:     randomID(8),
+		Name:       name,
+		Start:      time.Now(),
+		Attributes: attributes,
+		trace:      trace,
+	}
+	if parent != nil {
+		span.ParentID = parent.SpanID
+	}
+	trace.mux.Lock()
+	trace.Spans = append(trace.Spans, span)
+	trace.mux.Unlock()
+	return span
+}
+
+// Finish marks the span as completed. If err is non-nil, the span will be marked as failed.
+// It is safe to call on a nil span.
+func (span *Span) Finish(err error) {
+	if span == nil {
+		return
+	}
+	span.trace.mux.Lock()
+	defer span.trace.mux.Unlock()
+	if !span.End.IsZero() {
+		return
+	}
+	span.End = time.Now()
+	if err != nil {
+		span.Error = err.Error()
+	}
+}
+
+// Duration gets how long the span took, or has taken so far if it has not yet finished
+func (span *Span) Duration() time.Duration {
+	if span.End.IsZero() {
+		return time.Since(span.Start)
+	}
+	return span.End.Sub(span.Start)
+}
+
+func getTrace(buildID string) *Trace {
+	mux.RLock()
+	defer mux.RUnlock()
+	return traces[buildID]
+}
+
+// StartBuild begins a new trace for the given build. Does nothing if tracing is disabled.
+func StartBuild(buildID string) {
+	if !conf.EnableTracing {
+		return
+	}
+	trace := &Trace{ID: randomID(16), BuildID: buildID, Spans: []*Span{}}
+	trace.root = trace.newSpan(nil, "build", map[string]string{"build": buildID})
+
+	mux.Lock()
+	defer mux.Unlock()
+	traces[buildID] = trace
+	for i, id := range finished {
+		if id == buildID {
+			finished = append(finished[:i], finished[i+1:]...)
+			break
+		}
+	}
+}
+
+// SetStage finishes the span of the current stage of the build, and starts a span for the given stage
+func SetStage(buildID string, stage string) {
+	trace := getTrace(buildID)
+	if trace == nil || !trace.root.End.IsZero() {
+		return
+	}
+	trace.stage.Finish(nil)
+	trace.stage = trace.newSpan(trace.root, stage, nil)
+}
+
+// StartSpan starts a new span within the current stage of the given build. The returned span
+// will be nil if the build is not being traced.
+func StartSpan(buildID string, name string, attributes map[string]string) *Span {
+	trace := getTrace(buildID)
+	if trace == nil || !trace.root.End.IsZero() {
+		return nil
+	}
+	parent := trace.stage
+	if parent == nil {
+		parent = trace.root
+	}
+	return trace.newSpan(parent, name, attributes)
+}
+
+// FinishBuild completes the trace for the given build, and exports it if an exporter is configured
+func FinishBuild(buildID string, err error) {
+	trace := getTrace(buildID)
+	if trace == nil || !trace.root.End.IsZero() {
+		return
+	}
+	trace.stage.Finish(err)
+	trace.root.Finish(err)
+
+	mux.Lock()
+	finished = append(finished, buildID)
+	if len(finished) > maxStoredTraces {
+		delete(traces, finished[0])
+		finished = finished[1:]
+	}
+	mux.Unlock()
+
+	if len(conf.TracingEndpoint) == 0 {
+		return
+	}
+	go func() {
+		err := export(trace)
+		if err != nil {
+			log.WithFields(log.Fields{"build": buildID, "error": err}).Error("failed to export the trace")
+		}
+	}()
+}
+
+// GetTrace gets the trace of the given build
+func GetTrace(buildID string) (*Trace, error) {
+	trace := getTrace(buildID)
+	if trace == nil {
+		return nil, fmt.Errorf("no trace found for build \"%s\"", buildID)
+	}
+	return trace, nil
+}
@@ -0,0 +1,88 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package tracing
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	conf.EnableTracing = true
+	defer func() { conf.EnableTracing = false }()
+
+	StartBuild("test")
+	StartSpan("test", "ssh", map[string]string{"command": "ls"}).Finish(nil)
+	SetStage("test", "Provisioning Nodes")
+	span := StartSpan("test", "ssh", map[string]string{"command": "false"})
+	span.Finish(fmt.Errorf("exit status 1"))
+	SetStage("test", "Starting")
+	FinishBuild("test", nil)
+
+	trace, err := GetTrace("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var test = []struct {
+		name   string
+		parent int
+		err    bool
+	}{
+		{name: "build", parent: -1, err: false},
+		{name: "ssh", parent: 0, err: false},
+		{name: "Provisioning Nodes", parent: 0, err: false},
+		{name: "ssh", parent: 2, err: true},
+		{name: "Starting", parent: 0, err: false},
+	}
+	if len(trace.Spans) != len(test) {
+		t.Fatalf("expected %d spans, got %d", len(test), len(trace.Spans))
+	}
+	for i, tt := range test {
+		span := trace.Spans[i]
+		if span.Name != tt.name {
+			t.Errorf("span %d: expected name \"%s\", got \"%s\"", i, tt.name, span.Name)
+		}
+		if tt.parent == -1 && span.ParentID != "" {
+			t.Errorf("span %d: expected no parent", i)
+		}
+		if tt.parent != -1 && span.ParentID != trace.Spans[tt.parent].SpanID {
+			t.Errorf("span %d: expected parent %d", i, tt.parent)
+		}
+		if span.End.IsZero() {
+			t.Errorf("span %d: was not finished", i)
+		}
+		if (len(span.Error) > 0) != tt.err {
+			t.Errorf("span %d: unexpected error \"%s\"", i, span.Error)
+		}
+	}
+
+	if StartSpan("test", "ssh", nil) != nil {
+		t.Errorf("should not be able to start a span in a finished trace")
+	}
+	if _, err := GetTrace("missing"); err == nil {
+		t.Errorf("expected an error for a build without a trace")
+	}
+}
+
+func TestStartBuild_Disabled(t *testing.T) {
+	StartBuild("disabled")
+	if StartSpan("disabled", "ssh", nil) != nil {
+		t.Errorf("spans should not be recorded when tracing is disabled")
+	}
+}
@@ -0,0 +1,83 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package util
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteTarGz(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	contents := map[string]string{"0": `{"alloc":{}}`, "1": "[Peers]\n", "2": ""}
+	names := []string{"0", "1", "2"}
+	srcs := []string{}
+	for _, name := range names {
+		src := filepath.Join(dir, "src"+name)
+		err = ioutil.WriteFile(src, []byte(contents[name]), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcs = append(srcs, src)
+	}
+	dest := filepath.Join(dir, "out.tar.gz")
+	err = WriteTarGz(dest, names, srcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in, err := os.Open(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	out := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[hdr.Name] = string(data)
+	}
+	if !reflect.DeepEqual(out, contents) {
+		t.Errorf("contents of the tarball %v do not match expected value %v", out, contents)
+	}
+
+	if WriteTarGz(dest, names[:1], srcs) == nil {
+		t.Error("return value of WriteTarGz did not match expected value")
+	}
+}
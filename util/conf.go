@@ -51,16 +51,85 @@ type Config struct {
 	MaxNodes                int     `mapstructure:"maxNodes"`
 	MaxNodeMemory           string  `mapstructure:"maxNodeMemory"`
 	MaxNodeCPU              float64 `mapstructure:"maxNodeCpu"`
+	NodeDiskSize            string  `mapstructure:"nodeDiskSize"`
 	BridgePrefix            string  `mapstructure:"bridgePrefix"`
 	APIEndpoint             string  `mapstructure:"apiEndpoint"`
 	NibblerEndPoint         string  `mapstructure:"nibblerEndPoint"`
 	LogJSON                 bool    `mapstructure:"logJson"`
+	LogFile                 string  `mapstructure:"logFile"`
+	LogReportCaller         bool    `mapstructure:"logReportCaller"`
 	PrometheusConfig        string  `mapstructure:"prometheusConfig"`
 	PrometheusPort          int     `mapstructure:"prometheusPort"`
 	GanacheCLIOptions       string  `mapstructure:"ganacheCLIOptions"`
 	GanacheRPCPort          int     `mapstructure:"ganacheRPCPort"`
+	ExplorerPort            int     `mapstructure:"explorerPort"`
+	CAdvisorPort            int     `mapstructure:"cadvisorPort"`
+	GrafanaPort             int     `mapstructure:"grafanaPort"`
+	GrafanaProvisioning     string  `mapstructure:"grafanaProvisioning"`
+	HealthCheckTimeout      int     `mapstructure:"healthCheckTimeout"`
+	ThreadLimit             int     `mapstructure:"threadLimit"`
+	BuildRetries            int     `mapstructure:"buildRetries"`
+	LocalBackend            bool    `mapstructure:"localBackend"`
+	ContainerRuntime        string  `mapstructure:"containerRuntime"`
+	Kubectl                 string  `mapstructure:"kubectl"`
+	KubeNamespace           string  `mapstructure:"kubeNamespace"`
+	KubeNetemImage          string  `mapstructure:"kubeNetemImage"`
+	KubeReadyTimeout        int     `mapstructure:"kubeReadyTimeout"`
+	Secrets                 bool    `mapstructure:"secrets"`
+	SecretsKey              string  `mapstructure:"secretsKey"`
+	SecretsKeyCommand       string  `mapstructure:"secretsKeyCommand"`
+	EnableShell             bool    `mapstructure:"enableShell"`
+	ShellAuditLog           string  `mapstructure:"shellAuditLog"`
+	ArtifactsDir            string  `mapstructure:"artifactsDir"`
+	CaptureImage            string  `mapstructure:"captureImage"`
+	MaxCaptureDuration      int     `mapstructure:"maxCaptureDuration"`
+	MaxCaptureSize          int     `mapstructure:"maxCaptureSize"`
+	TrafficSampleInterval   int     `mapstructure:"trafficSampleInterval"`
 	MaxRunAttempts          int     `mapstructure:"maxRunAttempts"`
 	MaxConnections          int     `mapstructure:"maxConnections"`
+	SSHPoolMin              int     `mapstructure:"sshPoolMin"`
+	SSHPoolMax              int     `mapstructure:"sshPoolMax"`
+	SSHPoolIdleTimeout      int     `mapstructure:"sshPoolIdleTimeout"`
+	SSHMaxSessions          int     `mapstructure:"sshMaxSessions"`
+	Simulator               bool    `mapstructure:"simulator"`
+	SimulatorRules          string  `mapstructure:"simulatorRules"`
+	CompressTransfers       bool    `mapstructure:"compressTransfers"`
+	EnableRsync             bool    `mapstructure:"enableRsync"`
+	RemoteCacheDir          string  `mapstructure:"remoteCacheDir"`
+	RemoteCacheMaxAge       int     `mapstructure:"remoteCacheMaxAge"`
+	StagingDir              string  `mapstructure:"stagingDir"`
+	HostPortMin             int     `mapstructure:"hostPortMin"`
+	HostPortMax             int     `mapstructure:"hostPortMax"`
+	RestrictExposedPorts    bool    `mapstructure:"restrictExposedPorts"`
+	ExposedPortsAllow       string  `mapstructure:"exposedPortsAllow"`
+	MaxScenarioDuration     int     `mapstructure:"maxScenarioDuration"`
+	ConsensusProbeInterval  int     `mapstructure:"consensusProbeInterval"`
+	ConsensusStallTimeout   int     `mapstructure:"consensusStallTimeout"`
+	FederationToken         string  `mapstructure:"federationToken"`
+	QueueBackend            string  `mapstructure:"queueBackend"`
+	QueueAddress            string  `mapstructure:"queueAddress"`
+	QueueStream             string  `mapstructure:"queueStream"`
+	QueueWorkers            int     `mapstructure:"queueWorkers"`
+	QueueClaimTimeout       int     `mapstructure:"queueClaimTimeout"`
+	SolcPath                string  `mapstructure:"solcPath"`
+	WorkspaceDir            string  `mapstructure:"workspaceDir"`
+	WorkspaceQuota          string  `mapstructure:"workspaceQuota"`
+	StageTimeout            int     `mapstructure:"stageTimeout"`
+	CommandTimeout          int     `mapstructure:"commandTimeout"`
+	EnableCAdvisor          bool    `mapstructure:"enableCAdvisor"`
+	CAdvisorImage           string  `mapstructure:"cadvisorImage"`
+	SMTPHost                string  `mapstructure:"smtpHost"`
+	SMTPPort                int     `mapstructure:"smtpPort"`
+	SMTPUser                string  `mapstructure:"smtpUser"`
+	SMTPPassword            string  `mapstructure:"smtpPassword"`
+	SMTPFrom                string  `mapstructure:"smtpFrom"`
+	SoakSampleInterval      int     `mapstructure:"soakSampleInterval"`
+	SoakDigestInterval      int     `mapstructure:"soakDigestInterval"`
+	PprofToken              string  `mapstructure:"pprofToken"`
+	ProfileInterval         int     `mapstructure:"profileInterval"`
+	ProfileDuration         int     `mapstructure:"profileDuration"`
+	ProfileKeep             int     `mapstructure:"profileKeep"`
+	ProfileDir              string  `mapstructure:"profileDir"`
 	DataDirectory           string  `mapstructure:"datadir"`
 	DisableNibbler          bool    `mapstructure:"disableNibbler"`
 	DisableTestnetReporting bool    `mapstructure:"disableTestnetReporting"`
@@ -73,6 +142,17 @@ type Config struct {
 	EnablePortForwarding    bool    `mapstructure:"enablePortForwarding"`
 	EnableDockerVolumes     bool    `mapstructure:"enableDockerVolumes"`
 	EnableImageBuilding     bool    `mapstructure:"enableImageBuilding"`
+	WebhookRetries          uint    `mapstructure:"webhookRetries"`
+	WebhookTimeout          int     `mapstructure:"webhookTimeout"`
+	SlackWebhook            string  `mapstructure:"slackWebhook"`   //No default
+	DiscordWebhook          string  `mapstructure:"discordWebhook"` //No default
+	NotifyOnSuccess         bool    `mapstructure:"notifyOnSuccess"`
+	ReaperInterval          int     `mapstructure:"reaperInterval"`
+	ExpiryWarning           int     `mapstructure:"expiryWarning"`
+	NodeCheckInterval       int     `mapstructure:"nodeCheckInterval"`
+	EnableTracing           bool    `mapstructure:"enableTracing"`
+	TracingEndpoint         string  `mapstructure:"tracingEndpoint"` //No default
+	FaucetAmount            string  `mapstructure:"faucetAmount"`
 }
 
 //NodesPerCluster represents the maximum number of nodes allowed in a cluster
@@ -84,6 +164,7 @@ func setViperEnvBindings() {
 	viper.BindEnv("sshUser", "SSH_USER")
 	viper.BindEnv("listen", "LISTEN")
 	viper.BindEnv("sshKey", "SSH_KEY")
+	viper.BindEnv("sshHost", "SSH_HOST")
 	viper.BindEnv("verbosity", "VERBOSITY")
 	viper.BindEnv("serverBits", "SERVER_BITS")
 	viper.BindEnv("clusterBits", "CLUSTER_BITS")
@@ -104,16 +185,85 @@ func setViperEnvBindings() {
 	viper.BindEnv("maxNodes", "MAX_NODES")
 	viper.BindEnv("maxNodeMemory", "MAX_NODE_MEMORY")
 	viper.BindEnv("maxNodeCPU", "MAX_NODE_CPU")
+	viper.BindEnv("nodeDiskSize", "NODE_DISK_SIZE")
 	viper.BindEnv("bridgePrefix", "BRIDGE_PREFIX")
 	viper.BindEnv("apiEndpoint", "API_ENDPOINT")
 	viper.BindEnv("nibblerEndPoint", "NIBBLER_END_POINT")
 	viper.BindEnv("logJson", "LOG_JSON")
+	viper.BindEnv("logFile", "LOG_FILE")
+	viper.BindEnv("logReportCaller", "LOG_REPORT_CALLER")
 	viper.BindEnv("prometheusConfig", "PROMETHEUS_CONFIG")
 	viper.BindEnv("prometheusPort", "PROMETHEUS_PORT")
 	viper.BindEnv("ganacheCLIOptions", "GANACHE_CLI_OPTIONS")
 	viper.BindEnv("ganacheRPCPort", "GANACHE_RPC_PORT")
+	viper.BindEnv("explorerPort", "EXPLORER_PORT")
+	viper.BindEnv("cadvisorPort", "CADVISOR_PORT")
+	viper.BindEnv("grafanaPort", "GRAFANA_PORT")
+	viper.BindEnv("grafanaProvisioning", "GRAFANA_PROVISIONING")
+	viper.BindEnv("healthCheckTimeout", "HEALTH_CHECK_TIMEOUT")
+	viper.BindEnv("threadLimit", "THREAD_LIMIT")
+	viper.BindEnv("buildRetries", "BUILD_RETRIES")
+	viper.BindEnv("localBackend", "LOCAL_BACKEND")
+	viper.BindEnv("containerRuntime", "CONTAINER_RUNTIME")
+	viper.BindEnv("kubectl", "KUBECTL")
+	viper.BindEnv("kubeNamespace", "KUBE_NAMESPACE")
+	viper.BindEnv("kubeNetemImage", "KUBE_NETEM_IMAGE")
+	viper.BindEnv("kubeReadyTimeout", "KUBE_READY_TIMEOUT")
+	viper.BindEnv("secrets", "SECRETS")
+	viper.BindEnv("secretsKey", "SECRETS_KEY")
+	viper.BindEnv("secretsKeyCommand", "SECRETS_KEY_COMMAND")
+	viper.BindEnv("enableShell", "ENABLE_SHELL")
+	viper.BindEnv("shellAuditLog", "SHELL_AUDIT_LOG")
+	viper.BindEnv("artifactsDir", "ARTIFACTS_DIR")
+	viper.BindEnv("captureImage", "CAPTURE_IMAGE")
+	viper.BindEnv("maxCaptureDuration", "MAX_CAPTURE_DURATION")
+	viper.BindEnv("maxCaptureSize", "MAX_CAPTURE_SIZE")
+	viper.BindEnv("trafficSampleInterval", "TRAFFIC_SAMPLE_INTERVAL")
 	viper.BindEnv("maxRunAttempts", "MAX_RUN_ATTEMPTS")
 	viper.BindEnv("maxConnections", "MAX_CONNECTIONS")
+	viper.BindEnv("sshPoolMin", "SSH_POOL_MIN")
+	viper.BindEnv("sshPoolMax", "SSH_POOL_MAX")
+	viper.BindEnv("sshPoolIdleTimeout", "SSH_POOL_IDLE_TIMEOUT")
+	viper.BindEnv("sshMaxSessions", "SSH_MAX_SESSIONS")
+	viper.BindEnv("simulator", "SIMULATOR")
+	viper.BindEnv("simulatorRules", "SIMULATOR_RULES")
+	viper.BindEnv("compressTransfers", "COMPRESS_TRANSFERS")
+	viper.BindEnv("enableRsync", "ENABLE_RSYNC")
+	viper.BindEnv("remoteCacheDir", "REMOTE_CACHE_DIR")
+	viper.BindEnv("remoteCacheMaxAge", "REMOTE_CACHE_MAX_AGE")
+	viper.BindEnv("stagingDir", "STAGING_DIR")
+	viper.BindEnv("hostPortMin", "HOST_PORT_MIN")
+	viper.BindEnv("hostPortMax", "HOST_PORT_MAX")
+	viper.BindEnv("restrictExposedPorts", "RESTRICT_EXPOSED_PORTS")
+	viper.BindEnv("exposedPortsAllow", "EXPOSED_PORTS_ALLOW")
+	viper.BindEnv("maxScenarioDuration", "MAX_SCENARIO_DURATION")
+	viper.BindEnv("consensusProbeInterval", "CONSENSUS_PROBE_INTERVAL")
+	viper.BindEnv("consensusStallTimeout", "CONSENSUS_STALL_TIMEOUT")
+	viper.BindEnv("federationToken", "FEDERATION_TOKEN")
+	viper.BindEnv("queueBackend", "QUEUE_BACKEND")
+	viper.BindEnv("queueAddress", "QUEUE_ADDRESS")
+	viper.BindEnv("queueStream", "QUEUE_STREAM")
+	viper.BindEnv("queueWorkers", "QUEUE_WORKERS")
+	viper.BindEnv("queueClaimTimeout", "QUEUE_CLAIM_TIMEOUT")
+	viper.BindEnv("solcPath", "SOLC_PATH")
+	viper.BindEnv("workspaceDir", "WORKSPACE_DIR")
+	viper.BindEnv("workspaceQuota", "WORKSPACE_QUOTA")
+	viper.BindEnv("stageTimeout", "STAGE_TIMEOUT")
+	viper.BindEnv("commandTimeout", "COMMAND_TIMEOUT")
+	viper.BindEnv("enableCAdvisor", "ENABLE_CADVISOR")
+	viper.BindEnv("cadvisorImage", "CADVISOR_IMAGE")
+	viper.BindEnv("smtpHost", "SMTP_HOST")
+	viper.BindEnv("smtpPort", "SMTP_PORT")
+	viper.BindEnv("smtpUser", "SMTP_USER")
+	viper.BindEnv("smtpPassword", "SMTP_PASSWORD")
+	viper.BindEnv("smtpFrom", "SMTP_FROM")
+	viper.BindEnv("soakSampleInterval", "SOAK_SAMPLE_INTERVAL")
+	viper.BindEnv("soakDigestInterval", "SOAK_DIGEST_INTERVAL")
+	viper.BindEnv("pprofToken", "PPROF_TOKEN")
+	viper.BindEnv("profileInterval", "PROFILE_INTERVAL")
+	viper.BindEnv("profileDuration", "PROFILE_DURATION")
+	viper.BindEnv("profileKeep", "PROFILE_KEEP")
+	viper.BindEnv("profileDir", "PROFILE_DIR")
 	viper.BindEnv("datadir", "DATADIR")
 	viper.BindEnv("disableNibbler", "DISABLE_NIBBLER")
 	viper.BindEnv("disableTestnetReporting", "DISABLE_TESTNET_REPORTING")
@@ -126,6 +276,17 @@ func setViperEnvBindings() {
 	viper.BindEnv("enablePortForwarding", "ENABLE_PORT_FORWARDING")
 	viper.BindEnv("enableDockerVolumes", "ENABLE_DOCKER_VOLUMES")
 	viper.BindEnv("enableImageBuilding", "ENABLE_IMAGE_BUILDING")
+	viper.BindEnv("webhookRetries", "WEBHOOK_RETRIES")
+	viper.BindEnv("webhookTimeout", "WEBHOOK_TIMEOUT")
+	viper.BindEnv("slackWebhook", "SLACK_WEBHOOK")
+	viper.BindEnv("discordWebhook", "DISCORD_WEBHOOK")
+	viper.BindEnv("notifyOnSuccess", "NOTIFY_ON_SUCCESS")
+	viper.BindEnv("reaperInterval", "REAPER_INTERVAL")
+	viper.BindEnv("expiryWarning", "EXPIRY_WARNING")
+	viper.BindEnv("nodeCheckInterval", "NODE_CHECK_INTERVAL")
+	viper.BindEnv("enableTracing", "ENABLE_TRACING")
+	viper.BindEnv("tracingEndpoint", "TRACING_ENDPOINT")
+	viper.BindEnv("faucetAmount", "FAUCET_AMOUNT")
 }
 func setViperDefaults() {
 	viper.SetDefault("sshUser", os.Getenv("USER"))
@@ -146,53 +307,120 @@ func setViperDefaults() {
 	viper.SetDefault("maxNodes", 200)
 	viper.SetDefault("maxNodeMemory", "")
 	viper.SetDefault("maxNodeCpu", -1)
+	viper.SetDefault("nodeDiskSize", "")
 	viper.SetDefault("bridgePrefix", "wb_bridge")
 	viper.SetDefault("apiEndpoint", "https://api.whiteblock.io")
 	viper.SetDefault("nibblerEndPoint", "https://storage.googleapis.com/genesis-public/nibbler/master/bin/linux/amd64/nibbler")
 	viper.SetDefault("logJson", false)
+	viper.SetDefault("logFile", "")
+	viper.SetDefault("logReportCaller", false)
 	viper.SetDefault("prometheusConfig", "/tmp/prometheus.yml")
 	viper.SetDefault("prometheusPort", 9090)
 	viper.SetDefault("prometheusInstrumentationPort", 8008)
 	viper.SetDefault("maxRunAttempts", 30)
 	viper.SetDefault("maxConnections", 50)
+	viper.SetDefault("sshPoolMin", 1)
+	viper.SetDefault("sshPoolMax", 10)
+	viper.SetDefault("sshPoolIdleTimeout", 300)
+	viper.SetDefault("sshMaxSessions", 10)
+	viper.SetDefault("simulator", false)
+	viper.SetDefault("simulatorRules", "")
+	viper.SetDefault("compressTransfers", true)
+	viper.SetDefault("enableRsync", false)
+	viper.SetDefault("remoteCacheDir", "")
+	viper.SetDefault("remoteCacheMaxAge", 72)
+	viper.SetDefault("stagingDir", "/tmp")
+	viper.SetDefault("hostPortMin", 30000)
+	viper.SetDefault("hostPortMax", 32767)
+	viper.SetDefault("restrictExposedPorts", true)
+	viper.SetDefault("exposedPortsAllow", "")
+	viper.SetDefault("maxScenarioDuration", 3600)
+	viper.SetDefault("consensusProbeInterval", 10)
+	viper.SetDefault("consensusStallTimeout", 60)
+	viper.SetDefault("federationToken", "")
+	viper.SetDefault("queueBackend", "")
+	viper.SetDefault("queueAddress", "127.0.0.1:6379")
+	viper.SetDefault("queueStream", "genesis:builds")
+	viper.SetDefault("queueWorkers", 2)
+	viper.SetDefault("queueClaimTimeout", 300)
+	viper.SetDefault("solcPath", "solc")
+	viper.SetDefault("workspaceDir", "/tmp")
+	viper.SetDefault("workspaceQuota", "0")
+	viper.SetDefault("stageTimeout", 3600)
+	viper.SetDefault("commandTimeout", 1800)
+	viper.SetDefault("enableCAdvisor", false)
+	viper.SetDefault("cadvisorImage", "google/cadvisor")
+	viper.SetDefault("smtpHost", "")
+	viper.SetDefault("smtpPort", 587)
+	viper.SetDefault("smtpUser", "")
+	viper.SetDefault("smtpPassword", "")
+	viper.SetDefault("smtpFrom", "")
+	viper.SetDefault("soakSampleInterval", 300)
+	viper.SetDefault("soakDigestInterval", 86400)
+	viper.SetDefault("pprofToken", "")
+	viper.SetDefault("profileInterval", 0)
+	viper.SetDefault("profileDuration", 30)
+	viper.SetDefault("profileKeep", 24)
+	viper.SetDefault("profileDir", "")
 	viper.SetDefault("datadir", os.Getenv("HOME")+"/.config/whiteblock/")
 	viper.SetDefault("disableNibbler", false)
 	viper.SetDefault("disableTestnetReporting", false)
 	viper.SetDefault("requireAuth", false)
 	viper.SetDefault("maxCommandOutputLogSize", -1)
-	viper.SetDefault("resourceDir", "./resources")
+	viper.SetDefault("resourceDir", "")
 	viper.SetDefault("removeNodesOnFailure", true)
 	viper.SetDefault("nibblerRetries", 2)
 	viper.SetDefault("killRetries", 100)
 	viper.SetDefault("ganacheRPCPort", 8545)
+	viper.SetDefault("explorerPort", 8090)
+	viper.SetDefault("cadvisorPort", 8080)
+	viper.SetDefault("grafanaPort", 3000)
+	viper.SetDefault("grafanaProvisioning", "/tmp/grafana")
+	viper.SetDefault("healthCheckTimeout", 120)
+	viper.SetDefault("threadLimit", 10)
+	viper.SetDefault("buildRetries", 0)
+	viper.SetDefault("localBackend", true)
+	viper.SetDefault("containerRuntime", "docker")
+	viper.SetDefault("kubectl", "kubectl")
+	viper.SetDefault("kubeNamespace", "default")
+	viper.SetDefault("kubeNetemImage", "gaiadocker/iproute2")
+	viper.SetDefault("kubeReadyTimeout", 300)
+	viper.SetDefault("secrets", false)
+	viper.SetDefault("secretsKey", "")
+	viper.SetDefault("secretsKeyCommand", "")
+	viper.SetDefault("enableShell", true)
+	viper.SetDefault("shellAuditLog", "")
+	viper.SetDefault("artifactsDir", "")
+	viper.SetDefault("captureImage", "nicolaka/netshoot")
+	viper.SetDefault("maxCaptureDuration", 3600)
+	viper.SetDefault("maxCaptureSize", 1000)
+	viper.SetDefault("trafficSampleInterval", 10)
 	viper.SetDefault("ganacheCLIOptions", "--gasLimit 4000000000000")
 	viper.SetDefault("enablePortForwarding", true)
 	viper.SetDefault("enableDockerVolumes", true)
 	viper.SetDefault("enableImageBuilding", true)
-}
-
-// GCPFormatter enables the ability to use genesis logging with Stackdriver
-type GCPFormatter struct {
-	JSON           *log.JSONFormatter
-	ConstantFields log.Fields
-}
-
-// Format takes in the entry and processes it into the appropiate log entry
-func (gf GCPFormatter) Format(entry *log.Entry) ([]byte, error) {
-	for k, v := range gf.ConstantFields {
-		entry.Data[k] = v
-	}
-	return gf.JSON.Format(entry)
+	viper.SetDefault("webhookRetries", 3)
+	viper.SetDefault("webhookTimeout", 10)
+	viper.SetDefault("notifyOnSuccess", true)
+	viper.SetDefault("reaperInterval", 60)
+	viper.SetDefault("expiryWarning", 600)
+	viper.SetDefault("nodeCheckInterval", 30)
+	viper.SetDefault("enableTracing", false)
+	viper.SetDefault("faucetAmount", "1000000000000000000") //1 ether
 }
 
 func init() {
 	setViperDefaults()
 	setViperEnvBindings()
+	err := parseConfigFlags(os.Args[1:])
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Fatal("invalid config flag")
+	}
 	viper.AddConfigPath("/etc/whiteblock/")          // path to look for the config file in
 	viper.AddConfigPath("$HOME/.config/whiteblock/") // call multiple times to add many search paths
 	viper.SetConfigName("genesis")
 	viper.SetConfigType("yaml")
-	err := viper.ReadInConfig()
+	err = viper.ReadInConfig()
 
 	if err != nil {
 		log.WithFields(log.Fields{"error": err}).Warn("could not find the config file")
@@ -202,29 +430,9 @@ func init() {
 		log.Fatalf("unable to decode into struct, %v", err)
 	}
 
-	lvl, err := log.ParseLevel(conf.Verbosity)
-	if err != nil {
-		log.SetLevel(log.InfoLevel)
-		log.Warn(err)
-	}
-	log.SetLevel(lvl)
+	configureLogging()
 	NodesPerCluster = (1 << conf.NodeBits) - ReservedIps
 
-	if conf.LogJSON {
-		log.SetFormatter(&GCPFormatter{
-			JSON: &log.JSONFormatter{
-				FieldMap: log.FieldMap{
-					log.FieldKeyTime:  "eventTime",
-					log.FieldKeyLevel: "severity",
-					log.FieldKeyMsg:   "message",
-				},
-			},
-			ConstantFields: log.Fields{
-				"serviceContext": map[string]string{"service": "genesis", "version": "1.8.2"},
-			},
-		})
-	}
-
 	err = os.MkdirAll(conf.DataDirectory, 0776)
 	if err != nil {
 		log.WithFields(log.Fields{"error": err, "dir": conf.DataDirectory}).Fatal("could not create data directory")
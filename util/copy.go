@@ -0,0 +1,105 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// CopyFile copies the file at src to dest, keeping its mode and creating the parent directories of dest if needed
+func CopyFile(src string, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dest, info.Mode().Perm())
+}
+
+// CopyTree copies the file or directory at src to dest, in the manner of cp -rT, so that dest ends up
+// with the contents of src rather than with src inside of it. Symbolic links are copied as links.
+func CopyTree(src string, dest string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyEntry(src, dest, info)
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		return copyEntry(path, filepath.Join(dest, rel), info)
+	})
+}
+
+func copyEntry(src string, dest string, info os.FileInfo) error {
+	switch {
+	case info.IsDir():
+		err := os.MkdirAll(dest, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		return os.Chmod(dest, info.Mode().Perm())
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(filepath.Dir(dest), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.Remove(dest)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return os.Symlink(target, dest)
+	default:
+		return CopyFile(src, dest)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	if err := ioutil.WriteFile(src, []byte("contents"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(dir, "a", "b", "dest")
+	if err := CopyFile(src, dest); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(dest)
+	if err != nil || string(data) != "contents" {
+		t.Errorf("unexpected contents %q: %v", string(data), err)
+	}
+	info, err := os.Stat(dest)
+	if err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("expected the mode to be kept, got %v: %v", info.Mode(), err)
+	}
+	if err := CopyFile(filepath.Join(dir, "missing"), dest); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	files := map[string]string{"a": "1", "sub/b": "2", "sub/deeper/c": "3"}
+	for name, contents := range files {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("a", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(dir, "dest")
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ { // copying onto an existing copy must work too
+		if err := CopyTree(src, dest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, contents := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dest, name))
+		if err != nil || string(data) != contents {
+			t.Errorf("unexpected contents %q of %s: %v", string(data), name, err)
+		}
+	}
+	if target, err := os.Readlink(filepath.Join(dest, "link")); err != nil || target != "a" {
+		t.Errorf("expected the link to be copied, got %q: %v", target, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "src")); !os.IsNotExist(err) {
+		t.Error("expected the contents of src to be copied, not src itself")
+	}
+
+	if err := CopyTree(filepath.Join(src, "a"), filepath.Join(dir, "single")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "single")); string(data) != "1" {
+		t.Errorf("unexpected contents %q of a single file", string(data))
+	}
+}
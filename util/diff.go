@@ -0,0 +1,148 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Change represents a single difference between two values
+type Change struct {
+	// Field is the path to the value which changed, such as "params.blockTime" or "resources[0].cpus"
+	Field string `json:"field"`
+	// Old is the previous value, or nil if it was added
+	Old interface{} `json:"old"`
+	// New is the current value, or nil if it was removed
+	New interface{} `json:"new"`
+}
+
+// Diff compares the json representations of old and new, and returns every field which differs
+// between them, sorted by field
+func Diff(old interface{}, new interface{}) ([]Change, error) {
+	oldGeneric, err := toGeneric(old)
+	if err != nil {
+		return nil, LogError(err)
+	}
+	newGeneric, err := toGeneric(new)
+	if err != nil {
+		return nil, LogError(err)
+	}
+	out := diff("", oldGeneric, newGeneric, []Change{})
+	sort.Slice(out, func(i, j int) bool { return out[i].Field < out[j].Field })
+	return out, nil
+}
+
+func toGeneric(in interface{}) (interface{}, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	return out, json.Unmarshal(data, &out)
+}
+
+func diff(path string, old interface{}, new interface{}, changes []Change) []Change {
+	switch oldVal := old.(type) {
+	case map[string]interface{}:
+		newVal, ok := new.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for key, value := range oldVal {
+			changes = diff(joinPath(path, key), value, newVal[key], changes)
+		}
+		for key, value := range newVal {
+			if _, exists := oldVal[key]; !exists {
+				changes = diff(joinPath(path, key), nil, value, changes)
+			}
+		}
+		return changes
+	case []interface{}:
+		newVal, ok := new.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(oldVal) || i < len(newVal); i++ {
+			var oldElem, newElem interface{}
+			if i < len(oldVal) {
+				oldElem = oldVal[i]
+			}
+			if i < len(newVal) {
+				newElem = newVal[i]
+			}
+			changes = diff(fmt.Sprintf("%s[%d]", path, i), oldElem, newElem, changes)
+		}
+		return changes
+	}
+	if reflect.DeepEqual(old, new) {
+		return changes
+	}
+	return append(changes, Change{Field: path, Old: old, New: new})
+}
+
+func joinPath(path string, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+// DiffLines compares old and new line by line, and gives the lines which were removed from old prefixed
+// with "-" and those which were added in new prefixed with "+", in the order they appear in the text.
+// Lines which are in both are left out.
+func DiffLines(old string, new string) []string {
+	oldLines := strings.Split(old, "\n")
+	newLines := strings.Split(new, "\n")
+	// lcs[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	out := []string{}
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			i++
+			j++
+		case j == len(newLines) || (i < len(oldLines) && lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "-"+oldLines[i])
+			i++
+		default:
+			out = append(out, "+"+newLines[j])
+			j++
+		}
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var test = []struct {
+		old      interface{}
+		new      interface{}
+		expected []Change
+	}{
+		{old: map[string]int{"a": 1}, new: map[string]int{"a": 1}, expected: []Change{}},
+		{old: map[string]int{"a": 1}, new: map[string]int{"a": 2}, expected: []Change{{Field: "a", Old: 1.0, New: 2.0}}},
+		{
+			old: map[string]interface{}{"a": map[string]string{"b": "c"}, "d": true},
+			new: map[string]interface{}{"a": map[string]string{"b": "e"}, "f": "g"},
+			expected: []Change{
+				{Field: "a.b", Old: "c", New: "e"},
+				{Field: "d", Old: true, New: nil},
+				{Field: "f", Old: nil, New: "g"},
+			},
+		},
+		{
+			old:      map[string][]int{"servers": []int{1}},
+			new:      map[string][]int{"servers": []int{1, 2}},
+			expected: []Change{{Field: "servers[1]", Old: nil, New: 2.0}},
+		},
+		{
+			old:      map[string]interface{}{"a": []int{1}},
+			new:      map[string]interface{}{"a": "b"},
+			expected: []Change{{Field: "a", Old: []interface{}{1.0}, New: "b"}},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			res, err := Diff(tt.old, tt.new)
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(res, tt.expected) {
+				t.Errorf("return value of Diff %v does not match expected value %v", res, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	var test = []struct {
+		old      string
+		new      string
+		expected []string
+	}{
+		{old: "a\nb\nc", new: "a\nb\nc", expected: []string{}},
+		{old: "a\nb\nc", new: "a\nd\nc", expected: []string{"-b", "+d"}},
+		{old: "a\nb", new: "a\nb\nc", expected: []string{"+c"}},
+		{old: "a\nb\nc", new: "b\nc", expected: []string{"-a"}},
+		{old: "", new: "a", expected: []string{"-", "+a"}},
+		{old: "port = 30303\nmaxpeers = 25\n", new: "maxpeers = 50\nport = 30303\n",
+			expected: []string{"+maxpeers = 50", "-maxpeers = 25"}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			res := DiffLines(tt.old, tt.new)
+			if !reflect.DeepEqual(res, tt.expected) {
+				t.Errorf("return value of DiffLines %v does not match expected value %v", res, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"runtime/debug"
+	"strings"
+)
+
+// NodeError is an error which occurred while executing something on a node
+type NodeError struct {
+	// Node is the absolute number of the node
+	Node int
+	// Server is the id of the server the node is on
+	Server int
+	// Err is the error which occurred
+	Err error
+}
+
+// Error gives the error, prefixed by which node it occurred on
+func (ne NodeError) Error() string {
+	return fmt.Sprintf("node %d on server %d: %s", ne.Node, ne.Server, ne.Err.Error())
+}
+
+// MultiError is a collection of errors which occurred concurrently
+type MultiError []error
+
+// Error combines all of the errors into a single message
+func (me MultiError) Error() string {
+	if len(me) == 1 {
+		return me[0].Error()
+	}
+	msgs := make([]string, len(me))
+	for i, err := range me {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors occurred: %s", len(me), strings.Join(msgs, "; "))
+}
+
+// ErrorOrNil returns nil if there are no errors, otherwise it returns me
+func (me MultiError) ErrorOrNil() error {
+	if len(me) == 0 {
+		return nil
+	}
+	return me
+}
+
+// CommandError is an error from a command which failed to execute
+type CommandError struct {
+	// Command is the command which failed
+	Command string
+	// Output is what the command outputted
+	Output string
+	// Err is the error which occurred
+	Err error
+}
+
+// Error gives the output of the command followed by the error, the same as FormatError
+func (ce CommandError) Error() string {
+	return FormatError(ce.Output, ce.Err).Error()
+}
+
+// PanicError is a panic which was recovered, so that it can be reported like any other error
+type PanicError struct {
+	// Value is the value which was given to panic
+	Value interface{}
+	// Stack is the stack trace of the goroutine at the time of the panic
+	Stack string
+}
+
+// Error gives the value of the panic
+func (pe PanicError) Error() string {
+	if entry, ok := pe.Value.(*log.Entry); ok { //from log.Panic
+		return "panic: " + entry.Message
+	}
+	return fmt.Sprintf("panic: %v", pe.Value)
+}
+
+// Recover recovers from a panic, giving it to handle as a PanicError, so that a panic in a goroutine does not
+// take the whole process down. It must be deferred directly, as in defer util.Recover(bs.ReportError).
+func Recover(handle func(err error)) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err := PanicError{Value: r, Stack: string(debug.Stack())}
+	log.WithFields(log.Fields{"error": err, "stack": err.Stack}).Error("recovered from a panic")
+	handle(err)
+}
+
+// Safe calls fn, returning a PanicError if it panics
+func Safe(fn func() error) (err error) {
+	defer Recover(func(perr error) {
+		err = perr
+	})
+	return fn()
+}
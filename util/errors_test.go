@@ -0,0 +1,103 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMultiError(t *testing.T) {
+	var test = []struct {
+		errs     MultiError
+		expected string
+	}{
+		{
+			errs:     MultiError{NodeError{Node: 2, Server: 1, Err: fmt.Errorf("exit status 1")}},
+			expected: "node 2 on server 1: exit status 1",
+		},
+		{
+			errs: MultiError{
+				NodeError{Node: 0, Server: 1, Err: fmt.Errorf("a")},
+				fmt.Errorf("b"),
+			},
+			expected: "2 errors occurred: node 0 on server 1: a; b",
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if tt.errs.Error() != tt.expected {
+				t.Errorf("expected \"%s\", got \"%s\"", tt.expected, tt.errs.Error())
+			}
+		})
+	}
+	if (MultiError{}).ErrorOrNil() != nil {
+		t.Error("an empty MultiError should give a nil error")
+	}
+}
+
+func TestSafe(t *testing.T) {
+	err := Safe(func() error {
+		return fmt.Errorf("failed")
+	})
+	if err == nil || err.Error() != "failed" {
+		t.Errorf("expected the error to be returned, got %v", err)
+	}
+
+	err = Safe(func() error {
+		var nodes []int
+		return fmt.Errorf("%d", nodes[1])
+	})
+	pe, ok := err.(PanicError)
+	if !ok {
+		t.Fatalf("expected a PanicError, got %v", err)
+	}
+	if !strings.HasPrefix(pe.Error(), "panic: runtime error: index out of range") {
+		t.Errorf("unexpected error %s", pe.Error())
+	}
+	if !strings.Contains(pe.Stack, "TestSafe") {
+		t.Errorf("expected the stack to include the test, got %s", pe.Stack)
+	}
+
+	err = Safe(func() error {
+		log.Panic("unescaped '")
+		return nil
+	})
+	if err == nil || err.Error() != "panic: unescaped '" {
+		t.Errorf("expected the message of log.Panic, got %v", err)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	errs := make(chan error, 1)
+	go func() {
+		defer Recover(func(err error) {
+			errs <- err
+		})
+		panic("from a goroutine")
+	}()
+	err := <-errs
+	if err.Error() != "panic: from a goroutine" {
+		t.Errorf("unexpected error %s", err.Error())
+	}
+}
@@ -0,0 +1,140 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+var (
+	shell     string
+	shellOnce = sync.Once{}
+)
+
+// Shell gives the shell which local commands are run with, bash if it is installed, otherwise sh, so that
+// genesis also runs on minimal systems
+func Shell() string {
+	shellOnce.Do(func() {
+		shell = "sh"
+		if path, err := exec.LookPath("bash"); err == nil {
+			shell = path
+		}
+	})
+	return shell
+}
+
+// ExecOptions are the options of a command run on this machine
+type ExecOptions struct {
+	// Timeout is how long the command may run for before it is killed, there is no limit when it is 0
+	Timeout time.Duration
+	// Stdin is the input of the command
+	Stdin io.Reader
+	// Stdout and Stderr also receive the output of the command as it is written, when given
+	Stdout io.Writer
+	Stderr io.Writer
+	// Env are the environment variables given to the command on top of those of genesis, as KEY=value
+	Env []string
+	// Dir is the working directory of the command
+	Dir string
+}
+
+// ExecResult is the output of a command run on this machine
+type ExecResult struct {
+	Stdout string
+	Stderr string
+	// Combined is the stdout and stderr, interleaved as they were read
+	Combined string
+	// ExitCode is the exit code of the command, or -1 if it did not exit by itself
+	ExitCode int
+}
+
+// lockedBuffer is a buffer which is safe to write to from both of the output streams of a command
+type lockedBuffer struct {
+	mux sync.Mutex
+	buf bytes.Buffer
+}
+
+func (lb *lockedBuffer) Write(p []byte) (int, error) {
+	lb.mux.Lock()
+	defer lb.mux.Unlock()
+	return lb.buf.Write(p)
+}
+
+// LocalExec runs the command with the shell on this machine, see LocalExecContext
+func LocalExec(command string) (ExecResult, error) {
+	return LocalExecContext(context.Background(), command, ExecOptions{})
+}
+
+// LocalExecContext runs the command with the shell on this machine, killing it if ctx is done or its
+// timeout is reached. If it fails, the error is a CommandError, whose output is the stderr of the command,
+// or its stdout if it did not write to stderr.
+func LocalExecContext(ctx context.Context, command string, opts ExecOptions) (ExecResult, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, Shell(), "-c", command)
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	combined := &lockedBuffer{}
+	outputs := []io.Writer{stdout, combined}
+	errputs := []io.Writer{stderr, combined}
+	if opts.Stdout != nil {
+		outputs = append(outputs, opts.Stdout)
+	}
+	if opts.Stderr != nil {
+		errputs = append(errputs, opts.Stderr)
+	}
+	cmd.Stdout = io.MultiWriter(outputs...)
+	cmd.Stderr = io.MultiWriter(errputs...)
+	cmd.Stdin = opts.Stdin
+	cmd.Dir = opts.Dir
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+
+	err := cmd.Run()
+	out := ExecResult{Stdout: stdout.String(), Stderr: stderr.String(), Combined: combined.buf.String(),
+		ExitCode: -1}
+	if cmd.ProcessState != nil {
+		out.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if err == nil {
+		return out, nil
+	}
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		err = fmt.Errorf("timed out after %s", opts.Timeout)
+	case context.Canceled:
+		err = fmt.Errorf("cancelled")
+	}
+	output := out.Stderr
+	if len(output) == 0 {
+		output = out.Stdout
+	}
+	return out, CommandError{Command: command, Output: output, Err: err}
+}
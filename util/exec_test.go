@@ -0,0 +1,91 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalExec(t *testing.T) {
+	res, err := LocalExec("echo out; echo err >&2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Stdout != "out\n" || res.Stderr != "err\n" || res.ExitCode != 0 {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if len(res.Combined) != 8 || !strings.Contains(res.Combined, "out\n") || !strings.Contains(res.Combined, "err\n") {
+		t.Errorf("unexpected combined output %q", res.Combined)
+	}
+
+	res, err = LocalExec("echo partial; echo broken >&2; exit 3")
+	ce, ok := err.(CommandError)
+	if !ok {
+		t.Fatalf("expected a CommandError, got %v", err)
+	}
+	if ce.Output != "broken\n" || res.Stdout != "partial\n" || res.ExitCode != 3 {
+		t.Errorf("unexpected result %+v and error %+v", res, ce)
+	}
+
+	_, err = LocalExec("echo only stdout; false")
+	if ce, ok := err.(CommandError); !ok || ce.Output != "only stdout\n" {
+		t.Errorf("expected the stdout as the output, got %v", err)
+	}
+}
+
+func TestLocalExecContext(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	res, err := LocalExecContext(context.Background(), "cat; echo \"$GENESIS_TEST\"", ExecOptions{
+		Stdin: strings.NewReader("in\n"), Stdout: stdout, Env: []string{"GENESIS_TEST=env"}, Dir: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Stdout != "in\nenv\n" || stdout.String() != res.Stdout {
+		t.Errorf("unexpected output %q, streamed %q", res.Stdout, stdout.String())
+	}
+
+	start := time.Now()
+	res, err = LocalExecContext(context.Background(), "sleep 5", ExecOptions{Timeout: 50 * time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	if time.Since(start) > 3*time.Second || res.ExitCode != -1 {
+		t.Errorf("expected the command to be killed, got %+v after %s", res, time.Since(start))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	_, err = LocalExecContext(ctx, "sleep 5", ExecOptions{})
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("expected the command to be cancelled, got %v", err)
+	}
+}
+
+func TestShell(t *testing.T) {
+	if len(Shell()) == 0 {
+		t.Error("expected a shell")
+	}
+}
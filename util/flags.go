@@ -0,0 +1,111 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package util
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"reflect"
+)
+
+// RedactedValue replaces the value of sensitive configuration fields in Config.Redacted
+const RedactedValue = "REDACTED"
+
+// redactedFields are the config fields which must never be exposed, such as through the
+// /config endpoint
+var redactedFields = map[string]bool{
+	"influxPassword":    true,
+	"nodesPrivateKey":   true,
+	"secretsKey":        true,
+	"secretsKeyCommand": true,
+	"slackWebhook":      true,
+	"discordWebhook":    true,
+	"federationToken":   true,
+	"smtpPassword":      true,
+	"pprofToken":        true,
+}
+
+var configFlags = newConfigFlags()
+
+// newConfigFlags creates a flag for every field of Config, named after its
+// mapstructure key, ie --sshUser or --threadLimit
+func newConfigFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("genesis", pflag.ContinueOnError)
+	flags.ParseErrorsWhitelist.UnknownFlags = true
+	flags.Usage = func() {}
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("mapstructure")
+		usage := "overrides the " + key + " config option"
+		switch t.Field(i).Type.Kind() {
+		case reflect.Bool:
+			flags.Bool(key, false, usage)
+		case reflect.Int:
+			flags.Int(key, 0, usage)
+		case reflect.Uint:
+			flags.Uint(key, 0, usage)
+		case reflect.Uint32:
+			flags.Uint32(key, 0, usage)
+		case reflect.Float64:
+			flags.Float64(key, 0, usage)
+		default:
+			flags.String(key, "", usage)
+		}
+	}
+	return flags
+}
+
+// ConfigFlags gets the flags which override the config options, so that they can
+// be added to a command line parser. They are already parsed from os.Args by the
+// time this is called.
+func ConfigFlags() *pflag.FlagSet {
+	return configFlags
+}
+
+// parseConfigFlags parses the config flags out of args, ignoring any flag
+// which is not a config option, and binds them to viper, giving them
+// precedence over the environment, the config file and the defaults.
+func parseConfigFlags(args []string) error {
+	filtered := []string{}
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" { //help is left to the command line parser
+			continue
+		}
+		filtered = append(filtered, arg)
+	}
+	err := configFlags.Parse(filtered)
+	if err != nil {
+		return err
+	}
+	return viper.BindPFlags(configFlags)
+}
+
+// Redacted gets the config as a map of its option names to their values,
+// with the sensitive values replaced with RedactedValue
+func (c Config) Redacted() map[string]interface{} {
+	out := map[string]interface{}{}
+	v := reflect.ValueOf(c)
+	for i := 0; i < v.NumField(); i++ {
+		key := v.Type().Field(i).Tag.Get("mapstructure")
+		out[key] = v.Field(i).Interface()
+		if redactedFields[key] && !v.Field(i).IsZero() {
+			out[key] = RedactedValue
+		}
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package util
+
+import (
+	"github.com/spf13/viper"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestConfigFlags(t *testing.T) {
+	typ := reflect.TypeOf(Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		key := typ.Field(i).Tag.Get("mapstructure")
+		if ConfigFlags().Lookup(key) == nil {
+			t.Errorf("missing flag for config option \"%s\"", key)
+		}
+	}
+}
+
+func TestParseConfigFlags(t *testing.T) {
+	os.Setenv("BUILD_RETRIES", "4")
+	os.Setenv("KILL_RETRIES", "5")
+	defer os.Unsetenv("BUILD_RETRIES")
+	defer os.Unsetenv("KILL_RETRIES")
+
+	err := parseConfigFlags([]string{"build", "-f", "spec.yaml", "--help", "--buildRetries=7", "--unknown", "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var test = []struct {
+		key      string
+		expected int
+	}{
+		{key: "buildRetries", expected: 7}, //flag over env
+		{key: "killRetries", expected: 5},  //env over default
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if viper.GetInt(tt.key) != tt.expected {
+				t.Errorf("%s is %d, expected %d", tt.key, viper.GetInt(tt.key), tt.expected)
+			}
+		})
+	}
+
+	err = parseConfigFlags([]string{"--buildRetries=many"})
+	if err == nil {
+		t.Error("expected an error for an invalid flag value")
+	}
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	c := Config{SSHUser: "user", SecretsKey: "hunter2", SlackWebhook: "", SMTPPassword: "hunter2",
+		PprofToken: "hunter2"}
+	out := c.Redacted()
+	var test = []struct {
+		key      string
+		expected interface{}
+	}{
+		{key: "sshUser", expected: "user"},
+		{key: "secretsKey", expected: RedactedValue},
+		{key: "slackWebhook", expected: ""},
+		{key: "smtpPassword", expected: RedactedValue},
+		{key: "pprofToken", expected: RedactedValue},
+		{key: "threadLimit", expected: 0},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if out[tt.key] != tt.expected {
+				t.Errorf("%s is \"%v\", expected \"%v\"", tt.key, out[tt.key], tt.expected)
+			}
+		})
+	}
+	if len(out) != reflect.TypeOf(c).NumField() {
+		t.Errorf("expected every config option in the output, got %d", len(out))
+	}
+}
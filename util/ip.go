@@ -64,6 +64,37 @@ func GetNodeIP(server int, network int, index int) (string, error) {
 	return InetNtoa(ip), nil
 }
 
+// NetworksPerSubnet gets the number of node networks which fit in the ip block of a subnet id. The last
+// cluster of the block is left out, as the address of its first node is that of the cluster.
+func NetworksPerSubnet() int {
+	return (1 << conf.ClusterBits) - 1
+}
+
+// GetNodeNetwork gets the subnet id and the network in its ip block of the node with the given number,
+// on a server with the given subnet ids. The nodes fill the ip block of the first subnet id, then that
+// of the next, so that a server can hold more nodes than fit in a single block.
+func GetNodeNetwork(subnets []int, node int) (int, int, error) {
+	perSubnet := NetworksPerSubnet()
+	if node < 0 || node >= perSubnet*len(subnets) {
+		return 0, 0, fmt.Errorf("node %d does not fit in the ip blocks of the %d subnets of its server",
+			node, len(subnets))
+	}
+	return subnets[node/perSubnet], node % perSubnet, nil
+}
+
+// GetNodeFromIP gets the number of the node with the given ip address, or of the node the sidecar with
+// it belongs to, on a server with the given subnet ids. Returns false if the address is not in the ip
+// blocks of the server.
+func GetNodeFromIP(subnets []int, ip string) (int, bool) {
+	subnet, network, _ := GetInfoFromIP(ip)
+	for i, id := range subnets {
+		if id == subnet {
+			return i*NetworksPerSubnet() + network, true
+		}
+	}
+	return 0, false
+}
+
 // GetInfoFromIP returns the server number and the node number calculated from the given
 // IPv4 address based on the current IP scheme. (server,network,index)
 func GetInfoFromIP(ipStr string) (int, int, int) {
@@ -133,3 +133,64 @@ func TestGetNetworkAddress(t *testing.T) {
 		}
 	}
 }
+
+func TestGetNodeNetwork(t *testing.T) {
+	conf.ServerBits = 8
+	conf.NodeBits = 4
+	conf.ClusterBits = 2
+	conf.IPPrefix = 10
+	var test = []struct {
+		subnets []int
+		node    int
+		subnet  int
+		network int
+		err     bool
+	}{
+		{subnets: []int{1}, node: 0, subnet: 1, network: 0},
+		{subnets: []int{1}, node: 2, subnet: 1, network: 2},
+		{subnets: []int{1}, node: 3, err: true},
+		{subnets: []int{1, 5}, node: 3, subnet: 5, network: 0},
+		{subnets: []int{1, 5}, node: 5, subnet: 5, network: 2},
+		{subnets: []int{1, 5}, node: 6, err: true},
+		{subnets: []int{1}, node: -1, err: true},
+	}
+
+	for _, tt := range test {
+		subnet, network, err := GetNodeNetwork(tt.subnets, tt.node)
+		if (err != nil) != tt.err {
+			t.Errorf("unexpected result from GetNodeNetwork(%v,%d): %v", tt.subnets, tt.node, err)
+			continue
+		}
+		if subnet != tt.subnet || network != tt.network {
+			t.Errorf("GetNodeNetwork(%v,%d) returned subnet=%d,network=%d. Expected subnet=%d,network=%d",
+				tt.subnets, tt.node, subnet, network, tt.subnet, tt.network)
+		}
+	}
+}
+
+func TestGetNodeFromIP(t *testing.T) {
+	conf.ServerBits = 8
+	conf.NodeBits = 4
+	conf.ClusterBits = 2
+	conf.IPPrefix = 10
+	subnets := []int{1, 5}
+	for node := 0; node < 6; node++ {
+		subnet, network, err := GetNodeNetwork(subnets, node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, index := range []int{0, 1} {
+			ip, err := GetNodeIP(subnet, network, index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, ok := GetNodeFromIP(subnets, ip)
+			if !ok || out != node {
+				t.Errorf("GetNodeFromIP(%v,\"%s\") returned {%d,%v}. Expected {%d,true}", subnets, ip, out, ok, node)
+			}
+		}
+	}
+	if _, ok := GetNodeFromIP(subnets, "10.2.0.2"); ok {
+		t.Error("GetNodeFromIP found a node for an address outside of the ip blocks of the server")
+	}
+}
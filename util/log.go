@@ -0,0 +1,75 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	log "github.com/sirupsen/logrus"
+	"os"
+)
+
+// GCPFormatter enables the ability to use genesis logging with Stackdriver
+type GCPFormatter struct {
+	JSON           *log.JSONFormatter
+	ConstantFields log.Fields
+}
+
+// Format takes in the entry and processes it into the appropiate log entry
+func (gf GCPFormatter) Format(entry *log.Entry) ([]byte, error) {
+	for k, v := range gf.ConstantFields {
+		entry.Data[k] = v
+	}
+	return gf.JSON.Format(entry)
+}
+
+// configureLogging sets up the global logger based on the logging options in the config.
+// Log entries should be given the fields "build", "server", and "node" where relevant, so
+// that the logs for a given testnet can be easily filtered.
+func configureLogging() {
+	lvl, err := log.ParseLevel(conf.Verbosity)
+	if err != nil {
+		lvl = log.InfoLevel
+		log.Warn(err)
+	}
+	log.SetLevel(lvl)
+	log.SetReportCaller(conf.LogReportCaller)
+
+	if len(conf.LogFile) > 0 {
+		file, err := os.OpenFile(conf.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.WithFields(log.Fields{"file": conf.LogFile, "error": err}).Error("unable to open the log file")
+		} else {
+			log.SetOutput(file)
+		}
+	}
+
+	if conf.LogJSON {
+		log.SetFormatter(&GCPFormatter{
+			JSON: &log.JSONFormatter{
+				FieldMap: log.FieldMap{
+					log.FieldKeyTime:  "eventTime",
+					log.FieldKeyLevel: "severity",
+					log.FieldKeyMsg:   "message",
+				},
+			},
+			ConstantFields: log.Fields{
+				"serviceContext": map[string]string{"service": "genesis", "version": "1.8.2"},
+			},
+		})
+	}
+}
@@ -56,6 +56,12 @@ func Distances(pnts []Point) [][]float64 {
 // Distribute generates a roughly uniform random distribution for connections
 // among nodes.
 func Distribute(nodes []string, dist []int) ([][]string, error) {
+	return DistributeWithSeed(nodes, dist, time.Now().UnixNano())
+}
+
+// DistributeWithSeed is Distribute, with the randomness seeded by the given seed so that the same
+// distribution is generated every time
+func DistributeWithSeed(nodes []string, dist []int, seed int64) ([][]string, error) {
 	if len(nodes) < 2 {
 		return nil, fmt.Errorf("cannot distribute a series smaller than 1")
 	}
@@ -64,7 +70,7 @@ func Distribute(nodes []string, dist []int) ([][]string, error) {
 			return nil, fmt.Errorf("cannot distribute among more nodes than those that are provided")
 		}
 	}
-	s1 := rand.NewSource(time.Now().UnixNano())
+	s1 := rand.NewSource(seed)
 	r1 := rand.New(s1)
 
 	out := [][]string{}
@@ -197,13 +203,19 @@ func generateNoDuplicateMeshNetwork(nodes int, conns int, seed int64) ([][]int,
 // the if built in order, each node will be given a list of peers which is already up and running.
 // Note: This means that the first node will have an empty list
 func GenerateDependentMeshNetwork(nodes int, conns int) ([][]int, error) {
+	return GenerateDependentMeshNetworkWithSeed(nodes, conns, time.Now().UnixNano())
+}
+
+// GenerateDependentMeshNetworkWithSeed is GenerateDependentMeshNetwork, with the randomness seeded by the
+// given seed so that the same network is generated every time
+func GenerateDependentMeshNetworkWithSeed(nodes int, conns int, seed int64) ([][]int, error) {
 	if conns < 1 {
 		return nil, fmt.Errorf("each node must have at least one connection")
 	}
 	if conns >= nodes {
 		return nil, fmt.Errorf("too many connection to distribute without duplicates")
 	}
-	s1 := rand.NewSource(time.Now().UnixNano())
+	s1 := rand.NewSource(seed)
 	rng := rand.New(s1)
 	out := make([][]int, nodes)
 	nodeToEnsure := 0
@@ -241,3 +241,32 @@ func TestGenerateDependentMeshNetwork(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateDependentMeshNetworkWithSeed(t *testing.T) {
+	out, err := GenerateDependentMeshNetworkWithSeed(10, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := GenerateDependentMeshNetworkWithSeed(10, 3, 5)
+	if !reflect.DeepEqual(out, again) {
+		t.Error("the same seed generated a different network")
+	}
+}
+
+func TestDistributeWithSeed(t *testing.T) {
+	nodes := []string{"a", "b", "c", "d", "e"}
+	dist := []int{2, 1, 3, 1, 2}
+	out, err := DistributeWithSeed(nodes, dist, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, conns := range out {
+		if len(conns) != dist[i] {
+			t.Errorf("node %d was given %d connections instead of %d", i, len(conns), dist[i])
+		}
+	}
+	again, _ := DistributeWithSeed(nodes, dist, 5)
+	if !reflect.DeepEqual(out, again) {
+		t.Error("the same seed generated a different distribution")
+	}
+}
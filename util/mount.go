@@ -0,0 +1,92 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ValidateVolumeName ensures that the given name is a valid name for a named volume
+func ValidateVolumeName(name string) error {
+	if !volumeNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid volume name \"%s\"", name)
+	}
+	return nil
+}
+
+// Mount is a volume mounted into a node, which keeps its data outside of the filesystem of the container
+type Mount struct {
+	// Name is the name of a named volume, which is created for the node. Either Name or Source must be given.
+	Name string `json:"name"`
+	// Source is a directory on the server which is bind mounted into the node.
+	// Requires enableDockerVolumes.
+	Source string `json:"source"`
+	// Target is the absolute path of the mount in the node
+	Target string `json:"target"`
+	// Size is a hint of how large the named volume will grow, such as "20gb"
+	Size string `json:"size"`
+	// ReadOnly mounts the volume as read only
+	ReadOnly bool `json:"readOnly"`
+	// Preserve keeps the named volume when the testnet is torn down, so that its data can be snapshot
+	Preserve bool `json:"preserve"`
+}
+
+// Validate ensures that the mount is either a named volume or a bind mount, into an absolute path
+func (m Mount) Validate() error {
+	if len(m.Name) > 0 == (len(m.Source) > 0) {
+		return fmt.Errorf("a mount needs either a name or a source")
+	}
+	if len(m.Name) > 0 {
+		err := ValidateVolumeName(m.Name)
+		if err != nil {
+			return err
+		}
+	}
+	if len(m.Source) > 0 {
+		err := ValidateFilePath(m.Source)
+		if err != nil {
+			return fmt.Errorf("invalid mount source: %s", err.Error())
+		}
+		if !path.IsAbs(m.Source) {
+			return fmt.Errorf("the mount source \"%s\" must be an absolute path", m.Source)
+		}
+		if m.Preserve || len(m.Size) > 0 {
+			return fmt.Errorf("preserve and size only apply to named volumes")
+		}
+	}
+	err := ValidateFilePath(m.Target)
+	if err != nil {
+		return fmt.Errorf("invalid mount target: %s", err.Error())
+	}
+	if !path.IsAbs(m.Target) || strings.Contains(m.Target, ":") {
+		return fmt.Errorf("the mount target \"%s\" must be an absolute path", m.Target)
+	}
+	if len(m.Size) > 0 {
+		_, err = ParseSize(m.Size)
+		if err != nil {
+			return fmt.Errorf("invalid size \"%s\" for volume %s", m.Size, m.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMount_Validate(t *testing.T) {
+	var test = []struct {
+		mount Mount
+		valid bool
+	}{
+		{mount: Mount{Name: "data", Target: "/data"}, valid: true},
+		{mount: Mount{Name: "data", Target: "/data", Size: "20gb", Preserve: true}, valid: true},
+		{mount: Mount{Source: "/mnt/chain", Target: "/data", ReadOnly: true}, valid: true},
+		{mount: Mount{Target: "/data"}, valid: false},
+		{mount: Mount{Name: "data", Source: "/mnt/chain", Target: "/data"}, valid: false},
+		{mount: Mount{Name: "../data", Target: "/data"}, valid: false},
+		{mount: Mount{Name: "data", Target: "data"}, valid: false},
+		{mount: Mount{Name: "data", Target: "/data:rw"}, valid: false},
+		{mount: Mount{Source: "mnt/chain", Target: "/data"}, valid: false},
+		{mount: Mount{Source: "/mnt/chain", Target: "/data", Preserve: true}, valid: false},
+		{mount: Mount{Name: "data", Target: "/data", Size: "big"}, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.mount.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("expected %+v to be valid: %s", tt.mount, err.Error())
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected %+v to be invalid", tt.mount)
+			}
+		})
+	}
+}
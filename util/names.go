@@ -0,0 +1,135 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ScopeLength is the number of characters of the testnet id which are used
+// to scope the names of the containers belonging to that testnet
+const ScopeLength = 8
+
+// GetNameScope gets the portion of the testnet id used to scope container names
+func GetNameScope(testnetID string) string {
+	if len(testnetID) > ScopeLength {
+		return testnetID[:ScopeLength]
+	}
+	return testnetID
+}
+
+// GetContainerPrefix gets the prefix shared by the names of all of the containers
+// in the given testnet. If the testnet id is empty, the legacy, unscoped prefix is given
+func GetContainerPrefix(testnetID string) string {
+	if len(testnetID) == 0 {
+		return conf.NodePrefix
+	}
+	return fmt.Sprintf("%s%s-", conf.NodePrefix, GetNameScope(testnetID))
+}
+
+// GetNodeName gets the container name of the node with the given relative number in
+// the given testnet.
+func GetNodeName(testnetID string, node int) string {
+	return fmt.Sprintf("%s%d", GetContainerPrefix(testnetID), node)
+}
+
+// GetSideCarName gets the container name of a side car, from the testnet, the relative number of the node
+// it supports, and its network index
+func GetSideCarName(testnetID string, node int, index int) string {
+	return fmt.Sprintf("%s-%d", GetNodeName(testnetID, node), index)
+}
+
+// GetServicePrefix gets the prefix shared by the names of all of the service containers
+// in the given testnet
+func GetServicePrefix(testnetID string) string {
+	return fmt.Sprintf("%s%s-", conf.ServicePrefix, GetNameScope(testnetID))
+}
+
+// GetServiceName gets the container name of the given service in the given testnet
+func GetServiceName(testnetID string, service string) string {
+	return GetServicePrefix(testnetID) + service
+}
+
+// GetLegacyNodeName gets the name a node container was given before container names were
+// scoped by testnet
+func GetLegacyNodeName(node int) string {
+	return GetNodeName("", node)
+}
+
+// GetLegacySideCarName gets the name a side car container was given before container names were
+// scoped by testnet
+func GetLegacySideCarName(node int, index int) string {
+	return GetSideCarName("", node, index)
+}
+
+// GetNetworkName gets the name of the docker network of the node with the given relative number in
+// the given testnet. If the testnet id is empty, the legacy, unscoped name is given
+func GetNetworkName(testnetID string, node int) string {
+	if len(testnetID) == 0 {
+		return fmt.Sprintf("%s%d", conf.NodeNetworkPrefix, node)
+	}
+	return fmt.Sprintf("%s%s-%d", conf.NodeNetworkPrefix, GetNameScope(testnetID), node)
+}
+
+// GetLegacyNetworkName gets the name a node network was given before network names were
+// scoped by testnet
+func GetLegacyNetworkName(node int) string {
+	return GetNetworkName("", node)
+}
+
+// ParseContainerName extracts the name scope and the relative node number from the name of a node
+// or side car container. The scope will be empty for containers named using the legacy naming scheme.
+// Gives an error if the name was not generated by genesis.
+func ParseContainerName(name string) (string, int, error) {
+	scope, node, ok := parseScopedName(name, conf.NodePrefix)
+	if !ok {
+		return "", -1, fmt.Errorf("\"%s\" is not a node container", name)
+	}
+	return scope, node, nil
+}
+
+// ParseNetworkName extracts the name scope and the relative node number from the name of a node network.
+// The scope will be empty for networks named using the legacy naming scheme.
+// Gives an error if the name was not generated by genesis.
+func ParseNetworkName(name string) (string, int, error) {
+	scope, node, ok := parseScopedName(name, conf.NodeNetworkPrefix)
+	if !ok {
+		return "", -1, fmt.Errorf("\"%s\" is not a node network", name)
+	}
+	return scope, node, nil
+}
+
+func parseScopedName(name string, prefix string) (string, int, bool) {
+	if !strings.HasPrefix(name, prefix) {
+		return "", -1, false
+	}
+	parts := strings.Split(strings.TrimPrefix(name, prefix), "-")
+	scope := ""
+	if len(parts) > 1 && len(parts[0]) == ScopeLength {
+		scope = parts[0]
+		parts = parts[1:]
+	}
+	node, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", -1, false
+	}
+	return scope, node, true
+}
@@ -0,0 +1,159 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestGetNodeName(t *testing.T) {
+	conf.NodePrefix = "whiteblock-node"
+	var test = []struct {
+		testnetID string
+		node      int
+		expected  string
+	}{
+		{testnetID: "", node: 0, expected: "whiteblock-node0"},
+		{testnetID: "", node: 12, expected: "whiteblock-node12"},
+		{testnetID: "abc", node: 1, expected: "whiteblock-nodeabc-1"},
+		{testnetID: "4ac9d3b2-1a2b-4c5d-9e8f-0123456789ab", node: 3, expected: "whiteblock-node4ac9d3b2-3"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if GetNodeName(tt.testnetID, tt.node) != tt.expected {
+				t.Errorf("return value of GetNodeName(%s,%d) does not match expected value", tt.testnetID, tt.node)
+			}
+		})
+	}
+}
+
+func TestGetServiceName(t *testing.T) {
+	conf.ServicePrefix = "wb_service"
+	var test = []struct {
+		testnetID string
+		service   string
+		expected  string
+	}{
+		{testnetID: "abc", service: "ganache", expected: "wb_serviceabc-ganache"},
+		{testnetID: "4ac9d3b2-1a2b-4c5d-9e8f-0123456789ab", service: "prometheus", expected: "wb_service4ac9d3b2-prometheus"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if GetServiceName(tt.testnetID, tt.service) != tt.expected {
+				t.Errorf("return value of GetServiceName(%s,%s) does not match expected value", tt.testnetID, tt.service)
+			}
+		})
+	}
+}
+
+func TestGetSideCarName(t *testing.T) {
+	conf.NodePrefix = "whiteblock-node"
+	var test = []struct {
+		testnetID string
+		node      int
+		index     int
+		expected  string
+	}{
+		{testnetID: "", node: 0, index: 1, expected: "whiteblock-node0-1"},
+		{testnetID: "4ac9d3b2-1a2b-4c5d-9e8f-0123456789ab", node: 3, index: 2, expected: "whiteblock-node4ac9d3b2-3-2"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if GetSideCarName(tt.testnetID, tt.node, tt.index) != tt.expected {
+				t.Errorf("return value of GetSideCarName(%s,%d,%d) does not match expected value",
+					tt.testnetID, tt.node, tt.index)
+			}
+		})
+	}
+}
+
+func TestParseContainerName(t *testing.T) {
+	conf.NodePrefix = "whiteblock-node"
+	var test = []struct {
+		name  string
+		scope string
+		node  int
+		err   bool
+	}{
+		{name: "whiteblock-node0", scope: "", node: 0, err: false},
+		{name: "whiteblock-node12-1", scope: "", node: 12, err: false},
+		{name: "whiteblock-node4ac9d3b2-3", scope: "4ac9d3b2", node: 3, err: false},
+		{name: "whiteblock-node4ac9d3b2-3-2", scope: "4ac9d3b2", node: 3, err: false},
+		{name: "wb_service0", scope: "", node: -1, err: true},
+		{name: "whiteblock-nodeabc", scope: "", node: -1, err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			scope, node, err := ParseContainerName(tt.name)
+			if scope != tt.scope || node != tt.node || (err != nil) != tt.err {
+				t.Errorf("ParseContainerName(\"%s\") returned (%s,%d,%v)", tt.name, scope, node, err)
+			}
+		})
+	}
+}
+
+func TestGetNetworkName(t *testing.T) {
+	conf.NodeNetworkPrefix = "wb_vlan"
+	var test = []struct {
+		testnetID string
+		node      int
+		expected  string
+	}{
+		{testnetID: "", node: 0, expected: "wb_vlan0"},
+		{testnetID: "abc", node: 1, expected: "wb_vlanabc-1"},
+		{testnetID: "4ac9d3b2-1a2b-4c5d-9e8f-0123456789ab", node: 3, expected: "wb_vlan4ac9d3b2-3"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if GetNetworkName(tt.testnetID, tt.node) != tt.expected {
+				t.Errorf("return value of GetNetworkName(%s,%d) does not match expected value", tt.testnetID, tt.node)
+			}
+		})
+	}
+}
+
+func TestParseNetworkName(t *testing.T) {
+	conf.NodeNetworkPrefix = "wb_vlan"
+	var test = []struct {
+		name  string
+		scope string
+		node  int
+		err   bool
+	}{
+		{name: "wb_vlan0", scope: "", node: 0, err: false},
+		{name: "wb_vlan4ac9d3b2-3", scope: "4ac9d3b2", node: 3, err: false},
+		{name: "whiteblock-node0", scope: "", node: -1, err: true},
+		{name: "wb_vlanabc", scope: "", node: -1, err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			scope, node, err := ParseNetworkName(tt.name)
+			if scope != tt.scope || node != tt.node || (err != nil) != tt.err {
+				t.Errorf("ParseNetworkName(\"%s\") returned (%s,%d,%v)", tt.name, scope, node, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,389 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ParamError is a param of a build which failed to decode or to validate
+type ParamError struct {
+	// Param is the name of the param
+	Param string
+	// Err is why the param is invalid
+	Err error
+}
+
+// Error gives the error, prefixed by the param
+func (pe ParamError) Error() string {
+	return fmt.Sprintf("param %s: %s", pe.Param, pe.Err.Error())
+}
+
+// paramRules are the rules given in the param tag of a field
+type paramRules struct {
+	required bool
+	def      *string
+	min      *float64
+	max      *float64
+	oneOf    []string
+	// size is the unit of an integer which may be given as a size, such as 2GB
+	size int64
+	// duration is the unit of an integer which may be given as a duration, such as 30s
+	duration time.Duration
+}
+
+func parseParamRules(tag string) (paramRules, error) {
+	out := paramRules{}
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		if len(rule) == 0 {
+			continue
+		}
+		kv := strings.SplitN(rule, "=", 2)
+		switch rule {
+		case "required":
+			out.required = true
+			continue
+		case "size":
+			out.size = 1
+			continue
+		case "duration":
+			out.duration = time.Second
+			continue
+		}
+		if len(kv) != 2 {
+			return out, fmt.Errorf("invalid param rule \"%s\"", rule)
+		}
+		switch kv[0] {
+		case "default":
+			def := kv[1]
+			out.def = &def
+		case "min", "max":
+			bound, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return out, fmt.Errorf("invalid param rule \"%s\"", rule)
+			}
+			if kv[0] == "min" {
+				out.min = &bound
+			} else {
+				out.max = &bound
+			}
+		case "oneof":
+			out.oneOf = strings.Split(kv[1], "|")
+		case "size":
+			unit, err := ParseSize("1" + kv[1])
+			if err != nil {
+				return out, fmt.Errorf("invalid param rule \"%s\"", rule)
+			}
+			out.size = unit
+		case "duration":
+			unit, err := ParseDuration("1"+kv[1], time.Second)
+			if err != nil || unit <= 0 {
+				return out, fmt.Errorf("invalid param rule \"%s\"", rule)
+			}
+			out.duration = unit
+		default:
+			return out, fmt.Errorf("unknown param rule \"%s\"", rule)
+		}
+	}
+	return out, nil
+}
+
+// lookupParam finds the value of a param, matching its name case insensitively if it is not given
+// exactly, as encoding/json does
+func lookupParam(params map[string]interface{}, name string) (interface{}, bool) {
+	if val, ok := params[name]; ok {
+		return val, ok
+	}
+	for key, val := range params {
+		if strings.EqualFold(key, name) {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+func toFloat(raw interface{}) (float64, bool) {
+	switch val := raw.(type) {
+	case json.Number:
+		out, err := val.Float64()
+		return out, err == nil
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case uint64:
+		return float64(val), true
+	}
+	return 0, false
+}
+
+// convertUnits converts a size or a duration given as a string into a number of the unit of the field
+func convertUnits(field reflect.Value, raw interface{}, rules paramRules) (interface{}, error) {
+	str, ok := raw.(string)
+	if field.Type() == durationType {
+		if !ok {
+			val, ok := toFloat(raw)
+			if !ok {
+				return nil, fmt.Errorf("expected a duration such as 30s, got %v", raw)
+			}
+			return val * float64(time.Second), nil
+		}
+		duration, err := ParseDuration(str, time.Second)
+		return int64(duration), err
+	}
+	if !ok {
+		return raw, nil
+	}
+	if rules.size != 0 {
+		size, err := ParseSize(str)
+		if err != nil {
+			return nil, err
+		}
+		if size%rules.size != 0 {
+			return nil, fmt.Errorf("\"%s\" is not a whole number of units of %d bytes", str, rules.size)
+		}
+		return size / rules.size, nil
+	}
+	if rules.duration != 0 {
+		duration, err := ParseDuration(str, rules.duration)
+		if err != nil {
+			return nil, err
+		}
+		if duration%rules.duration != 0 {
+			return nil, fmt.Errorf("\"%s\" is not a whole number of %s", str, rules.duration)
+		}
+		return int64(duration / rules.duration), nil
+	}
+	return raw, nil
+}
+
+// setParam sets the field to the given raw value, converting between the types which the values of the
+// params may have and the type of the field
+func setParam(field reflect.Value, raw interface{}, rules paramRules) error {
+	raw, err := convertUnits(field, raw, rules)
+	if err != nil {
+		return err
+	}
+	if _, ok := field.Addr().Interface().(json.Unmarshaler); !ok {
+		switch field.Kind() {
+		case reflect.String:
+			switch val := raw.(type) {
+			case string:
+				field.SetString(val)
+				return nil
+			case json.Number:
+				field.SetString(val.String()) //big numbers, such as balances, are often given as numbers
+				return nil
+			}
+			return fmt.Errorf("expected a string, got %v", raw)
+		case reflect.Bool:
+			val, ok := raw.(bool)
+			if !ok {
+				return fmt.Errorf("expected a boolean, got %v", raw)
+			}
+			field.SetBool(val)
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if num, ok := raw.(json.Number); ok {
+				val, err := num.Int64()
+				if err != nil {
+					return fmt.Errorf("expected an integer, got %v", raw)
+				}
+				raw = val
+			}
+			val, ok := toFloat(raw)
+			if !ok || val != math.Trunc(val) {
+				return fmt.Errorf("expected an integer, got %v", raw)
+			}
+			if field.OverflowInt(int64(val)) {
+				return fmt.Errorf("%v is out of range", raw)
+			}
+			if num, ok := raw.(int64); ok {
+				field.SetInt(num) //keep the precision of large integers
+			} else {
+				field.SetInt(int64(val))
+			}
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			val, ok := toFloat(raw)
+			if !ok || val != math.Trunc(val) || val < 0 {
+				return fmt.Errorf("expected a positive integer, got %v", raw)
+			}
+			if field.OverflowUint(uint64(val)) {
+				return fmt.Errorf("%v is out of range", raw)
+			}
+			field.SetUint(uint64(val))
+			return nil
+		case reflect.Float32, reflect.Float64:
+			val, ok := toFloat(raw)
+			if !ok {
+				return fmt.Errorf("expected a number, got %v", raw)
+			}
+			field.SetFloat(val)
+			return nil
+		}
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	tmp := reflect.New(field.Type())
+	err = json.Unmarshal(data, tmp.Interface())
+	if err != nil {
+		return fmt.Errorf("expected a value of type %s, got %s", field.Type(), string(data))
+	}
+	field.Set(tmp.Elem())
+	return nil
+}
+
+// parseDefault gives the default of a field as it would have been given in the params
+func parseDefault(field reflect.Value, rules paramRules, def string) interface{} {
+	if field.Type() == durationType || rules.size != 0 || rules.duration != 0 {
+		return def
+	}
+	switch field.Kind() {
+	case reflect.String:
+		return def
+	case reflect.Bool:
+		val, err := strconv.ParseBool(def)
+		if err != nil {
+			return def
+		}
+		return val
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return json.Number(def)
+	}
+	return json.RawMessage(def)
+}
+
+// checkParam checks the value of the field against the rules
+func checkParam(field reflect.Value, rules paramRules) error {
+	var size float64
+	switch field.Kind() {
+	case reflect.String:
+		if len(rules.oneOf) > 0 {
+			for _, option := range rules.oneOf {
+				if field.String() == option {
+					return nil
+				}
+			}
+			return fmt.Errorf("must be one of %s, got \"%s\"", strings.Join(rules.oneOf, ", "), field.String())
+		}
+		size = float64(len(field.String()))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		size = float64(field.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(field.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = field.Float()
+	default:
+		return nil
+	}
+	if rules.min != nil && size < *rules.min {
+		return fmt.Errorf("must be at least %v, got %v", *rules.min, size)
+	}
+	if rules.max != nil && size > *rules.max {
+		return fmt.Errorf("must be at most %v, got %v", *rules.max, size)
+	}
+	return nil
+}
+
+// DecodeParams decodes the params of a build into out, which must be a pointer to a struct. Each field is
+// given by the param named in its json tag, and is checked against the rules in its param tag:
+//
+//	required     the param must be given, unless the field already has a value
+//	default=v    the value of the field when the param is not given and the field has no value
+//	min=n,max=n  the bounds of a number, or of the length of a string, slice or map
+//	oneof=a|b    the values a string may take
+//	size=unit    an integer may be given as a size, such as 2GB, and is stored as a number of the unit,
+//	             such as mb, or of bytes when the unit is omitted
+//	duration=u   an integer may be given as a duration, such as 5m, and is stored as a number of the unit,
+//	             such as ms, or of seconds when the unit is omitted
+//
+// A time.Duration field may be given as a duration, or as a number of seconds.
+//
+// The fields of params which are not given are left as they are, so that out can be filled in with the
+// defaults of the blockchain first. Every invalid param is returned at once, as a MultiError of ParamErrors.
+func DecodeParams(params map[string]interface{}, out interface{}) error {
+	ptr := reflect.ValueOf(out)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("params can only be decoded into a pointer to a struct, not %T", out)
+	}
+	val := ptr.Elem()
+	typ := val.Type()
+	errs := MultiError{}
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+		if len(fieldType.PkgPath) > 0 { //unexported
+			continue
+		}
+		name := strings.Split(fieldType.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			name = fieldType.Name
+		}
+		rules, err := parseParamRules(fieldType.Tag.Get("param"))
+		if err != nil {
+			return fmt.Errorf("field %s: %s", fieldType.Name, err.Error())
+		}
+		field := val.Field(i)
+		raw, ok := lookupParam(params, name)
+		if !ok || raw == nil {
+			if !field.IsZero() {
+				continue
+			}
+			if rules.required {
+				errs = append(errs, ParamError{Param: name, Err: fmt.Errorf("is required")})
+				continue
+			}
+			if rules.def == nil {
+				continue
+			}
+			raw = parseDefault(field, rules, *rules.def)
+		}
+		err = setParam(field, raw, rules)
+		if err == nil {
+			err = checkParam(field, rules)
+		}
+		if err != nil {
+			errs = append(errs, ParamError{Param: name, Err: err})
+		}
+	}
+	return errs.ErrorOrNil()
+}
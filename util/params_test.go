@@ -0,0 +1,173 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testParams struct {
+	Name      string   `json:"name" param:"required"`
+	Mode      string   `json:"mode" param:"default=full,oneof=full|fast|light"`
+	Peers     int64    `json:"peers" param:"default=25,min=1,max=100"`
+	Ratio     float64  `json:"ratio" param:"max=1"`
+	Port      uint16   `json:"port"`
+	Unlock    bool     `json:"unlock" param:"default=true"`
+	Balance   string   `json:"balance"`
+	Bootnodes []string `json:"bootnodes" param:"default=[\"a\"],max=2"`
+	Ignored   string   `json:"-"`
+	internal  int
+}
+
+func TestDecodeParams(t *testing.T) {
+	var test = []struct {
+		params   map[string]interface{}
+		initial  testParams
+		expected testParams
+		errs     []string
+	}{
+		{
+			params: map[string]interface{}{"name": "a"},
+			expected: testParams{Name: "a", Mode: "full", Peers: 25, Unlock: true,
+				Bootnodes: []string{"a"}},
+		},
+		{
+			params: map[string]interface{}{"name": "a", "mode": "fast", "peers": json.Number("3"),
+				"ratio": json.Number("0.5"), "port": 30303, "unlock": false,
+				"balance": json.Number("100000000000000000000"), "bootnodes": []interface{}{"b", "c"},
+				"Ignored": "x"},
+			expected: testParams{Name: "a", Mode: "fast", Peers: 3, Ratio: 0.5, Port: 30303, Unlock: false,
+				Balance: "100000000000000000000", Bootnodes: []string{"b", "c"}},
+		},
+		{
+			params:   map[string]interface{}{"NAME": "a", "Peers": json.Number("7")},
+			initial:  testParams{Mode: "light", Bootnodes: []string{}},
+			expected: testParams{Name: "a", Mode: "light", Peers: 7, Unlock: true, Bootnodes: []string{}},
+		},
+		{
+			params: map[string]interface{}{"mode": "slow", "peers": json.Number("1.5"), "ratio": "1",
+				"port": -1, "unlock": "yes", "bootnodes": []interface{}{"a", "b", "c"}},
+			errs: []string{"param name: is required", "param mode: must be one of full, fast, light",
+				"param peers: expected an integer", "param ratio: expected a number",
+				"param port: expected a positive integer", "param unlock: expected a boolean",
+				"param bootnodes: must be at most 2"},
+		},
+		{
+			params: map[string]interface{}{"name": "a", "peers": json.Number("101"), "port": 70000},
+			errs:   []string{"param peers: must be at most 100", "param port: 70000 is out of range"},
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out := tt.initial
+			err := DecodeParams(tt.params, &out)
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(out, tt.expected) {
+					t.Errorf("expected %+v, got %+v", tt.expected, out)
+				}
+				return
+			}
+			errs, ok := err.(MultiError)
+			if !ok || len(errs) != len(tt.errs) {
+				t.Fatalf("expected %d errors, got %v", len(tt.errs), err)
+			}
+			for j := range errs {
+				if _, ok := errs[j].(ParamError); !ok || !strings.HasPrefix(errs[j].Error(), tt.errs[j]) {
+					t.Errorf("expected \"%s\", got \"%s\"", tt.errs[j], errs[j].Error())
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeParams_Invalid(t *testing.T) {
+	var notStruct map[string]interface{}
+	if DecodeParams(map[string]interface{}{}, &notStruct) == nil {
+		t.Error("expected an error decoding into a map")
+	}
+	var badTag struct {
+		Value int `json:"value" param:"between=1"`
+	}
+	if DecodeParams(map[string]interface{}{}, &badTag) == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
+
+func TestDecodeParams_Units(t *testing.T) {
+	type unitParams struct {
+		Timeout   time.Duration `json:"timeout" param:"default=30s"`
+		Interval  int64         `json:"interval" param:"duration=ms"`
+		Wait      int           `json:"wait" param:"duration,max=3600"`
+		BlockSize int64         `json:"blockSize" param:"size"`
+		CacheMb   int64         `json:"cacheMb" param:"size=mb,default=1gb"`
+	}
+	var test = []struct {
+		params   map[string]interface{}
+		expected unitParams
+		errs     int
+	}{
+		{
+			params:   map[string]interface{}{},
+			expected: unitParams{Timeout: 30 * time.Second, CacheMb: 1000},
+		},
+		{
+			params: map[string]interface{}{"timeout": "1m", "interval": "2s", "wait": "1h", "blockSize": "2MiB",
+				"cacheMb": "512mb"},
+			expected: unitParams{Timeout: time.Minute, Interval: 2000, Wait: 3600, BlockSize: 2097152, CacheMb: 512},
+		},
+		{
+			params: map[string]interface{}{"timeout": json.Number("5"), "interval": json.Number("250"),
+				"wait": json.Number("10"), "blockSize": json.Number("1024"), "cacheMb": json.Number("64")},
+			expected: unitParams{Timeout: 5 * time.Second, Interval: 250, Wait: 10, BlockSize: 1024, CacheMb: 64},
+		},
+		{
+			params: map[string]interface{}{"timeout": "soon", "interval": "1us", "wait": "2h", "blockSize": "big",
+				"cacheMb": "1kb"},
+			errs: 5,
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var out unitParams
+			err := DecodeParams(tt.params, &out)
+			if tt.errs > 0 {
+				if errs, ok := err.(MultiError); !ok || len(errs) != tt.errs {
+					t.Errorf("expected %d errors, got %v", tt.errs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(out, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PatchJSON applies the given patch to the json document doc. A patch which is an object is taken to be a
+// JSON merge patch (RFC 7386), and one which is an array to be a JSON patch (RFC 6902).
+func PatchJSON(doc []byte, patch []byte) ([]byte, error) {
+	target, err := decodeJSON(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document: %s", err.Error())
+	}
+	generic, err := decodeJSON(patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch: %s", err.Error())
+	}
+	switch p := generic.(type) {
+	case map[string]interface{}:
+		target = mergePatch(target, p)
+	case []interface{}:
+		for i, rawOp := range p {
+			target, err = applyPatchOp(target, rawOp)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: %s", i, err.Error())
+			}
+		}
+	default:
+		return nil, fmt.Errorf("the patch must be an object or an array of operations")
+	}
+	return json.MarshalIndent(target, "", "  ")
+}
+
+// decodeJSON decodes the given json, keeping numbers as they were written so that large values are not
+// rounded off
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var out interface{}
+	err := decoder.Decode(&out)
+	if err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the json value")
+	}
+	return out, nil
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+func applyPatchOp(doc interface{}, rawOp interface{}) (interface{}, error) {
+	op, ok := rawOp.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("not an object")
+	}
+	name, _ := op["op"].(string)
+	path, err := getPatchPointer(op, "path")
+	if err != nil {
+		return nil, err
+	}
+	value, hasValue := op["value"]
+	if !hasValue && (name == "add" || name == "replace" || name == "test") {
+		return nil, fmt.Errorf("missing the value of the %s operation", name)
+	}
+
+	switch name {
+	case "add":
+		return addJSONValue(doc, path, value)
+	case "remove":
+		_, err = getJSONValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return removeJSONValue(doc, path)
+	case "replace":
+		_, err = getJSONValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		return updateJSONValue(doc, path, func(container interface{}, token string) (interface{}, error) {
+			if obj, ok := container.(map[string]interface{}); ok {
+				obj[token] = value
+				return obj, nil
+			}
+			arr := container.([]interface{})
+			i, _ := jsonIndex(token, len(arr)-1)
+			arr[i] = value
+			return arr, nil
+		})
+	case "move", "copy":
+		from, err := getPatchPointer(op, "from")
+		if err != nil {
+			return nil, err
+		}
+		value, err = getJSONValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if name == "copy" {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			value, err = decodeJSON(data)
+			if err != nil {
+				return nil, err
+			}
+			return addJSONValue(doc, path, value)
+		}
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, fmt.Errorf("cannot move a value into one of its children")
+		}
+		doc, err = removeJSONValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addJSONValue(doc, path, value)
+	case "test":
+		actual, err := getJSONValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(actual, value) {
+			return nil, fmt.Errorf("test failed, the value at \"%s\" is %v", op["path"], actual)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation \"%s\"", name)
+}
+
+// getPatchPointer gets the tokens of the json pointer (RFC 6901) in the given field of an operation
+func getPatchPointer(op map[string]interface{}, field string) ([]string, error) {
+	pointer, ok := op[field].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing the %s of the operation", field)
+	}
+	if len(pointer) == 0 {
+		return []string{}, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("the %s \"%s\" does not start with \"/\"", field, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(tokens[i], "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// jsonIndex parses an array index, which must be at most max
+func jsonIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index \"%s\"", token)
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index %d is out of bounds", i)
+	}
+	return i, nil
+}
+
+func getJSONValue(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("no member \"%s\"", token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := jsonIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[i]
+		default:
+			return nil, fmt.Errorf("cannot get \"%s\" from a value which is not an object or an array", token)
+		}
+	}
+	return doc, nil
+}
+
+// updateJSONValue calls fn with the container of the value at path and the last token of path, and replaces
+// that container with the one given by fn
+func updateJSONValue(doc interface{}, path []string,
+	fn func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		switch doc.(type) {
+		case map[string]interface{}, []interface{}:
+			return fn(doc, path[0])
+		}
+		return nil, fmt.Errorf("cannot set \"%s\" in a value which is not an object or an array", path[0])
+	}
+	child, err := getJSONValue(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updateJSONValue(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+	if obj, ok := doc.(map[string]interface{}); ok {
+		obj[path[0]] = child
+		return obj, nil
+	}
+	arr := doc.([]interface{})
+	i, _ := jsonIndex(path[0], len(arr)-1)
+	arr[i] = child
+	return arr, nil
+}
+
+func addJSONValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateJSONValue(doc, path, func(container interface{}, token string) (interface{}, error) {
+		if obj, ok := container.(map[string]interface{}); ok {
+			obj[token] = value
+			return obj, nil
+		}
+		arr := container.([]interface{})
+		if token == "-" {
+			return append(arr, value), nil
+		}
+		i, err := jsonIndex(token, len(arr))
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, nil)
+		copy(arr[i+1:], arr[i:])
+		arr[i] = value
+		return arr, nil
+	})
+}
+
+func removeJSONValue(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+	return updateJSONValue(doc, path, func(container interface{}, token string) (interface{}, error) {
+		if obj, ok := container.(map[string]interface{}); ok {
+			delete(obj, token)
+			return obj, nil
+		}
+		arr := container.([]interface{})
+		i, err := jsonIndex(token, len(arr)-1)
+		if err != nil {
+			return nil, err
+		}
+		return append(arr[:i], arr[i+1:]...), nil
+	})
+}
+
+// jsonEqual checks if two generic json values are equal, comparing numbers by their value
+func jsonEqual(a interface{}, b interface{}) bool {
+	switch aVal := a.(type) {
+	case json.Number:
+		bVal, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		aFloat, aErr := aVal.Float64()
+		bFloat, bErr := bVal.Float64()
+		if aErr != nil || bErr != nil {
+			return aVal == bVal
+		}
+		return aFloat == bFloat
+	case map[string]interface{}:
+		bVal, ok := b.(map[string]interface{})
+		if !ok || len(aVal) != len(bVal) {
+			return false
+		}
+		for key, value := range aVal {
+			other, ok := bVal[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bVal, ok := b.([]interface{})
+		if !ok || len(aVal) != len(bVal) {
+			return false
+		}
+		for i := range aVal {
+			if !jsonEqual(aVal[i], bVal[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,97 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestPatchJSON(t *testing.T) {
+	var test = []struct {
+		doc      string
+		patch    string
+		expected string
+	}{
+		{doc: `{"a":1,"b":{"c":2}}`, patch: `{"b":{"c":3,"d":4}}`, expected: `{"a":1,"b":{"c":3,"d":4}}`},
+		{doc: `{"a":1,"b":2}`, patch: `{"a":null}`, expected: `{"b":2}`},
+		{doc: `{"a":[1,2]}`, patch: `{"a":[3]}`, expected: `{"a":[3]}`},
+		{doc: `{"a":1}`, patch: `{"big":123456789012345678901234567890}`,
+			expected: `{"a":1,"big":123456789012345678901234567890}`},
+		{doc: `{"a":[1,2]}`, patch: `[{"op":"add","path":"/a/1","value":3}]`, expected: `{"a":[1,3,2]}`},
+		{doc: `{"a":[1,2]}`, patch: `[{"op":"add","path":"/a/-","value":3}]`, expected: `{"a":[1,2,3]}`},
+		{doc: `{"a":{"b":1}}`, patch: `[{"op":"remove","path":"/a/b"}]`, expected: `{"a":{}}`},
+		{doc: `{"a/b":1}`, patch: `[{"op":"replace","path":"/a~1b","value":2}]`, expected: `{"a/b":2}`},
+		{doc: `{"a":{"b":1}}`, patch: `[{"op":"move","from":"/a/b","path":"/c"}]`, expected: `{"a":{},"c":1}`},
+		{doc: `{"a":[{"b":1}]}`, patch: `[{"op":"copy","from":"/a/0","path":"/a/-"}]`,
+			expected: `{"a":[{"b":1},{"b":1}]}`},
+		{doc: `{"a":1}`, patch: `[{"op":"test","path":"/a","value":1.0},{"op":"add","path":"","value":[]}]`,
+			expected: `[]`},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			res, err := PatchJSON([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var expected, actual interface{}
+			if err := json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(res, &actual); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected %s, got %s", tt.expected, string(res))
+			}
+		})
+	}
+}
+
+func TestPatchJSON_Errors(t *testing.T) {
+	var test = []struct {
+		doc   string
+		patch string
+	}{
+		{doc: `{"a":1`, patch: `{}`},
+		{doc: `{}`, patch: `"a"`},
+		{doc: `{}`, patch: `[{"op":"remove","path":"/a"}]`},
+		{doc: `{}`, patch: `[{"op":"replace","path":"/a","value":1}]`},
+		{doc: `{"a":[]}`, patch: `[{"op":"add","path":"/a/1","value":1}]`},
+		{doc: `{"a":[]}`, patch: `[{"op":"add","path":"/a/01","value":1}]`},
+		{doc: `{"a":1}`, patch: `[{"op":"test","path":"/a","value":2}]`},
+		{doc: `{"a":{}}`, patch: `[{"op":"move","from":"/a","path":"/a/b"}]`},
+		{doc: `{}`, patch: `[{"op":"add","path":"a","value":1}]`},
+		{doc: `{}`, patch: `[{"op":"add","path":"/a"}]`},
+		{doc: `{}`, patch: `[{"op":"unknown","path":"/a"}]`},
+		{doc: `{"a":1}`, patch: `[{"op":"add","path":"/a/b","value":1}]`},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := PatchJSON([]byte(tt.doc), []byte(tt.patch))
+			if err == nil {
+				t.Errorf("expected applying %s to %s to fail", tt.patch, tt.doc)
+			}
+		})
+	}
+}
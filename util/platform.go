@@ -0,0 +1,57 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"strings"
+)
+
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"amd64":   "amd64",
+	"aarch64": "arm64",
+	"arm64":   "arm64",
+	"armv7l":  "arm",
+	"armv6l":  "arm",
+	"i386":    "386",
+	"i686":    "386",
+}
+
+// NormalizeArch converts the machine hardware name given by uname -m to the name docker uses
+// for the architecture. Names which docker shares, such as ppc64le and s390x, are given as is.
+func NormalizeArch(machine string) string {
+	machine = strings.TrimSpace(machine)
+	if arch, ok := archAliases[machine]; ok {
+		return arch
+	}
+	return machine
+}
+
+// PlatformTag gets the name conventionally given to the variant of the image which is built for
+// the given architecture, by suffixing its tag with the architecture, such as "repo:1.0-arm64".
+// An image referenced by digest has no such variant, so an empty string is given for it.
+func PlatformTag(image string, arch string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+	if strings.LastIndex(image, ":") <= strings.LastIndex(image, "/") {
+		return image + ":latest-" + arch
+	}
+	return image + "-" + arch
+}
@@ -0,0 +1,66 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestNormalizeArch(t *testing.T) {
+	var test = []struct {
+		machine  string
+		expected string
+	}{
+		{machine: "x86_64\n", expected: "amd64"},
+		{machine: "aarch64", expected: "arm64"},
+		{machine: "armv7l", expected: "arm"},
+		{machine: "i686", expected: "386"},
+		{machine: "ppc64le", expected: "ppc64le"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if arch := NormalizeArch(tt.machine); arch != tt.expected {
+				t.Errorf("NormalizeArch returned %s, expected %s", arch, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPlatformTag(t *testing.T) {
+	var test = []struct {
+		image    string
+		expected string
+	}{
+		{image: "ethereum/client-go:v1.8.27", expected: "ethereum/client-go:v1.8.27-arm64"},
+		{image: "ethereum/client-go", expected: "ethereum/client-go:latest-arm64"},
+		{image: "localhost:5000/geth", expected: "localhost:5000/geth:latest-arm64"},
+		{image: "localhost:5000/geth:dev", expected: "localhost:5000/geth:dev-arm64"},
+		{image: "geth@sha256:0123", expected: ""},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if tag := PlatformTag(tt.image, "arm64"); tag != tt.expected {
+				t.Errorf("PlatformTag returned %s, expected %s", tag, tt.expected)
+			}
+		})
+	}
+}
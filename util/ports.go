@@ -0,0 +1,146 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// PortMapping maps a port of a container to a port of the server it is on
+type PortMapping struct {
+	// HostIP is the address of the server the port is bound to, all of them if it is empty
+	HostIP string
+	// Host is the port on the server, 0 for one to be allocated
+	Host int
+	// Container is the port in the container
+	Container int
+	// Protocol is either tcp or udp
+	Protocol string
+}
+
+func parsePort(port string) (int, error) {
+	out, err := strconv.Atoi(port)
+	if err != nil || out < 1 || out > 65535 {
+		return 0, fmt.Errorf("invalid port \"%s\"", port)
+	}
+	return out, nil
+}
+
+// ParsePortMapping parses a port mapping of the form [[host ip:]host port:]container port[/protocol]. If the
+// host port is omitted, it is left as 0, to be allocated later.
+func ParsePortMapping(mapping string) (PortMapping, error) {
+	out := PortMapping{Protocol: "tcp"}
+	ports := mapping
+	if index := strings.LastIndex(mapping, "/"); index != -1 {
+		ports = mapping[:index]
+		out.Protocol = mapping[index+1:]
+		if out.Protocol != "tcp" && out.Protocol != "udp" {
+			return out, fmt.Errorf("invalid protocol \"%s\" in port mapping \"%s\"", out.Protocol, mapping)
+		}
+	}
+	parts := strings.Split(ports, ":")
+	if len(parts) > 3 {
+		return out, fmt.Errorf("invalid port mapping \"%s\"", mapping)
+	}
+	var err error
+	out.Container, err = parsePort(parts[len(parts)-1])
+	if err != nil {
+		return out, err
+	}
+	if len(parts) > 1 && len(parts[len(parts)-2]) > 0 {
+		out.Host, err = parsePort(parts[len(parts)-2])
+		if err != nil {
+			return out, err
+		}
+	}
+	if len(parts) == 3 {
+		if net.ParseIP(parts[0]) == nil {
+			return out, fmt.Errorf("invalid host ip \"%s\" in port mapping \"%s\"", parts[0], mapping)
+		}
+		out.HostIP = parts[0]
+	}
+	return out, nil
+}
+
+// String gives the port mapping in the form taken by docker run -p
+func (pm PortMapping) String() string {
+	out := fmt.Sprintf("%d:%d/%s", pm.Host, pm.Container, pm.Protocol)
+	if len(pm.HostIP) > 0 {
+		out = pm.HostIP + ":" + out
+	}
+	return out
+}
+
+// key identifies the port on the server which the mapping uses
+func (pm PortMapping) key() string {
+	return fmt.Sprintf("%d/%s", pm.Host, pm.Protocol)
+}
+
+// PortAllocator allocates the ports of a server to port mappings, making sure that the same port is
+// not given out twice
+type PortAllocator struct {
+	min  int
+	max  int
+	used map[string]bool
+}
+
+// NewPortAllocator creates a PortAllocator which allocates from the ports within min and max
+func NewPortAllocator(min int, max int) *PortAllocator {
+	return &PortAllocator{min: min, max: max, used: map[string]bool{}}
+}
+
+// Use marks the port of the given mapping as used. Mappings without a port on the server are ignored.
+func (pa *PortAllocator) Use(mapping PortMapping) {
+	if mapping.Host != 0 {
+		pa.used[mapping.key()] = true
+	}
+}
+
+// Allocate gives each of the mappings without a host port the lowest free port within the range of the
+// allocator. Returns an error if a mapping asks for a port which is already in use, or if the range
+// has run out of ports.
+func (pa *PortAllocator) Allocate(mappings []PortMapping) ([]PortMapping, error) {
+	out := make([]PortMapping, len(mappings))
+	for i, mapping := range mappings {
+		if mapping.Host != 0 {
+			if pa.used[mapping.key()] {
+				return nil, fmt.Errorf("port %d is already in use", mapping.Host)
+			}
+			pa.Use(mapping)
+			out[i] = mapping
+			continue
+		}
+		for port := pa.min; port <= pa.max; port++ {
+			mapping.Host = port
+			if !pa.used[mapping.key()] {
+				break
+			}
+			mapping.Host = 0
+		}
+		if mapping.Host == 0 {
+			return nil, fmt.Errorf("there are no free ports left between %d and %d", pa.min, pa.max)
+		}
+		pa.Use(mapping)
+		out[i] = mapping
+	}
+	return out, nil
+}
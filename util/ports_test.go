@@ -0,0 +1,152 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParsePortMapping(t *testing.T) {
+	var test = []struct {
+		mapping  string
+		expected PortMapping
+		err      bool
+	}{
+		{mapping: "8545", expected: PortMapping{Container: 8545, Protocol: "tcp"}},
+		{mapping: "8545:8546", expected: PortMapping{Host: 8545, Container: 8546, Protocol: "tcp"}},
+		{mapping: "30303/udp", expected: PortMapping{Container: 30303, Protocol: "udp"}},
+		{mapping: "127.0.0.1:8545:8545/tcp", expected: PortMapping{HostIP: "127.0.0.1", Host: 8545, Container: 8545, Protocol: "tcp"}},
+		{mapping: "127.0.0.1::8545", expected: PortMapping{HostIP: "127.0.0.1", Container: 8545, Protocol: "tcp"}},
+		{mapping: "8545/sctp", err: true},
+		{mapping: "70000", err: true},
+		{mapping: "0", err: true},
+		{mapping: "a:8545", err: true},
+		{mapping: "host:8545:8545", err: true},
+		{mapping: "1:2:3:4", err: true},
+		{mapping: "8545; rm -rf /", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mapping, err := ParsePortMapping(tt.mapping)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected an error for \"%s\"", tt.mapping)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(mapping, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, mapping)
+			}
+		})
+	}
+}
+
+func TestPortMapping_String(t *testing.T) {
+	var test = []struct {
+		mapping  PortMapping
+		expected string
+	}{
+		{mapping: PortMapping{Host: 30000, Container: 8545, Protocol: "tcp"}, expected: "30000:8545/tcp"},
+		{mapping: PortMapping{HostIP: "127.0.0.1", Host: 30000, Container: 30303, Protocol: "udp"},
+			expected: "127.0.0.1:30000:30303/udp"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if tt.mapping.String() != tt.expected {
+				t.Errorf("expected \"%s\", got \"%s\"", tt.expected, tt.mapping.String())
+			}
+		})
+	}
+}
+
+func TestPortAllocator_Allocate(t *testing.T) {
+	var test = []struct {
+		used     []PortMapping
+		mappings []PortMapping
+		expected []int
+		err      bool
+	}{
+		{
+			mappings: []PortMapping{{Container: 8545, Protocol: "tcp"}, {Container: 30303, Protocol: "tcp"}},
+			expected: []int{30000, 30001},
+		},
+		{
+			used:     []PortMapping{{Host: 30000, Protocol: "tcp"}, {Host: 30002, Protocol: "tcp"}},
+			mappings: []PortMapping{{Container: 8545, Protocol: "tcp"}},
+			expected: []int{30001},
+		},
+		{
+			used:     []PortMapping{{Host: 30000, Protocol: "tcp"}},
+			mappings: []PortMapping{{Container: 30303, Protocol: "udp"}},
+			expected: []int{30000},
+		},
+		{
+			mappings: []PortMapping{{Host: 8545, Container: 8545, Protocol: "tcp"}, {Container: 30303, Protocol: "tcp"}},
+			expected: []int{8545, 30000},
+		},
+		{
+			used:     []PortMapping{{Host: 8545, Protocol: "tcp"}},
+			mappings: []PortMapping{{Host: 8545, Container: 8545, Protocol: "tcp"}},
+			err:      true,
+		},
+		{
+			mappings: []PortMapping{{Host: 8545, Container: 8545, Protocol: "tcp"}, {Host: 8545, Container: 8546, Protocol: "tcp"}},
+			err:      true,
+		},
+		{
+			used:     []PortMapping{{Host: 30000, Protocol: "tcp"}, {Host: 30001, Protocol: "tcp"}, {Host: 30002, Protocol: "tcp"}},
+			mappings: []PortMapping{{Container: 8545, Protocol: "tcp"}},
+			err:      true,
+		},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			allocator := NewPortAllocator(30000, 30002)
+			for _, mapping := range tt.used {
+				allocator.Use(mapping)
+			}
+			mappings, err := allocator.Allocate(tt.mappings)
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for j, mapping := range mappings {
+				if mapping.Host != tt.expected[j] {
+					t.Errorf("expected mapping %d to be given port %d, got %d", j, tt.expected[j], mapping.Host)
+				}
+				if mapping.Container != tt.mappings[j].Container {
+					t.Errorf("expected mapping %d to keep its container port", j)
+				}
+			}
+		})
+	}
+}
@@ -33,47 +33,47 @@ type Resources struct {
 	// by a node. Can be more than 1.0, meaning the node can use multiple cores at
 	// a time.
 	Cpus string `json:"cpus"`
-	// Memory supports values up to Terrabytes (tb). If the unit is omitted, then it
+	// Memory is a size such as 512mb or 2GB, see ParseSize. If the unit is omitted, then it
 	// is assumed to be bytes. This is not case sensitive.
 	Memory string `json:"memory"`
 	// Volumes to be used by each node.
 	Volumes []string `json:"volumes"`
-	// Ports to be opened for each node, each item associated with one node.
+	// Ports to be opened for each node, of the form [[host ip:]host port:]container port[/protocol].
+	// A port on the server is allocated for each port without one.
 	Ports []string `json:"ports"`
+	// GPUs are the gpus passed into the node, either "all", a number of gpus, or "device="
+	// followed by the comma separated indexes of the gpus. Omit it to pass no gpus.
+	GPUs string `json:"gpus"`
+	// Mounts are the named volumes and bind mounts of the node
+	Mounts []Mount `json:"mounts"`
 }
 
-func memconv(mem string) (int64, error) {
-
-	m := strings.ToLower(mem)
-
-	var multiplier int64 = 1
-
-	if strings.HasSuffix(m, "kb") || strings.HasSuffix(m, "k") {
-		multiplier = 1000
-	} else if strings.HasSuffix(m, "mb") || strings.HasSuffix(m, "m") {
-		multiplier = 1000000
-	} else if strings.HasSuffix(m, "gb") || strings.HasSuffix(m, "g") {
-		multiplier = 1000000000
-	} else if strings.HasSuffix(m, "tb") || strings.HasSuffix(m, "t") {
-		multiplier = 1000000000000
-	}
-
-	i, err := strconv.ParseInt(strings.Trim(m, "bgkmt"), 10, 64)
-	if err != nil {
-		return -1, err
-	}
-
-	return i * multiplier, nil
-}
-
-// GetMemory gets the memory value as an integer.
+// GetMemory gets the memory value in bytes, see ParseSize
 func (res Resources) GetMemory() (int64, error) {
-	return memconv(res.Memory)
+	return ParseSize(res.Memory)
 }
 
 // Validate ensures that the given resource object is valid, and
 // allowable.
 func (res Resources) Validate() error {
+	for _, mount := range res.Mounts {
+		err := mount.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	for _, port := range res.Ports {
+		_, err := ParsePortMapping(port)
+		if err != nil {
+			return err
+		}
+	}
+	if !res.NoGPUs() {
+		_, err := res.GetGPUDevices(-1)
+		if err != nil {
+			return err
+		}
+	}
 	if res.NoLimits() {
 		return nil
 	}
@@ -96,7 +96,7 @@ func (res Resources) Validate() error {
 			return err
 		}
 		if len(conf.MaxNodeMemory) != 0 {
-			m1, err := memconv(conf.MaxNodeMemory)
+			m1, err := ParseSize(conf.MaxNodeMemory)
 			if err != nil {
 				log.WithFields(log.Fields{"error": err,
 					"memLimit": conf.MaxNodeMemory}).Panic("error parsing memory limit. check config file.")
@@ -154,3 +154,51 @@ func (res Resources) NoCPULimits() bool {
 func (res Resources) NoMemoryLimits() bool {
 	return len(res.Memory) == 0
 }
+
+// NoGPUs checks if the resources object doesn't request any gpus
+func (res Resources) NoGPUs() bool {
+	return len(res.GPUs) == 0
+}
+
+// GetGPUDevices gets the indexes of the gpus requested, out of the given number of gpus available.
+// If available is negative, only the format of the request is checked.
+func (res Resources) GetGPUDevices(available int) ([]int, error) {
+	if res.GPUs == "all" {
+		if available == 0 {
+			return nil, fmt.Errorf("all gpus were requested, but there are none")
+		}
+		out := []int{}
+		for i := 0; i < available; i++ {
+			out = append(out, i)
+		}
+		return out, nil
+	}
+
+	if strings.HasPrefix(res.GPUs, "device=") {
+		out := []int{}
+		for _, device := range strings.Split(strings.TrimPrefix(res.GPUs, "device="), ",") {
+			index, err := strconv.Atoi(strings.TrimSpace(device))
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid gpu index \"%s\"", device)
+			}
+			if available >= 0 && index >= available {
+				return nil, fmt.Errorf("gpu %d was requested, but there are only %d", index, available)
+			}
+			out = append(out, index)
+		}
+		return out, nil
+	}
+
+	count, err := strconv.Atoi(res.GPUs)
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("invalid value for gpus \"%s\", expected all, a number, or device=<indexes>", res.GPUs)
+	}
+	if available >= 0 && count > available {
+		return nil, fmt.Errorf("%d gpus were requested, but there are only %d", count, available)
+	}
+	out := []int{}
+	for i := 0; i < count && available >= 0; i++ {
+		out = append(out, i)
+	}
+	return out, nil
+}
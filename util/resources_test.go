@@ -0,0 +1,73 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestResources_GetGPUDevices(t *testing.T) {
+	var test = []struct {
+		gpus      string
+		available int
+		expected  []int
+		err       bool
+	}{
+		{gpus: "all", available: 2, expected: []int{0, 1}},
+		{gpus: "all", available: 0, err: true},
+		{gpus: "1", available: 2, expected: []int{0}},
+		{gpus: "3", available: 2, err: true},
+		{gpus: "0", available: 2, err: true},
+		{gpus: "device=1,3", available: 4, expected: []int{1, 3}},
+		{gpus: "device=1,3", available: 2, err: true},
+		{gpus: "device=a", available: 2, err: true},
+		{gpus: "many", available: 2, err: true},
+		{gpus: "device=0,1", available: -1, expected: []int{0, 1}},
+		{gpus: "4", available: -1, expected: []int{}},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			devices, err := Resources{GPUs: tt.gpus}.GetGPUDevices(tt.available)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected an error for %s gpus out of %d", tt.gpus, tt.available)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(devices, tt.expected) {
+				t.Errorf("GetGPUDevices returned %v, expected %v", devices, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResources_ValidateGPUs(t *testing.T) {
+	if err := (Resources{GPUs: "all"}).Validate(); err != nil {
+		t.Error(err)
+	}
+	if err := (Resources{GPUs: "some"}).Validate(); err == nil {
+		t.Error("expected an error for an invalid value for gpus")
+	}
+}
@@ -0,0 +1,113 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DockerRuntime is docker, with a daemon running as root
+	DockerRuntime = "docker"
+	// RootlessDockerRuntime is docker in rootless mode, with a daemon running as the ssh user
+	RootlessDockerRuntime = "docker-rootless"
+	// PodmanRuntime is podman, run as root through sudo
+	PodmanRuntime = "podman"
+	// RootlessPodmanRuntime is podman, run as the ssh user
+	RootlessPodmanRuntime = "podman-rootless"
+)
+
+// Runtime describes the container runtime used to run the nodes on a server
+type Runtime struct {
+	// Name is the name of the runtime, as given in the configuration
+	Name string
+	// CLI is the command which invokes the docker compatible cli of the runtime
+	CLI string
+	// Rootless is whether or not the containers are run without root. The networks of the
+	// containers are then not visible to the host, so they cannot be altered with tc or iptables.
+	Rootless bool
+
+	bridgeOption string
+	// cdiGPUs is whether gpus are passed in as CDI devices, instead of with --gpus
+	cdiGPUs bool
+}
+
+var runtimes = map[string]Runtime{
+	DockerRuntime: {
+		Name:         DockerRuntime,
+		CLI:          "docker",
+		bridgeOption: `-o "com.docker.network.bridge.name=%s"`,
+	},
+	RootlessDockerRuntime: {
+		Name:         RootlessDockerRuntime,
+		CLI:          "DOCKER_HOST=${DOCKER_HOST:-unix:///run/user/$(id -u)/docker.sock} docker",
+		Rootless:     true,
+		bridgeOption: `-o "com.docker.network.bridge.name=%s"`,
+	},
+	PodmanRuntime: {
+		Name:         PodmanRuntime,
+		CLI:          "sudo -n podman",
+		bridgeOption: "--interface-name %s",
+		cdiGPUs:      true,
+	},
+	RootlessPodmanRuntime: {
+		Name:         RootlessPodmanRuntime,
+		CLI:          "podman",
+		Rootless:     true,
+		bridgeOption: "--interface-name %s",
+		cdiGPUs:      true,
+	},
+}
+
+// GetRuntime gets the container runtime with the given name. If the name is empty, the
+// containerRuntime from the configuration is given.
+func GetRuntime(name string) (Runtime, error) {
+	if len(name) == 0 {
+		name = conf.ContainerRuntime
+	}
+	rt, ok := runtimes[name]
+	if !ok {
+		return Runtime{}, fmt.Errorf("unknown container runtime \"%s\"", name)
+	}
+	return rt, nil
+}
+
+// BridgeNameOption gets the option of "network create" which sets the name of the
+// bridge of the network on the host
+func (rt Runtime) BridgeNameOption(bridge string) string {
+	return fmt.Sprintf(rt.bridgeOption, bridge)
+}
+
+// GPUOptions gets the options of "run" which pass the gpus with the given indexes into the container
+func (rt Runtime) GPUOptions(devices []int) string {
+	if rt.cdiGPUs {
+		out := []string{}
+		for _, device := range devices {
+			out = append(out, fmt.Sprintf("--device nvidia.com/gpu=%d", device))
+		}
+		return strings.Join(out, " ")
+	}
+	indexes := []string{}
+	for _, device := range devices {
+		indexes = append(indexes, strconv.Itoa(device))
+	}
+	return fmt.Sprintf(`--gpus '"device=%s"'`, strings.Join(indexes, ","))
+}
@@ -0,0 +1,86 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestGetRuntime(t *testing.T) {
+	var test = []struct {
+		name     string
+		cli      string
+		rootless bool
+		bridge   string
+		err      bool
+	}{
+		{name: DockerRuntime, cli: "docker", rootless: false, bridge: `-o "com.docker.network.bridge.name=wb_bridge1"`},
+		{name: RootlessDockerRuntime, cli: "DOCKER_HOST=${DOCKER_HOST:-unix:///run/user/$(id -u)/docker.sock} docker",
+			rootless: true, bridge: `-o "com.docker.network.bridge.name=wb_bridge1"`},
+		{name: PodmanRuntime, cli: "sudo -n podman", rootless: false, bridge: "--interface-name wb_bridge1"},
+		{name: RootlessPodmanRuntime, cli: "podman", rootless: true, bridge: "--interface-name wb_bridge1"},
+		{name: "lxc", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			rt, err := GetRuntime(tt.name)
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error for an unknown runtime")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rt.Name != tt.name || rt.CLI != tt.cli || rt.Rootless != tt.rootless {
+				t.Errorf("unexpected runtime %+v", rt)
+			}
+			if rt.BridgeNameOption("wb_bridge1") != tt.bridge {
+				t.Errorf("BridgeNameOption returned %s, expected %s", rt.BridgeNameOption("wb_bridge1"), tt.bridge)
+			}
+		})
+	}
+}
+
+func TestGetRuntime_Default(t *testing.T) {
+	conf.ContainerRuntime = PodmanRuntime
+	defer func() { conf.ContainerRuntime = DockerRuntime }()
+
+	rt, err := GetRuntime("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.Name != PodmanRuntime {
+		t.Errorf("expected the configured runtime, got %s", rt.Name)
+	}
+}
+
+func TestRuntime_GPUOptions(t *testing.T) {
+	docker, _ := GetRuntime(DockerRuntime)
+	if opts := docker.GPUOptions([]int{0, 2}); opts != `--gpus '"device=0,2"'` {
+		t.Errorf("unexpected gpu options for docker: %s", opts)
+	}
+	podman, _ := GetRuntime(PodmanRuntime)
+	if opts := podman.GPUOptions([]int{0, 2}); opts != "--device nvidia.com/gpu=0 --device nvidia.com/gpu=2" {
+		t.Errorf("unexpected gpu options for podman: %s", opts)
+	}
+}
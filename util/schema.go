@@ -0,0 +1,164 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// jsonSchema is the subset of JSON Schema which genesis validates documents against, being
+// type, enum, required, properties, items and minItems
+type jsonSchema struct {
+	Type       schemaTypes            `json:"type"`
+	Enum       []interface{}          `json:"enum"`
+	Required   []string               `json:"required"`
+	Properties map[string]*jsonSchema `json:"properties"`
+	Items      *jsonSchema            `json:"items"`
+	MinItems   *int                   `json:"minItems"`
+}
+
+// schemaTypes is the type of a schema, which may be given as either a single type or a list of them
+type schemaTypes []string
+
+// UnmarshalJSON allows the type to be given as a string
+func (st *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if json.Unmarshal(data, &single) == nil {
+		*st = []string{single}
+		return nil
+	}
+	var multiple []string
+	err := json.Unmarshal(data, &multiple)
+	*st = multiple
+	return err
+}
+
+// ValidateJSONSchema checks the json document doc against the given JSON Schema. Only the type, enum,
+// required, properties, items and minItems keywords are supported, any others are ignored.
+func ValidateJSONSchema(schema []byte, doc []byte) error {
+	var s jsonSchema
+	err := json.Unmarshal(schema, &s)
+	if err != nil {
+		return fmt.Errorf("invalid schema: %s", err.Error())
+	}
+	value, err := decodeJSON(doc)
+	if err != nil {
+		return fmt.Errorf("invalid json: %s", err.Error())
+	}
+	return s.validate("", value)
+}
+
+func toSchemaValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(data)
+}
+
+func (s *jsonSchema) validate(path string, value interface{}) error {
+	if s == nil {
+		return nil
+	}
+	name := path
+	if len(name) == 0 {
+		name = "the document"
+	}
+	if len(s.Type) > 0 {
+		actual := schemaTypeOf(value)
+		matches := false
+		for _, expected := range s.Type {
+			if expected == actual || (expected == "number" && actual == "integer") {
+				matches = true
+			}
+		}
+		if !matches {
+			return fmt.Errorf("%s is of type %s, expected %s", name, actual, strings.Join(s.Type, " or "))
+		}
+	}
+	if len(s.Enum) > 0 {
+		matches := false
+		for _, option := range s.Enum {
+			option, err := toSchemaValue(option)
+			if err == nil && jsonEqual(option, value) {
+				matches = true
+			}
+		}
+		if !matches {
+			return fmt.Errorf("%s is not one of the allowed values", name)
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for _, field := range s.Required {
+			if _, ok := val[field]; !ok {
+				return fmt.Errorf("%s is missing the required field \"%s\"", name, field)
+			}
+		}
+		fields := make([]string, 0, len(s.Properties))
+		for field := range s.Properties {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			child, ok := val[field]
+			if !ok {
+				continue
+			}
+			err := s.Properties[field].validate(joinPath(path, field), child)
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			return fmt.Errorf("%s has %d items, expected at least %d", name, len(val), *s.MinItems)
+		}
+		for i, item := range val {
+			err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func schemaTypeOf(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if !strings.ContainsAny(val.String(), ".eE") {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
@@ -0,0 +1,70 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["chain_id", "validators"],
+		"properties": {
+			"chain_id": {"type": "string"},
+			"height": {"type": "integer"},
+			"mode": {"enum": ["a", 1]},
+			"validators": {
+				"type": "array",
+				"minItems": 1,
+				"items": {"type": "object", "required": ["power"], "properties": {"power": {"type": ["string", "number"]}}}
+			}
+		}
+	}`
+	var test = []struct {
+		doc   string
+		valid bool
+	}{
+		{doc: `{"chain_id":"a","validators":[{"power":"10"}]}`, valid: true},
+		{doc: `{"chain_id":"a","validators":[{"power":10.5}],"height":1,"mode":1,"other":null}`, valid: true},
+		{doc: `{"chain_id":"a","validators":[{"power":10}],"mode":"a"}`, valid: true},
+		{doc: `{"validators":[{"power":"10"}]}`, valid: false},
+		{doc: `{"chain_id":1,"validators":[{"power":"10"}]}`, valid: false},
+		{doc: `{"chain_id":"a","validators":[]}`, valid: false},
+		{doc: `{"chain_id":"a","validators":[{"power":true}]}`, valid: false},
+		{doc: `{"chain_id":"a","validators":[{}]}`, valid: false},
+		{doc: `{"chain_id":"a","validators":[{"power":"10"}],"height":1.5}`, valid: false},
+		{doc: `{"chain_id":"a","validators":[{"power":"10"}],"mode":"b"}`, valid: false},
+		{doc: `[]`, valid: false},
+		{doc: `{"chain_id":"a"`, valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := ValidateJSONSchema([]byte(schema), []byte(tt.doc))
+			if tt.valid && err != nil {
+				t.Errorf("expected %s to be valid: %s", tt.doc, err.Error())
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected %s to be invalid", tt.doc)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// signals are the names of the standard signals of linux, without the SIG prefix
+var signals = map[string]bool{
+	"HUP": true, "INT": true, "QUIT": true, "ILL": true, "TRAP": true, "ABRT": true, "BUS": true, "FPE": true,
+	"KILL": true, "USR1": true, "SEGV": true, "USR2": true, "PIPE": true, "ALRM": true, "TERM": true, "CHLD": true,
+	"CONT": true, "STOP": true, "TSTP": true, "TTIN": true, "TTOU": true, "URG": true, "XCPU": true, "XFSZ": true,
+	"VTALRM": true, "PROF": true, "WINCH": true, "IO": true, "PWR": true, "SYS": true,
+}
+
+// maxSignal is the highest signal number on linux, including the real time signals
+const maxSignal = 64
+
+// ParseSignal parses the name or number of a signal, such as SIGUSR1, usr1 or 10, giving it in the
+// form it can be given to kill with, which is the name without the SIG prefix or the number
+func ParseSignal(signal string) (string, error) {
+	if num, err := strconv.Atoi(signal); err == nil {
+		if num < 1 || num > maxSignal {
+			return "", fmt.Errorf("invalid signal number %d, must be between 1 and %d", num, maxSignal)
+		}
+		return signal, nil
+	}
+	name := strings.TrimPrefix(strings.ToUpper(signal), "SIG")
+	if !signals[name] {
+		return "", fmt.Errorf("invalid signal \"%s\", see `man 7 signal` for help", signal)
+	}
+	return name, nil
+}
@@ -0,0 +1,55 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestParseSignal(t *testing.T) {
+	var test = []struct {
+		signal   string
+		expected string
+		valid    bool
+	}{
+		{signal: "SIGSTOP", expected: "STOP", valid: true},
+		{signal: "cont", expected: "CONT", valid: true},
+		{signal: "SigUsr1", expected: "USR1", valid: true},
+		{signal: "10", expected: "10", valid: true},
+		{signal: "64", expected: "64", valid: true},
+		{signal: "0", valid: false},
+		{signal: "65", valid: false},
+		{signal: "SIGFOO", valid: false},
+		{signal: "STOP; rm -rf /", valid: false},
+		{signal: "", valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			res, err := ParseSignal(tt.signal)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ParseSignal(%q) returned %v", tt.signal, err)
+			}
+			if res != tt.expected {
+				t.Errorf("ParseSignal(%q) gave %q, expected %q", tt.signal, res, tt.expected)
+			}
+		})
+	}
+}
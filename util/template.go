@@ -0,0 +1,68 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs are the functions available to resource templates, in addition to the builtin
+// functions of text/template
+var TemplateFuncs = template.FuncMap{
+	// json encodes the value as json
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	// join joins the strings with the separator
+	"join": func(elems []string, sep string) string {
+		return strings.Join(elems, sep)
+	},
+	// add adds the integers together
+	"add": func(a int, b ...int) int {
+		for _, n := range b {
+			a += n
+		}
+		return a
+	},
+	// quote quotes the string for the shell
+	"quote": ShellQuote,
+}
+
+// ParseTemplate parses the given resource template. A missing key is an error, rather than being
+// rendered as "<no value>".
+func ParseTemplate(name string, text string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// RenderTemplate parses the given resource template and executes it with the given data
+func RenderTemplate(name string, text string, data interface{}) ([]byte, error) {
+	tmpl, err := ParseTemplate(name, text)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	err = tmpl.Execute(buf, data)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
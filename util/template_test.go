@@ -0,0 +1,59 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package util
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	data := map[string]interface{}{
+		"node":  2,
+		"ips":   []string{"10.0.0.2", "10.0.0.3"},
+		"name":  "it's",
+		"peers": []map[string]string{{"id": "a"}},
+	}
+	var test = []struct {
+		text     string
+		expected string
+		valid    bool
+	}{
+		{text: "node={{.node}}", expected: "node=2", valid: true},
+		{text: "port={{add 26656 .node}}", expected: "port=26658", valid: true},
+		{text: "{{join .ips \",\"}}", expected: "10.0.0.2,10.0.0.3", valid: true},
+		{text: "{{json .peers}}", expected: `[{"id":"a"}]`, valid: true},
+		{text: "echo {{quote .name}}", expected: `echo 'it'\''s'`, valid: true},
+		{text: "{{.missing}}", valid: false},
+		{text: "{{.node", valid: false},
+		{text: "{{unknown .node}}", valid: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			out, err := RenderTemplate("test", tt.text, data)
+			if (err == nil) != tt.valid {
+				t.Fatalf("unexpected result of RenderTemplate: %v", err)
+			}
+			if tt.valid && string(out) != tt.expected {
+				t.Errorf("return value of RenderTemplate \"%s\" does not match expected value \"%s\"",
+					string(out), tt.expected)
+			}
+		})
+	}
+}
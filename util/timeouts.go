@@ -0,0 +1,83 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package util
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timeouts are the deadlines of a build, which override the stageTimeout and commandTimeout set in the
+// config. Each is a duration such as 30m, see ParseDuration, with a number without a unit being seconds,
+// and 0 meaning there is no limit.
+type Timeouts struct {
+	// Stage is how long each stage of the build may take
+	Stage string `json:"stage,omitempty"`
+	// Stages overrides Stage for the stages with the given names
+	Stages map[string]string `json:"stages,omitempty"`
+	// Command is how long each command run on the servers may take
+	Command string `json:"command,omitempty"`
+}
+
+// parseTimeout parses the given timeout, giving def if it is empty
+func parseTimeout(name string, timeout string, def time.Duration) (time.Duration, error) {
+	if len(timeout) == 0 {
+		return def, nil
+	}
+	out, err := ParseDuration(timeout, time.Second)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s timeout: %s", name, err.Error())
+	}
+	if out < 0 {
+		return 0, fmt.Errorf("invalid %s timeout \"%s\": must not be negative", name, timeout)
+	}
+	return out, nil
+}
+
+// StageTimeout gets how long the stage with the given name may take, or 0 if there is no limit
+func (t Timeouts) StageTimeout(stage string) (time.Duration, error) {
+	def, err := parseTimeout("stage", t.Stage, time.Duration(conf.StageTimeout)*time.Second)
+	if err != nil {
+		return 0, err
+	}
+	return parseTimeout(fmt.Sprintf("\"%s\" stage", stage), t.Stages[stage], def)
+}
+
+// CommandTimeout gets how long each command may take, or 0 if there is no limit
+func (t Timeouts) CommandTimeout() (time.Duration, error) {
+	return parseTimeout("command", t.Command, time.Duration(conf.CommandTimeout)*time.Second)
+}
+
+// Validate checks that all of the timeouts are valid durations
+func (t Timeouts) Validate() error {
+	_, err := t.CommandTimeout()
+	if err != nil {
+		return err
+	}
+	_, err = t.StageTimeout("")
+	if err != nil {
+		return err
+	}
+	for stage := range t.Stages {
+		_, err = t.StageTimeout(stage)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
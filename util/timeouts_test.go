@@ -0,0 +1,79 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package util
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTimeouts(t *testing.T) {
+	stageTimeout, commandTimeout := conf.StageTimeout, conf.CommandTimeout
+	defer func() { conf.StageTimeout, conf.CommandTimeout = stageTimeout, commandTimeout }()
+	conf.StageTimeout, conf.CommandTimeout = 3600, 1800
+
+	var test = []struct {
+		timeouts Timeouts
+		stage    string
+		expStage time.Duration
+		expCmd   time.Duration
+		err      bool
+	}{
+		{timeouts: Timeouts{}, stage: "Building", expStage: time.Hour, expCmd: 30 * time.Minute},
+		{timeouts: Timeouts{Stage: "10m", Command: "30"}, stage: "Building", expStage: 10 * time.Minute,
+			expCmd: 30 * time.Second},
+		{timeouts: Timeouts{Stage: "10m", Stages: map[string]string{"Building": "1h30m"}}, stage: "Building",
+			expStage: 90 * time.Minute, expCmd: 30 * time.Minute},
+		{timeouts: Timeouts{Stage: "10m", Stages: map[string]string{"Building": "1h30m"}}, stage: "Starting",
+			expStage: 10 * time.Minute, expCmd: 30 * time.Minute},
+		{timeouts: Timeouts{Stage: "0", Command: "0"}, stage: "Building", expStage: 0, expCmd: 0},
+		{timeouts: Timeouts{Stage: "soon"}, err: true},
+		{timeouts: Timeouts{Command: "-5s"}, err: true},
+		{timeouts: Timeouts{Stages: map[string]string{"Building": "forever"}}, err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.timeouts.Validate()
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			stage, err := tt.timeouts.StageTimeout(tt.stage)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stage != tt.expStage {
+				t.Errorf("expected a stage timeout of %s but got %s", tt.expStage, stage)
+			}
+			cmd, err := tt.timeouts.CommandTimeout()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd != tt.expCmd {
+				t.Errorf("expected a command timeout of %s but got %s", tt.expCmd, cmd)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	sizeRegex = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([kmgtp]?i?b?)$`)
+	daysRegex = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)d(.*)$`)
+)
+
+// sizeUnits are the multipliers of the units of sizes, both in powers of 1000, such as mb, and in powers
+// of 1024, such as mib
+var sizeUnits = map[string]float64{
+	"": 1, "b": 1,
+	"k": 1e3, "kb": 1e3, "ki": 1 << 10, "kib": 1 << 10,
+	"m": 1e6, "mb": 1e6, "mi": 1 << 20, "mib": 1 << 20,
+	"g": 1e9, "gb": 1e9, "gi": 1 << 30, "gib": 1 << 30,
+	"t": 1e12, "tb": 1e12, "ti": 1 << 40, "tib": 1 << 40,
+	"p": 1e15, "pb": 1e15, "pi": 1 << 50, "pib": 1 << 50,
+}
+
+// ParseSize parses a size in bytes, such as 512, 512mb, 2GB or 1.5GiB. The units are not case sensitive,
+// the decimal units are powers of 1000, and the binary units, such as mib, are powers of 1024.
+func ParseSize(size string) (int64, error) {
+	matches := sizeRegex.FindStringSubmatch(strings.ToLower(strings.TrimSpace(size)))
+	if matches == nil {
+		return 0, fmt.Errorf("\"%s\" is not a size such as 512mb or 2GB", size)
+	}
+	unit, ok := sizeUnits[matches[2]]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit \"%s\" in size \"%s\"", matches[2], size)
+	}
+	val, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("\"%s\" is not a size such as 512mb or 2GB", size)
+	}
+	bytes := val * unit
+	if bytes != math.Trunc(bytes) {
+		return 0, fmt.Errorf("\"%s\" is not a whole number of bytes", size)
+	}
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("\"%s\" is too large", size)
+	}
+	return int64(bytes), nil
+}
+
+// ParseDuration parses a duration such as 30s, 5m, 1h30m or 2d, days being 24 hours. A number without a unit,
+// such as 30, is a number of the given unit, so that the params which used to be given as plain numbers
+// keep their meaning.
+func ParseDuration(duration string, unit time.Duration) (time.Duration, error) {
+	trimmed := strings.TrimSpace(duration)
+	if val, err := strconv.ParseFloat(trimmed, 64); err == nil {
+		return time.Duration(val * float64(unit)), nil
+	}
+	var days time.Duration
+	if matches := daysRegex.FindStringSubmatch(trimmed); matches != nil {
+		val, _ := strconv.ParseFloat(matches[1], 64)
+		days = time.Duration(val * float64(24*time.Hour))
+		trimmed = matches[2]
+		if len(trimmed) == 0 {
+			return days, nil
+		}
+	}
+	out, err := time.ParseDuration(trimmed)
+	if err != nil {
+		return 0, fmt.Errorf("\"%s\" is not a duration such as 30s, 5m or 2d", duration)
+	}
+	return days + out, nil
+}
@@ -0,0 +1,95 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package util
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	var test = []struct {
+		size     string
+		expected int64
+		err      bool
+	}{
+		{size: "512", expected: 512},
+		{size: "512b", expected: 512},
+		{size: "2k", expected: 2000},
+		{size: "512mb", expected: 512000000},
+		{size: "2GB", expected: 2000000000},
+		{size: " 1 tb ", expected: 1000000000000},
+		{size: "1KiB", expected: 1024},
+		{size: "1.5gib", expected: 1610612736},
+		{size: "4Mi", expected: 4194304},
+		{size: "0.5b", err: true},
+		{size: "", err: true},
+		{size: "-1gb", err: true},
+		{size: "12 apples", err: true},
+		{size: "1ib", err: true},
+		{size: "10000000pb", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			size, err := ParseSize(tt.size)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if size != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, size)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	var test = []struct {
+		duration string
+		unit     time.Duration
+		expected time.Duration
+		err      bool
+	}{
+		{duration: "30s", unit: time.Second, expected: 30 * time.Second},
+		{duration: "5m", unit: time.Second, expected: 5 * time.Minute},
+		{duration: "1h30m", unit: time.Second, expected: 90 * time.Minute},
+		{duration: "2d", unit: time.Second, expected: 48 * time.Hour},
+		{duration: "1d12h", unit: time.Second, expected: 36 * time.Hour},
+		{duration: "0.5d", unit: time.Second, expected: 12 * time.Hour},
+		{duration: "30", unit: time.Second, expected: 30 * time.Second},
+		{duration: "250", unit: time.Microsecond, expected: 250 * time.Microsecond},
+		{duration: "1.5", unit: time.Millisecond, expected: 1500 * time.Microsecond},
+		{duration: "", unit: time.Second, err: true},
+		{duration: "soon", unit: time.Second, err: true},
+		{duration: "2dd", unit: time.Second, err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			duration, err := ParseDuration(tt.duration, tt.unit)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if duration != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, duration)
+			}
+		})
+	}
+}
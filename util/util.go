@@ -190,27 +190,6 @@ func CombineConfig(entries []string) string {
 	return out
 }
 
-/*
-   BashExec executes _cmd in bash then return the result
-*/
-/*func BashExec(_cmd string) (string, error) {
-	cmd := exec.Command("bash", "-c", _cmd)
-
-	var resultsRaw bytes.Buffer
-
-	cmd.Stdout = &resultsRaw
-	err := cmd.Start()
-	if err != nil {
-		return "", LogError(err)
-	}
-	err = cmd.Wait()
-	if err != nil {
-		return "", LogError(err)
-	}
-
-	return resultsRaw.String(), nil
-}*/
-
 // GetPath extracts the base path of the given path
 func GetPath(path string) string {
 	index := strings.LastIndex(path, "/")
@@ -220,48 +199,6 @@ func GetPath(path string) string {
 	return path[:index]
 }
 
-/******* JSON helper functions *******/
-
-// GetJSONInt64 checks and extracts a int64 from data[field].
-// Will return an error if data[field] does not exist or is of the wrong type.
-func GetJSONInt64(data map[string]interface{}, field string, out *int64) error {
-	rawValue, exists := data[field]
-	if exists && rawValue != nil {
-		switch rawValue.(type) {
-		case json.Number:
-			value, err := rawValue.(json.Number).Int64()
-			if err != nil {
-				return err
-			}
-			*out = value
-			return nil
-		default:
-			return fmt.Errorf("incorrect type for %s", field)
-		}
-	}
-	return nil
-}
-
-// GetJSONString checks and extracts a string from data[field].
-// Will return an error if data[field] does not exist or is of the wrong type.
-func GetJSONString(data map[string]interface{}, field string, out *string) error {
-	rawValue, exists := data[field]
-	if exists && rawValue != nil {
-		switch rawValue.(type) {
-		case string:
-			value, valid := rawValue.(string)
-			if !valid {
-				return fmt.Errorf("invalid string")
-			}
-			*out = value
-			return nil
-		default:
-			return fmt.Errorf("incorrect type for %s", field)
-		}
-	}
-	return nil
-}
-
 // MergeStringMaps merges two maps of string to interface together and returns it
 // If there are conflicting keys, the value in m2 will be chosen.
 func MergeStringMaps(m1 map[string]interface{}, m2 map[string]interface{}) map[string]interface{} {
@@ -83,3 +83,23 @@ func ValidateCommandLine(str string) error {
 	}
 	return nil
 }
+
+// ValidateEnvName checks that the given name of an environment variable is made of letters, digits and
+// underscores, without starting with a digit
+func ValidateEnvName(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf("the name of an environment variable cannot be empty")
+	}
+	for i, c := range name {
+		if c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return fmt.Errorf("invalid environment variable name \"%s\"", name)
+	}
+	return nil
+}
+
+// ShellQuote quotes str as a single argument for the shell
+func ShellQuote(str string) string {
+	return "'" + strings.Replace(str, "'", `'\''`, -1) + "'"
+}
@@ -106,3 +106,42 @@ func TestValidateCommandLine(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateEnvName(t *testing.T) {
+	//test --> invalid?
+	tests := map[string]bool{
+		"PATH":         false,
+		"_private":     false,
+		"GETH_VERBOSE": false,
+		"NODE2":        false,
+		"":             true,
+		"2NODE":        true,
+		"A-B":          true,
+		"A=B":          true,
+		"A B":          true,
+	}
+	for test, expected := range tests {
+		err := ValidateEnvName(test)
+		if (err != nil) != expected {
+			if expected {
+				t.Errorf("ValidateEnvName(\"%s\") passed when should have failed", test)
+			} else {
+				t.Errorf("ValidateEnvName(\"%s\") failed when should have passed", test)
+			}
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"":                   "''",
+		"geth attach":        "'geth attach'",
+		"echo 'hi' > /tmp/a": `'echo '\''hi'\'' > /tmp/a'`,
+		"$(rm -rf /)":        "'$(rm -rf /)'",
+	}
+	for test, expected := range tests {
+		if ShellQuote(test) != expected {
+			t.Errorf("ShellQuote(\"%s\") returned %s, expected %s", test, ShellQuote(test), expected)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/db"
+	"github.com/whiteblock/genesis/util"
+	"net/url"
+	"sync"
+)
+
+const metaKey = "webhooks"
+
+var (
+	hooks  []Webhook
+	loaded = false
+	mux    = sync.Mutex{}
+)
+
+// Webhook represents a URL which is registered to receive events
+type Webhook struct {
+	// ID is the unique identifier of the webhook
+	ID string `json:"id"`
+
+	// URL is where the events will be sent
+	URL string `json:"url"`
+
+	// Secret, if given, is used to sign the body of each request
+	Secret string `json:"secret,omitempty"`
+
+	// TestNetID restricts the webhook to the events of a single testnet. If empty,
+	// the webhook will receive the events of every testnet.
+	TestNetID string `json:"testnetId,omitempty"`
+
+	// Events restricts the webhook to the given types of events. If empty, the webhook
+	// will receive every event.
+	Events []string `json:"events,omitempty"`
+}
+
+// Validate ensures that the webhook is valid
+func (hook Webhook) Validate() error {
+	uri, err := url.Parse(hook.URL)
+	if err != nil {
+		return err
+	}
+	if uri.Scheme != "http" && uri.Scheme != "https" {
+		return fmt.Errorf("webhook url must be http or https")
+	}
+	for _, event := range hook.Events {
+		switch event {
+		case BuildStarted, StageChanged, BuildCompleted, BuildFailed, NodeCrashed, TestNetExpiring, TestNetExpired,
+			ConsensusAlert, SoakDigest:
+		default:
+			return fmt.Errorf("unknown event type \"%s\"", event)
+		}
+	}
+	return nil
+}
+
+// Redacted gets a copy of the webhook with its secret replaced by util.RedactedValue, as the
+// secret is only ever given when the webhook is registered
+func (hook Webhook) Redacted() Webhook {
+	if len(hook.Secret) > 0 {
+		hook.Secret = util.RedactedValue
+	}
+	return hook
+}
+
+// Matches checks if the webhook should receive the given event
+func (hook Webhook) Matches(event Event) bool {
+	if len(hook.TestNetID) > 0 && hook.TestNetID != event.TestNetID {
+		return false
+	}
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, eventType := range hook.Events {
+		if eventType == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAll gets all of the registered webhooks
+func GetAll() ([]Webhook, error) {
+	mux.Lock()
+	defer mux.Unlock()
+	return getAll()
+}
+
+func getAll() ([]Webhook, error) {
+	if !loaded {
+		hooks = []Webhook{}
+		db.GetMetaP(metaKey, &hooks) //An error here just means that nothing has been registered yet
+		loaded = true
+	}
+	out := make([]Webhook, len(hooks))
+	copy(out, hooks)
+	return out, nil
+}
+
+func store(newHooks []Webhook) error {
+	err := db.SetMeta(metaKey, newHooks)
+	if err != nil {
+		return err
+	}
+	hooks = newHooks
+	return nil
+}
+
+// Get gets a webhook by its id
+func Get(id string) (Webhook, error) {
+	all, err := GetAll()
+	if err != nil {
+		return Webhook{}, err
+	}
+	for _, hook := range all {
+		if hook.ID == id {
+			return hook, nil
+		}
+	}
+	return Webhook{}, fmt.Errorf("webhook \"%s\" not found", id)
+}
+
+// Register validates and stores the given webhook, returning its id
+func Register(hook Webhook) (string, error) {
+	err := hook.Validate()
+	if err != nil {
+		return "", err
+	}
+	hook.ID, err = util.GetUUIDString()
+	if err != nil {
+		return "", util.LogError(err)
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	current, err := getAll()
+	if err != nil {
+		return "", err
+	}
+	return hook.ID, store(append(current, hook))
+}
+
+// Remove removes the webhook with the given id
+func Remove(id string) error {
+	mux.Lock()
+	defer mux.Unlock()
+	current, err := getAll()
+	if err != nil {
+		return err
+	}
+	for i, hook := range current {
+		if hook.ID == id {
+			return store(append(current[:i], current[i+1:]...))
+		}
+	}
+	return fmt.Errorf("webhook \"%s\" not found", id)
+}
+
+// RemoveByTestNet removes all of the webhooks which are specific to the given testnet
+func RemoveByTestNet(testnetID string) error {
+	mux.Lock()
+	defer mux.Unlock()
+	current, err := getAll()
+	if err != nil {
+		return err
+	}
+	out := []Webhook{}
+	for _, hook := range current {
+		if hook.TestNetID != testnetID {
+			out = append(out, hook)
+		}
+	}
+	return store(out)
+}
@@ -0,0 +1,157 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package webhook handles the delivery of build events to user registered URLs, allowing
+// external systems such as CI pipelines to react to what is happening in genesis.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/whiteblock/genesis/util"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	// BuildStarted is sent when a build, or the addition of nodes to a testnet, begins
+	BuildStarted = "build.started"
+
+	// StageChanged is sent when the stage of a build changes
+	StageChanged = "build.stage"
+
+	// BuildCompleted is sent when a build finishes without error
+	BuildCompleted = "build.completed"
+
+	// BuildFailed is sent when a build finishes with an error
+	BuildFailed = "build.failed"
+
+	// NodeCrashed is sent when a node which was previously up is found to be down
+	NodeCrashed = "node.crashed"
+
+	// TestNetExpiring is sent when a testnet will soon be torn down due to its TTL expiring
+	TestNetExpiring = "testnet.expiring"
+
+	// TestNetExpired is sent when a testnet has been torn down due to its TTL expiring
+	TestNetExpired = "testnet.expired"
+	// ConsensusAlert is sent when one of the alert rules on the consensus of a testnet fires
+	ConsensusAlert = "consensus.alert"
+	// SoakDigest is sent with the digest of a soak test of a testnet, once per digest interval
+	SoakDigest = "soak.digest"
+)
+
+// SignatureHeader is the header containing the hex encoded HMAC-SHA256 of the request body,
+// present only if the webhook has a secret
+const SignatureHeader = "X-Genesis-Signature"
+
+// EventHeader is the header containing the type of the event being delivered
+const EventHeader = "X-Genesis-Event"
+
+var conf = util.GetConfig()
+
+// Event represents an event which is delivered to the webhooks
+type Event struct {
+	// Type is the type of the event
+	Type string `json:"type"`
+
+	// TestNetID is the id of the testnet which the event concerns
+	TestNetID string `json:"testnetId"`
+
+	// Time is the unix timestamp of when the event occurred
+	Time int64 `json:"time"`
+
+	// Data contains the event specific information
+	Data interface{} `json:"data,omitempty"`
+}
+
+// Emit sends an event to all of the webhooks which are interested in it. The delivery happens
+// asynchronously, so this will not block the caller.
+func Emit(eventType string, testnetID string, data interface{}) {
+	all, err := GetAll()
+	if err != nil {
+		return
+	}
+	event := Event{Type: eventType, TestNetID: testnetID, Time: time.Now().Unix(), Data: data}
+	for _, hook := range all {
+		if !hook.Matches(event) {
+			continue
+		}
+		go func(hook Webhook) {
+			err := deliver(hook, event)
+			if err != nil {
+				log.WithFields(log.Fields{"webhook": hook.ID, "url": hook.URL, "event": event.Type,
+					"build": testnetID, "error": err}).Error("failed to deliver the event")
+			}
+		}(hook)
+	}
+}
+
+// Sign creates the signature for the given payload using the given secret
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func deliver(hook Webhook, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return util.LogError(err)
+	}
+	client := &http.Client{Timeout: time.Duration(conf.WebhookTimeout) * time.Second}
+
+	for i := uint(0); i <= conf.WebhookRetries; i++ {
+		if i > 0 {
+			time.Sleep(time.Duration(i) * time.Second)
+		}
+		err = post(client, hook, event.Type, payload)
+		if err == nil {
+			return nil
+		}
+		log.WithFields(log.Fields{"webhook": hook.ID, "attempt": i + 1, "error": err}).Debug("webhook delivery failed")
+	}
+	return err
+}
+
+func post(client *http.Client, hook Webhook, eventType string, payload []byte) error {
+	req, err := http.NewRequest("POST", hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	if len(hook.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, payload))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package webhook
+
+import (
+	"github.com/whiteblock/genesis/util"
+	"strconv"
+	"testing"
+)
+
+func TestWebhook_Matches(t *testing.T) {
+	var test = []struct {
+		hook     Webhook
+		event    Event
+		expected bool
+	}{
+		{hook: Webhook{}, event: Event{Type: BuildStarted, TestNetID: "a"}, expected: true},
+		{hook: Webhook{TestNetID: "a"}, event: Event{Type: BuildStarted, TestNetID: "a"}, expected: true},
+		{hook: Webhook{TestNetID: "b"}, event: Event{Type: BuildStarted, TestNetID: "a"}, expected: false},
+		{hook: Webhook{Events: []string{BuildFailed, NodeCrashed}}, event: Event{Type: NodeCrashed}, expected: true},
+		{hook: Webhook{Events: []string{BuildFailed}}, event: Event{Type: BuildCompleted}, expected: false},
+		{hook: Webhook{TestNetID: "a", Events: []string{BuildFailed}}, event: Event{Type: BuildFailed, TestNetID: "b"},
+			expected: false},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if tt.hook.Matches(tt.event) != tt.expected {
+				t.Errorf("return value of Matches does not match expected value")
+			}
+		})
+	}
+}
+
+func TestWebhook_Validate(t *testing.T) {
+	var test = []struct {
+		hook Webhook
+		err  bool
+	}{
+		{hook: Webhook{URL: "https://example.com/hook"}, err: false},
+		{hook: Webhook{URL: "http://127.0.0.1:8080", Events: []string{StageChanged}}, err: false},
+		{hook: Webhook{URL: "http://127.0.0.1:8080", Events: []string{ConsensusAlert}}, err: false},
+		{hook: Webhook{URL: "ftp://example.com"}, err: true},
+		{hook: Webhook{URL: "https://example.com", Events: []string{"build.exploded"}}, err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := tt.hook.Validate()
+			if (err != nil) != tt.err {
+				t.Errorf("unexpected result from Validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestWebhook_Redacted(t *testing.T) {
+	hook := Webhook{ID: "1", URL: "https://example.com", Secret: "hunter2"}
+	if hook.Redacted().Secret != util.RedactedValue {
+		t.Errorf("the secret was not redacted")
+	}
+	if hook.Secret != "hunter2" {
+		t.Errorf("redacting the webhook changed the original")
+	}
+	if (Webhook{ID: "2"}).Redacted().Secret != "" {
+		t.Errorf("an empty secret should be left empty")
+	}
+}
+
+func TestSign(t *testing.T) {
+	expected := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if Sign("key", []byte("The quick brown fox jumps over the lazy dog")) != expected {
+		t.Errorf("return value of Sign does not match expected value")
+	}
+}
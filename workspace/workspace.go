@@ -0,0 +1,209 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package workspace manages the working directories of the builds on the machine genesis runs on, where the
+// files of a build are written before they are sent to the servers. Each build has its own directory, whose
+// files are tracked and limited to workspaceQuota, and which is removed once the build is done.
+package workspace
+
+import (
+	"fmt"
+	"github.com/whiteblock/genesis/util"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var conf = util.GetConfig()
+
+// Base gets the directory under which the workspaces of the builds are created
+func Base() string {
+	if len(conf.WorkspaceDir) == 0 {
+		return "/tmp"
+	}
+	return conf.WorkspaceDir
+}
+
+// Quota gets the most bytes the files of a build may take up, or 0 if there is no limit
+func Quota() (int64, error) {
+	quota := strings.TrimSpace(conf.WorkspaceQuota)
+	if len(quota) == 0 || quota == "0" {
+		return 0, nil
+	}
+	out, err := util.ParseSize(quota)
+	if err != nil {
+		return 0, fmt.Errorf("invalid workspaceQuota: %s", err.Error())
+	}
+	return out, nil
+}
+
+// Workspace is the working directory of a build
+type Workspace struct {
+	dir   string
+	quota int64
+
+	mux   sync.Mutex
+	files map[string]int64
+	used  int64
+}
+
+// New creates the workspace of the given build, removing anything left in it from a previous run
+func New(buildID string) (*Workspace, error) {
+	if len(buildID) == 0 || strings.ContainsAny(buildID, `/\`) || buildID == "." || buildID == ".." {
+		return nil, fmt.Errorf("invalid build id \"%s\"", buildID)
+	}
+	quota, err := Quota()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(Base(), buildID)
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, util.LogError(err)
+	}
+	return &Workspace{dir: dir, quota: quota, files: map[string]int64{}}, nil
+}
+
+// Dir gets the directory of the workspace
+func (ws *Workspace) Dir() string {
+	return ws.dir
+}
+
+// Path gets where the file with the given name is. Names are relative to the workspace, unless they are
+// absolute or start with ./, in which case they are given as they are.
+func (ws *Workspace) Path(name string) string {
+	if strings.HasPrefix(name, "./") || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(ws.dir, name)
+}
+
+// Reserve counts the file with the given name, of the given size, against the quota of the workspace, replacing
+// the size it had if it is already tracked. It fails if the file would put the workspace over its quota.
+func (ws *Workspace) Reserve(name string, size int64) error {
+	name = filepath.Clean(name)
+	ws.mux.Lock()
+	defer ws.mux.Unlock()
+	used := ws.used - ws.files[name] + size
+	if ws.quota > 0 && used > ws.quota {
+		return fmt.Errorf("writing %s would put the workspace %s over its quota of %d bytes, %d are in use",
+			name, ws.dir, ws.quota, ws.used)
+	}
+	ws.files[name] = size
+	ws.used = used
+	return nil
+}
+
+// release stops tracking the file with the given name
+func (ws *Workspace) release(name string) {
+	name = filepath.Clean(name)
+	ws.mux.Lock()
+	defer ws.mux.Unlock()
+	ws.used -= ws.files[name]
+	delete(ws.files, name)
+}
+
+// Write writes data to the file with the given name in the workspace, replacing it if it exists
+func (ws *Workspace) Write(name string, data []byte, mode os.FileMode) error {
+	err := ws.Reserve(name, int64(len(data)))
+	if err != nil {
+		return err
+	}
+	path := ws.Path(name)
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = ioutil.WriteFile(path, data, mode)
+	}
+	if err != nil {
+		ws.release(name)
+		return util.LogError(err)
+	}
+	return nil
+}
+
+// Track counts the file or directory with the given name, created in the workspace by other means, against
+// the quota of the workspace. The file is removed if it puts the workspace over its quota.
+func (ws *Workspace) Track(name string) error {
+	var size int64
+	err := filepath.Walk(ws.Path(name), func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return util.LogError(err)
+	}
+	err = ws.Reserve(name, size)
+	if err != nil {
+		os.RemoveAll(ws.Path(name))
+	}
+	return err
+}
+
+// RemoveFile removes the file or directory with the given name from the workspace
+func (ws *Workspace) RemoveFile(name string) error {
+	ws.release(name)
+	err := os.RemoveAll(ws.Path(name))
+	if err != nil {
+		return util.LogError(err)
+	}
+	return nil
+}
+
+// Files gets the names of the files which have been created in the workspace, in order
+func (ws *Workspace) Files() []string {
+	ws.mux.Lock()
+	defer ws.mux.Unlock()
+	out := make([]string, 0, len(ws.files))
+	for name := range ws.files {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Used gets the number of bytes taken up by the files in the workspace
+func (ws *Workspace) Used() int64 {
+	ws.mux.Lock()
+	defer ws.mux.Unlock()
+	return ws.used
+}
+
+// Remove removes the workspace along with all of its files
+func (ws *Workspace) Remove() error {
+	ws.mux.Lock()
+	ws.files = map[string]int64{}
+	ws.used = 0
+	ws.mux.Unlock()
+	err := os.RemoveAll(ws.dir)
+	if err != nil {
+		return util.LogError(err)
+	}
+	return nil
+}
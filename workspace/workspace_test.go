@@ -0,0 +1,283 @@
+/*
+	Copyright 2019 whiteblock Inc.
+	This file is a part of the genesis.
+
+	Genesis is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	Genesis is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+package workspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func setup(t *testing.T, quota string) (string, func()) {
+	base, err := ioutil.TempDir("", "workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDir, oldQuota := conf.WorkspaceDir, conf.WorkspaceQuota
+	conf.WorkspaceDir, conf.WorkspaceQuota = base, quota
+	return base, func() {
+		conf.WorkspaceDir, conf.WorkspaceQuota = oldDir, oldQuota
+		os.RemoveAll(base)
+	}
+}
+
+func TestNew(t *testing.T) {
+	base, cleanup := setup(t, "0")
+	defer cleanup()
+
+	var test = []struct {
+		buildID string
+		err     bool
+	}{
+		{buildID: "c6c3e7de-2b6d-4b0a-9d62-2ce13e4b4a0e", err: false},
+		{buildID: "", err: true},
+		{buildID: "..", err: true},
+		{buildID: "../etc", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ws, err := New(tt.buildID)
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ws.Dir() != filepath.Join(base, tt.buildID) {
+				t.Errorf("unexpected dir %s", ws.Dir())
+			}
+			if info, err := os.Stat(ws.Dir()); err != nil || !info.IsDir() {
+				t.Errorf("workspace dir was not created: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewClearsLeftovers(t *testing.T) {
+	base, cleanup := setup(t, "0")
+	defer cleanup()
+
+	err := os.MkdirAll(filepath.Join(base, "build"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(base, "build", "stale"), []byte("stale"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws, err := New("build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ws.Path("stale")); !os.IsNotExist(err) {
+		t.Error("files from a previous run were kept")
+	}
+}
+
+func TestPath(t *testing.T) {
+	base, cleanup := setup(t, "0")
+	defer cleanup()
+
+	ws, err := New("build")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var test = []struct {
+		name     string
+		expected string
+	}{
+		{name: "genesis.json", expected: filepath.Join(base, "build", "genesis.json")},
+		{name: "keys/node0", expected: filepath.Join(base, "build", "keys", "node0")},
+		{name: "./Dockerfile", expected: "./Dockerfile"},
+		{name: "/etc/hosts", expected: "/etc/hosts"},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if ws.Path(tt.name) != tt.expected {
+				t.Errorf("expected %s but got %s", tt.expected, ws.Path(tt.name))
+			}
+		})
+	}
+}
+
+func TestQuota(t *testing.T) {
+	var test = []struct {
+		quota    string
+		expected int64
+		err      bool
+	}{
+		{quota: "", expected: 0},
+		{quota: "0", expected: 0},
+		{quota: "1KB", expected: 1000},
+		{quota: "bad", err: true},
+	}
+
+	for i, tt := range test {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, cleanup := setup(t, tt.quota)
+			defer cleanup()
+			quota, err := Quota()
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quota != tt.expected {
+				t.Errorf("expected %d but got %d", tt.expected, quota)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	_, cleanup := setup(t, "10")
+	defer cleanup()
+
+	ws, err := New("build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ws.Write("a", []byte("12345"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ws.Write("b/c", []byte("1234"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Used() != 9 {
+		t.Errorf("expected 9 bytes to be used but got %d", ws.Used())
+	}
+
+	err = ws.Write("d", []byte("12"), 0600)
+	if err == nil {
+		t.Error("expected the quota to be enforced")
+	}
+	if _, err := os.Stat(ws.Path("d")); !os.IsNotExist(err) {
+		t.Error("a file over the quota was written")
+	}
+
+	err = ws.Write("a", []byte("123456"), 0600)
+	if err != nil {
+		t.Errorf("rewriting a file should only count its new size: %v", err)
+	}
+	if ws.Used() != 10 {
+		t.Errorf("expected 10 bytes to be used but got %d", ws.Used())
+	}
+	if !reflect.DeepEqual(ws.Files(), []string{"a", "b/c"}) {
+		t.Errorf("unexpected files %v", ws.Files())
+	}
+
+	err = ws.RemoveFile("b/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Used() != 6 {
+		t.Errorf("expected 6 bytes to be used but got %d", ws.Used())
+	}
+	if _, err := os.Stat(ws.Path("b/c")); !os.IsNotExist(err) {
+		t.Error("the file was not removed")
+	}
+}
+
+func TestTrack(t *testing.T) {
+	_, cleanup := setup(t, "8")
+	defer cleanup()
+
+	ws, err := New("build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{"dir/a": "123", "dir/sub/b": "45", "big/c": "123456789"} {
+		err = os.MkdirAll(filepath.Dir(ws.Path(name)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(ws.Path(name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = ws.Track("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Used() != 5 {
+		t.Errorf("expected 5 bytes to be used but got %d", ws.Used())
+	}
+
+	err = ws.Track("big")
+	if err == nil {
+		t.Error("expected the quota to be enforced")
+	}
+	if _, err := os.Stat(ws.Path("big")); !os.IsNotExist(err) {
+		t.Error("a directory over the quota was kept")
+	}
+	if ws.Used() != 5 {
+		t.Errorf("expected 5 bytes to be used but got %d", ws.Used())
+	}
+}
+
+func TestRemove(t *testing.T) {
+	_, cleanup := setup(t, "0")
+	defer cleanup()
+
+	first, err := New("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := New("second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ws := range []*Workspace{first, second} {
+		err = ws.Write("genesis.json", []byte("{}"), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = first.Remove()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(first.Dir()); !os.IsNotExist(err) {
+		t.Error("the workspace was not removed")
+	}
+	if len(first.Files()) != 0 || first.Used() != 0 {
+		t.Error("the removed workspace still tracks files")
+	}
+	data, err := ioutil.ReadFile(second.Path("genesis.json"))
+	if err != nil || string(data) != "{}" {
+		t.Errorf("removing a workspace affected another: %v", err)
+	}
+}